@@ -93,9 +93,21 @@ func main() {
 		}
 	}()
 
+	// 启动审计日志导出和归档清理
+	services.Audit.Start()
+
 	// 启动节点异常监控服务
 	services.Anomaly.StartMonitoring()
 
+	// 启动节点资源指标历史采样服务
+	services.NodeMetrics.Start()
+
+	// 启动节点事件历史清理
+	services.NodeEvents.Start()
+
+	// 启动飞书定时容量报告
+	services.Capacity.Start()
+
 	// 启动 Ansible 定时任务调度服务
 	if err := services.Ansible.GetScheduleService().Start(); err != nil {
 		logger.Error("Failed to start Ansible schedule service: " + err.Error())
@@ -109,7 +121,7 @@ func main() {
 			AllowOrigins:     []string{"http://localhost:3000", "http://localhost:8080"},
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 			AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-			ExposeHeaders:    []string{"Content-Length"},
+			ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "X-Export-Total", "X-Export-Truncated"},
 			AllowCredentials: true,
 		}))
 	}
@@ -183,6 +195,8 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 	auth := api.Group("/auth")
 	{
 		auth.POST("/login", handlers.Auth.Login)
+		auth.POST("/login/mfa", handlers.Auth.CompleteMFALogin)
+		auth.POST("/login/mfa/setup", handlers.Auth.SetupMFAForLogin)
 		auth.POST("/logout", handlers.Auth.Logout)
 		auth.POST("/refresh", handlers.Auth.RefreshToken)
 		auth.GET("/user", handlers.Auth.AuthMiddleware(), handlers.Auth.GetUser)
@@ -191,6 +205,14 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		auth.GET("/profile/stats", handlers.Auth.AuthMiddleware(), handlers.Auth.GetProfileStats)
 		auth.POST("/test-ldap", handlers.Auth.AuthMiddleware(), handlers.Auth.TestLDAPConnection)
 		auth.POST("/diagnose-ldap", handlers.Auth.AuthMiddleware(), handlers.Auth.DiagnoseLDAP)
+		auth.POST("/logout-all", handlers.Auth.AuthMiddleware(), handlers.Auth.LogoutAll)
+		auth.GET("/sessions", handlers.Auth.AuthMiddleware(), handlers.Auth.ListMySessions)
+		auth.DELETE("/sessions/:session_id", handlers.Auth.AuthMiddleware(), handlers.Auth.RevokeMySession)
+		auth.GET("/mfa", handlers.Auth.AuthMiddleware(), handlers.Auth.GetMFAStatus)
+		auth.POST("/mfa/setup", handlers.Auth.AuthMiddleware(), handlers.Auth.SetupMFA)
+		auth.POST("/mfa/enable", handlers.Auth.AuthMiddleware(), handlers.Auth.EnableMFA)
+		auth.POST("/mfa/disable", handlers.Auth.AuthMiddleware(), handlers.Auth.DisableMFA)
+		auth.POST("/mfa/recovery-codes", handlers.Auth.AuthMiddleware(), handlers.Auth.RegenerateRecoveryCodes)
 	}
 
 	protected := api.Group("/")
@@ -205,6 +227,23 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 	// WebSocket 终端 (Admin only) - 使用认证中间件，支持从query参数读取token
 	api.GET("/terminal/ws", handlers.Auth.AuthMiddleware(), handlers.Terminal.HandleWebSocket)
 
+	// 节点文件传输 (Admin only) - 通过 SFTP，进度推送到 terminal_id 对应的终端 WebSocket
+	terminalFiles := protected.Group("/terminal/files")
+	{
+		terminalFiles.GET("", handlers.Terminal.ListFiles)
+		terminalFiles.GET("/config", handlers.Terminal.GetFileTransferConfig)
+		terminalFiles.GET("/download", handlers.Terminal.DownloadFile)
+		terminalFiles.POST("/upload", handlers.Terminal.UploadFile)
+	}
+
+	// 批量任务查询、取消与恢复
+	progressTasks := protected.Group("/progress/tasks")
+	{
+		progressTasks.GET("/:task_id", handlers.Progress.GetTask)
+		progressTasks.POST("/:task_id/cancel", handlers.Progress.CancelTask)
+		progressTasks.POST("/:task_id/resume", handlers.Progress.ResumeTask)
+	}
+
 	users := protected.Group("/users")
 	{
 		users.GET("", handlers.User.List)
@@ -214,6 +253,27 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		users.DELETE("/:id", handlers.User.Delete)
 		users.PUT("/:id/password", handlers.User.UpdatePassword)
 		users.POST("/:id/reset-password", handlers.User.ResetPassword)
+		users.DELETE("/:id/sessions", handlers.Auth.RevokeUserSessions)
+		users.DELETE("/:id/mfa", handlers.Auth.ResetUserMFA)
+		users.POST("/:id/unlock", handlers.Auth.UnlockUser)
+	}
+
+	// 服务账号 (Admin only)，列表使用 GET /users?service_account=true
+	protected.POST("/service-accounts", handlers.User.CreateServiceAccount)
+
+	// 访问令牌
+	apiTokens := protected.Group("/api-tokens")
+	{
+		apiTokens.GET("", handlers.Auth.ListAPITokens)
+		apiTokens.POST("", handlers.Auth.CreateAPIToken)
+		apiTokens.DELETE("/:id", handlers.Auth.RevokeAPIToken)
+	}
+
+	// 会话管理 (Admin only)
+	sessions := protected.Group("/sessions")
+	{
+		sessions.GET("", handlers.Auth.ListSessions)
+		sessions.DELETE("/:session_id", handlers.Auth.RevokeSession)
 	}
 
 	clusters := protected.Group("/clusters")
@@ -232,6 +292,10 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		nodes.GET("", handlers.Node.List)
 		nodes.GET("/:cluster_id/:node_name", handlers.Node.Get)
 		nodes.GET("/:cluster_id/stats", handlers.Node.GetSummary)
+		nodes.GET("/:cluster_id/:node_name/pods", handlers.Node.ListPods)
+		nodes.GET("/:cluster_id/:node_name/timeline", handlers.Timeline.GetNodeTimeline)
+		nodes.GET("/:cluster_id/:node_name/events", handlers.NodeEvent.List)
+		nodes.GET("/:cluster_id/:node_name/changes", handlers.NodeChange.ListNodeChanges)
 		// SSH 配置 (使用 ssh-config 前缀避免与 :cluster_id 通配符冲突)
 		nodes.GET("/ssh-config/:node_name", handlers.Terminal.GetSettings)
 		nodes.PUT("/ssh-config/:node_name", handlers.Terminal.UpdateSettings)
@@ -244,6 +308,8 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		nodes.POST("/batch-cordon", handlers.Node.BatchCordon)
 		nodes.POST("/batch-uncordon", handlers.Node.BatchUncordon)
 		nodes.POST("/batch-drain", handlers.Node.BatchDrain)
+		nodes.POST("/drain-preflight", handlers.Node.DrainPreflight)
+		nodes.POST("/schedule-simulate", handlers.Node.SimulateScheduling)
 		// 禁止调度历史查询 (避免路由冲突，放在批量操作中)
 		nodes.POST("/batch-cordon-history", handlers.Node.GetBatchCordonHistory)
 		nodes.POST("/cordon-history", handlers.Node.GetCordonHistory)
@@ -278,6 +344,7 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		labels.PUT("/templates/:id", handlers.Label.UpdateTemplate)
 		labels.DELETE("/templates/:id", handlers.Label.DeleteTemplate)
 		labels.POST("/templates/apply", handlers.Label.ApplyTemplate)
+		labels.POST("/simulate", handlers.Label.SimulateImpact)
 	}
 
 	taints := protected.Group("/taints")
@@ -293,16 +360,27 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		taints.PUT("/templates/:id", handlers.Taint.UpdateTemplate)
 		taints.DELETE("/templates/:id", handlers.Taint.DeleteTemplate)
 		taints.POST("/templates/apply", handlers.Taint.ApplyTemplate)
+		taints.POST("/simulate", handlers.Taint.SimulateImpact)
 	}
 
 	audit := protected.Group("/audit")
 	{
 		audit.GET("/logs", handlers.Audit.List)
+		audit.GET("/logs/export", handlers.Audit.Export)
 		audit.GET("/logs/:id", handlers.Audit.GetByID)
+		audit.GET("/stats", handlers.Audit.Stats)
+		// 哈希链校验和归档（仅管理员）
+		audit.GET("/verify", handlers.Audit.Verify)
+		audit.POST("/retention/run", handlers.Audit.RunRetention)
+		// 节点变更详情和撤销
+		audit.GET("/changes/:operation_id", handlers.NodeChange.GetOperation)
+		audit.POST("/changes/:operation_id/undo", handlers.NodeChange.Undo)
 	}
 
 	// GitLab routes (admin only)
-	gitlab := protected.Group("/gitlab")
+	gitlab := protected.Group("/gitlab", handlers.Audit.RecordMutations(model.ResourceGitlabSettings, map[string]model.ResourceType{
+		"runners": model.ResourceGitlabRunner,
+	}))
 	{
 		gitlab.GET("/settings", handlers.Gitlab.GetSettings)
 		gitlab.PUT("/settings", handlers.Gitlab.UpdateSettings)
@@ -322,13 +400,29 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 	}
 
 	// System SSH Keys routes (系统级 SSH 密钥管理)
-	sshkeys := protected.Group("/ssh-keys")
+	sshkeys := protected.Group("/ssh-keys", handlers.Audit.RecordMutations(model.ResourceSSHKey, nil))
 	{
 		sshkeys.GET("", handlers.SSHKey.List)
 		sshkeys.GET("/:id", handlers.SSHKey.Get)
 		sshkeys.POST("", handlers.SSHKey.Create)
 		sshkeys.PUT("/:id", handlers.SSHKey.Update)
 		sshkeys.DELETE("/:id", handlers.SSHKey.Delete)
+		sshkeys.POST("/:id/rotate", handlers.KeyRotation.RotateSystemKey)
+	}
+
+	// SSH key rotation routes (SSH 密钥轮换进度)
+	keyRotations := protected.Group("/ssh-key-rotations")
+	{
+		keyRotations.GET("", handlers.KeyRotation.ListRotations)
+		keyRotations.GET("/:id", handlers.KeyRotation.GetRotation)
+	}
+
+	// SSH CA routes (平台签发短期 SSH 证书)
+	sshCA := protected.Group("/ssh-ca", handlers.Audit.RecordMutations(model.ResourceSSHKey, nil))
+	{
+		sshCA.GET("", handlers.SSHCA.GetCA)
+		sshCA.GET("/certificates", handlers.SSHCA.ListCertificates)
+		sshCA.POST("/trust-template", handlers.SSHCA.InstallTrustTemplate)
 	}
 
 	// Feishu routes (使用长连接模式，无需 webhook)
@@ -373,12 +467,39 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		anomalies.PUT("/cleanup/config", handlers.Anomaly.UpdateCleanupConfig)
 		anomalies.GET("/cleanup/stats", handlers.Anomaly.GetCleanupStats)
 
+		// 异常前后的节点资源指标
+		anomalies.GET("/:id/metrics", handlers.Anomaly.GetMetricsWindow)
+
 		// 根据ID获取单个异常记录（必须放在最后，避免与其他路由冲突）
 		anomalies.GET("/:id", handlers.Anomaly.GetByID)
 	}
 
+	// Node metrics routes (节点资源指标历史)
+	metrics := protected.Group("/metrics")
+	{
+		metrics.GET("/nodes", handlers.NodeMetrics.Query)
+	}
+
+	// Capacity routes (容量规划)
+	capacity := protected.Group("/capacity")
+	{
+		capacity.GET("/report", handlers.Capacity.GetReport)
+	}
+
 	// Ansible routes (Ansible 任务管理)
-	ansible := protected.Group("/ansible")
+	ansible := protected.Group("/ansible", handlers.Audit.RecordMutations(model.ResourceAnsibleTask, map[string]model.ResourceType{
+		"templates":           model.ResourceAnsibleTemplate,
+		"inventories":         model.ResourceAnsibleInventory,
+		"ssh-keys":            model.ResourceAnsibleSSHKey,
+		"secrets":             model.ResourceAnsibleSecret,
+		"projects":            model.ResourceAnsibleProject,
+		"schedules":           model.ResourceAnsibleSchedule,
+		"favorites":           model.ResourceAnsibleFavorite,
+		"task-history":        model.ResourceAnsibleFavorite,
+		"tags":                model.ResourceAnsibleTag,
+		"workflows":           model.ResourceAnsibleWorkflow,
+		"workflow-executions": model.ResourceAnsibleWorkflow,
+	}))
 	{
 		// 任务管理
 		ansible.GET("/tasks", handlers.Ansible.ListTasks)
@@ -394,12 +515,17 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		ansible.POST("/tasks/:id/preflight-checks", handlers.Ansible.RunPreflightChecks)
 		ansible.GET("/tasks/:id/preflight-checks", handlers.Ansible.GetPreflightChecks)
 		ansible.GET("/tasks/:id/logs", handlers.Ansible.GetTaskLogs)
+		ansible.GET("/tasks/:id/host-results", handlers.Ansible.GetHostResults)
 		ansible.POST("/tasks/:id/refresh", handlers.Ansible.RefreshTaskStatus)
 		ansible.POST("/tasks/:id/reparse", handlers.Ansible.ReparseTaskStats)
 
 		// 统计信息
 		ansible.GET("/statistics", handlers.Ansible.GetStatistics)
 
+		// 临时命令（多节点并发执行）
+		ansible.GET("/adhoc/config", handlers.Ansible.GetAdhocConfig)
+		ansible.POST("/adhoc", handlers.Ansible.CreateAdhocTask)
+
 		// 模板管理
 		ansible.GET("/templates", handlers.AnsibleTemplate.ListTemplates)
 		ansible.GET("/templates/:id", handlers.AnsibleTemplate.GetTemplate)
@@ -407,6 +533,10 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		ansible.PUT("/templates/:id", handlers.AnsibleTemplate.UpdateTemplate)
 		ansible.DELETE("/templates/:id", handlers.AnsibleTemplate.DeleteTemplate)
 		ansible.POST("/templates/validate", handlers.AnsibleTemplate.ValidateTemplate)
+		ansible.GET("/templates/:id/revisions", handlers.AnsibleTemplate.ListRevisions)
+		ansible.GET("/templates/:id/revisions/:revision", handlers.AnsibleTemplate.GetRevision)
+		ansible.GET("/templates/:id/diff", handlers.AnsibleTemplate.DiffRevisions)
+		ansible.POST("/templates/:id/rollback", handlers.AnsibleTemplate.RollbackTemplate)
 
 		// 主机清单管理
 		ansible.GET("/inventories", handlers.AnsibleInventory.ListInventories)
@@ -415,6 +545,7 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		ansible.PUT("/inventories/:id", handlers.AnsibleInventory.UpdateInventory)
 		ansible.DELETE("/inventories/:id", handlers.AnsibleInventory.DeleteInventory)
 		ansible.POST("/inventories/generate", handlers.AnsibleInventory.GenerateFromCluster)
+		ansible.POST("/inventories/preview", handlers.AnsibleInventory.PreviewFromCluster)
 		ansible.POST("/inventories/:id/refresh", handlers.AnsibleInventory.RefreshInventory)
 
 		// SSH 密钥管理
@@ -424,6 +555,22 @@ func setupRoutes(router *gin.Engine, handlers *handler.Handlers, healthHandler *
 		ansible.PUT("/ssh-keys/:id", handlers.AnsibleSSHKey.Update)
 		ansible.DELETE("/ssh-keys/:id", handlers.AnsibleSSHKey.Delete)
 		ansible.POST("/ssh-keys/:id/test", handlers.AnsibleSSHKey.TestConnection)
+		ansible.POST("/ssh-keys/:id/rotate", handlers.KeyRotation.RotateAnsibleKey)
+
+		// 密钥变量管理
+		ansible.GET("/secrets", handlers.AnsibleSecret.List)
+		ansible.GET("/secrets/:id", handlers.AnsibleSecret.Get)
+		ansible.POST("/secrets", handlers.AnsibleSecret.Create)
+		ansible.PUT("/secrets/:id", handlers.AnsibleSecret.Update)
+		ansible.DELETE("/secrets/:id", handlers.AnsibleSecret.Delete)
+
+		// Git 项目管理
+		ansible.GET("/projects", handlers.AnsibleProject.ListProjects)
+		ansible.GET("/projects/:id", handlers.AnsibleProject.GetProject)
+		ansible.POST("/projects", handlers.AnsibleProject.CreateProject)
+		ansible.PUT("/projects/:id", handlers.AnsibleProject.UpdateProject)
+		ansible.DELETE("/projects/:id", handlers.AnsibleProject.DeleteProject)
+		ansible.POST("/projects/:id/sync", handlers.AnsibleProject.SyncProject)
 
 		// 定时任务调度管理
 		ansible.GET("/schedules", handlers.AnsibleSchedule.ListSchedules)
@@ -511,6 +658,21 @@ func gracefulShutdown(srv *http.Server, db *gorm.DB, logger *logger.Logger, serv
 		services.Anomaly.StopMonitoring()
 	}
 
+	// 停止节点资源指标历史采样服务
+	if services != nil && services.NodeMetrics != nil {
+		services.NodeMetrics.Stop()
+	}
+
+	// 停止节点事件历史清理
+	if services != nil && services.NodeEvents != nil {
+		services.NodeEvents.Stop()
+	}
+
+	// 停止飞书定时容量报告
+	if services != nil && services.Capacity != nil {
+		services.Capacity.Stop()
+	}
+
 	// 停止 Ansible 定时任务调度服务
 	if services != nil && services.Ansible != nil && services.Ansible.GetScheduleService() != nil {
 		services.Ansible.GetScheduleService().Stop()
@@ -521,6 +683,11 @@ func gracefulShutdown(srv *http.Server, db *gorm.DB, logger *logger.Logger, serv
 		logger.Error("Server forced to shutdown: " + err.Error())
 	}
 
+	// 停止审计日志归档清理，导出剩余的审计日志（在 HTTP 服务器关闭之后，避免丢失最后的请求）
+	if services != nil && services.Audit != nil {
+		services.Audit.Stop()
+	}
+
 	// 关闭数据库连接
 	if db != nil {
 		if sqlDB, err := db.DB(); err == nil {
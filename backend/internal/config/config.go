@@ -11,9 +11,15 @@ type Config struct {
 	Server     ServerConfig     `mapstructure:"server"`
 	Database   DatabaseConfig   `mapstructure:"database"`
 	JWT        JWTConfig        `mapstructure:"jwt"`
+	Security   SecurityConfig   `mapstructure:"security"`
 	LDAP       LDAPConfig       `mapstructure:"ldap"`
 	Progress   ProgressConfig   `mapstructure:"progress"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
+
+	Audit    AuditConfig    `mapstructure:"audit"`    // 审计日志保留、归档与导出配置
+	Terminal TerminalConfig `mapstructure:"terminal"` // Web 终端配置
+	Ansible  AnsibleConfig  `mapstructure:"ansible"`  // Ansible 模块配置
+	SSHCA    SSHCAConfig    `mapstructure:"ssh_ca"`   // 平台 SSH 证书颁发机构
 }
 
 type ServerConfig struct {
@@ -44,6 +50,17 @@ type JWTConfig struct {
 	ExpireTime int    `mapstructure:"expire_time"`
 }
 
+// SecurityConfig 登录安全配置
+type SecurityConfig struct {
+	MFAIssuer              string   `mapstructure:"mfa_issuer"`                 // 验证器应用中显示的发行方名称
+	MFARequiredRoles       []string `mapstructure:"mfa_required_roles"`         // 必须启用 MFA 的角色
+	MFAEncryptionKey       string   `mapstructure:"mfa_encryption_key"`         // TOTP 密钥加密密钥，为空时使用 JWT 密钥
+	MaxFailedLoginsPerUser int      `mapstructure:"max_failed_logins_per_user"` // 单个用户在窗口内允许的失败次数
+	MaxFailedLoginsPerIP   int      `mapstructure:"max_failed_logins_per_ip"`   // 单个 IP 在窗口内允许的失败次数
+	FailedLoginWindow      int      `mapstructure:"failed_login_window"`        // 失败计数窗口（秒）
+	LockoutDuration        int      `mapstructure:"lockout_duration"`           // 锁定时长（秒）
+}
+
 type LDAPConfig struct {
 	Enabled    bool   `mapstructure:"enabled"`
 	Host       string `mapstructure:"host"`
@@ -54,6 +71,95 @@ type LDAPConfig struct {
 	AdminPass  string `mapstructure:"admin_pass"`
 }
 
+type AuditConfig struct {
+	Retention AuditRetentionConfig `mapstructure:"retention"` // 保留与归档
+	Export    AuditExportConfig    `mapstructure:"export"`    // 导出到外部系统（SIEM）
+}
+
+type AuditRetentionConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`        // 是否删除超出保留期的审计日志
+	RetentionDays int    `mapstructure:"retention_days"` // 保留天数
+	CleanupTime   string `mapstructure:"cleanup_time"`   // 清理时间（HH:MM）
+	BatchSize     int    `mapstructure:"batch_size"`     // 每批归档删除的记录数
+	Archive       bool   `mapstructure:"archive"`        // 删除前归档到 JSON Lines 文件
+	ArchiveDir    string `mapstructure:"archive_dir"`    // 归档目录，多副本部署时应使用共享存储
+}
+
+type AuditExportConfig struct {
+	QueueSize     int                     `mapstructure:"queue_size"`     // 每个导出目标的队列长度
+	BatchSize     int                     `mapstructure:"batch_size"`     // 每批导出的最大记录数
+	FlushInterval int                     `mapstructure:"flush_interval"` // 不满一批时的最长等待时间（秒）
+	Syslog        AuditSyslogExportConfig `mapstructure:"syslog"`         // syslog（RFC 5424）
+	File          AuditFileExportConfig   `mapstructure:"file"`           // JSON Lines 文件
+	HTTP          AuditHTTPExportConfig   `mapstructure:"http"`           // HTTP
+}
+
+type AuditSyslogExportConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Network  string `mapstructure:"network"`  // tcp 或 udp
+	Address  string `mapstructure:"address"`  // host:port
+	Facility int    `mapstructure:"facility"` // syslog facility，默认 13（log audit）
+	AppName  string `mapstructure:"app_name"`
+}
+
+type AuditFileExportConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Path       string `mapstructure:"path"`        // 文件路径
+	MaxSizeMB  int    `mapstructure:"max_size_mb"` // 单个文件最大大小（MB），超过后轮转
+	MaxBackups int    `mapstructure:"max_backups"` // 保留的轮转文件数
+}
+
+type AuditHTTPExportConfig struct {
+	Enabled bool              `mapstructure:"enabled"`
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"` // 额外请求头，例如 Authorization
+	Timeout int               `mapstructure:"timeout"` // 请求超时（秒）
+}
+
+type TerminalConfig struct {
+	DebugPod DebugPodConfig `mapstructure:"debug_pod"` // 特权调试 Pod 终端（用于没有 SSH 的节点）
+	SFTP     SFTPConfig     `mapstructure:"sftp"`      // 通过 SFTP 上传下载节点文件
+}
+
+type DebugPodConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`         // 默认关闭，需要显式开启
+	Image          string `mapstructure:"image"`           // 调试镜像，需要包含 sh 和 chroot
+	Namespace      string `mapstructure:"namespace"`       // 调试 Pod 所在命名空间
+	MaxDuration    int    `mapstructure:"max_duration"`    // 单个会话最长时间（分钟）
+	StartupTimeout int    `mapstructure:"startup_timeout"` // 等待 Pod 启动的超时时间（秒）
+}
+
+type SFTPConfig struct {
+	Enabled           bool     `mapstructure:"enabled"`
+	MaxUploadSizeMB   int      `mapstructure:"max_upload_size_mb"`   // 单个上传文件的最大大小（MB）
+	MaxDownloadSizeMB int      `mapstructure:"max_download_size_mb"` // 单个下载文件的最大大小（MB）
+	DownloadPaths     []string `mapstructure:"download_paths"`       // 允许浏览和下载的目录
+	UploadPaths       []string `mapstructure:"upload_paths"`         // 允许上传的目录
+}
+
+type AnsibleConfig struct {
+	Adhoc AdhocConfig `mapstructure:"adhoc"` // 多节点临时命令
+}
+
+type AdhocConfig struct {
+	Enabled         bool     `mapstructure:"enabled"`
+	AllowedCommands []string `mapstructure:"allowed_commands"` // 允许的程序，为空表示不限制（仍受危险命令检查）
+	DeniedPatterns  []string `mapstructure:"denied_patterns"`  // 额外禁止的命令片段
+	DefaultForks    int      `mapstructure:"default_forks"`    // 默认并发主机数
+	MaxForks        int      `mapstructure:"max_forks"`        // 最大并发主机数
+	DefaultTimeout  int      `mapstructure:"default_timeout"`  // 单台主机默认命令超时（秒）
+	MaxTimeout      int      `mapstructure:"max_timeout"`      // 单台主机最大命令超时（秒）
+}
+
+type SSHCAConfig struct {
+	Enabled          bool                `mapstructure:"enabled"`
+	TerminalValidity int                 `mapstructure:"terminal_validity"` // 终端和文件传输证书有效期（分钟）
+	AnsibleValidity  int                 `mapstructure:"ansible_validity"`  // Ansible 任务证书有效期上限（分钟），任务超时更短时按任务超时签发
+	KeyFallback      bool                `mapstructure:"key_fallback"`      // 同时提供原有 SSH 密钥，节点未信任 CA 或签发失败时仍可登录
+	RolePrincipals   map[string][]string `mapstructure:"role_principals"`   // 用户角色 -> 证书 principals
+	NodePrincipals   map[string][]string `mapstructure:"node_principals"`   // 节点登录用户 -> 接受的 principals（生成节点信任模板）
+}
+
 type ProgressConfig struct {
 	EnableDatabase bool          `mapstructure:"enable_database"` // 启用数据库模式用于多副本支持
 	NotifyType     string        `mapstructure:"notify_type"`     // 通知方式：polling, postgres, redis
@@ -74,6 +180,10 @@ type MonitoringConfig struct {
 	ReportSchedulerEnabled bool          `mapstructure:"report_scheduler_enabled"` // 启用报告调度器
 	Cache                  CacheConfig   `mapstructure:"cache"`                    // 缓存配置
 	Cleanup                CleanupConfig `mapstructure:"cleanup"`                  // 清理配置
+	Metrics                MetricsConfig `mapstructure:"metrics"`                  // 节点资源指标历史配置
+
+	Capacity   CapacityConfig   `mapstructure:"capacity"`    // 容量规划配置
+	NodeEvents NodeEventsConfig `mapstructure:"node_events"` // 节点 Kubernetes Event 持久化配置
 }
 
 type CleanupConfig struct {
@@ -83,6 +193,37 @@ type CleanupConfig struct {
 	BatchSize     int    `mapstructure:"batch_size"`     // 批量删除大小
 }
 
+type MetricsConfig struct {
+	Enabled              bool   `mapstructure:"enabled"`                 // 启用节点资源指标采样
+	Interval             int    `mapstructure:"interval"`                // 采样周期（秒）
+	RawRetentionHours    int    `mapstructure:"raw_retention_hours"`     // 原始采样保留小时数
+	FiveMinRetentionDays int    `mapstructure:"five_min_retention_days"` // 5 分钟聚合保留天数
+	HourlyRetentionDays  int    `mapstructure:"hourly_retention_days"`   // 1 小时聚合保留天数
+	CleanupTime          string `mapstructure:"cleanup_time"`            // 清理时间（HH:MM）
+	BatchSize            int    `mapstructure:"batch_size"`              // 批量删除大小
+}
+
+type CapacityConfig struct {
+	PoolLabel string               `mapstructure:"pool_label"` // 节点池标签键，为空时只统计集群整体
+	LossNodes int                  `mapstructure:"loss_nodes"` // 冗余评估时假设失去的最大节点数
+	TrendDays int                  `mapstructure:"trend_days"` // 趋势预测使用的历史天数
+	Report    CapacityReportConfig `mapstructure:"report"`     // 飞书定时容量报告
+}
+
+type CapacityReportConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`  // 启用定时报告（同时需要启用 report_scheduler_enabled）
+	Time     string   `mapstructure:"time"`     // 每天发送时间（HH:MM）
+	ChatIDs  []string `mapstructure:"chat_ids"` // 接收报告的飞书群聊 ID
+	Clusters []string `mapstructure:"clusters"` // 报告包含的集群，为空时包含全部活跃集群
+}
+
+type NodeEventsConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`        // 启用节点 Event 监听和持久化
+	RetentionDays int    `mapstructure:"retention_days"` // 保留天数
+	CleanupTime   string `mapstructure:"cleanup_time"`   // 清理时间（HH:MM）
+	BatchSize     int    `mapstructure:"batch_size"`     // 批量删除大小
+}
+
 type CacheConfig struct {
 	Enabled  bool                `mapstructure:"enabled"`  // 启用缓存
 	Type     string              `mapstructure:"type"`     // 缓存类型：postgres, memory, none
@@ -128,6 +269,12 @@ func LoadConfig() *Config {
 	viper.SetDefault("database.migration_timeout", 300)
 	viper.SetDefault("jwt.secret", "your-secret-key-change-in-production")
 	viper.SetDefault("jwt.expire_time", 86400)
+	viper.SetDefault("security.mfa_issuer", "Kube Node Manager")
+	viper.SetDefault("security.mfa_required_roles", []string{})
+	viper.SetDefault("security.max_failed_logins_per_user", 5)
+	viper.SetDefault("security.max_failed_logins_per_ip", 20)
+	viper.SetDefault("security.failed_login_window", 900)
+	viper.SetDefault("security.lockout_duration", 900)
 	viper.SetDefault("ldap.enabled", false)
 	viper.SetDefault("ldap.port", 389)
 	viper.SetDefault("progress.enable_database", false)
@@ -153,6 +300,64 @@ func LoadConfig() *Config {
 	viper.SetDefault("monitoring.cleanup.retention_days", 90)
 	viper.SetDefault("monitoring.cleanup.cleanup_time", "02:00")
 	viper.SetDefault("monitoring.cleanup.batch_size", 1000)
+	viper.SetDefault("monitoring.metrics.enabled", true)
+	viper.SetDefault("monitoring.metrics.interval", 60)
+	viper.SetDefault("monitoring.metrics.raw_retention_hours", 48)
+	viper.SetDefault("monitoring.metrics.five_min_retention_days", 14)
+	viper.SetDefault("monitoring.metrics.hourly_retention_days", 180)
+	viper.SetDefault("monitoring.metrics.cleanup_time", "02:30")
+	viper.SetDefault("monitoring.metrics.batch_size", 1000)
+	viper.SetDefault("monitoring.capacity.pool_label", "")
+	viper.SetDefault("monitoring.capacity.loss_nodes", 1)
+	viper.SetDefault("monitoring.capacity.trend_days", 14)
+	viper.SetDefault("monitoring.capacity.report.enabled", false)
+	viper.SetDefault("monitoring.capacity.report.time", "09:30")
+	viper.SetDefault("monitoring.node_events.enabled", true)
+	viper.SetDefault("monitoring.node_events.retention_days", 30)
+	viper.SetDefault("monitoring.node_events.cleanup_time", "03:00")
+	viper.SetDefault("monitoring.node_events.batch_size", 1000)
+	viper.SetDefault("audit.retention.enabled", false)
+	viper.SetDefault("audit.retention.retention_days", 365)
+	viper.SetDefault("audit.retention.cleanup_time", "04:00")
+	viper.SetDefault("audit.retention.batch_size", 1000)
+	viper.SetDefault("audit.retention.archive", true)
+	viper.SetDefault("audit.retention.archive_dir", "./data/audit-archive")
+	viper.SetDefault("audit.export.queue_size", 10000)
+	viper.SetDefault("audit.export.batch_size", 100)
+	viper.SetDefault("audit.export.flush_interval", 5)
+	viper.SetDefault("audit.export.syslog.enabled", false)
+	viper.SetDefault("audit.export.syslog.network", "udp")
+	viper.SetDefault("audit.export.syslog.facility", 13)
+	viper.SetDefault("audit.export.syslog.app_name", "kube-node-manager")
+	viper.SetDefault("audit.export.file.enabled", false)
+	viper.SetDefault("audit.export.file.path", "./data/audit/audit.jsonl")
+	viper.SetDefault("audit.export.file.max_size_mb", 100)
+	viper.SetDefault("audit.export.file.max_backups", 10)
+	viper.SetDefault("audit.export.http.enabled", false)
+	viper.SetDefault("audit.export.http.timeout", 10)
+	viper.SetDefault("terminal.debug_pod.enabled", false)
+	viper.SetDefault("terminal.debug_pod.image", "busybox:1.36")
+	viper.SetDefault("terminal.debug_pod.namespace", "default")
+	viper.SetDefault("terminal.debug_pod.max_duration", 120)
+	viper.SetDefault("terminal.debug_pod.startup_timeout", 120)
+	viper.SetDefault("terminal.sftp.enabled", true)
+	viper.SetDefault("terminal.sftp.max_upload_size_mb", 100)
+	viper.SetDefault("terminal.sftp.max_download_size_mb", 500)
+	viper.SetDefault("terminal.sftp.download_paths", []string{"/var/log", "/tmp"})
+	viper.SetDefault("terminal.sftp.upload_paths", []string{"/tmp"})
+	viper.SetDefault("ansible.adhoc.enabled", true)
+	viper.SetDefault("ansible.adhoc.allowed_commands", []string{})
+	viper.SetDefault("ansible.adhoc.denied_patterns", []string{})
+	viper.SetDefault("ansible.adhoc.default_forks", 10)
+	viper.SetDefault("ansible.adhoc.max_forks", 50)
+	viper.SetDefault("ansible.adhoc.default_timeout", 60)
+	viper.SetDefault("ansible.adhoc.max_timeout", 3600)
+	viper.SetDefault("ssh_ca.enabled", false)
+	viper.SetDefault("ssh_ca.terminal_validity", 5)
+	viper.SetDefault("ssh_ca.ansible_validity", 120)
+	viper.SetDefault("ssh_ca.key_fallback", true)
+	viper.SetDefault("ssh_ca.role_principals", map[string][]string{"admin": {"knm-admin"}, "user": {"knm-operator"}})
+	viper.SetDefault("ssh_ca.node_principals", map[string][]string{"root": {"knm-admin"}})
 
 	viper.AutomaticEnv()
 	
@@ -85,6 +85,62 @@ func (h *Handler) GetByID(c *gin.Context) {
 	})
 }
 
+// GetMetricsWindow 获取异常前后的节点资源使用历史
+// @Summary 获取异常前后的节点资源使用历史
+// @Description 返回异常开始前到结束后（进行中的异常到当前时间）该节点的 CPU、内存和 Pod 数量历史
+// @Tags anomalies
+// @Produce json
+// @Param id path int true "异常记录ID"
+// @Param padding query int false "异常前后额外展示的分钟数，默认30"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /anomalies/{id}/metrics [get]
+func (h *Handler) GetMetricsWindow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid anomaly ID: " + err.Error(),
+		})
+		return
+	}
+
+	var padding time.Duration
+	if paddingStr := c.Query("padding"); paddingStr != "" {
+		minutes, err := strconv.Atoi(paddingStr)
+		if err != nil || minutes < 0 || minutes > 24*60 {
+			c.JSON(http.StatusBadRequest, Response{
+				Code:    http.StatusBadRequest,
+				Message: "padding must be between 0 and 1440 minutes",
+			})
+			return
+		}
+		padding = time.Duration(minutes) * time.Minute
+	}
+
+	result, err := h.anomalySvc.GetMetricsWindow(uint(id), padding)
+	if err != nil {
+		h.logger.Errorf("Failed to get metrics window for anomaly %d: %v", id, err)
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "Failed to get metrics window: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    result,
+	})
+}
+
 // List 获取异常记录列表
 // @Summary 获取异常记录列表
 // @Description 获取节点异常记录列表，支持多条件过滤和分页
@@ -0,0 +1,115 @@
+package ansible
+
+import (
+	"errors"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/ansible"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAdhocTask 在多个节点上执行临时命令
+// @Summary 执行临时命令
+// @Description 通过 ansible -m shell/command 在清单主机上并发执行命令，可按主机列表或节点标签选择器缩小范围
+// @Tags Ansible
+// @Accept json
+// @Produce json
+// @Param request body model.AdhocCommandRequest true "临时命令"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/adhoc [post]
+func (h *Handler) CreateAdhocTask(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	var req model.AdhocCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.service.CreateAdhocTask(req, h.getUserID(c))
+	if err != nil {
+		h.logger.Errorf("Failed to create ad-hoc task: %v", err)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ansible.ErrAdhocDisabled):
+			status = http.StatusForbidden
+		case errors.Is(err, ansible.ErrAdhocRejected):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Ad-hoc task created and started successfully",
+		"data":    task,
+	})
+}
+
+// GetAdhocConfig 获取临时命令策略
+// @Summary 获取临时命令策略
+// @Tags Ansible
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/adhoc/config [get]
+func (h *Handler) GetAdhocConfig(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	policy := h.service.GetAdhocPolicy()
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data": gin.H{
+			"enabled":          policy.Enabled,
+			"allowed_commands": policy.AllowedCommands,
+			"denied_patterns":  policy.DeniedPatterns,
+			"default_forks":    policy.DefaultForks,
+			"max_forks":        policy.MaxForks,
+			"default_timeout":  policy.DefaultTimeout,
+			"max_timeout":      policy.MaxTimeout,
+		},
+	})
+}
+
+// GetHostResults 获取临时命令各主机的执行结果
+// @Summary 获取临时命令主机结果
+// @Description 返回每台主机的状态、退出码和输出，并按相同输出分组便于对比
+// @Tags Ansible
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/tasks/{id}/host-results [get]
+func (h *Handler) GetHostResults(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task id"})
+		return
+	}
+
+	results, groups, err := h.service.GetHostResults(uint(id))
+	if err != nil {
+		h.logger.Errorf("Failed to get host results: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data": gin.H{
+			"results": results,
+			"groups":  groups,
+		},
+	})
+}
@@ -283,3 +283,39 @@ func (h *InventoryHandler) RefreshInventory(c *gin.Context) {
 	})
 }
 
+
+// PreviewFromCluster 预览从集群生成的主机清单
+// @Summary 预览从集群生成的主机清单
+// @Tags Ansible Inventories
+// @Accept json
+// @Produce json
+// @Param request body model.K8sInventoryPreviewRequest true "筛选条件"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/inventories/preview [post]
+func (h *InventoryHandler) PreviewFromCluster(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	var req model.K8sInventoryPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, hostsData, err := h.service.PreviewK8sInventory(req)
+	if err != nil {
+		h.logger.Errorf("Failed to preview inventory from cluster: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data": gin.H{
+			"content":    content,
+			"hosts_data": hostsData,
+		},
+	})
+}
@@ -0,0 +1,235 @@
+package ansible
+
+import (
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/ansible"
+	"kube-node-manager/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectHandler Git 项目 Handler
+type ProjectHandler struct {
+	service *ansible.ProjectService
+	logger  *logger.Logger
+}
+
+// NewProjectHandler 创建 Git 项目 Handler 实例
+func NewProjectHandler(service *ansible.ProjectService, logger *logger.Logger) *ProjectHandler {
+	return &ProjectHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListProjects 列出项目
+// @Summary 列出 Git 项目
+// @Tags Ansible Projects
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param keyword query string false "关键字"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/projects [get]
+func (h *ProjectHandler) ListProjects(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	var req model.ProjectListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	projects, total, err := h.service.ListProjects(req)
+	if err != nil {
+		h.logger.Errorf("Failed to list projects: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    projects,
+		"total":   total,
+	})
+}
+
+// GetProject 获取项目详情
+// @Summary 获取 Git 项目详情
+// @Tags Ansible Projects
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/projects/{id} [get]
+func (h *ProjectHandler) GetProject(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	project, err := h.service.GetProject(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    project,
+	})
+}
+
+// CreateProject 创建项目
+// @Summary 创建 Git 项目
+// @Tags Ansible Projects
+// @Accept json
+// @Produce json
+// @Param project body model.ProjectCreateRequest true "项目信息"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/projects [post]
+func (h *ProjectHandler) CreateProject(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	var req model.ProjectCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	project, err := h.service.CreateProject(req, userID.(uint))
+	if err != nil {
+		h.logger.Errorf("Failed to create project: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Project created successfully",
+		"data":    project,
+	})
+}
+
+// UpdateProject 更新项目
+// @Summary 更新 Git 项目
+// @Tags Ansible Projects
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param project body model.ProjectUpdateRequest true "项目信息"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/projects/{id} [put]
+func (h *ProjectHandler) UpdateProject(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	var req model.ProjectUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	project, err := h.service.UpdateProject(uint(id), req, userID.(uint))
+	if err != nil {
+		h.logger.Errorf("Failed to update project: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Project updated successfully",
+		"data":    project,
+	})
+}
+
+// DeleteProject 删除项目
+// @Summary 删除 Git 项目
+// @Tags Ansible Projects
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/projects/{id} [delete]
+func (h *ProjectHandler) DeleteProject(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.service.DeleteProject(uint(id), userID.(uint)); err != nil {
+		h.logger.Errorf("Failed to delete project: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Project deleted successfully",
+	})
+}
+
+// SyncProject 同步项目仓库
+// @Summary 同步 Git 项目，将 ref 解析为提交 SHA
+// @Tags Ansible Projects
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/projects/{id}/sync [post]
+func (h *ProjectHandler) SyncProject(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project id"})
+		return
+	}
+
+	project, err := h.service.SyncProject(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Project synced successfully",
+		"data":    project,
+	})
+}
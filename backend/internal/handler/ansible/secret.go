@@ -0,0 +1,204 @@
+package ansible
+
+import (
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/ansible"
+	"kube-node-manager/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecretHandler 密钥变量 Handler
+type SecretHandler struct {
+	service *ansible.SecretService
+	logger  *logger.Logger
+}
+
+// NewSecretHandler 创建密钥变量 Handler 实例
+func NewSecretHandler(service *ansible.SecretService, logger *logger.Logger) *SecretHandler {
+	return &SecretHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List 列出密钥变量
+// @Summary 列出密钥变量（不返回密钥值）
+// @Tags Ansible Secrets
+// @Accept json
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param keyword query string false "关键字"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/secrets [get]
+func (h *SecretHandler) List(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	var req model.SecretListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	secrets, total, err := h.service.List(req)
+	if err != nil {
+		h.logger.Errorf("Failed to list secrets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    secrets,
+		"total":   total,
+	})
+}
+
+// Get 获取密钥变量详情
+// @Summary 获取密钥变量详情（不返回密钥值）
+// @Tags Ansible Secrets
+// @Accept json
+// @Produce json
+// @Param id path int true "密钥变量 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/secrets/{id} [get]
+func (h *SecretHandler) Get(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid secret id"})
+		return
+	}
+
+	secret, err := h.service.GetByID(uint(id))
+	if err != nil {
+		h.logger.Errorf("Failed to get secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    secret,
+	})
+}
+
+// Create 创建密钥变量
+// @Summary 创建密钥变量
+// @Tags Ansible Secrets
+// @Accept json
+// @Produce json
+// @Param secret body model.SecretCreateRequest true "密钥变量信息"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/secrets [post]
+func (h *SecretHandler) Create(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	var req model.SecretCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	secret, err := h.service.Create(req, userID.(uint))
+	if err != nil {
+		h.logger.Errorf("Failed to create secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Secret created successfully",
+		"data":    secret,
+	})
+}
+
+// Update 更新密钥变量
+// @Summary 更新密钥变量（value 为空表示保持不变）
+// @Tags Ansible Secrets
+// @Accept json
+// @Produce json
+// @Param id path int true "密钥变量 ID"
+// @Param secret body model.SecretUpdateRequest true "密钥变量信息"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/secrets/{id} [put]
+func (h *SecretHandler) Update(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid secret id"})
+		return
+	}
+
+	var req model.SecretUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	secret, err := h.service.Update(uint(id), req, userID.(uint))
+	if err != nil {
+		h.logger.Errorf("Failed to update secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Secret updated successfully",
+		"data":    secret,
+	})
+}
+
+// Delete 删除密钥变量
+// @Summary 删除密钥变量
+// @Tags Ansible Secrets
+// @Accept json
+// @Produce json
+// @Param id path int true "密钥变量 ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/secrets/{id} [delete]
+func (h *SecretHandler) Delete(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid secret id"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.service.Delete(uint(id), userID.(uint)); err != nil {
+		h.logger.Errorf("Failed to delete secret: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Secret deleted successfully",
+	})
+}
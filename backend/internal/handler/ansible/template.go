@@ -244,3 +244,167 @@ func (h *TemplateHandler) ValidateTemplate(c *gin.Context) {
 	})
 }
 
+
+// ListRevisions 列出模板版本
+// @Summary 列出模板版本
+// @Tags Ansible Templates
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/templates/{id}/revisions [get]
+func (h *TemplateHandler) ListRevisions(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return
+	}
+
+	revisions, err := h.service.ListRevisions(uint(id))
+	if err != nil {
+		h.logger.Errorf("Failed to list template revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    revisions,
+	})
+}
+
+// GetRevision 获取模板指定版本
+// @Summary 获取模板指定版本
+// @Tags Ansible Templates
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param revision path int true "版本号"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/templates/{id}/revisions/{revision} [get]
+func (h *TemplateHandler) GetRevision(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return
+	}
+
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return
+	}
+
+	snapshot, err := h.service.GetRevision(uint(id), revision)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    snapshot,
+	})
+}
+
+// DiffRevisions 比较模板两个版本
+// @Summary 比较模板两个版本
+// @Tags Ansible Templates
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param from query int true "起始版本号"
+// @Param to query int false "目标版本号（默认最新版本）"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/templates/{id}/diff [get]
+func (h *TemplateHandler) DiffRevisions(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from revision"})
+		return
+	}
+
+	to := 0
+	if toStr := c.Query("to"); toStr != "" {
+		to, err = strconv.Atoi(toStr)
+		if err != nil || to < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to revision"})
+			return
+		}
+	}
+
+	diff, err := h.service.DiffRevisions(uint(id), from, to)
+	if err != nil {
+		h.logger.Errorf("Failed to diff template revisions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    diff,
+	})
+}
+
+// RollbackTemplate 回滚模板到指定版本
+// @Summary 回滚模板到指定版本
+// @Tags Ansible Templates
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body model.TemplateRollbackRequest true "回滚请求"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/templates/{id}/rollback [post]
+func (h *TemplateHandler) RollbackTemplate(c *gin.Context) {
+	if !checkAdminPermission(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return
+	}
+
+	var req model.TemplateRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	template, err := h.service.RollbackTemplate(uint(id), req.Revision, req.ChangeNote, userID.(uint))
+	if err != nil {
+		h.logger.Errorf("Failed to rollback template: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Template rolled back successfully",
+		"data":    template,
+	})
+}
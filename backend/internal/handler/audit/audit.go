@@ -39,51 +39,24 @@ func NewHandler(auditSvc *audit.Service, logger *logger.Logger) *Handler {
 // @Produce json
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
-// @Param user_id query int false "用户ID筛选"
-// @Param username query string false "用户名筛选"
+// @Param user_id query string false "用户ID筛选，多个用逗号分隔"
+// @Param username query string false "用户名筛选，多个用逗号分隔"
 // @Param cluster_id query int false "集群ID筛选"
-// @Param action query string false "操作类型筛选"
-// @Param resource_type query string false "资源类型筛选"
+// @Param action query string false "操作类型筛选，多个用逗号分隔"
+// @Param resource_type query string false "资源类型筛选，多个用逗号分隔"
 // @Param status query string false "状态筛选"
-// @Param start_date query string false "开始日期筛选 (YYYY-MM-DD)"
-// @Param end_date query string false "结束日期筛选 (YYYY-MM-DD)"
+// @Param node_name query string false "节点名称，支持 * 和 ? 通配符，多个用逗号分隔"
+// @Param keyword query string false "在详情、原因和错误信息中检索"
+// @Param start_date query string false "开始时间 (YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS)"
+// @Param end_date query string false "结束时间 (YYYY-MM-DD 或 YYYY-MM-DD HH:MM:SS)"
 // @Success 200 {object} Response
 // @Router /audit/logs [get]
 func (h *Handler) List(c *gin.Context) {
-	var req audit.ListRequest
-
-	// 解析查询参数
-	if pageStr := c.Query("page"); pageStr != "" {
-		if page, err := strconv.Atoi(pageStr); err == nil {
-			req.Page = page
-		}
-	}
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		if pageSize, err := strconv.Atoi(pageSizeStr); err == nil {
-			req.PageSize = pageSize
-		}
-	}
-	if userIDStr := c.Query("user_id"); userIDStr != "" {
-		if userID, err := strconv.ParseUint(userIDStr, 10, 32); err == nil {
-			req.UserID = uint(userID)
-		}
-	}
-	if clusterIDStr := c.Query("cluster_id"); clusterIDStr != "" {
-		if clusterID, err := strconv.ParseUint(clusterIDStr, 10, 32); err == nil {
-			req.ClusterID = uint(clusterID)
-		}
-	}
-
-	req.Username = c.Query("username")
-	req.Action = model.AuditAction(c.Query("action"))
-	req.ResourceType = model.ResourceType(c.Query("resource_type"))
-	req.Status = model.AuditStatus(c.Query("status"))
-	req.StartDate = c.Query("start_date")
-	req.EndDate = c.Query("end_date")
+	req := parseListRequest(c)
 
 	// 添加调试日志
-	h.logger.Infof("审计日志查询参数: action=%s, resource_type=%s, status=%s, username=%s",
-		req.Action, req.ResourceType, req.Status, req.Username)
+	h.logger.Infof("审计日志查询参数: action=%v, resource_type=%v, status=%s, username=%s, node_name=%s, keyword=%s",
+		req.Actions, req.ResourceTypes, req.Status, req.Username, req.NodeName, req.Keyword)
 
 	// 检查用户权限 - 普通用户只能查看自己的日志
 	currentUserID, exists := c.Get("user_id")
@@ -426,3 +399,88 @@ func (h *Handler) calculateUserActivity(logs []model.AuditLog, days int) map[str
 
 	return activity
 }
+
+// Verify 校验审计日志哈希链
+// @Summary 校验审计日志哈希链
+// @Description 重新计算每条审计日志的哈希并检查链接关系，发现被修改或删除的记录（仅管理员）
+// @Tags audit
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /audit/verify [get]
+func (h *Handler) Verify(c *gin.Context) {
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, Response{
+			Code:    http.StatusForbidden,
+			Message: "Only admin can verify audit logs",
+		})
+		return
+	}
+
+	result, err := h.auditSvc.Verify()
+	if err != nil {
+		h.logger.Errorf("Failed to verify audit log chain: %v", err)
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to verify audit logs: " + err.Error(),
+		})
+		return
+	}
+
+	if !result.Valid {
+		h.logger.Warningf("Audit log chain verification found %d issues", result.IssueCount)
+	}
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    result,
+	})
+}
+
+// RunRetention 立即执行审计日志归档清理
+// @Summary 执行审计日志归档清理
+// @Description 按保留策略立即归档并删除过期审计日志（仅管理员，需要启用 audit.retention）
+// @Tags audit
+// @Produce json
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /audit/retention/run [post]
+func (h *Handler) RunRetention(c *gin.Context) {
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, Response{
+			Code:    http.StatusForbidden,
+			Message: "Only admin can run audit log retention",
+		})
+		return
+	}
+
+	if !h.auditSvc.RetentionEnabled() {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Audit log retention is disabled",
+		})
+		return
+	}
+
+	result, err := h.auditSvc.ApplyRetention()
+	if err != nil {
+		h.logger.Errorf("Failed to apply audit log retention: %v", err)
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to apply audit log retention: " + err.Error(),
+			Data:    result,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    result,
+	})
+}
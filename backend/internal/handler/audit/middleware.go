@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readOnlyActions 使用 POST 但不修改数据的接口（路由最后一段），不记录审计日志
+var readOnlyActions = map[string]bool{
+	"validate": true,
+	"preview":  true,
+}
+
+// RecordMutations 记录路由组内所有写操作（POST/PUT/PATCH/DELETE）的审计日志
+// resources 按路由组之后的第一段路径确定资源类型（如 /ansible/templates/:id 中的 templates），未匹配时使用 fallback
+// 只记录请求方法、路径和响应状态，不记录请求体，避免把密钥等敏感信息写入审计日志
+func (h *Handler) RecordMutations(fallback model.ResourceType, resources map[string]model.ResourceType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		method := c.Request.Method
+		if method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch && method != http.MethodDelete {
+			return
+		}
+		route := c.FullPath()
+		segments := strings.Split(strings.TrimPrefix(route, "/api/v1/"), "/")
+		if route == "" || readOnlyActions[segments[len(segments)-1]] {
+			return
+		}
+		userID := c.GetUint("user_id")
+		if userID == 0 {
+			return
+		}
+
+		resource := fallback
+		if len(segments) > 1 {
+			if r, ok := resources[segments[1]]; ok {
+				resource = r
+			}
+		}
+
+		status := model.AuditStatusSuccess
+		errorMsg := ""
+		if code := c.Writer.Status(); code >= http.StatusBadRequest {
+			status = model.AuditStatusFailed
+			errorMsg = fmt.Sprintf("HTTP %d", code)
+			if len(c.Errors) > 0 {
+				errorMsg += ": " + c.Errors.String()
+			}
+		}
+
+		var apiTokenID *uint
+		if id := c.GetUint("api_token_id"); id > 0 {
+			apiTokenID = &id
+		}
+
+		h.auditSvc.Log(audit.LogRequest{
+			UserID:       userID,
+			Action:       mutationAction(method, segments),
+			ResourceType: resource,
+			Details:      fmt.Sprintf("%s %s", method, c.Request.URL.Path),
+			Status:       status,
+			ErrorMsg:     errorMsg,
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.GetHeader("User-Agent"),
+			APITokenID:   apiTokenID,
+		})
+		// 访问令牌请求已在这里记录，认证中间件不再重复记录
+		c.Set("audit_recorded", true)
+	}
+}
+
+// mutationAction 根据请求方法和路由推断操作类型
+// POST 到集合路径为创建，POST 到具体资源的子操作（取消、同步、执行等）为更新
+func mutationAction(method string, segments []string) model.AuditAction {
+	switch method {
+	case http.MethodDelete:
+		return model.ActionDelete
+	case http.MethodPut, http.MethodPatch:
+		return model.ActionUpdate
+	}
+
+	last := segments[len(segments)-1]
+	switch {
+	case last == "test":
+		return model.ActionTest
+	case strings.Contains(last, "delete"):
+		return model.ActionDelete
+	}
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			return model.ActionUpdate
+		}
+	}
+	return model.ActionCreate
+}
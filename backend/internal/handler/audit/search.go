@@ -0,0 +1,282 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportRow 导出的审计日志，在导出记录的基础上补充用户名和集群名称
+type exportRow struct {
+	audit.ExportEntry
+	Username    string `json:"username"`
+	ClusterName string `json:"cluster_name,omitempty"`
+}
+
+var csvHeader = []string{
+	"id", "created_at", "user_id", "username", "cluster_name", "node_name", "action", "resource_type",
+	"status", "details", "reason", "error_msg", "ip_address", "user_agent", "api_token_id", "operation_id", "hash",
+}
+
+// parseListRequest 解析审计日志检索条件，多值条件用逗号分隔
+func parseListRequest(c *gin.Context) audit.ListRequest {
+	var req audit.ListRequest
+
+	if page, err := strconv.Atoi(c.Query("page")); err == nil {
+		req.Page = page
+	}
+	if pageSize, err := strconv.Atoi(c.Query("page_size")); err == nil {
+		req.PageSize = pageSize
+	}
+	if clusterID, err := strconv.ParseUint(c.Query("cluster_id"), 10, 32); err == nil {
+		req.ClusterID = uint(clusterID)
+	}
+	for _, item := range strings.Split(c.Query("user_id"), ",") {
+		if userID, err := strconv.ParseUint(strings.TrimSpace(item), 10, 32); err == nil {
+			req.UserIDs = append(req.UserIDs, uint(userID))
+		}
+	}
+	for _, item := range strings.Split(c.Query("action"), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			req.Actions = append(req.Actions, model.AuditAction(item))
+		}
+	}
+	for _, item := range strings.Split(c.Query("resource_type"), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			req.ResourceTypes = append(req.ResourceTypes, model.ResourceType(item))
+		}
+	}
+
+	req.Username = c.Query("username")
+	req.Status = model.AuditStatus(c.Query("status"))
+	req.NodeName = c.Query("node_name")
+	req.Keyword = c.Query("keyword")
+	req.StartDate = c.Query("start_date")
+	req.EndDate = c.Query("end_date")
+	return req
+}
+
+// restrictToCurrentUser 非管理员只能检索自己的审计日志，返回 false 时已写入错误响应
+func restrictToCurrentUser(c *gin.Context, req *audit.ListRequest) bool {
+	currentUserID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, Response{
+			Code:    http.StatusUnauthorized,
+			Message: "User not authenticated",
+		})
+		return false
+	}
+
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin {
+		req.UserID = currentUserID.(uint)
+	}
+	return true
+}
+
+// Stats 按天、用户、操作类型聚合审计日志
+// @Summary 审计日志聚合统计
+// @Description 按天、用户、操作类型聚合符合检索条件的审计日志，未指定时间范围时统计最近30天；检索参数与列表接口相同
+// @Tags audit
+// @Produce json
+// @Param start_date query string false "开始时间"
+// @Param end_date query string false "结束时间"
+// @Success 200 {object} Response
+// @Failure 500 {object} Response
+// @Router /audit/stats [get]
+func (h *Handler) Stats(c *gin.Context) {
+	req := parseListRequest(c)
+	if !restrictToCurrentUser(c, &req) {
+		return
+	}
+
+	stats, err := h.auditSvc.Stats(req)
+	if err != nil {
+		h.logger.Errorf("Failed to aggregate audit logs: %v", err)
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to aggregate audit logs: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    stats,
+	})
+}
+
+// Export 导出审计日志
+// @Summary 导出审计日志
+// @Description 按检索条件导出审计日志为 CSV 或 JSON，按时间倒序最多导出100000条，超出时响应头 X-Export-Truncated 为 true；检索参数与列表接口相同
+// @Tags audit
+// @Produce octet-stream
+// @Param format query string false "导出格式：csv（默认）或 json"
+// @Success 200 {file} file
+// @Failure 400 {object} Response
+// @Failure 500 {object} Response
+// @Router /audit/logs/export [get]
+func (h *Handler) Export(c *gin.Context) {
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "format must be csv or json",
+		})
+		return
+	}
+
+	req := parseListRequest(c)
+	if !restrictToCurrentUser(c, &req) {
+		return
+	}
+
+	total, truncated, err := h.auditSvc.CountForExport(req)
+	if err != nil {
+		h.logger.Errorf("Failed to count audit logs for export: %v", err)
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    http.StatusInternalServerError,
+			Message: "Failed to export audit logs: " + err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Header("X-Export-Total", strconv.FormatInt(total, 10))
+	c.Header("X-Export-Truncated", strconv.FormatBool(truncated))
+
+	var exported int
+	if format == "csv" {
+		exported, err = h.exportCSV(c, req)
+	} else {
+		exported, err = h.exportJSON(c, req)
+	}
+	// 响应已经开始写入，出错时只能记录日志
+	if err != nil {
+		h.logger.Errorf("Failed to export audit logs: %v", err)
+	}
+
+	status := model.AuditStatusSuccess
+	errorMsg := ""
+	if err != nil {
+		status = model.AuditStatusFailed
+		errorMsg = err.Error()
+	}
+	h.auditSvc.Log(audit.LogRequest{
+		UserID:       c.GetUint("user_id"),
+		Action:       model.ActionExport,
+		ResourceType: model.ResourceAuditLog,
+		Details:      fmt.Sprintf("Exported %d audit logs as %s, filters: %s", exported, format, c.Request.URL.RawQuery),
+		Status:       status,
+		ErrorMsg:     errorMsg,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.GetHeader("User-Agent"),
+	})
+}
+
+func (h *Handler) exportCSV(c *gin.Context, req audit.ListRequest) (int, error) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	// UTF-8 BOM，便于 Excel 正确识别中文
+	c.Writer.Write([]byte("\xEF\xBB\xBF"))
+
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(csvHeader); err != nil {
+		return 0, err
+	}
+	exported, err := h.auditSvc.Export(req, func(logs []model.AuditLog) error {
+		for i := range logs {
+			row := newExportRow(&logs[i])
+			apiTokenID := ""
+			if row.APITokenID != nil {
+				apiTokenID = strconv.FormatUint(uint64(*row.APITokenID), 10)
+			}
+			record := []string{
+				strconv.FormatUint(uint64(row.ID), 10),
+				row.CreatedAt.Format(time.RFC3339),
+				strconv.FormatUint(uint64(row.UserID), 10),
+				row.Username,
+				row.ClusterName,
+				row.NodeName,
+				string(row.Action),
+				string(row.ResourceType),
+				string(row.Status),
+				row.Details,
+				row.Reason,
+				row.ErrorMsg,
+				row.IPAddress,
+				row.UserAgent,
+				apiTokenID,
+				row.OperationID,
+				row.Hash,
+			}
+			for j := range record {
+				record[j] = csvSafe(record[j])
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+	w.Flush()
+	return exported, err
+}
+
+func (h *Handler) exportJSON(c *gin.Context, req audit.ListRequest) (int, error) {
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	first := true
+	c.Writer.Write([]byte("["))
+	exported, err := h.auditSvc.Export(req, func(logs []model.AuditLog) error {
+		for i := range logs {
+			data, err := json.Marshal(newExportRow(&logs[i]))
+			if err != nil {
+				return err
+			}
+			if !first {
+				c.Writer.Write([]byte(","))
+			}
+			first = false
+			if _, err := c.Writer.Write(data); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	c.Writer.Write([]byte("]"))
+	return exported, err
+}
+
+func newExportRow(log *model.AuditLog) exportRow {
+	row := exportRow{
+		ExportEntry: audit.NewExportEntry(log),
+		Username:    log.User.Username,
+	}
+	if log.Cluster != nil {
+		row.ClusterName = log.Cluster.Name
+	}
+	return row
+}
+
+// csvSafe 以 = + - @ 开头的单元格前加单引号，防止在表格软件中被当作公式执行
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
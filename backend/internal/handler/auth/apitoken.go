@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/auth"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiTokenBodyLimit 解析请求体中集群名称时读取的最大长度
+const apiTokenBodyLimit = 1 << 20
+
+// apiTokenForbiddenPrefixes 访问令牌不能访问的路径：令牌不能用来管理登录会话、签发新令牌或打开交互式终端
+var apiTokenForbiddenPrefixes = []string{"/api/v1/auth", "/api/v1/api-tokens", "/api/v1/sessions", "/api/v1/terminal"}
+
+// apiTokenReadOnlyPaths 只做分析、不修改集群的 POST 接口，只读令牌即可调用
+var apiTokenReadOnlyPaths = map[string]bool{
+	"/api/v1/nodes/drain-preflight":   true,
+	"/api/v1/nodes/schedule-simulate": true,
+	"/api/v1/labels/simulate":         true,
+	"/api/v1/taints/simulate":         true,
+}
+
+// apiTokenRequestAccess 返回请求所需的令牌访问级别
+func apiTokenRequestAccess(c *gin.Context) string {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || apiTokenReadOnlyPaths[c.Request.URL.Path] {
+		return model.TokenAccessRead
+	}
+	return model.TokenAccessWrite
+}
+
+// authenticateAPIToken 使用访问令牌认证请求，并检查权限范围和集群限制
+func (h *Handler) authenticateAPIToken(c *gin.Context, plain string) bool {
+	token, user, err := h.service.ValidateAPIToken(plain, c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
+		return false
+	}
+
+	path := c.Request.URL.Path
+	for _, prefix := range apiTokenForbiddenPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API tokens cannot access this endpoint"})
+			return false
+		}
+	}
+
+	resource := strings.SplitN(strings.TrimPrefix(path, "/api/v1/"), "/", 2)[0]
+	access := apiTokenRequestAccess(c)
+	if !token.AllowsScope(resource, access) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API token lacks scope %s:%s", resource, access)})
+		return false
+	}
+
+	if clusterName := requestClusterName(c); clusterName != "" && !token.AllowsCluster(clusterName) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("API token is not allowed to access cluster %s", clusterName)})
+		return false
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+	c.Set("user_role", user.Role)
+	c.Set("api_token_id", token.ID)
+	return true
+}
+
+// requestClusterName 从路径参数、查询参数或 JSON 请求体中获取请求操作的集群名称
+func requestClusterName(c *gin.Context) string {
+	if name := c.Param("cluster_name"); name != "" {
+		return name
+	}
+	if name := c.Query("cluster_name"); name != "" {
+		return name
+	}
+
+	if c.Request.Body == nil || !strings.Contains(c.GetHeader("Content-Type"), "application/json") {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, apiTokenBodyLimit))
+	if err != nil {
+		return ""
+	}
+	// 还原请求体供后续处理函数绑定
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	var payload struct {
+		ClusterName string `json:"cluster_name"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return payload.ClusterName
+}
+
+// auditAPITokenRequest 记录通过访问令牌发起的写操作，便于按令牌追溯
+func (h *Handler) auditAPITokenRequest(c *gin.Context) {
+	if apiTokenRequestAccess(c) == model.TokenAccessRead || c.GetBool("audit_recorded") {
+		return
+	}
+	h.service.LogAPITokenRequest(auth.APITokenRequestLog{
+		TokenID:    c.GetUint("api_token_id"),
+		UserID:     c.GetUint("user_id"),
+		Method:     c.Request.Method,
+		URI:        c.Request.URL.RequestURI(),
+		StatusCode: c.Writer.Status(),
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.GetHeader("User-Agent"),
+	})
+}
+
+// CreateAPIToken 创建访问令牌
+func (h *Handler) CreateAPIToken(c *gin.Context) {
+	var req auth.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userRole, _ := c.Get("user_role")
+	resp, err := h.service.CreateAPIToken(req, c.GetUint("user_id"), userRole.(model.UserRole))
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "only administrators") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"code":    201,
+		"message": "API token created, store it now - it will not be shown again",
+		"data":    resp,
+	})
+}
+
+// ListAPITokens 列出访问令牌
+func (h *Handler) ListAPITokens(c *gin.Context) {
+	var req auth.ListAPITokensRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userRole, _ := c.Get("user_role")
+	tokens, err := h.service.ListAPITokens(req, c.GetUint("user_id"), userRole.(model.UserRole))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    tokens,
+	})
+}
+
+// RevokeAPIToken 撤销访问令牌
+func (h *Handler) RevokeAPIToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	userRole, _ := c.Get("user_role")
+	if err := h.service.RevokeAPIToken(uint(id), c.GetUint("user_id"), userRole.(model.UserRole)); err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "API token revoked",
+	})
+}
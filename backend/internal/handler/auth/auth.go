@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/auth"
 	"kube-node-manager/pkg/logger"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -33,17 +35,181 @@ func (h *Handler) Login(c *gin.Context) {
 
 	resp, err := h.service.Login(req, clientIP, userAgent)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		h.respondLoginError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// Logout 退出当前会话
+// 优先使用 Authorization 头中的访问令牌定位会话，访问令牌缺失时使用请求体中的刷新令牌
 func (h *Handler) Logout(c *gin.Context) {
+	var req auth.LogoutRequest
+	_ = c.ShouldBindJSON(&req)
+
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" {
+		tokenString = req.RefreshToken
+	}
+
+	if tokenString != "" {
+		if err := h.service.LogoutByToken(tokenString, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+			h.logger.Warningf("Failed to revoke session on logout: %v", err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
+// LogoutAll 退出当前用户的全部会话
+func (h *Handler) LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	count, err := h.service.LogoutAll(userID.(uint), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.logger.Errorf("Failed to logout all sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Logged out of all sessions",
+		"data":    gin.H{"revoked": count},
+	})
+}
+
+// ListMySessions 列出当前用户的有效会话
+func (h *Handler) ListMySessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessions, err := h.service.ListSessions(auth.ListSessionsRequest{UserID: userID.(uint)}, c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    sessions,
+	})
+}
+
+// RevokeMySession 撤销当前用户的指定会话
+func (h *Handler) RevokeMySession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.service.RevokeSession(c.Param("session_id"), userID.(uint), userID.(uint)); err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Session revoked",
+	})
+}
+
+// ListSessions 管理员列出会话，可按用户过滤
+func (h *Handler) ListSessions(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	var req auth.ListSessionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessions, err := h.service.ListSessions(req, c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    sessions,
+	})
+}
+
+// RevokeSession 管理员撤销任意会话
+func (h *Handler) RevokeSession(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	if err := h.service.RevokeSession(c.Param("session_id"), 0, c.GetUint("user_id")); err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Session revoked",
+	})
+}
+
+// RevokeUserSessions 管理员强制指定用户的全部会话下线
+func (h *Handler) RevokeUserSessions(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	count, err := h.service.RevokeAllSessionsByAdmin(uint(targetID), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "User sessions revoked",
+		"data":    gin.H{"revoked": count},
+	})
+}
+
+// requireAdmin 检查管理员权限
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can manage sessions"})
+		return false
+	}
+	return true
+}
+
+// respondSessionError 根据错误类型返回相应的状态码
+func (h *Handler) respondSessionError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 func (h *Handler) RefreshToken(c *gin.Context) {
 	var req auth.RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -122,7 +288,7 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ChangePassword(userID.(uint), req); err != nil {
+	if err := h.service.ChangePassword(userID.(uint), c.GetString("session_id"), req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -177,9 +343,20 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			}
 		}
 
+		// 访问令牌（CI、脚本、服务账号）
+		if strings.HasPrefix(tokenString, model.APITokenPrefix) {
+			if !h.authenticateAPIToken(c, tokenString) {
+				c.Abort()
+				return
+			}
+			c.Next()
+			h.auditAPITokenRequest(c)
+			return
+		}
+
 		claims, err := h.service.ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
 			c.Abort()
 			return
 		}
@@ -193,6 +370,7 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
 		c.Next()
 	}
 }
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"errors"
+	"kube-node-manager/internal/service/auth"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondLoginError 登录失败时返回相应的状态码，锁定时附带 Retry-After
+func (h *Handler) respondLoginError(c *gin.Context, err error) {
+	var locked *auth.LoginLockedError
+	if errors.As(err, &locked) {
+		c.Header("Retry-After", strconv.Itoa(locked.RetryAfter()))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+// CompleteMFALogin 登录第二步：提交 TOTP 验证码或恢复码
+func (h *Handler) CompleteMFALogin(c *gin.Context) {
+	var req auth.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.CompleteMFALogin(req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		h.respondLoginError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetupMFAForLogin 登录过程中为必须启用 MFA 的用户生成密钥
+func (h *Handler) SetupMFAForLogin(c *gin.Context) {
+	var req auth.MFALoginSetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.SetupMFAForLogin(req)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    resp,
+	})
+}
+
+// GetMFAStatus 获取当前用户的 MFA 状态
+func (h *Handler) GetMFAStatus(c *gin.Context) {
+	status, err := h.service.GetMFAStatus(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    status,
+	})
+}
+
+// SetupMFA 为当前用户生成 TOTP 密钥
+func (h *Handler) SetupMFA(c *gin.Context) {
+	resp, err := h.service.SetupMFA(c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    resp,
+	})
+}
+
+// EnableMFA 校验验证码后启用 MFA
+func (h *Handler) EnableMFA(c *gin.Context) {
+	var req auth.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.EnableMFA(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "MFA enabled, store the recovery codes now - they will not be shown again",
+		"data":    gin.H{"recovery_codes": codes},
+	})
+}
+
+// DisableMFA 关闭当前用户的 MFA
+func (h *Handler) DisableMFA(c *gin.Context) {
+	var req auth.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.DisableMFA(c.GetUint("user_id"), req.Code); err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "required for role") {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "MFA disabled",
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req auth.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.GetUint("user_id"), req.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Recovery codes regenerated",
+		"data":    gin.H{"recovery_codes": codes},
+	})
+}
+
+// ResetUserMFA 管理员重置指定用户的 MFA
+func (h *Handler) ResetUserMFA(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.ResetMFA(uint(targetID), c.GetUint("user_id"), c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "MFA reset, the user must enroll again on next login",
+	})
+}
+
+// UnlockUser 管理员解除指定用户的登录锁定
+func (h *Handler) UnlockUser(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.UnlockUser(uint(targetID), c.GetUint("user_id")); err != nil {
+		h.respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "User unlocked",
+	})
+}
@@ -0,0 +1,104 @@
+package capacity
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kube-node-manager/internal/service/capacity"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 容量规划处理器
+type Handler struct {
+	capacitySvc *capacity.Service
+	logger      *logger.Logger
+}
+
+// Response 通用响应结构
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NewHandler 创建容量规划处理器实例
+func NewHandler(capacitySvc *capacity.Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		capacitySvc: capacitySvc,
+		logger:      logger,
+	}
+}
+
+// GetReport 获取容量报告
+// @Summary 获取容量报告
+// @Description 按集群和节点池汇总 CPU、内存、GPU 和 Pod 的总量、已分配和使用量，评估失去 N 个最大节点后的冗余，并基于历史 requests 预测耗尽时间
+// @Tags capacity
+// @Produce json
+// @Param cluster_name query string true "集群名称"
+// @Param pool_label query string false "节点池标签键，默认使用配置的标签"
+// @Param loss_nodes query int false "假设失去的最大节点数，默认使用配置的值"
+// @Param trend_days query int false "趋势预测使用的历史天数，默认使用配置的值"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /capacity/report [get]
+func (h *Handler) GetReport(c *gin.Context) {
+	req := capacity.ReportRequest{
+		ClusterName: c.Query("cluster_name"),
+		PoolLabel:   c.Query("pool_label"),
+		LossNodes:   -1,
+	}
+	if req.ClusterName == "" {
+		h.badRequest(c, "cluster_name is required")
+		return
+	}
+
+	if value := c.Query("loss_nodes"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > 100 {
+			h.badRequest(c, "loss_nodes must be an integer between 0 and 100")
+			return
+		}
+		req.LossNodes = n
+	}
+	if value := c.Query("trend_days"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 365 {
+			h.badRequest(c, "trend_days must be an integer between 1 and 365")
+			return
+		}
+		req.TrendDays = n
+	}
+
+	report, err := h.capacitySvc.GetReport(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else {
+			h.logger.Errorf("Failed to build capacity report: %v", err)
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    report,
+	})
+}
+
+func (h *Handler) badRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    http.StatusBadRequest,
+		Message: message,
+	})
+}
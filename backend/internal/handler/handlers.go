@@ -5,15 +5,22 @@ import (
 	"kube-node-manager/internal/handler/anomaly"
 	"kube-node-manager/internal/handler/audit"
 	"kube-node-manager/internal/handler/auth"
+	"kube-node-manager/internal/handler/capacity"
 	"kube-node-manager/internal/handler/cluster"
 	"kube-node-manager/internal/handler/feishu"
 	"kube-node-manager/internal/handler/gitlab"
+	"kube-node-manager/internal/handler/keyrotation"
 	"kube-node-manager/internal/handler/label"
 	"kube-node-manager/internal/handler/node"
+	"kube-node-manager/internal/handler/nodechange"
+	"kube-node-manager/internal/handler/nodeevent"
+	"kube-node-manager/internal/handler/nodemetrics"
 	"kube-node-manager/internal/handler/progress"
+	"kube-node-manager/internal/handler/sshca"
 	"kube-node-manager/internal/handler/sshkey"
 	"kube-node-manager/internal/handler/taint"
 	"kube-node-manager/internal/handler/terminal"
+	"kube-node-manager/internal/handler/timeline"
 	"kube-node-manager/internal/handler/user"
 	"kube-node-manager/internal/handler/websocket"
 	"kube-node-manager/internal/service"
@@ -32,13 +39,22 @@ type Handlers struct {
 	Gitlab            *gitlab.Handler
 	Feishu            *feishu.Handler
 	Anomaly           *anomaly.Handler
+	NodeMetrics       *nodemetrics.Handler
+	NodeEvent         *nodeevent.Handler
+	Capacity          *capacity.Handler
+	Timeline          *timeline.Handler
+	NodeChange        *nodechange.Handler
 	WebSocket         *websocket.Handler
 	SSHKey            *sshkey.Handler
+	SSHCA             *sshca.Handler
+	KeyRotation       *keyrotation.Handler
 	Terminal          *terminal.Handler
 	Ansible           *ansibleHandler.Handler
 	AnsibleTemplate   *ansibleHandler.TemplateHandler
 	AnsibleInventory  *ansibleHandler.InventoryHandler
 	AnsibleSSHKey     *ansibleHandler.SSHKeyHandler
+	AnsibleSecret     *ansibleHandler.SecretHandler
+	AnsibleProject    *ansibleHandler.ProjectHandler
 	AnsibleSchedule   *ansibleHandler.ScheduleHandler
 	AnsibleFavorite   *ansibleHandler.FavoriteHandler
 	AnsibleEstimation    *ansibleHandler.EstimationHandler
@@ -65,13 +81,22 @@ func NewHandlers(services *service.Services, logger *logger.Logger) *Handlers {
 		Gitlab:           gitlab.NewHandler(services.Gitlab, logger),
 		Feishu:           feishu.NewHandler(services.Feishu, services.Audit, logger),
 		Anomaly:          anomaly.NewHandler(services.Anomaly, services.Anomaly.GetCleanupService(), logger),
+		NodeMetrics:      nodemetrics.NewHandler(services.NodeMetrics, logger),
+		NodeEvent:        nodeevent.NewHandler(services.NodeEvents, logger),
+		Capacity:         capacity.NewHandler(services.Capacity, logger),
+		Timeline:         timeline.NewHandler(services.Timeline, logger),
+		NodeChange:       nodechange.NewHandler(services.NodeChanges, logger),
 		WebSocket:        websocket.NewHandler(services.WSHub, logger),
 		SSHKey:           sshkey.NewHandler(services.SSHKey, logger),
-		Terminal:         terminal.NewHandler(services.Node, services.Audit, logger),
+		SSHCA:            sshca.NewHandler(services.SSHCA, logger),
+		KeyRotation:      keyrotation.NewHandler(services.KeyRotation, logger),
+		Terminal:         terminal.NewHandler(services.Node, services.K8s, services.Audit, services.Auth, logger),
 		Ansible:          ansibleMainHandler,
 		AnsibleTemplate:  ansibleHandler.NewTemplateHandler(services.Ansible.GetTemplateService(), logger),
 		AnsibleInventory: ansibleHandler.NewInventoryHandler(services.Ansible.GetInventoryService(), logger),
 		AnsibleSSHKey:    ansibleHandler.NewSSHKeyHandler(services.Ansible.GetSSHKeyService(), logger),
+		AnsibleSecret:    ansibleHandler.NewSecretHandler(services.Ansible.GetSecretService(), logger),
+		AnsibleProject:   ansibleHandler.NewProjectHandler(services.Ansible.GetProjectService(), logger),
 		AnsibleSchedule:   ansibleHandler.NewScheduleHandler(services.Ansible.GetScheduleService(), logger),
 		AnsibleFavorite:   ansibleHandler.NewFavoriteHandler(ansibleMainHandler),
 		AnsibleEstimation:    ansibleHandler.NewEstimationHandler(services.Ansible, logger),
@@ -0,0 +1,173 @@
+package keyrotation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/keyrotation"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler SSH 密钥轮换处理器
+type Handler struct {
+	service *keyrotation.Service
+	logger  *logger.Logger
+}
+
+// NewHandler 创建 SSH 密钥轮换处理器
+func NewHandler(service *keyrotation.Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// requireAdmin 检查管理员权限
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can rotate SSH keys"})
+		return false
+	}
+	return true
+}
+
+// RotateSystemKey 轮换系统 SSH 密钥
+// @Summary 轮换系统 SSH 密钥
+// @Description 生成新密钥并分发到所有引用该密钥的节点，验证通过后切换并删除旧公钥，任一节点失败则回滚
+// @Tags SSH Key Rotation
+// @Accept json
+// @Produce json
+// @Param id path int true "密钥ID"
+// @Param request body model.SSHKeyRotateRequest false "轮换参数"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ssh-keys/{id}/rotate [post]
+func (h *Handler) RotateSystemKey(c *gin.Context) {
+	h.rotate(c, model.SSHKeySourceSystem)
+}
+
+// RotateAnsibleKey 轮换 Ansible SSH 密钥
+// @Summary 轮换 Ansible SSH 密钥
+// @Description 生成新密钥并分发到所有引用该密钥的节点和清单主机，验证通过后切换并删除旧公钥，任一主机失败则回滚
+// @Tags SSH Key Rotation
+// @Accept json
+// @Produce json
+// @Param id path int true "密钥ID"
+// @Param request body model.SSHKeyRotateRequest false "轮换参数"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ansible/ssh-keys/{id}/rotate [post]
+func (h *Handler) RotateAnsibleKey(c *gin.Context) {
+	h.rotate(c, model.SSHKeySourceAnsible)
+}
+
+func (h *Handler) rotate(c *gin.Context, keySource string) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+
+	var req model.SSHKeyRotateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	rotation, err := h.service.Start(keySource, uint(id), req, c.GetUint("user_id"))
+	if err != nil {
+		h.logger.Errorf("Failed to start rotation of %s SSH key %d: %v", keySource, id, err)
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, keyrotation.ErrKeyNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, keyrotation.ErrRotationInProgress):
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Rotation started",
+		"data":    rotation,
+	})
+}
+
+// ListRotations 获取密钥轮换记录
+// @Summary 获取 SSH 密钥轮换记录
+// @Tags SSH Key Rotation
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param key_source query string false "密钥来源(system/ansible)"
+// @Param key_id query int false "密钥ID"
+// @Param status query string false "状态"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ssh-key-rotations [get]
+func (h *Handler) ListRotations(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	var req model.SSHKeyRotationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rotations, total, err := h.service.ListRotations(req)
+	if err != nil {
+		h.logger.Errorf("Failed to list SSH key rotations: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    rotations,
+		"total":   total,
+	})
+}
+
+// GetRotation 获取密钥轮换详情（包含每台主机的进度）
+// @Summary 获取 SSH 密钥轮换详情
+// @Tags SSH Key Rotation
+// @Produce json
+// @Param id path int true "轮换记录ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ssh-key-rotations/{id} [get]
+func (h *Handler) GetRotation(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+
+	rotation, err := h.service.GetRotation(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    rotation,
+	})
+}
@@ -1,10 +1,12 @@
 package label
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
 	"kube-node-manager/internal/service/label"
 	"kube-node-manager/pkg/logger"
 
@@ -399,6 +401,44 @@ func (h *Handler) ListTemplates(c *gin.Context) {
 	})
 }
 
+// SimulateImpact 模拟标签变更影响
+// @Summary 模拟标签变更影响
+// @Description 对一组节点模拟标签变更（dry-run），列出将被驱逐的 Pod 和失去全部可调度节点的工作负载
+// @Tags labels
+// @Accept json
+// @Produce json
+// @Param request body label.BatchUpdateRequest true "标签变更请求"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 500 {object} Response
+// @Router /labels/simulate [post]
+func (h *Handler) SimulateImpact(c *gin.Context) {
+	var req label.BatchUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	impact, err := h.labelSvc.SimulateImpact(req)
+	if err != nil {
+		h.logger.Errorf("Failed to simulate label change: %v", err)
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    impact,
+	})
+}
+
 // ApplyTemplate 应用标签模板
 // @Summary 应用标签模板
 // @Description 将标签模板应用到指定节点
@@ -441,6 +481,15 @@ func (h *Handler) ApplyTemplate(c *gin.Context) {
 	}
 
 	if err := h.labelSvc.ApplyTemplate(req, userID.(uint)); err != nil {
+		var impactErr *k8s.ImpactNotAcknowledgedError
+		if errors.As(err, &impactErr) {
+			c.JSON(http.StatusConflict, Response{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+				Data:    impactErr.Impact,
+			})
+			return
+		}
 		h.logger.Error("Failed to apply label template: %v", err)
 		c.JSON(http.StatusInternalServerError, Response{
 			Code:    http.StatusInternalServerError,
@@ -3,9 +3,11 @@ package node
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
 	"kube-node-manager/internal/service/node"
 	"kube-node-manager/pkg/logger"
 
@@ -421,6 +423,11 @@ func (h *Handler) BatchDrain(c *gin.Context) {
 		return
 	}
 
+	if req.DryRun {
+		h.respondDrainPreflight(c, node.DrainPreflightRequest{ClusterName: req.ClusterName, Nodes: req.Nodes}, userID.(uint))
+		return
+	}
+
 	results, err := h.nodeSvc.BatchDrain(req, userID.(uint))
 	if err != nil {
 		h.logger.Error("Failed to batch drain nodes: %v", err)
@@ -541,6 +548,64 @@ func (h *Handler) GetMetrics(c *gin.Context) {
 	})
 }
 
+// ListPods 获取节点上的 Pod 列表
+// @Summary 获取节点上的 Pod 列表
+// @Description 分页获取节点上的 Pod（Owner、QoS、阶段、重启次数、资源请求），并返回节点已分配资源和超卖比例
+// @Tags nodes
+// @Produce json
+// @Param node_name path string true "节点名称"
+// @Param cluster_name query string true "集群名称"
+// @Param namespace query string false "命名空间筛选"
+// @Param phase query string false "阶段筛选"
+// @Param search query string false "按 Pod 或 Owner 名称搜索"
+// @Param include_terminated query bool false "是否包含已终止的 Pod"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 500 {object} Response
+// @Router /nodes/{cluster_id}/{node_name}/pods [get]
+func (h *Handler) ListPods(c *gin.Context) {
+	clusterName := c.Query("cluster_name")
+	nodeName := c.Param("node_name")
+
+	if clusterName == "" {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "cluster_name is required",
+		})
+		return
+	}
+
+	var req k8s.NodePodsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.nodeSvc.ListPods(clusterName, nodeName, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: "Failed to list pods: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    result,
+	})
+}
+
 // BatchCordon 批量禁止调度节点
 // @Summary 批量禁止调度节点
 // @Description 批量标记节点为不可调度
@@ -1270,8 +1335,20 @@ func (h *Handler) BatchDrainWithProgress(c *gin.Context) {
 	}
 	req.ClusterName = clusterName
 
+	if req.DryRun {
+		h.respondDrainPreflight(c, node.DrainPreflightRequest{ClusterName: req.ClusterName, Nodes: req.Nodes}, userID.(uint))
+		return
+	}
+
+	// 任务启动前做一次预检，随任务ID一起返回，预检失败不影响驱逐
+	data := map[string]interface{}{}
+	if analysis, err := h.nodeSvc.DrainPreflight(node.DrainPreflightRequest{ClusterName: req.ClusterName, Nodes: req.Nodes}, userID.(uint)); err == nil {
+		data["preflight"] = analysis
+	}
+
 	// 生成任务ID
 	taskID := fmt.Sprintf("node_drain_batch_%d_%d", userID.(uint), time.Now().UnixNano())
+	data["task_id"] = taskID
 
 	// 启动异步批量操作
 	go func() {
@@ -1283,8 +1360,134 @@ func (h *Handler) BatchDrainWithProgress(c *gin.Context) {
 	c.JSON(http.StatusOK, Response{
 		Code:    http.StatusOK,
 		Message: "批量驱逐任务已启动",
-		Data: map[string]string{
-			"task_id": taskID,
-		},
+		Data:    data,
+	})
+}
+
+// DrainPreflight 驱逐预检
+// @Summary 驱逐预检
+// @Description 分析驱逐节点的影响（dry-run）：将被驱逐、被 PDB 阻塞、使用本地存储、没有控制器以及其他节点容量不足的 Pod
+// @Tags nodes
+// @Accept json
+// @Produce json
+// @Param request body node.DrainPreflightRequest true "预检请求"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 500 {object} Response
+// @Router /nodes/drain-preflight [post]
+func (h *Handler) DrainPreflight(c *gin.Context) {
+	var req node.DrainPreflightRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, Response{
+			Code:    http.StatusUnauthorized,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	h.respondDrainPreflight(c, req, userID.(uint))
+}
+
+// SimulateScheduling 调度模拟
+// @Summary 调度模拟
+// @Description 评估 Pod 规格或已有工作负载可以调度到哪些节点，返回每个节点满足或不满足的原因（标签、污点、可分配资源、已分配 requests、禁止调度状态）
+// @Tags nodes
+// @Accept json
+// @Produce json
+// @Param request body node.ScheduleSimulateRequest true "调度模拟请求"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /nodes/schedule-simulate [post]
+func (h *Handler) SimulateScheduling(c *gin.Context) {
+	var req node.ScheduleSimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	if (req.PodSpec == nil) == (req.Workload == nil) {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Exactly one of pod_spec and workload must be specified",
+		})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, Response{
+			Code:    http.StatusUnauthorized,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	sim, err := h.nodeSvc.SimulateScheduling(req, userID.(uint))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.Contains(err.Error(), "unsupported workload kind"):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Scheduling simulation completed",
+		Data:    sim,
+	})
+}
+
+// respondDrainPreflight 执行驱逐预检并返回结果
+func (h *Handler) respondDrainPreflight(c *gin.Context, req node.DrainPreflightRequest, userID uint) {
+	if req.ClusterName == "" {
+		req.ClusterName = c.Query("cluster_name")
+	}
+	if req.ClusterName == "" {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "cluster_name is required",
+		})
+		return
+	}
+
+	analysis, err := h.nodeSvc.DrainPreflight(req, userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Drain preflight completed",
+		Data:    analysis,
 	})
 }
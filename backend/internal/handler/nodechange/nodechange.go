@@ -0,0 +1,194 @@
+package nodechange
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/nodechange"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 节点变更记录处理器
+type Handler struct {
+	changeSvc *nodechange.Service
+	logger    *logger.Logger
+}
+
+// Response 通用响应结构
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NewHandler 创建节点变更记录处理器实例
+func NewHandler(changeSvc *nodechange.Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		changeSvc: changeSvc,
+		logger:    logger,
+	}
+}
+
+// GetOperation 获取操作的结构化变更详情
+// @Summary 获取操作变更详情
+// @Description 获取一次标签、污点或调度状态操作在每个节点上的变更前后快照
+// @Tags audit
+// @Produce json
+// @Param operation_id path string true "操作ID（审计日志中的 operation_id）"
+// @Success 200 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /audit/changes/{operation_id} [get]
+func (h *Handler) GetOperation(c *gin.Context) {
+	operationID := c.Param("operation_id")
+
+	op, err := h.changeSvc.GetOperation(operationID)
+	if err != nil {
+		if errors.Is(err, nodechange.ErrOperationNotFound) {
+			c.JSON(http.StatusNotFound, Response{
+				Code:    http.StatusNotFound,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Failed to get operation %s: %v", operationID, err)
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    op,
+	})
+}
+
+// Undo 撤销操作，将涉及的所有节点恢复到操作前的状态
+// @Summary 撤销操作
+// @Description 恢复操作修改过的标签、污点和调度状态；任一节点在操作之后被修改过时拒绝撤销并返回偏离的节点
+// @Tags audit
+// @Produce json
+// @Param operation_id path string true "操作ID（审计日志中的 operation_id）"
+// @Success 200 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 500 {object} Response
+// @Router /audit/changes/{operation_id}/undo [post]
+func (h *Handler) Undo(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, Response{
+			Code:    http.StatusUnauthorized,
+			Message: "User not authenticated",
+		})
+		return
+	}
+
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin && userRole != model.RoleUser {
+		c.JSON(http.StatusForbidden, Response{
+			Code:    http.StatusForbidden,
+			Message: "Insufficient permissions. Only admin and user roles can undo node operations",
+		})
+		return
+	}
+
+	operationID := c.Param("operation_id")
+	result, err := h.changeSvc.Undo(operationID, userID.(uint))
+	if err != nil {
+		var driftErr *nodechange.DriftError
+		switch {
+		case errors.As(err, &driftErr):
+			c.JSON(http.StatusConflict, Response{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+				Data:    driftErr.Nodes,
+			})
+		case errors.Is(err, nodechange.ErrOperationNotFound):
+			c.JSON(http.StatusNotFound, Response{
+				Code:    http.StatusNotFound,
+				Message: err.Error(),
+			})
+		case errors.Is(err, nodechange.ErrAlreadyUndone):
+			c.JSON(http.StatusConflict, Response{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			})
+		default:
+			h.logger.Errorf("Failed to undo operation %s: %v", operationID, err)
+			c.JSON(http.StatusInternalServerError, Response{
+				Code:    http.StatusInternalServerError,
+				Message: err.Error(),
+				Data:    result,
+			})
+		}
+		return
+	}
+
+	message := "Operation undone successfully"
+	if len(result.Failed) > 0 {
+		message = "Operation partially undone"
+	}
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    result,
+	})
+}
+
+// ListNodeChanges 查询节点最近的标签、污点和调度状态变更
+// @Summary 查询节点变更记录
+// @Description 查询节点最近的结构化变更记录，按时间倒序
+// @Tags nodes
+// @Produce json
+// @Param cluster_id path string true "集群ID（兼容路由，实际使用 cluster_name）"
+// @Param node_name path string true "节点名称"
+// @Param cluster_name query string true "集群名称"
+// @Param limit query int false "返回条数，默认100，最大500"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /nodes/{cluster_id}/{node_name}/changes [get]
+func (h *Handler) ListNodeChanges(c *gin.Context) {
+	clusterName := c.Query("cluster_name")
+	nodeName := c.Param("node_name")
+	if clusterName == "" {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "cluster_name is required",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	changes, err := h.changeSvc.ListNodeChanges(clusterName, nodeName, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else {
+			h.logger.Errorf("Failed to list changes for node %s: %v", nodeName, err)
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    changes,
+	})
+}
@@ -0,0 +1,117 @@
+package nodeevent
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/service/nodeevent"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 节点事件处理器
+type Handler struct {
+	nodeEventSvc *nodeevent.Service
+	logger       *logger.Logger
+}
+
+// Response 通用响应结构
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NewHandler 创建节点事件处理器实例
+func NewHandler(nodeEventSvc *nodeevent.Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		nodeEventSvc: nodeEventSvc,
+		logger:       logger,
+	}
+}
+
+// List 查询节点的历史 Kubernetes Event
+// @Summary 查询节点事件历史
+// @Description 查询已持久化的节点 Kubernetes Event（OOM、镜像回收失败、重启等），按最后发生时间倒序
+// @Tags nodes
+// @Produce json
+// @Param cluster_id path string true "集群ID（兼容路由，实际使用 cluster_name）"
+// @Param node_name path string true "节点名称"
+// @Param cluster_name query string true "集群名称"
+// @Param type query string false "事件类型 (Normal|Warning)"
+// @Param reason query string false "事件原因，如 Rebooted"
+// @Param start_time query string false "开始时间 (RFC3339格式)"
+// @Param end_time query string false "结束时间 (RFC3339格式)"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大500"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /nodes/{cluster_id}/{node_name}/events [get]
+func (h *Handler) List(c *gin.Context) {
+	req := nodeevent.ListRequest{
+		ClusterName: c.Query("cluster_name"),
+		NodeName:    c.Param("node_name"),
+		Type:        c.Query("type"),
+		Reason:      c.Query("reason"),
+	}
+	if req.ClusterName == "" {
+		h.badRequest(c, "cluster_name is required")
+		return
+	}
+	if req.Type != "" && req.Type != "Normal" && req.Type != "Warning" {
+		h.badRequest(c, "type must be Normal or Warning")
+		return
+	}
+
+	if value := c.Query("start_time"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.badRequest(c, "Invalid start_time, expected RFC3339 format")
+			return
+		}
+		req.StartTime = &t
+	}
+	if value := c.Query("end_time"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.badRequest(c, "Invalid end_time, expected RFC3339 format")
+			return
+		}
+		req.EndTime = &t
+	}
+	req.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	req.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	result, err := h.nodeEventSvc.List(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else {
+			h.logger.Errorf("Failed to list events for node %s: %v", req.NodeName, err)
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    result,
+	})
+}
+
+func (h *Handler) badRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    http.StatusBadRequest,
+		Message: message,
+	})
+}
@@ -0,0 +1,147 @@
+package nodemetrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/service/nodemetrics"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultQueryRange 未指定开始时间时查询的时长
+const defaultQueryRange = time.Hour
+
+// Handler 节点资源指标历史处理器
+type Handler struct {
+	metricsSvc *nodemetrics.Service
+	logger     *logger.Logger
+}
+
+// Response 通用响应结构
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NewHandler 创建节点资源指标历史处理器实例
+func NewHandler(metricsSvc *nodemetrics.Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		metricsSvc: metricsSvc,
+		logger:     logger,
+	}
+}
+
+// Query 查询节点资源使用历史
+// @Summary 查询节点资源使用历史
+// @Description 按时间范围、步长和聚合方式查询节点 CPU、内存和 Pod 数量的历史趋势
+// @Tags metrics
+// @Produce json
+// @Param cluster_name query string true "集群名称"
+// @Param nodes query string false "节点名称，多个用逗号分隔，默认全部节点"
+// @Param start query string false "开始时间 (RFC3339格式)，默认结束时间前1小时"
+// @Param end query string false "结束时间 (RFC3339格式)，默认当前时间"
+// @Param step query string false "步长，秒数或时长（如 5m），默认按时间范围自动选择"
+// @Param aggregation query string false "聚合方式 (avg|max|min)，默认avg"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /metrics/nodes [get]
+func (h *Handler) Query(c *gin.Context) {
+	req := nodemetrics.QueryRequest{
+		ClusterName: c.Query("cluster_name"),
+		Aggregation: c.Query("aggregation"),
+		End:         time.Now(),
+	}
+	if req.ClusterName == "" {
+		h.badRequest(c, "cluster_name is required")
+		return
+	}
+
+	if nodes := c.Query("nodes"); nodes != "" {
+		for _, name := range strings.Split(nodes, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				req.NodeNames = append(req.NodeNames, name)
+			}
+		}
+	}
+
+	if endStr := c.Query("end"); endStr != "" {
+		end, err := time.Parse(time.RFC3339, endStr)
+		if err != nil {
+			h.badRequest(c, "Invalid end time, expected RFC3339 format")
+			return
+		}
+		req.End = end
+	}
+	req.Start = req.End.Add(-defaultQueryRange)
+	if startStr := c.Query("start"); startStr != "" {
+		start, err := time.Parse(time.RFC3339, startStr)
+		if err != nil {
+			h.badRequest(c, "Invalid start time, expected RFC3339 format")
+			return
+		}
+		req.Start = start
+	}
+
+	if stepStr := c.Query("step"); stepStr != "" {
+		step, err := parseStep(stepStr)
+		if err != nil {
+			h.badRequest(c, err.Error())
+			return
+		}
+		req.Step = step
+	}
+
+	result, err := h.metricsSvc.Query(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.Contains(err.Error(), "must be") || strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "required"):
+			status = http.StatusBadRequest
+		default:
+			h.logger.Errorf("Failed to query node metrics: %v", err)
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    result,
+	})
+}
+
+// parseStep 解析步长：纯数字按秒处理，否则按时长格式（如 30s、5m、1h）解析
+func parseStep(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, fmt.Errorf("step must be positive")
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	step, err := time.ParseDuration(value)
+	if err != nil || step <= 0 {
+		return 0, fmt.Errorf("invalid step %q, expected seconds or a duration such as 5m", value)
+	}
+	return step, nil
+}
+
+func (h *Handler) badRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    http.StatusBadRequest,
+		Message: message,
+	})
+}
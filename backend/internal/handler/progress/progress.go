@@ -1,6 +1,9 @@
 package progress
 
 import (
+	"net/http"
+	"strings"
+
 	"kube-node-manager/internal/service/progress"
 	"kube-node-manager/pkg/logger"
 
@@ -25,3 +28,76 @@ func NewHandler(progressSvc *progress.Service, logger *logger.Logger) *Handler {
 func (h *Handler) HandleWebSocket(c *gin.Context) {
 	h.progressSvc.HandleWebSocket(c)
 }
+
+// GetTask 获取批量任务的执行报告（包括未执行的节点）
+func (h *Handler) GetTask(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	report, err := h.progressSvc.GetTaskReport(c.Param("task_id"), userID.(uint))
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "success",
+		"data":    report,
+	})
+}
+
+// CancelTask 取消运行中的批量任务
+func (h *Handler) CancelTask(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	taskID := c.Param("task_id")
+	if err := h.progressSvc.CancelTask(taskID, userID.(uint)); err != nil {
+		h.logger.Warningf("Failed to cancel task %s: %v", taskID, err)
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Cancel requested, nodes not yet started will be skipped",
+	})
+}
+
+// ResumeTask 继续处理已取消或已中断任务的剩余节点
+func (h *Handler) ResumeTask(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	taskID := c.Param("task_id")
+	if err := h.progressSvc.ResumeTask(taskID, userID.(uint)); err != nil {
+		h.logger.Warningf("Failed to resume task %s: %v", taskID, err)
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Task resumed",
+		"data":    gin.H{"task_id": taskID},
+	})
+}
+
+// respondError 根据错误类型返回相应的状态码
+func (h *Handler) respondError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
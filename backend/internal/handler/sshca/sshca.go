@@ -0,0 +1,129 @@
+package sshca
+
+import (
+	"errors"
+	"net/http"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/sshca"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler SSH 证书颁发机构处理器
+type Handler struct {
+	service *sshca.Service
+	logger  *logger.Logger
+}
+
+// NewHandler 创建 SSH 证书颁发机构处理器
+func NewHandler(service *sshca.Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// requireAdmin 检查管理员权限
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only administrators can manage SSH CA"})
+		return false
+	}
+	return true
+}
+
+// GetCA 获取 CA 公钥和签发策略
+// @Summary 获取 SSH CA 信息
+// @Description 返回 CA 公钥、指纹、证书有效期和 principals 配置，启用时如果 CA 不存在会自动生成
+// @Tags SSH CA
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ssh-ca [get]
+func (h *Handler) GetCA(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	info, err := h.service.GetCAInfo()
+	if err != nil {
+		h.logger.Errorf("Failed to get SSH CA: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    info,
+	})
+}
+
+// ListCertificates 获取证书签发记录
+// @Summary 获取 SSH 证书签发记录
+// @Tags SSH CA
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param purpose query string false "用途(terminal/sftp/ansible)"
+// @Param user_id query int false "用户ID"
+// @Param keyword query string false "Key ID 或目标关键字"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ssh-ca/certificates [get]
+func (h *Handler) ListCertificates(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	var req model.SSHCertificateListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	certs, total, err := h.service.ListCertificates(req)
+	if err != nil {
+		h.logger.Errorf("Failed to list SSH certificates: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Success",
+		"data":    certs,
+		"total":   total,
+	})
+}
+
+// InstallTrustTemplate 安装节点信任 CA 的 Ansible 模板
+// @Summary 安装节点信任 SSH CA 模板
+// @Description 创建或更新内置模板，模板包含当前 CA 公钥和节点 principals 配置，在目标清单上执行后节点即信任平台签发的证书
+// @Tags SSH CA
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/ssh-ca/trust-template [post]
+func (h *Handler) InstallTrustTemplate(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	template, err := h.service.InstallTrustTemplate(c.GetUint("user_id"))
+	if err != nil {
+		h.logger.Errorf("Failed to install SSH CA trust template: %v", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, sshca.ErrCADisabled) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "Trust template installed successfully",
+		"data":    template,
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/internal/service/nodechange"
 	"kube-node-manager/internal/service/taint"
 
 	"github.com/gin-gonic/gin"
@@ -64,13 +65,15 @@ func (h *Handler) BatchAddTaints(c *gin.Context) {
 		}
 	}
 
-	// 为每个节点批量添加污点
+	// 为每个节点批量添加污点，所有节点归属同一个操作以便整体撤销
+	operationID := nodechange.NewOperationID(userID.(uint))
 	for _, nodeName := range req.Nodes {
 		for _, taintData := range req.Taints {
 			// 构建污点数据
 			var taintReq taint.UpdateTaintsRequest
 			taintReq.ClusterName = clusterName
 			taintReq.NodeName = nodeName
+			taintReq.OperationID = operationID
 
 			// 解析污点数据
 			if key, ok := taintData["key"].(string); ok {
@@ -149,12 +152,14 @@ func (h *Handler) BatchDeleteTaints(c *gin.Context) {
 		return
 	}
 
-	// 为每个节点批量删除污点
+	// 为每个节点批量删除污点，所有节点归属同一个操作以便整体撤销
+	operationID := nodechange.NewOperationID(userID.(uint))
 	for _, nodeName := range req.Nodes {
 		// 构建删除污点的请求
 		var taintReq taint.UpdateTaintsRequest
 		taintReq.ClusterName = clusterName
 		taintReq.NodeName = nodeName
+		taintReq.OperationID = operationID
 		taintReq.Operation = "remove"
 
 		// 构建要删除的污点键
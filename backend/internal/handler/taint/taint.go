@@ -1,10 +1,12 @@
 package taint
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
 	"kube-node-manager/internal/service/taint"
 	"kube-node-manager/pkg/logger"
 
@@ -548,6 +550,44 @@ func (h *Handler) GetTemplate(c *gin.Context) {
 	})
 }
 
+// SimulateImpact 模拟污点变更影响
+// @Summary 模拟污点变更影响
+// @Description 对一组节点模拟污点变更（dry-run），列出将被驱逐的 Pod 和失去全部可调度节点的工作负载
+// @Tags taints
+// @Accept json
+// @Produce json
+// @Param request body taint.BatchUpdateRequest true "污点变更请求"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 500 {object} Response
+// @Router /taints/simulate [post]
+func (h *Handler) SimulateImpact(c *gin.Context) {
+	var req taint.BatchUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid request parameters: " + err.Error(),
+		})
+		return
+	}
+
+	impact, err := h.taintSvc.SimulateImpact(req)
+	if err != nil {
+		h.logger.Errorf("Failed to simulate taint change: %v", err)
+		c.JSON(http.StatusInternalServerError, Response{
+			Code:    http.StatusInternalServerError,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    impact,
+	})
+}
+
 // ApplyTemplate 应用污点模板
 // @Summary 应用污点模板
 // @Description 将污点模板应用到指定节点
@@ -590,6 +630,15 @@ func (h *Handler) ApplyTemplate(c *gin.Context) {
 	}
 
 	if err := h.taintSvc.ApplyTemplate(req, userID.(uint)); err != nil {
+		var impactErr *k8s.ImpactNotAcknowledgedError
+		if errors.As(err, &impactErr) {
+			c.JSON(http.StatusConflict, Response{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+				Data:    impactErr.Impact,
+			})
+			return
+		}
 		h.logger.Errorf("Failed to apply taint template: %v", err)
 		c.JSON(http.StatusInternalServerError, Response{
 			Code:    http.StatusInternalServerError,
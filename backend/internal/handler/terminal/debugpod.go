@@ -0,0 +1,157 @@
+package terminal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// modeDebugPod 通过特权调试 Pod 打开节点终端，用于没有 SSH 的节点
+const modeDebugPod = "debug"
+
+// sizeQueue 把前端的 resize 消息传给 exec 流
+type sizeQueue struct {
+	ch   chan remotecommand.TerminalSize
+	done chan struct{}
+}
+
+func newSizeQueue() *sizeQueue {
+	return &sizeQueue{
+		ch:   make(chan remotecommand.TerminalSize, 1),
+		done: make(chan struct{}),
+	}
+}
+
+// Next 返回 nil 表示会话结束
+func (q *sizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-q.ch:
+		return &size
+	case <-q.done:
+		return nil
+	}
+}
+
+// push 只保留最新的尺寸，不阻塞读取 WebSocket
+func (q *sizeQueue) push(cols, rows int) {
+	if cols <= 0 || rows <= 0 {
+		return
+	}
+	size := remotecommand.TerminalSize{Width: uint16(cols), Height: uint16(rows)}
+	select {
+	case <-q.ch:
+	default:
+	}
+	q.ch <- size
+}
+
+// serveDebugPod 在节点上创建特权调试 Pod，通过 Kubernetes exec 接口接入终端，会话结束后删除 Pod
+func (h *Handler) serveDebugPod(c *gin.Context, out *wsWriter, clusterName, nodeName string, userID uint) {
+	ws := out.ws
+	if !h.k8sSvc.DebugPodEnabled() {
+		out.info("[ERROR] 调试 Pod 终端未启用，请联系管理员开启 terminal.debug_pod.enabled")
+		return
+	}
+
+	clusterID, _ := h.auditSvc.GetClusterIDByName(clusterName)
+	logSession := func(details string, status model.AuditStatus, errorMsg string) {
+		h.auditSvc.Log(audit.LogRequest{
+			UserID:       userID,
+			ClusterID:    &clusterID,
+			NodeName:     nodeName,
+			Action:       model.ActionConnect,
+			ResourceType: model.ResourceNode,
+			Details:      details,
+			Status:       status,
+			ErrorMsg:     errorMsg,
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.GetHeader("User-Agent"),
+		})
+	}
+
+	out.info("[INFO] 正在节点 %s 上创建调试 Pod...", nodeName)
+	pod, err := h.k8sSvc.CreateNodeDebugPod(c.Request.Context(), clusterName, nodeName, c.GetString("username"))
+	if err != nil {
+		h.logger.Errorf("Failed to create debug pod on node %s: %v", nodeName, err)
+		out.info("[ERROR] 创建调试 Pod 失败: %v", err)
+		logSession(fmt.Sprintf("Failed to start debug pod terminal session to node %s", nodeName), model.AuditStatusFailed, err.Error())
+		return
+	}
+	// 请求上下文在连接断开后会被取消，删除使用独立的上下文
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := h.k8sSvc.DeleteDebugPod(ctx, clusterName, pod); err != nil {
+			h.logger.Errorf("Failed to delete debug pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}()
+
+	out.info("[INFO] 调试 Pod %s/%s 已创建，等待启动...", pod.Namespace, pod.Name)
+	if err := h.k8sSvc.WaitForDebugPodRunning(c.Request.Context(), clusterName, pod); err != nil {
+		h.logger.Errorf("Debug pod %s/%s failed to start: %v", pod.Namespace, pod.Name, err)
+		out.info("[ERROR] 调试 Pod 启动失败: %v", err)
+		logSession(fmt.Sprintf("Failed to start debug pod terminal session to node %s (pod %s/%s)", nodeName, pod.Namespace, pod.Name),
+			model.AuditStatusFailed, err.Error())
+		return
+	}
+	out.info("[INFO] 已连接，宿主机根目录挂载在 /host，退出 shell 后调试 Pod 将被删除")
+
+	logSession(fmt.Sprintf("Started debug pod terminal session to node %s (pod %s/%s)", nodeName, pod.Namespace, pod.Name),
+		model.AuditStatusSuccess, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stdinReader, stdinWriter := io.Pipe()
+	sizes := newSizeQueue()
+	sizes.push(120, 40)
+
+	go func() {
+		if err := h.k8sSvc.ExecInDebugPod(ctx, clusterName, pod, stdinReader, out, sizes); err != nil && ctx.Err() == nil {
+			h.logger.Errorf("Debug pod exec stream for node %s ended with error: %v", nodeName, err)
+			out.info("[ERROR] 终端会话异常结束: %v", err)
+		}
+		// shell 退出后关闭输入和 WebSocket，结束下面的读取循环
+		stdinReader.Close()
+		ws.Close()
+	}()
+
+	// 登录会话被撤销（退出登录、强制下线等）时断开终端
+	done := make(chan struct{})
+	defer close(done)
+	go h.watchSession(ws, c.GetString("session_id"), userID, done)
+
+	// 读取 WebSocket -> exec 输入
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		var msg TerminalMessage
+		if err := json.Unmarshal(message, &msg); err == nil && msg.Type != "" {
+			switch msg.Type {
+			case "input":
+				stdinWriter.Write([]byte(msg.Data))
+			case "resize":
+				sizes.push(msg.Cols, msg.Rows)
+			}
+		} else {
+			stdinWriter.Write(message)
+		}
+	}
+
+	cancel()
+	close(sizes.done)
+	stdinWriter.Close()
+
+	logSession(fmt.Sprintf("Ended debug pod terminal session to node %s (pod %s/%s)", nodeName, pod.Namespace, pod.Name),
+		model.AuditStatusSuccess, "")
+}
@@ -0,0 +1,85 @@
+package terminal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsWriter 把终端输出写入 WebSocket，终端输出、提示信息和传输进度会并发写入，需要加锁
+type wsWriter struct {
+	mu sync.Mutex
+	ws *websocket.Conn
+}
+
+func (w *wsWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.ws.WriteMessage(websocket.TextMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *wsWriter) info(format string, args ...interface{}) {
+	w.Write([]byte("\r\n" + fmt.Sprintf(format, args...) + "\r\n"))
+}
+
+// control 发送控制消息（会话信息、文件传输进度等），使用二进制帧与终端输出的文本帧区分
+func (w *wsWriter) control(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ws.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// terminalRegistry 记录打开的终端 WebSocket，文件传输接口通过 terminal_id 把进度推送到对应终端
+type terminalRegistry struct {
+	mu        sync.RWMutex
+	terminals map[string]*registeredTerminal
+}
+
+type registeredTerminal struct {
+	userID uint
+	out    *wsWriter
+}
+
+func newTerminalRegistry() *terminalRegistry {
+	return &terminalRegistry{terminals: make(map[string]*registeredTerminal)}
+}
+
+// register 登记终端连接，返回 terminal_id
+func (r *terminalRegistry) register(userID uint, out *wsWriter) string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.terminals[id] = &registeredTerminal{userID: userID, out: out}
+	return id
+}
+
+func (r *terminalRegistry) unregister(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.terminals, id)
+}
+
+// lookup 查找用户自己的终端连接，不存在或不属于该用户时返回 nil
+func (r *terminalRegistry) lookup(id string, userID uint) *wsWriter {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.terminals[id]
+	if !ok || t.userID != userID {
+		return nil
+	}
+	return t.out
+}
@@ -0,0 +1,375 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"kube-node-manager/internal/service/node"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadFiles 单次上传的最大文件数
+const maxUploadFiles = 10
+
+// Response 通用响应结构
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// transferProgress 通过终端 WebSocket 推送的文件传输进度
+type transferProgress struct {
+	Type        string `json:"type"` // 固定为 sftp_progress
+	TransferID  string `json:"transfer_id"`
+	Direction   string `json:"direction"` // upload, download
+	Path        string `json:"path"`
+	Transferred int64  `json:"transferred"`
+	Total       int64  `json:"total"`
+	Status      string `json:"status"` // running, done, failed
+	Error       string `json:"error,omitempty"`
+}
+
+// uploadResult 单个文件的上传结果
+type uploadResult struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+}
+
+// fileTransfer 一次文件传输请求的上下文
+type fileTransfer struct {
+	h           *Handler
+	c           *gin.Context
+	clusterName string
+	nodeName    string
+	userID      uint
+	transferID  string    // 客户端指定的传输 ID，原样返回在进度消息中
+	out         *wsWriter // 进度推送的终端连接，未指定 terminal_id 时为 nil
+}
+
+// newFileTransfer 校验参数和权限（Admin only），失败时已写入错误响应
+func (h *Handler) newFileTransfer(c *gin.Context, clusterName, nodeName, terminalID, transferID string) (*fileTransfer, bool) {
+	if clusterName == "" || nodeName == "" {
+		c.JSON(http.StatusBadRequest, Response{Code: http.StatusBadRequest, Message: "cluster_name and node_name are required"})
+		return nil, false
+	}
+	userID := c.GetUint("user_id")
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, Response{Code: http.StatusUnauthorized, Message: "Unauthorized"})
+		return nil, false
+	}
+	if userRole, _ := c.Get("user_role"); userRole != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, Response{Code: http.StatusForbidden, Message: "Forbidden: Admin only"})
+		return nil, false
+	}
+
+	return &fileTransfer{
+		h:           h,
+		c:           c,
+		clusterName: clusterName,
+		nodeName:    nodeName,
+		userID:      userID,
+		transferID:  transferID,
+		out:         h.terminals.lookup(terminalID, userID),
+	}, true
+}
+
+// progress 返回推送单个文件传输进度的回调
+func (t *fileTransfer) progress(direction, filePath string) node.ProgressFunc {
+	if t.out == nil {
+		return nil
+	}
+	return func(transferred, total int64) {
+		t.out.control(transferProgress{
+			Type:        "sftp_progress",
+			TransferID:  t.transferID,
+			Direction:   direction,
+			Path:        filePath,
+			Transferred: transferred,
+			Total:       total,
+			Status:      "running",
+		})
+	}
+}
+
+// finish 推送传输结果并记录审计日志，每个文件一条
+func (t *fileTransfer) finish(action model.AuditAction, filePath string, size int64, err error) {
+	direction := "download"
+	details := fmt.Sprintf("Downloaded %s (%d bytes) from node %s", filePath, size, t.nodeName)
+	if action == model.ActionUpload {
+		direction = "upload"
+		details = fmt.Sprintf("Uploaded %s (%d bytes) to node %s", filePath, size, t.nodeName)
+	}
+
+	status := model.AuditStatusSuccess
+	errorMsg := ""
+	if err != nil {
+		status = model.AuditStatusFailed
+		errorMsg = err.Error()
+		details = fmt.Sprintf("Failed to %s %s on node %s", direction, filePath, t.nodeName)
+	}
+
+	if t.out != nil {
+		progress := transferProgress{
+			Type:        "sftp_progress",
+			TransferID:  t.transferID,
+			Direction:   direction,
+			Path:        filePath,
+			Transferred: size,
+			Total:       size,
+			Status:      "done",
+		}
+		if err != nil {
+			progress.Status = "failed"
+			progress.Error = errorMsg
+		}
+		t.out.control(progress)
+	}
+
+	clusterID, _ := t.h.auditSvc.GetClusterIDByName(t.clusterName)
+	t.h.auditSvc.Log(audit.LogRequest{
+		UserID:       t.userID,
+		ClusterID:    &clusterID,
+		NodeName:     t.nodeName,
+		Action:       action,
+		ResourceType: model.ResourceNodeFile,
+		Details:      details,
+		Status:       status,
+		ErrorMsg:     errorMsg,
+		IPAddress:    t.c.ClientIP(),
+		UserAgent:    t.c.GetHeader("User-Agent"),
+	})
+}
+
+// sftpStatus 根据错误类型返回 HTTP 状态码
+func sftpStatus(err error) int {
+	switch {
+	case errors.Is(err, node.ErrSFTPDisabled), errors.Is(err, node.ErrSFTPPathNotAllowed):
+		return http.StatusBadRequest
+	case errors.Is(err, node.ErrSFTPFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) sftpError(c *gin.Context, message string, err error) {
+	h.logger.Errorf("%s: %v", message, err)
+	status := sftpStatus(err)
+	c.JSON(status, Response{Code: status, Message: message + ": " + err.Error()})
+}
+
+// GetFileTransferConfig 获取文件传输限制
+// @Summary 获取节点文件传输限制
+// @Tags terminal
+// @Produce json
+// @Success 200 {object} Response
+// @Router /terminal/files/config [get]
+func (h *Handler) GetFileTransferConfig(c *gin.Context) {
+	config := h.nodeSvc.GetSFTPConfig()
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data: gin.H{
+			"enabled":           config.Enabled,
+			"max_upload_size":   config.MaxUploadSize,
+			"max_download_size": config.MaxDownloadSize,
+			"download_paths":    config.DownloadPaths,
+			"upload_paths":      config.UploadPaths,
+		},
+	})
+}
+
+// ListFiles 列出节点目录
+// @Summary 列出节点目录
+// @Description 通过 SFTP 列出节点上允许访问的目录内容（Admin only）
+// @Tags terminal
+// @Produce json
+// @Param cluster_name query string true "集群名称"
+// @Param node_name query string true "节点名称"
+// @Param path query string false "目录，默认为第一个允许下载的目录"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Router /terminal/files [get]
+func (h *Handler) ListFiles(c *gin.Context) {
+	transfer, ok := h.newFileTransfer(c, c.Query("cluster_name"), c.Query("node_name"), "", "")
+	if !ok {
+		return
+	}
+	dir := c.Query("path")
+	if dir == "" {
+		dir = h.nodeSvc.GetSFTPConfig().DownloadPaths[0]
+	}
+
+	sess, err := h.nodeSvc.OpenSFTP(c.Request.Context(), transfer.clusterName, transfer.nodeName, transfer.userID)
+	if err != nil {
+		h.sftpError(c, "Failed to open SFTP session", err)
+		return
+	}
+	defer sess.Close()
+
+	dir, files, err := sess.List(dir)
+	if err != nil {
+		h.sftpError(c, "Failed to list directory", err)
+		return
+	}
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    gin.H{"path": dir, "files": files},
+	})
+}
+
+// DownloadFile 从节点下载文件
+// @Summary 从节点下载文件
+// @Description 通过 SFTP 下载节点上允许访问的目录中的文件（Admin only），传入 terminal_id 时通过终端 WebSocket 推送进度
+// @Tags terminal
+// @Produce octet-stream
+// @Param cluster_name query string true "集群名称"
+// @Param node_name query string true "节点名称"
+// @Param path query string true "文件路径"
+// @Param terminal_id query string false "推送进度的终端 ID"
+// @Param transfer_id query string false "传输 ID，原样返回在进度消息中"
+// @Success 200 {file} file
+// @Failure 400 {object} Response
+// @Failure 413 {object} Response
+// @Router /terminal/files/download [get]
+func (h *Handler) DownloadFile(c *gin.Context) {
+	transfer, ok := h.newFileTransfer(c, c.Query("cluster_name"), c.Query("node_name"), c.Query("terminal_id"), c.Query("transfer_id"))
+	if !ok {
+		return
+	}
+	filePath := c.Query("path")
+	if filePath == "" {
+		c.JSON(http.StatusBadRequest, Response{Code: http.StatusBadRequest, Message: "path is required"})
+		return
+	}
+
+	sess, err := h.nodeSvc.OpenSFTP(c.Request.Context(), transfer.clusterName, transfer.nodeName, transfer.userID)
+	if err != nil {
+		h.sftpError(c, "Failed to open SFTP session", err)
+		return
+	}
+	defer sess.Close()
+
+	resolved, info, err := sess.Stat(filePath)
+	if err != nil {
+		transfer.finish(model.ActionDownload, filePath, 0, err)
+		h.sftpError(c, "Failed to download file", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(resolved)))
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(info.Size(), 10))
+	c.Status(http.StatusOK)
+
+	n, err := sess.Download(resolved, c.Writer, transfer.progress("download", resolved))
+	// 响应已经开始写入，出错时只能记录日志
+	if err != nil {
+		h.logger.Errorf("Failed to download %s from node %s: %v", resolved, transfer.nodeName, err)
+	}
+	transfer.finish(model.ActionDownload, resolved, n, err)
+}
+
+// UploadFile 上传文件到节点
+// @Summary 上传文件到节点
+// @Description 通过 SFTP 把文件上传到节点上允许上传的目录（Admin only），同名文件会被覆盖；每个文件单独记录审计日志
+// @Tags terminal
+// @Accept multipart/form-data
+// @Produce json
+// @Param cluster_name formData string true "集群名称"
+// @Param node_name formData string true "节点名称"
+// @Param path formData string true "目标目录"
+// @Param files formData file true "文件，可以有多个"
+// @Param terminal_id formData string false "推送进度的终端 ID"
+// @Param transfer_id formData string false "传输 ID，原样返回在进度消息中"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 413 {object} Response
+// @Router /terminal/files/upload [post]
+func (h *Handler) UploadFile(c *gin.Context) {
+	config := h.nodeSvc.GetSFTPConfig()
+	// 请求体上限为单文件上限乘以文件数，另加表单字段的余量
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxUploadSize*maxUploadFiles+(1<<20))
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		status := http.StatusBadRequest
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		c.JSON(status, Response{Code: status, Message: "Invalid upload request: " + err.Error()})
+		return
+	}
+
+	transfer, ok := h.newFileTransfer(c, c.PostForm("cluster_name"), c.PostForm("node_name"), c.PostForm("terminal_id"), c.PostForm("transfer_id"))
+	if !ok {
+		return
+	}
+	dir := c.PostForm("path")
+	files := form.File["files"]
+	if dir == "" || len(files) == 0 {
+		c.JSON(http.StatusBadRequest, Response{Code: http.StatusBadRequest, Message: "path and files are required"})
+		return
+	}
+	if len(files) > maxUploadFiles {
+		c.JSON(http.StatusBadRequest, Response{Code: http.StatusBadRequest, Message: fmt.Sprintf("at most %d files can be uploaded at once", maxUploadFiles)})
+		return
+	}
+
+	sess, err := h.nodeSvc.OpenSFTP(c.Request.Context(), transfer.clusterName, transfer.nodeName, transfer.userID)
+	if err != nil {
+		h.sftpError(c, "Failed to open SFTP session", err)
+		return
+	}
+	defer sess.Close()
+
+	results := make([]uploadResult, 0, len(files))
+	failed := 0
+	for _, header := range files {
+		// 浏览器可能带上客户端路径，只保留文件名
+		name := path.Base(strings.ReplaceAll(header.Filename, "\\", "/"))
+		result := uploadResult{Name: name, Path: path.Join(dir, name)}
+
+		err := func() error {
+			if name == "" || name == "." || name == ".." || name == "/" {
+				return fmt.Errorf("invalid file name %q", header.Filename)
+			}
+			file, err := header.Open()
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			result.Path, result.Size, err = sess.Upload(result.Path, file, header.Size, transfer.progress("upload", result.Path))
+			return err
+		}()
+		if err != nil {
+			h.logger.Errorf("Failed to upload %s to node %s: %v", result.Path, transfer.nodeName, err)
+			result.Error = err.Error()
+			failed++
+		}
+		transfer.finish(model.ActionUpload, result.Path, result.Size, err)
+		results = append(results, result)
+	}
+
+	message := "Success"
+	if failed > 0 {
+		message = fmt.Sprintf("%d of %d files failed to upload", failed, len(files))
+	}
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    results,
+	})
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/audit"
+	"kube-node-manager/internal/service/k8s"
 	"kube-node-manager/internal/service/node"
+	"kube-node-manager/internal/service/sshca"
 	"kube-node-manager/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -18,18 +21,32 @@ import (
 	"golang.org/x/crypto/ssh"
 )
 
+// sessionCheckInterval 终端会话期间检查登录会话是否被撤销的间隔
+const sessionCheckInterval = 30 * time.Second
+
+// SessionChecker 登录会话状态检查接口
+type SessionChecker interface {
+	IsSessionActive(sessionID string) bool
+}
+
 type Handler struct {
-	nodeSvc  *node.Service
-	auditSvc *audit.Service
-	logger   *logger.Logger
-	upgrader websocket.Upgrader
+	nodeSvc    *node.Service
+	k8sSvc     *k8s.Service
+	terminals  *terminalRegistry
+	auditSvc   *audit.Service
+	sessionSvc SessionChecker
+	logger     *logger.Logger
+	upgrader   websocket.Upgrader
 }
 
-func NewHandler(nodeSvc *node.Service, auditSvc *audit.Service, logger *logger.Logger) *Handler {
+func NewHandler(nodeSvc *node.Service, k8sSvc *k8s.Service, auditSvc *audit.Service, sessionSvc SessionChecker, logger *logger.Logger) *Handler {
 	return &Handler{
-		nodeSvc:  nodeSvc,
-		auditSvc: auditSvc,
-		logger:   logger,
+		nodeSvc:    nodeSvc,
+		k8sSvc:     k8sSvc,
+		terminals:  newTerminalRegistry(),
+		auditSvc:   auditSvc,
+		sessionSvc: sessionSvc,
+		logger:     logger,
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true // 允许跨域，生产环境应限制
@@ -83,9 +100,21 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 	}
 	defer ws.Close()
 
+	// 终端输出、提示信息和文件传输进度会并发写入 WebSocket，统一通过 out 加锁写入
+	out := &wsWriter{ws: ws}
+	terminalID := h.terminals.register(userID.(uint), out)
+	defer h.terminals.unregister(terminalID)
+	out.control(gin.H{"type": "session", "terminal_id": terminalID})
+
+	// mode=debug 时通过特权调试 Pod 接入，不需要 SSH
+	if c.Query("mode") == modeDebugPod {
+		h.serveDebugPod(c, out, clusterName, nodeName, userID.(uint))
+		return
+	}
+
 	// 4. 获取 SSH 配置 (带超时)
 	h.logger.Infof("Attempting to get SSH config for node %s in cluster %s", nodeName, clusterName)
-	ws.WriteMessage(websocket.TextMessage, []byte("\r\n[INFO] 正在获取节点SSH配置...\r\n"))
+	out.Write([]byte("\r\n[INFO] 正在获取节点SSH配置...\r\n"))
 	
 	// 创建带超时的context
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
@@ -96,56 +125,43 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			h.logger.Errorf("Timeout getting SSH config for node %s", nodeName)
-			ws.WriteMessage(websocket.TextMessage, []byte("\r\n[ERROR] 获取SSH配置超时（30秒）\r\n可能原因:\r\n1. Kubernetes API响应缓慢\r\n2. 网络连接问题\r\n3. 集群负载过高\r\n"))
+			out.Write([]byte("\r\n[ERROR] 获取SSH配置超时（30秒）\r\n可能原因:\r\n1. Kubernetes API响应缓慢\r\n2. 网络连接问题\r\n3. 集群负载过高\r\n"))
 		} else {
 			h.logger.Errorf("Failed to get SSH config: %v", err)
-			ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n[ERROR] 获取SSH配置失败: %v\r\n", err)))
+			out.Write([]byte(fmt.Sprintf("\r\n[ERROR] 获取SSH配置失败: %v\r\n", err)))
 		}
 		return
 	}
 	
 	h.logger.Infof("SSH config retrieved: host=%s, port=%d, user=%s, keyType=%s", 
 		host, sshKey.Port, sshKey.Username, sshKey.Type)
-	ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n[INFO] SSH配置已加载: %s:%d (用户: %s)\r\n", host, sshKey.Port, sshKey.Username)))
+	out.Write([]byte(fmt.Sprintf("\r\n[INFO] SSH配置已加载: %s:%d (用户: %s)\r\n", host, sshKey.Port, sshKey.Username)))
 
 	// 5. 建立 SSH 连接
-	authMethods := []ssh.AuthMethod{}
-	if sshKey.Type == model.SSHKeyTypePrivateKey {
-		h.logger.Info("Using private key authentication")
-		signer, err := ssh.ParsePrivateKey([]byte(sshKey.PrivateKey))
-		if err != nil {
-			// 尝试带密码的私钥
-			if sshKey.Passphrase != "" {
-				h.logger.Info("Private key requires passphrase, trying with passphrase")
-				signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(sshKey.PrivateKey), []byte(sshKey.Passphrase))
-			}
-		}
-		if err != nil {
-			h.logger.Errorf("Failed to parse private key: %v", err)
-			ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n[ERROR] 解析私钥失败: %v\r\n私钥可能已损坏或密码错误\r\n", err)))
-			return
-		}
-		authMethods = append(authMethods, ssh.PublicKeys(signer))
-	} else {
-		h.logger.Info("Using password authentication")
-		authMethods = append(authMethods, ssh.Password(sshKey.Password))
+	// 启用 SSH CA 时为本次会话签发短期证书
+	sshConfig, cert, err := h.nodeSvc.SSHClientConfig(sshKey, sshca.IssueRequest{
+		UserID:  userID.(uint),
+		Purpose: model.SSHCertPurposeTerminal,
+		Target:  clusterName + "/" + nodeName,
+	})
+	if err != nil {
+		h.logger.Errorf("Failed to build SSH client config: %v", err)
+		out.Write([]byte(fmt.Sprintf("\r\n[ERROR] 生成SSH认证信息失败: %v\r\n私钥可能已损坏或密码错误\r\n", err)))
+		return
 	}
-
-	sshConfig := &ssh.ClientConfig{
-		User:            sshKey.Username,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 注意：生产环境应验证 Host Key
-		Timeout:         5 * time.Second, // 优化：从10秒减少到5秒
+	if cert != nil {
+		out.Write([]byte(fmt.Sprintf("\r\n[INFO] 使用平台签发的SSH证书 (serial: %d, principals: %s, 有效期至 %s)\r\n",
+			cert.Serial, strings.Join(cert.Principals, ","), cert.ValidBefore.Format("15:04:05"))))
 	}
 
 	addr := fmt.Sprintf("%s:%d", host, sshKey.Port)
 	h.logger.Infof("Attempting to connect to %s with user %s", addr, sshKey.Username)
-	ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n[INFO] 正在连接到 %s ...\r\n", addr)))
+	out.Write([]byte(fmt.Sprintf("\r\n[INFO] 正在连接到 %s ...\r\n", addr)))
 	
 	client, err := ssh.Dial("tcp", addr, sshConfig)
 	if err != nil {
 		h.logger.Errorf("Failed to establish SSH connection to %s: %v", addr, err)
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\n[ERROR] SSH连接失败: %s\r\n错误详情: %v\r\n\r\n可能的原因:\r\n1. SSH端口(%d)不正确\r\n2. SSH服务未运行\r\n3. 网络不可达\r\n4. 认证失败(用户名或密钥错误)\r\n", addr, err, sshKey.Port)))
+		out.Write([]byte(fmt.Sprintf("\r\n[ERROR] SSH连接失败: %s\r\n错误详情: %v\r\n\r\n可能的原因:\r\n1. SSH端口(%d)不正确\r\n2. SSH服务未运行\r\n3. 网络不可达\r\n4. 认证失败(用户名或密钥错误)\r\n", addr, err, sshKey.Port)))
 		return
 	}
 	defer client.Close()
@@ -154,7 +170,7 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 	// 6. 创建 Session
 	session, err := client.NewSession()
 	if err != nil {
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\nFailed to create session: %v\r\n", err)))
+		out.Write([]byte(fmt.Sprintf("\r\nFailed to create session: %v\r\n", err)))
 		return
 	}
 	defer session.Close()
@@ -167,7 +183,7 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 	}
 	// 优化：增大初始终端尺寸以匹配更大的窗口 (40行x120列)
 	if err := session.RequestPty("xterm-256color", 40, 120, modes); err != nil {
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\nFailed to request PTY: %v\r\n", err)))
+		out.Write([]byte(fmt.Sprintf("\r\nFailed to request PTY: %v\r\n", err)))
 		return
 	}
 
@@ -187,7 +203,7 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 
 	// 9. 启动 Shell
 	if err := session.Shell(); err != nil {
-		ws.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("\r\nFailed to start shell: %v\r\n", err)))
+		out.Write([]byte(fmt.Sprintf("\r\nFailed to start shell: %v\r\n", err)))
 		return
 	}
 
@@ -218,7 +234,7 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 			if n > 0 {
 				// 发送二进制或文本，xterm.js 都能处理
 				// 为了简单，直接发文本
-				if err := ws.WriteMessage(websocket.TextMessage, buf[:n]); err != nil {
+				if _, err := out.Write(buf[:n]); err != nil {
 					return
 				}
 			}
@@ -236,13 +252,18 @@ func (h *Handler) HandleWebSocket(c *gin.Context) {
 				return
 			}
 			if n > 0 {
-				if err := ws.WriteMessage(websocket.TextMessage, buf[:n]); err != nil {
+				if _, err := out.Write(buf[:n]); err != nil {
 					return
 				}
 			}
 		}
 	}()
 
+	// 登录会话被撤销（退出登录、强制下线等）时断开终端
+	done := make(chan struct{})
+	defer close(done)
+	go h.watchSession(ws, c.GetString("session_id"), userID.(uint), done)
+
 	// 读取 WebSocket -> SSH 输入
 	for {
 		_, message, err := ws.ReadMessage()
@@ -329,3 +350,26 @@ func (h *Handler) UpdateSettings(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "success"})
 }
+
+// watchSession 定期检查登录会话，会话失效时关闭 WebSocket
+func (h *Handler) watchSession(ws *websocket.Conn, sessionID string, userID uint, done <-chan struct{}) {
+	ticker := time.NewTicker(sessionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if h.sessionSvc.IsSessionActive(sessionID) {
+				continue
+			}
+			h.logger.Warningf("Closing terminal for user %d: session %s revoked", userID, sessionID)
+			ws.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked"),
+				time.Now().Add(time.Second))
+			ws.Close()
+			return
+		}
+	}
+}
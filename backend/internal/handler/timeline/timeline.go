@@ -0,0 +1,119 @@
+package timeline
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/service/timeline"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 节点时间线处理器
+type Handler struct {
+	timelineSvc *timeline.Service
+	logger      *logger.Logger
+}
+
+// Response 通用响应结构
+type Response struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// NewHandler 创建节点时间线处理器实例
+func NewHandler(timelineSvc *timeline.Service, logger *logger.Logger) *Handler {
+	return &Handler{
+		timelineSvc: timelineSvc,
+		logger:      logger,
+	}
+}
+
+// GetNodeTimeline 获取节点生命周期时间线
+// @Summary 获取节点生命周期时间线
+// @Description 按时间倒序合并节点的审计操作、异常记录、Ansible 任务、禁止调度注解和 Kubernetes Events
+// @Tags nodes
+// @Produce json
+// @Param cluster_id path string true "集群ID（兼容路由，实际使用 cluster_name）"
+// @Param node_name path string true "节点名称"
+// @Param cluster_name query string true "集群名称"
+// @Param sources query string false "事件来源，多个用逗号分隔 (audit|anomaly|ansible|cordon|k8s_event)，默认全部"
+// @Param start query string false "开始时间 (RFC3339格式)，默认结束时间前7天"
+// @Param end query string false "结束时间 (RFC3339格式)，默认当前时间"
+// @Param limit query int false "最多返回的事件数，默认500，最大2000"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /nodes/{cluster_id}/{node_name}/timeline [get]
+func (h *Handler) GetNodeTimeline(c *gin.Context) {
+	req := timeline.Request{
+		ClusterName: c.Query("cluster_name"),
+		NodeName:    c.Param("node_name"),
+	}
+	if req.ClusterName == "" {
+		h.badRequest(c, "cluster_name is required")
+		return
+	}
+
+	sources, err := timeline.ParseSources(c.Query("sources"))
+	if err != nil {
+		h.badRequest(c, err.Error())
+		return
+	}
+	req.Sources = sources
+
+	if value := c.Query("start"); value != "" {
+		if req.Start, err = time.Parse(time.RFC3339, value); err != nil {
+			h.badRequest(c, "Invalid start time, expected RFC3339 format")
+			return
+		}
+	}
+	if value := c.Query("end"); value != "" {
+		if req.End, err = time.Parse(time.RFC3339, value); err != nil {
+			h.badRequest(c, "Invalid end time, expected RFC3339 format")
+			return
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if req.Limit, err = strconv.Atoi(value); err != nil || req.Limit <= 0 {
+			h.badRequest(c, "limit must be a positive integer")
+			return
+		}
+	}
+
+	result, err := h.timelineSvc.GetTimeline(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			status = http.StatusNotFound
+		case strings.Contains(err.Error(), "must be"):
+			status = http.StatusBadRequest
+		default:
+			h.logger.Errorf("Failed to get timeline for node %s: %v", req.NodeName, err)
+		}
+		c.JSON(status, Response{
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{
+		Code:    http.StatusOK,
+		Message: "Success",
+		Data:    result,
+	})
+}
+
+func (h *Handler) badRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    http.StatusBadRequest,
+		Message: message,
+	})
+}
@@ -104,6 +104,29 @@ func (h *Handler) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, user)
 }
 
+// CreateServiceAccount 创建服务账号
+func (h *Handler) CreateServiceAccount(c *gin.Context) {
+	userRole, _ := c.Get("user_role")
+	if userRole != model.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin role required"})
+		return
+	}
+
+	var req user.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.service.CreateServiceAccount(req, c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
 func (h *Handler) Update(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 	userRole, _ := c.Get("user_role")
@@ -0,0 +1,141 @@
+package informer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// K8sEvent Kubernetes Event 对象变化（仅包含 involvedObject 为节点的 Event）
+type K8sEvent struct {
+	Type        EventType     // 事件类型：Add/Update（Event 因 TTL 过期被删除时不通知）
+	ClusterName string        // 集群名称
+	Event       *corev1.Event // Event 对象
+	Timestamp   time.Time     // 收到时间
+}
+
+// K8sEventHandler Kubernetes Event 处理器接口
+type K8sEventHandler interface {
+	OnK8sEvent(event K8sEvent)
+}
+
+// RegisterEventHandler 注册 Kubernetes Event 处理器
+func (s *Service) RegisterEventHandler(handler K8sEventHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.eventHandlers = append(s.eventHandlers, handler)
+	s.logger.Infof("Registered k8s event handler: %T", handler)
+}
+
+// HasEventHandlers 是否注册了 Kubernetes Event 处理器，未注册时无需启动 Event Informer
+func (s *Service) HasEventHandlers() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.eventHandlers) > 0
+}
+
+// StartEventInformer 为指定集群启动节点 Event Informer
+// Event 数量远多于节点，使用独立的 factory 并通过 field selector 只监听 involvedObject.kind=Node 的 Event
+// 注意：必须在 StartInformer 之后调用，复用节点 Informer 的 clientset 和停止通道
+func (s *Service) StartEventInformer(clusterName string) error {
+	s.mu.Lock()
+	clientset, exists := s.clients[clusterName]
+	stopCh, stopperExists := s.stoppers[clusterName]
+	if !exists || !stopperExists {
+		s.mu.Unlock()
+		return fmt.Errorf("node informer not started for cluster %s, please call StartInformer first", clusterName)
+	}
+	if _, exists := s.eventInformers[clusterName]; exists {
+		s.mu.Unlock()
+		return nil
+	}
+
+	selector := fields.OneTermEqualSelector("involvedObject.kind", "Node").String()
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 30*time.Minute,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = selector
+		}))
+	eventInformer := factory.Core().V1().Events().Informer()
+
+	eventInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if event, ok := obj.(*corev1.Event); ok {
+				s.handleK8sEvent(clusterName, EventTypeAdd, event)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldEvent, ok1 := oldObj.(*corev1.Event)
+			newEvent, ok2 := newObj.(*corev1.Event)
+			if !ok1 || !ok2 {
+				return
+			}
+			// 全量同步会触发内容未变化的 Update，只关注重复上报（count/lastTimestamp 变化）
+			if oldEvent.ResourceVersion == newEvent.ResourceVersion {
+				return
+			}
+			s.handleK8sEvent(clusterName, EventTypeUpdate, newEvent)
+		},
+	})
+
+	// 先占位再释放锁，等待同步期间不阻塞其他集群和缓存读取
+	s.eventInformers[clusterName] = eventInformer
+	s.mu.Unlock()
+
+	go factory.Start(stopCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if !cache.WaitForCacheSync(ctx.Done(), eventInformer.HasSynced) {
+		s.mu.Lock()
+		if s.eventInformers[clusterName] == eventInformer {
+			delete(s.eventInformers, clusterName)
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("failed to sync event cache for cluster %s within 60s", clusterName)
+	}
+
+	s.logger.Infof("Successfully started Event Informer for cluster: %s", clusterName)
+
+	return nil
+}
+
+// handleK8sEvent 处理节点 Event 添加或更新
+func (s *Service) handleK8sEvent(clusterName string, eventType EventType, event *corev1.Event) {
+	if event.InvolvedObject.Kind != "Node" || event.InvolvedObject.Name == "" {
+		return
+	}
+
+	s.notifyEventHandlers(K8sEvent{
+		Type:        eventType,
+		ClusterName: clusterName,
+		Event:       event,
+		Timestamp:   time.Now(),
+	})
+}
+
+// notifyEventHandlers 通知所有注册的 Kubernetes Event 处理器
+func (s *Service) notifyEventHandlers(event K8sEvent) {
+	s.mu.RLock()
+	handlers := make([]K8sEventHandler, len(s.eventHandlers))
+	copy(handlers, s.eventHandlers)
+	s.mu.RUnlock()
+
+	for _, handler := range handlers {
+		// 异步通知，避免阻塞
+		go func(h K8sEventHandler) {
+			defer func() {
+				if r := recover(); r != nil {
+					s.logger.Errorf("K8s event handler panic: %v", r)
+				}
+			}()
+			h.OnK8sEvent(event)
+		}(handler)
+	}
+}
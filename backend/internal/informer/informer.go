@@ -64,6 +64,9 @@ type Service struct {
 	podHandlers    []PodEventHandler                          // Pod 事件处理器列表
 	podInformers   map[string]cache.SharedIndexInformer       // cluster -> pod informer (用于检查同步状态)
 	mu             sync.RWMutex
+
+	eventHandlers  []K8sEventHandler                          // Kubernetes Event 处理器列表
+	eventInformers map[string]cache.SharedIndexInformer       // cluster -> 节点 Event informer
 }
 
 // NewService 创建 Informer 服务
@@ -76,6 +79,9 @@ func NewService(logger *logger.Logger) *Service {
 		handlers:     make([]NodeEventHandler, 0),
 		podHandlers:  make([]PodEventHandler, 0),
 		podInformers: make(map[string]cache.SharedIndexInformer),
+
+		eventHandlers:  make([]K8sEventHandler, 0),
+		eventInformers: make(map[string]cache.SharedIndexInformer),
 	}
 }
 
@@ -166,6 +172,7 @@ func (s *Service) StopInformer(clusterName string) {
 		delete(s.stoppers, clusterName)
 		delete(s.clients, clusterName)
 		delete(s.podInformers, clusterName)
+		delete(s.eventInformers, clusterName)
 		s.logger.Infof("Stopped Informer for cluster: %s", clusterName)
 	}
 }
@@ -184,6 +191,7 @@ func (s *Service) StopAll() {
 	s.stoppers = make(map[string]chan struct{})
 	s.clients = make(map[string]*kubernetes.Clientset)
 	s.podInformers = make(map[string]cache.SharedIndexInformer)
+	s.eventInformers = make(map[string]cache.SharedIndexInformer)
 	s.logger.Info("Stopped all Informers")
 }
 
@@ -15,6 +15,12 @@ const (
 	AnomalyTypeDiskPressure       AnomalyType = "DiskPressure"
 	AnomalyTypePIDPressure        AnomalyType = "PIDPressure"
 	AnomalyTypeNetworkUnavailable AnomalyType = "NetworkUnavailable"
+
+	// 以下类型由节点 Kubernetes Event 触发，一段时间内不再上报后自动恢复
+	AnomalyTypeOOMKilling    AnomalyType = "OOMKilling"
+	AnomalyTypeImageGCFailed AnomalyType = "ImageGCFailed"
+	AnomalyTypeRebooted      AnomalyType = "Rebooted"
+	AnomalyTypeKernelOops    AnomalyType = "KernelOops"
 )
 
 // AnomalyStatus 异常状态
@@ -32,8 +32,16 @@ const (
 type InventorySourceType string
 
 const (
-	InventorySourceK8s    InventorySourceType = "k8s"
-	InventorySourceManual InventorySourceType = "manual"
+	InventorySourceK8s        InventorySourceType = "k8s"
+	InventorySourceK8sDynamic InventorySourceType = "k8s_dynamic" // 执行时从集群实时解析
+	InventorySourceManual     InventorySourceType = "manual"
+)
+
+// 节点调度状态筛选
+const (
+	CordonStateAll         = ""            // 不筛选
+	CordonStateSchedulable = "schedulable" // 仅可调度节点
+	CordonStateCordoned    = "cordoned"    // 仅已禁止调度节点
 )
 
 // SSHKeyType SSH 密钥类型
@@ -109,6 +117,35 @@ func (hd HostsData) Value() (driver.Value, error) {
 	return json.Marshal(hd)
 }
 
+// K8sInventoryFilter 从 K8s 集群生成主机清单时的节点筛选条件
+type K8sInventoryFilter struct {
+	LabelSelector       string            `json:"label_selector"`        // 标签选择器，如 "env=prod,tier in (web,api)"
+	NodeLabels          map[string]string `json:"node_labels"`           // 等值标签筛选
+	RequireTaints       []string          `json:"require_taints"`        // 仅包含带有这些污点的节点（key、key=value、key:effect 或 key=value:effect）
+	ExcludeTaints       []string          `json:"exclude_taints"`        // 排除带有这些污点的节点
+	ReadyOnly           bool              `json:"ready_only"`            // 仅包含 Ready 节点
+	CordonState         string            `json:"cordon_state"`          // 调度状态筛选（空/schedulable/cordoned）
+	IncludeControlPlane bool              `json:"include_control_plane"` // 是否包含 master/control-plane 节点
+	SSHPort             *int              `json:"ssh_port"`              // SSH 连接端口（可选）
+}
+
+// Scan 实现 sql.Scanner 接口
+func (f *K8sInventoryFilter) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+// Value 实现 driver.Valuer 接口
+func (f K8sInventoryFilter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
 // StringArray 字符串数组类型（用于 JSONB）
 type StringArray []string
 
@@ -133,11 +170,36 @@ func (sa StringArray) Value() (driver.Value, error) {
 	return json.Marshal(sa)
 }
 
+// SecretVarRefs 密钥变量引用（变量名 -> 密钥名称）
+type SecretVarRefs map[string]string
+
+// Scan 实现 sql.Scanner 接口
+func (sv *SecretVarRefs) Scan(value interface{}) error {
+	if value == nil {
+		*sv = make(SecretVarRefs)
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, sv)
+}
+
+// Value 实现 driver.Valuer 接口
+func (sv SecretVarRefs) Value() (driver.Value, error) {
+	if sv == nil {
+		return nil, nil
+	}
+	return json.Marshal(sv)
+}
+
 // AnsibleTask Ansible 任务模型
 type AnsibleTask struct {
 	ID               uint              `json:"id" gorm:"primarykey"`
 	Name             string            `json:"name" gorm:"not null;size:255;comment:任务名称"`
 	TemplateID       *uint             `json:"template_id" gorm:"index;comment:关联模板ID"`
+	TemplateRevision int               `json:"template_revision" gorm:"default:0;comment:执行时使用的模板版本号"`
 	ClusterID        *uint             `json:"cluster_id" gorm:"index;comment:关联集群ID"`
 	InventoryID      *uint             `json:"inventory_id" gorm:"index;comment:关联主机清单ID"`
 	Status           AnsibleTaskStatus `json:"status" gorm:"not null;index;size:50;comment:任务状态"`
@@ -151,17 +213,27 @@ type AnsibleTask struct {
 	HostsSkipped     int               `json:"hosts_skipped" gorm:"default:0;comment:跳过主机数"`
 	ErrorMsg         string            `json:"error_msg" gorm:"type:text;comment:错误信息"`
 	PlaybookContent  string            `json:"playbook_content" gorm:"type:text;not null;comment:Playbook内容"`
+	InventorySnapshot string           `json:"inventory_snapshot" gorm:"type:text;comment:执行时解析的主机清单快照"`
+	ProjectID        *uint             `json:"project_id" gorm:"index;comment:关联Git项目ID"`
+	PlaybookPath     string            `json:"playbook_path" gorm:"size:512;comment:项目内Playbook路径"`
+	CommitSHA        string            `json:"commit_sha" gorm:"size:64;comment:执行时固定的项目提交"`
 	FullLog          string            `json:"full_log" gorm:"type:text;comment:完整日志"`
 	LogSize          int64             `json:"log_size" gorm:"default:0;comment:日志大小(bytes)"`
 	ExtraVars        ExtraVars         `json:"extra_vars" gorm:"type:jsonb;comment:额外变量"`
+	SecretVars       SecretVarRefs     `json:"secret_vars" gorm:"type:jsonb;comment:密钥变量引用(变量名->密钥名称)"`
 	RetryPolicy      *RetryPolicy           `json:"retry_policy" gorm:"type:jsonb;comment:重试策略"`
 	RetryCount       int                    `json:"retry_count" gorm:"default:0;comment:当前重试次数"`
 	MaxRetries       int                    `json:"max_retries" gorm:"default:0;comment:最大重试次数"`
 	DryRun           bool                   `json:"dry_run" gorm:"default:false;comment:是否为检查模式(Dry Run)"`
 	BatchConfig      *BatchExecutionConfig  `json:"batch_config" gorm:"type:jsonb;comment:分批执行配置"`
+	Adhoc            *AdhocCommandConfig    `json:"adhoc" gorm:"type:jsonb;comment:临时命令配置(为空表示执行Playbook)"`
 	CurrentBatch     int                    `json:"current_batch" gorm:"default:0;comment:当前执行批次"`
 	TotalBatches     int                    `json:"total_batches" gorm:"default:0;comment:总批次数"`
 	BatchStatus      string                 `json:"batch_status" gorm:"size:50;comment:批次状态(running/paused/completed)"`
+	BatchResults     *BatchResultList       `json:"batch_results" gorm:"type:jsonb;comment:各批次执行结果"`
+	// 批次控制字段只通过条件更新写入，整行保存任务时不会覆盖其他实例提交的请求
+	BatchControl     string                 `json:"batch_control" gorm:"->;size:20;default:'';comment:待处理的批次控制请求(pause/continue)"`
+	BatchHeartbeatAt *time.Time             `json:"batch_heartbeat_at" gorm:"->;comment:执行实例最后心跳时间"`
 	PreflightChecks  *PreflightCheckResult  `json:"preflight_checks" gorm:"type:jsonb;comment:前置检查结果"`
 	TimeoutSeconds   int                    `json:"timeout_seconds" gorm:"default:0;comment:超时时间(秒),0表示不限制"`
 	IsTimedOut       bool                   `json:"is_timed_out" gorm:"default:false;comment:是否超时"`
@@ -181,6 +253,7 @@ type AnsibleTask struct {
 
 	// 关联 - 删除模板/清单时将任务的外键设置为 NULL
 	Template  *AnsibleTemplate  `json:"template,omitempty" gorm:"foreignKey:TemplateID;constraint:OnDelete:SET NULL"`
+	Project   *AnsibleProject   `json:"project,omitempty" gorm:"foreignKey:ProjectID;constraint:OnDelete:SET NULL"`
 	Cluster   *Cluster          `json:"cluster,omitempty" gorm:"foreignKey:ClusterID;constraint:OnDelete:SET NULL"`
 	Inventory *AnsibleInventory `json:"inventory,omitempty" gorm:"foreignKey:InventoryID;constraint:OnDelete:SET NULL"`
 	User      *User             `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -258,6 +331,11 @@ func (t *AnsibleTask) IsBatchEnabled() bool {
 	return t.BatchConfig != nil && t.BatchConfig.Enabled
 }
 
+// IsAdhoc 检查是否为临时命令任务
+func (t *AnsibleTask) IsAdhoc() bool {
+	return t.Adhoc != nil
+}
+
 // AddExecutionEvent 添加执行事件到时间线
 func (t *AnsibleTask) AddExecutionEvent(phase ExecutionPhase, message string, details map[string]interface{}) {
 	if t.ExecutionTimeline == nil {
@@ -286,6 +364,12 @@ func (t *AnsibleTask) IsBatchPaused() bool {
 	return t.BatchStatus == "paused"
 }
 
+// 批次控制请求，保存在任务记录中，由执行该任务的实例轮询处理
+const (
+	BatchControlPause    = "pause"    // 当前批次结束后暂停
+	BatchControlContinue = "continue" // 继续执行下一批次
+)
+
 // MarkBatchCompleted 标记批次完成
 func (t *AnsibleTask) MarkBatchCompleted() {
 	t.BatchStatus = "completed"
@@ -326,17 +410,22 @@ type AnsibleTemplate struct {
 	Name            string         `json:"name" gorm:"not null;size:255;comment:模板名称"` // 唯一索引由迁移文件创建
 	Description     string         `json:"description" gorm:"type:text;comment:模板描述"`
 	PlaybookContent string         `json:"playbook_content" gorm:"type:text;not null;comment:Playbook内容"`
+	ProjectID       *uint          `json:"project_id" gorm:"index;comment:关联Git项目ID(为空表示使用PlaybookContent)"`
+	PlaybookPath    string         `json:"playbook_path" gorm:"size:512;comment:项目内Playbook路径"`
 	Variables       ExtraVars      `json:"variables" gorm:"type:jsonb;comment:变量定义"`
 	RequiredVars    StringArray    `json:"required_vars" gorm:"type:jsonb;comment:必需变量列表"`
+	SecretVars      SecretVarRefs  `json:"secret_vars" gorm:"type:jsonb;comment:密钥变量引用(变量名->密钥名称)"`
 	Tags            string         `json:"tags" gorm:"size:255;comment:标签(逗号分隔)"`
 	RiskLevel       string         `json:"risk_level" gorm:"size:20;default:'low';comment:风险等级(low/medium/high)"`
+	CurrentRevision int            `json:"current_revision" gorm:"default:0;comment:当前版本号"`
 	UserID          uint           `json:"user_id" gorm:"not null;index;comment:创建用户ID"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 
 	// 关联
-	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Project *AnsibleProject `json:"project,omitempty" gorm:"foreignKey:ProjectID;constraint:OnDelete:SET NULL"`
+	User    *User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
 // TableName 指定表名
@@ -344,6 +433,35 @@ func (AnsibleTemplate) TableName() string {
 	return "ansible_templates"
 }
 
+// AnsibleTemplateRevision Ansible 模板版本快照（不可变，只追加）
+type AnsibleTemplateRevision struct {
+	ID              uint          `json:"id" gorm:"primarykey"`
+	TemplateID      uint          `json:"template_id" gorm:"not null;uniqueIndex:idx_template_revision;comment:关联模板ID"`
+	Revision        int           `json:"revision" gorm:"not null;uniqueIndex:idx_template_revision;comment:版本号"`
+	Name            string        `json:"name" gorm:"not null;size:255;comment:模板名称"`
+	Description     string        `json:"description" gorm:"type:text;comment:模板描述"`
+	PlaybookContent string        `json:"playbook_content" gorm:"type:text;comment:Playbook内容"`
+	ProjectID       *uint         `json:"project_id" gorm:"comment:关联Git项目ID"`
+	PlaybookPath    string        `json:"playbook_path" gorm:"size:512;comment:项目内Playbook路径"`
+	Variables       ExtraVars     `json:"variables" gorm:"type:jsonb;comment:变量定义"`
+	RequiredVars    StringArray   `json:"required_vars" gorm:"type:jsonb;comment:必需变量列表"`
+	SecretVars      SecretVarRefs `json:"secret_vars" gorm:"type:jsonb;comment:密钥变量引用(变量名->密钥名称)"`
+	Tags            string        `json:"tags" gorm:"size:255;comment:标签(逗号分隔)"`
+	RiskLevel       string        `json:"risk_level" gorm:"size:20;comment:风险等级(low/medium/high)"`
+	ChangeNote      string        `json:"change_note" gorm:"type:text;comment:变更说明"`
+	UserID          uint          `json:"user_id" gorm:"not null;index;comment:修改用户ID"`
+	CreatedAt       time.Time     `json:"created_at"`
+
+	// 关联
+	Template *AnsibleTemplate `json:"-" gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE"`
+	User     *User            `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName 指定表名
+func (AnsibleTemplateRevision) TableName() string {
+	return "ansible_template_revisions"
+}
+
 // AnsibleLog Ansible 任务执行日志模型
 type AnsibleLog struct {
 	ID         uint           `json:"id" gorm:"primarykey"`
@@ -372,6 +490,7 @@ type AnsibleInventory struct {
 	SSHKeyID    *uint               `json:"ssh_key_id" gorm:"index;comment:关联SSH密钥ID"`
 	Content     string              `json:"content" gorm:"type:text;not null;comment:清单内容(INI或YAML)"`
 	HostsData   HostsData           `json:"hosts_data" gorm:"type:jsonb;comment:结构化主机数据"`
+	K8sFilter   *K8sInventoryFilter `json:"k8s_filter" gorm:"type:jsonb;comment:K8s节点筛选条件"`
 	Environment string              `json:"environment" gorm:"size:20;default:'dev';comment:环境标签(dev/staging/production)"`
 	UserID      uint                `json:"user_id" gorm:"not null;index;comment:创建用户ID"`
 	CreatedAt   time.Time           `json:"created_at"`
@@ -389,9 +508,19 @@ func (AnsibleInventory) TableName() string {
 	return "ansible_inventories"
 }
 
+// IsFromProject 检查模板是否来自 Git 项目
+func (t *AnsibleTemplate) IsFromProject() bool {
+	return t.ProjectID != nil
+}
+
 // IsFromK8s 检查是否来自 K8s
 func (i *AnsibleInventory) IsFromK8s() bool {
-	return i.SourceType == InventorySourceK8s
+	return i.SourceType == InventorySourceK8s || i.SourceType == InventorySourceK8sDynamic
+}
+
+// IsDynamic 检查是否为执行时动态解析的清单
+func (i *AnsibleInventory) IsDynamic() bool {
+	return i.SourceType == InventorySourceK8sDynamic
 }
 
 // TaskListRequest 任务列表请求
@@ -413,6 +542,9 @@ type TaskCreateRequest struct {
 	InventoryID     *uint                  `json:"inventory_id"`
 	PlaybookContent string                 `json:"playbook_content"`
 	ExtraVars       map[string]interface{} `json:"extra_vars"`
+	SecretVars      map[string]string      `json:"secret_vars"`   // 密钥变量引用（变量名 -> 密钥名称），覆盖模板中的同名引用
+	CommitSHA       string                 `json:"commit_sha"`    // 项目模板使用的提交（为空则使用项目最近同步的提交）
+	TemplateRevision *int                  `json:"template_revision"` // 固定使用的模板版本（为空或0表示最新版本）
 	DryRun          bool                   `json:"dry_run"`       // 是否为检查模式（不实际执行变更）
 	BatchConfig     *BatchExecutionConfig  `json:"batch_config"`  // 分批执行配置
 	TimeoutSeconds  int                    `json:"timeout_seconds"` // 超时时间（秒），0表示不限制
@@ -432,11 +564,15 @@ type TemplateListRequest struct {
 type TemplateCreateRequest struct {
 	Name            string                 `json:"name" binding:"required"`
 	Description     string                 `json:"description"`
-	PlaybookContent string                 `json:"playbook_content" binding:"required"`
+	PlaybookContent string                 `json:"playbook_content"`   // 未关联项目时必需
+	ProjectID       *uint                  `json:"project_id"`         // 关联的 Git 项目
+	PlaybookPath    string                 `json:"playbook_path"`      // 项目内 Playbook 路径（关联项目时必需）
 	Variables       map[string]interface{} `json:"variables"`
 	Tags            string                 `json:"tags"`
 	RiskLevel       string                 `json:"risk_level"` // 风险等级(low/medium/high)
 	RequiredVars    []string               `json:"required_vars"` // 必需变量列表
+	SecretVars      map[string]string      `json:"secret_vars"`   // 密钥变量引用（变量名 -> 密钥名称）
+	ChangeNote      string                 `json:"change_note"`   // 版本变更说明
 }
 
 // TemplateUpdateRequest 模板更新请求
@@ -444,10 +580,36 @@ type TemplateUpdateRequest struct {
 	Name            string                 `json:"name"`
 	Description     string                 `json:"description"`
 	PlaybookContent string                 `json:"playbook_content"`
+	ProjectID       *uint                  `json:"project_id"`
+	PlaybookPath    string                 `json:"playbook_path"`
 	Variables       map[string]interface{} `json:"variables"`
 	Tags            string                 `json:"tags"`
 	RiskLevel       string                 `json:"risk_level"` // 风险等级(low/medium/high)
 	RequiredVars    []string               `json:"required_vars"` // 必需变量列表
+	SecretVars      map[string]string      `json:"secret_vars"`   // 密钥变量引用（变量名 -> 密钥名称）
+	ChangeNote      string                 `json:"change_note"`   // 版本变更说明
+}
+
+// TemplateRollbackRequest 模板回滚请求
+type TemplateRollbackRequest struct {
+	Revision   int    `json:"revision" binding:"required,min=1"`
+	ChangeNote string `json:"change_note"`
+}
+
+// TemplateRevisionDiff 模板版本差异
+type TemplateRevisionDiff struct {
+	TemplateID    uint                  `json:"template_id"`
+	FromRevision  int                   `json:"from_revision"`
+	ToRevision    int                   `json:"to_revision"`
+	PlaybookDiff  string                `json:"playbook_diff"`  // Playbook 内容的 unified diff
+	FieldChanges  []TemplateFieldChange `json:"field_changes"`  // 其他字段的变化
+}
+
+// TemplateFieldChange 模板字段变化
+type TemplateFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
 }
 
 // InventoryListRequest 主机清单列表请求
@@ -477,16 +639,43 @@ type InventoryUpdateRequest struct {
 	SSHKeyID    *uint                  `json:"ssh_key_id"` // 关联的 SSH 密钥 ID
 	Content     string                 `json:"content"`
 	HostsData   map[string]interface{} `json:"hosts_data"`
+	K8sFilter   *K8sInventoryFilter    `json:"k8s_filter"` // K8s 清单的节点筛选条件（仅 K8s 来源）
 }
 
 // GenerateInventoryRequest 从集群生成清单请求
 type GenerateInventoryRequest struct {
-	Name        string            `json:"name" binding:"required"`
-	Description string            `json:"description"`
-	ClusterID   uint              `json:"cluster_id" binding:"required"`
-	SSHKeyID    *uint             `json:"ssh_key_id"`  // 关联的 SSH 密钥 ID
-	SSHPort     *int              `json:"ssh_port"`    // SSH 连接端口（可选）
-	NodeLabels  map[string]string `json:"node_labels"` // 用于筛选节点的标签
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Dynamic     bool   `json:"dynamic"` // 是否在执行时从集群实时解析
+	K8sInventoryPreviewRequest
+}
+
+// K8sInventoryPreviewRequest 从集群预览清单请求（节点筛选条件）
+type K8sInventoryPreviewRequest struct {
+	ClusterID           uint              `json:"cluster_id" binding:"required"`
+	SSHKeyID            *uint             `json:"ssh_key_id"`            // 关联的 SSH 密钥 ID
+	SSHPort             *int              `json:"ssh_port"`              // SSH 连接端口（可选）
+	NodeLabels          map[string]string `json:"node_labels"`           // 用于筛选节点的标签
+	LabelSelector       string            `json:"label_selector"`        // 标签选择器
+	RequireTaints       []string          `json:"require_taints"`        // 仅包含带有这些污点的节点
+	ExcludeTaints       []string          `json:"exclude_taints"`        // 排除带有这些污点的节点
+	ReadyOnly           bool              `json:"ready_only"`            // 仅包含 Ready 节点
+	CordonState         string            `json:"cordon_state"`          // 调度状态筛选（空/schedulable/cordoned）
+	IncludeControlPlane bool              `json:"include_control_plane"` // 是否包含 master/control-plane 节点
+}
+
+// K8sFilter 从请求构建节点筛选条件
+func (r *K8sInventoryPreviewRequest) K8sFilter() *K8sInventoryFilter {
+	return &K8sInventoryFilter{
+		LabelSelector:       r.LabelSelector,
+		NodeLabels:          r.NodeLabels,
+		RequireTaints:       r.RequireTaints,
+		ExcludeTaints:       r.ExcludeTaints,
+		ReadyOnly:           r.ReadyOnly,
+		CordonState:         r.CordonState,
+		IncludeControlPlane: r.IncludeControlPlane,
+		SSHPort:             r.SSHPort,
+	}
 }
 
 // ======================== SSH 密钥管理 ========================
@@ -578,6 +767,134 @@ func (k *AnsibleSSHKey) ToResponse() *SSHKeyResponse {
 	}
 }
 
+// ======================== 密钥变量管理 ========================
+
+// AnsibleSecret 密钥变量模型（值加密存储，执行时通过 Vault 文件注入）
+type AnsibleSecret struct {
+	ID          uint           `json:"id" gorm:"primarykey"`
+	Name        string         `json:"name" gorm:"not null;size:255"` // 唯一索引由迁移文件创建
+	Description string         `json:"description" gorm:"type:text"`
+	Value       string         `json:"-" gorm:"type:text;not null"` // 密钥值（加密存储）
+	CreatedBy   uint           `json:"created_by" gorm:"not null;index"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (AnsibleSecret) TableName() string {
+	return "ansible_secrets"
+}
+
+// SecretListRequest 密钥变量列表请求
+type SecretListRequest struct {
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"page_size" form:"page_size"`
+	Keyword  string `json:"keyword" form:"keyword"`
+}
+
+// SecretCreateRequest 密钥变量创建请求
+type SecretCreateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Value       string `json:"value" binding:"required"`
+}
+
+// SecretUpdateRequest 密钥变量更新请求（Value 为空表示不修改）
+type SecretUpdateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+}
+
+// SecretResponse 密钥变量响应（不包含密钥值）
+type SecretResponse struct {
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	HasValue    bool      `json:"has_value"`
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToResponse 转换为响应对象（不包含密钥值）
+func (s *AnsibleSecret) ToResponse() *SecretResponse {
+	return &SecretResponse{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		HasValue:    s.Value != "",
+		CreatedBy:   s.CreatedBy,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+// ======================== Git 项目源 ========================
+
+// ProjectSyncStatus 项目同步状态
+type ProjectSyncStatus string
+
+const (
+	ProjectSyncStatusNever   ProjectSyncStatus = "never"   // 从未同步
+	ProjectSyncStatusSyncing ProjectSyncStatus = "syncing" // 同步中
+	ProjectSyncStatusSuccess ProjectSyncStatus = "success" // 同步成功
+	ProjectSyncStatusFailed  ProjectSyncStatus = "failed"  // 同步失败
+)
+
+// AnsibleProject Git 项目源（包含 playbook、roles、group_vars 等多文件内容）
+type AnsibleProject struct {
+	ID            uint              `json:"id" gorm:"primarykey"`
+	Name          string            `json:"name" gorm:"not null;size:255;comment:项目名称"` // 唯一索引由迁移文件创建
+	Description   string            `json:"description" gorm:"type:text;comment:项目描述"`
+	RepoURL       string            `json:"repo_url" gorm:"not null;size:1024;comment:Git仓库地址"`
+	Ref           string            `json:"ref" gorm:"size:255;default:'main';comment:分支/标签/提交"`
+	SSHKeyID      *uint             `json:"ssh_key_id" gorm:"index;comment:访问仓库使用的SSH密钥ID"`
+	SyncStatus    ProjectSyncStatus `json:"sync_status" gorm:"size:20;default:'never';comment:同步状态"`
+	SyncError     string            `json:"sync_error" gorm:"type:text;comment:同步错误信息"`
+	LastCommitSHA string            `json:"last_commit_sha" gorm:"size:64;comment:最近同步的提交"`
+	LastSyncedAt  *time.Time        `json:"last_synced_at" gorm:"comment:最近同步时间"`
+	UserID        uint              `json:"user_id" gorm:"not null;index;comment:创建用户ID"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt    `json:"-" gorm:"index"`
+
+	// 关联
+	SSHKey *AnsibleSSHKey `json:"ssh_key,omitempty" gorm:"foreignKey:SSHKeyID;constraint:OnDelete:SET NULL"`
+	User   *User          `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName 指定表名
+func (AnsibleProject) TableName() string {
+	return "ansible_projects"
+}
+
+// ProjectListRequest 项目列表请求
+type ProjectListRequest struct {
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"page_size" form:"page_size"`
+	Keyword  string `json:"keyword" form:"keyword"`
+}
+
+// ProjectCreateRequest 项目创建请求
+type ProjectCreateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	RepoURL     string `json:"repo_url" binding:"required"`
+	Ref         string `json:"ref"`        // 默认 main
+	SSHKeyID    *uint  `json:"ssh_key_id"` // 私有仓库凭据（私钥用于 SSH 地址，密码用于 HTTP(S) 地址）
+}
+
+// ProjectUpdateRequest 项目更新请求
+type ProjectUpdateRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	RepoURL     string `json:"repo_url"`
+	Ref         string `json:"ref"`
+	SSHKeyID    *uint  `json:"ssh_key_id"`
+}
+
 // ======================== 定时任务调度 ========================
 
 // AnsibleSchedule 定时任务调度模型
@@ -588,6 +905,7 @@ type AnsibleSchedule struct {
 	TemplateID  uint           `json:"template_id" gorm:"not null;index;comment:关联模板ID"`
 	InventoryID uint           `json:"inventory_id" gorm:"not null;index;comment:关联主机清单ID"`
 	ClusterID   *uint          `json:"cluster_id" gorm:"index;comment:关联集群ID"`
+	TemplateRevision int       `json:"template_revision" gorm:"default:0;comment:固定的模板版本号(0表示最新版本)"`
 	CronExpr    string         `json:"cron_expr" gorm:"not null;size:100;comment:Cron表达式"`
 	ExtraVars   ExtraVars      `json:"extra_vars" gorm:"type:jsonb;comment:额外变量"`
 	Enabled     bool           `json:"enabled" gorm:"default:true;index;comment:是否启用"`
@@ -625,6 +943,7 @@ type ScheduleCreateRequest struct {
 	Name        string                 `json:"name" binding:"required"`
 	Description string                 `json:"description"`
 	TemplateID  uint                   `json:"template_id" binding:"required"`
+	TemplateRevision int               `json:"template_revision"` // 固定的模板版本（0表示始终使用最新版本）
 	InventoryID uint                   `json:"inventory_id" binding:"required"`
 	ClusterID   *uint                  `json:"cluster_id"`
 	CronExpr    string                 `json:"cron_expr" binding:"required"`
@@ -637,6 +956,7 @@ type ScheduleUpdateRequest struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	TemplateID  uint                   `json:"template_id"`
+	TemplateRevision *int              `json:"template_revision"` // 固定的模板版本（0表示始终使用最新版本）
 	InventoryID uint                   `json:"inventory_id"`
 	ClusterID   *uint                  `json:"cluster_id"`
 	CronExpr    string                 `json:"cron_expr"`
@@ -678,8 +998,15 @@ type BatchExecutionConfig struct {
 	BatchSize        int    `json:"batch_size"`         // 每批主机数量（与 BatchPercent 二选一）
 	BatchPercent     int    `json:"batch_percent"`      // 每批主机百分比（0-100）
 	PauseAfterBatch  bool   `json:"pause_after_batch"`  // 每批执行后是否暂停等待确认
+	PauseTimeout     int    `json:"pause_timeout"`      // 暂停等待确认的超时（秒），超时后停止执行，默认 86400
 	FailureThreshold int    `json:"failure_threshold"`  // 失败阈值（失败主机数超过此值则停止）
 	MaxBatchFailRate int    `json:"max_batch_fail_rate"` // 单批最大失败率（0-100，超过则停止）
+
+	// 批次间健康检查（所有检查通过后才会执行下一批次）
+	HealthCheckCommand  string `json:"health_check_command"`   // 健康检查命令（通过 ansible -m command 在本批次主机上执行，任一主机退出码非 0 视为失败）
+	HealthCheckTimeout  int    `json:"health_check_timeout"`   // 健康检查超时（秒），默认 300
+	WaitForNodesReady   bool   `json:"wait_for_nodes_ready"`   // 是否等待本批次对应的 K8s 节点 Ready
+	NodeReadyTimeout    int    `json:"node_ready_timeout"`     // 等待节点 Ready 超时（秒），默认 600
 }
 
 // Scan 实现 sql.Scanner 接口
@@ -699,6 +1026,44 @@ func (bec BatchExecutionConfig) Value() (driver.Value, error) {
 	return json.Marshal(bec)
 }
 
+// BatchResult 单个批次的执行结果
+type BatchResult struct {
+	BatchNumber  int        `json:"batch_number"`  // 批次号（从 1 开始）
+	Hosts        []string   `json:"hosts"`         // 本批次主机列表
+	Status       string     `json:"status"`        // running/success/failed/cancelled
+	HostsOk      int        `json:"hosts_ok"`      // 成功主机数
+	HostsFailed  int        `json:"hosts_failed"`  // 失败主机数
+	FailedHosts  []string   `json:"failed_hosts"`  // 失败主机列表
+	HealthCheck  string     `json:"health_check"`  // 健康检查结果（passed/failed/skipped）
+	Message      string     `json:"message"`       // 附加信息（如停止原因）
+	StartedAt    time.Time  `json:"started_at"`    // 开始时间
+	FinishedAt   *time.Time `json:"finished_at"`   // 完成时间
+}
+
+// BatchResultList 批次执行结果列表
+type BatchResultList []BatchResult
+
+// Scan 实现 sql.Scanner 接口
+func (brl *BatchResultList) Scan(value interface{}) error {
+	if value == nil {
+		*brl = make(BatchResultList, 0)
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, brl)
+}
+
+// Value 实现 driver.Valuer 接口
+func (brl BatchResultList) Value() (driver.Value, error) {
+	if brl == nil {
+		return json.Marshal([]BatchResult{})
+	}
+	return json.Marshal(brl)
+}
+
 // ======================== 前置检查 ========================
 
 // PreflightCheckResult 前置检查结果
@@ -863,6 +1228,8 @@ const (
 	PhasePreflightCheck ExecutionPhase = "preflight_check" // 前置检查
 	PhaseExecuting      ExecutionPhase = "executing"       // 执行中
 	PhaseBatchPaused    ExecutionPhase = "batch_paused"    // 批次暂停
+	PhaseBatchCompleted ExecutionPhase = "batch_completed" // 单个批次完成
+	PhaseHealthCheck    ExecutionPhase = "health_check"    // 批次间健康检查
 	PhaseCompleted      ExecutionPhase = "completed"       // 已完成
 	PhaseFailed         ExecutionPhase = "failed"          // 失败
 	PhaseCancelled      ExecutionPhase = "cancelled"       // 已取消
@@ -1054,3 +1421,92 @@ type WorkflowExecutionListRequest struct {
 	Status     string `json:"status" form:"status"`
 }
 
+
+// ======================== 临时命令（Ad-hoc） ========================
+
+// 临时命令支持的模块
+const (
+	AdhocModuleShell   = "shell"
+	AdhocModuleCommand = "command"
+)
+
+// 单台主机的临时命令执行结果状态
+const (
+	HostResultOk          = "ok"
+	HostResultChanged     = "changed"
+	HostResultFailed      = "failed"
+	HostResultUnreachable = "unreachable"
+	HostResultSkipped     = "skipped"
+	HostResultNoResult    = "no_result" // 任务结束时没有返回结果（超时或被取消）
+)
+
+// AdhocCommandConfig 临时命令配置
+type AdhocCommandConfig struct {
+	Module         string   `json:"module"`          // shell 或 command
+	Args           string   `json:"args"`            // 要执行的命令
+	Hosts          []string `json:"hosts"`           // 创建任务时解析出的目标主机
+	LabelSelector  string   `json:"label_selector"`  // 按节点标签筛选目标（记录用）
+	Forks          int      `json:"forks"`           // 并发主机数
+	CommandTimeout int      `json:"command_timeout"` // 单台主机的命令超时（秒）
+}
+
+// Scan 实现 sql.Scanner 接口
+func (ac *AdhocCommandConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, ac)
+}
+
+// Value 实现 driver.Valuer 接口
+func (ac AdhocCommandConfig) Value() (driver.Value, error) {
+	return json.Marshal(ac)
+}
+
+// AnsibleHostResult 临时命令在单台主机上的执行结果
+type AnsibleHostResult struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	TaskID     uint      `json:"task_id" gorm:"not null;index;comment:关联任务ID"`
+	Host       string    `json:"host" gorm:"not null;size:255;comment:主机名"`
+	Status     string    `json:"status" gorm:"not null;size:20;index;comment:执行结果(ok/changed/failed/unreachable/skipped/no_result)"`
+	ReturnCode int       `json:"return_code" gorm:"default:-1;comment:命令退出码(-1表示无退出码)"`
+	Output     string    `json:"output" gorm:"type:text;comment:命令输出(stdout与stderr)"`
+	OutputHash string    `json:"output_hash" gorm:"size:64;index;comment:输出摘要(用于跨主机对比)"`
+	Truncated  bool      `json:"truncated" gorm:"default:false;comment:输出是否被截断"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// 关联 - 删除任务时级联删除结果
+	Task *AnsibleTask `json:"-" gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName 指定表名
+func (AnsibleHostResult) TableName() string {
+	return "ansible_host_results"
+}
+
+// AdhocCommandRequest 临时命令执行请求
+// 目标主机来自主机清单，可再按主机名列表或节点标签选择器缩小范围
+type AdhocCommandRequest struct {
+	Name           string   `json:"name"`
+	InventoryID    uint     `json:"inventory_id" binding:"required"`
+	Hosts          []string `json:"hosts"`          // 仅在这些主机上执行（需在清单中）
+	LabelSelector  string   `json:"label_selector"` // 仅在匹配的节点上执行（需清单关联集群）
+	Module         string   `json:"module"`         // shell（默认）或 command
+	Args           string   `json:"args" binding:"required"`
+	Forks          int      `json:"forks"`           // 并发主机数，0 使用默认值
+	CommandTimeout int      `json:"command_timeout"` // 单台主机命令超时（秒），0 使用默认值
+	TimeoutSeconds int      `json:"timeout_seconds"` // 整个任务的超时（秒），0 表示不限制
+}
+
+// AdhocResultGroup 输出相同的主机分组，用于跨主机对比结果
+type AdhocResultGroup struct {
+	Status     string   `json:"status"`
+	ReturnCode int      `json:"return_code"`
+	OutputHash string   `json:"output_hash"`
+	Output     string   `json:"output"`
+	Hosts      []string `json:"hosts"`
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// APITokenPrefix 个人访问令牌前缀，用于在认证时区分 JWT 和访问令牌
+const APITokenPrefix = "knm_"
+
+// APIToken 个人访问令牌，供 CI 和脚本调用 REST API
+type APIToken struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	Name       string      `json:"name" gorm:"size:100;not null"`
+	UserID     uint        `json:"user_id" gorm:"not null;index"`         // 令牌所属用户（可以是服务账号）
+	TokenHash  string      `json:"-" gorm:"uniqueIndex;size:64;not null"` // 令牌 SHA-256 摘要，明文只在创建时返回一次
+	TokenHint  string      `json:"token_hint" gorm:"size:20"`             // 令牌前几位，便于识别
+	Scopes     StringArray `json:"scopes" gorm:"type:jsonb"`              // 权限范围，如 nodes:read、labels:write、*:read
+	Clusters   StringArray `json:"clusters" gorm:"type:jsonb"`            // 允许访问的集群，为空表示不限制
+	ExpiresAt  *time.Time  `json:"expires_at" gorm:"index"`               // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time  `json:"last_used_at"`                          // 最近使用时间
+	LastUsedIP string      `json:"last_used_ip" gorm:"size:64"`           // 最近使用的来源地址
+	RevokedAt  *time.Time  `json:"revoked_at" gorm:"index"`               // 撤销时间，为空表示有效
+	CreatedBy  uint        `json:"created_by"`                            // 创建人
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName 指定表名
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// IsActive 令牌是否仍然有效
+func (t *APIToken) IsActive() bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || time.Now().Before(*t.ExpiresAt)
+}
+
+// 令牌权限范围的访问级别
+const (
+	TokenAccessRead  = "read"
+	TokenAccessWrite = "write"
+	TokenScopeAll    = "*"
+)
+
+// TokenScopeResources 可授权给访问令牌的资源，对应 /api/v1 下的一级路径
+var TokenScopeResources = []string{
+	"nodes", "labels", "taints", "clusters", "audit", "progress",
+	"ansible", "anomalies", "metrics", "capacity", "gitlab", "feishu", "ssh-keys", "ssh-key-rotations", "ssh-ca", "users",
+}
+
+// IsValidTokenScope 校验权限范围格式：<资源>:<read|write|*>，资源可以为 *
+func IsValidTokenScope(scope string) bool {
+	resource, access, ok := strings.Cut(scope, ":")
+	if !ok {
+		return false
+	}
+	if access != TokenAccessRead && access != TokenAccessWrite && access != TokenScopeAll {
+		return false
+	}
+	if resource == TokenScopeAll {
+		return true
+	}
+	for _, r := range TokenScopeResources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsScope 判断令牌是否允许对资源执行指定级别的访问，写权限包含读权限
+func (t *APIToken) AllowsScope(resource, access string) bool {
+	for _, scope := range t.Scopes {
+		r, a, _ := strings.Cut(scope, ":")
+		if r != TokenScopeAll && r != resource {
+			continue
+		}
+		if a == TokenScopeAll || a == access || (a == TokenAccessWrite && access == TokenAccessRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsCluster 判断令牌是否允许访问指定集群
+func (t *APIToken) AllowsCluster(clusterName string) bool {
+	if len(t.Clusters) == 0 {
+		return true
+	}
+	for _, name := range t.Clusters {
+		if name == clusterName {
+			return true
+		}
+	}
+	return false
+}
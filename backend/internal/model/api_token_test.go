@@ -0,0 +1,53 @@
+package model
+
+import "testing"
+
+func TestAPITokenAllowsScope(t *testing.T) {
+	token := &APIToken{Scopes: StringArray{"nodes:write", "labels:read", "*:read"}}
+
+	tests := []struct {
+		resource string
+		access   string
+		want     bool
+	}{
+		{"nodes", TokenAccessWrite, true},
+		{"nodes", TokenAccessRead, true}, // 写权限包含读权限
+		{"labels", TokenAccessRead, true},
+		{"labels", TokenAccessWrite, false},
+		{"taints", TokenAccessRead, true}, // *:read
+		{"taints", TokenAccessWrite, false},
+	}
+	for _, tt := range tests {
+		if got := token.AllowsScope(tt.resource, tt.access); got != tt.want {
+			t.Errorf("AllowsScope(%s, %s) = %v, want %v", tt.resource, tt.access, got, tt.want)
+		}
+	}
+}
+
+func TestAPITokenAllowsCluster(t *testing.T) {
+	unrestricted := &APIToken{}
+	if !unrestricted.AllowsCluster("prod") {
+		t.Error("token without cluster list should allow any cluster")
+	}
+
+	restricted := &APIToken{Clusters: StringArray{"staging"}}
+	if !restricted.AllowsCluster("staging") || restricted.AllowsCluster("prod") {
+		t.Error("restricted token should only allow listed clusters")
+	}
+}
+
+func TestIsValidTokenScope(t *testing.T) {
+	valid := []string{"nodes:read", "labels:write", "*:read", "ansible:*", "ssh-ca:read", "ssh-key-rotations:read", "*:*"}
+	for _, scope := range valid {
+		if !IsValidTokenScope(scope) {
+			t.Errorf("expected %q to be valid", scope)
+		}
+	}
+
+	invalid := []string{"nodes", "nodes:admin", "unknown:read", ""}
+	for _, scope := range invalid {
+		if IsValidTokenScope(scope) {
+			t.Errorf("expected %q to be invalid", scope)
+		}
+	}
+}
@@ -17,12 +17,35 @@ type AuditLog struct {
 	ErrorMsg     string       `json:"error_msg"`
 	IPAddress    string       `json:"ip_address"`
 	UserAgent    string       `json:"user_agent"`
+	APITokenID   *uint        `json:"api_token_id,omitempty" gorm:"index"` // 通过访问令牌发起的操作
 	CreatedAt    time.Time    `json:"created_at"`
 
+	OperationID string `json:"operation_id,omitempty" gorm:"size:64;index"` // 节点变更操作 ID，可用于查看变更详情和撤销
+
+	PrevHash string `json:"prev_hash,omitempty" gorm:"size:64"` // 上一条记录的哈希
+	Hash     string `json:"hash,omitempty" gorm:"size:64"`      // 本条记录的哈希，覆盖上一条记录的哈希和本条记录内容
+
 	User    User     `json:"user" gorm:"foreignKey:UserID"`
 	Cluster *Cluster `json:"cluster,omitempty" gorm:"foreignKey:ClusterID"`
 }
 
+// AuditChainState 审计日志哈希链状态，只有一行（ID=1）
+// 写入审计日志时锁定该行，保证多副本并发写入时哈希链不分叉；
+// Anchor 为最近一次归档删除的最后一条记录，校验从锚点之后开始
+type AuditChainState struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	LastLogID  uint      `json:"last_log_id"`
+	LastHash   string    `json:"last_hash" gorm:"size:64"`
+	AnchorID   uint      `json:"anchor_id"`
+	AnchorHash string    `json:"anchor_hash" gorm:"size:64"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (AuditChainState) TableName() string {
+	return "audit_chain_state"
+}
+
 type AuditAction string
 
 const (
@@ -37,6 +60,10 @@ const (
 	ActionQuery  AuditAction = "query"  // 查询
 	ActionBind   AuditAction = "bind"   // 绑定
 	ActionUnbind AuditAction = "unbind" // 解绑
+
+	ActionExport   AuditAction = "export"   // 导出
+	ActionUpload   AuditAction = "upload"   // 上传文件到节点
+	ActionDownload AuditAction = "download" // 从节点下载文件
 )
 
 type ResourceType string
@@ -52,6 +79,24 @@ const (
 	ResourceFeishuSettings ResourceType = "feishu_settings" // 飞书配置
 	ResourceFeishuGroup    ResourceType = "feishu_group"    // 飞书群组
 	ResourceFeishuUser     ResourceType = "feishu_user"     // 飞书用户
+	ResourceAPIToken       ResourceType = "api_token"       // 访问令牌
+
+	ResourceAuditLog         ResourceType = "audit_log"         // 审计日志（导出）
+	ResourceSSHKey           ResourceType = "ssh_key"           // 系统 SSH 密钥
+	ResourceGitlabSettings   ResourceType = "gitlab_settings"   // GitLab 配置
+	ResourceGitlabRunner     ResourceType = "gitlab_runner"     // GitLab Runner
+	ResourceAnsibleTask      ResourceType = "ansible_task"      // Ansible 任务
+	ResourceAnsibleTemplate  ResourceType = "ansible_template"  // Ansible 模板
+	ResourceAnsibleInventory ResourceType = "ansible_inventory" // Ansible 主机清单
+	ResourceAnsibleSSHKey    ResourceType = "ansible_ssh_key"   // Ansible SSH 密钥
+	ResourceAnsibleSecret    ResourceType = "ansible_secret"    // Ansible 密钥变量
+	ResourceAnsibleProject   ResourceType = "ansible_project"   // Ansible Git 项目
+	ResourceAnsibleSchedule  ResourceType = "ansible_schedule"  // Ansible 定时任务
+	ResourceAnsibleWorkflow  ResourceType = "ansible_workflow"  // Ansible 工作流
+	ResourceAnsibleTag       ResourceType = "ansible_tag"       // Ansible 标签
+	ResourceAnsibleFavorite  ResourceType = "ansible_favorite"  // Ansible 收藏和执行历史
+
+	ResourceNodeFile ResourceType = "node_file" // 节点文件（SFTP 传输）
 )
 
 type AuditStatus string
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// CapacityReportRun 定时容量报告的发送记录
+// RunKey 唯一，多副本部署时只有成功写入记录的副本发送报告
+type CapacityReportRun struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RunKey    string    `json:"run_key" gorm:"size:64;not null;uniqueIndex"` // 报告日期（YYYY-MM-DD）
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (CapacityReportRun) TableName() string {
+	return "capacity_report_runs"
+}
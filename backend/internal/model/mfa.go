@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// UserMFA 用户多因素认证（TOTP）配置
+type UserMFA struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	UserID        uint        `json:"user_id" gorm:"uniqueIndex;not null"`
+	Secret        string      `json:"-" gorm:"type:text;not null"`  // 加密存储的 TOTP 密钥
+	Enabled       bool        `json:"enabled" gorm:"default:false"` // 未启用表示已生成密钥但尚未验证
+	EnabledAt     *time.Time  `json:"enabled_at"`
+	RecoveryCodes StringArray `json:"-" gorm:"type:jsonb"` // 未使用的恢复码 SHA-256 摘要，使用后移除
+	LastUsedStep  int64       `json:"-"`                   // 最近一次通过校验的时间步，用于拒绝验证码重放
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// LoginThrottle 登录失败计数，按用户名和来源 IP 分别记录
+type LoginThrottle struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ThrottleKey string     `json:"throttle_key" gorm:"uniqueIndex;size:255;not null"` // user:<用户名> 或 ip:<地址>
+	Failures    int        `json:"failures"`                                          // 当前窗口内的失败次数
+	WindowStart time.Time  `json:"window_start"`                                      // 计数窗口开始时间
+	LockedUntil *time.Time `json:"locked_until" gorm:"index"`                         // 锁定截止时间
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+// IsLocked 是否处于锁定状态
+func (t *LoginThrottle) IsLocked() bool {
+	return t.LockedUntil != nil && time.Now().Before(*t.LockedUntil)
+}
@@ -8,10 +8,15 @@ import (
 func GetAllModels() []interface{} {
 	return []interface{}{
 		&User{},
+		&UserSession{},
+		&APIToken{},
+		&UserMFA{},
+		&LoginThrottle{},
 		&Cluster{},
 		&LabelTemplate{},
 		&TaintTemplate{},
 		&AuditLog{},
+		&AuditChainState{},
 		&ProgressTask{},
 		&ProgressMessage{},
 		&GitlabSettings{},
@@ -20,17 +25,28 @@ func GetAllModels() []interface{} {
 		&FeishuUserMapping{},
 		&FeishuUserSession{},
 		&NodeAnomaly{},
+		&NodeMetricSample{},
+		&NodeEvent{},
+		&NodeChange{},
+		&CapacityReportRun{},
 		&CacheEntry{},
 		&AnsibleTask{},
 		&AnsibleTemplate{},
+		&AnsibleTemplateRevision{},
 		&AnsibleLog{},
+		&AnsibleHostResult{},
 		&AnsibleInventory{},
 		&AnsibleSSHKey{},
+		&AnsibleSecret{},
+		&AnsibleProject{},
 		&AnsibleSchedule{},
 		&AnsibleFavorite{},
 		&AnsibleTaskHistory{},
 		&SystemSSHKey{},
 		&NodeSettings{},
+		&SSHCertAuthority{},
+		&SSHCertificate{},
+		&SSHKeyRotation{},
 		&AnsibleTag{},
 		&AnsibleTaskTag{},
 		&AnsibleWorkflow{},
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NodeChangeResource 节点变更的资源类型
+type NodeChangeResource string
+
+const (
+	NodeChangeLabel  NodeChangeResource = "label"  // 标签
+	NodeChangeTaint  NodeChangeResource = "taint"  // 污点
+	NodeChangeCordon NodeChangeResource = "cordon" // 调度状态
+)
+
+// TaintSnapshot 污点快照
+type TaintSnapshot struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// NodeStateSnapshot 节点状态快照，只包含变更资源类型对应的字段
+type NodeStateSnapshot struct {
+	Labels        map[string]string `json:"labels,omitempty"`
+	Taints        []TaintSnapshot   `json:"taints,omitempty"`
+	Unschedulable *bool             `json:"unschedulable,omitempty"`
+	CordonReason  string            `json:"cordon_reason,omitempty"`
+}
+
+// Scan 实现 sql.Scanner 接口
+func (s *NodeStateSnapshot) Scan(value interface{}) error {
+	if value == nil {
+		*s = NodeStateSnapshot{}
+		return nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return fmt.Errorf("unsupported type for NodeStateSnapshot: %T", value)
+	}
+}
+
+// Value 实现 driver.Valuer 接口
+func (s NodeStateSnapshot) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// NodeChange 节点标签、污点、调度状态变更记录
+// 同一次操作（单节点或批量）的所有节点共享 OperationID，撤销时按 OperationID 整体恢复
+type NodeChange struct {
+	ID              uint               `json:"id" gorm:"primaryKey"`
+	OperationID     string             `json:"operation_id" gorm:"size:64;not null;index:idx_node_change_operation"`
+	ClusterID       uint               `json:"cluster_id" gorm:"not null;index:idx_node_change_node,priority:1"`
+	ClusterName     string             `json:"cluster_name" gorm:"not null"`
+	NodeName        string             `json:"node_name" gorm:"not null;index:idx_node_change_node,priority:2"`
+	ResourceType    NodeChangeResource `json:"resource_type" gorm:"size:20;not null"`
+	Before          NodeStateSnapshot  `json:"before" gorm:"column:before_state;type:text"`
+	After           NodeStateSnapshot  `json:"after" gorm:"column:after_state;type:text"`
+	UserID          uint               `json:"user_id" gorm:"not null"`
+	UndoneAt        *time.Time         `json:"undone_at,omitempty"`
+	UndoneBy        *uint              `json:"undone_by,omitempty"`
+	UndoOperationID string             `json:"undo_operation_id,omitempty" gorm:"size:64"` // 执行撤销的操作 ID
+	CreatedAt       time.Time          `json:"created_at" gorm:"index:idx_node_change_node,priority:3"`
+}
+
+// TableName 指定表名
+func (NodeChange) TableName() string {
+	return "node_changes"
+}
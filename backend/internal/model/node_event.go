@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// NodeEvent 持久化的节点相关 Kubernetes Event
+// 同一个 Event 对象（按 UID）只保存一行，Count 和 LastTimestamp 随重复上报更新
+type NodeEvent struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ClusterID      uint      `json:"cluster_id" gorm:"not null;uniqueIndex:idx_node_event_uid,priority:1;index:idx_node_event_node,priority:1"`
+	ClusterName    string    `json:"cluster_name" gorm:"not null"`
+	NodeName       string    `json:"node_name" gorm:"not null;index:idx_node_event_node,priority:2"`
+	EventUID       string    `json:"event_uid" gorm:"size:64;not null;uniqueIndex:idx_node_event_uid,priority:2"`
+	Type           string    `json:"type" gorm:"size:20"` // Normal 或 Warning
+	Reason         string    `json:"reason" gorm:"size:128;index:idx_node_event_reason"`
+	Message        string    `json:"message" gorm:"type:text"`
+	Source         string    `json:"source"` // 上报组件，如 kubelet、node-problem-detector
+	Count          int32     `json:"count" gorm:"not null;default:1"`
+	FirstTimestamp time.Time `json:"first_timestamp" gorm:"not null"`
+	LastTimestamp  time.Time `json:"last_timestamp" gorm:"not null;index:idx_node_event_node,priority:3;index:idx_node_event_last"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (NodeEvent) TableName() string {
+	return "node_events"
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+// MetricTier 节点指标降采样层级
+type MetricTier string
+
+const (
+	MetricTierRaw     MetricTier = "raw" // 原始采样
+	MetricTierFiveMin MetricTier = "5m"  // 5 分钟聚合
+	MetricTierHourly  MetricTier = "1h"  // 1 小时聚合
+)
+
+// NodeMetricSample 节点资源使用历史数据点
+// 原始采样的 SampleCount 为 1，聚合层级保存桶内的平均值、最小值和最大值
+// requests 只保存平均值，用于容量趋势预测
+type NodeMetricSample struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	ClusterID         uint       `json:"cluster_id" gorm:"not null;uniqueIndex:idx_node_metric_point,priority:1"`
+	ClusterName       string     `json:"cluster_name" gorm:"not null"`
+	NodeName          string     `json:"node_name" gorm:"not null;uniqueIndex:idx_node_metric_point,priority:2"`
+	Tier              MetricTier `json:"tier" gorm:"size:10;not null;uniqueIndex:idx_node_metric_point,priority:3;index:idx_node_metric_tier_time,priority:1"`
+	Timestamp         time.Time  `json:"timestamp" gorm:"not null;uniqueIndex:idx_node_metric_point,priority:4;index:idx_node_metric_tier_time,priority:2"` // 采样时间或聚合桶起始时间
+	SampleCount       int        `json:"sample_count" gorm:"not null;default:1"`
+	CPUAvg            float64    `json:"cpu_avg"` // 毫核
+	CPUMin            int64      `json:"cpu_min"`
+	CPUMax            int64      `json:"cpu_max"`
+	MemoryAvg         float64    `json:"memory_avg"` // 字节
+	MemoryMin         int64      `json:"memory_min"`
+	MemoryMax         int64      `json:"memory_max"`
+	PodsAvg           float64    `json:"pods_avg"`
+	PodsMin           int64      `json:"pods_min"`
+	PodsMax           int64      `json:"pods_max"`
+	CPUAllocatable    int64      `json:"cpu_allocatable"`    // 毫核
+	MemoryAllocatable int64      `json:"memory_allocatable"` // 字节
+	PodsAllocatable   int64      `json:"pods_allocatable"`
+	RequestSamples    int        `json:"request_samples" gorm:"not null;default:0"` // 统计到 requests 的采样数，为 0 时 requests 无效
+	CPURequestsAvg    float64    `json:"cpu_requests_avg"`                          // 毫核
+	MemoryRequestsAvg float64    `json:"memory_requests_avg"`                       // 字节
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (NodeMetricSample) TableName() string {
+	return "node_metric_samples"
+}
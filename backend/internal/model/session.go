@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+// UserSession 用户登录会话，支持服务端撤销和刷新令牌轮换
+type UserSession struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	SessionID     string     `json:"session_id" gorm:"uniqueIndex;size:64;not null"` // 会话标识，写入令牌的 sid 声明
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	RefreshJTI    string     `json:"-" gorm:"size:64;not null"` // 当前有效刷新令牌的 jti，轮换后旧令牌失效
+	IPAddress     string     `json:"ip_address" gorm:"size:64"`
+	UserAgent     string     `json:"user_agent" gorm:"size:512"`
+	LastUsedAt    time.Time  `json:"last_used_at"`            // 最近一次刷新时间
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"index"` // 刷新令牌过期时间
+	RevokedAt     *time.Time `json:"revoked_at" gorm:"index"` // 撤销时间，为空表示有效
+	RevokedReason string     `json:"revoked_reason" gorm:"size:255"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// IsActive 会话是否仍然有效
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// 会话撤销原因
+const (
+	SessionRevokedLogout      = "logout"              // 用户退出登录
+	SessionRevokedLogoutAll   = "logout_all"          // 用户退出全部会话
+	SessionRevokedByAdmin     = "revoked_by_admin"    // 管理员强制下线
+	SessionRevokedTokenReuse  = "refresh_token_reuse" // 检测到已轮换的刷新令牌被重复使用
+	SessionRevokedUserBlocked = "user_disabled"       // 用户被禁用或删除
+	SessionRevokedPassword    = "password_changed"    // 用户修改密码
+	SessionRevokedMFAReset    = "mfa_reset"           // 管理员重置 MFA
+)
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"time"
+)
+
+// SSHCertAuthority 平台 SSH 证书颁发机构（CA）
+// 节点只需信任 CA 公钥，终端会话和 Ansible 任务使用 CA 签发的短期证书登录
+type SSHCertAuthority struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;size:100;not null"`
+	KeyType     string    `json:"key_type" gorm:"size:50;not null"`
+	PublicKey   string    `json:"public_key" gorm:"type:text;not null"` // authorized_keys 格式
+	PrivateKey  string    `json:"-" gorm:"type:text;not null"`          // OpenSSH 格式私钥（加密存储）
+	Fingerprint string    `json:"fingerprint" gorm:"size:100"`          // SHA256 指纹
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SSHCertAuthority) TableName() string {
+	return "ssh_cert_authorities"
+}
+
+// 证书用途
+const (
+	SSHCertPurposeTerminal = "terminal" // Web 终端
+	SSHCertPurposeSFTP     = "sftp"     // 节点文件传输
+	SSHCertPurposeAnsible  = "ansible"  // Ansible 任务
+)
+
+// SSHCertificate 平台签发的 SSH 用户证书记录（只记录元数据，私钥不落库）
+type SSHCertificate struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	Serial      uint64      `json:"serial" gorm:"uniqueIndex;not null"`
+	KeyID       string      `json:"key_id" gorm:"size:255;not null"` // 证书 Key ID，sshd 登录日志中会输出
+	Principals  StringArray `json:"principals" gorm:"type:jsonb"`
+	Purpose     string      `json:"purpose" gorm:"size:20;not null;index"`
+	Target      string      `json:"target" gorm:"size:255"` // 集群/节点或 Ansible 任务
+	UserID      uint        `json:"user_id" gorm:"not null;index"`
+	Username    string      `json:"username" gorm:"size:100"`
+	Fingerprint string      `json:"fingerprint" gorm:"size:100"` // 临时公钥的 SHA256 指纹
+	ValidAfter  time.Time   `json:"valid_after"`
+	ValidBefore time.Time   `json:"valid_before"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (SSHCertificate) TableName() string {
+	return "ssh_certificates"
+}
+
+// SSHCertificateListRequest 证书签发记录查询请求
+type SSHCertificateListRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Purpose  string `form:"purpose"`
+	UserID   uint   `form:"user_id"`
+	Keyword  string `form:"keyword"` // 匹配 Key ID 或目标
+}
@@ -0,0 +1,129 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SSH 密钥来源表
+const (
+	SSHKeySourceSystem  = "system"  // system_ssh_keys
+	SSHKeySourceAnsible = "ansible" // ansible_ssh_keys
+)
+
+// SSHKeyRotationStatus 密钥轮换状态
+type SSHKeyRotationStatus string
+
+const (
+	SSHKeyRotationRunning    SSHKeyRotationStatus = "running"     // 执行中
+	SSHKeyRotationCompleted  SSHKeyRotationStatus = "completed"   // 已完成，所有引用已切换到新密钥
+	SSHKeyRotationRolledBack SSHKeyRotationStatus = "rolled_back" // 部分节点失败，已撤销分发的新公钥，仍使用旧密钥
+	SSHKeyRotationFailed     SSHKeyRotationStatus = "failed"      // 执行前检查失败或被中断
+)
+
+// 轮换阶段
+const (
+	SSHKeyRotationPhasePlan       = "plan"       // 收集引用该密钥的节点
+	SSHKeyRotationPhaseDistribute = "distribute" // 使用旧密钥登录，追加新公钥
+	SSHKeyRotationPhaseVerify     = "verify"     // 使用新密钥登录验证
+	SSHKeyRotationPhaseSwitch     = "switch"     // 替换密钥记录中的私钥
+	SSHKeyRotationPhaseCleanup    = "cleanup"    // 使用新密钥登录，删除旧公钥
+	SSHKeyRotationPhaseRollback   = "rollback"   // 删除已分发的新公钥
+	SSHKeyRotationPhaseDone       = "done"
+)
+
+// 单个目标主机的轮换状态
+const (
+	SSHKeyRotationTargetPending     = "pending"
+	SSHKeyRotationTargetDistributed = "distributed"
+	SSHKeyRotationTargetVerified    = "verified"
+	SSHKeyRotationTargetCleaned     = "cleaned"
+	SSHKeyRotationTargetFailed      = "failed"
+	SSHKeyRotationTargetRolledBack  = "rolled_back"
+)
+
+// SSHKeyRotationTarget 轮换目标主机
+type SSHKeyRotationTarget struct {
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	Username  string    `json:"username"`
+	Sources   []string  `json:"sources"` // 引用来源，如 node:<集群>/<节点>、inventory:<清单名>
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SSHKeyRotationTargets 轮换目标主机列表
+type SSHKeyRotationTargets []SSHKeyRotationTarget
+
+// Scan 实现 sql.Scanner 接口
+func (t *SSHKeyRotationTargets) Scan(value interface{}) error {
+	if value == nil {
+		*t = make(SSHKeyRotationTargets, 0)
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, t)
+}
+
+// Value 实现 driver.Valuer 接口
+func (t SSHKeyRotationTargets) Value() (driver.Value, error) {
+	if t == nil {
+		return json.Marshal([]SSHKeyRotationTarget{})
+	}
+	return json.Marshal(t)
+}
+
+// SSHKeyRotation SSH 密钥轮换记录
+// 新公钥分发并验证到所有引用节点后才替换密钥记录，任一节点失败则撤销已分发的新公钥
+type SSHKeyRotation struct {
+	ID             uint                  `json:"id" gorm:"primaryKey"`
+	KeySource      string                `json:"key_source" gorm:"size:20;not null;index:idx_ssh_key_rotation_key"`
+	KeyID          uint                  `json:"key_id" gorm:"not null;index:idx_ssh_key_rotation_key"`
+	KeyName        string                `json:"key_name" gorm:"size:255"`
+	Status         SSHKeyRotationStatus  `json:"status" gorm:"size:20;not null;index"`
+	RunningKey     *string               `json:"-" gorm:"size:64;uniqueIndex"` // 执行中为 <来源>:<密钥ID>，结束后清空；唯一索引保证同一密钥只有一个执行中的轮换
+	Phase          string                `json:"phase" gorm:"size:20"`
+	OldFingerprint string                `json:"old_fingerprint" gorm:"size:100"`
+	NewFingerprint string                `json:"new_fingerprint" gorm:"size:100"`
+	NewPublicKey   string                `json:"new_public_key" gorm:"type:text"`
+	Targets        SSHKeyRotationTargets `json:"targets" gorm:"type:jsonb"`
+	TotalTargets   int                   `json:"total_targets"`
+	Concurrency    int                   `json:"concurrency"`
+	ErrorMessage   string                `json:"error_message" gorm:"type:text"`
+	UserID         uint                  `json:"user_id" gorm:"not null;index"`
+	Username       string                `json:"username" gorm:"size:100"`
+	StartedAt      time.Time             `json:"started_at"`
+	CompletedAt    *time.Time            `json:"completed_at"`
+	CreatedAt      time.Time             `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (SSHKeyRotation) TableName() string {
+	return "ssh_key_rotations"
+}
+
+// SSHKeyRotationRunningKey 执行中轮换的唯一键
+func SSHKeyRotationRunningKey(keySource string, keyID uint) string {
+	return fmt.Sprintf("%s:%d", keySource, keyID)
+}
+
+// SSHKeyRotateRequest 发起密钥轮换请求
+type SSHKeyRotateRequest struct {
+	Concurrency int `json:"concurrency"` // 同时处理的主机数，0 使用默认值
+}
+
+// SSHKeyRotationListRequest 轮换记录查询请求
+type SSHKeyRotationListRequest struct {
+	Page      int    `form:"page"`
+	PageSize  int    `form:"page_size"`
+	KeySource string `form:"key_source"`
+	KeyID     uint   `form:"key_id"`
+	Status    string `form:"status"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,23 +25,29 @@ type NodeError struct {
 
 // ProgressTask 进度任务模型 - 用于多副本环境下的状态共享
 type ProgressTask struct {
-	ID           uint           `json:"id" gorm:"primarykey"`
-	TaskID       string         `json:"task_id" gorm:"uniqueIndex;not null"` // 任务唯一标识
-	UserID       uint           `json:"user_id" gorm:"not null;index"`       // 所属用户
-	Action       string         `json:"action" gorm:"not null"`              // 操作类型 batch_label, batch_taint
-	Status       TaskStatus     `json:"status" gorm:"not null;index"`        // 任务状态
-	Current      int            `json:"current" gorm:"default:0"`            // 当前完成数量
-	Total        int            `json:"total" gorm:"not null"`               // 总数量
-	Progress     float64        `json:"progress" gorm:"default:0"`           // 进度百分比
-	CurrentNode  string         `json:"current_node"`                        // 当前处理的节点
-	SuccessNodes string         `json:"success_nodes" gorm:"type:text"`      // 成功节点列表(JSON)
-	FailedNodes  string         `json:"failed_nodes" gorm:"type:text"`       // 失败节点列表(JSON)
-	Message      string         `json:"message"`                             // 状态消息
-	ErrorMsg     string         `json:"error_msg"`                           // 错误消息
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	CompletedAt  *time.Time     `json:"completed_at"` // 完成时间
-	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primarykey"`
+	TaskID          string         `json:"task_id" gorm:"uniqueIndex;not null"`   // 任务唯一标识
+	UserID          uint           `json:"user_id" gorm:"not null;index"`         // 所属用户
+	Action          string         `json:"action" gorm:"not null"`                // 操作类型 batch_label, batch_taint
+	Status          TaskStatus     `json:"status" gorm:"not null;index"`          // 任务状态
+	Current         int            `json:"current" gorm:"default:0"`              // 当前完成数量
+	Total           int            `json:"total" gorm:"not null"`                 // 总数量
+	Progress        float64        `json:"progress" gorm:"default:0"`             // 进度百分比
+	CurrentNode     string         `json:"current_node"`                          // 当前处理的节点
+	SuccessNodes    string         `json:"success_nodes" gorm:"type:text"`        // 成功节点列表(JSON)
+	FailedNodes     string         `json:"failed_nodes" gorm:"type:text"`         // 失败节点列表(JSON)
+	Message         string         `json:"message"`                               // 状态消息
+	ErrorMsg        string         `json:"error_msg"`                             // 错误消息
+	NodePlan        string         `json:"-" gorm:"type:text"`                    // 计划处理的全部节点(JSON)
+	Params          string         `json:"-" gorm:"type:text"`                    // 重建处理器所需的请求参数(JSON)
+	Concurrency     int            `json:"concurrency" gorm:"default:0"`          // 并发数
+	Owner           string         `json:"owner" gorm:"index"`                    // 执行任务的副本标识
+	HeartbeatAt     *time.Time     `json:"heartbeat_at"`                          // 执行副本最后心跳时间
+	CancelRequested bool           `json:"cancel_requested" gorm:"default:false"` // 是否已请求取消
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	CompletedAt     *time.Time     `json:"completed_at"` // 完成时间
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // ProgressMessage 待发送的进度消息模型
@@ -58,6 +65,7 @@ type ProgressMessage struct {
 	FailedNodes  string         `json:"failed_nodes" gorm:"type:text"`        // 失败节点列表(JSON)
 	Message      string         `json:"message"`                              // 消息内容
 	ErrorMsg     string         `json:"error_msg"`                            // 错误信息
+	PendingNodes string         `json:"pending_nodes" gorm:"type:text"`       // 未执行节点列表(JSON)，取消或中断时填充
 	Processed    bool           `json:"processed" gorm:"default:false;index"` // 是否已处理
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
@@ -71,6 +79,8 @@ func (pt *ProgressTask) ToProgressMessage() *ProgressMessage {
 		msgType = "complete"
 	} else if pt.Status == TaskStatusFailed {
 		msgType = "error"
+	} else if pt.Status == TaskStatusCancelled {
+		msgType = "cancelled"
 	}
 
 	return &ProgressMessage{
@@ -116,6 +126,45 @@ func (pt *ProgressTask) MarkFailed(errorMsg string) {
 	pt.UpdatedAt = now
 }
 
+// MarkCancelled 标记任务已取消
+func (pt *ProgressTask) MarkCancelled() {
+	pt.Status = TaskStatusCancelled
+	now := time.Now()
+	pt.CompletedAt = &now
+	pt.UpdatedAt = now
+}
+
+// PendingNodeList 返回计划中既未成功也未失败的节点
+func (pt *ProgressTask) PendingNodeList() []string {
+	var plan, success []string
+	var failed []NodeError
+	if pt.NodePlan == "" || json.Unmarshal([]byte(pt.NodePlan), &plan) != nil {
+		return nil
+	}
+	if pt.SuccessNodes != "" {
+		json.Unmarshal([]byte(pt.SuccessNodes), &success)
+	}
+	if pt.FailedNodes != "" {
+		json.Unmarshal([]byte(pt.FailedNodes), &failed)
+	}
+
+	done := make(map[string]bool, len(success)+len(failed))
+	for _, node := range success {
+		done[node] = true
+	}
+	for _, nodeErr := range failed {
+		done[nodeErr.NodeName] = true
+	}
+
+	pending := []string{}
+	for _, node := range plan {
+		if !done[node] {
+			pending = append(pending, node)
+		}
+	}
+	return pending
+}
+
 // IsCompleted 检查任务是否完成
 func (pt *ProgressTask) IsCompleted() bool {
 	return pt.Status == TaskStatusCompleted || pt.Status == TaskStatusFailed || pt.Status == TaskStatusCancelled
@@ -64,6 +64,12 @@ func (m *Manager) RegisterPodEventHandler(handler informer.PodEventHandler) {
 	}
 }
 
+// RegisterK8sEventHandler 注册节点 Kubernetes Event 处理器
+// 注册了处理器后，集群注册时才会启动 Event Informer
+func (m *Manager) RegisterK8sEventHandler(handler informer.K8sEventHandler) {
+	m.informerSvc.RegisterEventHandler(handler)
+}
+
 // Start 启动管理器
 func (m *Manager) Start() {
 	// 启动 WebSocket Hub
@@ -121,6 +127,13 @@ func (m *Manager) RegisterCluster(clusterName string, clientset *kubernetes.Clie
 				m.podCountCache.MarkSynced(clusterName)
 			}
 		}
+
+		// 节点 Event Informer（用于持久化节点事件）
+		if m.informerSvc.HasEventHandlers() {
+			if err := m.informerSvc.StartEventInformer(clusterName); err != nil {
+				m.logger.Warningf("Failed to start Event Informer for cluster %s: %v", clusterName, err)
+			}
+		}
 	}()
 
 	m.logger.Infof("Cluster registered: %s (Pod Informer will start in 10s)", clusterName)
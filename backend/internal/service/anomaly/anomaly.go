@@ -8,6 +8,7 @@ import (
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/cluster"
 	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/internal/service/nodemetrics"
 	"kube-node-manager/pkg/logger"
 	"sync"
 	"time"
@@ -29,6 +30,9 @@ type Service struct {
 	ctx        context.Context
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
+
+	nodeMetricsSvc *nodemetrics.Service // 节点资源指标历史，未设置时不提供异常前后的指标
+	eventMu        sync.Mutex           // 串行处理节点事件触发的异常，避免重复创建
 }
 
 // CacheTTL 缓存TTL配置
@@ -182,6 +186,16 @@ func (s *Service) checkClusterNodes(cluster model.Cluster) error {
 	// 检查之前活跃的异常是否已恢复
 	for nodeName, anomalyMap := range activeAnomalyMap {
 		for anomalyType, anomaly := range anomalyMap {
+			// 事件触发的异常不由节点状态判断，静默一段时间后才恢复
+			if isEventAnomalyType(anomalyType) {
+				if time.Since(anomaly.LastCheck) >= eventAnomalyQuietPeriod {
+					if err := s.resolveAnomaly(anomaly); err != nil {
+						s.logger.Errorf("Failed to resolve anomaly for node %s: %v", nodeName, err)
+					}
+				}
+				continue
+			}
+
 			// 如果当前检测中没有这个异常，说明已经恢复
 			if currentAnomalies[nodeName] == nil || !currentAnomalies[nodeName][anomalyType] {
 				if err := s.resolveAnomaly(anomaly); err != nil {
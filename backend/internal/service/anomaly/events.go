@@ -0,0 +1,99 @@
+package anomaly
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// eventAnomalyQuietPeriod 事件触发的异常在该时长内没有再次上报时视为恢复
+const eventAnomalyQuietPeriod = 30 * time.Minute
+
+// eventAnomalyRules 节点 Event 原因到异常类型的映射
+// 来源包括 kubelet（SystemOOM、ImageGCFailed、Rebooted 等）和 node-problem-detector（OOMKilling、KernelOops）
+var eventAnomalyRules = map[string]model.AnomalyType{
+	"SystemOOM":           model.AnomalyTypeOOMKilling,
+	"OOMKilling":          model.AnomalyTypeOOMKilling,
+	"ImageGCFailed":       model.AnomalyTypeImageGCFailed,
+	"FreeDiskSpaceFailed": model.AnomalyTypeImageGCFailed,
+	"Rebooted":            model.AnomalyTypeRebooted,
+	"KernelOops":          model.AnomalyTypeKernelOops,
+}
+
+// isEventAnomalyType 判断异常类型是否由节点 Event 触发
+func isEventAnomalyType(anomalyType model.AnomalyType) bool {
+	for _, t := range eventAnomalyRules {
+		if t == anomalyType {
+			return true
+		}
+	}
+	return false
+}
+
+// OnNodeEventRecorded 实现 nodeevent.Listener 接口，根据节点 Event 记录异常
+// 同一节点同类型的异常进行中时只刷新最后上报时间和消息
+func (s *Service) OnNodeEventRecorded(event model.NodeEvent) {
+	if !s.enabled {
+		return
+	}
+	anomalyType, ok := eventAnomalyRules[event.Reason]
+	if !ok {
+		return
+	}
+	// Informer 初始同步会带来已过去较久的 Event，这类事件不再触发新的异常
+	if time.Since(event.LastTimestamp) >= eventAnomalyQuietPeriod {
+		return
+	}
+
+	if err := s.recordEventAnomaly(event, anomalyType); err != nil {
+		s.logger.Errorf("Failed to record anomaly from event for node %s: %v", event.NodeName, err)
+	}
+}
+
+// recordEventAnomaly 创建或刷新事件触发的异常
+func (s *Service) recordEventAnomaly(event model.NodeEvent, anomalyType model.AnomalyType) error {
+	s.eventMu.Lock()
+	defer s.eventMu.Unlock()
+
+	var existing model.NodeAnomaly
+	err := s.db.Where("cluster_id = ? AND node_name = ? AND anomaly_type = ? AND status = ?",
+		event.ClusterID, event.NodeName, anomalyType, model.AnomalyStatusActive).
+		First(&existing).Error
+	if err == nil {
+		if !event.LastTimestamp.After(existing.LastCheck) {
+			return nil
+		}
+		existing.LastCheck = event.LastTimestamp
+		existing.Reason = event.Reason
+		existing.Message = event.Message
+		return s.db.Save(&existing).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to get active anomaly: %w", err)
+	}
+
+	newAnomaly := model.NodeAnomaly{
+		ClusterID:   event.ClusterID,
+		ClusterName: event.ClusterName,
+		NodeName:    event.NodeName,
+		AnomalyType: anomalyType,
+		Status:      model.AnomalyStatusActive,
+		StartTime:   event.LastTimestamp,
+		LastCheck:   event.LastTimestamp,
+		Reason:      event.Reason,
+		Message:     event.Message,
+	}
+	if err := s.db.Create(&newAnomaly).Error; err != nil {
+		return fmt.Errorf("failed to create anomaly record: %w", err)
+	}
+
+	s.logger.Infof("New anomaly detected from event: cluster=%s, node=%s, type=%s, reason=%s",
+		event.ClusterName, event.NodeName, anomalyType, event.Reason)
+
+	s.invalidateCache(event.ClusterID)
+	return nil
+}
@@ -0,0 +1,48 @@
+package anomaly
+
+import (
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/service/nodemetrics"
+)
+
+// defaultMetricsWindowPadding 异常前后额外展示的指标时长
+const defaultMetricsWindowPadding = 30 * time.Minute
+
+// SetNodeMetricsService 设置节点资源指标历史服务，用于关联异常前后的资源使用情况
+func (s *Service) SetNodeMetricsService(svc *nodemetrics.Service) {
+	s.nodeMetricsSvc = svc
+}
+
+// GetMetricsWindow 获取异常发生前后一段时间内该节点的资源使用历史
+// 进行中的异常以当前时间作为结束时间
+func (s *Service) GetMetricsWindow(id uint, padding time.Duration) (*nodemetrics.QueryResult, error) {
+	if s.nodeMetricsSvc == nil {
+		return nil, fmt.Errorf("node metrics history is not available")
+	}
+
+	anomaly, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if padding <= 0 {
+		padding = defaultMetricsWindowPadding
+	}
+	now := time.Now()
+	end := now
+	if anomaly.EndTime != nil {
+		end = anomaly.EndTime.Add(padding)
+		if end.After(now) {
+			end = now
+		}
+	}
+
+	return s.nodeMetricsSvc.Query(nodemetrics.QueryRequest{
+		ClusterName: anomaly.ClusterName,
+		NodeNames:   []string{anomaly.NodeName},
+		Start:       anomaly.StartTime.Add(-padding),
+		End:         end,
+	})
+}
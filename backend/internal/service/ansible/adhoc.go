@@ -0,0 +1,567 @@
+package ansible
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kube-node-manager/internal/model"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxAdhocOutputSize 单台主机保存的最大输出（字节）
+const maxAdhocOutputSize = 64 * 1024
+
+var (
+	// ErrAdhocDisabled 临时命令功能未启用
+	ErrAdhocDisabled = errors.New("ad-hoc command execution is disabled")
+	// ErrAdhocRejected 命令或目标不符合临时命令策略
+	ErrAdhocRejected = errors.New("ad-hoc command rejected")
+)
+
+// adhocHeaderPattern 匹配 ansible 临时命令每台主机输出的首行，例如：
+//
+//	node-1 | CHANGED | rc=0 >>
+//	node-2 | FAILED | rc=1 >>
+//	node-3 | UNREACHABLE! => {
+var adhocHeaderPattern = regexp.MustCompile(`^(\S+) \| (CHANGED|SUCCESS|FAILED!?|UNREACHABLE!|SKIPPED)(?: \| rc=(-?\d+))?(?: (>>|=>)(.*))?$`)
+
+// commandSeparatorPattern 拆分 shell 命令中的多个子命令
+var commandSeparatorPattern = regexp.MustCompile(`\|\||&&|[;|&\n]`)
+
+// AdhocPolicy 临时命令执行策略
+type AdhocPolicy struct {
+	Enabled         bool
+	AllowedCommands []string // 非空时命令中出现的每个程序都必须在列表中
+	DeniedPatterns  []string // 在内置危险命令之外额外禁止的命令片段
+	DefaultForks    int      // 默认并发主机数
+	MaxForks        int      // 最大并发主机数
+	DefaultTimeout  int      // 单台主机默认命令超时（秒）
+	MaxTimeout      int      // 单台主机最大命令超时（秒）
+}
+
+// DefaultAdhocPolicy 返回默认的临时命令策略
+func DefaultAdhocPolicy() AdhocPolicy {
+	return AdhocPolicy{
+		Enabled:        true,
+		DefaultForks:   10,
+		MaxForks:       50,
+		DefaultTimeout: 60,
+		MaxTimeout:     3600,
+	}
+}
+
+// SetAdhocPolicy 设置临时命令策略，未设置的数值使用默认值
+func (s *Service) SetAdhocPolicy(policy AdhocPolicy) {
+	defaults := DefaultAdhocPolicy()
+	if policy.DefaultForks <= 0 {
+		policy.DefaultForks = defaults.DefaultForks
+	}
+	if policy.MaxForks <= 0 {
+		policy.MaxForks = defaults.MaxForks
+	}
+	if policy.DefaultTimeout <= 0 {
+		policy.DefaultTimeout = defaults.DefaultTimeout
+	}
+	if policy.MaxTimeout <= 0 {
+		policy.MaxTimeout = defaults.MaxTimeout
+	}
+	s.adhocPolicy = policy
+}
+
+// GetAdhocPolicy 获取临时命令策略
+func (s *Service) GetAdhocPolicy() AdhocPolicy {
+	return s.adhocPolicy
+}
+
+// CreateAdhocTask 创建并执行临时命令任务
+// 命令通过 ansible -m shell/command 在清单中的目标主机上并发执行，每台主机的结果单独保存
+func (s *Service) CreateAdhocTask(req model.AdhocCommandRequest, userID uint) (*model.AnsibleTask, error) {
+	policy := s.adhocPolicy
+	if !policy.Enabled {
+		return nil, ErrAdhocDisabled
+	}
+
+	module := req.Module
+	if module == "" {
+		module = model.AdhocModuleShell
+	}
+	args := strings.TrimSpace(req.Args)
+	if err := validateAdhocCommand(policy, module, args); err != nil {
+		return nil, err
+	}
+
+	forks := req.Forks
+	if forks <= 0 {
+		forks = policy.DefaultForks
+	}
+	if forks > policy.MaxForks {
+		return nil, fmt.Errorf("%w: forks must not exceed %d", ErrAdhocRejected, policy.MaxForks)
+	}
+	commandTimeout := req.CommandTimeout
+	if commandTimeout <= 0 {
+		commandTimeout = policy.DefaultTimeout
+	}
+	if commandTimeout > policy.MaxTimeout {
+		return nil, fmt.Errorf("%w: command_timeout must not exceed %d seconds", ErrAdhocRejected, policy.MaxTimeout)
+	}
+
+	inventory, err := s.inventorySvc.GetInventory(req.InventoryID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inventory: %w", err)
+	}
+	hosts, err := s.inventorySvc.ResolveTargetHosts(inventory, req.Hosts, req.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = "Ad-hoc: " + truncateString(args, 80)
+	}
+
+	config := &model.AdhocCommandConfig{
+		Module:         module,
+		Args:           args,
+		Hosts:          hosts,
+		LabelSelector:  req.LabelSelector,
+		Forks:          forks,
+		CommandTimeout: commandTimeout,
+	}
+
+	now := time.Now()
+	task := &model.AnsibleTask{
+		Name:            name,
+		ClusterID:       inventory.ClusterID,
+		InventoryID:     &inventory.ID,
+		Status:          model.AnsibleTaskStatusPending,
+		UserID:          userID,
+		PlaybookContent: adhocCommandLine(config),
+		Adhoc:           config,
+		TimeoutSeconds:  req.TimeoutSeconds,
+		Priority:        string(model.TaskPriorityMedium),
+		QueuedAt:        &now,
+		HostsTotal:      len(hosts),
+	}
+
+	if err := s.db.Create(task).Error; err != nil {
+		s.logger.Errorf("Failed to create ad-hoc task: %v", err)
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+
+	s.logger.Infof("Created ad-hoc task: %s (ID: %d) by user %d on %d hosts", task.Name, task.ID, userID, len(hosts))
+
+	go func() {
+		if err := s.executor.ExecuteTask(task.ID); err != nil {
+			s.logger.Errorf("Failed to execute ad-hoc task %d: %v", task.ID, err)
+
+			task.Status = model.AnsibleTaskStatusFailed
+			task.ErrorMsg = err.Error()
+			if err := s.db.Save(task).Error; err != nil {
+				s.logger.Errorf("Failed to update task status: %v", err)
+			}
+		}
+	}()
+
+	return task, nil
+}
+
+// GetHostResults 获取临时命令任务各主机的执行结果，并按输出分组便于跨主机对比
+func (s *Service) GetHostResults(taskID uint) ([]model.AnsibleHostResult, []model.AdhocResultGroup, error) {
+	var task model.AnsibleTask
+	if err := s.db.Select("id", "adhoc").First(&task, taskID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, fmt.Errorf("task not found")
+		}
+		return nil, nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if !task.IsAdhoc() {
+		return nil, nil, fmt.Errorf("task %d is not an ad-hoc command task", taskID)
+	}
+
+	var results []model.AnsibleHostResult
+	if err := s.db.Where("task_id = ?", taskID).Order("host").Find(&results).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get host results: %w", err)
+	}
+	return results, groupHostResults(results), nil
+}
+
+// groupHostResults 按执行状态、退出码和输出分组，主机最多的分组排在最前
+func groupHostResults(results []model.AnsibleHostResult) []model.AdhocResultGroup {
+	groups := make([]model.AdhocResultGroup, 0)
+	index := make(map[string]int)
+	for _, result := range results {
+		key := fmt.Sprintf("%s/%d/%s", result.Status, result.ReturnCode, result.OutputHash)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, model.AdhocResultGroup{
+				Status:     result.Status,
+				ReturnCode: result.ReturnCode,
+				OutputHash: result.OutputHash,
+				Output:     result.Output,
+			})
+		}
+		groups[i].Hosts = append(groups[i].Hosts, result.Host)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i].Hosts) > len(groups[j].Hosts) })
+	return groups
+}
+
+// validateAdhocCommand 按策略校验临时命令
+// 配置了允许列表时，禁止命令替换和输出重定向，避免绕过允许列表执行其他程序或写文件
+func validateAdhocCommand(policy AdhocPolicy, module, args string) error {
+	if module != model.AdhocModuleShell && module != model.AdhocModuleCommand {
+		return fmt.Errorf("%w: unsupported module %q, only shell and command are allowed", ErrAdhocRejected, module)
+	}
+	if args == "" {
+		return fmt.Errorf("%w: command is required", ErrAdhocRejected)
+	}
+
+	patterns := append(append([]string{}, dangerousCommandPatterns...), policy.DeniedPatterns...)
+	if pattern := findDangerousCommand(args, patterns); pattern != "" {
+		return fmt.Errorf("%w: command contains denied pattern %q", ErrAdhocRejected, pattern)
+	}
+
+	if len(policy.AllowedCommands) == 0 {
+		return nil
+	}
+	if strings.Contains(args, "`") || strings.Contains(args, "$(") || strings.Contains(args, "<(") || strings.Contains(args, ">") {
+		return fmt.Errorf("%w: command substitution and redirection are not allowed when an allow list is configured", ErrAdhocRejected)
+	}
+	allowed := make(map[string]bool, len(policy.AllowedCommands))
+	for _, command := range policy.AllowedCommands {
+		allowed[command] = true
+	}
+	for _, program := range commandPrograms(args) {
+		if !allowed[program] {
+			return fmt.Errorf("%w: %q is not in the allowed command list", ErrAdhocRejected, program)
+		}
+	}
+	return nil
+}
+
+// commandPrograms 返回命令中各子命令调用的程序名（跳过环境变量赋值和 sudo，路径只保留文件名）
+func commandPrograms(args string) []string {
+	programs := make([]string, 0)
+	for _, segment := range commandSeparatorPattern.Split(args, -1) {
+		for _, field := range strings.Fields(segment) {
+			if field == "sudo" || (strings.Contains(field, "=") && !strings.HasPrefix(field, "=")) {
+				continue
+			}
+			programs = append(programs, path.Base(field))
+			break
+		}
+	}
+	return programs
+}
+
+// adhocCommandLine 生成临时命令的等效命令行，保存在任务的 Playbook 内容中用于展示
+func adhocCommandLine(config *model.AdhocCommandConfig) string {
+	return fmt.Sprintf("ansible all --limit %s -m %s -a %s -f %d",
+		strings.Join(config.Hosts, ","), config.Module, strconv.Quote(config.Args), config.Forks)
+}
+
+// truncateString 截断字符串到指定长度（按字符）
+func truncateString(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "..."
+}
+
+// ResolveTargetHosts 解析临时命令的目标主机
+// 从清单（动态清单实时解析）中取主机，再按主机名列表和节点标签选择器依次缩小范围
+// 标签选择器按 K8s 节点名与清单主机名匹配，要求清单关联集群
+func (s *InventoryService) ResolveTargetHosts(inventory *model.AnsibleInventory, hosts []string, labelSelector string) ([]string, error) {
+	content, err := s.ResolveContent(inventory)
+	if err != nil {
+		return nil, err
+	}
+	targets := listInventoryHosts(content)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("inventory %s has no hosts", inventory.Name)
+	}
+
+	if len(hosts) > 0 {
+		known := make(map[string]bool, len(targets))
+		for _, host := range targets {
+			known[host] = true
+		}
+		selected := make([]string, 0, len(hosts))
+		seen := make(map[string]bool, len(hosts))
+		var unknown []string
+		for _, host := range hosts {
+			if seen[host] {
+				continue
+			}
+			seen[host] = true
+			if !known[host] {
+				unknown = append(unknown, host)
+				continue
+			}
+			selected = append(selected, host)
+		}
+		if len(unknown) > 0 {
+			return nil, fmt.Errorf("%w: hosts not found in inventory %s: %s", ErrAdhocRejected, inventory.Name, strings.Join(unknown, ","))
+		}
+		targets = selected
+	}
+
+	if labelSelector != "" {
+		if inventory.ClusterID == nil {
+			return nil, fmt.Errorf("%w: label_selector requires an inventory associated with a cluster", ErrAdhocRejected)
+		}
+		var cluster model.Cluster
+		if err := s.db.First(&cluster, *inventory.ClusterID).Error; err != nil {
+			return nil, fmt.Errorf("failed to get cluster: %w", err)
+		}
+		nodes, err := s.k8sSvc.ListNodes(cluster.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		matched, err := filterK8sNodes(nodes, &model.K8sInventoryFilter{LabelSelector: labelSelector, IncludeControlPlane: true})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrAdhocRejected, err)
+		}
+		names := make(map[string]bool, len(matched))
+		for _, node := range matched {
+			names[node.Name] = true
+		}
+		selected := make([]string, 0, len(targets))
+		for _, host := range targets {
+			if names[host] {
+				selected = append(selected, host)
+			}
+		}
+		targets = selected
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: no hosts match the specified targets", ErrAdhocRejected)
+	}
+	return targets, nil
+}
+
+// buildAdhocCommand 构建 ansible 临时命令
+// 单台主机的命令超时通过 ANSIBLE_TASK_TIMEOUT 控制，整个任务的超时由 ctx 控制
+func (e *TaskExecutor) buildAdhocCommand(ctx context.Context, files *taskFiles, task *model.AnsibleTask) *exec.Cmd {
+	config := task.Adhoc
+	args := []string{
+		"all",
+		"-i", files.Inventory,
+		"-m", config.Module,
+		"-a", config.Args,
+		"-f", strconv.Itoa(config.Forks),
+		"--limit", strings.Join(config.Hosts, ","),
+	}
+	if files.SSHKey != "" {
+		args = append(args, "--private-key", files.SSHKey)
+		args = append(args, sshFallbackArgs(files)...)
+	}
+
+	cmd := exec.CommandContext(ctx, "ansible", args...)
+	cmd.Dir = e.workDir
+	cmd.Env = append(os.Environ(),
+		"ANSIBLE_HOST_KEY_CHECKING=False",
+		"ANSIBLE_REMOTE_TMP=/tmp/.ansible-${USER}/tmp",
+		"ANSIBLE_TASK_TIMEOUT="+strconv.Itoa(config.CommandTimeout),
+	)
+
+	e.logger.Infof("Task %d: Executing ad-hoc command on %d hosts (module: %s, forks: %d, timeout: %ds)",
+		task.ID, len(config.Hosts), config.Module, config.Forks, config.CommandTimeout)
+	return cmd
+}
+
+// adhocHostHeader 主机输出首行的解析结果
+type adhocHostHeader struct {
+	Host       string
+	Status     string
+	ReturnCode int
+	JSON       bool   // 结果为 JSON（=>），否则为命令输出（>>）
+	Rest       string // 首行剩余内容
+}
+
+// parseAdhocHeader 解析主机输出首行，只接受目标主机，避免命令输出中的相似内容被误判
+func parseAdhocHeader(line string, hosts map[string]bool) *adhocHostHeader {
+	match := adhocHeaderPattern.FindStringSubmatch(line)
+	if match == nil || !hosts[match[1]] {
+		return nil
+	}
+
+	header := &adhocHostHeader{Host: match[1], ReturnCode: -1, JSON: match[4] == "=>", Rest: match[5]}
+	switch match[2] {
+	case "CHANGED":
+		header.Status = model.HostResultChanged
+	case "SUCCESS":
+		header.Status = model.HostResultOk
+	case "UNREACHABLE!":
+		header.Status = model.HostResultUnreachable
+	case "SKIPPED":
+		header.Status = model.HostResultSkipped
+	default:
+		header.Status = model.HostResultFailed
+	}
+	if match[3] != "" {
+		header.ReturnCode, _ = strconv.Atoi(match[3])
+	}
+	return header
+}
+
+// newHostResult 根据首行和后续输出生成主机结果
+func newHostResult(header *adhocHostHeader, body []string) model.AnsibleHostResult {
+	output := strings.TrimRight(strings.Join(body, "\n"), "\n")
+	returnCode := header.ReturnCode
+
+	if header.JSON {
+		raw := strings.TrimSpace(header.Rest + "\n" + output)
+		var parsed struct {
+			RC     *int   `json:"rc"`
+			Stdout string `json:"stdout"`
+			Stderr string `json:"stderr"`
+			Msg    string `json:"msg"`
+		}
+		output = raw
+		if err := json.Unmarshal([]byte(raw), &parsed); err == nil {
+			parts := make([]string, 0, 3)
+			for _, part := range []string{parsed.Stdout, parsed.Stderr, parsed.Msg} {
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+			output = strings.Join(parts, "\n")
+			if parsed.RC != nil {
+				returnCode = *parsed.RC
+			}
+		}
+	}
+
+	result := model.AnsibleHostResult{
+		Host:       header.Host,
+		Status:     header.Status,
+		ReturnCode: returnCode,
+		Output:     output,
+	}
+	if len(result.Output) > maxAdhocOutputSize {
+		result.Output = strings.ToValidUTF8(result.Output[:maxAdhocOutputSize], "")
+		result.Truncated = true
+	}
+	sum := sha256.Sum256([]byte(output))
+	result.OutputHash = hex.EncodeToString(sum[:])
+	return result
+}
+
+// parseAdhocOutput 从任务完整日志中解析每台主机的结果，按目标主机顺序返回
+// 没有输出结果的目标主机（超时或被取消）记为 no_result
+func parseAdhocOutput(fullLog string, hosts []string) []model.AnsibleHostResult {
+	hostSet := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		hostSet[host] = true
+	}
+
+	parsed := make(map[string]model.AnsibleHostResult, len(hosts))
+	var current *adhocHostHeader
+	var body []string
+	flush := func() {
+		if current != nil {
+			parsed[current.Host] = newHostResult(current, body)
+		}
+	}
+
+	for _, line := range strings.Split(fullLog, "\n") {
+		// 主机输出只出现在 stdout，stderr 中是 ansible 自身的警告
+		content, ok := strings.CutPrefix(line, "["+string(model.AnsibleLogTypeStdout)+"] ")
+		if !ok {
+			continue
+		}
+		if header := parseAdhocHeader(content, hostSet); header != nil {
+			flush()
+			current, body = header, nil
+			continue
+		}
+		if current != nil {
+			body = append(body, content)
+		}
+	}
+	flush()
+
+	results := make([]model.AnsibleHostResult, 0, len(hosts))
+	for _, host := range hosts {
+		result, ok := parsed[host]
+		if !ok {
+			result = model.AnsibleHostResult{Host: host, Status: model.HostResultNoResult, ReturnCode: -1}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// parseAdhocResults 解析并保存临时命令各主机的结果，同时更新任务统计
+// 可重复调用（重新解析时先删除旧结果）
+func (e *TaskExecutor) parseAdhocResults(task *model.AnsibleTask) {
+	results := parseAdhocOutput(task.FullLog, task.Adhoc.Hosts)
+
+	ok, failed, skipped := 0, 0, 0
+	for i := range results {
+		results[i].TaskID = task.ID
+		switch results[i].Status {
+		case model.HostResultOk, model.HostResultChanged:
+			ok++
+		case model.HostResultSkipped:
+			skipped++
+		default:
+			failed++
+		}
+	}
+	task.UpdateStats(len(results), ok, failed, skipped)
+
+	err := e.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", task.ID).Delete(&model.AnsibleHostResult{}).Error; err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(results, 100).Error
+	})
+	if err != nil {
+		e.logger.Errorf("Failed to save host results for task %d: %v", task.ID, err)
+	}
+
+	e.logger.Infof("Task %d: ad-hoc results - total: %d, ok: %d, failed: %d, skipped: %d",
+		task.ID, len(results), ok, failed, skipped)
+}
+
+// pushAdhocHostResultToWebSocket 推送单台主机的执行状态（主机完成时即推送，输出随日志推送）
+func (e *TaskExecutor) pushAdhocHostResultToWebSocket(taskID uint, header *adhocHostHeader) {
+	if e.wsHub == nil {
+		return
+	}
+
+	type WSHub interface {
+		BroadcastToTask(taskID uint, message interface{})
+	}
+
+	if hub, ok := e.wsHub.(WSHub); ok {
+		hub.BroadcastToTask(taskID, map[string]interface{}{
+			"type":        "adhoc_host_result",
+			"task_id":     taskID,
+			"host":        header.Host,
+			"status":      header.Status,
+			"return_code": header.ReturnCode,
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ansible
+
+import (
+	"errors"
+	"kube-node-manager/internal/model"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseAdhocOutput 测试从任务日志解析每台主机的临时命令结果
+func TestParseAdhocOutput(t *testing.T) {
+	fullLog := strings.Join([]string{
+		"[stderr] [WARNING]: Platform linux on host node-1 is using the discovered Python interpreter",
+		"[stdout] node-1 | CHANGED | rc=0 >>",
+		"[stdout]  10:00:01 up 3 days,  load average: 0.10, 0.20, 0.30",
+		"[stdout] Filesystem      Size  Used Avail Use% Mounted on",
+		"[stdout] node-2 | CHANGED | rc=0 >>",
+		"[stdout]  10:00:01 up 3 days,  load average: 0.10, 0.20, 0.30",
+		"[stdout] Filesystem      Size  Used Avail Use% Mounted on",
+		"[stdout] node-3 | FAILED | rc=127 >>",
+		"[stdout] /bin/sh: df: not found",
+		"[stdout] other | SUCCESS | rc=0 >>",
+		"[stdout] node-4 | UNREACHABLE! => {",
+		`[stdout]     "changed": false,`,
+		`[stdout]     "msg": "Failed to connect to the host via ssh: Connection timed out",`,
+		`[stdout]     "unreachable": true`,
+		"[stdout] }",
+	}, "\n")
+
+	results := parseAdhocOutput(fullLog, []string{"node-1", "node-2", "node-3", "node-4", "node-5"})
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+
+	want := []struct {
+		status     string
+		returnCode int
+	}{
+		{model.HostResultChanged, 0},
+		{model.HostResultChanged, 0},
+		{model.HostResultFailed, 127},
+		{model.HostResultUnreachable, -1},
+		{model.HostResultNoResult, -1},
+	}
+	for i, w := range want {
+		if results[i].Status != w.status || results[i].ReturnCode != w.returnCode {
+			t.Errorf("%s: got status=%s rc=%d, want status=%s rc=%d",
+				results[i].Host, results[i].Status, results[i].ReturnCode, w.status, w.returnCode)
+		}
+	}
+
+	// 不在目标主机中的相似行属于上一台主机的输出
+	if !strings.HasSuffix(results[2].Output, "other | SUCCESS | rc=0 >>") {
+		t.Errorf("unexpected node-3 output: %q", results[2].Output)
+	}
+	if results[3].Output != "Failed to connect to the host via ssh: Connection timed out" {
+		t.Errorf("unexpected unreachable output: %q", results[3].Output)
+	}
+	if results[0].OutputHash != results[1].OutputHash || results[0].OutputHash == results[2].OutputHash {
+		t.Error("output hash should be equal only for identical output")
+	}
+
+	groups := groupHostResults(results)
+	if len(groups) != 4 || !reflect.DeepEqual(groups[0].Hosts, []string{"node-1", "node-2"}) {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+}
+
+// TestValidateAdhocCommand 测试临时命令的允许/禁止列表
+func TestValidateAdhocCommand(t *testing.T) {
+	open := DefaultAdhocPolicy()
+	restricted := DefaultAdhocPolicy()
+	restricted.AllowedCommands = []string{"uptime", "df", "grep", "systemctl"}
+	restricted.DeniedPatterns = []string{"systemctl stop"}
+
+	tests := []struct {
+		name    string
+		policy  AdhocPolicy
+		module  string
+		args    string
+		wantErr bool
+	}{
+		{"Any command without allow list", open, model.AdhocModuleShell, "uptime; free -m", false},
+		{"Built-in dangerous command", open, model.AdhocModuleShell, "rm -rf / --no-preserve-root", true},
+		{"Unsupported module", open, "copy", "src=/etc/hosts dest=/tmp/hosts", true},
+		{"Empty command", open, model.AdhocModuleCommand, "", true},
+		{"Allowed programs", restricted, model.AdhocModuleShell, "uptime; df -h | grep /var && /usr/bin/systemctl status kubelet", false},
+		{"Program not in allow list", restricted, model.AdhocModuleShell, "uptime; free -m", true},
+		{"Sudo and env prefix", restricted, model.AdhocModuleShell, "LANG=C sudo df -h", false},
+		{"Command substitution", restricted, model.AdhocModuleShell, "df $(cat /etc/shadow)", true},
+		{"Output redirection", restricted, model.AdhocModuleShell, "df -h > /etc/motd", true},
+		{"Configured denied pattern", restricted, model.AdhocModuleShell, "systemctl stop kubelet", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAdhocCommand(tt.policy, tt.module, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateAdhocCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrAdhocRejected) {
+				t.Errorf("expected ErrAdhocRejected, got %v", err)
+			}
+		})
+	}
+}
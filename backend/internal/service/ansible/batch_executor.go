@@ -0,0 +1,756 @@
+package ansible
+
+import (
+	"context"
+	"fmt"
+	"kube-node-manager/internal/model"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHealthCheckTimeout = 300   // 健康检查默认超时（秒）
+	defaultNodeReadyTimeout   = 600   // 等待节点 Ready 默认超时（秒）
+	defaultPauseTimeout       = 86400 // 暂停等待确认默认超时（秒）
+	nodeReadyPollInterval     = 10 * time.Second
+
+	// batchHeartbeatInterval 执行实例续期心跳并检查批次控制请求的间隔
+	batchHeartbeatInterval = 5 * time.Second
+	// batchStaleTimeout 心跳超过该时长未更新的暂停任务视为执行实例已中断，继续时由其他实例接管
+	batchStaleTimeout = time.Minute
+)
+
+// captureBatchOutput 记录当前批次的输出行（未开启批次捕获时忽略）
+func (rt *RunningTask) captureBatchOutput(line string) {
+	rt.batchMu.Lock()
+	defer rt.batchMu.Unlock()
+
+	if rt.batchOutput != nil {
+		rt.batchOutput.WriteString(line)
+		rt.batchOutput.WriteString("\n")
+	}
+}
+
+// startBatchCapture 开始捕获新批次的输出
+func (rt *RunningTask) startBatchCapture() {
+	rt.batchMu.Lock()
+	defer rt.batchMu.Unlock()
+	rt.batchOutput = &strings.Builder{}
+}
+
+// stopBatchCapture 停止捕获并返回本批次输出
+func (rt *RunningTask) stopBatchCapture() string {
+	rt.batchMu.Lock()
+	defer rt.batchMu.Unlock()
+
+	if rt.batchOutput == nil {
+		return ""
+	}
+	output := rt.batchOutput.String()
+	rt.batchOutput = nil
+	return output
+}
+
+// executeBatches 分批执行任务
+// 执行器自行拆分 inventory 主机，每个批次调用一次 ansible-playbook --limit，
+// 批次结束后评估失败阈值、执行健康检查，并在需要时暂停等待 ContinueBatchExecution
+// 任务已有批次结果时（被其他实例接管），从下一个批次继续执行
+func (e *TaskExecutor) executeBatches(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask, files *taskFiles) {
+	// 使用执行开始时快照的清单，保证批次划分与实际执行的主机一致
+	hosts := listInventoryHosts(task.InventorySnapshot)
+	if len(hosts) == 0 {
+		close(runningTask.LogChannel)
+		e.handleTaskError(task, runningTask, fmt.Errorf("inventory has no hosts for batch execution"))
+		return
+	}
+
+	task.HostsTotal = len(hosts)
+	batches := splitIntoBatches(hosts, e.calculateBatchSize(task))
+	task.TotalBatches = len(batches)
+
+	results := make(model.BatchResultList, 0, len(batches))
+	totalOk, totalFailed, totalSkipped := 0, 0, 0
+	if task.BatchResults != nil && len(*task.BatchResults) > 0 {
+		results = append(results, *task.BatchResults...)
+		totalOk, totalFailed, totalSkipped = task.HostsOk, task.HostsFailed, task.HostsSkipped
+	}
+	task.BatchResults = &results
+
+	e.logger.Infof("Task %d: Batch execution with %d hosts in %d batches, starting at batch %d",
+		task.ID, len(hosts), len(batches), len(results)+1)
+
+	// 续期心跳，并响应其他实例提交的停止请求
+	aliveCtx, stopAlive := context.WithCancel(ctx)
+	defer stopAlive()
+	go e.keepBatchAlive(aliveCtx, task.ID, runningTask)
+
+	stopReason := ""
+	startBatch := len(results)
+
+	for i := startBatch; i < len(batches); i++ {
+		if ctx.Err() != nil {
+			break
+		}
+
+		batchHosts := batches[i]
+		batchNumber := i + 1
+		results = append(results, model.BatchResult{
+			BatchNumber: batchNumber,
+			Hosts:       batchHosts,
+			Status:      "running",
+			StartedAt:   time.Now(),
+		})
+		result := &results[len(results)-1]
+
+		task.CurrentBatch = batchNumber
+		task.BatchStatus = "running"
+		task.BatchResults = &results
+		if err := e.db.Save(task).Error; err != nil {
+			e.logger.Errorf("Failed to save batch %d state for task %d: %v", batchNumber, task.ID, err)
+		}
+		e.pushBatchStatusToWebSocket(task)
+
+		e.emitSystemLog(runningTask, fmt.Sprintf("===== Batch %d/%d started (%d hosts): %s =====",
+			batchNumber, len(batches), len(batchHosts), strings.Join(batchHosts, ",")))
+
+		// 批次间可能暂停很久，后续批次使用重新签发的 SSH 证书
+		if i > startBatch {
+			if err := e.renewSSHCertFiles(task, files); err != nil {
+				stopReason = fmt.Sprintf("批次 %d: %v", batchNumber, err)
+				e.finishBatchResult(result, "failed", stopReason)
+				break
+			}
+		}
+
+		// 执行本批次
+		cmd := e.buildAnsibleCommand(ctx, files, task, batchHosts)
+		runningTask.Cmd = cmd
+
+		runningTask.startBatchCapture()
+		started, runErr := e.runCommand(cmd, runningTask)
+		output := runningTask.stopBatchCapture()
+
+		if !started {
+			stopReason = runErr.Error()
+			e.finishBatchResult(result, "failed", stopReason)
+			break
+		}
+
+		if ctx.Err() != nil {
+			e.finishBatchResult(result, "cancelled", "")
+			break
+		}
+
+		// 统计本批次结果
+		ok, skipped, failedHosts := evaluateBatchOutput(output, batchHosts, runErr)
+		result.HostsOk = ok
+		result.HostsFailed = len(failedHosts)
+		result.FailedHosts = failedHosts
+		totalOk += ok
+		totalSkipped += skipped
+		totalFailed += len(failedHosts)
+
+		task.AddExecutionEvent(model.PhaseBatchCompleted, fmt.Sprintf("批次 %d/%d 执行完成", batchNumber, len(batches)), map[string]interface{}{
+			"batch_number": batchNumber,
+			"hosts":        len(batchHosts),
+			"hosts_ok":     ok,
+			"hosts_failed": len(failedHosts),
+			"failed_hosts": failedHosts,
+		})
+		e.emitSystemLog(runningTask, fmt.Sprintf("===== Batch %d/%d finished: ok=%d failed=%d skipped=%d =====",
+			batchNumber, len(batches), ok, len(failedHosts), skipped))
+
+		// 评估失败阈值
+		if reason := checkBatchThresholds(task.BatchConfig, len(batchHosts), len(failedHosts), totalFailed); reason != "" {
+			stopReason = fmt.Sprintf("批次 %d: %s", batchNumber, reason)
+			e.finishBatchResult(result, "failed", stopReason)
+			break
+		}
+
+		batchStatus := "success"
+		if len(failedHosts) > 0 {
+			batchStatus = "failed"
+		}
+
+		// 最后一个批次无需健康检查和暂停
+		if batchNumber == len(batches) {
+			result.HealthCheck = "skipped"
+			e.finishBatchResult(result, batchStatus, "")
+			break
+		}
+
+		// 批次间健康检查
+		healthStatus, err := e.runBatchHealthGate(ctx, task, runningTask, files, batchNumber, batchHosts)
+		result.HealthCheck = healthStatus
+		if err != nil {
+			if ctx.Err() != nil {
+				e.finishBatchResult(result, "cancelled", "")
+				break
+			}
+			stopReason = fmt.Sprintf("批次 %d 健康检查失败: %v", batchNumber, err)
+			e.finishBatchResult(result, "failed", stopReason)
+			break
+		}
+		e.finishBatchResult(result, batchStatus, "")
+
+		// 按配置或用户请求暂停
+		pauseRequested := e.takeBatchControl(task.ID, model.BatchControlPause)
+		if task.BatchConfig.PauseAfterBatch || pauseRequested {
+			task.UpdateStats(len(hosts), totalOk, totalFailed, totalSkipped)
+			if err := e.waitForBatchContinue(ctx, task, runningTask, batchNumber); err != nil {
+				if ctx.Err() == nil {
+					stopReason = err.Error()
+				}
+				break
+			}
+		}
+	}
+
+	// 关闭日志通道，并等待日志收集完成
+	close(runningTask.LogChannel)
+	<-runningTask.LogDone
+
+	runningTask.LogMutex.Lock()
+	task.FullLog = runningTask.LogBuffer.String()
+	task.LogSize = runningTask.LogSize
+	runningTask.LogMutex.Unlock()
+
+	task.UpdateStats(len(hosts), totalOk, totalFailed, totalSkipped)
+	task.BatchResults = &results
+
+	e.completeBatchTask(ctx, task, runningTask, stopReason)
+}
+
+// completeBatchTask 根据批次执行情况标记任务最终状态
+func (e *TaskExecutor) completeBatchTask(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask, stopReason string) {
+	isTimedOut := ctx.Err() == context.DeadlineExceeded
+	isCancelled := ctx.Err() == context.Canceled
+
+	var phase model.ExecutionPhase
+	var message, errorMsg string
+	success := false
+
+	switch {
+	case isTimedOut:
+		phase = model.PhaseTimeout
+		message = "任务执行超时"
+		errorMsg = fmt.Sprintf("任务执行超时（超过 %d 秒）", task.TimeoutSeconds)
+		task.BatchStatus = "stopped"
+	case isCancelled:
+		phase = model.PhaseCancelled
+		message = "任务已取消"
+		task.BatchStatus = "stopped"
+	case stopReason != "":
+		phase = model.PhaseFailed
+		message = "分批执行已停止"
+		errorMsg = stopReason
+		task.BatchStatus = "stopped"
+	case task.HostsFailed > 0:
+		phase = model.PhaseFailed
+		message = "任务执行失败"
+		errorMsg = fmt.Sprintf("有 %d 个主机执行失败", task.HostsFailed)
+		task.MarkBatchCompleted()
+	default:
+		phase = model.PhaseCompleted
+		message = "任务执行成功"
+		success = true
+		task.MarkBatchCompleted()
+	}
+
+	task.AddExecutionEvent(phase, message, map[string]interface{}{
+		"hosts_total":   task.HostsTotal,
+		"hosts_ok":      task.HostsOk,
+		"hosts_failed":  task.HostsFailed,
+		"hosts_skipped": task.HostsSkipped,
+		"current_batch": task.CurrentBatch,
+		"total_batches": task.TotalBatches,
+		"error_msg":     errorMsg,
+	})
+
+	task.IsTimedOut = isTimedOut
+	if isCancelled {
+		task.MarkCancelled()
+	} else {
+		task.MarkCompleted(success, errorMsg)
+	}
+
+	if err := e.db.Save(task).Error; err != nil {
+		e.logger.Errorf("Failed to save task completion: %v", err)
+	}
+
+	e.logger.Infof("Task %d batch execution finished at batch %d/%d, status: %s",
+		task.ID, task.CurrentBatch, task.TotalBatches, task.Status)
+
+	e.pushTaskCompletionToWebSocket(task.ID, string(task.Status))
+
+	e.mu.Lock()
+	delete(e.runningTasks, task.ID)
+	e.mu.Unlock()
+
+	if !success && !isCancelled {
+		e.checkAndRetryTask(task)
+	}
+}
+
+// finishBatchResult 记录批次结束状态
+func (e *TaskExecutor) finishBatchResult(result *model.BatchResult, status, message string) {
+	now := time.Now()
+	result.Status = status
+	result.Message = message
+	result.FinishedAt = &now
+}
+
+// waitForBatchContinue 暂停批次执行，轮询任务记录直到收到继续请求或任务被取消/暂停超时
+// 返回错误表示任务已被取消或暂停超时，不应继续执行
+func (e *TaskExecutor) waitForBatchContinue(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask, batchNumber int) error {
+	timeout := task.BatchConfig.PauseTimeout
+	if timeout <= 0 {
+		timeout = defaultPauseTimeout
+	}
+
+	task.BatchStatus = "paused"
+	task.AddExecutionEvent(model.PhaseBatchPaused, fmt.Sprintf("批次 %d/%d 已完成，等待确认继续", batchNumber, task.TotalBatches), map[string]interface{}{
+		"batch_number":  batchNumber,
+		"pause_timeout": timeout,
+	})
+	e.emitSystemLog(runningTask, fmt.Sprintf("===== Batch %d/%d paused, waiting for confirmation =====", batchNumber, task.TotalBatches))
+
+	// 保存已有日志，执行实例中断后由其他实例接管时从这里继续记录
+	runningTask.LogMutex.Lock()
+	task.FullLog = runningTask.LogBuffer.String()
+	task.LogSize = runningTask.LogSize
+	runningTask.LogMutex.Unlock()
+
+	if err := e.db.Save(task).Error; err != nil {
+		e.logger.Errorf("Failed to save paused state for task %d: %v", task.ID, err)
+	}
+	e.pushBatchStatusToWebSocket(task)
+	e.logger.Infof("Task %d: Paused after batch %d/%d", task.ID, batchNumber, task.TotalBatches)
+
+	deadline := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer deadline.Stop()
+	ticker := time.NewTicker(batchHeartbeatInterval)
+	defer ticker.Stop()
+
+	for continued := false; !continued; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("批次 %d 暂停超过 %d 秒未确认继续", batchNumber, timeout)
+		case <-ticker.C:
+			continued = e.takeBatchControl(task.ID, model.BatchControlContinue)
+		}
+	}
+
+	task.BatchStatus = "running"
+	task.AddExecutionEvent(model.PhaseExecuting, fmt.Sprintf("继续执行批次 %d/%d", batchNumber+1, task.TotalBatches), map[string]interface{}{
+		"batch_number": batchNumber + 1,
+	})
+	if err := e.db.Save(task).Error; err != nil {
+		e.logger.Errorf("Failed to save resumed state for task %d: %v", task.ID, err)
+	}
+	return nil
+}
+
+// takeBatchControl 消费任务记录中的批次控制请求，返回是否存在该请求
+// 条件更新保证同一请求只被处理一次
+func (e *TaskExecutor) takeBatchControl(taskID uint, control string) bool {
+	result := e.db.Table(model.AnsibleTask{}.TableName()).
+		Where("id = ? AND batch_control = ?", taskID, control).
+		Update("batch_control", "")
+	if result.Error != nil {
+		e.logger.Warningf("Failed to check batch control for task %d: %v", taskID, result.Error)
+		return false
+	}
+	return result.RowsAffected > 0
+}
+
+// keepBatchAlive 定期续期执行实例心跳，任务在其他实例上被停止时取消执行
+func (e *TaskExecutor) keepBatchAlive(ctx context.Context, taskID uint, runningTask *RunningTask) {
+	heartbeat := func() bool {
+		if err := e.db.Table(model.AnsibleTask{}.TableName()).
+			Where("id = ?", taskID).
+			Update("batch_heartbeat_at", time.Now()).Error; err != nil {
+			e.logger.Warningf("Failed to update batch heartbeat for task %d: %v", taskID, err)
+			return true
+		}
+
+		var state model.AnsibleTask
+		if err := e.db.Select("status").First(&state, taskID).Error; err != nil {
+			return true
+		}
+		if state.Status != model.AnsibleTaskStatusRunning {
+			e.logger.Infof("Task %d: Stopping batch execution (status=%s)", taskID, state.Status)
+			runningTask.Cancel()
+			return false
+		}
+		return true
+	}
+
+	if !heartbeat() {
+		return
+	}
+
+	ticker := time.NewTicker(batchHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !heartbeat() {
+				return
+			}
+		}
+	}
+}
+
+// resumePausedBatchTask 接管执行实例已中断的暂停任务，在当前实例上执行剩余批次
+func (e *TaskExecutor) resumePausedBatchTask(task *model.AnsibleTask) error {
+	e.mu.Lock()
+	if len(e.runningTasks) >= e.maxConcurrent {
+		e.mu.Unlock()
+		return fmt.Errorf("maximum concurrent tasks limit reached (%d)", e.maxConcurrent)
+	}
+	if _, exists := e.runningTasks[task.ID]; exists {
+		e.mu.Unlock()
+		return fmt.Errorf("task is already running")
+	}
+
+	// 条件更新保证多个实例同时继续时只有一个实例接管
+	now := time.Now()
+	result := e.db.Table(model.AnsibleTask{}.TableName()).
+		Where("id = ? AND status = ? AND batch_status = ? AND (batch_heartbeat_at IS NULL OR batch_heartbeat_at < ?)",
+			task.ID, model.AnsibleTaskStatusRunning, "paused", now.Add(-batchStaleTimeout)).
+		Updates(map[string]interface{}{
+			"batch_heartbeat_at": now,
+			"batch_control":      "",
+		})
+	if result.Error != nil {
+		e.mu.Unlock()
+		return fmt.Errorf("failed to take over task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		e.mu.Unlock()
+		return fmt.Errorf("task has already been taken over by another instance")
+	}
+
+	// 超时从任务开始时间计算
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if task.TimeoutSeconds > 0 && task.StartedAt != nil {
+		ctx, cancel = context.WithDeadline(context.Background(), task.StartedAt.Add(time.Duration(task.TimeoutSeconds)*time.Second))
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	runningTask := e.newRunningTask(task.ID, cancel)
+	e.runningTasks[task.ID] = runningTask
+	e.mu.Unlock()
+
+	e.logger.Infof("Task %d: Taking over paused batch execution at batch %d/%d", task.ID, task.CurrentBatch, task.TotalBatches)
+	go e.resumeBatchTaskAsync(ctx, task, runningTask)
+	return nil
+}
+
+// resumeBatchTaskAsync 重新准备执行文件，从下一个批次继续执行
+func (e *TaskExecutor) resumeBatchTaskAsync(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask) {
+	runningTask.LogBuffer.WriteString(task.FullLog)
+	runningTask.LogSize = task.LogSize
+
+	task.BatchStatus = "running"
+	task.AddExecutionEvent(model.PhaseExecuting, fmt.Sprintf("原执行实例已中断，接管并继续执行批次 %d/%d", task.CurrentBatch+1, task.TotalBatches), map[string]interface{}{
+		"batch_number": task.CurrentBatch + 1,
+	})
+
+	files := &taskFiles{}
+	defer files.Remove()
+
+	if err := e.prepareTaskFiles(ctx, task, runningTask, files); err != nil {
+		e.handleTaskError(task, runningTask, err)
+		return
+	}
+
+	go e.collectLogs(runningTask)
+	e.emitSystemLog(runningTask, fmt.Sprintf("===== Batch execution taken over, continuing with batch %d/%d =====", task.CurrentBatch+1, task.TotalBatches))
+	e.executeBatches(ctx, task, runningTask, files)
+}
+
+// runBatchHealthGate 执行批次间的健康检查
+// 返回健康检查结果（passed/failed/skipped）
+func (e *TaskExecutor) runBatchHealthGate(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask, files *taskFiles, batchNumber int, hosts []string) (string, error) {
+	config := task.BatchConfig
+	if config.HealthCheckCommand == "" && !config.WaitForNodesReady {
+		return "skipped", nil
+	}
+
+	task.AddExecutionEvent(model.PhaseHealthCheck, fmt.Sprintf("批次 %d/%d 健康检查", batchNumber, task.TotalBatches), map[string]interface{}{
+		"batch_number":         batchNumber,
+		"wait_for_nodes_ready": config.WaitForNodesReady,
+		"has_command":          config.HealthCheckCommand != "",
+	})
+
+	if config.WaitForNodesReady {
+		if err := e.waitForNodesReady(ctx, task, runningTask, hosts); err != nil {
+			return "failed", err
+		}
+	}
+
+	if config.HealthCheckCommand != "" {
+		if err := e.runHealthCheckCommand(ctx, task, runningTask, files, batchNumber, hosts); err != nil {
+			return "failed", err
+		}
+	}
+
+	return "passed", nil
+}
+
+// runHealthCheckCommand 在本批次主机上执行健康检查命令
+// 命令通过 ansible -m command 在目标主机上执行（不经过 shell），任一主机退出码非 0 或不可达视为失败
+func (e *TaskExecutor) runHealthCheckCommand(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask, files *taskFiles, batchNumber int, hosts []string) error {
+	command := task.BatchConfig.HealthCheckCommand
+	// 兼容校验规则加入前创建的任务
+	if pattern := findDangerousCommand(command, dangerousCommandPatterns); pattern != "" {
+		return fmt.Errorf("health check command contains denied pattern %q", pattern)
+	}
+
+	timeout := task.BatchConfig.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	cmd := e.buildHealthCheckCommand(checkCtx, files, command, hosts, timeout)
+
+	e.emitSystemLog(runningTask, fmt.Sprintf("Running health check for batch %d on %d hosts", batchNumber, len(hosts)))
+	output, err := cmd.CombinedOutput()
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if line != "" {
+			e.emitSystemLog(runningTask, "[health-check] "+runningTask.Sanitizer.Sanitize(line))
+		}
+	}
+
+	if checkCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("health check timed out after %d seconds", timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("health check command failed: %w", err)
+	}
+
+	e.emitSystemLog(runningTask, fmt.Sprintf("Health check for batch %d passed", batchNumber))
+	return nil
+}
+
+// buildHealthCheckCommand 构建在批次主机上执行健康检查的 ansible 临时命令
+// 与临时命令任务使用相同的清单和连接参数
+func (e *TaskExecutor) buildHealthCheckCommand(ctx context.Context, files *taskFiles, command string, hosts []string, timeout int) *exec.Cmd {
+	forks := len(hosts)
+	if maxForks := DefaultAdhocPolicy().MaxForks; forks > maxForks {
+		forks = maxForks
+	}
+	args := []string{
+		"all",
+		"-i", files.Inventory,
+		"-m", model.AdhocModuleCommand,
+		"-a", command,
+		"-f", strconv.Itoa(forks),
+		"--limit", strings.Join(hosts, ","),
+	}
+	if files.SSHKey != "" {
+		args = append(args, "--private-key", files.SSHKey)
+		args = append(args, sshFallbackArgs(files)...)
+	}
+
+	cmd := exec.CommandContext(ctx, "ansible", args...)
+	cmd.Dir = e.workDir
+	cmd.Env = append(os.Environ(),
+		"ANSIBLE_HOST_KEY_CHECKING=False",
+		"ANSIBLE_REMOTE_TMP=/tmp/.ansible-${USER}/tmp",
+		"ANSIBLE_TASK_TIMEOUT="+strconv.Itoa(timeout),
+	)
+	return cmd
+}
+
+// waitForNodesReady 等待本批次对应的 K8s 节点全部 Ready
+// 主机名需与 K8s 节点名一致（从集群生成的 inventory 默认满足）
+func (e *TaskExecutor) waitForNodesReady(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask, hosts []string) error {
+	clusterName, err := e.resolveTaskClusterName(task)
+	if err != nil {
+		return err
+	}
+
+	k8sSvc := e.inventorySvc.k8sSvc
+	if k8sSvc == nil {
+		return fmt.Errorf("k8s service is not available")
+	}
+
+	timeout := task.BatchConfig.NodeReadyTimeout
+	if timeout <= 0 {
+		timeout = defaultNodeReadyTimeout
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	e.emitSystemLog(runningTask, fmt.Sprintf("Waiting for %d nodes to be Ready in cluster %s", len(hosts), clusterName))
+
+	ticker := time.NewTicker(nodeReadyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		notReady := make([]string, 0)
+		for _, host := range hosts {
+			node, err := k8sSvc.GetNodeWithCacheContext(waitCtx, clusterName, host, true)
+			if err != nil || !strings.HasPrefix(node.Status, "Ready") {
+				notReady = append(notReady, host)
+			}
+		}
+
+		if len(notReady) == 0 {
+			e.emitSystemLog(runningTask, "All batch nodes are Ready")
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("nodes not ready after %d seconds: %s", timeout, strings.Join(notReady, ","))
+		}
+	}
+}
+
+// resolveTaskClusterName 获取任务关联的集群名称（任务未指定时使用清单关联的集群）
+func (e *TaskExecutor) resolveTaskClusterName(task *model.AnsibleTask) (string, error) {
+	clusterID := task.ClusterID
+	if clusterID == nil && task.Inventory != nil {
+		clusterID = task.Inventory.ClusterID
+	}
+	if clusterID == nil {
+		return "", fmt.Errorf("task has no associated cluster, cannot wait for nodes ready")
+	}
+
+	var cluster model.Cluster
+	if err := e.db.Select("id, name").First(&cluster, *clusterID).Error; err != nil {
+		return "", fmt.Errorf("failed to get cluster %d: %w", *clusterID, err)
+	}
+	return cluster.Name, nil
+}
+
+// emitSystemLog 向任务日志流写入一条系统事件日志
+func (e *TaskExecutor) emitSystemLog(runningTask *RunningTask, content string) {
+	log := &model.AnsibleLog{
+		TaskID:    runningTask.TaskID,
+		LogType:   model.AnsibleLogTypeEvent,
+		Content:   content,
+		CreatedAt: time.Now(),
+	}
+
+	select {
+	case runningTask.LogChannel <- log:
+	case <-time.After(5 * time.Second):
+		e.logger.Warningf("Log channel full for task %d, dropping event log", runningTask.TaskID)
+	}
+}
+
+// pushBatchStatusToWebSocket 推送批次状态到 WebSocket
+func (e *TaskExecutor) pushBatchStatusToWebSocket(task *model.AnsibleTask) {
+	if e.wsHub == nil {
+		return
+	}
+
+	type WSHub interface {
+		BroadcastToTask(taskID uint, message interface{})
+	}
+
+	if hub, ok := e.wsHub.(WSHub); ok {
+		hub.BroadcastToTask(task.ID, map[string]interface{}{
+			"type":          "batch_status",
+			"task_id":       task.ID,
+			"batch_status":  task.BatchStatus,
+			"current_batch": task.CurrentBatch,
+			"total_batches": task.TotalBatches,
+			"batch_results": task.BatchResults,
+		})
+	}
+}
+
+// splitIntoBatches 按批次大小拆分主机列表
+func splitIntoBatches(hosts []string, batchSize int) [][]string {
+	if batchSize <= 0 || batchSize >= len(hosts) {
+		return [][]string{hosts}
+	}
+
+	batches := make([][]string, 0, (len(hosts)+batchSize-1)/batchSize)
+	for start := 0; start < len(hosts); start += batchSize {
+		end := start + batchSize
+		if end > len(hosts) {
+			end = len(hosts)
+		}
+		batches = append(batches, hosts[start:end])
+	}
+	return batches
+}
+
+// evaluateBatchOutput 根据批次输出中的 RECAP 统计主机结果
+// 未出现在 RECAP 中的主机：命令失败时视为失败，否则视为跳过
+func evaluateBatchOutput(output string, hosts []string, runErr error) (ok, skipped int, failedHosts []string) {
+	failedHosts = make([]string, 0)
+	seen := make(map[string]bool)
+
+	for _, match := range recapStatsPattern.FindAllStringSubmatch(extractRecapText(output), -1) {
+		hostname := match[1]
+		hostOk, _ := strconv.Atoi(match[2])
+		unreachable, _ := strconv.Atoi(match[4])
+		failed, _ := strconv.Atoi(match[5])
+		seen[hostname] = true
+
+		switch {
+		case unreachable > 0 || failed > 0:
+			failedHosts = append(failedHosts, hostname)
+		case hostOk > 0:
+			ok++
+		default:
+			skipped++
+		}
+	}
+
+	for _, host := range hosts {
+		if seen[host] {
+			continue
+		}
+		if runErr != nil {
+			failedHosts = append(failedHosts, host)
+		} else {
+			skipped++
+		}
+	}
+
+	return ok, skipped, failedHosts
+}
+
+// checkBatchThresholds 检查失败阈值，返回非空字符串表示需要停止后续批次
+func checkBatchThresholds(config *model.BatchExecutionConfig, batchHosts, batchFailed, totalFailed int) string {
+	if config == nil {
+		return ""
+	}
+
+	if config.FailureThreshold > 0 && totalFailed > config.FailureThreshold {
+		return fmt.Sprintf("累计失败主机数 %d 超过阈值 %d", totalFailed, config.FailureThreshold)
+	}
+
+	if config.MaxBatchFailRate > 0 && batchHosts > 0 && batchFailed*100 > config.MaxBatchFailRate*batchHosts {
+		return fmt.Sprintf("批次失败率 %d%% 超过阈值 %d%%", batchFailed*100/batchHosts, config.MaxBatchFailRate)
+	}
+
+	return ""
+}
@@ -0,0 +1,281 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/sshca"
+	"kube-node-manager/pkg/logger"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestSplitIntoBatches 测试主机分批
+func TestSplitIntoBatches(t *testing.T) {
+	hosts := []string{"node-1", "node-2", "node-3", "node-4", "node-5"}
+
+	tests := []struct {
+		name      string
+		batchSize int
+		want      [][]string
+	}{
+		{
+			name:      "Even split with remainder",
+			batchSize: 2,
+			want:      [][]string{{"node-1", "node-2"}, {"node-3", "node-4"}, {"node-5"}},
+		},
+		{
+			name:      "Batch size larger than hosts",
+			batchSize: 10,
+			want:      [][]string{hosts},
+		},
+		{
+			name:      "Zero batch size runs all hosts at once",
+			batchSize: 0,
+			want:      [][]string{hosts},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitIntoBatches(hosts, tt.batchSize)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitIntoBatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEvaluateBatchOutput 测试从批次输出的 RECAP 中统计主机结果
+func TestEvaluateBatchOutput(t *testing.T) {
+	output := `PLAY [all] *********************************************************************
+TASK [ping] ********************************************************************
+ok: [node-1]
+fatal: [node-2]: UNREACHABLE!
+
+PLAY RECAP *********************************************************************
+node-1                     : ok=2    changed=1    unreachable=0    failed=0    skipped=0    rescued=0    ignored=0
+node-2                     : ok=0    changed=0    unreachable=1    failed=0    skipped=0    rescued=0    ignored=0
+node-3                     : ok=0    changed=0    unreachable=0    failed=0    skipped=2    rescued=0    ignored=0
+`
+	hosts := []string{"node-1", "node-2", "node-3", "node-4"}
+
+	ok, skipped, failedHosts := evaluateBatchOutput(output, hosts, nil)
+	if ok != 1 || skipped != 2 || !reflect.DeepEqual(failedHosts, []string{"node-2"}) {
+		t.Errorf("without error: got ok=%d skipped=%d failed=%v", ok, skipped, failedHosts)
+	}
+
+	// 命令失败时，未出现在 RECAP 中的主机视为失败
+	ok, skipped, failedHosts = evaluateBatchOutput(output, hosts, errors.New("exit status 4"))
+	if ok != 1 || skipped != 1 || !reflect.DeepEqual(failedHosts, []string{"node-2", "node-4"}) {
+		t.Errorf("with error: got ok=%d skipped=%d failed=%v", ok, skipped, failedHosts)
+	}
+}
+
+// TestCheckBatchThresholds 测试批次失败阈值
+func TestCheckBatchThresholds(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *model.BatchExecutionConfig
+		batchHosts  int
+		batchFailed int
+		totalFailed int
+		wantStop    bool
+	}{
+		{
+			name:        "No thresholds configured",
+			config:      &model.BatchExecutionConfig{Enabled: true},
+			batchHosts:  5,
+			batchFailed: 5,
+			totalFailed: 5,
+			wantStop:    false,
+		},
+		{
+			name:        "Total failures equal to threshold",
+			config:      &model.BatchExecutionConfig{Enabled: true, FailureThreshold: 2},
+			batchHosts:  5,
+			batchFailed: 1,
+			totalFailed: 2,
+			wantStop:    false,
+		},
+		{
+			name:        "Total failures exceed threshold",
+			config:      &model.BatchExecutionConfig{Enabled: true, FailureThreshold: 2},
+			batchHosts:  5,
+			batchFailed: 1,
+			totalFailed: 3,
+			wantStop:    true,
+		},
+		{
+			name:        "Batch fail rate exceeds limit",
+			config:      &model.BatchExecutionConfig{Enabled: true, MaxBatchFailRate: 20},
+			batchHosts:  4,
+			batchFailed: 1,
+			totalFailed: 1,
+			wantStop:    true,
+		},
+		{
+			name:        "Batch fail rate at limit",
+			config:      &model.BatchExecutionConfig{Enabled: true, MaxBatchFailRate: 25},
+			batchHosts:  4,
+			batchFailed: 1,
+			totalFailed: 1,
+			wantStop:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := checkBatchThresholds(tt.config, tt.batchHosts, tt.batchFailed, tt.totalFailed)
+			if (reason != "") != tt.wantStop {
+				t.Errorf("checkBatchThresholds() = %q, wantStop %v", reason, tt.wantStop)
+			}
+		})
+	}
+}
+
+// TestBatchControl 测试暂停/继续请求保存在任务记录中，可在任意实例上提交并只被处理一次
+func TestBatchControl(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.AnsibleTask{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	task := &model.AnsibleTask{
+		Name:         "batch",
+		Status:       model.AnsibleTaskStatusRunning,
+		UserID:       1,
+		BatchConfig:  &model.BatchExecutionConfig{Enabled: true, BatchSize: 1, PauseTimeout: 1},
+		BatchStatus:  "running",
+		CurrentBatch: 1,
+		TotalBatches: 3,
+	}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	owner := NewTaskExecutor(db, logger.NewLogger(), nil, nil, nil, nil, nil)
+	other := NewTaskExecutor(db, logger.NewLogger(), nil, nil, nil, nil, nil)
+
+	if err := other.PauseBatchExecution(task.ID); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := other.PauseBatchExecution(task.ID); err == nil {
+		t.Error("expected duplicate pause request to be rejected")
+	}
+
+	// 执行实例整行保存任务不会覆盖其他实例提交的请求
+	task.HostsOk = 1
+	if err := db.Save(task).Error; err != nil {
+		t.Fatalf("save task: %v", err)
+	}
+	if !owner.takeBatchControl(task.ID, model.BatchControlPause) {
+		t.Fatal("pause request was lost")
+	}
+	if owner.takeBatchControl(task.ID, model.BatchControlPause) {
+		t.Error("pause request should only be taken once")
+	}
+
+	if err := other.ContinueBatchExecution(task.ID); err == nil {
+		t.Error("expected continue to fail when batch execution is not paused")
+	}
+
+	// 暂停超时后停止执行
+	runningTask := owner.newRunningTask(task.ID, func() {})
+	err = owner.waitForBatchContinue(context.Background(), task, runningTask, 1)
+	if err == nil || !strings.Contains(err.Error(), "1 秒") {
+		t.Fatalf("expected pause timeout, got %v", err)
+	}
+
+	// 执行实例心跳正常时，继续请求交由执行实例处理
+	if err := db.Table(task.TableName()).Where("id = ?", task.ID).Update("batch_heartbeat_at", time.Now()).Error; err != nil {
+		t.Fatalf("update heartbeat: %v", err)
+	}
+	if err := other.ContinueBatchExecution(task.ID); err != nil {
+		t.Fatalf("continue: %v", err)
+	}
+	if err := other.ContinueBatchExecution(task.ID); err == nil {
+		t.Error("expected duplicate continue request to be rejected")
+	}
+	if !owner.takeBatchControl(task.ID, model.BatchControlContinue) {
+		t.Error("continue request was lost")
+	}
+}
+
+// TestRenewSSHCertFiles 测试分批执行时重新签发证书并删除旧证书
+func TestRenewSSHCertFiles(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.User{}, &model.SSHCertAuthority{}, &model.SSHCertificate{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Create(&model.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: model.RoleAdmin, Status: model.StatusActive}).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	config := sshca.DefaultConfig()
+	config.Enabled = true
+	config.KeyFallback = false
+	e := NewTaskExecutor(db, logger.NewLogger(), nil, nil, nil, nil, nil)
+	e.sshCA = sshca.NewService(db, logger.NewLogger(), "test-key", config)
+	e.workDir = t.TempDir()
+
+	task := &model.AnsibleTask{ID: 1, UserID: 1, TimeoutSeconds: 7 * 24 * 3600}
+	files := &taskFiles{}
+	if err := e.createSSHCertFiles(task, files); err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	firstKey, firstCert := files.SSHKey, files.SSHCert
+	if firstCert == "" {
+		t.Fatal("expected certificate file")
+	}
+
+	if err := e.renewSSHCertFiles(task, files); err != nil {
+		t.Fatalf("renew certificate: %v", err)
+	}
+	if files.SSHKey == firstKey || files.SSHCert == firstCert {
+		t.Error("renewed certificate should use new files")
+	}
+	for _, path := range []string{firstKey, firstCert} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("previous certificate file %s should be removed", path)
+		}
+	}
+	for _, path := range []string{files.SSHKey, files.SSHCert} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("renewed certificate file missing: %v", err)
+		}
+	}
+
+	var certs []model.SSHCertificate
+	db.Order("id").Find(&certs)
+	if len(certs) != 2 {
+		t.Fatalf("issued %d certificates, want 2", len(certs))
+	}
+	for _, cert := range certs {
+		if lifetime := time.Until(cert.ValidBefore); lifetime > config.AnsibleValidity {
+			t.Errorf("certificate valid for %s, should be capped at %s despite the long task timeout", lifetime, config.AnsibleValidity)
+		}
+	}
+
+	// 未使用证书时不签发
+	plain := &taskFiles{SSHKey: "inventory-key"}
+	if err := e.renewSSHCertFiles(task, plain); err != nil || plain.SSHKey != "inventory-key" || plain.SSHCert != "" {
+		t.Errorf("renew without certificate = %v, files %+v", err, plain)
+	}
+}
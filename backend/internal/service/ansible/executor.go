@@ -4,10 +4,14 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/sshca"
+	ansibleUtil "kube-node-manager/pkg/ansible"
 	"kube-node-manager/pkg/logger"
 	"os"
 	"os/exec"
@@ -31,6 +35,9 @@ type TaskExecutor struct {
 	wsHub           interface{} // WebSocket Hub for log streaming
 	inventorySvc    *InventoryService
 	sshKeySvc       *SSHKeyService
+	sshCA           *sshca.Service // SSH 证书颁发服务，启用时使用短期证书连接节点
+	secretSvc       *SecretService
+	projectSvc      *ProjectService
 	workDir         string          // 工作目录
 	sanitizer       *Sanitizer // 日志脱敏器
 }
@@ -49,6 +56,20 @@ func NewSanitizer() *Sanitizer {
 	return &Sanitizer{patterns: patterns}
 }
 
+// WithSecrets 返回附加了指定密钥值脱敏规则的新脱敏器（不修改原脱敏器）
+func (s *Sanitizer) WithSecrets(secrets []string) *Sanitizer {
+	patterns := make([]*regexp.Regexp, 0, len(s.patterns)+len(secrets))
+	// 先替换完整的密钥值，避免被通用规则部分替换后无法匹配
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		patterns = append(patterns, regexp.MustCompile(regexp.QuoteMeta(secret)))
+	}
+	patterns = append(patterns, s.patterns...)
+	return &Sanitizer{patterns: patterns}
+}
+
 // Sanitize 对输入字符串进行脱敏处理
 func (s *Sanitizer) Sanitize(input string) string {
 	output := input
@@ -70,10 +91,43 @@ type RunningTask struct {
 	LogSize      int64            // 当前日志大小
 	MaxLogSize   int64            // 最大日志大小 (10MB)
 	SSHKeyFile   string           // SSH 密钥临时文件路径
+	Sanitizer    *Sanitizer       // 日志脱敏器（包含本任务密钥变量的值）
+	LogDone      chan struct{}    // 日志收集完成信号（collectLogs 退出时关闭）
+	AdhocHosts   map[string]bool  // 临时命令的目标主机（用于实时识别主机结果，Playbook 任务为 nil）
+
+	// 分批执行相关
+	batchMu     sync.Mutex       // 保护 batchOutput
+	batchOutput *strings.Builder // 当前批次的输出（用于解析批次 RECAP）
+}
+
+// taskFiles 任务执行期间使用的临时文件
+type taskFiles struct {
+	ProjectDir     string // Git 项目检出目录（非空时 Playbook 位于检出目录内，不属于临时文件）
+	Playbook       string
+	Inventory      string
+	SSHKey         string
+	SSHCert        string // SSH CA 签发的证书（<SSHKey>-cert.pub）
+	FallbackSSHKey string // 使用证书时清单原有的私钥，作为后备身份
+	ExtraVars      string // 额外变量文件（JSON）
+	SecretVars     string // Vault 加密的密钥变量文件
+	VaultPassword  string // Vault 密码文件
+}
+
+// Remove 删除所有已创建的临时文件
+func (f *taskFiles) Remove() {
+	paths := []string{f.Inventory, f.SSHKey, f.SSHCert, f.FallbackSSHKey, f.ExtraVars, f.SecretVars, f.VaultPassword}
+	if f.ProjectDir == "" {
+		paths = append(paths, f.Playbook)
+	}
+	for _, path := range paths {
+		if path != "" {
+			os.Remove(path)
+		}
+	}
 }
 
 // NewTaskExecutor 创建任务执行器实例
-func NewTaskExecutor(db *gorm.DB, logger *logger.Logger, inventorySvc *InventoryService, sshKeySvc *SSHKeyService, wsHub interface{}) *TaskExecutor {
+func NewTaskExecutor(db *gorm.DB, logger *logger.Logger, inventorySvc *InventoryService, sshKeySvc *SSHKeyService, secretSvc *SecretService, projectSvc *ProjectService, wsHub interface{}) *TaskExecutor {
 	// 创建工作目录
 	workDir := filepath.Join(os.TempDir(), "kube-node-manager-ansible")
 	if err := os.MkdirAll(workDir, 0755); err != nil {
@@ -88,6 +142,8 @@ func NewTaskExecutor(db *gorm.DB, logger *logger.Logger, inventorySvc *Inventory
 		wsHub:         wsHub,
 		inventorySvc:  inventorySvc,
 		sshKeySvc:     sshKeySvc,
+		secretSvc:     secretSvc,
+		projectSvc:    projectSvc,
 		workDir:       workDir,
 		sanitizer:     NewSanitizer(), // 初始化日志脱敏器
 	}
@@ -150,14 +206,13 @@ func (e *TaskExecutor) ExecuteTask(taskID uint) error {
 	}
 
 	// 创建运行任务记录
-	runningTask := &RunningTask{
-		TaskID:      taskID,
-		Cancel:      cancel,
-		StartTime:   time.Now(),
-		LogChannel:  make(chan *model.AnsibleLog, 2000), // 增加到 2000（支持大规模主机，避免日志丢失）
-		LogBuffer:   &strings.Builder{},
-		LogSize:     0,
-		MaxLogSize:  10 * 1024 * 1024, // 10MB 日志大小限制
+	runningTask := e.newRunningTask(taskID, cancel)
+
+	if task.IsAdhoc() {
+		runningTask.AdhocHosts = make(map[string]bool, len(task.Adhoc.Hosts))
+		for _, host := range task.Adhoc.Hosts {
+			runningTask.AdhocHosts[host] = true
+		}
 	}
 
 	e.mu.Lock()
@@ -170,6 +225,21 @@ func (e *TaskExecutor) ExecuteTask(taskID uint) error {
 	return nil
 }
 
+// newRunningTask 创建运行任务记录
+func (e *TaskExecutor) newRunningTask(taskID uint, cancel context.CancelFunc) *RunningTask {
+	return &RunningTask{
+		TaskID:     taskID,
+		Cancel:     cancel,
+		StartTime:  time.Now(),
+		LogChannel: make(chan *model.AnsibleLog, 2000), // 增加到 2000（支持大规模主机，避免日志丢失）
+		LogBuffer:  &strings.Builder{},
+		LogSize:    0,
+		MaxLogSize: 10 * 1024 * 1024, // 10MB 日志大小限制
+		Sanitizer:  e.sanitizer,
+		LogDone:    make(chan struct{}),
+	}
+}
+
 // executeTaskAsync 异步执行任务
 func (e *TaskExecutor) executeTaskAsync(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask) {
 	// 添加执行开始事件
@@ -192,86 +262,43 @@ func (e *TaskExecutor) executeTaskAsync(ctx context.Context, task *model.Ansible
 	}
 
 	// 创建临时文件
-	playbookFile, err := e.createPlaybookFile(task)
-	if err != nil {
-		e.handleTaskError(task, runningTask, fmt.Errorf("failed to create playbook file: %w", err))
-		return
-	}
-	defer os.Remove(playbookFile)
+	files := &taskFiles{}
+	defer files.Remove()
 
-	inventoryFile, err := e.createInventoryFile(task)
-	if err != nil {
-		e.handleTaskError(task, runningTask, fmt.Errorf("failed to create inventory file: %w", err))
+	if err := e.prepareTaskFiles(ctx, task, runningTask, files); err != nil {
+		e.handleTaskError(task, runningTask, err)
 		return
 	}
-	defer os.Remove(inventoryFile)
-
-	// 创建 SSH 密钥文件（如果需要）
-	sshKeyFile, err := e.createSSHKeyFile(task)
-	if err != nil {
-		e.handleTaskError(task, runningTask, fmt.Errorf("failed to create ssh key file: %w", err))
-		return
-	}
-	if sshKeyFile != "" {
-		runningTask.SSHKeyFile = sshKeyFile
-		defer os.Remove(sshKeyFile)
-	}
-
-	// 构建命令
-	cmd := e.buildAnsibleCommand(ctx, playbookFile, inventoryFile, sshKeyFile, task)
-	runningTask.Cmd = cmd
 
 	// 启动日志收集
 	e.logger.Infof("Task %d: Starting log collection goroutine", task.ID)
 	go e.collectLogs(runningTask)
 
-	// 捕获输出
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		e.handleTaskError(task, runningTask, fmt.Errorf("failed to create stdout pipe: %w", err))
+	// 分批执行：每个批次单独调用一次 ansible-playbook
+	if task.IsBatchEnabled() {
+		e.executeBatches(ctx, task, runningTask, files)
 		return
 	}
 
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		e.handleTaskError(task, runningTask, fmt.Errorf("failed to create stderr pipe: %w", err))
-		return
+	// 构建命令
+	var cmd *exec.Cmd
+	if task.IsAdhoc() {
+		cmd = e.buildAdhocCommand(ctx, files, task)
+	} else {
+		cmd = e.buildAnsibleCommand(ctx, files, task, nil)
 	}
+	runningTask.Cmd = cmd
 
-	// 启动命令
-	e.logger.Infof("Task %d: Starting ansible-playbook command", task.ID)
-	if err := cmd.Start(); err != nil {
-		e.handleTaskError(task, runningTask, fmt.Errorf("failed to start ansible command: %w", err))
+	started, err := e.runCommand(cmd, runningTask)
+	if !started {
+		close(runningTask.LogChannel)
+		e.handleTaskError(task, runningTask, err)
 		return
 	}
-	e.logger.Infof("Task %d: ansible-playbook command started successfully", task.ID)
-
-	// 读取输出
-	var wg sync.WaitGroup
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		e.logger.Infof("Task %d: Starting stdout reader", task.ID)
-		e.readOutput(stdout, runningTask, model.AnsibleLogTypeStdout)
-		e.logger.Infof("Task %d: Stdout reader finished", task.ID)
-	}()
-
-	go func() {
-		defer wg.Done()
-		e.logger.Infof("Task %d: Starting stderr reader", task.ID)
-		e.readOutput(stderr, runningTask, model.AnsibleLogTypeStderr)
-		e.logger.Infof("Task %d: Stderr reader finished", task.ID)
-	}()
 
-	wg.Wait()
-	e.logger.Infof("Task %d: All output readers finished", task.ID)
-
-	// 等待命令完成
-	err = cmd.Wait()
-
-	// 关闭日志通道
+	// 关闭日志通道，并等待日志收集完成
 	close(runningTask.LogChannel)
+	<-runningTask.LogDone
 
 	// 检查是否超时
 	isTimedOut := false
@@ -356,6 +383,110 @@ func (e *TaskExecutor) executeTaskAsync(ctx context.Context, task *model.Ansible
 	}
 }
 
+// prepareTaskFiles 准备任务执行所需的 playbook、清单、密钥和变量文件
+// 出错时已创建的文件由调用方通过 files.Remove 清理
+func (e *TaskExecutor) prepareTaskFiles(ctx context.Context, task *model.AnsibleTask, runningTask *RunningTask, files *taskFiles) error {
+	if task.ProjectID != nil {
+		// Git 项目：在固定提交的检出目录中执行，支持 roles、group_vars 等多文件内容
+		projectDir, err := e.projectSvc.PrepareCheckout(ctx, *task.ProjectID, task.CommitSHA)
+		if err != nil {
+			return fmt.Errorf("failed to prepare project checkout: %w", err)
+		}
+		files.ProjectDir = projectDir
+		files.Playbook = filepath.Join(projectDir, task.PlaybookPath)
+		e.logger.Infof("Task %d: Using project %d at commit %s, playbook %s", task.ID, *task.ProjectID, shortSHA(task.CommitSHA), task.PlaybookPath)
+	} else if !task.IsAdhoc() {
+		playbookFile, err := e.createPlaybookFile(task)
+		if err != nil {
+			return fmt.Errorf("failed to create playbook file: %w", err)
+		}
+		files.Playbook = playbookFile
+	}
+
+	inventoryFile, err := e.createInventoryFile(task)
+	if err != nil {
+		return fmt.Errorf("failed to create inventory file: %w", err)
+	}
+	files.Inventory = inventoryFile
+
+	// 创建 SSH 密钥文件（如果需要）
+	sshKeyFile, err := e.createSSHKeyFile(task)
+	if err != nil {
+		return fmt.Errorf("failed to create ssh key file: %w", err)
+	}
+	if sshKeyFile != "" {
+		runningTask.SSHKeyFile = sshKeyFile
+		files.SSHKey = sshKeyFile
+	}
+
+	// 启用 SSH CA 时签发本任务的短期证书
+	if err := e.createSSHCertFiles(task, files); err != nil {
+		return fmt.Errorf("failed to issue ssh certificate: %w", err)
+	}
+
+	// 额外变量写入文件，避免变量值出现在命令行和日志中
+	extraVarsFile, err := e.createExtraVarsFile(task)
+	if err != nil {
+		return fmt.Errorf("failed to create extra vars file: %w", err)
+	}
+	files.ExtraVars = extraVarsFile
+
+	// 密钥变量写入 Vault 加密文件，并生成一次性 Vault 密码文件
+	if err := e.createSecretVarsFiles(task, runningTask, files); err != nil {
+		return fmt.Errorf("failed to prepare secret variables: %w", err)
+	}
+
+	return nil
+}
+
+// runCommand 启动命令并读取 stdout/stderr，直到命令结束
+// 返回值 started 表示命令是否成功启动；err 为启动失败原因或命令的退出错误
+func (e *TaskExecutor) runCommand(cmd *exec.Cmd, runningTask *RunningTask) (bool, error) {
+	taskID := runningTask.TaskID
+
+	// 捕获输出
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return false, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+
+	// 启动命令
+	e.logger.Infof("Task %d: Starting ansible-playbook command", taskID)
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("failed to start ansible command: %w", err)
+	}
+	e.logger.Infof("Task %d: ansible-playbook command started successfully", taskID)
+
+	// 读取输出
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		e.logger.Infof("Task %d: Starting stdout reader", taskID)
+		e.readOutput(stdout, runningTask, model.AnsibleLogTypeStdout)
+		e.logger.Infof("Task %d: Stdout reader finished", taskID)
+	}()
+
+	go func() {
+		defer wg.Done()
+		e.logger.Infof("Task %d: Starting stderr reader", taskID)
+		e.readOutput(stderr, runningTask, model.AnsibleLogTypeStderr)
+		e.logger.Infof("Task %d: Stderr reader finished", taskID)
+	}()
+
+	wg.Wait()
+	e.logger.Infof("Task %d: All output readers finished", taskID)
+
+	// 等待命令完成
+	return true, cmd.Wait()
+}
+
 // checkAndRetryTask 检查并重试失败的任务
 func (e *TaskExecutor) checkAndRetryTask(task *model.AnsibleTask) {
 	// 检查是否配置了重试策略
@@ -406,6 +537,11 @@ func (e *TaskExecutor) retryTask(taskID uint) {
 	task.ErrorMsg = ""
 	task.FullLog = ""
 	task.LogSize = 0
+	if task.IsBatchEnabled() {
+		task.BatchStatus = "pending"
+		task.CurrentBatch = 0
+		task.BatchResults = nil
+	}
 
 	if err := e.db.Save(&task).Error; err != nil {
 		e.logger.Errorf("Failed to update task %d for retry: %v", taskID, err)
@@ -460,42 +596,49 @@ func (e *TaskExecutor) CancelTask(taskID uint) error {
 	return nil
 }
 
-// ContinueBatchExecution 继续批次执行
+// PauseBatchExecution 请求在当前批次结束后暂停
+// 请求保存在任务记录中，由执行该任务的实例在批次结束时处理，可以在任意实例上发起
+func (e *TaskExecutor) PauseBatchExecution(taskID uint) error {
+	result := e.db.Table(model.AnsibleTask{}.TableName()).
+		Where("id = ? AND status = ? AND batch_status <> ? AND batch_control = ?", taskID, model.AnsibleTaskStatusRunning, "paused", "").
+		Update("batch_control", model.BatchControlPause)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save pause request: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("batch execution is not running or a pause request is already pending")
+	}
+
+	e.logger.Infof("Task %d: Pause requested, will pause after current batch", taskID)
+	return nil
+}
+
+// ContinueBatchExecution 继续执行处于暂停状态的批次任务
+// 执行实例仍在运行时写入继续请求，由其轮询处理；执行实例已中断（如重启）时由当前实例接管并执行剩余批次
 func (e *TaskExecutor) ContinueBatchExecution(taskID uint) error {
-	// 获取任务
 	var task model.AnsibleTask
-	if err := e.db.First(&task, taskID).Error; err != nil {
+	if err := e.db.Preload("Inventory").First(&task, taskID).Error; err != nil {
 		return fmt.Errorf("failed to get task: %w", err)
 	}
+	if task.Status != model.AnsibleTaskStatusRunning || !task.IsBatchPaused() {
+		return fmt.Errorf("batch execution is not waiting for confirmation")
+	}
 
-	// 检查批次配置
-	if !task.IsBatchEnabled() {
-		return fmt.Errorf("batch execution not enabled")
+	if task.BatchHeartbeatAt == nil || time.Since(*task.BatchHeartbeatAt) > batchStaleTimeout {
+		return e.resumePausedBatchTask(&task)
 	}
 
-	// 检查是否还有剩余批次
-	if task.CurrentBatch >= task.TotalBatches {
-		// 所有批次已完成
-		task.MarkBatchCompleted()
-		task.Status = model.AnsibleTaskStatusSuccess
-		finishedAt := time.Now()
-		task.FinishedAt = &finishedAt
-		duration := int(time.Since(*task.StartedAt).Seconds())
-		task.Duration = duration
-		
-		if err := e.db.Save(&task).Error; err != nil {
-			e.logger.Errorf("Failed to update task: %v", err)
-		}
-		
-		e.logger.Infof("Task %d: All batches completed", taskID)
-		return nil
+	result := e.db.Table(model.AnsibleTask{}.TableName()).
+		Where("id = ? AND status = ? AND batch_status = ? AND batch_control <> ?", taskID, model.AnsibleTaskStatusRunning, "paused", model.BatchControlContinue).
+		Update("batch_control", model.BatchControlContinue)
+	if result.Error != nil {
+		return fmt.Errorf("failed to save continue request: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("batch execution is not waiting for confirmation")
 	}
 
-	e.logger.Infof("Task %d: Continuing to batch %d/%d", taskID, task.CurrentBatch, task.TotalBatches)
-	
-	// 注意：实际的批次执行逻辑在 ExecuteTask 中处理
-	// 这里只是记录日志，实际执行会在 ansible-playbook 命令中通过 serial 参数控制
-	
+	e.logger.Infof("Task %d: Batch execution continue requested", taskID)
 	return nil
 }
 
@@ -537,9 +680,27 @@ func (e *TaskExecutor) createInventoryFile(task *model.AnsibleTask) (string, err
 		return "", err
 	}
 
+	// 动态清单在此时从集群实时解析
+	content, err := e.inventorySvc.ResolveContent(inventory)
+	if err != nil {
+		return "", err
+	}
+
+	// 将实际使用的清单快照到任务上，便于复现
+	task.InventorySnapshot = content
+	updates := map[string]interface{}{"inventory_snapshot": content}
+	// 临时命令的主机总数为创建时解析的目标主机数
+	if inventory.IsDynamic() && !task.IsAdhoc() {
+		task.HostsTotal = len(listInventoryHosts(content))
+		updates["hosts_total"] = task.HostsTotal
+	}
+	if err := e.db.Model(task).Updates(updates).Error; err != nil {
+		e.logger.Errorf("Failed to save inventory snapshot for task %d: %v", task.ID, err)
+	}
+
 	filename := filepath.Join(e.workDir, fmt.Sprintf("inventory-%d-%d.ini", task.ID, time.Now().Unix()))
 	
-	if err := os.WriteFile(filename, []byte(inventory.Content), 0644); err != nil {
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
 		return "", err
 	}
 
@@ -601,11 +762,83 @@ func (e *TaskExecutor) createSSHKeyFile(task *model.AnsibleTask) (string, error)
 	return filename, nil
 }
 
+// createExtraVarsFile 创建额外变量临时文件（JSON 格式，通过 --extra-vars @file 引用）
+func (e *TaskExecutor) createExtraVarsFile(task *model.AnsibleTask) (string, error) {
+	if len(task.ExtraVars) == 0 {
+		return "", nil
+	}
+
+	content, err := json.Marshal(task.ExtraVars)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal extra vars: %w", err)
+	}
+
+	filename := filepath.Join(e.workDir, fmt.Sprintf("extra-vars-%d-%d.json", task.ID, time.Now().Unix()))
+	if err := os.WriteFile(filename, content, 0600); err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+// createSecretVarsFiles 解析任务引用的密钥变量，生成 Vault 加密的变量文件和随机 Vault 密码文件
+// 同时为该任务构建包含密钥值的脱敏器，确保密钥值不会出现在保存的日志中
+func (e *TaskExecutor) createSecretVarsFiles(task *model.AnsibleTask, runningTask *RunningTask, files *taskFiles) error {
+	if len(task.SecretVars) == 0 {
+		return nil
+	}
+
+	values, err := e.secretSvc.Resolve(task.SecretVars)
+	if err != nil {
+		return err
+	}
+
+	secrets := make([]string, 0, len(values))
+	for _, value := range values {
+		secrets = append(secrets, value)
+	}
+	runningTask.Sanitizer = e.sanitizer.WithSecrets(secrets)
+
+	// JSON 是合法的 YAML，可直接作为 vars 文件内容
+	content, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal secret vars: %w", err)
+	}
+
+	passwordBytes := make([]byte, 32)
+	if _, err := rand.Read(passwordBytes); err != nil {
+		return fmt.Errorf("failed to generate vault password: %w", err)
+	}
+	password := hex.EncodeToString(passwordBytes)
+
+	vaultContent, err := ansibleUtil.VaultEncrypt(content, password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secret vars: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	passwordFile := filepath.Join(e.workDir, fmt.Sprintf("vault-pass-%d-%d", task.ID, timestamp))
+	if err := os.WriteFile(passwordFile, []byte(password), 0600); err != nil {
+		return fmt.Errorf("failed to write vault password file: %w", err)
+	}
+	files.VaultPassword = passwordFile
+
+	secretFile := filepath.Join(e.workDir, fmt.Sprintf("secret-vars-%d-%d.yml", task.ID, timestamp))
+	if err := os.WriteFile(secretFile, []byte(vaultContent), 0600); err != nil {
+		return fmt.Errorf("failed to write secret vars file: %w", err)
+	}
+	files.SecretVars = secretFile
+
+	e.logger.Infof("Task %d: Prepared %d secret variables in vault file", task.ID, len(values))
+	return nil
+}
+
 // buildAnsibleCommand 构建 ansible-playbook 命令
-func (e *TaskExecutor) buildAnsibleCommand(ctx context.Context, playbookFile, inventoryFile, sshKeyFile string, task *model.AnsibleTask) *exec.Cmd {
+// limitHosts 非空时通过 --limit 将执行范围限制在这些主机（用于分批执行）
+func (e *TaskExecutor) buildAnsibleCommand(ctx context.Context, files *taskFiles, task *model.AnsibleTask, limitHosts []string) *exec.Cmd {
 	args := []string{
-		"-i", inventoryFile,
-		playbookFile,
+		"-i", files.Inventory,
+		files.Playbook,
 		"-v", // verbose mode
 	}
 
@@ -615,32 +848,33 @@ func (e *TaskExecutor) buildAnsibleCommand(ctx context.Context, playbookFile, in
 		e.logger.Infof("Task %d: Running in Dry Run mode (--check), no changes will be made", task.ID)
 	}
 	
-	// 如果启用了分批执行，计算并添加 --limit 参数
-	if task.IsBatchEnabled() && task.CurrentBatch > 0 {
-		// 构建批次限制（基础实现，使用 ansible 的 batch 参数）
-		batchSize := e.calculateBatchSize(task)
-		if batchSize > 0 {
-			// 使用 ansible-playbook 的 --limit 参数结合 serial 实现分批
-			e.logger.Infof("Task %d: Batch execution - batch %d/%d, size: %d", 
-				task.ID, task.CurrentBatch, task.TotalBatches, batchSize)
-		}
+	// 如果是分批执行，通过 --limit 限制为当前批次的主机
+	if len(limitHosts) > 0 {
+		args = append(args, "--limit", strings.Join(limitHosts, ","))
+		e.logger.Infof("Task %d: Batch execution - batch %d/%d, hosts: %d", 
+			task.ID, task.CurrentBatch, task.TotalBatches, len(limitHosts))
 	}
 
 	// 如果有 SSH 密钥文件，添加 --private-key 参数
-	if sshKeyFile != "" {
-		args = append(args, "--private-key", sshKeyFile)
-		e.logger.Infof("Task %d: Ansible will use SSH key file: %s", task.ID, sshKeyFile)
+	if files.SSHKey != "" {
+		args = append(args, "--private-key", files.SSHKey)
+		e.logger.Infof("Task %d: Ansible will use SSH key file: %s", task.ID, files.SSHKey)
+		args = append(args, sshFallbackArgs(files)...)
 	} else {
 		e.logger.Warningf("Task %d: No SSH key file provided, Ansible will use default authentication", task.ID)
 	}
 
-	// 添加额外变量
-	if len(task.ExtraVars) > 0 {
-		extraVarsJSON, _ := json.Marshal(task.ExtraVars)
-		args = append(args, "--extra-vars", string(extraVarsJSON))
+	// 添加额外变量（通过文件引用）
+	if files.ExtraVars != "" {
+		args = append(args, "--extra-vars", "@"+files.ExtraVars)
+	}
+
+	// 添加 Vault 加密的密钥变量
+	if files.SecretVars != "" {
+		args = append(args, "--extra-vars", "@"+files.SecretVars, "--vault-password-file", files.VaultPassword)
 	}
 	
-	// 如果启用了分批执行，添加 serial 参数到 extra-vars
+	// 保留 ansible_serial 变量，兼容在 playbook 中显式引用 serial 的场景
 	if task.IsBatchEnabled() {
 		batchSize := e.calculateBatchSize(task)
 		if batchSize > 0 {
@@ -656,6 +890,10 @@ func (e *TaskExecutor) buildAnsibleCommand(ctx context.Context, playbookFile, in
 
 	cmd := exec.CommandContext(ctx, "ansible-playbook", args...)
 	cmd.Dir = e.workDir
+	if files.ProjectDir != "" {
+		// 在项目根目录执行，使项目内的 ansible.cfg、roles 等按相对路径生效
+		cmd.Dir = files.ProjectDir
+	}
 
 	// 设置环境变量
 	cmd.Env = append(os.Environ(),
@@ -709,7 +947,10 @@ func (e *TaskExecutor) readOutput(reader io.Reader, runningTask *RunningTask, lo
 		line := scanner.Text()
 		
 		// 对日志内容进行脱敏处理
-		sanitizedLine := e.sanitizer.Sanitize(line)
+		sanitizedLine := runningTask.Sanitizer.Sanitize(line)
+
+		// 记录当前批次输出（仅分批执行时启用）
+		runningTask.captureBatchOutput(sanitizedLine)
 		
 		// 创建日志记录
 		log := &model.AnsibleLog{
@@ -746,6 +987,8 @@ func (e *TaskExecutor) readOutput(reader io.Reader, runningTask *RunningTask, lo
 
 // collectLogs 收集并保存日志
 func (e *TaskExecutor) collectLogs(runningTask *RunningTask) {
+	defer close(runningTask.LogDone)
+
 	importantLogs := make([]*model.AnsibleLog, 0, 10) // 只保存重要日志到数据库
 	
 	for {
@@ -776,6 +1019,13 @@ func (e *TaskExecutor) collectLogs(runningTask *RunningTask) {
 			// 推送到 WebSocket（仍然实时推送）
 			e.pushLogToWebSocket(log)
 
+			// 临时命令：主机完成时推送该主机的执行状态
+			if runningTask.AdhocHosts != nil && log.LogType == model.AnsibleLogTypeStdout {
+				if header := parseAdhocHeader(log.Content, runningTask.AdhocHosts); header != nil {
+					e.pushAdhocHostResultToWebSocket(runningTask.TaskID, header)
+				}
+			}
+
 			// 只保留重要的日志（错误、RECAP）到数据库
 			if e.isImportantLog(log) {
 				importantLogs = append(importantLogs, log)
@@ -892,6 +1142,12 @@ func (e *TaskExecutor) pushLogsToWebSocket(logs []*model.AnsibleLog) {
 
 // parseTaskStats 解析任务统计信息
 func (e *TaskExecutor) parseTaskStats(task *model.AnsibleTask) {
+	// 临时命令没有 PLAY RECAP，按主机输出解析
+	if task.IsAdhoc() {
+		e.parseAdhocResults(task)
+		return
+	}
+
 	// 优先从完整日志中解析统计信息
 	logContent := task.FullLog
 	
@@ -920,40 +1176,14 @@ func (e *TaskExecutor) parseTaskStats(task *model.AnsibleTask) {
 	}
 
 	// 查找 PLAY RECAP 部分
-	lines := strings.Split(logContent, "\n")
-	var recapBuffer bytes.Buffer
-	inRecap := false
-
-	for _, line := range lines {
-		if strings.Contains(line, "PLAY RECAP") {
-			inRecap = true
-			continue
-		}
-
-		if inRecap {
-			trimmedLine := strings.TrimSpace(line)
-			// 如果遇到新的 PLAY 或 TASK 标记，停止读取
-			if strings.HasPrefix(trimmedLine, "PLAY [") || strings.HasPrefix(trimmedLine, "TASK [") {
-				break
-			}
-			// 只要还在 RECAP 部分，就继续读取（包括空行）
-			// 因为主机列表可能很长，中间可能有空行
-			if trimmedLine != "" {
-				recapBuffer.WriteString(line + "\n")
-			}
-		}
-	}
-
-	recapText := recapBuffer.String()
+	recapText := extractRecapText(logContent)
 	if recapText == "" {
 		e.logger.Warningf("Task %d: No RECAP section found in logs", task.ID)
 		return
 	}
 
 	// 解析统计信息
-	// 格式示例: hostname : ok=2 changed=1 unreachable=0 failed=0 skipped=0 rescued=0 ignored=0
-	re := regexp.MustCompile(`(\S+)\s*:\s*ok=(\d+)\s+changed=(\d+)\s+unreachable=(\d+)\s+failed=(\d+)\s+skipped=(\d+)`)
-	matches := re.FindAllStringSubmatch(recapText, -1)
+	matches := recapStatsPattern.FindAllStringSubmatch(recapText, -1)
 
 	if len(matches) == 0 {
 		e.logger.Warningf("Task %d: No host stats found in RECAP section", task.ID)
@@ -1018,6 +1248,40 @@ func (e *TaskExecutor) parseTaskStats(task *model.AnsibleTask) {
 	task.UpdateStats(originalHostsTotal, hostsOk, hostsFailed, hostsSkipped)
 }
 
+// recapStatsPattern 匹配 RECAP 中的主机统计行
+// 格式示例: hostname : ok=2 changed=1 unreachable=0 failed=0 skipped=0 rescued=0 ignored=0
+var recapStatsPattern = regexp.MustCompile(`(\S+)\s*:\s*ok=(\d+)\s+changed=(\d+)\s+unreachable=(\d+)\s+failed=(\d+)\s+skipped=(\d+)`)
+
+// extractRecapText 提取日志中所有 PLAY RECAP 部分的内容
+// 分批执行时每个批次都会输出一个 RECAP，因此需要收集全部 RECAP
+func extractRecapText(logContent string) string {
+	var recapBuffer bytes.Buffer
+	inRecap := false
+
+	for _, line := range strings.Split(logContent, "\n") {
+		if strings.Contains(line, "PLAY RECAP") {
+			inRecap = true
+			continue
+		}
+
+		if inRecap {
+			trimmedLine := strings.TrimSpace(line)
+			// 如果遇到新的 PLAY 或 TASK 标记，当前 RECAP 结束
+			if strings.HasPrefix(trimmedLine, "PLAY [") || strings.HasPrefix(trimmedLine, "TASK [") {
+				inRecap = false
+				continue
+			}
+			// 只要还在 RECAP 部分，就继续读取（包括空行）
+			// 因为主机列表可能很长，中间可能有空行
+			if trimmedLine != "" {
+				recapBuffer.WriteString(line + "\n")
+			}
+		}
+	}
+
+	return recapBuffer.String()
+}
+
 // handleTaskError 处理任务错误
 func (e *TaskExecutor) handleTaskError(task *model.AnsibleTask, runningTask *RunningTask, err error) {
 	e.logger.Errorf("Task %d error: %v", task.ID, err)
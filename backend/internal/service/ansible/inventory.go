@@ -5,6 +5,7 @@ import (
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/k8s"
 	"kube-node-manager/pkg/logger"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -163,7 +164,21 @@ func (s *InventoryService) UpdateInventory(id uint, req model.InventoryUpdateReq
 		inventory.SSHKeyID = req.SSHKeyID
 	}
 
+	// 更新 K8s 节点筛选条件（动态清单在下次执行时生效，静态清单在刷新时生效）
+	if req.K8sFilter != nil {
+		if !inventory.IsFromK8s() {
+			return nil, fmt.Errorf("k8s_filter is only supported for k8s-sourced inventories")
+		}
+		if err := validateK8sFilter(req.K8sFilter); err != nil {
+			return nil, err
+		}
+		inventory.K8sFilter = req.K8sFilter
+	}
+
 	if req.Content != "" {
+		if inventory.IsDynamic() {
+			return nil, fmt.Errorf("content of dynamic inventory is resolved from the cluster and cannot be edited")
+		}
 		// 验证 inventory 内容格式
 		if err := s.validateInventoryContent(req.Content); err != nil {
 			return nil, fmt.Errorf("invalid inventory content: %w", err)
@@ -216,7 +231,8 @@ func (s *InventoryService) DeleteInventory(id uint, userID uint) error {
 	})
 }
 
-// GenerateFromK8s 从 K8s 集群动态生成主机清单
+// GenerateFromK8s 从 K8s 集群生成主机清单
+// 静态清单保存生成时的快照；动态清单（req.Dynamic）在每次执行时按筛选条件从集群实时解析
 func (s *InventoryService) GenerateFromK8s(req model.GenerateInventoryRequest, userID uint) (*model.AnsibleInventory, error) {
 	// 获取集群信息
 	var cluster model.Cluster
@@ -227,79 +243,20 @@ func (s *InventoryService) GenerateFromK8s(req model.GenerateInventoryRequest, u
 		return nil, fmt.Errorf("failed to get cluster: %w", err)
 	}
 
-	// 获取 SSH 密钥的用户名（如果指定了 SSH 密钥）
-	ansibleUser := "root" // 默认用户名
-	if req.SSHKeyID != nil {
-		var sshKey model.AnsibleSSHKey
-		if err := s.db.First(&sshKey, *req.SSHKeyID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				s.logger.Warningf("SSH key %d not found, using default user 'root'", *req.SSHKeyID)
-			} else {
-				s.logger.Errorf("Failed to get SSH key %d: %v", *req.SSHKeyID, err)
-			}
-		} else {
-			ansibleUser = sshKey.Username
-			s.logger.Infof("Using SSH key username: %s", ansibleUser)
-		}
-	}
-
-	// 获取节点列表
-	nodes, err := s.k8sSvc.ListNodes(cluster.Name)
-	if err != nil {
-		s.logger.Errorf("Failed to list nodes from cluster %s: %v", cluster.Name, err)
-		return nil, fmt.Errorf("failed to list nodes: %w", err)
-	}
-
-	if len(nodes) == 0 {
-		return nil, fmt.Errorf("no nodes found in cluster %s", cluster.Name)
+	filter := req.K8sFilter()
+	if err := validateK8sFilter(filter); err != nil {
+		return nil, err
 	}
 
-	// 过滤节点
-	var filteredNodes []k8s.NodeInfo
-	for _, node := range nodes {
-		// 1. 首先过滤掉 master 和 control-plane 节点
-		isMaster := false
-		if _, exists := node.Labels["node-role.kubernetes.io/master"]; exists {
-			isMaster = true
-		}
-		if _, exists := node.Labels["node-role.kubernetes.io/control-plane"]; exists {
-			isMaster = true
-		}
-		
-		// 如果是 master/control-plane 节点，跳过
-		if isMaster {
-			s.logger.Infof("Skipping master/control-plane node: %s", node.Name)
-			continue
-		}
-		
-		// 2. 然后根据用户指定的标签过滤（如果有）
-		if len(req.NodeLabels) > 0 {
-			match := true
-			for key, value := range req.NodeLabels {
-				if nodeValue, exists := node.Labels[key]; !exists || nodeValue != value {
-					match = false
-					break
-				}
-			}
-			if match {
-				filteredNodes = append(filteredNodes, node)
-			}
-		} else {
-			// 如果没有指定标签过滤条件，只要不是 master 节点就加入
-			filteredNodes = append(filteredNodes, node)
-		}
-	}
+	// 获取 SSH 密钥的用户名（如果指定了 SSH 密钥）
+	ansibleUser := s.resolveAnsibleUser(req.SSHKeyID)
 
-	if len(filteredNodes) == 0 {
-		return nil, fmt.Errorf("no nodes match the specified labels")
+	// 生成 inventory 内容（INI 格式）和结构化主机数据；动态清单的内容作为预览，执行时重新解析
+	content, hostsData, nodeCount, err := s.buildK8sInventory(cluster.Name, filter, ansibleUser, req.SSHPort)
+	if err != nil {
+		return nil, err
 	}
 
-	// 生成 inventory 内容（INI 格式），使用从 SSH 密钥获取的用户名和端口
-	content := s.generateINIInventory(filteredNodes, cluster.Name, ansibleUser, req.SSHPort)
-
-	// 生成结构化主机数据，使用从 SSH 密钥获取的用户名和端口
-	hostsData := s.generateHostsData(filteredNodes, ansibleUser, req.SSHPort)
-
 	// 检查名称是否重复
 	var count int64
 	if err := s.db.Model(&model.AnsibleInventory{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
@@ -310,15 +267,21 @@ func (s *InventoryService) GenerateFromK8s(req model.GenerateInventoryRequest, u
 		return nil, fmt.Errorf("inventory name already exists")
 	}
 
+	sourceType := model.InventorySourceK8s
+	if req.Dynamic {
+		sourceType = model.InventorySourceK8sDynamic
+	}
+
 	// 创建 inventory
 	inventory := &model.AnsibleInventory{
 		Name:        req.Name,
 		Description: req.Description,
-		SourceType:  model.InventorySourceK8s,
+		SourceType:  sourceType,
 		ClusterID:   &req.ClusterID,
 		SSHKeyID:    req.SSHKeyID, // 关联 SSH 密钥
 		Content:     content,
 		HostsData:   hostsData,
+		K8sFilter:   filter,
 		UserID:      userID,
 	}
 
@@ -327,20 +290,25 @@ func (s *InventoryService) GenerateFromK8s(req model.GenerateInventoryRequest, u
 		return nil, fmt.Errorf("failed to create inventory: %w", err)
 	}
 
-	s.logger.Infof("Successfully generated inventory from K8s cluster %s: %s (ID: %d, %d nodes, user: %s)",
-		cluster.Name, inventory.Name, inventory.ID, len(filteredNodes), ansibleUser)
+	s.logger.Infof("Successfully generated %s inventory from K8s cluster %s: %s (ID: %d, %d nodes, user: %s)",
+		sourceType, cluster.Name, inventory.Name, inventory.ID, nodeCount, ansibleUser)
 	return inventory, nil
 }
 
 // generateINIInventory 生成 INI 格式的 inventory 内容
+// 所有主机写入 [all] 组并附带节点信息主机变量，再按节点角色、可用区、地域、机型和状态生成分组
 // ansibleUser: Ansible 连接使用的用户名，从 SSH 密钥获取或默认为 root
 // sshPort: SSH 连接端口（可选），如果为 nil 则不设置
 func (s *InventoryService) generateINIInventory(nodes []k8s.NodeInfo, clusterName string, ansibleUser string, sshPort *int) string {
 	var builder strings.Builder
 
+	fmt.Fprintf(&builder, "# Generated from Kubernetes cluster %s\n", clusterName)
+
 	// 使用 [all] 组作为默认组
 	builder.WriteString("[all]\n")
 
+	groups := make(map[string][]string)
+
 	// 写入主机
 	for _, node := range nodes {
 		// 优先使用 InternalIP
@@ -354,12 +322,32 @@ func (s *InventoryService) generateINIInventory(nodes []k8s.NodeInfo, clusterNam
 			continue
 		}
 
-		// 格式: hostname ansible_host=ip ansible_user=<username> [ansible_ssh_port=<port>]
+		// 格式: hostname ansible_host=ip ansible_user=<username> [ansible_ssh_port=<port>] k8s_xxx=...
 		hostLine := fmt.Sprintf("%s ansible_host=%s ansible_user=%s", node.Name, ip, ansibleUser)
 		if sshPort != nil && *sshPort > 0 {
 			hostLine = fmt.Sprintf("%s ansible_ssh_port=%d", hostLine, *sshPort)
 		}
+		for _, v := range nodeHostVars(node) {
+			hostLine = fmt.Sprintf("%s %s=%s", hostLine, v[0], iniValue(v[1]))
+		}
 		builder.WriteString(hostLine + "\n")
+
+		for _, group := range nodeInventoryGroups(node) {
+			groups[group] = append(groups[group], node.Name)
+		}
+	}
+
+	// 写入分组
+	groupNames := make([]string, 0, len(groups))
+	for group := range groups {
+		groupNames = append(groupNames, group)
+	}
+	sort.Strings(groupNames)
+	for _, group := range groupNames {
+		builder.WriteString("\n[" + group + "]\n")
+		for _, host := range groups[group] {
+			builder.WriteString(host + "\n")
+		}
 	}
 
 	// 写入变量组 [all:vars]
@@ -388,15 +376,21 @@ func (s *InventoryService) generateHostsData(nodes []k8s.NodeInfo, ansibleUser s
 		}
 
 		host := map[string]interface{}{
-			"name":         node.Name,
-			"ip":           ip,
-			"internal_ip":  node.InternalIP,
-			"external_ip":  node.ExternalIP,
-			"roles":        node.Roles,
-			"labels":       node.Labels,
-			"version":      node.Version,
-			"os":           node.OS,
-			"ansible_user": ansibleUser, // 添加 ansible_user 信息
+			"name":          node.Name,
+			"ip":            ip,
+			"internal_ip":   node.InternalIP,
+			"external_ip":   node.ExternalIP,
+			"roles":         node.Roles,
+			"labels":        node.Labels,
+			"taints":        node.Taints,
+			"version":       node.Version,
+			"os":            node.OS,
+			"zone":          firstLabel(node, zoneLabels),
+			"instance_type": firstLabel(node, instanceTypeLabels),
+			"ready":         isNodeReady(node),
+			"schedulable":   node.Schedulable,
+			"groups":        nodeInventoryGroups(node),
+			"ansible_user":  ansibleUser, // 添加 ansible_user 信息
 		}
 		
 		// 如果指定了 SSH 端口，添加到主机数据中
@@ -502,6 +496,176 @@ func (s *InventoryService) CountHosts(inventory *model.AnsibleInventory) int {
 	return hostCount
 }
 
+// ListHostNames 按出现顺序返回 Inventory 中的主机名（去重，跳过 :vars/:children 等特殊组）
+func (s *InventoryService) ListHostNames(inventory *model.AnsibleInventory) []string {
+	if inventory == nil {
+		return nil
+	}
+	return listInventoryHosts(inventory.Content)
+}
+
+// listInventoryHosts 按出现顺序返回 INI 清单内容中的主机名
+func listInventoryHosts(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	hosts := make([]string, 0)
+	hostSet := make(map[string]bool)
+	inHostGroup := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inHostGroup = !strings.Contains(strings.Trim(line, "[]"), ":")
+			continue
+		}
+
+		if !inHostGroup {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.Contains(fields[0], "=") {
+			continue
+		}
+		if !hostSet[fields[0]] {
+			hostSet[fields[0]] = true
+			hosts = append(hosts, fields[0])
+		}
+	}
+
+	return hosts
+}
+
+// InventoryHasHost 判断 INI 清单内容中是否包含指定主机（按主机名或 ansible_host 匹配）
+func InventoryHasHost(content string, names ...string) bool {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name != "" {
+			wanted[name] = true
+		}
+	}
+	if len(wanted) == 0 || content == "" {
+		return false
+	}
+
+	inHostGroup := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inHostGroup = !strings.Contains(strings.Trim(line, "[]"), ":")
+			continue
+		}
+
+		if !inHostGroup {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.Contains(fields[0], "=") {
+			continue
+		}
+		if wanted[fields[0]] {
+			return true
+		}
+		for _, field := range fields[1:] {
+			if value, ok := strings.CutPrefix(field, "ansible_host="); ok && wanted[value] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// InventorySSHHost 清单中主机的 SSH 连接信息
+type InventorySSHHost struct {
+	Name string
+	Host string
+	User string // 未设置 ansible_user 时为空
+	Port int    // 未设置端口时为 0
+}
+
+// InventorySSHHosts 解析 INI 清单内容中每台主机的 SSH 连接信息，[all:vars] 中的连接变量作为默认值
+func InventorySSHHosts(content string) []InventorySSHHost {
+	var hosts []InventorySSHHost
+	hostIndex := make(map[string]int)
+	defaults := InventorySSHHost{}
+	section := ""
+
+	apply := func(host *InventorySSHHost, key, value string) {
+		switch key {
+		case "ansible_host", "ansible_ssh_host":
+			host.Host = value
+		case "ansible_user", "ansible_ssh_user":
+			host.User = value
+		case "ansible_port", "ansible_ssh_port":
+			if port, err := strconv.Atoi(value); err == nil {
+				host.Port = port
+			}
+		}
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+
+		if section == "all:vars" {
+			if key, value, ok := strings.Cut(line, "="); ok {
+				apply(&defaults, strings.TrimSpace(key), strings.Trim(strings.TrimSpace(value), `'"`))
+			}
+			continue
+		}
+		if strings.Contains(section, ":") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.Contains(fields[0], "=") {
+			continue
+		}
+		idx, ok := hostIndex[fields[0]]
+		if !ok {
+			idx = len(hosts)
+			hostIndex[fields[0]] = idx
+			hosts = append(hosts, InventorySSHHost{Name: fields[0]})
+		}
+		for _, field := range fields[1:] {
+			if key, value, ok := strings.Cut(field, "="); ok {
+				apply(&hosts[idx], key, strings.Trim(value, `'"`))
+			}
+		}
+	}
+
+	for i := range hosts {
+		if hosts[i].Host == "" {
+			hosts[i].Host = hosts[i].Name
+		}
+		if hosts[i].User == "" {
+			hosts[i].User = defaults.User
+		}
+		if hosts[i].Port == 0 {
+			hosts[i].Port = defaults.Port
+		}
+	}
+	return hosts
+}
+
 // parseInventoryContent 解析 INI 格式的 inventory 内容，提取主机信息
 func (s *InventoryService) parseInventoryContent(content string) model.HostsData {
 	hostsData := make(model.HostsData)
@@ -620,6 +784,7 @@ func (s *InventoryService) parseSSHPortFromInventory(content string) *int {
 }
 
 // RefreshK8sInventory 刷新来自 K8s 的主机清单
+// 按清单保存的筛选条件重新生成内容；动态清单刷新的是预览内容，执行时仍会实时解析
 func (s *InventoryService) RefreshK8sInventory(id uint, userID uint) (*model.AnsibleInventory, error) {
 	var inventory model.AnsibleInventory
 
@@ -631,7 +796,7 @@ func (s *InventoryService) RefreshK8sInventory(id uint, userID uint) (*model.Ans
 	}
 
 	// 检查是否为 K8s 来源
-	if inventory.SourceType != model.InventorySourceK8s {
+	if !inventory.IsFromK8s() {
 		return nil, fmt.Errorf("only k8s-sourced inventories can be refreshed")
 	}
 
@@ -644,38 +809,21 @@ func (s *InventoryService) RefreshK8sInventory(id uint, userID uint) (*model.Ans
 	}
 
 	// 获取 SSH 密钥的用户名（如果清单关联了 SSH 密钥）
-	ansibleUser := "root" // 默认用户名
-	if inventory.SSHKeyID != nil {
-		var sshKey model.AnsibleSSHKey
-		if err := s.db.First(&sshKey, *inventory.SSHKeyID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				s.logger.Warningf("SSH key %d not found, using default user 'root'", *inventory.SSHKeyID)
-			} else {
-				s.logger.Errorf("Failed to get SSH key %d: %v", *inventory.SSHKeyID, err)
-			}
-		} else {
-			ansibleUser = sshKey.Username
-			s.logger.Infof("Refreshing inventory with SSH key username: %s", ansibleUser)
-		}
-	}
+	ansibleUser := s.resolveAnsibleUser(inventory.SSHKeyID)
 
-	// 获取节点列表
-	nodes, err := s.k8sSvc.ListNodes(inventory.Cluster.Name)
-	if err != nil {
-		s.logger.Errorf("Failed to list nodes from cluster %s: %v", inventory.Cluster.Name, err)
-		return nil, fmt.Errorf("failed to list nodes: %w", err)
-	}
-
-	if len(nodes) == 0 {
-		return nil, fmt.Errorf("no nodes found in cluster %s", inventory.Cluster.Name)
+	// 优先使用筛选条件中的 SSH 端口，旧清单从现有内容中解析
+	sshPort := s.parseSSHPortFromInventory(inventory.Content)
+	if inventory.K8sFilter != nil && inventory.K8sFilter.SSHPort != nil {
+		sshPort = inventory.K8sFilter.SSHPort
 	}
 
-	// 从现有内容中解析 SSH 端口（如果有）
-	sshPort := s.parseSSHPortFromInventory(inventory.Content)
-	
 	// 重新生成 inventory 内容，使用从 SSH 密钥获取的用户名和端口
-	inventory.Content = s.generateINIInventory(nodes, inventory.Cluster.Name, ansibleUser, sshPort)
-	inventory.HostsData = s.generateHostsData(nodes, ansibleUser, sshPort)
+	content, hostsData, nodeCount, err := s.buildK8sInventory(inventory.Cluster.Name, inventory.K8sFilter, ansibleUser, sshPort)
+	if err != nil {
+		return nil, err
+	}
+	inventory.Content = content
+	inventory.HostsData = hostsData
 
 	if err := s.db.Save(&inventory).Error; err != nil {
 		s.logger.Errorf("Failed to update inventory %d: %v", id, err)
@@ -683,7 +831,6 @@ func (s *InventoryService) RefreshK8sInventory(id uint, userID uint) (*model.Ans
 	}
 
 	s.logger.Infof("Successfully refreshed K8s inventory: %s (ID: %d, %d nodes, user: %s)", 
-		inventory.Name, inventory.ID, len(nodes), ansibleUser)
+		inventory.Name, inventory.ID, nodeCount, ansibleUser)
 	return &inventory, nil
 }
-
@@ -0,0 +1,333 @@
+package ansible
+
+import (
+	"fmt"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// 节点拓扑相关标签（新标签优先，兼容旧的 beta 标签）
+var (
+	zoneLabels         = []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}
+	regionLabels       = []string{"topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"}
+	instanceTypeLabels = []string{"node.kubernetes.io/instance-type", "beta.kubernetes.io/instance-type"}
+)
+
+// invalidGroupChars Ansible 组名中不允许的字符
+var invalidGroupChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// ResolveContent 返回任务执行使用的清单内容
+// 动态清单在调用时从集群实时解析，其他清单直接返回保存的内容
+func (s *InventoryService) ResolveContent(inventory *model.AnsibleInventory) (string, error) {
+	if !inventory.IsDynamic() {
+		return inventory.Content, nil
+	}
+
+	if inventory.ClusterID == nil {
+		return "", fmt.Errorf("dynamic inventory %s has no associated cluster", inventory.Name)
+	}
+
+	var cluster model.Cluster
+	if err := s.db.First(&cluster, *inventory.ClusterID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", fmt.Errorf("cluster not found")
+		}
+		return "", fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	filter := inventory.K8sFilter
+	if filter == nil {
+		filter = &model.K8sInventoryFilter{}
+	}
+
+	content, _, count, err := s.buildK8sInventory(cluster.Name, filter, s.resolveAnsibleUser(inventory.SSHKeyID), filter.SSHPort)
+	if err != nil {
+		return "", err
+	}
+
+	s.logger.Infof("Resolved dynamic inventory %s (ID: %d) from cluster %s: %d hosts",
+		inventory.Name, inventory.ID, cluster.Name, count)
+	return content, nil
+}
+
+// PreviewK8sInventory 按筛选条件预览从集群生成的清单，不保存
+func (s *InventoryService) PreviewK8sInventory(req model.K8sInventoryPreviewRequest) (string, model.HostsData, error) {
+	var cluster model.Cluster
+	if err := s.db.First(&cluster, req.ClusterID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", nil, fmt.Errorf("cluster not found")
+		}
+		return "", nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	filter := req.K8sFilter()
+	if err := validateK8sFilter(filter); err != nil {
+		return "", nil, err
+	}
+
+	content, hostsData, _, err := s.buildK8sInventory(cluster.Name, filter, s.resolveAnsibleUser(req.SSHKeyID), req.SSHPort)
+	if err != nil {
+		return "", nil, err
+	}
+	return content, hostsData, nil
+}
+
+// buildK8sInventory 从集群获取节点并按筛选条件生成清单内容和结构化主机数据
+// filter 为 nil 时包含全部节点（兼容未保存筛选条件的旧清单）
+func (s *InventoryService) buildK8sInventory(clusterName string, filter *model.K8sInventoryFilter, ansibleUser string, sshPort *int) (string, model.HostsData, int, error) {
+	nodes, err := s.k8sSvc.ListNodes(clusterName)
+	if err != nil {
+		s.logger.Errorf("Failed to list nodes from cluster %s: %v", clusterName, err)
+		return "", nil, 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	if len(nodes) == 0 {
+		return "", nil, 0, fmt.Errorf("no nodes found in cluster %s", clusterName)
+	}
+
+	if filter != nil {
+		nodes, err = filterK8sNodes(nodes, filter)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		if len(nodes) == 0 {
+			return "", nil, 0, fmt.Errorf("no nodes match the specified filter")
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	content := s.generateINIInventory(nodes, clusterName, ansibleUser, sshPort)
+	hostsData := s.generateHostsData(nodes, ansibleUser, sshPort)
+	return content, hostsData, len(nodes), nil
+}
+
+// resolveAnsibleUser 获取 Ansible 连接用户名：使用 SSH 密钥的用户名，默认为 root
+func (s *InventoryService) resolveAnsibleUser(sshKeyID *uint) string {
+	if sshKeyID == nil {
+		return "root"
+	}
+
+	var sshKey model.AnsibleSSHKey
+	if err := s.db.First(&sshKey, *sshKeyID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			s.logger.Warningf("SSH key %d not found, using default user 'root'", *sshKeyID)
+		} else {
+			s.logger.Errorf("Failed to get SSH key %d: %v", *sshKeyID, err)
+		}
+		return "root"
+	}
+	return sshKey.Username
+}
+
+// validateK8sFilter 校验节点筛选条件
+func validateK8sFilter(filter *model.K8sInventoryFilter) error {
+	if filter == nil {
+		return nil
+	}
+	if _, err := labels.Parse(filter.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+	switch filter.CordonState {
+	case model.CordonStateAll, model.CordonStateSchedulable, model.CordonStateCordoned:
+	default:
+		return fmt.Errorf("invalid cordon_state: %s", filter.CordonState)
+	}
+	for _, spec := range append(append([]string{}, filter.RequireTaints...), filter.ExcludeTaints...) {
+		if key, _, _ := parseTaintSpec(spec); key == "" {
+			return fmt.Errorf("invalid taint filter: %q", spec)
+		}
+	}
+	return nil
+}
+
+// filterK8sNodes 按筛选条件过滤节点
+func filterK8sNodes(nodes []k8s.NodeInfo, filter *model.K8sInventoryFilter) ([]k8s.NodeInfo, error) {
+	if err := validateK8sFilter(filter); err != nil {
+		return nil, err
+	}
+	selector, _ := labels.Parse(filter.LabelSelector)
+
+	var filtered []k8s.NodeInfo
+	for _, node := range nodes {
+		if !filter.IncludeControlPlane && isControlPlaneNode(node) {
+			continue
+		}
+		if !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		if !matchNodeLabels(node, filter.NodeLabels) {
+			continue
+		}
+		if filter.ReadyOnly && !isNodeReady(node) {
+			continue
+		}
+		if filter.CordonState == model.CordonStateSchedulable && !node.Schedulable {
+			continue
+		}
+		if filter.CordonState == model.CordonStateCordoned && node.Schedulable {
+			continue
+		}
+		if !hasAllTaints(node, filter.RequireTaints) || hasAnyTaint(node, filter.ExcludeTaints) {
+			continue
+		}
+		filtered = append(filtered, node)
+	}
+	return filtered, nil
+}
+
+// isControlPlaneNode 判断是否为 master/control-plane 节点
+func isControlPlaneNode(node k8s.NodeInfo) bool {
+	if _, exists := node.Labels["node-role.kubernetes.io/master"]; exists {
+		return true
+	}
+	_, exists := node.Labels["node-role.kubernetes.io/control-plane"]
+	return exists
+}
+
+// isNodeReady 判断节点是否 Ready（Status 形如 "Ready" 或 "Ready,SchedulingDisabled"）
+func isNodeReady(node k8s.NodeInfo) bool {
+	return strings.SplitN(node.Status, ",", 2)[0] == "Ready"
+}
+
+// matchNodeLabels 等值标签匹配
+func matchNodeLabels(node k8s.NodeInfo, expected map[string]string) bool {
+	for key, value := range expected {
+		if nodeValue, exists := node.Labels[key]; !exists || nodeValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTaintSpec 解析污点筛选表达式：key、key=value、key:effect 或 key=value:effect
+func parseTaintSpec(spec string) (key, value, effect string) {
+	spec = strings.TrimSpace(spec)
+	if idx := strings.LastIndex(spec, ":"); idx >= 0 {
+		spec, effect = spec[:idx], spec[idx+1:]
+	}
+	key = spec
+	if idx := strings.Index(spec, "="); idx >= 0 {
+		key, value = spec[:idx], spec[idx+1:]
+	}
+	return key, value, effect
+}
+
+// matchTaintSpec 判断节点污点是否匹配筛选表达式（未指定的 value/effect 视为任意值）
+func matchTaintSpec(taint k8s.TaintInfo, spec string) bool {
+	key, value, effect := parseTaintSpec(spec)
+	if taint.Key != key {
+		return false
+	}
+	if value != "" && taint.Value != value {
+		return false
+	}
+	return effect == "" || taint.Effect == effect
+}
+
+// hasAllTaints 节点是否带有全部指定污点
+func hasAllTaints(node k8s.NodeInfo, specs []string) bool {
+	for _, spec := range specs {
+		found := false
+		for _, taint := range node.Taints {
+			if matchTaintSpec(taint, spec) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAnyTaint 节点是否带有任一指定污点
+func hasAnyTaint(node k8s.NodeInfo, specs []string) bool {
+	for _, spec := range specs {
+		for _, taint := range node.Taints {
+			if matchTaintSpec(taint, spec) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstLabel 按顺序返回第一个存在的标签值
+func firstLabel(node k8s.NodeInfo, keys []string) string {
+	for _, key := range keys {
+		if value := node.Labels[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// inventoryGroupName 生成合法的 Ansible 组名
+func inventoryGroupName(prefix, value string) string {
+	name := invalidGroupChars.ReplaceAllString(strings.ToLower(value), "_")
+	return prefix + strings.Trim(name, "_")
+}
+
+// nodeInventoryGroups 根据节点角色、可用区、地域、机型和状态生成节点所属的组
+func nodeInventoryGroups(node k8s.NodeInfo) []string {
+	var groups []string
+	for _, role := range node.Roles {
+		groups = append(groups, inventoryGroupName("role_", role))
+	}
+	if zone := firstLabel(node, zoneLabels); zone != "" {
+		groups = append(groups, inventoryGroupName("zone_", zone))
+	}
+	if region := firstLabel(node, regionLabels); region != "" {
+		groups = append(groups, inventoryGroupName("region_", region))
+	}
+	if instanceType := firstLabel(node, instanceTypeLabels); instanceType != "" {
+		groups = append(groups, inventoryGroupName("instance_type_", instanceType))
+	}
+	if !node.Schedulable {
+		groups = append(groups, "cordoned")
+	}
+	if !isNodeReady(node) {
+		groups = append(groups, "not_ready")
+	}
+	return groups
+}
+
+// nodeHostVars 根据节点信息生成主机变量（不含连接变量）
+func nodeHostVars(node k8s.NodeInfo) [][2]string {
+	vars := [][2]string{
+		{"k8s_kubelet_version", node.Version},
+		{"k8s_os_image", node.OSImage},
+		{"k8s_kernel_version", node.KernelVersion},
+		{"k8s_container_runtime", node.ContainerRuntime},
+		{"k8s_zone", firstLabel(node, zoneLabels)},
+		{"k8s_region", firstLabel(node, regionLabels)},
+		{"k8s_instance_type", firstLabel(node, instanceTypeLabels)},
+		{"k8s_ready", strconv.FormatBool(isNodeReady(node))},
+		{"k8s_schedulable", strconv.FormatBool(node.Schedulable)},
+	}
+
+	result := make([][2]string, 0, len(vars))
+	for _, v := range vars {
+		if v[1] != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// iniValue 格式化 INI 主机变量值，包含空白或引号时加引号
+func iniValue(value string) string {
+	if strings.ContainsAny(value, " \t\"'#;=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,113 @@
+package ansible
+
+import (
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+	"reflect"
+	"testing"
+)
+
+func testNodes() []k8s.NodeInfo {
+	return []k8s.NodeInfo{
+		{
+			Name: "master-1", Status: "Ready", Schedulable: true, Roles: []string{"control-plane"},
+			Labels: map[string]string{"node-role.kubernetes.io/control-plane": ""},
+		},
+		{
+			Name: "worker-1", Status: "Ready", Schedulable: true, Roles: []string{"worker"},
+			Labels: map[string]string{"env": "prod", "topology.kubernetes.io/zone": "us-east-1a", "node.kubernetes.io/instance-type": "m5.xlarge"},
+		},
+		{
+			Name: "worker-2", Status: "Ready,SchedulingDisabled", Schedulable: false, Roles: []string{"worker"},
+			Labels: map[string]string{"env": "prod"},
+			Taints: []k8s.TaintInfo{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}},
+		},
+		{
+			Name: "worker-3", Status: "NotReady", Schedulable: true, Roles: []string{"worker"},
+			Labels: map[string]string{"env": "test"},
+		},
+	}
+}
+
+func nodeNames(nodes []k8s.NodeInfo) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+// TestFilterK8sNodes 测试标签选择器、污点、就绪和调度状态筛选
+func TestFilterK8sNodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter model.K8sInventoryFilter
+		want   []string
+	}{
+		{"default excludes control plane", model.K8sInventoryFilter{}, []string{"worker-1", "worker-2", "worker-3"}},
+		{"include control plane", model.K8sInventoryFilter{IncludeControlPlane: true}, []string{"master-1", "worker-1", "worker-2", "worker-3"}},
+		{"label selector", model.K8sInventoryFilter{LabelSelector: "env in (prod)"}, []string{"worker-1", "worker-2"}},
+		{"ready only", model.K8sInventoryFilter{ReadyOnly: true}, []string{"worker-1", "worker-2"}},
+		{"schedulable", model.K8sInventoryFilter{CordonState: model.CordonStateSchedulable}, []string{"worker-1", "worker-3"}},
+		{"cordoned", model.K8sInventoryFilter{CordonState: model.CordonStateCordoned}, []string{"worker-2"}},
+		{"require taint", model.K8sInventoryFilter{RequireTaints: []string{"dedicated=gpu:NoSchedule"}}, []string{"worker-2"}},
+		{"exclude taint key", model.K8sInventoryFilter{ExcludeTaints: []string{"dedicated"}}, []string{"worker-1", "worker-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter
+			got, err := filterK8sNodes(testNodes(), &filter)
+			if err != nil {
+				t.Fatalf("filterK8sNodes() error = %v", err)
+			}
+			if names := nodeNames(got); !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("filterK8sNodes() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+
+	if _, err := filterK8sNodes(testNodes(), &model.K8sInventoryFilter{LabelSelector: "env in prod"}); err == nil {
+		t.Error("filterK8sNodes() with invalid selector should fail")
+	}
+	if _, err := filterK8sNodes(testNodes(), &model.K8sInventoryFilter{CordonState: "drained"}); err == nil {
+		t.Error("filterK8sNodes() with invalid cordon state should fail")
+	}
+}
+
+// TestNodeInventoryGroups 测试按节点信息生成分组
+func TestNodeInventoryGroups(t *testing.T) {
+	nodes := testNodes()
+
+	got := nodeInventoryGroups(nodes[1])
+	want := []string{"role_worker", "zone_us_east_1a", "instance_type_m5_xlarge"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodeInventoryGroups(worker-1) = %v, want %v", got, want)
+	}
+
+	got = nodeInventoryGroups(nodes[2])
+	want = []string{"role_worker", "cordoned"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodeInventoryGroups(worker-2) = %v, want %v", got, want)
+	}
+}
+
+// TestListInventoryHosts 测试带分组的清单中主机去重
+func TestListInventoryHosts(t *testing.T) {
+	content := "# generated\n[all]\nnode-a ansible_host=10.0.0.1 k8s_os_image=\"Ubuntu 22.04 LTS\"\nnode-b ansible_host=10.0.0.2\n\n[role_worker]\nnode-a\nnode-b\n\n[all:vars]\nansible_user=root\n"
+	got := listInventoryHosts(content)
+	if !reflect.DeepEqual(got, []string{"node-a", "node-b"}) {
+		t.Errorf("listInventoryHosts() = %v", got)
+	}
+
+	if !InventoryHasHost(content, "node-b") || !InventoryHasHost(content, "10.0.0.1") {
+		t.Error("InventoryHasHost() should match host name and ansible_host")
+	}
+	if InventoryHasHost(content, "node-c", "root") {
+		t.Error("InventoryHasHost() should not match hosts outside host groups")
+	}
+
+	if v := iniValue("Ubuntu 22.04 LTS"); v != `"Ubuntu 22.04 LTS"` {
+		t.Errorf("iniValue() = %s", v)
+	}
+}
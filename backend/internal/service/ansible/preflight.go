@@ -62,9 +62,11 @@ func (s *PreflightService) RunPreflightChecks(taskID uint) (*model.PreflightChec
 		checks = append(checks, check2)
 	}
 
-	// 3. 检查 Playbook 语法
-	check3 := s.checkPlaybookSyntax(task.PlaybookContent)
-	checks = append(checks, check3)
+	// 3. 检查 Playbook 语法（临时命令没有 Playbook）
+	if !task.IsAdhoc() {
+		check3 := s.checkPlaybookSyntax(task.PlaybookContent)
+		checks = append(checks, check3)
+	}
 
 	// 计算摘要
 	summary := s.calculateSummary(checks)
@@ -0,0 +1,467 @@
+package ansible
+
+import (
+	"context"
+	"fmt"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxProjectCheckouts 每个项目保留的检出目录数量
+const maxProjectCheckouts = 10
+
+// ProjectService Git 项目服务
+// 每个项目维护一个裸镜像（mirror.git），任务执行时按提交 SHA 检出到 checkouts/<sha>
+type ProjectService struct {
+	db        *gorm.DB
+	logger    *logger.Logger
+	sshKeySvc *SSHKeyService
+	baseDir   string
+	locks     map[uint]*sync.Mutex
+	locksMu   sync.Mutex
+}
+
+// NewProjectService 创建 Git 项目服务实例
+func NewProjectService(db *gorm.DB, logger *logger.Logger, sshKeySvc *SSHKeyService, baseDir string) *ProjectService {
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		logger.Errorf("Failed to create project directory: %v", err)
+	}
+
+	return &ProjectService{
+		db:        db,
+		logger:    logger,
+		sshKeySvc: sshKeySvc,
+		baseDir:   baseDir,
+		locks:     make(map[uint]*sync.Mutex),
+	}
+}
+
+// ListProjects 列出项目
+func (s *ProjectService) ListProjects(req model.ProjectListRequest) ([]model.AnsibleProject, int64, error) {
+	var projects []model.AnsibleProject
+	var total int64
+
+	query := s.db.Model(&model.AnsibleProject{})
+
+	if req.Keyword != "" {
+		query = query.Where("name LIKE ? OR repo_url LIKE ?", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count projects: %w", err)
+	}
+
+	if req.Page > 0 && req.PageSize > 0 {
+		offset := (req.Page - 1) * req.PageSize
+		query = query.Offset(offset).Limit(req.PageSize)
+	}
+
+	if err := query.Order("created_at DESC").Find(&projects).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	return projects, total, nil
+}
+
+// GetProject 获取项目详情
+func (s *ProjectService) GetProject(id uint) (*model.AnsibleProject, error) {
+	var project model.AnsibleProject
+	if err := s.db.First(&project, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("project not found")
+		}
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return &project, nil
+}
+
+// CreateProject 创建项目
+func (s *ProjectService) CreateProject(req model.ProjectCreateRequest, userID uint) (*model.AnsibleProject, error) {
+	var count int64
+	if err := s.db.Model(&model.AnsibleProject{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check project name: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("project name already exists")
+	}
+
+	repoURL := strings.TrimSpace(req.RepoURL)
+	if err := validateRepoURL(repoURL); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateSSHKey(req.SSHKeyID); err != nil {
+		return nil, err
+	}
+
+	ref := req.Ref
+	if ref == "" {
+		ref = "main"
+	}
+
+	project := &model.AnsibleProject{
+		Name:        req.Name,
+		Description: req.Description,
+		RepoURL:     repoURL,
+		Ref:         ref,
+		SSHKeyID:    req.SSHKeyID,
+		SyncStatus:  model.ProjectSyncStatusNever,
+		UserID:      userID,
+	}
+
+	if err := s.db.Create(project).Error; err != nil {
+		return nil, fmt.Errorf("failed to create project: %w", err)
+	}
+
+	s.logger.Infof("Created project: %s (ID: %d) by user %d", project.Name, project.ID, userID)
+	return project, nil
+}
+
+// UpdateProject 更新项目（修改仓库地址或 ref 后需要重新同步）
+func (s *ProjectService) UpdateProject(id uint, req model.ProjectUpdateRequest, userID uint) (*model.AnsibleProject, error) {
+	project, err := s.GetProject(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" && req.Name != project.Name {
+		var count int64
+		if err := s.db.Model(&model.AnsibleProject{}).Where("name = ? AND id != ?", req.Name, id).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check project name: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("project name already exists")
+		}
+		project.Name = req.Name
+	}
+	if req.Description != "" {
+		project.Description = req.Description
+	}
+	if req.RepoURL != "" {
+		repoURL := strings.TrimSpace(req.RepoURL)
+		if err := validateRepoURL(repoURL); err != nil {
+			return nil, err
+		}
+		project.RepoURL = repoURL
+	}
+	if req.Ref != "" {
+		project.Ref = req.Ref
+	}
+	if req.SSHKeyID != nil {
+		if err := s.validateSSHKey(req.SSHKeyID); err != nil {
+			return nil, err
+		}
+		project.SSHKeyID = req.SSHKeyID
+	}
+
+	if err := s.db.Save(project).Error; err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	s.logger.Infof("Updated project: %s (ID: %d) by user %d", project.Name, project.ID, userID)
+	return project, nil
+}
+
+// DeleteProject 删除项目及其本地工作区
+func (s *ProjectService) DeleteProject(id uint, userID uint) error {
+	project, err := s.GetProject(id)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.Model(&model.AnsibleTemplate{}).Where("project_id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check template usage: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("project is in use by %d templates, cannot delete", count)
+	}
+
+	if err := s.db.Delete(project).Error; err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	lock := s.projectLock(id)
+	lock.Lock()
+	if err := os.RemoveAll(s.projectDir(id)); err != nil {
+		s.logger.Warningf("Failed to remove workspace of project %d: %v", id, err)
+	}
+	lock.Unlock()
+
+	s.logger.Infof("Deleted project: %s (ID: %d) by user %d", project.Name, project.ID, userID)
+	return nil
+}
+
+// SyncProject 同步项目仓库，并将 ref 解析为提交 SHA 记录到项目
+func (s *ProjectService) SyncProject(ctx context.Context, id uint) (*model.AnsibleProject, error) {
+	project, err := s.GetProject(id)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := s.projectLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	s.db.Model(project).Update("sync_status", model.ProjectSyncStatusSyncing)
+
+	sha, syncErr := s.fetchAndResolve(ctx, project)
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_synced_at": &now,
+	}
+	if syncErr != nil {
+		updates["sync_status"] = model.ProjectSyncStatusFailed
+		updates["sync_error"] = syncErr.Error()
+	} else {
+		updates["sync_status"] = model.ProjectSyncStatusSuccess
+		updates["sync_error"] = ""
+		updates["last_commit_sha"] = sha
+	}
+	if err := s.db.Model(project).Updates(updates).Error; err != nil {
+		s.logger.Errorf("Failed to save sync result of project %d: %v", id, err)
+	}
+
+	if syncErr != nil {
+		s.logger.Errorf("Failed to sync project %s (ID: %d): %v", project.Name, id, syncErr)
+		return nil, syncErr
+	}
+
+	s.logger.Infof("Synced project %s (ID: %d) ref %s to commit %s", project.Name, id, project.Ref, shortSHA(sha))
+	s.pruneCheckouts(ctx, id)
+
+	return s.GetProject(id)
+}
+
+// ReadPlaybook 读取指定提交中的 playbook 内容
+func (s *ProjectService) ReadPlaybook(ctx context.Context, id uint, sha, playbookPath string) (string, error) {
+	if err := validatePlaybookPath(playbookPath); err != nil {
+		return "", err
+	}
+
+	lock := s.projectLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.ensureCommit(ctx, id, sha); err != nil {
+		return "", err
+	}
+
+	return readFileAtCommit(ctx, s.mirrorDir(id), sha, playbookPath)
+}
+
+// ResolveCommit 校验并解析提交（支持短 SHA、分支、标签），返回完整 SHA
+func (s *ProjectService) ResolveCommit(ctx context.Context, id uint, ref string) (string, error) {
+	lock := s.projectLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.ensureCommit(ctx, id, ref); err != nil {
+		return "", err
+	}
+	return resolveCommit(ctx, s.mirrorDir(id), ref)
+}
+
+// PrepareCheckout 准备指定提交的检出目录，返回目录路径
+func (s *ProjectService) PrepareCheckout(ctx context.Context, id uint, sha string) (string, error) {
+	if sha == "" {
+		return "", fmt.Errorf("commit sha is required")
+	}
+
+	lock := s.projectLock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := s.ensureCommit(ctx, id, sha); err != nil {
+		return "", err
+	}
+
+	checkoutDir := filepath.Join(s.projectDir(id), "checkouts", sha)
+	if err := checkoutCommit(ctx, s.mirrorDir(id), checkoutDir, sha); err != nil {
+		return "", fmt.Errorf("failed to checkout commit %s: %w", shortSHA(sha), err)
+	}
+
+	// 更新修改时间，用于检出目录的淘汰
+	now := time.Now()
+	os.Chtimes(checkoutDir, now, now)
+
+	return checkoutDir, nil
+}
+
+// ensureCommit 确保镜像中存在指定提交（镜像缺失或提交不存在时重新拉取），调用方需持有项目锁
+func (s *ProjectService) ensureCommit(ctx context.Context, id uint, sha string) error {
+	mirrorDir := s.mirrorDir(id)
+	if _, err := os.Stat(mirrorDir); err == nil && hasCommit(ctx, mirrorDir, sha) {
+		return nil
+	}
+
+	project, err := s.GetProject(id)
+	if err != nil {
+		return err
+	}
+
+	env, cleanup, err := s.gitCredentialEnv(project)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	if err := syncMirror(ctx, env, mirrorDir, project.RepoURL); err != nil {
+		return err
+	}
+	if !hasCommit(ctx, mirrorDir, sha) {
+		return fmt.Errorf("commit %s not found in project %s", shortSHA(sha), project.Name)
+	}
+	return nil
+}
+
+// fetchAndResolve 拉取仓库并解析项目 ref，调用方需持有项目锁
+func (s *ProjectService) fetchAndResolve(ctx context.Context, project *model.AnsibleProject) (string, error) {
+	env, cleanup, err := s.gitCredentialEnv(project)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	mirrorDir := s.mirrorDir(project.ID)
+	if err := syncMirror(ctx, env, mirrorDir, project.RepoURL); err != nil {
+		return "", err
+	}
+
+	return resolveCommit(ctx, mirrorDir, project.Ref)
+}
+
+// gitCredentialEnv 根据项目关联的 SSH 密钥生成 git 凭据环境变量
+// 私钥通过 GIT_SSH_COMMAND 使用，密码通过 GIT_ASKPASS 脚本提供；返回的 cleanup 用于删除临时文件
+// SSH 连接使用项目工作区内的 known_hosts：首次连接记录主机密钥，之后主机密钥变化时拒绝连接
+func (s *ProjectService) gitCredentialEnv(project *model.AnsibleProject) ([]string, func(), error) {
+	noop := func() {}
+
+	if err := os.MkdirAll(s.projectDir(project.ID), 0755); err != nil {
+		return nil, noop, fmt.Errorf("failed to create project directory: %w", err)
+	}
+	sshCommand := fmt.Sprintf("ssh -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile=%s", s.knownHostsFile(project.ID))
+
+	if project.SSHKeyID == nil {
+		return []string{"GIT_SSH_COMMAND=" + sshCommand}, noop, nil
+	}
+
+	key, err := s.sshKeySvc.GetDecryptedByID(*project.SSHKeyID)
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to get ssh key: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(s.baseDir, "git-cred-")
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to create credential directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+
+	if key.Type == model.SSHKeyTypePassword {
+		scriptFile := filepath.Join(tmpDir, "askpass.sh")
+		if err := os.WriteFile(scriptFile, []byte(gitAskPassScript), 0700); err != nil {
+			cleanup()
+			return nil, noop, fmt.Errorf("failed to write askpass script: %w", err)
+		}
+		return []string{
+			"GIT_SSH_COMMAND=" + sshCommand,
+			"GIT_ASKPASS=" + scriptFile,
+			"GIT_USERNAME=" + key.Username,
+			"GIT_PASSWORD=" + key.Password,
+		}, cleanup, nil
+	}
+
+	keyFile := filepath.Join(tmpDir, "id_key")
+	if err := os.WriteFile(keyFile, []byte(key.PrivateKey), 0600); err != nil {
+		cleanup()
+		return nil, noop, fmt.Errorf("failed to write ssh key file: %w", err)
+	}
+	sshCommand += fmt.Sprintf(" -i %s -o IdentitiesOnly=yes", keyFile)
+	return []string{"GIT_SSH_COMMAND=" + sshCommand}, cleanup, nil
+}
+
+// pruneCheckouts 只保留最近使用的若干检出目录，调用方需持有项目锁
+func (s *ProjectService) pruneCheckouts(ctx context.Context, id uint) {
+	checkoutsDir := filepath.Join(s.projectDir(id), "checkouts")
+	entries, err := os.ReadDir(checkoutsDir)
+	if err != nil || len(entries) <= maxProjectCheckouts {
+		return
+	}
+
+	type checkout struct {
+		path    string
+		modTime time.Time
+	}
+	checkouts := make([]checkout, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		checkouts = append(checkouts, checkout{path: filepath.Join(checkoutsDir, entry.Name()), modTime: info.ModTime()})
+	}
+
+	// 最近使用的在前
+	sort.Slice(checkouts, func(i, j int) bool {
+		return checkouts[i].modTime.After(checkouts[j].modTime)
+	})
+
+	for _, c := range checkouts[min(maxProjectCheckouts, len(checkouts)):] {
+		// 跳过一小时内使用过的检出，避免删除正在执行的任务目录
+		if time.Since(c.modTime) < time.Hour {
+			continue
+		}
+		if err := removeCheckout(ctx, s.mirrorDir(id), c.path); err != nil {
+			s.logger.Warningf("Failed to remove checkout %s: %v", c.path, err)
+		}
+	}
+}
+
+// validateSSHKey 校验凭据 SSH 密钥是否存在
+func (s *ProjectService) validateSSHKey(sshKeyID *uint) error {
+	if sshKeyID == nil {
+		return nil
+	}
+	if _, err := s.sshKeySvc.GetByID(*sshKeyID); err != nil {
+		return fmt.Errorf("invalid ssh key: %w", err)
+	}
+	return nil
+}
+
+// projectLock 获取项目级别的锁，串行化同一项目的 git 操作
+func (s *ProjectService) projectLock(id uint) *sync.Mutex {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	lock, ok := s.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[id] = lock
+	}
+	return lock
+}
+
+// projectDir 项目工作区目录
+func (s *ProjectService) projectDir(id uint) string {
+	return filepath.Join(s.baseDir, strconv.FormatUint(uint64(id), 10))
+}
+
+// mirrorDir 项目裸镜像目录
+func (s *ProjectService) mirrorDir(id uint) string {
+	return filepath.Join(s.projectDir(id), "mirror.git")
+}
+
+// knownHostsFile 项目仓库主机的 known_hosts 文件（删除项目时随工作区一起删除）
+func (s *ProjectService) knownHostsFile(id uint) string {
+	return filepath.Join(s.projectDir(id), "known_hosts")
+}
@@ -0,0 +1,187 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// gitCommandTimeout 单条 git 命令的超时时间
+const gitCommandTimeout = 5 * time.Minute
+
+// gitAskPassScript 通过环境变量提供 HTTP(S) 用户名和密码，避免凭据出现在仓库地址或命令行中
+const gitAskPassScript = `#!/bin/sh
+case "$1" in
+  Username*) echo "$GIT_USERNAME" ;;
+  *) echo "$GIT_PASSWORD" ;;
+esac
+`
+
+// scpRepoURLPattern 匹配 scp 风格的 SSH 仓库地址，例如 git@gitlab.example.com:group/repo.git
+// 路径不能以冒号开头，避免被识别为 <transport>::<address> 形式的远程助手
+var scpRepoURLPattern = regexp.MustCompile(`^(?:[A-Za-z0-9._~-]+@)?[A-Za-z0-9][A-Za-z0-9.-]*:[^:\s][^\s]*$`)
+
+// runGit 执行 git 命令并返回标准输出
+func runGit(ctx context.Context, env []string, dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	cmd.Env = append(cmd.Env, env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+
+	return stdout.String(), nil
+}
+
+// syncMirror 同步仓库到本地裸镜像：首次 clone --mirror，之后 fetch --prune
+func syncMirror(ctx context.Context, env []string, mirrorDir, repoURL string) error {
+	if _, err := os.Stat(filepath.Join(mirrorDir, "HEAD")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(mirrorDir), 0755); err != nil {
+			return fmt.Errorf("failed to create project directory: %w", err)
+		}
+		os.RemoveAll(mirrorDir)
+		_, err := runGit(ctx, env, "", "clone", "--mirror", "--", repoURL, mirrorDir)
+		return err
+	}
+
+	// 仓库地址可能已被修改
+	if _, err := runGit(ctx, env, mirrorDir, "remote", "set-url", "--", "origin", repoURL); err != nil {
+		return err
+	}
+	_, err := runGit(ctx, env, mirrorDir, "fetch", "--prune", "origin")
+	return err
+}
+
+// resolveCommit 将分支/标签/提交解析为完整的提交 SHA
+func resolveCommit(ctx context.Context, mirrorDir, ref string) (string, error) {
+	output, err := runGit(ctx, nil, mirrorDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("ref %s not found in repository", ref)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// hasCommit 检查镜像中是否存在指定提交
+func hasCommit(ctx context.Context, mirrorDir, sha string) bool {
+	_, err := runGit(ctx, nil, mirrorDir, "cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// checkoutCommit 以 detached worktree 方式检出指定提交
+// 同一提交的检出目录内容不可变，已存在时直接复用
+func checkoutCommit(ctx context.Context, mirrorDir, checkoutDir, sha string) error {
+	if _, err := os.Stat(filepath.Join(checkoutDir, ".git")); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(checkoutDir), 0755); err != nil {
+		return fmt.Errorf("failed to create checkout directory: %w", err)
+	}
+	os.RemoveAll(checkoutDir)
+
+	// 清理残留的 worktree 记录（目录被外部删除的情况）
+	runGit(ctx, nil, mirrorDir, "worktree", "prune")
+
+	_, err := runGit(ctx, nil, mirrorDir, "worktree", "add", "--detach", "--force", checkoutDir, sha)
+	return err
+}
+
+// removeCheckout 删除检出目录
+func removeCheckout(ctx context.Context, mirrorDir, checkoutDir string) error {
+	if _, err := runGit(ctx, nil, mirrorDir, "worktree", "remove", "--force", checkoutDir); err != nil {
+		if rmErr := os.RemoveAll(checkoutDir); rmErr != nil {
+			return rmErr
+		}
+		runGit(ctx, nil, mirrorDir, "worktree", "prune")
+	}
+	return nil
+}
+
+// readFileAtCommit 读取指定提交中的文件内容
+func readFileAtCommit(ctx context.Context, mirrorDir, sha, path string) (string, error) {
+	output, err := runGit(ctx, nil, mirrorDir, "show", sha+":"+filepath.ToSlash(path))
+	if err != nil {
+		return "", fmt.Errorf("file %s not found at commit %s", path, shortSHA(sha))
+	}
+	return output, nil
+}
+
+// validateRepoURL 校验仓库地址：只允许 https、ssh 和 scp 风格的 SSH 地址
+// 禁止以 - 开头（会被 git 解析为选项）以及 file://、ext:: 等可在服务器本地读取文件或执行命令的协议
+func validateRepoURL(repoURL string) error {
+	if repoURL == "" {
+		return fmt.Errorf("repo_url is required")
+	}
+	if strings.HasPrefix(repoURL, "-") {
+		return fmt.Errorf("repo_url must not start with '-'")
+	}
+
+	if strings.Contains(repoURL, "://") {
+		parsed, err := url.Parse(repoURL)
+		if err != nil {
+			return fmt.Errorf("invalid repo_url: %w", err)
+		}
+		if parsed.Scheme != "https" && parsed.Scheme != "ssh" {
+			return fmt.Errorf("repo_url scheme %q is not allowed, use https or ssh", parsed.Scheme)
+		}
+		if parsed.Hostname() == "" || strings.HasPrefix(parsed.Hostname(), "-") {
+			return fmt.Errorf("repo_url must contain a valid host")
+		}
+		return nil
+	}
+
+	if !scpRepoURLPattern.MatchString(repoURL) {
+		return fmt.Errorf("repo_url must be an https, ssh or scp-style (user@host:path) address")
+	}
+	return nil
+}
+
+// validatePlaybookPath 校验项目内 playbook 路径：必须是仓库内的相对 YAML 文件路径
+func validatePlaybookPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("playbook_path is required for project templates")
+	}
+	if filepath.IsAbs(path) {
+		return fmt.Errorf("playbook_path must be relative to the repository root")
+	}
+
+	cleaned := filepath.Clean(path)
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("playbook_path must not escape the repository")
+	}
+
+	ext := strings.ToLower(filepath.Ext(cleaned))
+	if ext != ".yml" && ext != ".yaml" {
+		return fmt.Errorf("playbook_path must be a .yml or .yaml file")
+	}
+
+	return nil
+}
+
+// shortSHA 返回提交 SHA 的短格式
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
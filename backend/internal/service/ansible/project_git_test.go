@@ -0,0 +1,191 @@
+package ansible
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitForTest 在指定目录执行 git 命令，失败时终止测试
+func gitForTest(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// newTestRepository 创建本地裸仓库并推送一个包含 playbook 和 role 的提交，返回仓库地址和提交 SHA
+func newTestRepository(t *testing.T) (string, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	bare := filepath.Join(root, "origin.git")
+	work := filepath.Join(root, "work")
+
+	gitForTest(t, root, "init", "--bare", "--initial-branch=main", bare)
+	gitForTest(t, root, "clone", bare, work)
+
+	files := map[string]string{
+		"site.yml":                    "- hosts: all\n  roles:\n    - common\n",
+		"roles/common/tasks/main.yml": "- name: ping\n  ping:\n",
+		"group_vars/all.yml":          "app_version: \"1.0\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(work, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gitForTest(t, work, "checkout", "-b", "main")
+	gitForTest(t, work, "add", ".")
+	gitForTest(t, work, "commit", "-m", "initial")
+	first := gitForTest(t, work, "rev-parse", "HEAD")
+	gitForTest(t, work, "push", "origin", "main")
+
+	// 第二个提交修改 group_vars，用于验证按提交固定
+	if err := os.WriteFile(filepath.Join(work, "group_vars/all.yml"), []byte("app_version: \"2.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitForTest(t, work, "commit", "-am", "bump version")
+	second := gitForTest(t, work, "rev-parse", "HEAD")
+	gitForTest(t, work, "push", "origin", "main")
+
+	return "file://" + bare, first, second
+}
+
+// TestProjectGitWorkspace 测试镜像同步、ref 解析、按提交检出和读取文件
+func TestProjectGitWorkspace(t *testing.T) {
+	repoURL, firstSHA, secondSHA := newTestRepository(t)
+	ctx := context.Background()
+
+	mirrorDir := filepath.Join(t.TempDir(), "1", "mirror.git")
+	if err := syncMirror(ctx, nil, mirrorDir, repoURL); err != nil {
+		t.Fatalf("syncMirror() initial clone error = %v", err)
+	}
+	// 再次同步走 fetch 路径
+	if err := syncMirror(ctx, nil, mirrorDir, repoURL); err != nil {
+		t.Fatalf("syncMirror() fetch error = %v", err)
+	}
+
+	sha, err := resolveCommit(ctx, mirrorDir, "main")
+	if err != nil {
+		t.Fatalf("resolveCommit() error = %v", err)
+	}
+	if sha != secondSHA {
+		t.Errorf("resolveCommit(main) = %s, want %s", sha, secondSHA)
+	}
+	if _, err := resolveCommit(ctx, mirrorDir, "no-such-branch"); err == nil {
+		t.Error("resolveCommit() for missing ref should fail")
+	}
+
+	if !hasCommit(ctx, mirrorDir, firstSHA) {
+		t.Errorf("hasCommit(%s) = false, want true", firstSHA)
+	}
+
+	checkoutDir := filepath.Join(filepath.Dir(mirrorDir), "checkouts", firstSHA)
+	if err := checkoutCommit(ctx, mirrorDir, checkoutDir, firstSHA); err != nil {
+		t.Fatalf("checkoutCommit() error = %v", err)
+	}
+	// 已存在的检出目录直接复用
+	if err := checkoutCommit(ctx, mirrorDir, checkoutDir, firstSHA); err != nil {
+		t.Fatalf("checkoutCommit() reuse error = %v", err)
+	}
+
+	vars, err := os.ReadFile(filepath.Join(checkoutDir, "group_vars/all.yml"))
+	if err != nil {
+		t.Fatalf("checkout missing group_vars: %v", err)
+	}
+	if !strings.Contains(string(vars), "1.0") {
+		t.Errorf("checkout is not pinned to first commit, group_vars = %q", vars)
+	}
+	if _, err := os.Stat(filepath.Join(checkoutDir, "roles/common/tasks/main.yml")); err != nil {
+		t.Errorf("checkout missing role files: %v", err)
+	}
+
+	content, err := readFileAtCommit(ctx, mirrorDir, secondSHA, "group_vars/all.yml")
+	if err != nil {
+		t.Fatalf("readFileAtCommit() error = %v", err)
+	}
+	if !strings.Contains(content, "2.0") {
+		t.Errorf("readFileAtCommit() = %q, want version 2.0", content)
+	}
+	if _, err := readFileAtCommit(ctx, mirrorDir, secondSHA, "missing.yml"); err == nil {
+		t.Error("readFileAtCommit() for missing file should fail")
+	}
+
+	if err := removeCheckout(ctx, mirrorDir, checkoutDir); err != nil {
+		t.Fatalf("removeCheckout() error = %v", err)
+	}
+	if _, err := os.Stat(checkoutDir); !os.IsNotExist(err) {
+		t.Errorf("checkout directory still exists after removeCheckout()")
+	}
+}
+
+// TestValidatePlaybookPath 测试项目内 playbook 路径校验
+func TestValidatePlaybookPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"site.yml", false},
+		{"playbooks/deploy.yaml", false},
+		{"", true},
+		{"/etc/site.yml", true},
+		{"../outside.yml", true},
+		{"playbooks/../../outside.yml", true},
+		{"README.md", true},
+	}
+
+	for _, tt := range tests {
+		err := validatePlaybookPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validatePlaybookPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+// TestValidateRepoURL 测试仓库地址校验
+func TestValidateRepoURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://gitlab.example.com/ops/playbooks.git", false},
+		{"ssh://git@gitlab.example.com:2222/ops/playbooks.git", false},
+		{"git@gitlab.example.com:ops/playbooks.git", false},
+		{"gitlab.example.com:/srv/git/playbooks.git", false},
+		{"", true},
+		{"--upload-pack=touch /tmp/pwned", true},
+		{"-u sh", true},
+		{"file:///etc", true},
+		{"/srv/git/playbooks.git", true},
+		{"http://gitlab.example.com/ops/playbooks.git", true},
+		{"ext::sh -c touch% /tmp/pwned", true},
+		{"fd::3", true},
+		{"https://-oProxyCommand=sh/repo.git", true},
+	}
+
+	for _, tt := range tests {
+		err := validateRepoURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateRepoURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
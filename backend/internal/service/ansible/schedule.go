@@ -152,6 +152,7 @@ func (s *ScheduleService) executeSchedule(scheduleID uint) {
 	taskReq := model.TaskCreateRequest{
 		Name:            fmt.Sprintf("[定时任务] %s", schedule.Name),
 		TemplateID:      &schedule.TemplateID,
+		TemplateRevision: &schedule.TemplateRevision,
 		ClusterID:       schedule.ClusterID,
 		InventoryID:     &schedule.InventoryID,
 		ExtraVars:       schedule.ExtraVars,
@@ -212,6 +213,13 @@ func (s *ScheduleService) CreateSchedule(req model.ScheduleCreateRequest, userID
 		return nil, fmt.Errorf("failed to verify template: %w", err)
 	}
 
+	// 验证固定的模板版本
+	if req.TemplateRevision != 0 {
+		if _, err := s.service.GetTemplateService().GetRevision(req.TemplateID, req.TemplateRevision); err != nil {
+			return nil, err
+		}
+	}
+
 	// 验证清单是否存在
 	var inventory model.AnsibleInventory
 	if err := s.db.First(&inventory, req.InventoryID).Error; err != nil {
@@ -226,6 +234,7 @@ func (s *ScheduleService) CreateSchedule(req model.ScheduleCreateRequest, userID
 		Name:        req.Name,
 		Description: req.Description,
 		TemplateID:  req.TemplateID,
+		TemplateRevision: req.TemplateRevision,
 		InventoryID: req.InventoryID,
 		ClusterID:   req.ClusterID,
 		CronExpr:    req.CronExpr,
@@ -331,6 +340,21 @@ func (s *ScheduleService) UpdateSchedule(id uint, req model.ScheduleUpdateReques
 		}
 		updates["template_id"] = req.TemplateID
 	}
+	if req.TemplateRevision != nil {
+		templateID := schedule.TemplateID
+		if req.TemplateID > 0 {
+			templateID = req.TemplateID
+		}
+		if *req.TemplateRevision != 0 {
+			if _, err := s.service.GetTemplateService().GetRevision(templateID, *req.TemplateRevision); err != nil {
+				return nil, err
+			}
+		}
+		updates["template_revision"] = *req.TemplateRevision
+	} else if req.TemplateID > 0 && req.TemplateID != schedule.TemplateID {
+		// 切换模板后原固定版本失效，改为跟随最新版本
+		updates["template_revision"] = 0
+	}
 	if req.InventoryID > 0 {
 		// 验证清单
 		var inventory model.AnsibleInventory
@@ -0,0 +1,286 @@
+package ansible
+
+import (
+	"fmt"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/crypto"
+	"kube-node-manager/pkg/logger"
+	"regexp"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// secretVarNamePattern Ansible 变量名规则
+var secretVarNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// SecretService 密钥变量服务
+type SecretService struct {
+	db        *gorm.DB
+	logger    *logger.Logger
+	encryptor *crypto.Encryptor
+}
+
+// NewSecretService 创建新的密钥变量服务实例
+func NewSecretService(db *gorm.DB, logger *logger.Logger, encryptor *crypto.Encryptor) *SecretService {
+	return &SecretService{
+		db:        db,
+		logger:    logger,
+		encryptor: encryptor,
+	}
+}
+
+// Create 创建密钥变量
+func (s *SecretService) Create(req model.SecretCreateRequest, userID uint) (*model.SecretResponse, error) {
+	var count int64
+	if err := s.db.Model(&model.AnsibleSecret{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check secret name: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("secret with name %s already exists", req.Name)
+	}
+
+	encryptedValue, err := s.encryptor.Encrypt(req.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret value: %w", err)
+	}
+
+	secret := &model.AnsibleSecret{
+		Name:        req.Name,
+		Description: req.Description,
+		Value:       encryptedValue,
+		CreatedBy:   userID,
+	}
+
+	if err := s.db.Create(secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to create secret: %w", err)
+	}
+
+	s.logger.Infof("Created secret: %s (ID: %d) by user %d", secret.Name, secret.ID, userID)
+
+	return secret.ToResponse(), nil
+}
+
+// List 列出密钥变量（不包含密钥值）
+func (s *SecretService) List(req model.SecretListRequest) ([]*model.SecretResponse, int64, error) {
+	var secrets []model.AnsibleSecret
+	var total int64
+
+	query := s.db.Model(&model.AnsibleSecret{})
+
+	if req.Keyword != "" {
+		query = query.Where("name LIKE ? OR description LIKE ?", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count secrets: %w", err)
+	}
+
+	if req.Page > 0 && req.PageSize > 0 {
+		offset := (req.Page - 1) * req.PageSize
+		query = query.Offset(offset).Limit(req.PageSize)
+	}
+
+	if err := query.Order("name ASC").Find(&secrets).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	responses := make([]*model.SecretResponse, len(secrets))
+	for i := range secrets {
+		responses[i] = secrets[i].ToResponse()
+	}
+
+	return responses, total, nil
+}
+
+// GetByID 根据 ID 获取密钥变量（不包含密钥值）
+func (s *SecretService) GetByID(id uint) (*model.SecretResponse, error) {
+	var secret model.AnsibleSecret
+	if err := s.db.First(&secret, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("secret not found")
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	return secret.ToResponse(), nil
+}
+
+// Update 更新密钥变量
+func (s *SecretService) Update(id uint, req model.SecretUpdateRequest, userID uint) (*model.SecretResponse, error) {
+	var secret model.AnsibleSecret
+	if err := s.db.First(&secret, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("secret not found")
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if req.Name != "" && req.Name != secret.Name {
+		// 模板按名称引用密钥，被引用时不允许改名
+		templates, err := s.templatesReferencing(secret.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(templates) > 0 {
+			return nil, fmt.Errorf("secret is referenced by templates %v, cannot rename", templates)
+		}
+
+		var count int64
+		if err := s.db.Model(&model.AnsibleSecret{}).Where("name = ? AND id != ?", req.Name, id).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check secret name: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("secret with name %s already exists", req.Name)
+		}
+		secret.Name = req.Name
+	}
+	if req.Description != "" {
+		secret.Description = req.Description
+	}
+	if req.Value != "" {
+		encrypted, err := s.encryptor.Encrypt(req.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt secret value: %w", err)
+		}
+		secret.Value = encrypted
+	}
+
+	if err := s.db.Save(&secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	s.logger.Infof("Updated secret: %s (ID: %d) by user %d", secret.Name, secret.ID, userID)
+
+	return secret.ToResponse(), nil
+}
+
+// Delete 删除密钥变量
+func (s *SecretService) Delete(id uint, userID uint) error {
+	var secret model.AnsibleSecret
+	if err := s.db.First(&secret, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("secret not found")
+		}
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	templates, err := s.templatesReferencing(secret.Name)
+	if err != nil {
+		return err
+	}
+	if len(templates) > 0 {
+		return fmt.Errorf("secret is referenced by templates %v, cannot delete", templates)
+	}
+
+	if err := s.db.Delete(&secret).Error; err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+
+	s.logger.Infof("Deleted secret: %s (ID: %d) by user %d", secret.Name, secret.ID, userID)
+
+	return nil
+}
+
+// ValidateRefs 校验密钥变量引用：变量名合法且引用的密钥存在
+func (s *SecretService) ValidateRefs(refs map[string]string) error {
+	return validateSecretRefs(s.db, refs)
+}
+
+// Resolve 解析密钥变量引用，返回变量名到明文值的映射（仅供执行器内部使用）
+func (s *SecretService) Resolve(refs map[string]string) (map[string]string, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, secretName := range refs {
+		names = append(names, secretName)
+	}
+
+	var secrets []model.AnsibleSecret
+	if err := s.db.Where("name IN ?", names).Find(&secrets).Error; err != nil {
+		return nil, fmt.Errorf("failed to load secrets: %w", err)
+	}
+
+	byName := make(map[string]model.AnsibleSecret, len(secrets))
+	for _, secret := range secrets {
+		byName[secret.Name] = secret
+	}
+
+	values := make(map[string]string, len(refs))
+	for varName, secretName := range refs {
+		secret, ok := byName[secretName]
+		if !ok {
+			return nil, fmt.Errorf("secret %s referenced by variable %s not found", secretName, varName)
+		}
+		value, err := s.encryptor.Decrypt(secret.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s: %w", secretName, err)
+		}
+		values[varName] = value
+	}
+
+	return values, nil
+}
+
+// templatesReferencing 查找引用指定密钥的模板名称
+func (s *SecretService) templatesReferencing(secretName string) ([]string, error) {
+	var templates []model.AnsibleTemplate
+	if err := s.db.Select("id", "name", "secret_vars").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to check template usage: %w", err)
+	}
+
+	var names []string
+	for _, template := range templates {
+		for _, ref := range template.SecretVars {
+			if ref == secretName {
+				names = append(names, template.Name)
+				break
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// validateSecretRefs 校验密钥变量引用（模板服务和任务创建共用）
+func validateSecretRefs(db *gorm.DB, refs map[string]string) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(refs))
+	for varName, secretName := range refs {
+		if !secretVarNamePattern.MatchString(varName) {
+			return fmt.Errorf("invalid secret variable name: %s", varName)
+		}
+		if secretName == "" {
+			return fmt.Errorf("secret variable %s must reference a secret", varName)
+		}
+		names = append(names, secretName)
+	}
+
+	var existing []string
+	if err := db.Model(&model.AnsibleSecret{}).Where("name IN ?", names).Pluck("name", &existing).Error; err != nil {
+		return fmt.Errorf("failed to check secrets: %w", err)
+	}
+
+	found := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		found[name] = true
+	}
+
+	var missing []string
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("referenced secrets not found: %v", missing)
+	}
+
+	return nil
+}
@@ -1,12 +1,15 @@
 package ansible
 
 import (
+	"context"
 	"fmt"
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/k8s"
 	ansibleUtil "kube-node-manager/pkg/ansible"
 	"kube-node-manager/pkg/crypto"
 	"kube-node-manager/pkg/logger"
+	"os"
+	"path/filepath"
 	"time"
 
 	"gorm.io/gorm"
@@ -19,6 +22,8 @@ type Service struct {
 	templateSvc      *TemplateService
 	inventorySvc     *InventoryService
 	sshKeySvc        *SSHKeyService
+	secretSvc        *SecretService
+	projectSvc       *ProjectService
 	scheduleSvc      *ScheduleService
 	favoriteSvc      *FavoriteService
 	preflightSvc     *PreflightService
@@ -29,6 +34,7 @@ type Service struct {
 	workflowSvc      *WorkflowService
 	workflowExecutor *WorkflowExecutor
 	executor         *TaskExecutor
+	adhocPolicy      AdhocPolicy
 }
 
 // NewService 创建 Ansible 服务实例
@@ -42,6 +48,8 @@ func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, wsHub i
 
 	encryptor := crypto.NewEncryptor(encryptionKey)
 	sshKeySvc := NewSSHKeyService(db, logger, encryptor)
+	secretSvc := NewSecretService(db, logger, encryptor)
+	projectSvc := NewProjectService(db, logger, sshKeySvc, filepath.Join(os.TempDir(), "kube-node-manager-ansible", "projects"))
 	inventorySvc := NewInventoryService(db, logger, k8sSvc)
 	templateSvc := NewTemplateService(db, logger)
 	favoriteSvc := NewFavoriteService(db, logger)
@@ -50,7 +58,7 @@ func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, wsHub i
 	queueSvc := NewQueueService(db, logger)
 	tagSvc := NewTagService(db, logger)
 	visualizationSvc := NewVisualizationService(db, logger)
-	executor := NewTaskExecutor(db, logger, inventorySvc, sshKeySvc, wsHub)
+	executor := NewTaskExecutor(db, logger, inventorySvc, sshKeySvc, secretSvc, projectSvc, wsHub)
 	workflowSvc := NewWorkflowService(db, logger)
 	workflowExecutor := NewWorkflowExecutor(db, logger, executor)
 
@@ -60,6 +68,8 @@ func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, wsHub i
 		templateSvc:      templateSvc,
 		inventorySvc:     inventorySvc,
 		sshKeySvc:        sshKeySvc,
+		secretSvc:        secretSvc,
+		projectSvc:       projectSvc,
 		favoriteSvc:      favoriteSvc,
 		preflightSvc:     preflightSvc,
 		estimationSvc:    estimationSvc,
@@ -69,6 +79,7 @@ func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, wsHub i
 		workflowSvc:      workflowSvc,
 		workflowExecutor: workflowExecutor,
 		executor:         executor,
+		adhocPolicy:      DefaultAdhocPolicy(),
 	}
 
 	// 创建定时任务调度服务（需要依赖 service）
@@ -93,6 +104,16 @@ func (s *Service) GetSSHKeyService() *SSHKeyService {
 	return s.sshKeySvc
 }
 
+// GetSecretService 获取密钥变量服务
+func (s *Service) GetSecretService() *SecretService {
+	return s.secretSvc
+}
+
+// GetProjectService 获取 Git 项目服务
+func (s *Service) GetProjectService() *ProjectService {
+	return s.projectSvc
+}
+
 // GetExecutor 获取执行器
 func (s *Service) GetExecutor() *TaskExecutor {
 	return s.executor
@@ -153,27 +174,77 @@ func (s *Service) CreateTask(req model.TaskCreateRequest, userID uint) (*model.A
 	// 获取 playbook 内容
 	playbookContent := req.PlaybookContent
 
+	// 密钥变量引用：模板中的引用为默认值，请求中的同名引用覆盖模板
+	secretVars := make(model.SecretVarRefs)
+
 	// 如果指定了模板，使用模板内容
 	var template *model.AnsibleTemplate
+	var commitSHA string
+	var templateRevision int
 	if req.TemplateID != nil {
-		t, err := s.templateSvc.GetTemplate(*req.TemplateID)
+		t, err := s.resolveTemplateRevision(*req.TemplateID, req.TemplateRevision)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get template: %w", err)
+			return nil, err
 		}
 		template = t
+		templateRevision = t.CurrentRevision
 
 		playbookContent = template.PlaybookContent
 
+		// Git 项目模板：固定执行提交，并读取该提交的入口 playbook
+		if template.IsFromProject() {
+			sha, content, err := s.resolveProjectPlaybook(template, req.CommitSHA)
+			if err != nil {
+				return nil, err
+			}
+			commitSHA = sha
+			playbookContent = content
+		}
+
+		for varName, secretName := range template.SecretVars {
+			secretVars[varName] = secretName
+		}
+	}
+	for varName, secretName := range req.SecretVars {
+		secretVars[varName] = secretName
+	}
+
+	if req.TemplateRevision != nil && *req.TemplateRevision > 0 && template == nil {
+		return nil, fmt.Errorf("template_revision requires template_id")
+	}
+	if req.CommitSHA != "" && commitSHA == "" {
+		return nil, fmt.Errorf("commit_sha is only supported for project templates")
+	}
+
+	for varName := range secretVars {
+		if _, exists := req.ExtraVars[varName]; exists {
+			return nil, fmt.Errorf("variable '%s' is defined both as extra var and secret var", varName)
+		}
+	}
+	if err := s.secretSvc.ValidateRefs(secretVars); err != nil {
+		return nil, fmt.Errorf("invalid secret variables: %w", err)
+	}
+
+	if template != nil {
+		// 密钥变量视为已提供（值在执行时注入）
+		providedVars := make(model.ExtraVars, len(req.ExtraVars)+len(secretVars))
+		for varName, value := range req.ExtraVars {
+			providedVars[varName] = value
+		}
+		for varName := range secretVars {
+			providedVars[varName] = ""
+		}
+
 		// 如果模板定义了变量，验证提供的变量
-		if len(template.Variables) > 0 && len(req.ExtraVars) > 0 {
-			if err := s.templateSvc.ValidateTemplateVariables(*req.TemplateID, req.ExtraVars); err != nil {
+		if len(template.Variables) > 0 && len(providedVars) > 0 {
+			if err := s.templateSvc.ValidateVariables(template.Variables, providedVars); err != nil {
 				return nil, fmt.Errorf("template variable validation failed: %w", err)
 			}
 		}
 		
 		// 验证必需变量是否都已提供
 		if len(template.RequiredVars) > 0 {
-			missingVars := s.validateRequiredVariables([]string(template.RequiredVars), req.ExtraVars)
+			missingVars := s.validateRequiredVariables([]string(template.RequiredVars), providedVars)
 			if len(missingVars) > 0 {
 				s.logger.Warningf("Task creation: missing required variables: %v", missingVars)
 				return nil, fmt.Errorf("missing required variables: %v", missingVars)
@@ -195,6 +266,13 @@ func (s *Service) CreateTask(req model.TaskCreateRequest, userID uint) (*model.A
 		return nil, fmt.Errorf("invalid playbook: %w", err)
 	}
 
+	// 健康检查命令在目标主机上执行，按临时命令策略校验
+	if req.BatchConfig != nil && req.BatchConfig.HealthCheckCommand != "" {
+		if err := validateAdhocCommand(s.adhocPolicy, model.AdhocModuleCommand, req.BatchConfig.HealthCheckCommand); err != nil {
+			return nil, fmt.Errorf("invalid health check command: %w", err)
+		}
+	}
+
 	// 设置任务优先级（默认为 medium）
 	priority := req.Priority
 	if priority == "" {
@@ -226,12 +304,15 @@ func (s *Service) CreateTask(req model.TaskCreateRequest, userID uint) (*model.A
 	task := &model.AnsibleTask{
 		Name:            req.Name,
 		TemplateID:      req.TemplateID,
+		TemplateRevision: templateRevision,
 		ClusterID:       req.ClusterID,
 		InventoryID:     req.InventoryID,
 		Status:          model.AnsibleTaskStatusPending,
 		UserID:          userID,
 		PlaybookContent: playbookContent,
 		ExtraVars:       req.ExtraVars,
+		SecretVars:      secretVars,
+		CommitSHA:       commitSHA,
 		DryRun:          req.DryRun,
 		BatchConfig:     req.BatchConfig,
 		TimeoutSeconds:  req.TimeoutSeconds,
@@ -240,6 +321,11 @@ func (s *Service) CreateTask(req model.TaskCreateRequest, userID uint) (*model.A
 		HostsTotal:      hostsTotal, // 设置主机总数
 	}
 	
+	if template != nil && template.IsFromProject() {
+		task.ProjectID = template.ProjectID
+		task.PlaybookPath = template.PlaybookPath
+	}
+
 	// 如果启用了分批执行，初始化批次状态并计算总批次数
 	if task.IsBatchEnabled() {
 		task.BatchStatus = "pending"
@@ -424,6 +510,36 @@ func (s *Service) CancelTask(taskID uint, userID uint) error {
 	return nil
 }
 
+// resolveTemplateRevision 获取任务使用的模板内容：指定版本时以该版本快照覆盖模板字段，否则使用最新版本
+func (s *Service) resolveTemplateRevision(templateID uint, revision *int) (*model.AnsibleTemplate, error) {
+	template, err := s.templateSvc.GetTemplate(templateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	if revision == nil || *revision == 0 {
+		if template.CurrentRevision == 0 {
+			template.CurrentRevision = 1
+		}
+		return template, nil
+	}
+
+	snapshot, err := s.templateSvc.GetRevision(templateID, *revision)
+	if err != nil {
+		return nil, err
+	}
+
+	template.PlaybookContent = snapshot.PlaybookContent
+	template.ProjectID = snapshot.ProjectID
+	template.PlaybookPath = snapshot.PlaybookPath
+	template.Variables = snapshot.Variables
+	template.RequiredVars = snapshot.RequiredVars
+	template.SecretVars = snapshot.SecretVars
+	template.RiskLevel = snapshot.RiskLevel
+	template.CurrentRevision = snapshot.Revision
+	return template, nil
+}
+
 // RetryTask 重试失败的任务
 func (s *Service) RetryTask(taskID uint, userID uint) (*model.AnsibleTask, error) {
 	// 获取原任务
@@ -441,12 +557,21 @@ func (s *Service) RetryTask(taskID uint, userID uint) (*model.AnsibleTask, error
 	newTask := &model.AnsibleTask{
 		Name:            originalTask.Name + " (Retry)",
 		TemplateID:      originalTask.TemplateID,
+		TemplateRevision: originalTask.TemplateRevision,
 		ClusterID:       originalTask.ClusterID,
 		InventoryID:     originalTask.InventoryID,
 		Status:          model.AnsibleTaskStatusPending,
 		UserID:          userID,
 		PlaybookContent: originalTask.PlaybookContent,
 		ExtraVars:       originalTask.ExtraVars,
+		SecretVars:      originalTask.SecretVars,
+		ProjectID:       originalTask.ProjectID,
+		PlaybookPath:    originalTask.PlaybookPath,
+		CommitSHA:       originalTask.CommitSHA,
+		Adhoc:           originalTask.Adhoc,
+	}
+	if newTask.IsAdhoc() {
+		newTask.HostsTotal = len(newTask.Adhoc.Hosts)
 	}
 
 	if err := s.db.Create(newTask).Error; err != nil {
@@ -496,13 +621,13 @@ func (s *Service) PauseBatchExecution(taskID uint) error {
 		return fmt.Errorf("batch execution is already paused")
 	}
 
-	// 更新批次状态为暂停
-	task.BatchStatus = "paused"
-	if err := s.db.Save(task).Error; err != nil {
+	// 请求执行器在当前批次结束后暂停（批次状态由执行器在真正暂停时更新）
+	if err := s.executor.PauseBatchExecution(taskID); err != nil {
 		return fmt.Errorf("failed to pause batch execution: %w", err)
 	}
 
-	s.logger.Infof("Batch execution paused for task %d", taskID)
+	s.logger.Infof("Batch execution pause requested for task %d at batch %d/%d", 
+		taskID, task.CurrentBatch, task.TotalBatches)
 	return nil
 }
 
@@ -529,23 +654,13 @@ func (s *Service) ContinueBatchExecution(taskID uint) error {
 		return fmt.Errorf("batch execution is not paused")
 	}
 
-	// 更新批次状态为运行中
-	task.BatchStatus = "running"
-	task.CurrentBatch++ // 移动到下一批次
-	if err := s.db.Save(task).Error; err != nil {
+	// 唤醒执行器执行下一批次（批次号和状态由执行器维护）
+	if err := s.executor.ContinueBatchExecution(taskID); err != nil {
 		return fmt.Errorf("failed to continue batch execution: %w", err)
 	}
 
-	s.logger.Infof("Batch execution continued for task %d, moving to batch %d/%d", 
+	s.logger.Infof("Batch execution continued for task %d after batch %d/%d", 
 		taskID, task.CurrentBatch, task.TotalBatches)
-	
-	// 触发执行器继续执行
-	go func() {
-		if err := s.executor.ContinueBatchExecution(taskID); err != nil {
-			s.logger.Errorf("Failed to continue batch execution for task %d: %v", taskID, err)
-		}
-	}()
-	
 	return nil
 }
 
@@ -704,6 +819,35 @@ func (s *Service) validateTaskCreateRequest(req model.TaskCreateRequest) error {
 	return nil
 }
 
+// resolveProjectPlaybook 为项目模板确定执行提交并读取入口 playbook 内容
+// 未指定提交时使用项目最近一次同步的提交，返回完整提交 SHA 和 playbook 内容
+func (s *Service) resolveProjectPlaybook(template *model.AnsibleTemplate, commitRef string) (string, string, error) {
+	ctx := context.Background()
+
+	project, err := s.projectSvc.GetProject(*template.ProjectID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get template project: %w", err)
+	}
+
+	sha := project.LastCommitSHA
+	if commitRef != "" {
+		sha, err = s.projectSvc.ResolveCommit(ctx, project.ID, commitRef)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid commit_sha: %w", err)
+		}
+	}
+	if sha == "" {
+		return "", "", fmt.Errorf("project %s has not been synced yet", project.Name)
+	}
+
+	content, err := s.projectSvc.ReadPlaybook(ctx, project.ID, sha, template.PlaybookPath)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read project playbook: %w", err)
+	}
+
+	return sha, content, nil
+}
+
 // validateRequiredVariables 验证必需变量是否都已提供
 func (s *Service) validateRequiredVariables(requiredVars []string, providedVars model.ExtraVars) []string {
 	return ansibleUtil.ValidateVariables(requiredVars, providedVars)
@@ -0,0 +1,102 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/sshca"
+)
+
+// SetSSHCA 设置 SSH 证书颁发服务，启用后每个任务使用为执行用户签发的短期证书连接节点
+func (s *Service) SetSSHCA(sshCA *sshca.Service) {
+	s.executor.sshCA = sshCA
+}
+
+// createSSHCertFiles 启用 SSH CA 时为任务签发短期证书
+// 证书写入 <私钥文件>-cert.pub，ssh 会自动加载；按配置保留清单原有的私钥作为后备身份
+func (e *TaskExecutor) createSSHCertFiles(task *model.AnsibleTask, files *taskFiles) error {
+	if !e.sshCA.Enabled() {
+		return nil
+	}
+
+	keyFile, err := e.issueSSHCertFile(task)
+	if err != nil {
+		if e.sshCA.KeyFallback() {
+			e.logger.Warningf("Task %d: Failed to issue SSH certificate, using inventory SSH key: %v", task.ID, err)
+			return nil
+		}
+		return err
+	}
+
+	if e.sshCA.KeyFallback() {
+		files.FallbackSSHKey = files.SSHKey
+	} else if files.SSHKey != "" {
+		os.Remove(files.SSHKey)
+	}
+	files.SSHKey = keyFile
+	files.SSHCert = keyFile + "-cert.pub"
+	return nil
+}
+
+// renewSSHCertFiles 分批执行时在每个批次开始前重新签发证书，避免批次间暂停超过证书有效期
+// 签发失败时允许后备密钥则沿用原证书，否则返回错误
+func (e *TaskExecutor) renewSSHCertFiles(task *model.AnsibleTask, files *taskFiles) error {
+	if files.SSHCert == "" {
+		return nil
+	}
+
+	keyFile, err := e.issueSSHCertFile(task)
+	if err != nil {
+		if e.sshCA.KeyFallback() {
+			e.logger.Warningf("Task %d: Failed to renew SSH certificate, keeping previous certificate and inventory SSH key: %v", task.ID, err)
+			return nil
+		}
+		return fmt.Errorf("failed to renew ssh certificate: %w", err)
+	}
+
+	os.Remove(files.SSHKey)
+	os.Remove(files.SSHCert)
+	files.SSHKey = keyFile
+	files.SSHCert = keyFile + "-cert.pub"
+	return nil
+}
+
+// issueSSHCertFile 为任务签发证书并写入临时私钥和证书文件，返回私钥文件路径
+// 证书有效期不超过 SSH CA 配置的 Ansible 证书有效期
+func (e *TaskExecutor) issueSSHCertFile(task *model.AnsibleTask) (string, error) {
+	cert, err := e.sshCA.Issue(sshca.IssueRequest{
+		UserID:   task.UserID,
+		Purpose:  model.SSHCertPurposeAnsible,
+		Target:   fmt.Sprintf("task-%d", task.ID),
+		Validity: time.Duration(task.TimeoutSeconds) * time.Second,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	keyFile := filepath.Join(e.workDir, fmt.Sprintf("ssh-cert-%d-%d", task.ID, time.Now().UnixNano()))
+	if err := os.WriteFile(keyFile, cert.PrivateKeyPEM, 0600); err != nil {
+		return "", fmt.Errorf("failed to write certificate key file: %w", err)
+	}
+	if err := os.WriteFile(keyFile+"-cert.pub", cert.CertLine, 0600); err != nil {
+		os.Remove(keyFile)
+		return "", fmt.Errorf("failed to write certificate file: %w", err)
+	}
+
+	e.logger.Infof("Task %d: Using SSH certificate serial %d (principals: %v, valid until %s, fallback key: %t)",
+		task.ID, cert.Record.Serial, cert.Record.Principals, cert.Record.ValidBefore.Format(time.RFC3339), e.sshCA.KeyFallback())
+	return keyFile, nil
+}
+
+// sshFallbackArgs 启用证书时追加清单原有的私钥作为后备身份，证书认证失败时 ssh 会继续尝试
+// 生成的清单设置了 ansible_ssh_common_args，这里使用不会被清单覆盖的 extra args
+func sshFallbackArgs(files *taskFiles) []string {
+	if files.FallbackSSHKey == "" {
+		return nil
+	}
+	identity := "-o IdentityFile=" + files.FallbackSSHKey
+	return []string{"--ssh-extra-args", identity, "--sftp-extra-args", identity, "--scp-extra-args", identity}
+}
@@ -276,6 +276,15 @@ func (s *SSHKeyService) Delete(id uint, userID uint) error {
 		return fmt.Errorf("ssh key is in use by %d inventories, cannot delete", count)
 	}
 
+	// 检查是否有 Git 项目正在使用此密钥
+	if err := s.db.Model(&model.AnsibleProject{}).Where("ssh_key_id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check project usage: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("ssh key is in use by %d projects, cannot delete", count)
+	}
+
 	// 删除
 	if err := s.db.Delete(&key).Error; err != nil {
 		return fmt.Errorf("failed to delete ssh key: %w", err)
@@ -96,8 +96,12 @@ func (s *TemplateService) CreateTemplate(req model.TemplateCreateRequest, userID
 		return nil, fmt.Errorf("template name already exists")
 	}
 
-	// 验证 playbook 内容
-	if err := s.ValidatePlaybook(req.PlaybookContent); err != nil {
+	// 验证 playbook 来源：Git 项目模板校验项目和路径，否则校验 playbook 内容
+	if req.ProjectID != nil {
+		if err := s.validateProjectSource(*req.ProjectID, req.PlaybookPath); err != nil {
+			return nil, err
+		}
+	} else if err := s.ValidatePlaybook(req.PlaybookContent); err != nil {
 		return nil, fmt.Errorf("invalid playbook: %w", err)
 	}
 
@@ -106,11 +110,16 @@ func (s *TemplateService) CreateTemplate(req model.TemplateCreateRequest, userID
 	if len(req.RequiredVars) > 0 {
 		requiredVars = req.RequiredVars
 		s.logger.Infof("Using user-provided required vars: %v", requiredVars)
-	} else {
+	} else if req.ProjectID == nil {
 		requiredVars = ansibleUtil.ExtractVariables(req.PlaybookContent)
 		s.logger.Infof("Auto-extracted %d variables from playbook: %v", len(requiredVars), requiredVars)
 	}
 
+	// 校验密钥变量引用
+	if err := validateSecretRefs(s.db, req.SecretVars); err != nil {
+		return nil, err
+	}
+
 	// 处理风险等级：如果未提供则默认为 low
 	riskLevel := req.RiskLevel
 	if riskLevel == "" {
@@ -122,15 +131,34 @@ func (s *TemplateService) CreateTemplate(req model.TemplateCreateRequest, userID
 		Description:     req.Description,
 		PlaybookContent: req.PlaybookContent,
 		Variables:       req.Variables,
+		ProjectID:       req.ProjectID,
+		PlaybookPath:    req.PlaybookPath,
 		RequiredVars:    model.StringArray(requiredVars),
+		SecretVars:      model.SecretVarRefs(req.SecretVars),
 		Tags:            req.Tags,
 		RiskLevel:       riskLevel,
+		CurrentRevision: 1,
 		UserID:          userID,
 	}
 
-	if err := s.db.Create(template).Error; err != nil {
+	note := req.ChangeNote
+	if note == "" {
+		note = "Initial revision"
+	}
+
+	// 模板与初始版本在同一事务中创建
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(template).Error; err != nil {
+			return fmt.Errorf("failed to create template: %w", err)
+		}
+		if err := tx.Create(newRevisionSnapshot(template, 1, note, userID)).Error; err != nil {
+			return fmt.Errorf("failed to create template revision: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		s.logger.Errorf("Failed to create template: %v", err)
-		return nil, fmt.Errorf("failed to create template: %w", err)
+		return nil, err
 	}
 
 	s.logger.Infof("Successfully created template: %s (ID: %d) by user %d", template.Name, template.ID, userID)
@@ -148,6 +176,9 @@ func (s *TemplateService) UpdateTemplate(id uint, req model.TemplateUpdateReques
 		return nil, fmt.Errorf("failed to get template: %w", err)
 	}
 
+	// 旧模板在修改前先补建初始版本，保证历史内容不丢失
+	original := template
+
 	// 检查名称是否与其他记录重复
 	if req.Name != "" && req.Name != template.Name {
 		var count int64
@@ -195,6 +226,34 @@ func (s *TemplateService) UpdateTemplate(id uint, req model.TemplateUpdateReques
 		template.Variables = req.Variables
 	}
 
+	// 更新 Git 项目来源
+	if req.ProjectID != nil || req.PlaybookPath != "" {
+		projectID := template.ProjectID
+		if req.ProjectID != nil {
+			projectID = req.ProjectID
+		}
+		playbookPath := template.PlaybookPath
+		if req.PlaybookPath != "" {
+			playbookPath = req.PlaybookPath
+		}
+		if projectID == nil {
+			return nil, fmt.Errorf("playbook_path requires a project")
+		}
+		if err := s.validateProjectSource(*projectID, playbookPath); err != nil {
+			return nil, err
+		}
+		template.ProjectID = projectID
+		template.PlaybookPath = playbookPath
+	}
+
+	// 密钥变量引用整体替换（传空对象表示清除）
+	if req.SecretVars != nil {
+		if err := validateSecretRefs(s.db, req.SecretVars); err != nil {
+			return nil, err
+		}
+		template.SecretVars = model.SecretVarRefs(req.SecretVars)
+	}
+
 	if req.Tags != "" {
 		template.Tags = req.Tags
 	}
@@ -205,12 +264,20 @@ func (s *TemplateService) UpdateTemplate(id uint, req model.TemplateUpdateReques
 		s.logger.Infof("Updated risk level to: %s", req.RiskLevel)
 	}
 
-	if err := s.db.Save(&template).Error; err != nil {
+	// 每次更新追加一个不可变版本
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureInitialRevision(tx, &original); err != nil {
+			return err
+		}
+		template.CurrentRevision = original.CurrentRevision
+		return s.saveWithRevision(tx, &template, req.ChangeNote, userID)
+	})
+	if err != nil {
 		s.logger.Errorf("Failed to update template %d: %v", id, err)
-		return nil, fmt.Errorf("failed to update template: %w", err)
+		return nil, err
 	}
 
-	s.logger.Infof("Successfully updated template: %s (ID: %d) by user %d", template.Name, template.ID, userID)
+	s.logger.Infof("Successfully updated template: %s (ID: %d, revision %d) by user %d", template.Name, template.ID, template.CurrentRevision, userID)
 	return &template, nil
 }
 
@@ -247,6 +314,18 @@ func (s *TemplateService) DeleteTemplate(id uint, userID uint) error {
 	})
 }
 
+// validateProjectSource 校验模板关联的 Git 项目和 playbook 路径
+func (s *TemplateService) validateProjectSource(projectID uint, playbookPath string) error {
+	var count int64
+	if err := s.db.Model(&model.AnsibleProject{}).Where("id = ?", projectID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check project: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("project not found")
+	}
+	return validatePlaybookPath(playbookPath)
+}
+
 // ValidatePlaybook 验证 playbook 语法
 func (s *TemplateService) ValidatePlaybook(content string) error {
 	if content == "" {
@@ -319,27 +398,33 @@ func (s *TemplateService) ValidatePlaybook(content string) error {
 	return nil
 }
 
-// checkDangerousCommands 检查危险命令
-func (s *TemplateService) checkDangerousCommands(content string) error {
-	// 定义危险命令列表
-	dangerousPatterns := []string{
-		"rm -rf /",
-		"rm -rf /*",
-		"mkfs",
-		"dd if=/dev/zero",
-		":(){ :|:& };:",  // fork bomb
-		"> /dev/sda",
-		"format c:",
-	}
+// dangerousCommandPatterns 危险命令列表（Playbook 与临时命令共用）
+var dangerousCommandPatterns = []string{
+	"rm -rf /",
+	"rm -rf /*",
+	"mkfs",
+	"dd if=/dev/zero",
+	":(){ :|:& };:", // fork bomb
+	"> /dev/sda",
+	"format c:",
+}
 
+// findDangerousCommand 返回内容中包含的第一个危险命令，不包含时返回空字符串
+func findDangerousCommand(content string, patterns []string) string {
 	contentLower := strings.ToLower(content)
-
-	for _, pattern := range dangerousPatterns {
+	for _, pattern := range patterns {
 		if strings.Contains(contentLower, strings.ToLower(pattern)) {
-			return fmt.Errorf("playbook contains potentially dangerous command: %s", pattern)
+			return pattern
 		}
 	}
+	return ""
+}
 
+// checkDangerousCommands 检查危险命令
+func (s *TemplateService) checkDangerousCommands(content string) error {
+	if pattern := findDangerousCommand(content, dangerousCommandPatterns); pattern != "" {
+		return fmt.Errorf("playbook contains potentially dangerous command: %s", pattern)
+	}
 	return nil
 }
 
@@ -364,13 +449,18 @@ func (s *TemplateService) ValidateTemplateVariables(templateID uint, providedVar
 		return err
 	}
 
+	return s.ValidateVariables(template.Variables, providedVars)
+}
+
+// ValidateVariables 按变量定义验证提供的变量（用于固定版本的模板）
+func (s *TemplateService) ValidateVariables(variables model.ExtraVars, providedVars map[string]interface{}) error {
 	// 如果模板没有定义变量，则不需要验证
-	if len(template.Variables) == 0 {
+	if len(variables) == 0 {
 		return nil
 	}
 
 	// 检查必需的变量是否都提供了
-	for varName, varDef := range template.Variables {
+	for varName, varDef := range variables {
 		varDefMap, ok := varDef.(map[string]interface{})
 		if !ok {
 			continue
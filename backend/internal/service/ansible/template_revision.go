@@ -0,0 +1,275 @@
+package ansible
+
+import (
+	"fmt"
+	"kube-node-manager/internal/model"
+	"reflect"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/gorm"
+)
+
+// newRevisionSnapshot 根据模板当前状态生成版本快照
+func newRevisionSnapshot(template *model.AnsibleTemplate, revision int, note string, userID uint) *model.AnsibleTemplateRevision {
+	return &model.AnsibleTemplateRevision{
+		TemplateID:      template.ID,
+		Revision:        revision,
+		Name:            template.Name,
+		Description:     template.Description,
+		PlaybookContent: template.PlaybookContent,
+		ProjectID:       template.ProjectID,
+		PlaybookPath:    template.PlaybookPath,
+		Variables:       template.Variables,
+		RequiredVars:    template.RequiredVars,
+		SecretVars:      template.SecretVars,
+		Tags:            template.Tags,
+		RiskLevel:       template.RiskLevel,
+		ChangeNote:      note,
+		UserID:          userID,
+	}
+}
+
+// ensureInitialRevision 为版本功能上线前创建的模板补建初始版本
+// 必须在修改模板字段之前调用，以便快照记录修改前的内容
+func (s *TemplateService) ensureInitialRevision(tx *gorm.DB, template *model.AnsibleTemplate) error {
+	if template.CurrentRevision > 0 {
+		return nil
+	}
+
+	snapshot := newRevisionSnapshot(template, 1, "Initial revision", template.UserID)
+	if err := tx.Create(snapshot).Error; err != nil {
+		return fmt.Errorf("failed to create initial revision: %w", err)
+	}
+	template.CurrentRevision = 1
+	return nil
+}
+
+// saveWithRevision 保存模板并追加一个新版本
+func (s *TemplateService) saveWithRevision(tx *gorm.DB, template *model.AnsibleTemplate, note string, userID uint) error {
+	template.CurrentRevision++
+	if err := tx.Save(template).Error; err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+
+	snapshot := newRevisionSnapshot(template, template.CurrentRevision, note, userID)
+	if err := tx.Create(snapshot).Error; err != nil {
+		return fmt.Errorf("failed to create template revision: %w", err)
+	}
+	return nil
+}
+
+// ListRevisions 列出模板的全部版本（按版本号倒序）
+func (s *TemplateService) ListRevisions(templateID uint) ([]model.AnsibleTemplateRevision, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []model.AnsibleTemplateRevision
+	if err := s.db.Preload("User").
+		Where("template_id = ?", templateID).
+		Order("revision DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list template revisions: %w", err)
+	}
+
+	// 尚未产生版本记录的旧模板，以当前内容作为初始版本展示
+	if len(revisions) == 0 {
+		revisions = append(revisions, *newRevisionSnapshot(template, 1, "Initial revision", template.UserID))
+	}
+
+	return revisions, nil
+}
+
+// GetRevision 获取模板的指定版本，revision 为 0 时返回最新版本
+func (s *TemplateService) GetRevision(templateID uint, revision int) (*model.AnsibleTemplateRevision, error) {
+	if revision < 0 {
+		return nil, fmt.Errorf("invalid revision: %d", revision)
+	}
+
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if revision == 0 {
+		revision = template.CurrentRevision
+	}
+	if template.CurrentRevision == 0 && revision <= 1 {
+		return newRevisionSnapshot(template, 1, "Initial revision", template.UserID), nil
+	}
+
+	var snapshot model.AnsibleTemplateRevision
+	if err := s.db.Preload("User").
+		Where("template_id = ? AND revision = ?", templateID, revision).
+		First(&snapshot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("template revision %d not found", revision)
+		}
+		return nil, fmt.Errorf("failed to get template revision: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// DiffRevisions 比较模板的两个版本
+func (s *TemplateService) DiffRevisions(templateID uint, fromRevision, toRevision int) (*model.TemplateRevisionDiff, error) {
+	from, err := s.GetRevision(templateID, fromRevision)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetRevision(templateID, toRevision)
+	if err != nil {
+		return nil, err
+	}
+
+	playbookDiff, err := diffPlaybook(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TemplateRevisionDiff{
+		TemplateID:   templateID,
+		FromRevision: from.Revision,
+		ToRevision:   to.Revision,
+		PlaybookDiff: playbookDiff,
+		FieldChanges: diffRevisionFields(from, to),
+	}, nil
+}
+
+// RollbackTemplate 将模板回滚到指定版本，回滚本身会生成一个新版本
+func (s *TemplateService) RollbackTemplate(templateID uint, revision int, note string, userID uint) (*model.AnsibleTemplate, error) {
+	target, err := s.GetRevision(templateID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	var template model.AnsibleTemplate
+	if err := s.db.First(&template, templateID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	if target.Revision == template.CurrentRevision || (template.CurrentRevision == 0 && target.Revision == 1) {
+		return nil, fmt.Errorf("template is already at revision %d", target.Revision)
+	}
+
+	// 目标版本引用的项目和密钥可能已被删除
+	if target.ProjectID != nil {
+		if err := s.validateProjectSource(*target.ProjectID, target.PlaybookPath); err != nil {
+			return nil, fmt.Errorf("cannot rollback to revision %d: %w", target.Revision, err)
+		}
+	}
+	if err := validateSecretRefs(s.db, target.SecretVars); err != nil {
+		return nil, fmt.Errorf("cannot rollback to revision %d: %w", target.Revision, err)
+	}
+
+	// 名称可能已被其他模板占用
+	if target.Name != template.Name {
+		var count int64
+		if err := s.db.Model(&model.AnsibleTemplate{}).
+			Where("name = ? AND id != ?", target.Name, templateID).
+			Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check template name: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("cannot rollback to revision %d: template name %s already exists", target.Revision, target.Name)
+		}
+	}
+
+	if note == "" {
+		note = fmt.Sprintf("Rollback to revision %d", target.Revision)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.ensureInitialRevision(tx, &template); err != nil {
+			return err
+		}
+
+		template.Name = target.Name
+		template.Description = target.Description
+		template.PlaybookContent = target.PlaybookContent
+		template.ProjectID = target.ProjectID
+		template.PlaybookPath = target.PlaybookPath
+		template.Variables = target.Variables
+		template.RequiredVars = target.RequiredVars
+		template.SecretVars = target.SecretVars
+		template.Tags = target.Tags
+		template.RiskLevel = target.RiskLevel
+
+		return s.saveWithRevision(tx, &template, note, userID)
+	})
+	if err != nil {
+		s.logger.Errorf("Failed to rollback template %d to revision %d: %v", templateID, target.Revision, err)
+		return nil, err
+	}
+
+	s.logger.Infof("Rolled back template %s (ID: %d) to revision %d as revision %d by user %d",
+		template.Name, template.ID, target.Revision, template.CurrentRevision, userID)
+	return &template, nil
+}
+
+// diffPlaybook 生成两个版本 Playbook 内容的 unified diff
+func diffPlaybook(from, to *model.AnsibleTemplateRevision) (string, error) {
+	if from.PlaybookContent == to.PlaybookContent {
+		return "", nil
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from.PlaybookContent),
+		B:        difflib.SplitLines(to.PlaybookContent),
+		FromFile: fmt.Sprintf("revision %d", from.Revision),
+		ToFile:   fmt.Sprintf("revision %d", to.Revision),
+		Context:  3,
+	}
+	text, err := difflib.GetUnifiedDiffString(diff)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff playbook: %w", err)
+	}
+	return text, nil
+}
+
+// diffRevisionFields 比较 Playbook 以外的模板字段
+func diffRevisionFields(from, to *model.AnsibleTemplateRevision) []model.TemplateFieldChange {
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"name", from.Name, to.Name},
+		{"description", from.Description, to.Description},
+		{"project_id", from.ProjectID, to.ProjectID},
+		{"playbook_path", from.PlaybookPath, to.PlaybookPath},
+		{"variables", from.Variables, to.Variables},
+		{"required_vars", from.RequiredVars, to.RequiredVars},
+		{"secret_vars", from.SecretVars, to.SecretVars},
+		{"tags", from.Tags, to.Tags},
+		{"risk_level", from.RiskLevel, to.RiskLevel},
+	}
+
+	changes := []model.TemplateFieldChange{}
+	for _, field := range fields {
+		if equalRevisionField(field.from, field.to) {
+			continue
+		}
+		changes = append(changes, model.TemplateFieldChange{
+			Field: field.name,
+			From:  field.from,
+			To:    field.to,
+		})
+	}
+	return changes
+}
+
+// equalRevisionField 比较字段值，nil 与空集合视为相等
+func equalRevisionField(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Ptr && vb.Kind() == reflect.Ptr {
+		if va.IsNil() || vb.IsNil() {
+			return va.IsNil() == vb.IsNil()
+		}
+		return reflect.DeepEqual(va.Elem().Interface(), vb.Elem().Interface())
+	}
+	if (va.Kind() == reflect.Map || va.Kind() == reflect.Slice) && va.Len() == 0 && vb.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,49 @@
+package ansible
+
+import (
+	"kube-node-manager/internal/model"
+	"strings"
+	"testing"
+)
+
+// TestDiffRevisions 测试模板版本的 Playbook 差异和字段差异
+func TestDiffRevisions(t *testing.T) {
+	projectID := uint(1)
+	from := &model.AnsibleTemplateRevision{
+		Revision:        1,
+		Name:            "deploy",
+		PlaybookContent: "- hosts: all\n  tasks:\n    - ping:\n",
+		RequiredVars:    model.StringArray{},
+		RiskLevel:       "low",
+	}
+	to := &model.AnsibleTemplateRevision{
+		Revision:        2,
+		Name:            "deploy",
+		PlaybookContent: "- hosts: web\n  tasks:\n    - ping:\n",
+		ProjectID:       &projectID,
+		RiskLevel:       "high",
+	}
+
+	diff, err := diffPlaybook(from, to)
+	if err != nil {
+		t.Fatalf("diffPlaybook() error = %v", err)
+	}
+	for _, want := range []string{"--- revision 1", "+++ revision 2", "-- hosts: all", "+- hosts: web"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diffPlaybook() missing %q in:\n%s", want, diff)
+		}
+	}
+
+	if same, _ := diffPlaybook(from, from); same != "" {
+		t.Errorf("diffPlaybook() of identical revisions = %q, want empty", same)
+	}
+
+	changes := diffRevisionFields(from, to)
+	fields := make(map[string]bool, len(changes))
+	for _, change := range changes {
+		fields[change.Field] = true
+	}
+	if len(changes) != 2 || !fields["project_id"] || !fields["risk_level"] {
+		t.Errorf("diffRevisionFields() = %+v, want project_id and risk_level", changes)
+	}
+}
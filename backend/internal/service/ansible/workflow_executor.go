@@ -277,6 +277,7 @@ func (e *WorkflowExecutor) executeTaskNode(ctx context.Context, execution *model
 		UserID:              execution.UserID,
 		PlaybookContent:     node.TaskConfig.PlaybookContent,
 		ExtraVars:           node.TaskConfig.ExtraVars,
+		SecretVars:          model.SecretVarRefs(node.TaskConfig.SecretVars),
 		DryRun:              node.TaskConfig.DryRun,
 		TimeoutSeconds:      node.TaskConfig.TimeoutSeconds,
 		Priority:            node.TaskConfig.Priority,
@@ -1,16 +1,32 @@
 package audit
 
 import (
+	"context"
 	"kube-node-manager/internal/model"
 	"kube-node-manager/pkg/logger"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Config 审计日志保留、归档与导出配置
+type Config struct {
+	Retention RetentionConfig
+	Export    ExportConfig
+}
+
 type Service struct {
 	db     *gorm.DB
 	logger *logger.Logger
+
+	config   *Config
+	exporter *Exporter
+	chainMu  sync.Mutex // 本副本内串行写入哈希链，跨副本由数据库行锁保证
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 type LogRequest struct {
@@ -25,6 +41,8 @@ type LogRequest struct {
 	ErrorMsg     string             `json:"error_msg,omitempty"`
 	IPAddress    string             `json:"ip_address,omitempty"`
 	UserAgent    string             `json:"user_agent,omitempty"`
+	APITokenID   *uint              `json:"api_token_id,omitempty"`
+	OperationID  string             `json:"operation_id,omitempty"` // 节点变更操作 ID
 }
 
 type ListRequest struct {
@@ -38,6 +56,13 @@ type ListRequest struct {
 	Status       model.AuditStatus  `json:"status"`
 	StartDate    string             `json:"start_date"`
 	EndDate      string             `json:"end_date"`
+
+	// 多值筛选和检索，与上面的单值条件同时生效
+	UserIDs       []uint               `json:"user_ids,omitempty"`
+	Actions       []model.AuditAction  `json:"actions,omitempty"`
+	ResourceTypes []model.ResourceType `json:"resource_types,omitempty"`
+	NodeName      string               `json:"node_name,omitempty"` // 节点名称，支持 * 和 ? 通配符，多个用逗号分隔
+	Keyword       string               `json:"keyword,omitempty"`   // 在详情、原因和错误信息中检索
 }
 
 type ListResponse struct {
@@ -47,11 +72,64 @@ type ListResponse struct {
 	PageSize int              `json:"page_size"`
 }
 
-func NewService(db *gorm.DB, logger *logger.Logger) *Service {
-	return &Service{
+func NewService(db *gorm.DB, logger *logger.Logger, config *Config) *Service {
+	if config.Retention.RetentionDays <= 0 {
+		config.Retention.RetentionDays = 365
+	}
+	if config.Retention.CleanupTime == "" {
+		config.Retention.CleanupTime = "04:00"
+	}
+	if config.Retention.BatchSize <= 0 {
+		config.Retention.BatchSize = 1000
+	}
+	if config.Retention.ArchiveDir == "" {
+		config.Retention.ArchiveDir = "./data/audit-archive"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Service{
 		db:     db,
 		logger: logger,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	exporter, err := NewExporter(config.Export, logger)
+	if err != nil {
+		logger.Errorf("Failed to create audit log exporter, export disabled: %v", err)
+	} else {
+		s.exporter = exporter
+	}
+	return s
+}
+
+// Start 启动审计日志导出和过期数据归档清理
+func (s *Service) Start() {
+	if s.exporter != nil {
+		s.exporter.Start()
 	}
+
+	if !s.config.Retention.Enabled {
+		s.logger.Info("Audit log retention is disabled")
+		return
+	}
+	s.logger.Infof("Starting audit log retention (retention: %d days, cleanup time: %s, archive: %v)",
+		s.config.Retention.RetentionDays, s.config.Retention.CleanupTime, s.config.Retention.Archive)
+
+	s.wg.Add(1)
+	go s.retentionLoop()
+}
+
+// Stop 停止归档清理，并导出队列中剩余的审计日志
+func (s *Service) Stop() {
+	s.cancel()
+	s.wg.Wait()
+
+	if s.exporter != nil {
+		s.exporter.Stop()
+	}
+	s.logger.Info("Audit log service stopped")
 }
 
 func (s *Service) Log(req LogRequest) {
@@ -67,9 +145,11 @@ func (s *Service) Log(req LogRequest) {
 		ErrorMsg:     req.ErrorMsg,
 		IPAddress:    req.IPAddress,
 		UserAgent:    req.UserAgent,
+		APITokenID:   req.APITokenID,
+		OperationID:  req.OperationID,
 	}
 
-	if err := s.db.Create(&auditLog).Error; err != nil {
+	if err := s.create(&auditLog); err != nil {
 		s.logger.Errorf("Failed to create audit log: %v", err)
 	}
 }
@@ -88,9 +168,11 @@ func (s *Service) LogWithError(req LogRequest) error {
 		ErrorMsg:     req.ErrorMsg,
 		IPAddress:    req.IPAddress,
 		UserAgent:    req.UserAgent,
+		APITokenID:   req.APITokenID,
+		OperationID:  req.OperationID,
 	}
 
-	if err := s.db.Create(&auditLog).Error; err != nil {
+	if err := s.create(&auditLog); err != nil {
 		s.logger.Errorf("Failed to create audit log: %v", err)
 		return err
 	}
@@ -111,11 +193,13 @@ func (s *Service) LogWithCustomTime(req LogRequest, customTime time.Time) error
 		ErrorMsg:     req.ErrorMsg,
 		IPAddress:    req.IPAddress,
 		UserAgent:    req.UserAgent,
+		APITokenID:   req.APITokenID,
+		OperationID:  req.OperationID,
 		CreatedAt:    customTime,
 	}
 
-	// 手动设置时间戳，禁用自动时间戳
-	if err := s.db.Set("gorm:update_time_stamp", false).Create(&auditLog).Error; err != nil {
+	// 手动设置时间戳，create 中禁用自动时间戳
+	if err := s.create(&auditLog); err != nil {
 		s.logger.Errorf("Failed to create audit log with custom time: %v", err)
 		return err
 	}
@@ -123,35 +207,7 @@ func (s *Service) LogWithCustomTime(req LogRequest, customTime time.Time) error
 }
 
 func (s *Service) List(req ListRequest) (*ListResponse, error) {
-	query := s.db.Model(&model.AuditLog{}).Preload("User").Preload("Cluster")
-
-	if req.UserID > 0 {
-		query = query.Where("user_id = ?", req.UserID)
-	}
-	if req.Username != "" {
-		query = query.Joins("LEFT JOIN users ON audit_logs.user_id = users.id").Where("users.username LIKE ?", "%"+req.Username+"%")
-	}
-	if req.ClusterID > 0 {
-		query = query.Where("cluster_id = ?", req.ClusterID)
-	}
-	if req.Action != "" {
-		s.logger.Infof("添加action过滤条件: %s", req.Action)
-		query = query.Where("action = ?", req.Action)
-	}
-	if req.ResourceType != "" {
-		s.logger.Infof("添加resource_type过滤条件: %s", req.ResourceType)
-		query = query.Where("resource_type = ?", req.ResourceType)
-	}
-	if req.Status != "" {
-		s.logger.Infof("添加status过滤条件: %s", req.Status)
-		query = query.Where("status = ?", req.Status)
-	}
-	if req.StartDate != "" {
-		query = query.Where("created_at >= ?", req.StartDate)
-	}
-	if req.EndDate != "" {
-		query = query.Where("created_at <= ?", req.EndDate)
-	}
+	query := s.filteredQuery(req).Preload("User").Preload("Cluster")
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -0,0 +1,198 @@
+package audit
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.User{}, &model.Cluster{}, &model.AuditLog{}, &model.AuditChainState{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Create(&model.User{Username: "admin", Email: "admin@example.com", Password: "x"}).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	config := &Config{Retention: RetentionConfig{Enabled: true, RetentionDays: 30, Archive: true, ArchiveDir: t.TempDir()}}
+	return NewService(db, logger.NewLogger(), config)
+}
+
+func logN(t *testing.T, s *Service, n int, createdAt time.Time) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		req := LogRequest{UserID: 1, Action: model.ActionUpdate, ResourceType: model.ResourceNode, Details: "cordon node", Status: model.AuditStatusSuccess}
+		if err := s.LogWithCustomTime(req, createdAt); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	s := newTestService(t)
+	logN(t, s, 5, time.Now())
+
+	result, err := s.Verify()
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !result.Valid || result.Checked != 5 {
+		t.Fatalf("expected valid chain of 5, got %+v", result)
+	}
+
+	s.db.Model(&model.AuditLog{}).Where("id = ?", 2).Update("details", "nothing happened")
+	s.db.Delete(&model.AuditLog{}, 4)
+	s.db.Delete(&model.AuditLog{}, 5)
+
+	result, err = s.Verify()
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if result.Valid {
+		t.Fatal("expected tampering to be detected")
+	}
+	reasons := map[uint]string{}
+	for _, issue := range result.Issues {
+		reasons[issue.LogID] = issue.Reason
+	}
+	if !strings.Contains(reasons[2], "modified") {
+		t.Errorf("modified record not reported: %+v", result.Issues)
+	}
+	if !strings.Contains(reasons[5], "tail") {
+		t.Errorf("deleted tail not reported: %+v", result.Issues)
+	}
+}
+
+func TestRetentionKeepsChainVerifiable(t *testing.T) {
+	s := newTestService(t)
+	logN(t, s, 3, time.Now().AddDate(0, 0, -60))
+	logN(t, s, 2, time.Now())
+
+	result, err := s.ApplyRetention()
+	if err != nil {
+		t.Fatalf("retention: %v", err)
+	}
+	if result.Deleted != 3 || result.AnchorID != 3 || len(result.Files) != 1 {
+		t.Fatalf("unexpected retention result: %+v", result)
+	}
+
+	f, err := os.Open(result.Files[0])
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer f.Close()
+	lines := 0
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		lines++
+	}
+	if lines != 3 {
+		t.Errorf("expected 3 archived lines, got %d", lines)
+	}
+
+	verify, err := s.Verify()
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !verify.Valid || verify.Checked != 2 || verify.AnchorID != 3 {
+		t.Errorf("expected valid chain after retention, got %+v", verify)
+	}
+}
+
+func TestSyslogFormat(t *testing.T) {
+	sink, err := NewSyslogSink(SyslogConfig{Address: "127.0.0.1:514"})
+	if err != nil {
+		t.Fatalf("new sink: %v", err)
+	}
+	sink.hostname = "host-1"
+	sink.procID = "42"
+
+	entry := ExportEntry{
+		ID:           7,
+		CreatedAt:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		UserID:       1,
+		NodeName:     `node"]1`,
+		Action:       model.ActionUpdate,
+		ResourceType: model.ResourceNode,
+		Status:       model.AuditStatusFailed,
+		Hash:         "abc",
+	}
+	msg := sink.format(entry)
+	prefix := `<108>1 2026-01-02T03:04:05.000000Z host-1 kube-node-manager 42 update [audit@32473 id="7" user_id="1" resource_type="node" status="failed" node="node\"\]1" hash="abc"] {`
+	if !strings.HasPrefix(msg, prefix) {
+		t.Errorf("unexpected syslog message:\n%s\nwant prefix:\n%s", msg, prefix)
+	}
+}
+
+func TestSearchFiltersAndStats(t *testing.T) {
+	s := newTestService(t)
+	entries := []LogRequest{
+		{UserID: 1, Action: model.ActionUpdate, ResourceType: model.ResourceNode, NodeName: "gpu-node-1", Details: "Cordoned node", Status: model.AuditStatusSuccess},
+		{UserID: 1, Action: model.ActionUpdate, ResourceType: model.ResourceLabel, NodeName: "gpu-node-2", Details: "Updated labels", Reason: "Maintenance window", Status: model.AuditStatusSuccess},
+		{UserID: 1, Action: model.ActionDelete, ResourceType: model.ResourceAnsibleTask, Details: "DELETE /api/v1/ansible/tasks/3", Status: model.AuditStatusFailed},
+		{UserID: 1, Action: model.ActionUpdate, ResourceType: model.ResourceNode, NodeName: "cpu-node-1", Details: "Uncordoned node", Status: model.AuditStatusSuccess},
+	}
+	for _, req := range entries {
+		if err := s.LogWithError(req); err != nil {
+			t.Fatalf("log: %v", err)
+		}
+	}
+
+	count := func(req ListRequest) int64 {
+		t.Helper()
+		resp, err := s.List(req)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		return resp.Total
+	}
+	if got := count(ListRequest{NodeName: "gpu-*"}); got != 2 {
+		t.Errorf("node pattern: got %d, want 2", got)
+	}
+	if got := count(ListRequest{NodeName: "gpu-node-1,cpu-node-1"}); got != 2 {
+		t.Errorf("node list: got %d, want 2", got)
+	}
+	if got := count(ListRequest{Keyword: "maintenance"}); got != 1 {
+		t.Errorf("keyword: got %d, want 1", got)
+	}
+	if got := count(ListRequest{ResourceTypes: []model.ResourceType{model.ResourceNode, model.ResourceAnsibleTask}}); got != 3 {
+		t.Errorf("resource types: got %d, want 3", got)
+	}
+	if got := count(ListRequest{Username: "adm", EndDate: time.Now().Format("2006-01-02")}); got != 4 {
+		t.Errorf("username and end date: got %d, want 4", got)
+	}
+
+	stats, err := s.Stats(ListRequest{})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.Total != 4 || len(stats.Daily) != 2 || len(stats.ByUser) != 1 || stats.ByUser[0].Username != "admin" {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if stats.ByAction[0].Action != model.ActionUpdate || stats.ByAction[0].Count != 3 {
+		t.Errorf("unexpected action stats: %+v", stats.ByAction)
+	}
+
+	var exported int
+	n, err := s.Export(ListRequest{Actions: []model.AuditAction{model.ActionUpdate}}, func(logs []model.AuditLog) error {
+		exported += len(logs)
+		return nil
+	})
+	if err != nil || n != 3 || exported != 3 {
+		t.Errorf("export: n=%d exported=%d err=%v", n, exported, err)
+	}
+}
@@ -0,0 +1,228 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	chainStateID    = 1    // 哈希链状态行 ID
+	verifyBatchSize = 1000 // 校验时每批读取的记录数
+	maxChainIssues  = 100  // 校验结果最多返回的问题数
+)
+
+// ChainIssue 哈希链校验发现的问题
+type ChainIssue struct {
+	LogID  uint   `json:"log_id"`
+	Reason string `json:"reason"`
+}
+
+// VerifyResult 哈希链校验结果
+type VerifyResult struct {
+	Valid      bool         `json:"valid"`
+	Checked    int64        `json:"checked"`     // 校验的链上记录数
+	Legacy     int64        `json:"legacy"`      // 启用哈希链之前写入的记录数（不参与校验）
+	AnchorID   uint         `json:"anchor_id"`   // 最近归档删除的最后一条记录 ID，校验从其后开始
+	LastLogID  uint         `json:"last_log_id"` // 校验截止的记录 ID
+	IssueCount int          `json:"issue_count"`
+	Issues     []ChainIssue `json:"issues"` // 最多返回前 100 个问题
+	VerifiedAt time.Time    `json:"verified_at"`
+}
+
+// hashedFields 参与哈希计算的字段，字段顺序固定
+// 不包含 cluster_id：删除集群时会把审计日志的 cluster_id 置空
+type hashedFields struct {
+	ID           uint   `json:"id"`
+	UserID       uint   `json:"user_id"`
+	NodeName     string `json:"node_name"`
+	Action       string `json:"action"`
+	ResourceType string `json:"resource_type"`
+	Details      string `json:"details"`
+	Reason       string `json:"reason"`
+	Status       string `json:"status"`
+	ErrorMsg     string `json:"error_msg"`
+	IPAddress    string `json:"ip_address"`
+	UserAgent    string `json:"user_agent"`
+	APITokenID   uint   `json:"api_token_id"`
+	OperationID  string `json:"operation_id"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// ComputeHash 计算审计日志的哈希：SHA-256(上一条记录的哈希 + 本条记录内容)
+func ComputeHash(log *model.AuditLog) string {
+	fields := hashedFields{
+		ID:           log.ID,
+		UserID:       log.UserID,
+		NodeName:     log.NodeName,
+		Action:       string(log.Action),
+		ResourceType: string(log.ResourceType),
+		Details:      log.Details,
+		Reason:       log.Reason,
+		Status:       string(log.Status),
+		ErrorMsg:     log.ErrorMsg,
+		IPAddress:    log.IPAddress,
+		UserAgent:    log.UserAgent,
+		OperationID:  log.OperationID,
+		CreatedAt:    log.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if log.APITokenID != nil {
+		fields.APITokenID = *log.APITokenID
+	}
+
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(append([]byte(log.PrevHash+"\n"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// create 写入审计日志并链接到哈希链，写入成功后交给导出器
+// 在事务中锁定哈希链状态行，保证多副本并发写入时链不分叉；
+// 哈希基于写入后重新读取的记录计算，避免不同数据库对时间精度和时区的处理差异
+func (s *Service) create(auditLog *model.AuditLog) error {
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+
+	var stored model.AuditLog
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		state, err := lockChainState(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Set("gorm:update_time_stamp", false).Create(auditLog).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&stored, auditLog.ID).Error; err != nil {
+			return err
+		}
+
+		stored.PrevHash = state.LastHash
+		stored.Hash = ComputeHash(&stored)
+		if err := tx.Model(&model.AuditLog{}).Where("id = ?", stored.ID).Updates(map[string]interface{}{
+			"prev_hash": stored.PrevHash,
+			"hash":      stored.Hash,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.AuditChainState{}).Where("id = ?", chainStateID).Updates(map[string]interface{}{
+			"last_log_id": stored.ID,
+			"last_hash":   stored.Hash,
+			"updated_at":  time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	auditLog.PrevHash = stored.PrevHash
+	auditLog.Hash = stored.Hash
+	if s.exporter != nil {
+		s.exporter.Enqueue(NewExportEntry(&stored))
+	}
+	return nil
+}
+
+// lockChainState 锁定哈希链状态行，不存在时创建
+func lockChainState(tx *gorm.DB) (*model.AuditChainState, error) {
+	var state model.AuditChainState
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&state, chainStateID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.AuditChainState{ID: chainStateID}).Error; err != nil {
+			return nil, fmt.Errorf("failed to init audit chain state: %w", err)
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&state, chainStateID).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock audit chain state: %w", err)
+	}
+	return &state, nil
+}
+
+// Verify 校验哈希链：逐条重新计算哈希，并检查每条记录的 prev_hash 与上一条记录一致
+// 记录被修改时哈希不匹配，中间记录被删除时下一条记录的 prev_hash 不匹配，
+// 最新记录被删除时链尾与哈希链状态不一致
+func (s *Service) Verify() (*VerifyResult, error) {
+	result := &VerifyResult{Issues: []ChainIssue{}, VerifiedAt: time.Now()}
+
+	var state model.AuditChainState
+	if err := s.db.First(&state, chainStateID).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get audit chain state: %w", err)
+		}
+	}
+	result.AnchorID = state.AnchorID
+	result.LastLogID = state.LastLogID
+
+	// 还没有任何链上记录
+	if state.LastLogID == 0 {
+		if err := s.db.Model(&model.AuditLog{}).Count(&result.Legacy).Error; err != nil {
+			return nil, fmt.Errorf("failed to count audit logs: %w", err)
+		}
+		result.Valid = true
+		return result, nil
+	}
+
+	expected := state.AnchorHash
+	chained := state.AnchorHash != ""
+	lastID := state.AnchorID
+	for {
+		var logs []model.AuditLog
+		if err := s.db.Where("id > ? AND id <= ?", lastID, state.LastLogID).
+			Order("id ASC").
+			Limit(verifyBatchSize).
+			Find(&logs).Error; err != nil {
+			return nil, fmt.Errorf("failed to read audit logs: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+
+		for i := range logs {
+			log := &logs[i]
+			lastID = log.ID
+
+			if log.Hash == "" {
+				if !chained {
+					result.Legacy++
+				} else {
+					result.addIssue(log.ID, "hash is missing")
+				}
+				continue
+			}
+			chained = true
+			result.Checked++
+
+			if log.PrevHash != expected {
+				result.addIssue(log.ID, "prev_hash does not match the previous record, records before it may have been deleted")
+			}
+			if ComputeHash(log) != log.Hash {
+				result.addIssue(log.ID, "hash mismatch, record has been modified")
+			}
+			expected = log.Hash
+		}
+	}
+
+	if expected != state.LastHash {
+		result.addIssue(state.LastLogID, "chain tail does not match the latest hash, latest records may have been deleted")
+	}
+
+	result.Valid = result.IssueCount == 0
+	return result, nil
+}
+
+func (r *VerifyResult) addIssue(logID uint, reason string) {
+	r.IssueCount++
+	if len(r.Issues) < maxChainIssues {
+		r.Issues = append(r.Issues, ChainIssue{LogID: logID, Reason: reason})
+	}
+}
@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/logger"
+)
+
+// ExportEntry 导出到外部系统的审计日志
+type ExportEntry struct {
+	ID           uint               `json:"id"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UserID       uint               `json:"user_id"`
+	ClusterID    *uint              `json:"cluster_id,omitempty"`
+	NodeName     string             `json:"node_name,omitempty"`
+	Action       model.AuditAction  `json:"action"`
+	ResourceType model.ResourceType `json:"resource_type"`
+	Details      string             `json:"details,omitempty"`
+	Reason       string             `json:"reason,omitempty"`
+	Status       model.AuditStatus  `json:"status"`
+	ErrorMsg     string             `json:"error_msg,omitempty"`
+	IPAddress    string             `json:"ip_address,omitempty"`
+	UserAgent    string             `json:"user_agent,omitempty"`
+	APITokenID   *uint              `json:"api_token_id,omitempty"`
+	OperationID  string             `json:"operation_id,omitempty"`
+	PrevHash     string             `json:"prev_hash"`
+	Hash         string             `json:"hash"`
+}
+
+// NewExportEntry 从审计日志生成导出记录
+func NewExportEntry(log *model.AuditLog) ExportEntry {
+	return ExportEntry{
+		ID:           log.ID,
+		CreatedAt:    log.CreatedAt,
+		UserID:       log.UserID,
+		ClusterID:    log.ClusterID,
+		NodeName:     log.NodeName,
+		Action:       log.Action,
+		ResourceType: log.ResourceType,
+		Details:      log.Details,
+		Reason:       log.Reason,
+		Status:       log.Status,
+		ErrorMsg:     log.ErrorMsg,
+		IPAddress:    log.IPAddress,
+		UserAgent:    log.UserAgent,
+		APITokenID:   log.APITokenID,
+		OperationID:  log.OperationID,
+		PrevHash:     log.PrevHash,
+		Hash:         log.Hash,
+	}
+}
+
+// Sink 审计日志导出目标
+type Sink interface {
+	Name() string
+	Write(entries []ExportEntry) error
+	Close() error
+}
+
+// ExportConfig 审计日志导出配置
+type ExportConfig struct {
+	QueueSize     int           // 每个导出目标的队列长度，队列满时丢弃并告警
+	BatchSize     int           // 每批导出的最大记录数
+	FlushInterval time.Duration // 不满一批时的最长等待时间
+	Syslog        SyslogConfig
+	File          FileSinkConfig
+	HTTP          HTTPSinkConfig
+}
+
+// Exporter 审计日志异步导出器，每个导出目标独立排队，慢速目标不影响其他目标和审计写入
+// 每个副本只导出自己写入的审计日志
+type Exporter struct {
+	logger  *logger.Logger
+	config  ExportConfig
+	workers []*sinkWorker
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// sinkWorker 单个导出目标的队列
+type sinkWorker struct {
+	sink    Sink
+	queue   chan ExportEntry
+	dropped int64
+}
+
+// NewExporter 根据配置创建导出器，没有启用任何导出目标时返回 nil
+func NewExporter(config ExportConfig, logger *logger.Logger) (*Exporter, error) {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 10000
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 5 * time.Second
+	}
+
+	var sinks []Sink
+	if config.Syslog.Enabled {
+		sink, err := NewSyslogSink(config.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if config.File.Enabled {
+		sink, err := NewFileSink(config.File)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if config.HTTP.Enabled {
+		sink, err := NewHTTPSink(config.HTTP)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+
+	e := &Exporter{logger: logger, config: config}
+	for _, sink := range sinks {
+		e.workers = append(e.workers, &sinkWorker{
+			sink:  sink,
+			queue: make(chan ExportEntry, config.QueueSize),
+		})
+	}
+	return e, nil
+}
+
+// Start 启动导出协程
+func (e *Exporter) Start() {
+	for _, w := range e.workers {
+		e.logger.Infof("Audit log export to %s enabled", w.sink.Name())
+		e.wg.Add(1)
+		go e.run(w)
+	}
+}
+
+// Enqueue 把审计日志放入每个导出目标的队列，不阻塞调用方
+func (e *Exporter) Enqueue(entry ExportEntry) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return
+	}
+
+	for _, w := range e.workers {
+		select {
+		case w.queue <- entry:
+		default:
+			dropped := atomic.AddInt64(&w.dropped, 1)
+			if dropped == 1 || dropped%1000 == 0 {
+				e.logger.Warningf("Audit export queue of %s is full, %d entries dropped", w.sink.Name(), dropped)
+			}
+		}
+	}
+}
+
+// Stop 停止接收新记录，导出队列中剩余的记录后关闭导出目标
+func (e *Exporter) Stop() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	for _, w := range e.workers {
+		close(w.queue)
+	}
+	e.mu.Unlock()
+
+	e.wg.Wait()
+}
+
+// run 按批次或时间间隔把队列中的记录写入导出目标
+func (e *Exporter) run(w *sinkWorker) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ExportEntry, 0, e.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.sink.Write(batch); err != nil {
+			e.logger.Errorf("Failed to export %d audit logs to %s: %v", len(batch), w.sink.Name(), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				flush()
+				if err := w.sink.Close(); err != nil {
+					e.logger.Warningf("Failed to close audit export sink %s: %v", w.sink.Name(), err)
+				}
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= e.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// RetentionConfig 审计日志保留与归档配置
+type RetentionConfig struct {
+	Enabled       bool   // 是否删除超出保留期的审计日志
+	RetentionDays int    // 保留天数
+	CleanupTime   string // 清理时间（格式：HH:MM）
+	BatchSize     int    // 每批归档删除的记录数
+	Archive       bool   // 删除前是否归档到 JSON Lines 文件
+	ArchiveDir    string // 归档目录，多副本部署时应使用共享存储
+}
+
+// ArchiveResult 归档清理结果
+type ArchiveResult struct {
+	Deleted  int64     `json:"deleted"`
+	Files    []string  `json:"files"`
+	AnchorID uint      `json:"anchor_id"`
+	Cutoff   time.Time `json:"cutoff"`
+}
+
+// RetentionEnabled 是否启用审计日志保留策略
+func (s *Service) RetentionEnabled() bool {
+	return s.config.Retention.Enabled
+}
+
+// retentionLoop 每天在配置的时间归档并删除过期审计日志
+func (s *Service) retentionLoop() {
+	defer s.wg.Done()
+
+	nextRun := s.calculateNextRetentionTime()
+	s.logger.Infof("Next audit log retention scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+	for {
+		timer := time.NewTimer(time.Until(nextRun))
+		select {
+		case <-timer.C:
+			if _, err := s.ApplyRetention(); err != nil {
+				s.logger.Errorf("Scheduled audit log retention failed: %v", err)
+			}
+			nextRun = s.calculateNextRetentionTime()
+			s.logger.Infof("Next audit log retention scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// calculateNextRetentionTime 计算下次清理时间
+func (s *Service) calculateNextRetentionTime() time.Time {
+	now := time.Now()
+
+	hour, minute := 4, 0
+	fmt.Sscanf(s.config.Retention.CleanupTime, "%d:%d", &hour, &minute)
+
+	nextRun := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if now.After(nextRun) {
+		nextRun = nextRun.Add(24 * time.Hour)
+	}
+	return nextRun
+}
+
+// ApplyRetention 按 ID 顺序分批归档并删除超出保留期的审计日志
+// 只删除从最早记录开始的连续前缀，并把最后删除的记录记为哈希链锚点，删除后剩余记录仍可校验
+func (s *Service) ApplyRetention() (*ArchiveResult, error) {
+	startTime := time.Now()
+	result := &ArchiveResult{
+		Files:  []string{},
+		Cutoff: startTime.AddDate(0, 0, -s.config.Retention.RetentionDays),
+	}
+
+	for {
+		deleted, file, err := s.archiveBatch(result.Cutoff)
+		if err != nil {
+			return result, err
+		}
+		if deleted == 0 {
+			break
+		}
+
+		result.Deleted += int64(deleted)
+		if file != "" && (len(result.Files) == 0 || result.Files[len(result.Files)-1] != file) {
+			result.Files = append(result.Files, file)
+		}
+
+		// 短暂休息，避免长时间阻塞审计日志写入
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	var state model.AuditChainState
+	if err := s.db.First(&state, chainStateID).Error; err == nil {
+		result.AnchorID = state.AnchorID
+	}
+
+	s.logger.Infof("Audit log retention completed: %d logs before %s archived and deleted in %v",
+		result.Deleted, result.Cutoff.Format("2006-01-02 15:04:05"), time.Since(startTime))
+	return result, nil
+}
+
+// archiveBatch 在锁定哈希链状态的事务中归档并删除一批过期记录，返回删除数量和归档文件
+func (s *Service) archiveBatch(cutoff time.Time) (int, string, error) {
+	s.chainMu.Lock()
+	defer s.chainMu.Unlock()
+
+	var deleted int
+	var file string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockChainState(tx); err != nil {
+			return err
+		}
+
+		var logs []model.AuditLog
+		if err := tx.Order("id ASC").Limit(s.config.Retention.BatchSize).Find(&logs).Error; err != nil {
+			return fmt.Errorf("failed to read audit logs: %w", err)
+		}
+		// 只处理连续的过期前缀，自定义时间写入的记录可能与 ID 顺序不一致
+		expired := 0
+		for expired < len(logs) && logs[expired].CreatedAt.Before(cutoff) {
+			expired++
+		}
+		if expired == 0 {
+			return nil
+		}
+		logs = logs[:expired]
+
+		if s.config.Retention.Archive {
+			path, err := s.writeArchive(logs)
+			if err != nil {
+				return err
+			}
+			file = path
+		}
+
+		ids := make([]uint, len(logs))
+		for i, log := range logs {
+			ids[i] = log.ID
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&model.AuditLog{}).Error; err != nil {
+			return fmt.Errorf("failed to delete audit logs: %w", err)
+		}
+
+		last := logs[len(logs)-1]
+		if err := tx.Model(&model.AuditChainState{}).Where("id = ?", chainStateID).Updates(map[string]interface{}{
+			"anchor_id":   last.ID,
+			"anchor_hash": last.Hash,
+			"updated_at":  time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to update audit chain anchor: %w", err)
+		}
+
+		deleted = len(logs)
+		return nil
+	})
+	return deleted, file, err
+}
+
+// writeArchive 把记录追加到当天的归档文件并落盘，返回文件路径
+// 删除事务提交失败时记录会在下次归档时重复写入，归档文件按 id 去重即可
+func (s *Service) writeArchive(logs []model.AuditLog) (string, error) {
+	if err := os.MkdirAll(s.config.Retention.ArchiveDir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create audit archive directory: %w", err)
+	}
+	path := filepath.Join(s.config.Retention.ArchiveDir, "audit-"+time.Now().Format("20060102")+".jsonl")
+
+	var buf bytes.Buffer
+	for i := range logs {
+		data, err := json.Marshal(NewExportEntry(&logs[i]))
+		if err != nil {
+			return "", err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return "", fmt.Errorf("failed to open audit archive: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to write audit archive: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return "", fmt.Errorf("failed to sync audit archive: %w", err)
+	}
+	return path, nil
+}
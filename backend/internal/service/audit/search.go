@@ -0,0 +1,296 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	exportBatchSize  = 1000
+	maxExportRows    = 100000 // 单次导出的最大记录数
+	defaultStatsDays = 30     // 未指定时间范围时统计最近 30 天
+)
+
+// DailyStat 按天、用户、操作类型聚合的审计日志数量
+type DailyStat struct {
+	Date     string            `json:"date"`
+	UserID   uint              `json:"user_id"`
+	Username string            `json:"username"`
+	Action   model.AuditAction `json:"action"`
+	Count    int64             `json:"count"`
+}
+
+// UserStat 按用户聚合的数量
+type UserStat struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Count    int64  `json:"count"`
+}
+
+// ActionStat 按操作类型聚合的数量
+type ActionStat struct {
+	Action model.AuditAction `json:"action"`
+	Count  int64             `json:"count"`
+}
+
+// StatsResponse 审计日志聚合统计
+type StatsResponse struct {
+	StartDate string       `json:"start_date"`
+	EndDate   string       `json:"end_date"`
+	Total     int64        `json:"total"`
+	Daily     []DailyStat  `json:"daily"`
+	ByUser    []UserStat   `json:"by_user"`
+	ByAction  []ActionStat `json:"by_action"`
+}
+
+// filteredQuery 根据检索条件构建查询，列名都带表名前缀，避免与关联查询冲突
+func (s *Service) filteredQuery(req ListRequest) *gorm.DB {
+	query := s.db.Model(&model.AuditLog{})
+
+	if req.UserID > 0 {
+		query = query.Where("audit_logs.user_id = ?", req.UserID)
+	}
+	if len(req.UserIDs) > 0 {
+		query = query.Where("audit_logs.user_id IN ?", req.UserIDs)
+	}
+	if req.Username != "" {
+		names := splitList(req.Username)
+		conditions := make([]string, len(names))
+		args := make([]interface{}, len(names))
+		for i, name := range names {
+			conditions[i] = "username LIKE ?"
+			args[i] = "%" + name + "%"
+		}
+		query = query.Where("audit_logs.user_id IN (SELECT id FROM users WHERE "+strings.Join(conditions, " OR ")+")", args...)
+	}
+	if req.ClusterID > 0 {
+		query = query.Where("audit_logs.cluster_id = ?", req.ClusterID)
+	}
+	if req.Action != "" {
+		query = query.Where("audit_logs.action = ?", req.Action)
+	}
+	if len(req.Actions) > 0 {
+		query = query.Where("audit_logs.action IN ?", req.Actions)
+	}
+	if req.ResourceType != "" {
+		query = query.Where("audit_logs.resource_type = ?", req.ResourceType)
+	}
+	if len(req.ResourceTypes) > 0 {
+		query = query.Where("audit_logs.resource_type IN ?", req.ResourceTypes)
+	}
+	if req.Status != "" {
+		query = query.Where("audit_logs.status = ?", req.Status)
+	}
+	if req.NodeName != "" {
+		var conditions []string
+		var args []interface{}
+		for _, pattern := range splitList(req.NodeName) {
+			if strings.ContainsAny(pattern, "*?") {
+				conditions = append(conditions, "audit_logs.node_name LIKE ?")
+				args = append(args, strings.NewReplacer("*", "%", "?", "_").Replace(pattern))
+			} else {
+				conditions = append(conditions, "audit_logs.node_name = ?")
+				args = append(args, pattern)
+			}
+		}
+		if len(conditions) > 0 {
+			query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+		}
+	}
+	if keyword := strings.TrimSpace(req.Keyword); keyword != "" {
+		like := "%" + strings.ToLower(keyword) + "%"
+		query = query.Where("(LOWER(audit_logs.details) LIKE ? OR LOWER(audit_logs.reason) LIKE ? OR LOWER(audit_logs.error_msg) LIKE ?)",
+			like, like, like)
+	}
+	if req.StartDate != "" {
+		query = query.Where("audit_logs.created_at >= ?", parseTimeBound(req.StartDate, false))
+	}
+	if req.EndDate != "" {
+		// 只有日期时包含当天
+		if end := parseTimeBound(req.EndDate, true); isDateOnly(req.EndDate) {
+			query = query.Where("audit_logs.created_at < ?", end)
+		} else {
+			query = query.Where("audit_logs.created_at <= ?", end)
+		}
+	}
+
+	return query
+}
+
+// Stats 按天、用户、操作类型聚合审计日志，未指定时间范围时统计最近 30 天
+func (s *Service) Stats(req ListRequest) (*StatsResponse, error) {
+	if req.StartDate == "" && req.EndDate == "" {
+		req.StartDate = time.Now().AddDate(0, 0, -defaultStatsDays).Format("2006-01-02")
+	}
+
+	var rows []struct {
+		Day    string
+		UserID uint
+		Action model.AuditAction
+		Count  int64
+	}
+	if err := s.filteredQuery(req).
+		Select("DATE(audit_logs.created_at) AS day, audit_logs.user_id AS user_id, audit_logs.action AS action, COUNT(*) AS count").
+		Group("DATE(audit_logs.created_at), audit_logs.user_id, audit_logs.action").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to aggregate audit logs: %w", err)
+	}
+
+	userIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		userIDs = append(userIDs, row.UserID)
+	}
+	usernames, err := s.usernames(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &StatsResponse{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Daily:     make([]DailyStat, 0, len(rows)),
+		ByUser:    []UserStat{},
+		ByAction:  []ActionStat{},
+	}
+	byUser := make(map[uint]int64)
+	byAction := make(map[model.AuditAction]int64)
+	for _, row := range rows {
+		// PostgreSQL/MySQL 返回日期时间，SQLite 返回日期字符串，统一取日期部分
+		day := row.Day
+		if len(day) > 10 {
+			day = day[:10]
+		}
+		resp.Daily = append(resp.Daily, DailyStat{
+			Date:     day,
+			UserID:   row.UserID,
+			Username: usernames[row.UserID],
+			Action:   row.Action,
+			Count:    row.Count,
+		})
+		resp.Total += row.Count
+		byUser[row.UserID] += row.Count
+		byAction[row.Action] += row.Count
+	}
+
+	for userID, count := range byUser {
+		resp.ByUser = append(resp.ByUser, UserStat{UserID: userID, Username: usernames[userID], Count: count})
+	}
+	for action, count := range byAction {
+		resp.ByAction = append(resp.ByAction, ActionStat{Action: action, Count: count})
+	}
+	sort.Slice(resp.Daily, func(i, j int) bool {
+		a, b := resp.Daily[i], resp.Daily[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		return a.Action < b.Action
+	})
+	sort.Slice(resp.ByUser, func(i, j int) bool { return resp.ByUser[i].Count > resp.ByUser[j].Count })
+	sort.Slice(resp.ByAction, func(i, j int) bool { return resp.ByAction[i].Count > resp.ByAction[j].Count })
+	return resp, nil
+}
+
+// CountForExport 统计符合条件的记录数，并返回是否超过单次导出上限
+func (s *Service) CountForExport(req ListRequest) (int64, bool, error) {
+	var total int64
+	if err := s.filteredQuery(req).Count(&total).Error; err != nil {
+		return 0, false, err
+	}
+	return total, total > maxExportRows, nil
+}
+
+// Export 按时间倒序分批读取符合条件的审计日志交给 fn 处理，最多 100000 条，返回导出的记录数
+func (s *Service) Export(req ListRequest, fn func(logs []model.AuditLog) error) (int, error) {
+	exported := 0
+	var lastID uint
+	for exported < maxExportRows {
+		query := s.filteredQuery(req).Preload("User").Preload("Cluster")
+		if lastID > 0 {
+			query = query.Where("audit_logs.id < ?", lastID)
+		}
+
+		batch := exportBatchSize
+		if remaining := maxExportRows - exported; remaining < batch {
+			batch = remaining
+		}
+		var logs []model.AuditLog
+		if err := query.Order("audit_logs.id DESC").Limit(batch).Find(&logs).Error; err != nil {
+			return exported, fmt.Errorf("failed to read audit logs: %w", err)
+		}
+		if len(logs) == 0 {
+			break
+		}
+		if err := fn(logs); err != nil {
+			return exported, err
+		}
+
+		exported += len(logs)
+		lastID = logs[len(logs)-1].ID
+		if len(logs) < batch {
+			break
+		}
+	}
+	return exported, nil
+}
+
+// usernames 查询用户 ID 对应的用户名
+func (s *Service) usernames(userIDs []uint) (map[uint]string, error) {
+	names := make(map[uint]string)
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+
+	var users []model.User
+	if err := s.db.Select("id", "username").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, user := range users {
+		names[user.ID] = user.Username
+	}
+	return names, nil
+}
+
+// splitList 拆分逗号分隔的多个值，忽略空值
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isDateOnly 是否只包含日期（YYYY-MM-DD）
+func isDateOnly(value string) bool {
+	_, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(value), time.Local)
+	return err == nil
+}
+
+// parseTimeBound 解析时间范围边界，支持 RFC3339、"YYYY-MM-DD HH:MM:SS" 和 "YYYY-MM-DD"
+// 结束边界只有日期时返回次日零点；无法解析时原样返回，交给数据库比较
+func parseTimeBound(value string, end bool) interface{} {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+		return t
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if end {
+			return t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileSinkConfig JSON Lines 文件导出配置
+type FileSinkConfig struct {
+	Enabled    bool
+	Path       string // 文件路径
+	MaxSizeMB  int    // 单个文件最大大小，超过后轮转
+	MaxBackups int    // 保留的轮转文件数
+}
+
+// FileSink 把审计日志按 JSON Lines 格式追加到文件，按大小轮转
+// 轮转后的文件名为 <path>.<时间戳>，超过保留数量的旧文件会被删除
+type FileSink struct {
+	config FileSinkConfig
+	file   *os.File
+	size   int64
+}
+
+// NewFileSink 创建文件导出目标
+func NewFileSink(config FileSinkConfig) (*FileSink, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("audit file export requires path")
+	}
+	if config.MaxSizeMB <= 0 {
+		config.MaxSizeMB = 100
+	}
+	if config.MaxBackups <= 0 {
+		config.MaxBackups = 10
+	}
+
+	sink := &FileSink{config: config}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// Name 导出目标名称
+func (s *FileSink) Name() string {
+	return "file(" + s.config.Path + ")"
+}
+
+// Write 追加一批记录，写入前检查是否需要轮转
+func (s *FileSink) Write(entries []ExportEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.size > 0 && s.size+int64(buf.Len()) > int64(s.config.MaxSizeMB)*1024*1024 {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(buf.Bytes())
+	s.size += int64(n)
+	return err
+}
+
+// Close 关闭文件
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.config.Path), 0750); err != nil {
+		return fmt.Errorf("failed to create audit export directory: %w", err)
+	}
+	file, err := os.OpenFile(s.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open audit export file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate 重命名当前文件并打开新文件，删除超出保留数量的旧文件
+func (s *FileSink) rotate() error {
+	if err := s.Close(); err != nil {
+		return err
+	}
+	backup := s.config.Path + "." + time.Now().Format("20060102-150405.000")
+	if err := os.Rename(s.config.Path, backup); err != nil {
+		return fmt.Errorf("failed to rotate audit export file: %w", err)
+	}
+
+	backups, _ := filepath.Glob(s.config.Path + ".*")
+	if len(backups) > s.config.MaxBackups {
+		sort.Strings(backups)
+		for _, old := range backups[:len(backups)-s.config.MaxBackups] {
+			os.Remove(old)
+		}
+	}
+	return s.open()
+}
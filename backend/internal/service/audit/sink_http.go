@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const httpSinkMaxAttempts = 3
+
+// HTTPSinkConfig HTTP 导出配置
+type HTTPSinkConfig struct {
+	Enabled bool
+	URL     string
+	Headers map[string]string // 额外请求头，例如 Authorization
+	Timeout time.Duration
+}
+
+// HTTPSink 以 JSON 数组的形式把一批审计日志 POST 到 HTTP 接口，失败时退避重试
+type HTTPSink struct {
+	config HTTPSinkConfig
+	client *http.Client
+}
+
+// NewHTTPSink 创建 HTTP 导出目标
+func NewHTTPSink(config HTTPSinkConfig) (*HTTPSink, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("audit http export requires url")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &HTTPSink{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// Name 导出目标名称
+func (s *HTTPSink) Name() string {
+	return "http(" + s.config.URL + ")"
+}
+
+// Write 发送一批记录
+func (s *HTTPSink) Write(entries []ExportEntry) error {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = s.post(body)
+		if err == nil || attempt == httpSinkMaxAttempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+}
+
+// Close 实现 Sink 接口
+func (s *HTTPSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *HTTPSink) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/model"
+)
+
+const (
+	syslogDialTimeout  = 5 * time.Second
+	syslogWriteTimeout = 10 * time.Second
+	syslogSDID         = "audit@32473" // 结构化数据 ID，32473 为 RFC 5612 保留的示例企业号
+)
+
+// SyslogConfig syslog 导出配置
+type SyslogConfig struct {
+	Enabled  bool
+	Network  string // tcp 或 udp
+	Address  string // host:port
+	Facility int    // 默认 13（log audit）
+	AppName  string
+}
+
+// SyslogSink 按 RFC 5424 格式发送审计日志到 syslog，TCP 使用 RFC 6587 octet-counting 分帧
+type SyslogSink struct {
+	config   SyslogConfig
+	hostname string
+	procID   string
+	conn     net.Conn
+}
+
+// NewSyslogSink 创建 syslog 导出目标，连接在首次写入时建立
+func NewSyslogSink(config SyslogConfig) (*SyslogSink, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("audit syslog export requires address")
+	}
+	if config.Network == "" {
+		config.Network = "udp"
+	}
+	if config.Network != "tcp" && config.Network != "udp" {
+		return nil, fmt.Errorf("unsupported audit syslog network: %s", config.Network)
+	}
+	if config.Facility <= 0 || config.Facility > 23 {
+		config.Facility = 13
+	}
+	if config.AppName == "" {
+		config.AppName = "kube-node-manager"
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSink{
+		config:   config,
+		hostname: hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+// Name 导出目标名称
+func (s *SyslogSink) Name() string {
+	return "syslog(" + s.config.Network + "://" + s.config.Address + ")"
+}
+
+// Write 逐条发送，写入失败时重新连接并重试一次
+func (s *SyslogSink) Write(entries []ExportEntry) error {
+	for _, entry := range entries {
+		msg := s.format(entry)
+		if s.config.Network == "tcp" {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+
+		if err := s.send(msg); err != nil {
+			s.closeConn()
+			if err := s.send(msg); err != nil {
+				s.closeConn()
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close 关闭连接
+func (s *SyslogSink) Close() error {
+	return s.closeConn()
+}
+
+func (s *SyslogSink) send(msg string) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.config.Network, s.config.Address, syslogDialTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
+
+func (s *SyslogSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format 生成 RFC 5424 消息：<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD] MSG
+// MSGID 为操作类型，关键字段放在结构化数据中便于 SIEM 检索，MSG 为完整的 JSON 记录
+func (s *SyslogSink) format(entry ExportEntry) string {
+	severity := 5 // notice
+	if entry.Status == model.AuditStatusFailed {
+		severity = 4 // warning
+	}
+	pri := s.config.Facility*8 + severity
+
+	params := []string{
+		sdParam("id", strconv.FormatUint(uint64(entry.ID), 10)),
+		sdParam("user_id", strconv.FormatUint(uint64(entry.UserID), 10)),
+		sdParam("resource_type", string(entry.ResourceType)),
+		sdParam("status", string(entry.Status)),
+	}
+	if entry.NodeName != "" {
+		params = append(params, sdParam("node", entry.NodeName))
+	}
+	if entry.OperationID != "" {
+		params = append(params, sdParam("operation_id", entry.OperationID))
+	}
+	if entry.IPAddress != "" {
+		params = append(params, sdParam("ip", entry.IPAddress))
+	}
+	params = append(params, sdParam("hash", entry.Hash))
+
+	body, _ := json.Marshal(entry)
+	return fmt.Sprintf("<%d>1 %s %s %s %s %s [%s %s] %s",
+		pri,
+		entry.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		headerField(s.hostname, 255),
+		headerField(s.config.AppName, 48),
+		headerField(s.procID, 128),
+		headerField(string(entry.Action), 32),
+		syslogSDID,
+		strings.Join(params, " "),
+		body,
+	)
+}
+
+// sdParam 生成结构化数据参数，转义 "、\ 和 ]
+func sdParam(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+	return name + `="` + value + `"`
+}
+
+// headerField 头部字段只允许可打印 ASCII 且不含空格，为空时使用 NILVALUE
+func headerField(value string, maxLen int) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r > 32 && r < 127 {
+			b.WriteRune(r)
+		}
+		if b.Len() >= maxLen {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// apiTokenTouchInterval 最近使用时间的最小更新间隔，避免每个请求都写数据库
+const apiTokenTouchInterval = time.Minute
+
+// CreateAPITokenRequest 创建访问令牌请求
+type CreateAPITokenRequest struct {
+	Name      string   `json:"name" binding:"required,max=100"`
+	UserID    uint     `json:"user_id"`                         // 令牌所属用户，为空表示当前用户；为其他用户或服务账号创建需要管理员权限
+	Scopes    []string `json:"scopes" binding:"required,min=1"` // 权限范围，如 nodes:read、labels:write
+	Clusters  []string `json:"clusters"`                        // 允许访问的集群，为空表示不限制
+	ExpiresIn int      `json:"expires_in"`                      // 有效天数，0 表示永不过期
+}
+
+// CreateAPITokenResponse 创建访问令牌响应，明文令牌只返回这一次
+type CreateAPITokenResponse struct {
+	model.APIToken
+	Token string `json:"token"`
+}
+
+// ListAPITokensRequest 访问令牌列表请求
+type ListAPITokensRequest struct {
+	UserID         uint `form:"user_id"`
+	IncludeRevoked bool `form:"include_revoked"`
+}
+
+// APITokenRequestLog 通过访问令牌发起的请求
+type APITokenRequestLog struct {
+	TokenID    uint
+	UserID     uint
+	Method     string
+	URI        string
+	StatusCode int
+	IPAddress  string
+	UserAgent  string
+}
+
+// hashAPIToken 计算令牌摘要，数据库只保存摘要
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIToken 创建访问令牌
+func (s *Service) CreateAPIToken(req CreateAPITokenRequest, operatorID uint, operatorRole model.UserRole) (*CreateAPITokenResponse, error) {
+	ownerID := req.UserID
+	if ownerID == 0 {
+		ownerID = operatorID
+	}
+	if ownerID != operatorID && operatorRole != model.RoleAdmin {
+		return nil, errors.New("only administrators can create tokens for other users")
+	}
+
+	var owner model.User
+	if err := s.db.First(&owner, ownerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	if owner.Status != model.StatusActive {
+		return nil, errors.New("cannot create token for inactive user")
+	}
+
+	for _, scope := range req.Scopes {
+		if !model.IsValidTokenScope(scope) {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+	for _, name := range req.Clusters {
+		var count int64
+		if err := s.db.Model(&model.Cluster{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, fmt.Errorf("cluster not found: %s", name)
+		}
+	}
+	if req.ExpiresIn < 0 {
+		return nil, errors.New("expires_in must not be negative")
+	}
+
+	secret, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	plain := model.APITokenPrefix + secret
+
+	token := model.APIToken{
+		Name:      strings.TrimSpace(req.Name),
+		UserID:    owner.ID,
+		TokenHash: hashAPIToken(plain),
+		TokenHint: plain[:len(model.APITokenPrefix)+6],
+		Scopes:    model.StringArray(req.Scopes),
+		Clusters:  model.StringArray(req.Clusters),
+		CreatedBy: operatorID,
+	}
+	if req.ExpiresIn > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresIn)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.db.Create(&token).Error; err != nil {
+		return nil, fmt.Errorf("failed to create api token: %w", err)
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       operatorID,
+		Action:       model.ActionCreate,
+		ResourceType: model.ResourceAPIToken,
+		Details:      fmt.Sprintf("Created API token %s (ID: %d) for user %s, scopes: %s", token.Name, token.ID, owner.Username, strings.Join(req.Scopes, ",")),
+		Status:       model.AuditStatusSuccess,
+	})
+	s.logger.Infof("API token %s (ID: %d) created for user %s by user %d", token.Name, token.ID, owner.Username, operatorID)
+
+	return &CreateAPITokenResponse{APIToken: token, Token: plain}, nil
+}
+
+// ListAPITokens 列出访问令牌，非管理员只能查看自己的令牌
+func (s *Service) ListAPITokens(req ListAPITokensRequest, operatorID uint, operatorRole model.UserRole) ([]model.APIToken, error) {
+	query := s.db.Preload("User")
+	if operatorRole != model.RoleAdmin {
+		query = query.Where("user_id = ?", operatorID)
+	} else if req.UserID != 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if !req.IncludeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+
+	var tokens []model.APIToken
+	if err := query.Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// RevokeAPIToken 撤销访问令牌，非管理员只能撤销自己的令牌
+func (s *Service) RevokeAPIToken(id uint, operatorID uint, operatorRole model.UserRole) error {
+	var token model.APIToken
+	if err := s.db.First(&token, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("api token not found")
+		}
+		return err
+	}
+	if token.UserID != operatorID && operatorRole != model.RoleAdmin {
+		return errors.New("api token not found")
+	}
+	if token.RevokedAt != nil {
+		return nil
+	}
+
+	if err := s.db.Model(&token).Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke api token: %w", err)
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       operatorID,
+		Action:       model.ActionDelete,
+		ResourceType: model.ResourceAPIToken,
+		Details:      fmt.Sprintf("Revoked API token %s (ID: %d) of user %d", token.Name, token.ID, token.UserID),
+		Status:       model.AuditStatusSuccess,
+	})
+	return nil
+}
+
+// ValidateAPIToken 校验访问令牌，返回令牌及其所属用户
+// 令牌状态直接查询数据库，撤销在所有副本上立即生效
+func (s *Service) ValidateAPIToken(plain, ipAddress string) (*model.APIToken, *model.User, error) {
+	if !strings.HasPrefix(plain, model.APITokenPrefix) {
+		return nil, nil, errors.New("invalid api token")
+	}
+
+	var token model.APIToken
+	if err := s.db.Preload("User").Where("token_hash = ?", hashAPIToken(plain)).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, errors.New("invalid api token")
+		}
+		return nil, nil, err
+	}
+	if token.RevokedAt != nil {
+		return nil, nil, errors.New("api token has been revoked")
+	}
+	if !token.IsActive() {
+		return nil, nil, errors.New("api token has expired")
+	}
+	if token.User == nil || token.User.Status != model.StatusActive {
+		return nil, nil, errors.New("account is inactive")
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval || token.LastUsedIP != ipAddress {
+		if err := s.db.Model(&model.APIToken{}).Where("id = ?", token.ID).
+			Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ipAddress}).Error; err != nil {
+			s.logger.Warningf("Failed to update last used time of api token %d: %v", token.ID, err)
+		}
+	}
+
+	return &token, token.User, nil
+}
+
+// LogAPITokenRequest 记录通过访问令牌发起的写请求
+func (s *Service) LogAPITokenRequest(req APITokenRequestLog) {
+	action := model.ActionUpdate
+	switch req.Method {
+	case http.MethodPost:
+		action = model.ActionCreate
+	case http.MethodDelete:
+		action = model.ActionDelete
+	}
+
+	status := model.AuditStatusSuccess
+	errorMsg := ""
+	if req.StatusCode >= http.StatusBadRequest {
+		status = model.AuditStatusFailed
+		errorMsg = fmt.Sprintf("HTTP %d", req.StatusCode)
+	}
+
+	tokenID := req.TokenID
+	s.audit.Log(audit.LogRequest{
+		UserID:       req.UserID,
+		Action:       action,
+		ResourceType: model.ResourceAPIToken,
+		Details:      fmt.Sprintf("API token %d: %s %s", req.TokenID, req.Method, req.URI),
+		Status:       status,
+		ErrorMsg:     errorMsg,
+		IPAddress:    req.IPAddress,
+		UserAgent:    req.UserAgent,
+		APITokenID:   &tokenID,
+	})
+}
@@ -7,7 +7,9 @@ import (
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/audit"
 	"kube-node-manager/internal/service/ldap"
+	"kube-node-manager/pkg/crypto"
 	"kube-node-manager/pkg/logger"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,6 +22,13 @@ type Service struct {
 	jwtCfg config.JWTConfig
 	ldap   *ldap.Service
 	audit  *audit.Service
+	// 登录安全配置与 TOTP 密钥加密器
+	securityCfg  config.SecurityConfig
+	mfaEncryptor *crypto.Encryptor
+	// 会话状态缓存 map[sessionID]状态，过期条目在写入时清理
+	sessionCache         map[string]sessionCacheEntry
+	sessionCachePrunedAt time.Time
+	cacheMutex           sync.RWMutex
 }
 
 type LoginRequest struct {
@@ -32,6 +41,12 @@ type LoginResponse struct {
 	RefreshToken string     `json:"refresh_token"`
 	User         model.User `json:"user"`
 	ExpiresAt    time.Time  `json:"expires_at"`
+
+	// 需要第二因素时不签发令牌，只返回短期的 MFA 令牌
+	MFARequired      bool     `json:"mfa_required,omitempty"`       // 需要输入 TOTP 验证码或恢复码
+	MFASetupRequired bool     `json:"mfa_setup_required,omitempty"` // 角色要求 MFA 但尚未绑定，需要先完成绑定
+	MFAToken         string   `json:"mfa_token,omitempty"`
+	RecoveryCodes    []string `json:"recovery_codes,omitempty"` // 登录时完成绑定才会返回
 }
 
 type RefreshTokenRequest struct {
@@ -39,10 +54,11 @@ type RefreshTokenRequest struct {
 }
 
 type Claims struct {
-	UserID   uint           `json:"user_id"`
-	Username string         `json:"username"`
-	Role     model.UserRole `json:"role"`
-	Type     string         `json:"type"` // "access" or "refresh"
+	UserID    uint           `json:"user_id"`
+	Username  string         `json:"username"`
+	Role      model.UserRole `json:"role"`
+	Type      string         `json:"type"`          // "access" or "refresh"
+	SessionID string         `json:"sid,omitempty"` // 所属会话，用于服务端撤销
 	jwt.RegisteredClaims
 }
 
@@ -60,17 +76,67 @@ type ProfileStatsResponse struct {
 	OperationCount int `json:"operationCount"`
 }
 
-func NewService(db *gorm.DB, logger *logger.Logger, jwtCfg config.JWTConfig, ldap *ldap.Service, audit *audit.Service) *Service {
+func NewService(db *gorm.DB, logger *logger.Logger, jwtCfg config.JWTConfig, securityCfg config.SecurityConfig, ldap *ldap.Service, audit *audit.Service) *Service {
+	mfaKey := securityCfg.MFAEncryptionKey
+	if mfaKey == "" {
+		mfaKey = jwtCfg.Secret
+	}
+
 	return &Service{
 		db:     db,
 		logger: logger,
 		jwtCfg: jwtCfg,
 		ldap:   ldap,
 		audit:  audit,
+
+		securityCfg:  securityCfg,
+		mfaEncryptor: crypto.NewEncryptor(mfaKey),
+
+		sessionCache: make(map[string]sessionCacheEntry),
 	}
 }
 
+// Login 校验用户名和密码
+// 启用了 MFA（或角色要求 MFA）的用户只会拿到短期的 MFA 令牌，需要继续调用 CompleteMFALogin
 func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginResponse, error) {
+	if err := s.checkLoginThrottle(req.Username, ipAddress); err != nil {
+		s.audit.Log(audit.LogRequest{
+			Action:       model.ActionLogin,
+			ResourceType: model.ResourceUser,
+			Details:      fmt.Sprintf("Login attempt rejected for username %s: %v", req.Username, err),
+			Status:       model.AuditStatusFailed,
+			ErrorMsg:     "Too many failed login attempts",
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+		return nil, err
+	}
+
+	user, isLDAPAuth, err := s.authenticate(req, ipAddress, userAgent)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrAccountNotFound) {
+			s.recordLoginFailure(req.Username, ipAddress, userAgent)
+		}
+		return nil, err
+	}
+
+	mfaResp, err := s.beginMFA(user)
+	if err != nil {
+		return nil, err
+	}
+	if mfaResp != nil {
+		return mfaResp, nil
+	}
+
+	method := ""
+	if isLDAPAuth {
+		method = "LDAP"
+	}
+	return s.completeLogin(user, method, ipAddress, userAgent)
+}
+
+// authenticate 校验用户名和密码（本地或 LDAP），返回用户以及是否通过 LDAP 认证
+func (s *Service) authenticate(req LoginRequest, ipAddress, userAgent string) (*model.User, bool, error) {
 	var user model.User
 	// 首先查询包含软删除的用户记录
 	err := s.db.Unscoped().Where("username = ?", req.Username).First(&user).Error
@@ -95,7 +161,7 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 					IPAddress:    ipAddress,
 					UserAgent:    userAgent,
 				})
-				return nil, errors.New("invalid credentials")
+				return nil, false, ErrInvalidCredentials
 			}
 
 			s.logger.Infof("LDAP authentication successful for user %s, creating local user record", req.Username)
@@ -109,12 +175,14 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 			user.HashPassword("") // LDAP users don't have local passwords
 			if err := s.db.Create(&user).Error; err != nil {
 				s.logger.Errorf("Failed to create local user record for LDAP user %s: %v", req.Username, err)
-				return nil, err
+				return nil, false, err
 			}
 			s.logger.Infof("Local user record created for LDAP user %s (ID: %d) with Viewer role", req.Username, user.ID)
 			isLDAPAuth = true
+		} else if err == gorm.ErrRecordNotFound {
+			return nil, false, ErrInvalidCredentials
 		} else {
-			return nil, err
+			return nil, false, err
 		}
 	} else {
 		// 用户存在（可能被软删除）
@@ -132,7 +200,7 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 					// 如果 LDAP 认证失败且用户原本就不是 LDAP 用户，则拒绝登录
 					if !user.IsLDAPUser {
 						s.logger.Warningf("Soft-deleted local user %s failed LDAP authentication, access denied", req.Username)
-						return nil, errors.New("account not found")
+						return nil, false, ErrAccountNotFound
 					}
 
 					// 如果是原 LDAP 用户但认证失败，记录审计日志
@@ -146,7 +214,7 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 						IPAddress:    ipAddress,
 						UserAgent:    userAgent,
 					})
-					return nil, errors.New("invalid credentials")
+					return nil, false, ErrInvalidCredentials
 				}
 
 				// LDAP 认证成功，恢复/转换用户
@@ -160,18 +228,19 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 				user.Email = ldapUser.Email
 				user.Status = model.StatusActive
 				user.IsLDAPUser = true
+				user.IsServiceAccount = false
 				user.Role = model.RoleViewer // 确保转换后的用户使用默认 LDAP 角色
 
 				if err := s.db.Unscoped().Save(&user).Error; err != nil {
 					s.logger.Errorf("Failed to restore/convert soft-deleted user %s: %v", req.Username, err)
-					return nil, err
+					return nil, false, err
 				}
 				s.logger.Infof("Successfully restored/converted user %s to LDAP user (ID: %d)", req.Username, user.ID)
 				isLDAPAuth = true
 			} else {
 				// LDAP 未启用，拒绝软删除用户登录
 				s.logger.Warningf("Attempt to login with soft-deleted user %s, but LDAP is disabled", req.Username)
-				return nil, errors.New("account not found")
+				return nil, false, ErrAccountNotFound
 			}
 		} else {
 			// 用户正常存在，检查是否为 LDAP 用户
@@ -193,7 +262,7 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 						IPAddress:    ipAddress,
 						UserAgent:    userAgent,
 					})
-					return nil, errors.New("invalid credentials")
+					return nil, false, ErrInvalidCredentials
 				}
 
 				// LDAP 认证成功，同步用户信息
@@ -230,7 +299,22 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 			IPAddress:    ipAddress,
 			UserAgent:    userAgent,
 		})
-		return nil, errors.New("account is inactive")
+		return nil, false, errors.New("account is inactive")
+	}
+
+	// 服务账号只能通过访问令牌调用 API
+	if user.IsServiceAccount {
+		s.audit.Log(audit.LogRequest{
+			UserID:       user.ID,
+			Action:       model.ActionLogin,
+			ResourceType: model.ResourceUser,
+			Details:      fmt.Sprintf("Interactive login attempt for service account: %s", user.Username),
+			Status:       model.AuditStatusFailed,
+			ErrorMsg:     "Service accounts cannot log in interactively",
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+		return nil, false, errors.New("service accounts cannot log in interactively")
 	}
 
 	if !isLDAPAuth {
@@ -245,30 +329,30 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 				IPAddress:    ipAddress,
 				UserAgent:    userAgent,
 			})
-			return nil, errors.New("invalid credentials")
+			return nil, false, ErrInvalidCredentials
 		}
 	}
 
-	expiresAt := time.Now().Add(time.Duration(s.jwtCfg.ExpireTime) * time.Second)
-
-	token, err := s.generateToken(user.ID, user.Username, user.Role, "access", expiresAt)
-	if err != nil {
-		return nil, err
-	}
+	return &user, isLDAPAuth, nil
+}
 
-	refreshToken, err := s.generateToken(user.ID, user.Username, user.Role, "refresh", time.Now().Add(7*24*time.Hour))
+// completeLogin 认证全部通过后创建会话、更新最后登录时间并记录审计日志
+func (s *Service) completeLogin(user *model.User, method, ipAddress, userAgent string) (*LoginResponse, error) {
+	resp, err := s.createSession(user, ipAddress, userAgent)
 	if err != nil {
+		s.logger.Errorf("Failed to create session for user %s: %v", user.Username, err)
 		return nil, err
 	}
 
 	now := time.Now()
-	s.db.Model(&user).Update("last_login", now)
+	s.db.Model(user).Update("last_login", now)
+	s.resetLoginFailures(user.Username)
 
 	// 记录成功登录的审计日志
 	loginDetails := fmt.Sprintf("Successful login for user: %s", user.Username)
-	if isLDAPAuth {
-		loginDetails = fmt.Sprintf("Successful LDAP login for user: %s", user.Username)
-		s.logger.Infof("User %s successfully logged in via LDAP", user.Username)
+	if method != "" {
+		loginDetails = fmt.Sprintf("Successful %s login for user: %s", method, user.Username)
+		s.logger.Infof("User %s successfully logged in via %s", user.Username, method)
 	} else {
 		s.logger.Infof("User %s successfully logged in via local authentication", user.Username)
 	}
@@ -283,64 +367,41 @@ func (s *Service) Login(req LoginRequest, ipAddress, userAgent string) (*LoginRe
 		UserAgent:    userAgent,
 	})
 
-	return &LoginResponse{
-		Token:        token,
-		RefreshToken: refreshToken,
-		User:         user,
-		ExpiresAt:    expiresAt,
-	}, nil
+	return resp, nil
 }
 
-func (s *Service) RefreshToken(req RefreshTokenRequest) (*LoginResponse, error) {
-	claims, err := s.validateToken(req.RefreshToken)
+// ValidateToken 校验访问令牌的签名、有效期以及所属会话是否已被撤销
+func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := s.parseToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims.Type != "refresh" {
+	// 只接受访问令牌；刷新令牌和 MFA 令牌不能用于调用 API
+	if claims.Type != "access" {
 		return nil, errors.New("invalid token type")
 	}
 
-	var user model.User
-	if err := s.db.First(&user, claims.UserID).Error; err != nil {
-		return nil, err
-	}
-
-	if user.Status != model.StatusActive {
-		return nil, errors.New("account is inactive")
-	}
-
-	expiresAt := time.Now().Add(time.Duration(s.jwtCfg.ExpireTime) * time.Second)
-
-	token, err := s.generateToken(user.ID, user.Username, user.Role, "access", expiresAt)
-	if err != nil {
-		return nil, err
+	// 未绑定会话的旧令牌无法撤销，要求重新登录
+	if claims.SessionID == "" {
+		return nil, errors.New("session expired, please login again")
 	}
-
-	refreshToken, err := s.generateToken(user.ID, user.Username, user.Role, "refresh", time.Now().Add(7*24*time.Hour))
-	if err != nil {
-		return nil, err
+	if !s.IsSessionActive(claims.SessionID) {
+		return nil, errors.New("session has been revoked")
 	}
 
-	return &LoginResponse{
-		Token:        token,
-		RefreshToken: refreshToken,
-		User:         user,
-		ExpiresAt:    expiresAt,
-	}, nil
-}
-
-func (s *Service) ValidateToken(tokenString string) (*Claims, error) {
-	return s.validateToken(tokenString)
+	return claims, nil
 }
 
-func (s *Service) generateToken(userID uint, username string, role model.UserRole, tokenType string, expiresAt time.Time) (string, error) {
+func (s *Service) generateToken(userID uint, username string, role model.UserRole, tokenType, sessionID, tokenID string, expiresAt time.Time) (string, error) {
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Role:     role,
-		Type:     tokenType,
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		Type:      tokenType,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -350,13 +411,14 @@ func (s *Service) generateToken(userID uint, username string, role model.UserRol
 	return token.SignedString([]byte(s.jwtCfg.Secret))
 }
 
-func (s *Service) validateToken(tokenString string) (*Claims, error) {
+// parseToken 只校验令牌签名和有效期
+func (s *Service) parseToken(tokenString string, opts ...jwt.ParserOption) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return []byte(s.jwtCfg.Secret), nil
-	})
+	}, opts...)
 
 	if err != nil {
 		return nil, err
@@ -409,8 +471,8 @@ func (s *Service) UpdateProfile(userID uint, req UpdateProfileRequest) (*model.U
 	return &user, nil
 }
 
-// ChangePassword 修改用户密码
-func (s *Service) ChangePassword(userID uint, req ChangePasswordRequest) error {
+// ChangePassword 修改用户密码，成功后当前会话以外的其他会话全部下线
+func (s *Service) ChangePassword(userID uint, sessionID string, req ChangePasswordRequest) error {
 	var user model.User
 	if err := s.db.First(&user, userID).Error; err != nil {
 		return err
@@ -436,6 +498,8 @@ func (s *Service) ChangePassword(userID uint, req ChangePasswordRequest) error {
 		return err
 	}
 
+	s.revokeSessions(s.db.Where("user_id = ? AND session_id <> ?", userID, sessionID), model.SessionRevokedPassword)
+
 	s.audit.Log(audit.LogRequest{
 		UserID:       userID,
 		Action:       model.ActionUpdate,
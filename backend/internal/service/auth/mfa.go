@@ -0,0 +1,483 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"kube-node-manager/pkg/totp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// mfaTokenTTL 密码校验通过后完成第二因素验证的时限
+	mfaTokenTTL = 5 * time.Minute
+	// mfaClockSkew 允许的验证器时钟偏差（时间步）
+	mfaClockSkew = 1
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+)
+
+// MFALoginRequest 登录第二步请求
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP 验证码或恢复码
+}
+
+// MFALoginSetupRequest 登录过程中绑定 MFA 的请求
+type MFALoginSetupRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// MFACodeRequest 需要验证码确认的 MFA 操作请求
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFASetupResponse 绑定 MFA 时返回的密钥
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAStatus 用户 MFA 状态
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"` // 角色要求启用 MFA
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// mfaRequiredFor 判断角色是否必须启用 MFA
+func (s *Service) mfaRequiredFor(role model.UserRole) bool {
+	for _, r := range s.securityCfg.MFARequiredRoles {
+		if model.UserRole(r) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// getMFA 获取用户的 MFA 配置，不存在时返回 nil
+func (s *Service) getMFA(userID uint) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	if err := s.db.Where("user_id = ?", userID).First(&mfa).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// beginMFA 密码校验通过后判断是否需要第二因素，需要时返回携带 MFA 令牌的响应
+func (s *Service) beginMFA(user *model.User) (*LoginResponse, error) {
+	mfa, err := s.getMFA(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := mfa != nil && mfa.Enabled
+	if !enabled && !s.mfaRequiredFor(user.Role) {
+		return nil, nil
+	}
+
+	tokenID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	mfaToken, err := s.generateToken(user.ID, user.Username, user.Role, "mfa", "", tokenID, time.Now().Add(mfaTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		User:             *user,
+		MFARequired:      enabled,
+		MFASetupRequired: !enabled,
+		MFAToken:         mfaToken,
+	}, nil
+}
+
+// userFromMFAToken 校验 MFA 令牌并返回对应的用户
+func (s *Service) userFromMFAToken(mfaToken string) (*model.User, error) {
+	claims, err := s.parseToken(mfaToken)
+	if err != nil {
+		return nil, errors.New("mfa token expired, please login again")
+	}
+	if claims.Type != "mfa" {
+		return nil, errors.New("invalid token type")
+	}
+
+	var user model.User
+	if err := s.db.First(&user, claims.UserID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Status != model.StatusActive {
+		return nil, errors.New("account is inactive")
+	}
+	return &user, nil
+}
+
+// SetupMFA 为用户生成新的 TOTP 密钥，验证通过 EnableMFA 后才生效
+func (s *Service) SetupMFA(userID uint) (*MFASetupResponse, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.IsServiceAccount {
+		return nil, errors.New("service accounts cannot enable MFA")
+	}
+
+	mfa, err := s.getMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		return nil, errors.New("MFA is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.mfaEncryptor.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt mfa secret: %w", err)
+	}
+
+	if mfa == nil {
+		mfa = &model.UserMFA{UserID: userID}
+	}
+	mfa.Secret = encrypted
+	mfa.LastUsedStep = 0
+	if err := s.db.Save(mfa).Error; err != nil {
+		return nil, fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+
+	return &MFASetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.securityCfg.MFAIssuer, user.Username, secret),
+	}, nil
+}
+
+// SetupMFAForLogin 登录过程中为角色要求 MFA 但尚未绑定的用户生成密钥
+func (s *Service) SetupMFAForLogin(req MFALoginSetupRequest) (*MFASetupResponse, error) {
+	user, err := s.userFromMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.SetupMFA(user.ID)
+}
+
+// EnableMFA 校验验证码后启用 MFA，返回一次性展示的恢复码
+func (s *Service) EnableMFA(userID uint, code string) ([]string, error) {
+	mfa, err := s.getMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, errors.New("MFA setup has not been started")
+	}
+	if mfa.Enabled {
+		return nil, errors.New("MFA is already enabled")
+	}
+
+	if ok, _, err := s.verifyMFACode(mfa, code, false); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("invalid verification code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.db.Model(mfa).Updates(map[string]interface{}{
+		"enabled":        true,
+		"enabled_at":     now,
+		"recovery_codes": hashes,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       userID,
+		Action:       model.ActionUpdate,
+		ResourceType: model.ResourceUser,
+		Details:      "Enabled TOTP multi-factor authentication",
+		Status:       model.AuditStatusSuccess,
+	})
+	return codes, nil
+}
+
+// CompleteMFALogin 登录第二步：校验 TOTP 验证码或恢复码后签发令牌
+// 尚未绑定 MFA 的用户（角色要求）在这一步完成绑定，响应中携带恢复码
+func (s *Service) CompleteMFALogin(req MFALoginRequest, ipAddress, userAgent string) (*LoginResponse, error) {
+	user, err := s.userFromMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkLoginThrottle(user.Username, ipAddress); err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.getMFA(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, errors.New("MFA setup has not been started")
+	}
+
+	// 登录时完成绑定
+	if !mfa.Enabled {
+		codes, err := s.EnableMFA(user.ID, req.Code)
+		if err != nil {
+			s.recordLoginFailure(user.Username, ipAddress, userAgent)
+			return nil, err
+		}
+		resp, err := s.completeLogin(user, "MFA", ipAddress, userAgent)
+		if err != nil {
+			return nil, err
+		}
+		resp.RecoveryCodes = codes
+		return resp, nil
+	}
+
+	ok, usedRecovery, err := s.verifyMFACode(mfa, req.Code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.audit.Log(audit.LogRequest{
+			UserID:       user.ID,
+			Action:       model.ActionLogin,
+			ResourceType: model.ResourceUser,
+			Details:      fmt.Sprintf("Failed MFA verification for user: %s", user.Username),
+			Status:       model.AuditStatusFailed,
+			ErrorMsg:     "Invalid MFA code",
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+		s.recordLoginFailure(user.Username, ipAddress, userAgent)
+		return nil, ErrInvalidCredentials
+	}
+
+	method := "MFA"
+	if usedRecovery {
+		method = "MFA recovery code"
+	}
+	return s.completeLogin(user, method, ipAddress, userAgent)
+}
+
+// verifyMFACode 校验 TOTP 验证码，allowRecovery 为 true 时也接受恢复码（使用后失效）
+// 返回是否通过以及是否使用了恢复码
+func (s *Service) verifyMFACode(mfa *model.UserMFA, code string, allowRecovery bool) (bool, bool, error) {
+	secret, err := s.mfaEncryptor.Decrypt(mfa.Secret)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to decrypt mfa secret: %w", err)
+	}
+
+	if step, ok := totp.Validate(secret, code, time.Now(), mfaClockSkew); ok {
+		// 条件更新保证同一验证码在所有副本上只能使用一次
+		result := s.db.Model(&model.UserMFA{}).
+			Where("id = ? AND last_used_step < ?", mfa.ID, step).
+			Update("last_used_step", step)
+		if result.Error != nil {
+			return false, false, result.Error
+		}
+		return result.RowsAffected > 0, false, nil
+	}
+
+	if !allowRecovery {
+		return false, false, nil
+	}
+
+	hash := hashRecoveryCode(code)
+	remaining := make(model.StringArray, 0, len(mfa.RecoveryCodes))
+	found := false
+	for _, h := range mfa.RecoveryCodes {
+		if !found && h == hash {
+			found = true
+			continue
+		}
+		remaining = append(remaining, h)
+	}
+	if !found {
+		return false, false, nil
+	}
+
+	// 条件更新保证同一恢复码在多个副本上并发使用时只有一次能成功
+	// 恢复码已被其他请求使用或重新生成时不更新任何记录，按校验失败处理
+	result := s.db.Model(&model.UserMFA{}).
+		Where("id = ? AND recovery_codes = ?", mfa.ID, mfa.RecoveryCodes).
+		Update("recovery_codes", remaining)
+	if result.Error != nil {
+		return false, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, false, nil
+	}
+	mfa.RecoveryCodes = remaining
+	s.logger.Warningf("Recovery code used by user %d, %d remaining", mfa.UserID, len(remaining))
+	return true, true, nil
+}
+
+// DisableMFA 关闭 MFA，需要验证码确认；角色要求 MFA 时不允许关闭
+func (s *Service) DisableMFA(userID uint, code string) error {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+	if s.mfaRequiredFor(user.Role) {
+		return fmt.Errorf("MFA is required for role %s", user.Role)
+	}
+
+	mfa, err := s.getMFA(userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return errors.New("MFA is not enabled")
+	}
+
+	if ok, _, err := s.verifyMFACode(mfa, code, true); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("invalid verification code")
+	}
+
+	if err := s.db.Delete(mfa).Error; err != nil {
+		return fmt.Errorf("failed to disable mfa: %w", err)
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       userID,
+		Action:       model.ActionUpdate,
+		ResourceType: model.ResourceUser,
+		Details:      "Disabled TOTP multi-factor authentication",
+		Status:       model.AuditStatusSuccess,
+	})
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+func (s *Service) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	mfa, err := s.getMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Enabled {
+		return nil, errors.New("MFA is not enabled")
+	}
+
+	if ok, _, err := s.verifyMFACode(mfa, code, false); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, errors.New("invalid verification code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(mfa).Update("recovery_codes", hashes).Error; err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       userID,
+		Action:       model.ActionUpdate,
+		ResourceType: model.ResourceUser,
+		Details:      "Regenerated MFA recovery codes",
+		Status:       model.AuditStatusSuccess,
+	})
+	return codes, nil
+}
+
+// GetMFAStatus 获取用户 MFA 状态
+func (s *Service) GetMFAStatus(userID uint) (*MFAStatus, error) {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	status := &MFAStatus{Required: s.mfaRequiredFor(user.Role)}
+	mfa, err := s.getMFA(userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Enabled {
+		status.Enabled = true
+		status.EnabledAt = mfa.EnabledAt
+		status.RecoveryCodesRemaining = len(mfa.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// ResetMFA 管理员重置用户的 MFA（例如丢失手机且恢复码用尽），同时强制其全部会话下线
+func (s *Service) ResetMFA(userID, operatorID uint, ipAddress, userAgent string) error {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	result := s.db.Where("user_id = ?", userID).Delete(&model.UserMFA{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to reset mfa: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("MFA configuration not found for this user")
+	}
+
+	if _, err := s.RevokeUserSessions(userID, model.SessionRevokedMFAReset); err != nil {
+		s.logger.Errorf("Failed to revoke sessions after MFA reset for user %s: %v", user.Username, err)
+	}
+
+	s.logger.Warningf("MFA reset for user %s by user %d", user.Username, operatorID)
+	s.audit.Log(audit.LogRequest{
+		UserID:       operatorID,
+		Action:       model.ActionUpdate,
+		ResourceType: model.ResourceUser,
+		Details:      fmt.Sprintf("Reset MFA for user: %s (ID: %d)", user.Username, user.ID),
+		Status:       model.AuditStatusSuccess,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+	return nil
+}
+
+// generateRecoveryCodes 生成恢复码明文及其摘要
+func generateRecoveryCodes() ([]string, model.StringArray, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make(model.StringArray, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := hex.EncodeToString(buf)
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode 计算恢复码摘要，忽略大小写、空格和连字符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashAPIToken(normalized)
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/totp"
+)
+
+// currentCode 计算密钥当前时间步的验证码
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.CodeAt(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatalf("totp code: %v", err)
+	}
+	return code
+}
+
+// invalidCode 返回在允许的时钟偏差内都不匹配的验证码
+func invalidCode(t *testing.T, secret string) string {
+	t.Helper()
+	for i := 0; i < 10; i++ {
+		code := strings.Repeat(fmt.Sprint(i), totp.Digits)
+		if _, ok := totp.Validate(secret, code, time.Now(), mfaClockSkew); !ok {
+			return code
+		}
+	}
+	t.Fatal("no invalid code found")
+	return ""
+}
+
+// enableMFA 为用户绑定并启用 MFA，返回密钥和恢复码
+func enableMFA(t *testing.T, s *Service, user *model.User) (string, []string) {
+	t.Helper()
+	setup, err := s.SetupMFA(user.ID)
+	if err != nil {
+		t.Fatalf("setup mfa: %v", err)
+	}
+	codes, err := s.EnableMFA(user.ID, currentCode(t, setup.Secret))
+	if err != nil {
+		t.Fatalf("enable mfa: %v", err)
+	}
+	return setup.Secret, codes
+}
+
+// TestCompleteMFALoginEnrollment 测试角色要求 MFA 的用户在登录过程中完成绑定
+func TestCompleteMFALoginEnrollment(t *testing.T) {
+	s := newTestService(t)
+	s.securityCfg.MFARequiredRoles = []string{string(model.RoleAdmin)}
+	user := createTestUser(t, s, "admin")
+	s.db.Model(user).Update("role", model.RoleAdmin)
+	user.Role = model.RoleAdmin
+
+	begin, err := s.beginMFA(user)
+	if err != nil {
+		t.Fatalf("begin mfa: %v", err)
+	}
+	if begin == nil || !begin.MFASetupRequired || begin.MFARequired || begin.Token != "" {
+		t.Fatalf("expected setup-required response without tokens, got %+v", begin)
+	}
+
+	if _, err := s.CompleteMFALogin(MFALoginRequest{MFAToken: begin.MFAToken, Code: "123456"}, "10.0.0.1", "test-agent"); err == nil {
+		t.Error("completing login before setup should fail")
+	}
+
+	setup, err := s.SetupMFAForLogin(MFALoginSetupRequest{MFAToken: begin.MFAToken})
+	if err != nil {
+		t.Fatalf("setup mfa for login: %v", err)
+	}
+
+	if _, err := s.CompleteMFALogin(MFALoginRequest{MFAToken: begin.MFAToken, Code: invalidCode(t, setup.Secret)}, "10.0.0.1", "test-agent"); err == nil {
+		t.Fatal("invalid code should not complete enrollment")
+	}
+	if mfa, _ := s.getMFA(user.ID); mfa == nil || mfa.Enabled {
+		t.Fatal("mfa should stay disabled after an invalid code")
+	}
+
+	code := currentCode(t, setup.Secret)
+	resp, err := s.CompleteMFALogin(MFALoginRequest{MFAToken: begin.MFAToken, Code: code}, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("complete mfa login: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" {
+		t.Error("enrollment login should issue tokens")
+	}
+	if len(resp.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("recovery codes = %d, want %d", len(resp.RecoveryCodes), recoveryCodeCount)
+	}
+	if _, err := s.ValidateToken(resp.Token); err != nil {
+		t.Errorf("issued token should be valid: %v", err)
+	}
+
+	status, err := s.GetMFAStatus(user.ID)
+	if err != nil {
+		t.Fatalf("mfa status: %v", err)
+	}
+	if !status.Enabled || !status.Required || status.RecoveryCodesRemaining != recoveryCodeCount {
+		t.Errorf("unexpected mfa status %+v", status)
+	}
+
+	// 绑定后再次登录需要验证码，同一验证码不能重放
+	begin, err = s.beginMFA(user)
+	if err != nil {
+		t.Fatalf("begin mfa: %v", err)
+	}
+	if !begin.MFARequired || begin.MFASetupRequired {
+		t.Fatalf("expected mfa-required response, got %+v", begin)
+	}
+	if _, err := s.CompleteMFALogin(MFALoginRequest{MFAToken: begin.MFAToken, Code: code}, "10.0.0.1", "test-agent"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("replayed code error = %v, want invalid credentials", err)
+	}
+	if _, err := s.CompleteMFALogin(MFALoginRequest{MFAToken: resp.Token, Code: code}, "10.0.0.1", "test-agent"); err == nil {
+		t.Error("access token should not be accepted as mfa token")
+	}
+}
+
+// TestRecoveryCodeConsumption 测试恢复码只能使用一次，并发使用同一恢复码只有一次成功
+func TestRecoveryCodeConsumption(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "alice")
+	_, codes := enableMFA(t, s, user)
+
+	begin, err := s.beginMFA(user)
+	if err != nil {
+		t.Fatalf("begin mfa: %v", err)
+	}
+
+	// 恢复码不区分大小写，可以省略连字符
+	code := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if _, err := s.CompleteMFALogin(MFALoginRequest{MFAToken: begin.MFAToken, Code: code}, "10.0.0.1", "test-agent"); err != nil {
+		t.Fatalf("login with recovery code: %v", err)
+	}
+	if _, err := s.CompleteMFALogin(MFALoginRequest{MFAToken: begin.MFAToken, Code: codes[0]}, "10.0.0.1", "test-agent"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("reused recovery code error = %v, want invalid credentials", err)
+	}
+
+	// 两个副本读取到同一份恢复码后同时使用同一个恢复码
+	first, _ := s.getMFA(user.ID)
+	second, _ := s.getMFA(user.ID)
+	if ok, usedRecovery, err := s.verifyMFACode(first, codes[1], true); err != nil || !ok || !usedRecovery {
+		t.Fatalf("first use = %v, %v, %v, want recovery code accepted", ok, usedRecovery, err)
+	}
+	if ok, _, err := s.verifyMFACode(second, codes[1], true); err != nil || ok {
+		t.Errorf("second use = %v, %v, want recovery code rejected", ok, err)
+	}
+
+	// 不允许使用恢复码的操作不会消耗恢复码
+	current, _ := s.getMFA(user.ID)
+	if ok, _, _ := s.verifyMFACode(current, codes[2], false); ok {
+		t.Error("recovery code should not be accepted when recovery is not allowed")
+	}
+
+	status, err := s.GetMFAStatus(user.ID)
+	if err != nil {
+		t.Fatalf("mfa status: %v", err)
+	}
+	if status.RecoveryCodesRemaining != recoveryCodeCount-2 {
+		t.Errorf("recovery codes remaining = %d, want %d", status.RecoveryCodesRemaining, recoveryCodeCount-2)
+	}
+}
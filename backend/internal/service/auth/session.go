@@ -0,0 +1,412 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// refreshTokenTTL 刷新令牌有效期，每次轮换后重新计算
+	refreshTokenTTL = 7 * 24 * time.Hour
+	// sessionCacheTTL 会话状态本地缓存时间，其他副本上的撤销最迟在该时间后生效
+	sessionCacheTTL = 10 * time.Second
+	// sessionRetention 过期或撤销的会话保留时间
+	sessionRetention = 30 * 24 * time.Hour
+)
+
+// sessionCacheEntry 会话状态缓存
+type sessionCacheEntry struct {
+	active    bool
+	checkedAt time.Time
+}
+
+// SessionInfo 会话信息
+type SessionInfo struct {
+	model.UserSession
+	Current bool `json:"current"` // 是否为发起请求的会话
+}
+
+// LogoutRequest 退出登录请求
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ListSessionsRequest 会话列表请求
+type ListSessionsRequest struct {
+	UserID         uint `form:"user_id"`
+	IncludeRevoked bool `form:"include_revoked"`
+}
+
+// newTokenID 生成随机的会话或令牌标识
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createSession 为登录用户创建会话并签发令牌
+func (s *Service) createSession(user *model.User, ipAddress, userAgent string) (*LoginResponse, error) {
+	sessionID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	refreshJTI, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &model.UserSession{
+		SessionID:  sessionID,
+		UserID:     user.ID,
+		RefreshJTI: refreshJTI,
+		IPAddress:  ipAddress,
+		UserAgent:  truncate(userAgent, 512),
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+	if err := s.db.Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	// 顺带清理很久以前过期或撤销的会话
+	s.db.Where("expires_at < ? OR revoked_at < ?", now.Add(-sessionRetention), now.Add(-sessionRetention)).
+		Delete(&model.UserSession{})
+
+	return s.issueTokens(user, session)
+}
+
+// issueTokens 为会话签发访问令牌和刷新令牌
+func (s *Service) issueTokens(user *model.User, session *model.UserSession) (*LoginResponse, error) {
+	expiresAt := time.Now().Add(time.Duration(s.jwtCfg.ExpireTime) * time.Second)
+
+	token, err := s.generateToken(user.ID, user.Username, user.Role, "access", session.SessionID, "", expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.generateToken(user.ID, user.Username, user.Role, "refresh", session.SessionID, session.RefreshJTI, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         *user,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// RefreshToken 轮换刷新令牌
+// 每个刷新令牌只能使用一次；已轮换的令牌再次出现说明令牌可能泄露，整个会话会被撤销
+func (s *Service) RefreshToken(req RefreshTokenRequest) (*LoginResponse, error) {
+	claims, err := s.parseToken(req.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != "refresh" {
+		return nil, errors.New("invalid token type")
+	}
+	if claims.SessionID == "" || claims.ID == "" {
+		return nil, errors.New("session expired, please login again")
+	}
+
+	var session model.UserSession
+	if err := s.db.Where("session_id = ?", claims.SessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("session not found")
+		}
+		return nil, err
+	}
+	if !session.IsActive() {
+		return nil, errors.New("session has been revoked")
+	}
+
+	if session.RefreshJTI != claims.ID {
+		s.revokeReusedSession(&session)
+		return nil, errors.New("refresh token has already been used, session revoked")
+	}
+
+	var user model.User
+	if err := s.db.First(&user, claims.UserID).Error; err != nil {
+		return nil, err
+	}
+
+	if user.Status != model.StatusActive {
+		return nil, errors.New("account is inactive")
+	}
+
+	newJTI, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	// 条件更新保证同一个刷新令牌在多个副本上并发使用时只有一次能成功
+	now := time.Now()
+	expiresAt := now.Add(refreshTokenTTL)
+	result := s.db.Model(&model.UserSession{}).
+		Where("id = ? AND refresh_jti = ? AND revoked_at IS NULL", session.ID, claims.ID).
+		Updates(map[string]interface{}{
+			"refresh_jti":  newJTI,
+			"last_used_at": now,
+			"expires_at":   expiresAt,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		s.revokeReusedSession(&session)
+		return nil, errors.New("refresh token has already been used, session revoked")
+	}
+
+	session.RefreshJTI = newJTI
+	session.LastUsedAt = now
+	session.ExpiresAt = expiresAt
+	return s.issueTokens(&user, &session)
+}
+
+// revokeReusedSession 检测到刷新令牌重放时撤销会话并记录审计日志
+func (s *Service) revokeReusedSession(session *model.UserSession) {
+	s.logger.Warningf("Refresh token reuse detected for session %s of user %d, revoking session", session.SessionID, session.UserID)
+	s.revokeSessions(s.db.Where("session_id = ?", session.SessionID), model.SessionRevokedTokenReuse)
+	s.audit.Log(audit.LogRequest{
+		UserID:       session.UserID,
+		Action:       model.ActionLogout,
+		ResourceType: model.ResourceUser,
+		Details:      fmt.Sprintf("Session %s revoked: refresh token reuse detected", session.SessionID),
+		Status:       model.AuditStatusFailed,
+		ErrorMsg:     "refresh token reuse",
+	})
+}
+
+// IsSessionActive 检查会话是否有效，结果在本地短暂缓存
+func (s *Service) IsSessionActive(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+
+	s.cacheMutex.RLock()
+	entry, exists := s.sessionCache[sessionID]
+	s.cacheMutex.RUnlock()
+	if exists && time.Since(entry.checkedAt) < sessionCacheTTL {
+		return entry.active
+	}
+
+	var session model.UserSession
+	active := false
+	if err := s.db.Select("revoked_at", "expires_at").Where("session_id = ?", sessionID).First(&session).Error; err == nil {
+		active = session.IsActive()
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		// 数据库暂时不可用时沿用上一次的检查结果，避免所有请求被拒绝
+		s.logger.Errorf("Failed to check session %s: %v", sessionID, err)
+		return exists && entry.active
+	}
+
+	now := time.Now()
+	s.cacheMutex.Lock()
+	s.pruneSessionCacheLocked(now)
+	s.sessionCache[sessionID] = sessionCacheEntry{active: active, checkedAt: now}
+	s.cacheMutex.Unlock()
+	return active
+}
+
+// pruneSessionCacheLocked 清理已过期的会话缓存，每个缓存周期最多执行一次，调用方需持有写锁
+func (s *Service) pruneSessionCacheLocked(now time.Time) {
+	if now.Sub(s.sessionCachePrunedAt) < sessionCacheTTL {
+		return
+	}
+	for id, entry := range s.sessionCache {
+		if now.Sub(entry.checkedAt) >= sessionCacheTTL {
+			delete(s.sessionCache, id)
+		}
+	}
+	s.sessionCachePrunedAt = now
+}
+
+// revokeSessions 撤销查询条件匹配的有效会话，返回撤销数量
+func (s *Service) revokeSessions(query *gorm.DB, reason string) (int64, error) {
+	var sessionIDs []string
+	if err := query.Session(&gorm.Session{}).Model(&model.UserSession{}).
+		Where("revoked_at IS NULL").Pluck("session_id", &sessionIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	if len(sessionIDs) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	result := s.db.Model(&model.UserSession{}).
+		Where("session_id IN ? AND revoked_at IS NULL", sessionIDs).
+		Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"revoked_reason": reason,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", result.Error)
+	}
+
+	// 本副本立即生效，其他副本在缓存过期后生效
+	s.cacheMutex.Lock()
+	s.pruneSessionCacheLocked(now)
+	for _, id := range sessionIDs {
+		s.sessionCache[id] = sessionCacheEntry{active: false, checkedAt: now}
+	}
+	s.cacheMutex.Unlock()
+
+	return result.RowsAffected, nil
+}
+
+// Logout 退出当前会话
+func (s *Service) Logout(sessionID string, userID uint, ipAddress, userAgent string) error {
+	if _, err := s.revokeSessions(s.db.Where("session_id = ? AND user_id = ?", sessionID, userID), model.SessionRevokedLogout); err != nil {
+		return err
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       userID,
+		Action:       model.ActionLogout,
+		ResourceType: model.ResourceUser,
+		Details:      "User logged out",
+		Status:       model.AuditStatusSuccess,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+	return nil
+}
+
+// LogoutByToken 根据访问令牌或刷新令牌退出其所属会话，令牌过期也可以退出
+func (s *Service) LogoutByToken(tokenString, ipAddress, userAgent string) error {
+	claims, err := s.parseToken(tokenString, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return err
+	}
+	if claims.SessionID == "" {
+		return nil
+	}
+	return s.Logout(claims.SessionID, claims.UserID, ipAddress, userAgent)
+}
+
+// LogoutAll 退出用户的全部会话
+func (s *Service) LogoutAll(userID uint, ipAddress, userAgent string) (int64, error) {
+	count, err := s.revokeSessions(s.db.Where("user_id = ?", userID), model.SessionRevokedLogoutAll)
+	if err != nil {
+		return 0, err
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       userID,
+		Action:       model.ActionLogout,
+		ResourceType: model.ResourceUser,
+		Details:      fmt.Sprintf("User logged out of all sessions (%d revoked)", count),
+		Status:       model.AuditStatusSuccess,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+	})
+	return count, nil
+}
+
+// RevokeUserSessions 撤销用户的全部会话（用户被禁用、删除时调用）
+func (s *Service) RevokeUserSessions(userID uint, reason string) (int64, error) {
+	count, err := s.revokeSessions(s.db.Where("user_id = ?", userID), reason)
+	if err != nil {
+		s.logger.Errorf("Failed to revoke sessions of user %d: %v", userID, err)
+		return 0, err
+	}
+	if count > 0 {
+		s.logger.Infof("Revoked %d sessions of user %d (%s)", count, userID, reason)
+	}
+	return count, nil
+}
+
+// ListSessions 列出会话，userID 为 0 时列出全部用户的会话
+func (s *Service) ListSessions(req ListSessionsRequest, currentSessionID string) ([]SessionInfo, error) {
+	query := s.db.Preload("User").Order("last_used_at DESC")
+	if req.UserID != 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if !req.IncludeRevoked {
+		query = query.Where("revoked_at IS NULL AND expires_at > ?", time.Now())
+	}
+
+	var sessions []model.UserSession
+	if err := query.Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	result := make([]SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, SessionInfo{
+			UserSession: session,
+			Current:     session.SessionID == currentSessionID,
+		})
+	}
+	return result, nil
+}
+
+// RevokeSession 撤销指定会话；userID 不为 0 时只能撤销该用户自己的会话
+func (s *Service) RevokeSession(sessionID string, userID uint, operatorID uint) error {
+	query := s.db.Where("session_id = ?", sessionID)
+	reason := model.SessionRevokedByAdmin
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+		reason = model.SessionRevokedLogout
+	}
+
+	var session model.UserSession
+	if err := query.Session(&gorm.Session{}).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("session not found")
+		}
+		return err
+	}
+
+	if _, err := s.revokeSessions(query, reason); err != nil {
+		return err
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       operatorID,
+		Action:       model.ActionLogout,
+		ResourceType: model.ResourceUser,
+		Details:      fmt.Sprintf("Revoked session %s of user %d", sessionID, session.UserID),
+		Status:       model.AuditStatusSuccess,
+	})
+	return nil
+}
+
+// RevokeAllSessionsByAdmin 管理员强制用户下线
+func (s *Service) RevokeAllSessionsByAdmin(userID uint, operatorID uint) (int64, error) {
+	count, err := s.revokeSessions(s.db.Where("user_id = ?", userID), model.SessionRevokedByAdmin)
+	if err != nil {
+		return 0, err
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       operatorID,
+		Action:       model.ActionLogout,
+		ResourceType: model.ResourceUser,
+		Details:      fmt.Sprintf("Revoked all sessions of user %d (%d revoked)", userID, count),
+		Status:       model.AuditStatusSuccess,
+	})
+	return count, nil
+}
+
+// truncate 截断字符串到指定长度
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/config"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.User{}, &model.Cluster{}, &model.UserSession{}, &model.UserMFA{}, &model.LoginThrottle{}, &model.AuditLog{}, &model.AuditChainState{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	log := logger.NewLogger()
+	auditService := audit.NewService(db, log, &audit.Config{})
+	jwtCfg := config.JWTConfig{Secret: "test-secret", ExpireTime: 3600}
+	return NewService(db, log, jwtCfg, config.SecurityConfig{}, nil, auditService)
+}
+
+func createTestUser(t *testing.T, s *Service, username string) *model.User {
+	t.Helper()
+	user := &model.User{Username: username, Email: username + "@example.com", Password: "x", Role: model.RoleUser, Status: model.StatusActive}
+	if err := s.db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func getSession(t *testing.T, s *Service, token string) *model.UserSession {
+	t.Helper()
+	claims, err := s.parseToken(token)
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	var session model.UserSession
+	if err := s.db.Where("session_id = ?", claims.SessionID).First(&session).Error; err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	return &session
+}
+
+// TestRefreshTokenRotation 测试刷新令牌轮换后旧令牌失效，重放旧令牌会撤销整个会话
+func TestRefreshTokenRotation(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "alice")
+
+	login, err := s.createSession(user, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.ValidateToken(login.Token); err != nil {
+		t.Fatalf("access token should be valid: %v", err)
+	}
+	if _, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: login.Token}); err == nil {
+		t.Error("access token should not be accepted as refresh token")
+	}
+
+	rotated, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if rotated.RefreshToken == login.RefreshToken {
+		t.Fatal("refresh token should be rotated")
+	}
+	if _, err := s.ValidateToken(rotated.Token); err != nil {
+		t.Fatalf("rotated access token should be valid: %v", err)
+	}
+	session := getSession(t, s, rotated.RefreshToken)
+	claims, _ := s.parseToken(rotated.RefreshToken)
+	if session.RefreshJTI != claims.ID {
+		t.Errorf("session refresh jti = %s, want %s", session.RefreshJTI, claims.ID)
+	}
+
+	// 重放已轮换的刷新令牌，会话被撤销，新令牌也随之失效
+	if _, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: login.RefreshToken}); err == nil {
+		t.Fatal("replayed refresh token should be rejected")
+	}
+	session = getSession(t, s, rotated.RefreshToken)
+	if session.RevokedAt == nil || session.RevokedReason != model.SessionRevokedTokenReuse {
+		t.Errorf("session should be revoked for token reuse, got revoked_at=%v reason=%q", session.RevokedAt, session.RevokedReason)
+	}
+	if _, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: rotated.RefreshToken}); err == nil {
+		t.Error("refresh token of revoked session should be rejected")
+	}
+	if _, err := s.ValidateToken(rotated.Token); err == nil {
+		t.Error("access token of revoked session should be rejected")
+	}
+
+	var reuseLogs int64
+	s.db.Model(&model.AuditLog{}).Where("user_id = ? AND error_msg = ?", user.ID, "refresh token reuse").Count(&reuseLogs)
+	if reuseLogs != 1 {
+		t.Errorf("token reuse audit logs = %d, want 1", reuseLogs)
+	}
+}
+
+// TestValidateTokenRejectsRefreshToken 测试刷新令牌（包括已轮换的旧令牌）不能作为访问令牌调用 API
+func TestValidateTokenRejectsRefreshToken(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "alice")
+
+	login, err := s.createSession(user, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	if _, err := s.ValidateToken(login.RefreshToken); err == nil {
+		t.Error("refresh token should not be accepted for API calls")
+	}
+
+	rotated, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: login.RefreshToken})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if _, err := s.ValidateToken(login.RefreshToken); err == nil {
+		t.Error("rotated-out refresh token should not be accepted for API calls")
+	}
+	if _, err := s.ValidateToken(rotated.RefreshToken); err == nil {
+		t.Error("current refresh token should not be accepted for API calls")
+	}
+	if _, err := s.ValidateToken(rotated.Token); err != nil {
+		t.Errorf("access token should be accepted: %v", err)
+	}
+
+	// 校验访问令牌不会触发重放检测，会话仍然有效
+	if session := getSession(t, s, rotated.Token); session.RevokedAt != nil {
+		t.Errorf("session should stay active, revoked with reason %q", session.RevokedReason)
+	}
+}
+
+// TestLogoutAll 测试退出全部会话只影响当前用户，并在其他副本上生效
+func TestLogoutAll(t *testing.T) {
+	s := newTestService(t)
+	alice := createTestUser(t, s, "alice")
+	bob := createTestUser(t, s, "bob")
+
+	var aliceLogins []*LoginResponse
+	for i := 0; i < 2; i++ {
+		login, err := s.createSession(alice, "10.0.0.1", "test-agent")
+		if err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+		aliceLogins = append(aliceLogins, login)
+	}
+	bobLogin, err := s.createSession(bob, "10.0.0.2", "test-agent")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	// 另一个副本共享数据库，但有自己的会话缓存
+	other := NewService(s.db, s.logger, s.jwtCfg, s.securityCfg, nil, s.audit)
+	for _, login := range aliceLogins {
+		if _, err := other.ValidateToken(login.Token); err != nil {
+			t.Fatalf("token should be valid before logout: %v", err)
+		}
+	}
+
+	count, err := s.LogoutAll(alice.ID, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("logout all: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("revoked %d sessions, want 2", count)
+	}
+
+	for _, login := range aliceLogins {
+		if _, err := s.ValidateToken(login.Token); err == nil {
+			t.Error("access token should be rejected after logout all")
+		}
+		if _, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: login.RefreshToken}); err == nil {
+			t.Error("refresh token should be rejected after logout all")
+		}
+		if session := getSession(t, s, login.Token); session.RevokedReason != model.SessionRevokedLogoutAll {
+			t.Errorf("revoked reason = %q, want %q", session.RevokedReason, model.SessionRevokedLogoutAll)
+		}
+	}
+	if _, err := s.ValidateToken(bobLogin.Token); err != nil {
+		t.Errorf("other user's session should stay valid: %v", err)
+	}
+
+	// 其他副本在缓存过期后生效
+	other.cacheMutex.Lock()
+	other.sessionCache = make(map[string]sessionCacheEntry)
+	other.cacheMutex.Unlock()
+	if _, err := other.ValidateToken(aliceLogins[0].Token); err == nil {
+		t.Error("other replica should reject revoked session once cache expires")
+	}
+
+	if count, err := s.LogoutAll(alice.ID, "10.0.0.1", "test-agent"); err != nil || count != 0 {
+		t.Errorf("second logout all = %d, %v, want 0 revoked", count, err)
+	}
+}
+
+// TestBlockedUserSessionsRevoked 测试用户被禁用后会话被撤销，且无法再刷新令牌
+func TestBlockedUserSessionsRevoked(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "alice")
+
+	first, err := s.createSession(user, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	second, err := s.createSession(user, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	// 用户服务禁用用户后调用 RevokeUserSessions
+	if err := s.db.Model(user).Update("status", model.StatusBlocked).Error; err != nil {
+		t.Fatalf("block user: %v", err)
+	}
+	count, err := s.RevokeUserSessions(user.ID, model.SessionRevokedUserBlocked)
+	if err != nil {
+		t.Fatalf("revoke user sessions: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("revoked %d sessions, want 2", count)
+	}
+
+	for _, login := range []*LoginResponse{first, second} {
+		if _, err := s.ValidateToken(login.Token); err == nil {
+			t.Error("access token of blocked user should be rejected")
+		}
+		if _, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: login.RefreshToken}); err == nil {
+			t.Error("refresh token of blocked user should be rejected")
+		}
+		if session := getSession(t, s, login.Token); session.RevokedReason != model.SessionRevokedUserBlocked {
+			t.Errorf("revoked reason = %q, want %q", session.RevokedReason, model.SessionRevokedUserBlocked)
+		}
+	}
+
+	// 会话撤销失败时，刷新令牌仍会因为用户状态被拒绝
+	s.db.Model(user).Update("status", model.StatusActive)
+	login, err := s.createSession(user, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+	s.db.Model(user).Update("status", model.StatusBlocked)
+	if _, err := s.RefreshToken(RefreshTokenRequest{RefreshToken: login.RefreshToken}); err == nil {
+		t.Error("blocked user should not be able to refresh tokens")
+	}
+}
+
+// TestSessionCachePrune 测试写入会话缓存时清理过期条目
+func TestSessionCachePrune(t *testing.T) {
+	s := newTestService(t)
+	user := createTestUser(t, s, "alice")
+	login, err := s.createSession(user, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	stale := time.Now().Add(-2 * sessionCacheTTL)
+	s.cacheMutex.Lock()
+	for i := 0; i < 100; i++ {
+		s.sessionCache[fmt.Sprintf("old-%d", i)] = sessionCacheEntry{active: true, checkedAt: stale}
+	}
+	s.cacheMutex.Unlock()
+
+	if _, err := s.ValidateToken(login.Token); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	s.cacheMutex.RLock()
+	defer s.cacheMutex.RUnlock()
+	if len(s.sessionCache) != 1 {
+		t.Errorf("cache entries = %d, want only the session just checked", len(s.sessionCache))
+	}
+	if entry, ok := s.sessionCache[getSession(t, s, login.Token).SessionID]; !ok || !entry.active {
+		t.Error("checked session should be cached as active")
+	}
+}
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCredentials 用户名或密码（验证码）错误
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrAccountNotFound 账号已被删除
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+// LoginLockedError 登录失败次数过多，暂时锁定
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again after %s", e.Until.Format(time.RFC3339))
+}
+
+// RetryAfter 距离解锁的剩余秒数
+func (e *LoginLockedError) RetryAfter() int {
+	seconds := int(time.Until(e.Until).Seconds()) + 1
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+func userThrottleKey(username string) string { return "user:" + username }
+func ipThrottleKey(ip string) string         { return "ip:" + ip }
+
+// throttleLimits 返回需要检查的限流键及各自允许的失败次数
+func (s *Service) throttleLimits(username, ipAddress string) map[string]int {
+	limits := make(map[string]int, 2)
+	if username != "" && s.securityCfg.MaxFailedLoginsPerUser > 0 {
+		limits[userThrottleKey(username)] = s.securityCfg.MaxFailedLoginsPerUser
+	}
+	if ipAddress != "" && s.securityCfg.MaxFailedLoginsPerIP > 0 {
+		limits[ipThrottleKey(ipAddress)] = s.securityCfg.MaxFailedLoginsPerIP
+	}
+	return limits
+}
+
+// checkLoginThrottle 检查用户名或来源 IP 是否处于锁定状态
+func (s *Service) checkLoginThrottle(username, ipAddress string) error {
+	limits := s.throttleLimits(username, ipAddress)
+	if len(limits) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(limits))
+	for key := range limits {
+		keys = append(keys, key)
+	}
+
+	var throttles []model.LoginThrottle
+	if err := s.db.Where("throttle_key IN ? AND locked_until > ?", keys, time.Now()).Find(&throttles).Error; err != nil {
+		// 限流表不可用时不阻止登录
+		s.logger.Errorf("Failed to check login throttle: %v", err)
+		return nil
+	}
+
+	var until time.Time
+	for _, t := range throttles {
+		if t.LockedUntil.After(until) {
+			until = *t.LockedUntil
+		}
+	}
+	if until.IsZero() {
+		return nil
+	}
+	return &LoginLockedError{Until: until}
+}
+
+// recordLoginFailure 记录一次登录失败，达到阈值后锁定对应的用户名或 IP
+// 计数使用数据库原子更新，多个副本共享同一份计数
+func (s *Service) recordLoginFailure(username, ipAddress, userAgent string) {
+	now := time.Now()
+	window := time.Duration(s.securityCfg.FailedLoginWindow) * time.Second
+	lockout := time.Duration(s.securityCfg.LockoutDuration) * time.Second
+
+	for key, limit := range s.throttleLimits(username, ipAddress) {
+		failures, err := s.incrementFailures(key, now, window)
+		if err != nil {
+			s.logger.Errorf("Failed to record login failure for %s: %v", key, err)
+			continue
+		}
+		if failures < limit {
+			continue
+		}
+
+		lockedUntil := now.Add(lockout)
+		result := s.db.Model(&model.LoginThrottle{}).
+			Where("throttle_key = ? AND (locked_until IS NULL OR locked_until < ?)", key, now).
+			Update("locked_until", lockedUntil)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		s.logger.Warningf("Login locked for %s until %s after %d failed attempts", key, lockedUntil.Format(time.RFC3339), failures)
+		s.audit.Log(audit.LogRequest{
+			Action:       model.ActionLogin,
+			ResourceType: model.ResourceUser,
+			Details:      fmt.Sprintf("Login locked for %s until %s after %d failed attempts", key, lockedUntil.Format(time.RFC3339), failures),
+			Status:       model.AuditStatusFailed,
+			ErrorMsg:     "Too many failed login attempts",
+			IPAddress:    ipAddress,
+			UserAgent:    userAgent,
+		})
+	}
+}
+
+// incrementFailures 在计数窗口内累加失败次数，窗口过期时重新计数，返回累加后的次数
+func (s *Service) incrementFailures(key string, now time.Time, window time.Duration) (int, error) {
+	result := s.db.Model(&model.LoginThrottle{}).
+		Where("throttle_key = ? AND window_start > ?", key, now.Add(-window)).
+		Update("failures", gorm.Expr("failures + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		// 窗口已过期或尚无记录：重置计数
+		result = s.db.Model(&model.LoginThrottle{}).
+			Where("throttle_key = ? AND window_start <= ?", key, now.Add(-window)).
+			Updates(map[string]interface{}{"failures": 1, "window_start": now})
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected == 0 {
+			throttle := model.LoginThrottle{ThrottleKey: key, Failures: 1, WindowStart: now}
+			if err := s.db.Create(&throttle).Error; err != nil {
+				// 其他副本并发创建了记录，改为累加
+				if err := s.db.Model(&model.LoginThrottle{}).Where("throttle_key = ?", key).
+					Update("failures", gorm.Expr("failures + 1")).Error; err != nil {
+					return 0, err
+				}
+			} else {
+				return 1, nil
+			}
+		}
+	}
+
+	var throttle model.LoginThrottle
+	if err := s.db.Where("throttle_key = ?", key).First(&throttle).Error; err != nil {
+		return 0, err
+	}
+	return throttle.Failures, nil
+}
+
+// resetLoginFailures 登录成功后清除用户名的失败计数
+// IP 计数不清除，避免攻击者用一个有效账号重置整个来源的计数
+func (s *Service) resetLoginFailures(username string) {
+	if err := s.db.Where("throttle_key = ?", userThrottleKey(username)).Delete(&model.LoginThrottle{}).Error; err != nil {
+		s.logger.Warningf("Failed to reset login failures for user %s: %v", username, err)
+	}
+}
+
+// UnlockUser 管理员解除用户的登录锁定
+func (s *Service) UnlockUser(userID, operatorID uint) error {
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	s.resetLoginFailures(user.Username)
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       operatorID,
+		Action:       model.ActionUpdate,
+		ResourceType: model.ResourceUser,
+		Details:      fmt.Sprintf("Unlocked login for user: %s", user.Username),
+		Status:       model.AuditStatusSuccess,
+	})
+	return nil
+}
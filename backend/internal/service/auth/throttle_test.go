@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+)
+
+// TestIncrementFailuresWindow 测试失败计数在窗口内累加，窗口过期后重新计数
+func TestIncrementFailuresWindow(t *testing.T) {
+	s := newTestService(t)
+	key := userThrottleKey("alice")
+	window := time.Minute
+	start := time.Now()
+
+	steps := []struct {
+		at   time.Time
+		want int
+	}{
+		{start, 1},
+		{start.Add(10 * time.Second), 2},
+		{start.Add(50 * time.Second), 3},
+		// 窗口从第一次失败开始计算，过期后从 1 重新计数
+		{start.Add(window + time.Second), 1},
+		{start.Add(window + 2*time.Second), 2},
+	}
+	for i, step := range steps {
+		failures, err := s.incrementFailures(key, step.at, window)
+		if err != nil {
+			t.Fatalf("step %d: increment: %v", i, err)
+		}
+		if failures != step.want {
+			t.Errorf("step %d: failures = %d, want %d", i, failures, step.want)
+		}
+	}
+
+	var count int64
+	s.db.Model(&model.LoginThrottle{}).Where("throttle_key = ?", key).Count(&count)
+	if count != 1 {
+		t.Errorf("throttle records = %d, want 1", count)
+	}
+	if failures, _ := s.incrementFailures(userThrottleKey("bob"), start, window); failures != 1 {
+		t.Errorf("other key failures = %d, want 1", failures)
+	}
+}
+
+// TestLoginLockout 测试达到失败次数后锁定用户名和来源 IP，登录成功只清除用户名计数
+func TestLoginLockout(t *testing.T) {
+	s := newTestService(t)
+	s.securityCfg.MaxFailedLoginsPerUser = 3
+	s.securityCfg.MaxFailedLoginsPerIP = 5
+	s.securityCfg.FailedLoginWindow = 60
+	s.securityCfg.LockoutDuration = 300
+	alice := createTestUser(t, s, "alice")
+
+	for i := 0; i < 2; i++ {
+		s.recordLoginFailure("alice", "10.0.0.1", "test-agent")
+	}
+	if err := s.checkLoginThrottle("alice", "10.0.0.1"); err != nil {
+		t.Fatalf("should not be locked below the limit: %v", err)
+	}
+
+	s.recordLoginFailure("alice", "10.0.0.1", "test-agent")
+	err := s.checkLoginThrottle("alice", "10.0.0.2")
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected user lockout, got %v", err)
+	}
+	if until := time.Until(locked.Until); until < 290*time.Second || until > 300*time.Second {
+		t.Errorf("locked for %s, want about 300s", until)
+	}
+	if err := s.checkLoginThrottle("bob", "10.0.0.2"); err != nil {
+		t.Errorf("other user should not be locked: %v", err)
+	}
+
+	// 同一 IP 尝试其他用户名，达到 IP 阈值后锁定该 IP
+	s.recordLoginFailure("bob", "10.0.0.1", "test-agent")
+	s.recordLoginFailure("carol", "10.0.0.1", "test-agent")
+	if err := s.checkLoginThrottle("bob", "10.0.0.1"); err == nil {
+		t.Error("source ip should be locked after too many failures")
+	}
+	if err := s.checkLoginThrottle("bob", "10.0.0.2"); err != nil {
+		t.Errorf("bob from another ip should not be locked: %v", err)
+	}
+
+	var lockLogs int64
+	s.db.Model(&model.AuditLog{}).Where("error_msg = ?", "Too many failed login attempts").Count(&lockLogs)
+	if lockLogs != 2 {
+		t.Errorf("lockout audit logs = %d, want 2", lockLogs)
+	}
+
+	if err := s.UnlockUser(alice.ID, alice.ID); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := s.checkLoginThrottle("alice", "10.0.0.2"); err != nil {
+		t.Errorf("user should be unlocked: %v", err)
+	}
+	if err := s.checkLoginThrottle("alice", "10.0.0.1"); err == nil {
+		t.Error("unlocking a user should not clear the ip lockout")
+	}
+}
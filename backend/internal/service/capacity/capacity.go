@@ -0,0 +1,291 @@
+package capacity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/internal/service/nodemetrics"
+	"kube-node-manager/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// UnlabeledPool 未设置节点池标签的节点归入的池名称
+const UnlabeledPool = "<none>"
+
+// Config 容量规划配置
+type Config struct {
+	PoolLabel string // 默认节点池标签键
+	LossNodes int    // 默认假设失去的最大节点数
+	TrendDays int    // 默认趋势预测天数
+
+	ReportEnabled  bool     // 启用飞书定时报告
+	ReportTime     string   // 每天发送时间（格式：HH:MM）
+	ReportChatIDs  []string // 接收报告的飞书群聊 ID
+	ReportClusters []string // 报告包含的集群，为空时包含全部活跃集群
+}
+
+// MessageSender 飞书消息发送接口
+type MessageSender interface {
+	SendMessage(chatID, msgType, content string) error
+}
+
+// Service 容量规划服务：汇总集群和节点池的容量、分配和使用情况，并基于历史 requests 预测耗尽时间
+type Service struct {
+	db         *gorm.DB
+	logger     *logger.Logger
+	k8sSvc     *k8s.Service
+	metricsSvc *nodemetrics.Service
+	sender     MessageSender
+	config     *Config
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// ReportRequest 容量报告请求
+type ReportRequest struct {
+	ClusterName string
+	PoolLabel   string // 为空时使用配置的默认标签，仍为空时只统计集群整体
+	LossNodes   int    // 小于 0 时使用配置的默认值
+	TrendDays   int    // 小于等于 0 时使用配置的默认值
+}
+
+// ResourceCapacity 单项资源的容量情况
+// CPU 单位为毫核，内存单位为字节；GPU 不统计使用量
+type ResourceCapacity struct {
+	Allocatable    int64   `json:"allocatable"`
+	Requested      int64   `json:"requested"`
+	Used           int64   `json:"used"`
+	RequestPercent float64 `json:"request_percent"`
+	UsedPercent    float64 `json:"used_percent"`
+	Available      int64   `json:"available"`     // allocatable - requested
+	LossHeadroom   int64   `json:"loss_headroom"` // 失去 N 个该资源最大的节点后，剩余可调度节点的 allocatable 减去全部 requests，负数表示无法容纳
+}
+
+// PoolCapacity 集群或节点池的容量情况
+type PoolCapacity struct {
+	Name             string                      `json:"name"`
+	NodeCount        int                         `json:"node_count"`
+	ReadyNodes       int                         `json:"ready_nodes"`
+	SchedulableNodes int                         `json:"schedulable_nodes"`
+	UsageNodes       int                         `json:"usage_nodes"` // 有使用量数据的节点数
+	CPU              ResourceCapacity            `json:"cpu"`
+	Memory           ResourceCapacity            `json:"memory"`
+	Pods             ResourceCapacity            `json:"pods"`
+	GPU              map[string]ResourceCapacity `json:"gpu,omitempty"`
+	Forecasts        []Forecast                  `json:"forecasts"`
+}
+
+// Report 容量报告
+type Report struct {
+	ClusterName string         `json:"cluster_name"`
+	PoolLabel   string         `json:"pool_label"`
+	LossNodes   int            `json:"loss_nodes"`
+	TrendDays   int            `json:"trend_days"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Cluster     PoolCapacity   `json:"cluster"`
+	Pools       []PoolCapacity `json:"pools"`
+}
+
+// NewService 创建容量规划服务
+func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, metricsSvc *nodemetrics.Service, config *Config) *Service {
+	if config.LossNodes < 0 {
+		config.LossNodes = 1
+	}
+	if config.TrendDays <= 0 {
+		config.TrendDays = 14
+	}
+	if config.ReportTime == "" {
+		config.ReportTime = "09:30"
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		db:         db,
+		logger:     logger,
+		k8sSvc:     k8sSvc,
+		metricsSvc: metricsSvc,
+		config:     config,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// SetMessageSender 设置飞书消息发送服务，用于定时报告
+func (s *Service) SetMessageSender(sender MessageSender) {
+	s.sender = sender
+}
+
+// GetReport 生成集群及各节点池的容量报告
+func (s *Service) GetReport(req ReportRequest) (*Report, error) {
+	if req.ClusterName == "" {
+		return nil, fmt.Errorf("cluster_name is required")
+	}
+	if req.PoolLabel == "" {
+		req.PoolLabel = s.config.PoolLabel
+	}
+	if req.LossNodes < 0 {
+		req.LossNodes = s.config.LossNodes
+	}
+	if req.TrendDays <= 0 {
+		req.TrendDays = s.config.TrendDays
+	}
+
+	var cluster model.Cluster
+	if err := s.db.Where("name = ?", req.ClusterName).First(&cluster).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("cluster %s not found", req.ClusterName)
+		}
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	nodes, err := s.k8sSvc.CollectNodeCapacity(req.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect node capacity: %w", err)
+	}
+
+	// 使用量和历史 requests 来自节点资源指标历史，未启用采样时不影响容量统计
+	now := time.Now()
+	var usage map[string]model.NodeMetricSample
+	var history []model.NodeMetricSample
+	if s.metricsSvc != nil {
+		if usage, err = s.metricsSvc.LatestUsage(req.ClusterName); err != nil {
+			s.logger.Warningf("Failed to get latest node usage for cluster %s: %v", req.ClusterName, err)
+		}
+		if history, err = s.metricsSvc.RequestHistory(req.ClusterName, now.AddDate(0, 0, -req.TrendDays)); err != nil {
+			s.logger.Warningf("Failed to get request history for cluster %s: %v", req.ClusterName, err)
+		}
+	}
+
+	report := &Report{
+		ClusterName: req.ClusterName,
+		PoolLabel:   req.PoolLabel,
+		LossNodes:   req.LossNodes,
+		TrendDays:   req.TrendDays,
+		GeneratedAt: now,
+		Cluster:     buildPoolCapacity(req.ClusterName, nodes, usage, history, req.LossNodes, now),
+		Pools:       []PoolCapacity{},
+	}
+
+	if req.PoolLabel != "" {
+		pools := make(map[string][]k8s.NodeCapacity)
+		for _, node := range nodes {
+			name, ok := node.Labels[req.PoolLabel]
+			if !ok || name == "" {
+				name = UnlabeledPool
+			}
+			pools[name] = append(pools[name], node)
+		}
+		names := make([]string, 0, len(pools))
+		for name := range pools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			report.Pools = append(report.Pools, buildPoolCapacity(name, pools[name], usage, history, req.LossNodes, now))
+		}
+	}
+
+	return report, nil
+}
+
+// buildPoolCapacity 汇总一组节点的容量、分配、使用情况、冗余和耗尽预测
+func buildPoolCapacity(name string, nodes []k8s.NodeCapacity, usage map[string]model.NodeMetricSample,
+	history []model.NodeMetricSample, lossNodes int, now time.Time) PoolCapacity {
+	pool := PoolCapacity{Name: name, NodeCount: len(nodes)}
+
+	members := make(map[string]bool, len(nodes))
+	available := make([]k8s.NodeCapacity, 0, len(nodes)) // 可承接 Pod 的节点
+	gpuNames := make(map[string]bool)
+	for _, node := range nodes {
+		members[node.NodeName] = true
+		if node.Ready {
+			pool.ReadyNodes++
+		}
+		if node.Schedulable {
+			pool.SchedulableNodes++
+		}
+		if node.Ready && node.Schedulable {
+			available = append(available, node)
+		}
+
+		pool.CPU.Allocatable += node.CPUAllocatable
+		pool.CPU.Requested += node.CPURequests
+		pool.Memory.Allocatable += node.MemoryAllocatable
+		pool.Memory.Requested += node.MemoryRequests
+		pool.Pods.Allocatable += node.PodsAllocatable
+		pool.Pods.Requested += node.Pods
+		if u, ok := usage[node.NodeName]; ok {
+			pool.UsageNodes++
+			pool.CPU.Used += int64(u.CPUAvg)
+			pool.Memory.Used += int64(u.MemoryAvg)
+			pool.Pods.Used += int64(u.PodsAvg)
+		}
+		for gpu := range node.GPUAllocatable {
+			gpuNames[gpu] = true
+		}
+		for gpu := range node.GPURequests {
+			gpuNames[gpu] = true
+		}
+	}
+
+	finishResource(&pool.CPU, available, lossNodes, func(n k8s.NodeCapacity) int64 { return n.CPUAllocatable })
+	finishResource(&pool.Memory, available, lossNodes, func(n k8s.NodeCapacity) int64 { return n.MemoryAllocatable })
+	finishResource(&pool.Pods, available, lossNodes, func(n k8s.NodeCapacity) int64 { return n.PodsAllocatable })
+
+	if len(gpuNames) > 0 {
+		pool.GPU = make(map[string]ResourceCapacity, len(gpuNames))
+		for gpu := range gpuNames {
+			var rc ResourceCapacity
+			for _, node := range nodes {
+				rc.Allocatable += node.GPUAllocatable[gpu]
+				rc.Requested += node.GPURequests[gpu]
+			}
+			finishResource(&rc, available, lossNodes, func(n k8s.NodeCapacity) int64 { return n.GPUAllocatable[gpu] })
+			pool.GPU[gpu] = rc
+		}
+	}
+
+	points := poolTrend(history, members)
+	pool.Forecasts = []Forecast{
+		forecast(ResourceCPU, points, func(p trendPoint) float64 { return p.cpu }, pool.CPU, now),
+		forecast(ResourceMemory, points, func(p trendPoint) float64 { return p.memory }, pool.Memory, now),
+		forecast(ResourcePods, points, func(p trendPoint) float64 { return p.pods }, pool.Pods, now),
+	}
+	return pool
+}
+
+// finishResource 计算占比、可用量和失去 N 个最大节点后的冗余
+func finishResource(rc *ResourceCapacity, available []k8s.NodeCapacity, lossNodes int, allocatable func(k8s.NodeCapacity) int64) {
+	rc.RequestPercent = percentOf(rc.Requested, rc.Allocatable)
+	rc.UsedPercent = percentOf(rc.Used, rc.Allocatable)
+	rc.Available = rc.Allocatable - rc.Requested
+
+	sizes := make([]int64, 0, len(available))
+	var remaining int64
+	for _, node := range available {
+		size := allocatable(node)
+		sizes = append(sizes, size)
+		remaining += size
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] > sizes[j] })
+	for i := 0; i < lossNodes && i < len(sizes); i++ {
+		remaining -= sizes[i]
+	}
+	rc.LossHeadroom = remaining - rc.Requested
+}
+
+// percentOf 计算占比（保留一位小数），分母为 0 时返回 0
+func percentOf(used, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(int64(float64(used)*1000/float64(total))) / 10
+}
@@ -0,0 +1,146 @@
+package capacity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+)
+
+func node(name string, cpu, memory, cpuReq, memoryReq int64, ready, schedulable bool) k8s.NodeCapacity {
+	return k8s.NodeCapacity{
+		NodeName:          name,
+		Ready:             ready,
+		Schedulable:       schedulable,
+		CPUAllocatable:    cpu,
+		MemoryAllocatable: memory,
+		PodsAllocatable:   110,
+		CPURequests:       cpuReq,
+		MemoryRequests:    memoryReq,
+		Pods:              10,
+	}
+}
+
+func TestBuildPoolCapacity(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	nodes := []k8s.NodeCapacity{
+		node("big", 16000, 64<<30, 8000, 32<<30, true, true),
+		node("small-1", 4000, 16<<30, 2000, 8<<30, true, true),
+		node("small-2", 4000, 16<<30, 1000, 4<<30, true, true),
+		node("cordoned", 8000, 32<<30, 0, 0, true, false),
+	}
+	nodes[0].GPUAllocatable = map[string]int64{"nvidia.com/gpu": 8}
+	nodes[0].GPURequests = map[string]int64{"nvidia.com/gpu": 2}
+	usage := map[string]model.NodeMetricSample{
+		"big": {CPUAvg: 4000, MemoryAvg: float64(16 << 30), PodsAvg: 10},
+	}
+
+	pool := buildPoolCapacity("test", nodes, usage, nil, 1, now)
+	if pool.NodeCount != 4 || pool.ReadyNodes != 4 || pool.SchedulableNodes != 3 || pool.UsageNodes != 1 {
+		t.Fatalf("unexpected node counts: %+v", pool)
+	}
+	if pool.CPU.Allocatable != 32000 || pool.CPU.Requested != 11000 || pool.CPU.Available != 21000 {
+		t.Errorf("unexpected cpu totals: %+v", pool.CPU)
+	}
+	if pool.CPU.UsedPercent != 12.5 {
+		t.Errorf("expected cpu used 12.5%%, got %v", pool.CPU.UsedPercent)
+	}
+	// 禁止调度的节点不计入冗余，失去最大的节点后剩余 8000m
+	if pool.CPU.LossHeadroom != 8000-11000 {
+		t.Errorf("expected cpu loss headroom -3000, got %d", pool.CPU.LossHeadroom)
+	}
+	if pool.Pods.LossHeadroom != 220-40 {
+		t.Errorf("expected pods loss headroom 180, got %d", pool.Pods.LossHeadroom)
+	}
+	gpu, ok := pool.GPU["nvidia.com/gpu"]
+	if !ok || gpu.Allocatable != 8 || gpu.Requested != 2 || gpu.LossHeadroom != -2 {
+		t.Errorf("unexpected gpu capacity: %+v", pool.GPU)
+	}
+	if len(pool.Forecasts) != 3 || pool.Forecasts[0].Status != ForecastInsufficientData {
+		t.Errorf("expected insufficient data forecasts: %+v", pool.Forecasts)
+	}
+	if level := poolLevel(pool); level != "orange" {
+		t.Errorf("expected orange level for negative headroom, got %s", level)
+	}
+
+	noLoss := buildPoolCapacity("test", nodes, nil, nil, 0, now)
+	if noLoss.CPU.LossHeadroom != 24000-11000 {
+		t.Errorf("expected cpu headroom 13000 without node loss, got %d", noLoss.CPU.LossHeadroom)
+	}
+}
+
+func TestForecast(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	members := map[string]bool{"n1": true, "n2": true}
+
+	// 每天增长 1000m，每 6 小时一个时间桶，共 3 天
+	var history []model.NodeMetricSample
+	for i := 0; i < 12; i++ {
+		ts := now.Add(-72 * time.Hour).Add(time.Duration(i) * 6 * time.Hour)
+		cpu := float64(i) * 250
+		history = append(history,
+			model.NodeMetricSample{NodeName: "n1", Timestamp: ts, CPURequestsAvg: 1000 + cpu, PodsAvg: 5},
+			model.NodeMetricSample{NodeName: "n2", Timestamp: ts, CPURequestsAvg: 1000, PodsAvg: 5},
+			model.NodeMetricSample{NodeName: "other", Timestamp: ts, CPURequestsAvg: 9999},
+		)
+	}
+	// 采样不完整的时间桶被丢弃
+	history = append(history, model.NodeMetricSample{NodeName: "n1", Timestamp: now.Add(-time.Hour), CPURequestsAvg: 1})
+
+	points := poolTrend(history, members)
+	if len(points) != 12 {
+		t.Fatalf("expected 12 trend points, got %d", len(points))
+	}
+
+	cpu := func(p trendPoint) float64 { return p.cpu }
+	f := forecast(ResourceCPU, points, cpu, ResourceCapacity{Allocatable: 10000, Requested: 5000}, now)
+	if f.Status != ForecastGrowing || f.GrowthPerDay != 1000 || f.DaysToExhaustion == nil || *f.DaysToExhaustion != 5 {
+		t.Fatalf("unexpected forecast: %+v", f)
+	}
+	if !f.ExhaustionTime.Equal(now.Add(5 * 24 * time.Hour)) {
+		t.Errorf("unexpected exhaustion time: %v", f.ExhaustionTime)
+	}
+
+	pods := forecast(ResourcePods, points, func(p trendPoint) float64 { return p.pods }, ResourceCapacity{Allocatable: 220, Requested: 10}, now)
+	if pods.Status != ForecastStable {
+		t.Errorf("expected stable pods forecast, got %+v", pods)
+	}
+
+	exhausted := forecast(ResourceCPU, points, cpu, ResourceCapacity{Allocatable: 10000, Requested: 10000}, now)
+	if exhausted.Status != ForecastExhausted {
+		t.Errorf("expected exhausted forecast, got %+v", exhausted)
+	}
+
+	short := forecast(ResourceCPU, points[:3], cpu, ResourceCapacity{Allocatable: 10000, Requested: 5000}, now)
+	if short.Status != ForecastInsufficientData {
+		t.Errorf("expected insufficient data, got %+v", short)
+	}
+}
+
+func TestBuildReportCard(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	days := 3.0
+	at := now.Add(72 * time.Hour)
+	report := &Report{
+		ClusterName: "prod",
+		PoolLabel:   "pool",
+		LossNodes:   1,
+		TrendDays:   14,
+		GeneratedAt: now,
+		Cluster: PoolCapacity{
+			Name:      "prod",
+			NodeCount: 3,
+			Forecasts: []Forecast{{Resource: ResourceMemory, Status: ForecastGrowing, DaysToExhaustion: &days, ExhaustionTime: &at}},
+		},
+		Pools: []PoolCapacity{{Name: "gpu", NodeCount: 1}},
+	}
+
+	card := buildReportCard(report)
+	for _, want := range []string{"容量报告 - prod", "节点池 pool=gpu", "内存 预计 3.0 天后（2024-01-18）", `"template":"orange"`} {
+		if !strings.Contains(card, want) {
+			t.Errorf("card missing %q: %s", want, card)
+		}
+	}
+}
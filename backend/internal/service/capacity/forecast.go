@@ -0,0 +1,144 @@
+package capacity
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"kube-node-manager/internal/model"
+)
+
+// 预测的资源类型
+const (
+	ResourceCPU    = "cpu"
+	ResourceMemory = "memory"
+	ResourcePods   = "pods"
+)
+
+// 预测状态
+const (
+	ForecastGrowing          = "growing"           // requests 增长，已给出耗尽时间
+	ForecastStable           = "stable"            // requests 持平或下降
+	ForecastExhausted        = "exhausted"         // requests 已达到 allocatable
+	ForecastInsufficientData = "insufficient_data" // 历史数据不足
+)
+
+const (
+	minForecastPoints  = 6              // 参与拟合的最少数据点
+	minForecastSpan    = 24 * time.Hour // 参与拟合的最短时间跨度
+	minBucketNodeRatio = 0.8            // 时间桶内节点数低于最大节点数的该比例时视为采样不完整
+	maxForecastHorizon = 365.0          // 超过该天数的预测不给出耗尽时间
+)
+
+// Forecast 基于历史 requests 线性趋势的耗尽预测
+type Forecast struct {
+	Resource         string     `json:"resource"`
+	Status           string     `json:"status"`
+	Allocatable      int64      `json:"allocatable"`
+	Requested        int64      `json:"requested"`
+	GrowthPerDay     float64    `json:"growth_per_day"`               // requests 每天的增长量
+	DaysToExhaustion *float64   `json:"days_to_exhaustion,omitempty"` // requests 预计超过 allocatable 的天数
+	ExhaustionTime   *time.Time `json:"exhaustion_time,omitempty"`
+	DataPoints       int        `json:"data_points"`
+}
+
+// trendPoint 某个时间桶内一组节点的 requests 之和
+type trendPoint struct {
+	timestamp time.Time
+	nodes     int
+	cpu       float64
+	memory    float64
+	pods      float64
+}
+
+// poolTrend 按时间桶汇总属于节点池的历史 requests，丢弃节点数明显偏少（采样不完整）的时间桶
+func poolTrend(history []model.NodeMetricSample, members map[string]bool) []trendPoint {
+	buckets := make(map[time.Time]*trendPoint)
+	for _, row := range history {
+		if !members[row.NodeName] {
+			continue
+		}
+		p, ok := buckets[row.Timestamp]
+		if !ok {
+			p = &trendPoint{timestamp: row.Timestamp}
+			buckets[row.Timestamp] = p
+		}
+		p.nodes++
+		p.cpu += row.CPURequestsAvg
+		p.memory += row.MemoryRequestsAvg
+		p.pods += row.PodsAvg
+	}
+
+	maxNodes := 0
+	for _, p := range buckets {
+		maxNodes = max(maxNodes, p.nodes)
+	}
+
+	points := make([]trendPoint, 0, len(buckets))
+	for _, p := range buckets {
+		if float64(p.nodes) >= float64(maxNodes)*minBucketNodeRatio {
+			points = append(points, *p)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].timestamp.Before(points[j].timestamp) })
+	return points
+}
+
+// forecast 对历史 requests 做最小二乘线性拟合，以当前 requests 为起点按增长率推算超过 allocatable 的时间
+func forecast(resource string, points []trendPoint, value func(trendPoint) float64, rc ResourceCapacity, now time.Time) Forecast {
+	f := Forecast{
+		Resource:    resource,
+		Allocatable: rc.Allocatable,
+		Requested:   rc.Requested,
+		DataPoints:  len(points),
+	}
+
+	if rc.Allocatable > 0 && rc.Requested >= rc.Allocatable {
+		f.Status = ForecastExhausted
+		zero := 0.0
+		f.DaysToExhaustion = &zero
+		f.ExhaustionTime = &now
+		return f
+	}
+	if len(points) < minForecastPoints || points[len(points)-1].timestamp.Sub(points[0].timestamp) < minForecastSpan {
+		f.Status = ForecastInsufficientData
+		return f
+	}
+
+	// x 为距第一个点的天数
+	origin := points[0].timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.timestamp.Sub(origin).Hours() / 24
+		y := value(p)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(len(points))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		f.Status = ForecastInsufficientData
+		return f
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	f.GrowthPerDay = math.Round(slope*100) / 100
+
+	if slope <= 0 || rc.Allocatable <= 0 {
+		f.Status = ForecastStable
+		return f
+	}
+
+	days := float64(rc.Allocatable-rc.Requested) / slope
+	if days > maxForecastHorizon {
+		f.Status = ForecastStable
+		return f
+	}
+	days = math.Round(days*10) / 10
+	at := now.Add(time.Duration(days * 24 * float64(time.Hour)))
+	f.Status = ForecastGrowing
+	f.DaysToExhaustion = &days
+	f.ExhaustionTime = &at
+	return f
+}
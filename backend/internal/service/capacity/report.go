@@ -0,0 +1,247 @@
+package capacity
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm/clause"
+)
+
+// reportWarningDays 耗尽时间在该天数内时报告标记为警告
+const reportWarningDays = 30
+
+// Start 启动飞书定时容量报告
+func (s *Service) Start() {
+	if !s.config.ReportEnabled {
+		s.logger.Info("Scheduled capacity report is disabled")
+		return
+	}
+	if len(s.config.ReportChatIDs) == 0 || s.sender == nil {
+		s.logger.Warning("Scheduled capacity report is enabled but no Feishu chat is configured")
+		return
+	}
+
+	s.wg.Add(1)
+	go s.reportLoop()
+}
+
+// Stop 停止定时报告
+func (s *Service) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// reportLoop 每天在配置的时间发送容量报告
+func (s *Service) reportLoop() {
+	defer s.wg.Done()
+
+	nextRun := s.calculateNextReportTime()
+	s.logger.Infof("Next capacity report scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+	for {
+		timer := time.NewTimer(time.Until(nextRun))
+		select {
+		case <-timer.C:
+			if err := s.sendScheduledReport(nextRun); err != nil {
+				s.logger.Errorf("Scheduled capacity report failed: %v", err)
+			}
+			nextRun = s.calculateNextReportTime()
+			s.logger.Infof("Next capacity report scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// calculateNextReportTime 计算下次报告时间
+func (s *Service) calculateNextReportTime() time.Time {
+	now := time.Now()
+
+	hour, minute := 9, 30
+	fmt.Sscanf(s.config.ReportTime, "%d:%d", &hour, &minute)
+
+	nextRun := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if now.After(nextRun) {
+		nextRun = nextRun.Add(24 * time.Hour)
+	}
+	return nextRun
+}
+
+// sendScheduledReport 生成并发送当天的容量报告
+// 多副本部署时通过唯一的 RunKey 保证每天只有一个副本发送
+func (s *Service) sendScheduledReport(runAt time.Time) error {
+	run := model.CapacityReportRun{RunKey: runAt.Format("2006-01-02")}
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&run)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record report run: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		s.logger.Infof("Capacity report for %s already sent by another instance", run.RunKey)
+		return nil
+	}
+
+	clusterNames := s.config.ReportClusters
+	if len(clusterNames) == 0 {
+		var clusters []model.Cluster
+		if err := s.db.Where("status = ?", model.ClusterStatusActive).Order("name").Find(&clusters).Error; err != nil {
+			return fmt.Errorf("failed to list clusters: %w", err)
+		}
+		for _, c := range clusters {
+			clusterNames = append(clusterNames, c.Name)
+		}
+	}
+
+	for _, name := range clusterNames {
+		report, err := s.GetReport(ReportRequest{ClusterName: name, LossNodes: -1})
+		if err != nil {
+			s.logger.Warningf("Failed to build capacity report for cluster %s: %v", name, err)
+			continue
+		}
+		card := buildReportCard(report)
+		for _, chatID := range s.config.ReportChatIDs {
+			if err := s.sender.SendMessage(chatID, "interactive", card); err != nil {
+				s.logger.Warningf("Failed to send capacity report for cluster %s to chat %s: %v", name, chatID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// buildReportCard 构建容量报告飞书卡片
+func buildReportCard(report *Report) string {
+	pools := append([]PoolCapacity{report.Cluster}, report.Pools...)
+
+	template := "green"
+	elements := make([]interface{}, 0, len(pools)*2+1)
+	for i, pool := range pools {
+		title := "集群整体"
+		if i > 0 {
+			title = fmt.Sprintf("节点池 %s=%s", report.PoolLabel, pool.Name)
+		}
+		level := poolLevel(pool)
+		if level == "red" || (level == "orange" && template != "red") {
+			template = level
+		}
+
+		elements = append(elements,
+			map[string]interface{}{
+				"tag": "div",
+				"text": map[string]interface{}{
+					"content": formatPoolContent(title, pool, report.LossNodes),
+					"tag":     "lark_md",
+				},
+			},
+			map[string]interface{}{"tag": "hr"},
+		)
+	}
+	elements = append(elements, map[string]interface{}{
+		"tag": "note",
+		"elements": []interface{}{
+			map[string]interface{}{
+				"tag":     "plain_text",
+				"content": fmt.Sprintf("生成时间: %s · 趋势基于最近 %d 天的 requests 历史", report.GeneratedAt.Format("2006-01-02 15:04"), report.TrendDays),
+			},
+		},
+	})
+
+	card := map[string]interface{}{
+		"config": map[string]interface{}{
+			"wide_screen_mode": true,
+		},
+		"header": map[string]interface{}{
+			"template": template,
+			"title": map[string]interface{}{
+				"content": fmt.Sprintf("📊 容量报告 - %s", report.ClusterName),
+				"tag":     "plain_text",
+			},
+		},
+		"elements": elements,
+	}
+
+	cardJSON, _ := json.Marshal(card)
+	return string(cardJSON)
+}
+
+// poolLevel 根据冗余和预测判断节点池的告警级别
+func poolLevel(pool PoolCapacity) string {
+	level := "green"
+	for _, f := range pool.Forecasts {
+		if f.Status == ForecastExhausted {
+			return "red"
+		}
+		if f.Status == ForecastGrowing && *f.DaysToExhaustion <= reportWarningDays {
+			level = "orange"
+		}
+	}
+	if pool.CPU.LossHeadroom < 0 || pool.Memory.LossHeadroom < 0 || pool.Pods.LossHeadroom < 0 {
+		level = "orange"
+	}
+	return level
+}
+
+// formatPoolContent 格式化节点池的容量概要
+func formatPoolContent(title string, pool PoolCapacity, lossNodes int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**　节点 %d（就绪 %d，可调度 %d）\n", title, pool.NodeCount, pool.ReadyNodes, pool.SchedulableNodes)
+	fmt.Fprintf(&b, "**CPU**: 请求 %s / %s 核（%.1f%%）", formatCores(pool.CPU.Requested), formatCores(pool.CPU.Allocatable), pool.CPU.RequestPercent)
+	if pool.UsageNodes > 0 {
+		fmt.Fprintf(&b, "，使用 %.1f%%", pool.CPU.UsedPercent)
+	}
+	fmt.Fprintf(&b, "，失去 %d 个最大节点后剩余 %s 核\n", lossNodes, formatCores(pool.CPU.LossHeadroom))
+	fmt.Fprintf(&b, "**内存**: 请求 %s / %s（%.1f%%）", formatBytes(pool.Memory.Requested), formatBytes(pool.Memory.Allocatable), pool.Memory.RequestPercent)
+	if pool.UsageNodes > 0 {
+		fmt.Fprintf(&b, "，使用 %.1f%%", pool.Memory.UsedPercent)
+	}
+	fmt.Fprintf(&b, "，失去 %d 个最大节点后剩余 %s\n", lossNodes, formatBytes(pool.Memory.LossHeadroom))
+	fmt.Fprintf(&b, "**Pods**: %d / %d（%.1f%%），失去 %d 个最大节点后剩余 %d\n",
+		pool.Pods.Requested, pool.Pods.Allocatable, pool.Pods.RequestPercent, lossNodes, pool.Pods.LossHeadroom)
+
+	gpuNames := make([]string, 0, len(pool.GPU))
+	for name := range pool.GPU {
+		gpuNames = append(gpuNames, name)
+	}
+	sort.Strings(gpuNames)
+	for _, name := range gpuNames {
+		gpu := pool.GPU[name]
+		fmt.Fprintf(&b, "**%s**: 请求 %d / %d（%.1f%%）\n", name, gpu.Requested, gpu.Allocatable, gpu.RequestPercent)
+	}
+
+	for _, f := range pool.Forecasts {
+		switch f.Status {
+		case ForecastExhausted:
+			fmt.Fprintf(&b, "🔴 %s requests 已达到 allocatable\n", resourceTitle(f.Resource))
+		case ForecastGrowing:
+			fmt.Fprintf(&b, "⚠️ %s 预计 %.1f 天后（%s）requests 超过 allocatable\n",
+				resourceTitle(f.Resource), *f.DaysToExhaustion, f.ExhaustionTime.Format("2006-01-02"))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func resourceTitle(resource string) string {
+	switch resource {
+	case ResourceCPU:
+		return "CPU"
+	case ResourceMemory:
+		return "内存"
+	default:
+		return "Pods"
+	}
+}
+
+// formatCores 将毫核格式化为核数
+func formatCores(milli int64) string {
+	return fmt.Sprintf("%.2f", float64(milli)/1000)
+}
+
+// formatBytes 将字节格式化为 Gi
+func formatBytes(bytes int64) string {
+	return fmt.Sprintf("%.1fGi", float64(bytes)/(1024*1024*1024))
+}
@@ -170,6 +170,7 @@ func BuildHelpCard() string {
 /node info <节点名> - 查看节点详情
 /node cordon <节点名> [原因] - 禁止调度
 /node uncordon <节点名> - 恢复调度节点
+/node drain <节点名> [原因] - 驱逐节点（先预检再确认）
 /node batch <operation> <nodes> - 批量操作
 
 **标签管理命令**
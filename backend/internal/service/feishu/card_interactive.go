@@ -3,6 +3,7 @@ package feishu
 import (
 	"encoding/json"
 	"fmt"
+	"kube-node-manager/internal/service/k8s"
 	"strings"
 )
 
@@ -473,3 +474,41 @@ func BuildConfirmActionCard(action, target, description, confirmCommand string)
 	cardJSON, _ := json.Marshal(card)
 	return string(cardJSON)
 }
+
+// drainCardMaxRiskPods 驱逐确认卡片中最多列出的风险 Pod 数量
+const drainCardMaxRiskPods = 10
+
+// BuildDrainConfirmCard builds a drain confirmation card showing the preflight analysis
+func BuildDrainConfirmCard(nodeName, clusterName string, analysis *k8s.DrainAnalysis, confirmCommand string) string {
+	sum := analysis.Summary
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("集群: %s\n", clusterName))
+	b.WriteString(fmt.Sprintf("Pod 总数: %d，将驱逐: %d，跳过: %d，PDB 阻塞: %d\n", sum.TotalPods, sum.EvictPods, sum.SkipPods, sum.BlockedPods))
+	b.WriteString(fmt.Sprintf("本地存储: %d，无控制器: %d，容量不足: %d\n", sum.LocalStoragePods, sum.UnmanagedPods, sum.InsufficientCapacity))
+	b.WriteString(fmt.Sprintf("可接收节点: %d 个\n", len(sum.CandidateNodes)))
+
+	if analysis.Safe {
+		b.WriteString("\n✅ 预检通过，未发现风险 Pod")
+	} else {
+		b.WriteString("\n**风险 Pod**\n")
+		listed := 0
+		for _, pod := range analysis.Pods {
+			if len(pod.Reasons) == 0 {
+				continue
+			}
+			if listed == drainCardMaxRiskPods {
+				b.WriteString("...（更多风险 Pod 请在控制台查看）\n")
+				break
+			}
+			b.WriteString(fmt.Sprintf("• `%s/%s`: %s\n", pod.Namespace, pod.Name, strings.Join(pod.Reasons, "；")))
+			listed++
+		}
+	}
+
+	for _, warning := range analysis.Warnings {
+		b.WriteString(fmt.Sprintf("\n⚠️ %s", warning))
+	}
+
+	return BuildConfirmActionCard("驱逐节点", nodeName, b.String(), confirmCommand)
+}
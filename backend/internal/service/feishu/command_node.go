@@ -16,7 +16,7 @@ func (h *NodeCommandHandler) Handle(ctx *CommandContext) (*CommandResponse, erro
 	// Node commands require action
 	if ctx.Command.Action == "" {
 		return &CommandResponse{
-			Text: "请指定操作。用法: /node <list|info|cordon|uncordon|batch> [参数...]",
+			Text: "请指定操作。用法: /node <list|info|cordon|uncordon|drain|batch> [参数...]",
 		}, nil
 	}
 
@@ -29,11 +29,15 @@ func (h *NodeCommandHandler) Handle(ctx *CommandContext) (*CommandResponse, erro
 		return h.handleCordon(ctx)
 	case "uncordon":
 		return h.handleUncordon(ctx)
+	case "drain":
+		return h.handleDrain(ctx)
+	case "drain-confirm":
+		return h.handleDrainConfirm(ctx)
 	case "batch":
 		return h.handleBatchOperation(ctx)
 	default:
 		return &CommandResponse{
-			Text: fmt.Sprintf("未知操作: %s。支持的操作: list, info, cordon, uncordon, batch", ctx.Command.Action),
+			Text: fmt.Sprintf("未知操作: %s。支持的操作: list, info, cordon, uncordon, drain, batch", ctx.Command.Action),
 		}, nil
 	}
 }
@@ -429,6 +433,110 @@ func (h *NodeCommandHandler) handleUncordon(ctx *CommandContext) (*CommandRespon
 	}, nil
 }
 
+// handleDrain handles the node drain command: runs a preflight analysis and asks for confirmation
+func (h *NodeCommandHandler) handleDrain(ctx *CommandContext) (*CommandResponse, error) {
+	clusterName, err := ctx.Service.GetCurrentCluster(ctx.UserMapping.FeishuUserID)
+	if err != nil {
+		return &CommandResponse{
+			Card: BuildErrorCard(fmt.Sprintf("获取当前集群失败: %s", err.Error())),
+		}, nil
+	}
+
+	if clusterName == "" {
+		return &CommandResponse{
+			Card: BuildErrorCard("❌ 尚未选择集群\n\n请先使用 /cluster list 查看集群列表\n然后使用 /cluster set <集群名> 选择集群"),
+		}, nil
+	}
+
+	if len(ctx.Command.Args) < 1 {
+		return &CommandResponse{
+			Card: BuildErrorCard("参数不足。用法: /node drain <节点名> [原因]"),
+		}, nil
+	}
+
+	if ctx.Service.nodeService == nil {
+		return &CommandResponse{
+			Card: BuildErrorCard("节点服务未配置"),
+		}, nil
+	}
+
+	nodeName := ctx.Command.Args[0]
+	reason := joinArgs(ctx.Command.Args[1:])
+
+	result, err := ctx.Service.nodeService.DrainPreflight(node.DrainPreflightRequest{
+		ClusterName: clusterName,
+		Nodes:       []string{nodeName},
+	}, ctx.UserMapping.SystemUserID)
+	if err != nil {
+		ctx.Service.logger.Error(fmt.Sprintf("驱逐预检失败: %v", err))
+		return &CommandResponse{
+			Card: BuildErrorCard(fmt.Sprintf("驱逐预检失败: %s", err.Error())),
+		}, nil
+	}
+
+	analysis, ok := result.(*k8s.DrainAnalysis)
+	if !ok {
+		return &CommandResponse{
+			Card: BuildErrorCard("预检数据格式错误"),
+		}, nil
+	}
+
+	confirmCommand := fmt.Sprintf("/node drain-confirm %s", nodeName)
+	if reason != "" {
+		confirmCommand += " " + reason
+	}
+
+	return &CommandResponse{
+		Card: BuildDrainConfirmCard(nodeName, clusterName, analysis, confirmCommand),
+	}, nil
+}
+
+// handleDrainConfirm handles the confirmed drain from the drain confirmation card
+func (h *NodeCommandHandler) handleDrainConfirm(ctx *CommandContext) (*CommandResponse, error) {
+	clusterName, err := ctx.Service.GetCurrentCluster(ctx.UserMapping.FeishuUserID)
+	if err != nil {
+		return &CommandResponse{
+			Card: BuildErrorCard(fmt.Sprintf("获取当前集群失败: %s", err.Error())),
+		}, nil
+	}
+
+	if clusterName == "" || len(ctx.Command.Args) < 1 {
+		return &CommandResponse{
+			Card: BuildErrorCard("参数不足。请使用 /node drain <节点名> [原因] 重新发起驱逐"),
+		}, nil
+	}
+
+	if ctx.Service.nodeService == nil {
+		return &CommandResponse{
+			Card: BuildErrorCard("节点服务未配置"),
+		}, nil
+	}
+
+	nodeName := ctx.Command.Args[0]
+	reason := joinArgs(ctx.Command.Args[1:])
+
+	err = ctx.Service.nodeService.Drain(node.DrainRequest{
+		ClusterName: clusterName,
+		NodeName:    nodeName,
+		Reason:      reason,
+	}, ctx.UserMapping.SystemUserID)
+	if err != nil {
+		ctx.Service.logger.Error(fmt.Sprintf("驱逐节点失败: %v", err))
+		return &CommandResponse{
+			Card: BuildErrorCard(fmt.Sprintf("驱逐节点失败: %s", err.Error())),
+		}, nil
+	}
+
+	reasonText := ""
+	if reason != "" {
+		reasonText = fmt.Sprintf("\n原因: %s", reason)
+	}
+
+	return &CommandResponse{
+		Card: BuildSuccessCard(fmt.Sprintf("✅ 节点已驱逐完成\n\n节点: `%s`\n集群: %s%s", nodeName, clusterName, reasonText)),
+	}, nil
+}
+
 // handleBatchOperation handles batch operations on multiple nodes
 func (h *NodeCommandHandler) handleBatchOperation(ctx *CommandContext) (*CommandResponse, error) {
 	// 批量操作格式: /node batch <operation> <node1,node2,node3> [args...]
@@ -25,6 +25,8 @@ type NodeServiceInterface interface {
 	Get(req interface{}, userID uint) (interface{}, error)
 	Cordon(req interface{}, userID uint) error
 	Uncordon(req interface{}, userID uint) error
+	DrainPreflight(req interface{}, userID uint) (interface{}, error)
+	Drain(req interface{}, userID uint) error
 }
 
 // AuditServiceInterface 审计服务接口
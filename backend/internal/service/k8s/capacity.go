@@ -0,0 +1,98 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// NodeCapacity 节点容量（数值形式，用于容量规划）
+type NodeCapacity struct {
+	NodeName          string
+	Labels            map[string]string
+	Ready             bool
+	Schedulable       bool
+	CPUAllocatable    int64 // 毫核
+	MemoryAllocatable int64 // 字节
+	PodsAllocatable   int64
+	GPUAllocatable    map[string]int64
+	CPURequests       int64 // 毫核，节点上非终止 Pod 的 requests 之和
+	MemoryRequests    int64 // 字节
+	Pods              int64 // 非终止 Pod 数量
+	GPURequests       map[string]int64
+}
+
+// CollectNodeCapacity 汇总集群各节点的 allocatable 和已分配的 requests
+// 节点来自 ListNodesWithCache，Pod 只使用 Informer 缓存，缓存不可用时返回 error，避免全量拉取 Pod
+func (s *Service) CollectNodeCapacity(clusterName string) ([]NodeCapacity, error) {
+	nodes, err := s.ListNodesWithCache(clusterName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := s.getPodsFromInformer(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("pod cache not available for cluster %s: %w", clusterName, err)
+	}
+
+	requested := make(map[string]*podResources, len(nodes))
+	podCounts := make(map[string]int64, len(nodes))
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || isPodTerminated(pod) {
+			continue
+		}
+		res, ok := requested[pod.Spec.NodeName]
+		if !ok {
+			res = &podResources{}
+			requested[pod.Spec.NodeName] = res
+		}
+		res.add(computePodResources(pod))
+		podCounts[pod.Spec.NodeName]++
+	}
+
+	result := make([]NodeCapacity, 0, len(nodes))
+	for _, node := range nodes {
+		nc := NodeCapacity{
+			NodeName:          node.Name,
+			Labels:            node.Labels,
+			Ready:             strings.HasPrefix(node.Status, "Ready"),
+			Schedulable:       node.Schedulable,
+			CPUAllocatable:    parseMilli(node.Allocatable.CPU),
+			MemoryAllocatable: parseValue(node.Allocatable.Memory),
+			PodsAllocatable:   parseValue(node.Allocatable.Pods),
+			Pods:              podCounts[node.Name],
+		}
+		if len(node.Allocatable.GPU) > 0 {
+			nc.GPUAllocatable = make(map[string]int64, len(node.Allocatable.GPU))
+			for name, v := range node.Allocatable.GPU {
+				nc.GPUAllocatable[name] = parseValue(v)
+			}
+		}
+		if res, ok := requested[node.Name]; ok {
+			nc.CPURequests = res.cpuRequests
+			nc.MemoryRequests = res.memoryRequests
+			nc.GPURequests = res.gpuRequests
+		}
+		result = append(result, nc)
+	}
+	return result, nil
+}
+
+// parseMilli 解析 NodeInfo 中格式化后的资源数量为毫值，无法解析时返回 0
+func parseMilli(value string) int64 {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0
+	}
+	return q.MilliValue()
+}
+
+// parseValue 解析 NodeInfo 中格式化后的资源数量，无法解析时返回 0
+func parseValue(value string) int64 {
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0
+	}
+	return q.Value()
+}
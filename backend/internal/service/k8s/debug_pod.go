@@ -0,0 +1,276 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	debugPodContainer  = "debugger"
+	debugPodHostRoot   = "/host"
+	debugPodLabelKey   = "kube-node-manager/node-debugger"
+	debugPodNodeAnnKey = "kube-node-manager/debug-node"
+	debugPodUserAnnKey = "kube-node-manager/debug-user"
+)
+
+// debugShellCommand 优先 chroot 到宿主机使用宿主机的 shell，宿主机没有 shell 时使用调试镜像自带的 sh
+var debugShellCommand = []string{"sh", "-c",
+	"if [ -x " + debugPodHostRoot + "/bin/bash ]; then exec chroot " + debugPodHostRoot + " /bin/bash -l; " +
+		"elif [ -x " + debugPodHostRoot + "/bin/sh ]; then exec chroot " + debugPodHostRoot + " /bin/sh -l; " +
+		"else echo 'no shell found on host, using debug image shell (host filesystem mounted at " + debugPodHostRoot + ")'; exec sh; fi"}
+
+// DebugPodConfig 节点调试 Pod 配置
+type DebugPodConfig struct {
+	Enabled        bool          // 是否允许通过调试 Pod 打开节点终端
+	Image          string        // 调试镜像，需要包含 sh 和 chroot
+	Namespace      string        // 调试 Pod 所在命名空间
+	MaxDuration    time.Duration // 单个会话最长时间，超时后 Pod 由 activeDeadlineSeconds 终止
+	StartupTimeout time.Duration // 等待 Pod 运行的超时时间
+}
+
+// DefaultDebugPodConfig 默认调试 Pod 配置
+// 调试 Pod 是特权 Pod 且需要额外的 RBAC 权限，默认关闭，由运维显式开启
+func DefaultDebugPodConfig() DebugPodConfig {
+	return DebugPodConfig{
+		Enabled:        false,
+		Image:          "busybox:1.36",
+		Namespace:      "default",
+		MaxDuration:    2 * time.Hour,
+		StartupTimeout: 2 * time.Minute,
+	}
+}
+
+// SetDebugPodConfig 设置节点调试 Pod 配置，未配置的字段使用默认值
+func (s *Service) SetDebugPodConfig(config DebugPodConfig) {
+	defaults := DefaultDebugPodConfig()
+	if config.Image == "" {
+		config.Image = defaults.Image
+	}
+	if config.Namespace == "" {
+		config.Namespace = defaults.Namespace
+	}
+	if config.MaxDuration <= 0 {
+		config.MaxDuration = defaults.MaxDuration
+	}
+	if config.StartupTimeout <= 0 {
+		config.StartupTimeout = defaults.StartupTimeout
+	}
+	s.debugPodConfig = config
+}
+
+// DebugPodEnabled 是否启用节点调试 Pod
+func (s *Service) DebugPodEnabled() bool {
+	return s.debugPodConfig.Enabled
+}
+
+// CreateNodeDebugPod 在节点上创建特权调试 Pod（与 kubectl debug node 类似）
+// Pod 共享宿主机 PID/网络/IPC 命名空间并把宿主机根目录挂载到 /host，容忍所有污点，超过最长会话时间后自动终止
+func (s *Service) CreateNodeDebugPod(ctx context.Context, clusterName, nodeName, username string) (*corev1.Pod, error) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	config := s.debugPodConfig
+
+	// 清理异常退出遗留的调试 Pod，失败不影响本次创建
+	s.cleanupStaleDebugPods(ctx, clusterName)
+
+	pod := buildDebugPod(nodeName, username, config)
+	created, err := client.CoreV1().Pods(config.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create debug pod: %w", err)
+	}
+	s.logger.Infof("Created debug pod %s/%s on node %s in cluster %s for user %s",
+		created.Namespace, created.Name, nodeName, clusterName, username)
+	return created, nil
+}
+
+// WaitForDebugPodRunning 等待调试 Pod 进入 Running 状态，镜像拉取失败等不可恢复的错误会立即返回
+func (s *Service) WaitForDebugPodRunning(ctx context.Context, clusterName string, pod *corev1.Pod) error {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.debugPodConfig.StartupTimeout)
+	defer cancel()
+
+	var lastReason string
+	err = wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		current, err := client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to get debug pod: %w", err)
+		}
+		switch current.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodFailed, corev1.PodSucceeded:
+			return false, fmt.Errorf("debug pod exited with phase %s: %s", current.Status.Phase, current.Status.Message)
+		}
+		for _, status := range current.Status.ContainerStatuses {
+			if status.State.Waiting == nil {
+				continue
+			}
+			lastReason = status.State.Waiting.Reason
+			switch lastReason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError", "CreateContainerError":
+				return false, fmt.Errorf("debug pod failed to start: %s: %s", lastReason, status.State.Waiting.Message)
+			}
+		}
+		return false, nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("timed out after %v waiting for debug pod to run (last state: %s)", s.debugPodConfig.StartupTimeout, lastReason)
+	}
+	return err
+}
+
+// ExecInDebugPod 在调试 Pod 中启动交互式 shell，直到 shell 退出或 ctx 取消
+func (s *Service) ExecInDebugPod(ctx context.Context, clusterName string, pod *corev1.Pod, stdin io.Reader, stdout io.Writer, sizeQueue remotecommand.TerminalSizeQueue) error {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	config, exists := s.restConfigs[clusterName]
+	s.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("rest config not found for cluster: %s", clusterName)
+	}
+	// 客户端默认的请求超时会中断长连接，流式会话不设超时
+	config = rest.CopyConfig(config)
+	config.Timeout = 0
+
+	req := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: debugPodContainer,
+			Command:   debugShellCommand,
+			Stdin:     true,
+			Stdout:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:             stdin,
+		Stdout:            stdout,
+		Tty:               true,
+		TerminalSizeQueue: sizeQueue,
+	})
+}
+
+// DeleteDebugPod 立即删除调试 Pod
+func (s *Service) DeleteDebugPod(ctx context.Context, clusterName string, pod *corev1.Pod) error {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return err
+	}
+
+	gracePeriod := int64(0)
+	err = client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete debug pod: %w", err)
+	}
+	s.logger.Infof("Deleted debug pod %s/%s in cluster %s", pod.Namespace, pod.Name, clusterName)
+	return nil
+}
+
+// cleanupStaleDebugPods 删除已结束或超过最长会话时间的调试 Pod（服务重启等情况下未能删除的 Pod）
+func (s *Service) cleanupStaleDebugPods(ctx context.Context, clusterName string) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return
+	}
+
+	pods, err := client.CoreV1().Pods(s.debugPodConfig.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: debugPodLabelKey + "=true",
+	})
+	if err != nil {
+		s.logger.Warningf("Failed to list debug pods in cluster %s: %v", clusterName, err)
+		return
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		finished := pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+		expired := time.Since(pod.CreationTimestamp.Time) > s.debugPodConfig.MaxDuration
+		if !finished && !expired {
+			continue
+		}
+		if err := s.DeleteDebugPod(ctx, clusterName, pod); err != nil {
+			s.logger.Warningf("Failed to clean up stale debug pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+}
+
+// buildDebugPod 构建固定在节点上的特权调试 Pod
+func buildDebugPod(nodeName, username string, config DebugPodConfig) *corev1.Pod {
+	deadline := int64(config.MaxDuration.Seconds())
+	gracePeriod := int64(0)
+	privileged := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: debugPodNamePrefix(nodeName),
+			Namespace:    config.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "kube-node-manager",
+				debugPodLabelKey:               "true",
+			},
+			Annotations: map[string]string{
+				debugPodNodeAnnKey: nodeName,
+				debugPodUserAnnKey: username,
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:                      nodeName,
+			HostPID:                       true,
+			HostNetwork:                   true,
+			HostIPC:                       true,
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:         &deadline,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			Tolerations:                   []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name:            debugPodContainer,
+				Image:           config.Image,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				// 主进程只负责保持 Pod 运行，终端通过 exec 接入
+				Command:         []string{"sleep", strconv.FormatInt(deadline, 10)},
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "host-root", MountPath: debugPodHostRoot}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "host-root",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+			}},
+		},
+	}
+	return pod
+}
+
+// debugPodNamePrefix 生成调试 Pod 名称前缀，节点名过长时截断
+func debugPodNamePrefix(nodeName string) string {
+	name := strings.ToLower(nodeName)
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return "node-debugger-" + strings.Trim(name, ".-") + "-"
+}
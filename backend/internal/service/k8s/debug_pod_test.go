@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBuildDebugPod(t *testing.T) {
+	config := DefaultDebugPodConfig()
+	if config.Enabled {
+		t.Error("privileged debug pods must be disabled by default")
+	}
+	config.MaxDuration = 30 * time.Minute
+	pod := buildDebugPod("ip-10-0-1-23.ec2.internal", "admin", config)
+
+	spec := pod.Spec
+	if spec.NodeName != "ip-10-0-1-23.ec2.internal" || !spec.HostPID || !spec.HostNetwork {
+		t.Fatalf("debug pod must be pinned to the node with host namespaces: %+v", spec)
+	}
+	if spec.ActiveDeadlineSeconds == nil || *spec.ActiveDeadlineSeconds != 1800 {
+		t.Errorf("unexpected active deadline: %v", spec.ActiveDeadlineSeconds)
+	}
+	if len(spec.Tolerations) != 1 || spec.Tolerations[0].Operator != corev1.TolerationOpExists {
+		t.Errorf("debug pod should tolerate all taints: %+v", spec.Tolerations)
+	}
+	container := spec.Containers[0]
+	if container.SecurityContext == nil || !*container.SecurityContext.Privileged {
+		t.Error("debug container must be privileged")
+	}
+	if container.VolumeMounts[0].MountPath != debugPodHostRoot || spec.Volumes[0].HostPath.Path != "/" {
+		t.Errorf("host root not mounted: %+v %+v", container.VolumeMounts, spec.Volumes)
+	}
+	if pod.Labels[debugPodLabelKey] != "true" || pod.Annotations[debugPodUserAnnKey] != "admin" {
+		t.Errorf("unexpected metadata: %+v %+v", pod.Labels, pod.Annotations)
+	}
+}
+
+func TestDebugPodNamePrefix(t *testing.T) {
+	prefix := debugPodNamePrefix("Node-" + strings.Repeat("a", 35) + ".-example")
+	if prefix != "node-debugger-node-"+strings.Repeat("a", 35)+"-" {
+		t.Errorf("unexpected prefix: %s", prefix)
+	}
+}
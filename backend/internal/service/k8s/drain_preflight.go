@@ -0,0 +1,297 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// 驱逐预检中单个 Pod 的结论
+const (
+	DrainVerdictEvict   = "evict"   // 将被驱逐
+	DrainVerdictSkip    = "skip"    // 不会被驱逐（DaemonSet、静态 Pod、已完成等）
+	DrainVerdictBlocked = "blocked" // 被 PDB 阻塞
+)
+
+// DrainPodVerdict 驱逐预检中单个 Pod 的分析结果
+type DrainPodVerdict struct {
+	Namespace            string   `json:"namespace"`
+	Name                 string   `json:"name"`
+	NodeName             string   `json:"node_name"`
+	OwnerKind            string   `json:"owner_kind,omitempty"`
+	OwnerName            string   `json:"owner_name,omitempty"`
+	Verdict              string   `json:"verdict"`
+	SkipReason           string   `json:"skip_reason,omitempty"`
+	BlockingPDB          string   `json:"blocking_pdb,omitempty"`
+	LocalStorage         []string `json:"local_storage,omitempty"` // emptyDir 卷或绑定本地 PV 的 PVC，驱逐后数据丢失
+	Unmanaged            bool     `json:"unmanaged"`               // 没有控制器，驱逐后不会被重建
+	InsufficientCapacity bool     `json:"insufficient_capacity"`   // 其他可调度节点上没有足够空间
+	Reasons              []string `json:"reasons,omitempty"`
+}
+
+// DrainSummary 驱逐预检汇总
+type DrainSummary struct {
+	TotalPods            int      `json:"total_pods"`
+	EvictPods            int      `json:"evict_pods"`
+	SkipPods             int      `json:"skip_pods"`
+	BlockedPods          int      `json:"blocked_pods"`
+	LocalStoragePods     int      `json:"local_storage_pods"`
+	UnmanagedPods        int      `json:"unmanaged_pods"`
+	InsufficientCapacity int      `json:"insufficient_capacity_pods"`
+	CandidateNodes       []string `json:"candidate_nodes"` // 可接收被驱逐 Pod 的节点
+}
+
+// DrainAnalysis 驱逐预检结果（dry-run，不修改集群）
+type DrainAnalysis struct {
+	ClusterName string            `json:"cluster_name"`
+	Nodes       []string          `json:"nodes"`
+	Pods        []DrainPodVerdict `json:"pods"`
+	Summary     DrainSummary      `json:"summary"`
+	Safe        bool              `json:"safe"` // 没有阻塞、本地存储、无控制器及容量不足的 Pod
+	Warnings    []string          `json:"warnings,omitempty"`
+	AnalyzedAt  time.Time         `json:"analyzed_at"`
+}
+
+// localStorageLookup 判断 PVC 是否绑定到本地 PV（local 或 hostPath）
+type localStorageLookup func(namespace, claimName string) bool
+
+// AnalyzeDrain 分析驱逐一个或多个节点的影响：哪些 Pod 会被驱逐、哪些被 PDB 阻塞、
+// 哪些使用本地存储或没有控制器，以及其他节点在当前污点和禁止调度状态下是否有足够容量
+func (s *Service) AnalyzeDrain(ctx context.Context, clusterName string, nodeNames []string) (*DrainAnalysis, error) {
+	if len(nodeNames) == 0 {
+		return nil, fmt.Errorf("no nodes specified")
+	}
+
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.loadClusterSnapshot(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(snapshot.nodes))
+	for _, node := range snapshot.nodes {
+		known[node.Name] = true
+	}
+	for _, name := range nodeNames {
+		if !known[name] {
+			return nil, fmt.Errorf("node %s not found", name)
+		}
+	}
+
+	var warnings []string
+	var pdbs []policyv1.PodDisruptionBudget
+	pdbList, err := client.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("无法获取 PodDisruptionBudget，未检查 PDB 阻塞: %v", err))
+	} else {
+		pdbs = pdbList.Items
+	}
+
+	// PVC 与 PV 按需查询并缓存
+	localClaims := make(map[string]bool)
+	lookup := func(namespace, claimName string) bool {
+		key := namespace + "/" + claimName
+		if v, ok := localClaims[key]; ok {
+			return v
+		}
+		local := false
+		pvc, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, claimName, metav1.GetOptions{})
+		if err == nil && pvc.Spec.VolumeName != "" {
+			pv, err := client.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+			if err == nil {
+				local = pv.Spec.Local != nil || pv.Spec.HostPath != nil
+			}
+		}
+		localClaims[key] = local
+		return local
+	}
+
+	analysis := s.analyzeDrain(snapshot, nodeNames, pdbs, lookup)
+	analysis.ClusterName = clusterName
+	analysis.Warnings = append(warnings, analysis.Warnings...)
+	return analysis, nil
+}
+
+// analyzeDrain 基于集群快照进行驱逐预检
+func (s *Service) analyzeDrain(snapshot *clusterSnapshot, nodeNames []string, pdbs []policyv1.PodDisruptionBudget, lookup localStorageLookup) *DrainAnalysis {
+	draining := make(map[string]bool, len(nodeNames))
+	for _, name := range nodeNames {
+		draining[name] = true
+	}
+
+	analysis := &DrainAnalysis{
+		Nodes:      nodeNames,
+		Pods:       []DrainPodVerdict{},
+		AnalyzedAt: time.Now(),
+	}
+
+	// 剩余允许中断数，按驱逐顺序逐个扣减
+	pdbSelectors := make([]labels.Selector, len(pdbs))
+	pdbRemaining := make([]int32, len(pdbs))
+	for i := range pdbs {
+		pdbRemaining[i] = pdbs[i].Status.DisruptionsAllowed
+		if pdbs[i].Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdbs[i].Spec.Selector)
+		if err == nil {
+			pdbSelectors[i] = selector
+		}
+	}
+
+	// 可接收被驱逐 Pod 的节点：不在驱逐列表中、可调度且就绪
+	var candidates []*nodeCapacity
+	for i := range snapshot.nodes {
+		node := &snapshot.nodes[i]
+		if draining[node.Name] || node.Spec.Unschedulable || !isNodeReady(node) {
+			continue
+		}
+		candidates = append(candidates, newNodeCapacity(node, snapshot.podsByNode[node.Name]))
+		analysis.Summary.CandidateNodes = append(analysis.Summary.CandidateNodes, node.Name)
+	}
+
+	type placement struct {
+		index int
+		pod   *corev1.Pod
+		res   podResources
+	}
+	var toPlace []placement
+
+	for _, nodeName := range nodeNames {
+		pods := snapshot.podsByNode[nodeName]
+		sort.Slice(pods, func(i, j int) bool {
+			if pods[i].Namespace != pods[j].Namespace {
+				return pods[i].Namespace < pods[j].Namespace
+			}
+			return pods[i].Name < pods[j].Name
+		})
+
+		for _, pod := range pods {
+			kind, name := podOwner(pod)
+			v := DrainPodVerdict{
+				Namespace: pod.Namespace,
+				Name:      pod.Name,
+				NodeName:  nodeName,
+				OwnerKind: kind,
+				OwnerName: name,
+			}
+
+			if reason := s.podEvictionSkipReason(pod); reason != "" {
+				v.Verdict = DrainVerdictSkip
+				v.SkipReason = reason
+				analysis.Pods = append(analysis.Pods, v)
+				continue
+			}
+
+			v.Verdict = DrainVerdictEvict
+			for i := range pdbs {
+				if pdbSelectors[i] == nil || pdbs[i].Namespace != pod.Namespace || !pdbSelectors[i].Matches(labels.Set(pod.Labels)) {
+					continue
+				}
+				if pdbRemaining[i] <= 0 {
+					v.Verdict = DrainVerdictBlocked
+					v.BlockingPDB = pdbs[i].Name
+					v.Reasons = append(v.Reasons, fmt.Sprintf("PDB %s 不允许更多中断", pdbs[i].Name))
+					break
+				}
+			}
+			if v.Verdict == DrainVerdictEvict {
+				for i := range pdbs {
+					if pdbSelectors[i] != nil && pdbs[i].Namespace == pod.Namespace && pdbSelectors[i].Matches(labels.Set(pod.Labels)) {
+						pdbRemaining[i]--
+					}
+				}
+			}
+
+			for _, vol := range pod.Spec.Volumes {
+				switch {
+				case vol.EmptyDir != nil:
+					v.LocalStorage = append(v.LocalStorage, "emptyDir:"+vol.Name)
+				case vol.PersistentVolumeClaim != nil && lookup != nil && lookup(pod.Namespace, vol.PersistentVolumeClaim.ClaimName):
+					v.LocalStorage = append(v.LocalStorage, "local-pv:"+vol.PersistentVolumeClaim.ClaimName)
+				}
+			}
+			if len(v.LocalStorage) > 0 {
+				v.Reasons = append(v.Reasons, "使用本地存储，驱逐后数据丢失")
+			}
+
+			if metav1.GetControllerOf(pod) == nil {
+				v.Unmanaged = true
+				v.Reasons = append(v.Reasons, "没有控制器，驱逐后不会被重建")
+			} else {
+				toPlace = append(toPlace, placement{index: len(analysis.Pods), pod: pod, res: computePodResources(pod)})
+			}
+
+			analysis.Pods = append(analysis.Pods, v)
+		}
+	}
+
+	// 按 requests 从大到小依次放置，找不到可用节点的 Pod 标记为容量不足
+	sort.SliceStable(toPlace, func(i, j int) bool {
+		if toPlace[i].res.cpuRequests != toPlace[j].res.cpuRequests {
+			return toPlace[i].res.cpuRequests > toPlace[j].res.cpuRequests
+		}
+		return toPlace[i].res.memoryRequests > toPlace[j].res.memoryRequests
+	})
+	for _, p := range toPlace {
+		placed := false
+		lastReason := "没有可调度的节点"
+		for _, c := range candidates {
+			ok, reason := podFitsNode(p.pod, c, p.res)
+			if ok {
+				c.reserve(p.res)
+				placed = true
+				break
+			}
+			lastReason = reason
+		}
+		if !placed {
+			v := &analysis.Pods[p.index]
+			v.InsufficientCapacity = true
+			v.Reasons = append(v.Reasons, "其他节点无法容纳: "+lastReason)
+		}
+	}
+
+	for _, v := range analysis.Pods {
+		analysis.Summary.TotalPods++
+		switch v.Verdict {
+		case DrainVerdictEvict:
+			analysis.Summary.EvictPods++
+		case DrainVerdictSkip:
+			analysis.Summary.SkipPods++
+		case DrainVerdictBlocked:
+			analysis.Summary.BlockedPods++
+		}
+		if len(v.LocalStorage) > 0 {
+			analysis.Summary.LocalStoragePods++
+		}
+		if v.Unmanaged {
+			analysis.Summary.UnmanagedPods++
+		}
+		if v.InsufficientCapacity {
+			analysis.Summary.InsufficientCapacity++
+		}
+	}
+
+	sum := analysis.Summary
+	analysis.Safe = sum.BlockedPods == 0 && sum.LocalStoragePods == 0 && sum.UnmanagedPods == 0 && sum.InsufficientCapacity == 0
+	if sum.BlockedPods > 0 {
+		// evictPod 在驱逐被拒绝（429）时会回退为直接删除
+		analysis.Warnings = append(analysis.Warnings, fmt.Sprintf("%d 个 Pod 被 PDB 阻塞，执行驱逐时将回退为直接删除并绕过 PDB", sum.BlockedPods))
+	}
+	if len(candidates) == 0 && len(toPlace) > 0 {
+		analysis.Warnings = append(analysis.Warnings, "集群中没有其他可调度且就绪的节点")
+	}
+
+	return analysis
+}
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testNode(name, cpu string, unschedulable bool) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func testPod(name, node, cpu, ownerKind string, podLabels map[string]string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: podLabels},
+		Spec: corev1.PodSpec{
+			NodeName:   node,
+			Containers: []corev1.Container{container(cpu, "", "", "")},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: name + "-owner", Controller: &controller}}
+	}
+	return pod
+}
+
+func TestAnalyzeDrain(t *testing.T) {
+	pods := []*corev1.Pod{
+		testPod("ds", "n1", "100m", "DaemonSet", nil),
+		testPod("web-1", "n1", "500m", "ReplicaSet", map[string]string{"app": "web"}),
+		testPod("web-2", "n1", "500m", "ReplicaSet", map[string]string{"app": "web"}),
+		testPod("bare", "n1", "100m", "", nil),
+		testPod("big", "n1", "3", "StatefulSet", nil),
+		testPod("other", "n2", "500m", "ReplicaSet", nil),
+	}
+	pods[4].Spec.Volumes = []corev1.Volume{{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+
+	snapshot := &clusterSnapshot{
+		nodes: []corev1.Node{
+			testNode("n1", "4", false),
+			testNode("n2", "2", false),
+			testNode("n3", "8", true), // 已禁止调度，不能接收 Pod
+		},
+		podsByNode: map[string][]*corev1.Pod{},
+	}
+	for _, pod := range pods {
+		snapshot.podsByNode[pod.Spec.NodeName] = append(snapshot.podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	pdbs := []policyv1.PodDisruptionBudget{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-pdb", Namespace: "default"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+	}}
+
+	s := &Service{}
+	analysis := s.analyzeDrain(snapshot, []string{"n1"}, pdbs, nil)
+
+	verdicts := make(map[string]DrainPodVerdict)
+	for _, v := range analysis.Pods {
+		verdicts[v.Name] = v
+	}
+
+	if v := verdicts["ds"]; v.Verdict != DrainVerdictSkip || v.SkipReason != EvictSkipDaemonSet {
+		t.Errorf("ds verdict = %s/%s, want skip/daemonset", v.Verdict, v.SkipReason)
+	}
+	// PDB 只允许一次中断：第一个被驱逐，第二个被阻塞
+	if verdicts["web-1"].Verdict != DrainVerdictEvict || verdicts["web-2"].Verdict != DrainVerdictBlocked {
+		t.Errorf("web verdicts = %s, %s, want evict, blocked", verdicts["web-1"].Verdict, verdicts["web-2"].Verdict)
+	}
+	if !verdicts["bare"].Unmanaged {
+		t.Error("bare pod should be unmanaged")
+	}
+	if v := verdicts["big"]; !v.InsufficientCapacity || len(v.LocalStorage) != 1 {
+		t.Errorf("big pod = %+v, want insufficient capacity with local storage", v)
+	}
+	if verdicts["web-1"].InsufficientCapacity {
+		t.Error("web-1 should fit on n2")
+	}
+	if analysis.Safe {
+		t.Error("analysis should not be safe")
+	}
+	if len(analysis.Summary.CandidateNodes) != 1 || analysis.Summary.CandidateNodes[0] != "n2" {
+		t.Errorf("candidate nodes = %v, want [n2]", analysis.Summary.CandidateNodes)
+	}
+}
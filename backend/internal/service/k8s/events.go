@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// NodeEvent 节点相关的 Kubernetes Event
+type NodeEvent struct {
+	Type           string    `json:"type"` // Normal 或 Warning
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Source         string    `json:"source"` // 上报组件，如 kubelet、node-controller
+	Count          int32     `json:"count"`
+	FirstTimestamp time.Time `json:"first_timestamp"`
+	LastTimestamp  time.Time `json:"last_timestamp"`
+}
+
+// ListNodeEvents 获取节点的 Kubernetes Events，按最后发生时间倒序
+// Events 由 API Server 按 TTL（默认 1 小时）保留，只能反映最近的情况
+func (s *Service) ListNodeEvents(ctx context.Context, clusterName, nodeName string) ([]NodeEvent, error) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	eventList, err := client.CoreV1().Events("").List(ctx, metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{
+			"involvedObject.kind": "Node",
+			"involvedObject.name": nodeName,
+		}).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events for node %s: %w", nodeName, err)
+	}
+
+	events := make([]NodeEvent, 0, len(eventList.Items))
+	for i := range eventList.Items {
+		events = append(events, ConvertNodeEvent(&eventList.Items[i]))
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].LastTimestamp.After(events[j].LastTimestamp)
+	})
+	return events, nil
+}
+
+// ConvertNodeEvent 转换 Event，兼容只设置了 EventTime 和 Series 的新版事件
+func ConvertNodeEvent(e *corev1.Event) NodeEvent {
+	first := e.FirstTimestamp.Time
+	last := e.LastTimestamp.Time
+	count := e.Count
+	if e.Series != nil {
+		if last.IsZero() || e.Series.LastObservedTime.After(last) {
+			last = e.Series.LastObservedTime.Time
+		}
+		count = max(count, e.Series.Count)
+	}
+	if last.IsZero() {
+		last = e.EventTime.Time
+	}
+	if last.IsZero() {
+		last = e.CreationTimestamp.Time
+	}
+	if first.IsZero() {
+		first = e.EventTime.Time
+	}
+	if first.IsZero() || first.After(last) {
+		first = last
+	}
+
+	source := e.Source.Component
+	if source == "" {
+		source = e.ReportingController
+	}
+	if count == 0 {
+		count = 1
+	}
+
+	return NodeEvent{
+		Type:           e.Type,
+		Reason:         e.Reason,
+		Message:        e.Message,
+		Source:         source,
+		Count:          count,
+		FirstTimestamp: first,
+		LastTimestamp:  last,
+	}
+}
@@ -0,0 +1,317 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeMutation 根据节点当前的标签和污点计算变更后的标签和污点
+type NodeMutation func(labels map[string]string, taints []TaintInfo) (map[string]string, []TaintInfo, error)
+
+// ImpactedPod 变更后将被驱逐或删除的 Pod
+type ImpactedPod struct {
+	Namespace         string `json:"namespace"`
+	Name              string `json:"name"`
+	NodeName          string `json:"node_name"`
+	OwnerKind         string `json:"owner_kind,omitempty"`
+	OwnerName         string `json:"owner_name,omitempty"`
+	Reason            string `json:"reason"`
+	TolerationSeconds *int64 `json:"toleration_seconds,omitempty"` // 容忍有时限时，Pod 在该秒数后被驱逐
+}
+
+// WorkloadImpact 变更后失去全部可调度节点的工作负载
+type WorkloadImpact struct {
+	Namespace           string `json:"namespace"`
+	Kind                string `json:"kind"`
+	Name                string `json:"name"`
+	Pods                int    `json:"pods"`
+	PendingPods         int    `json:"pending_pods"`
+	EligibleNodesBefore int    `json:"eligible_nodes_before"`
+	EligibleNodesAfter  int    `json:"eligible_nodes_after"`
+	Reason              string `json:"reason"` // 变更节点上不再满足的条件示例
+}
+
+// ChangeImpact 标签或污点变更的影响模拟结果（不修改集群）
+type ChangeImpact struct {
+	ClusterName       string           `json:"cluster_name"`
+	Nodes             []string         `json:"nodes"`
+	EvictedPods       []ImpactedPod    `json:"evicted_pods"`
+	AffectedWorkloads []WorkloadImpact `json:"affected_workloads"`
+	Safe              bool             `json:"safe"`
+	AnalyzedAt        time.Time        `json:"analyzed_at"`
+}
+
+// SimulateNodeChanges 模拟对一组节点的标签或污点变更：
+// 列出因新增 NoExecute 污点被驱逐（或因 DaemonSet 节点选择器不再匹配被删除）的运行中 Pod，
+// 以及变更后失去全部可调度节点的工作负载
+func (s *Service) SimulateNodeChanges(ctx context.Context, clusterName string, nodeNames []string, mutate NodeMutation) (*ChangeImpact, error) {
+	if len(nodeNames) == 0 {
+		return nil, fmt.Errorf("no nodes specified")
+	}
+
+	snapshot, err := s.loadClusterSnapshot(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	proposed, err := applyNodeMutation(snapshot.nodes, nodeNames, mutate)
+	if err != nil {
+		return nil, err
+	}
+
+	impact := simulateNodeChanges(snapshot, proposed)
+	impact.ClusterName = clusterName
+	impact.Nodes = nodeNames
+	return impact, nil
+}
+
+// applyNodeMutation 对快照中的节点副本应用变更，返回变更后的节点
+func applyNodeMutation(nodes []corev1.Node, nodeNames []string, mutate NodeMutation) (map[string]*corev1.Node, error) {
+	byName := make(map[string]*corev1.Node, len(nodes))
+	for i := range nodes {
+		byName[nodes[i].Name] = &nodes[i]
+	}
+
+	proposed := make(map[string]*corev1.Node, len(nodeNames))
+	for _, name := range nodeNames {
+		node, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("node %s not found", name)
+		}
+
+		labels, taintInfos, err := mutate(copyStringMap(node.Labels), taintsToInfo(node.Spec.Taints))
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", name, err)
+		}
+
+		changed := node.DeepCopy()
+		changed.Labels = labels
+		changed.Spec.Taints = taintInfoToTaints(taintInfos)
+		proposed[name] = changed
+	}
+	return proposed, nil
+}
+
+// simulateNodeChanges 基于快照和变更后的节点计算影响
+func simulateNodeChanges(snapshot *clusterSnapshot, proposed map[string]*corev1.Node) *ChangeImpact {
+	impact := &ChangeImpact{
+		EvictedPods:       []ImpactedPod{},
+		AffectedWorkloads: []WorkloadImpact{},
+		AnalyzedAt:        time.Now(),
+	}
+
+	current := make(map[string]*corev1.Node, len(snapshot.nodes))
+	for i := range snapshot.nodes {
+		current[snapshot.nodes[i].Name] = &snapshot.nodes[i]
+	}
+
+	// 变更节点上的运行中 Pod
+	for name, after := range proposed {
+		before := current[name]
+		for _, pod := range snapshot.podsByNode[name] {
+			if isPodTerminated(pod) || pod.DeletionTimestamp != nil {
+				continue
+			}
+			if evicted, ok := podEvictedByChange(pod, before, after); ok {
+				impact.EvictedPods = append(impact.EvictedPods, evicted)
+			}
+		}
+	}
+	sort.Slice(impact.EvictedPods, func(i, j int) bool {
+		a, b := impact.EvictedPods[i], impact.EvictedPods[j]
+		if a.NodeName != b.NodeName {
+			return a.NodeName < b.NodeName
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	// 按控制器聚合工作负载，DaemonSet 按节点逐个处理（已在上面计入）
+	type workload struct {
+		impact WorkloadImpact
+		sample *corev1.Pod
+	}
+	workloads := make(map[string]*workload)
+	var keys []string
+	for _, pod := range snapshot.pods {
+		if isPodTerminated(pod) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		kind, name := podOwner(pod)
+		if kind == "DaemonSet" || kind == "Node" {
+			continue
+		}
+		if kind == "" {
+			kind, name = "Pod", pod.Name
+		}
+		key := pod.Namespace + "/" + kind + "/" + name
+		w, ok := workloads[key]
+		if !ok {
+			w = &workload{
+				impact: WorkloadImpact{Namespace: pod.Namespace, Kind: kind, Name: name},
+				sample: pod,
+			}
+			workloads[key] = w
+			keys = append(keys, key)
+		}
+		w.impact.Pods++
+		if pod.Spec.NodeName == "" {
+			w.impact.PendingPods++
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		w := workloads[key]
+		before, after := 0, 0
+		reason := ""
+		for i := range snapshot.nodes {
+			node := &snapshot.nodes[i]
+			eligibleBefore, _ := podEligibleOnNode(w.sample, node)
+			if eligibleBefore {
+				before++
+			}
+			changed, ok := proposed[node.Name]
+			if !ok {
+				if eligibleBefore {
+					after++
+				}
+				continue
+			}
+			eligibleAfter, why := podEligibleOnNode(w.sample, changed)
+			if eligibleAfter {
+				after++
+			} else if eligibleBefore && reason == "" {
+				reason = fmt.Sprintf("%s: %s", node.Name, why)
+			}
+		}
+		if before > 0 && after == 0 {
+			w.impact.EligibleNodesBefore = before
+			w.impact.EligibleNodesAfter = after
+			w.impact.Reason = reason
+			impact.AffectedWorkloads = append(impact.AffectedWorkloads, w.impact)
+		}
+	}
+
+	impact.Safe = len(impact.EvictedPods) == 0 && len(impact.AffectedWorkloads) == 0
+	return impact
+}
+
+// podEvictedByChange 判断节点变更后运行中的 Pod 是否会被移除
+func podEvictedByChange(pod *corev1.Pod, before, after *corev1.Node) (ImpactedPod, bool) {
+	kind, name := podOwner(pod)
+	result := ImpactedPod{
+		Namespace: pod.Namespace,
+		Name:      pod.Name,
+		NodeName:  after.Name,
+		OwnerKind: kind,
+		OwnerName: name,
+	}
+
+	// 新增的 NoExecute 污点：不容忍则立即驱逐，有时限容忍则在时限后驱逐
+	for i := range after.Spec.Taints {
+		taint := &after.Spec.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoExecute || nodeHasTaint(before, taint) {
+			continue
+		}
+		tolerated := false
+		var seconds *int64
+		for j := range pod.Spec.Tolerations {
+			toleration := &pod.Spec.Tolerations[j]
+			if !toleration.ToleratesTaint(taint) {
+				continue
+			}
+			if toleration.TolerationSeconds == nil {
+				tolerated = true
+				break
+			}
+			if seconds == nil || *toleration.TolerationSeconds > *seconds {
+				seconds = toleration.TolerationSeconds
+			}
+		}
+		if tolerated {
+			continue
+		}
+		result.Reason = fmt.Sprintf("不容忍新增污点 %s", formatTaint(taint))
+		result.TolerationSeconds = seconds
+		return result, true
+	}
+
+	// DaemonSet 控制器会删除节点选择器不再匹配的节点上的 Pod
+	if kind == "DaemonSet" && podMatchesNodeSelector(pod, before) && !podMatchesNodeSelector(pod, after) {
+		result.Reason = "DaemonSet 节点选择器或节点亲和性不再匹配"
+		return result, true
+	}
+
+	return ImpactedPod{}, false
+}
+
+// nodeHasTaint 判断节点是否已有相同 key、value、effect 的污点
+func nodeHasTaint(node *corev1.Node, taint *corev1.Taint) bool {
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].MatchTaint(taint) && node.Spec.Taints[i].Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// taintsToInfo 将 Kubernetes 污点转换为 TaintInfo
+func taintsToInfo(taints []corev1.Taint) []TaintInfo {
+	infos := make([]TaintInfo, 0, len(taints))
+	for _, taint := range taints {
+		info := TaintInfo{
+			Key:    taint.Key,
+			Value:  taint.Value,
+			Effect: string(taint.Effect),
+		}
+		if taint.TimeAdded != nil {
+			t := taint.TimeAdded.Time
+			info.TimeAdded = &t
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// taintInfoToTaints 将 TaintInfo 转换为 Kubernetes 污点
+func taintInfoToTaints(infos []TaintInfo) []corev1.Taint {
+	taints := make([]corev1.Taint, 0, len(infos))
+	for _, info := range infos {
+		taint := corev1.Taint{
+			Key:    info.Key,
+			Value:  info.Value,
+			Effect: corev1.TaintEffect(info.Effect),
+		}
+		if info.TimeAdded != nil {
+			taint.TimeAdded = &metav1.Time{Time: *info.TimeAdded}
+		}
+		taints = append(taints, taint)
+	}
+	return taints
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// ImpactNotAcknowledgedError 变更存在影响但调用方未确认
+type ImpactNotAcknowledgedError struct {
+	Impact *ChangeImpact
+}
+
+func (e *ImpactNotAcknowledgedError) Error() string {
+	return fmt.Sprintf("change impact must be acknowledged: %d pods would be evicted, %d workloads would lose all eligible nodes",
+		len(e.Impact.EvictedPods), len(e.Impact.AffectedWorkloads))
+}
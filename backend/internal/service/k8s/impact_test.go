@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSimulateNodeChanges(t *testing.T) {
+	n1 := testNode("n1", "4", false)
+	n1.Labels = map[string]string{"pool": "gpu"}
+	n2 := testNode("n2", "4", false)
+
+	tolerant := testPod("tolerant", "n1", "100m", "ReplicaSet", nil)
+	tolerant.Spec.Tolerations = []corev1.Toleration{{Key: "maintenance", Operator: corev1.TolerationOpExists}}
+	seconds := int64(300)
+	delayed := testPod("delayed", "n1", "100m", "ReplicaSet", nil)
+	delayed.Spec.Tolerations = []corev1.Toleration{{Key: "maintenance", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: &seconds}}
+	gpu := testPod("gpu", "n1", "100m", "StatefulSet", nil)
+	gpu.Spec.NodeSelector = map[string]string{"pool": "gpu"}
+	plain := testPod("plain", "n2", "100m", "ReplicaSet", nil)
+
+	snapshot := &clusterSnapshot{
+		nodes: []corev1.Node{n1, n2},
+		pods:  []*corev1.Pod{tolerant, delayed, gpu, plain},
+		podsByNode: map[string][]*corev1.Pod{
+			"n1": {tolerant, delayed, gpu},
+			"n2": {plain},
+		},
+	}
+
+	// 在 n1 上添加 NoExecute 污点并删除 pool 标签
+	proposed, err := applyNodeMutation(snapshot.nodes, []string{"n1"}, func(labels map[string]string, taints []TaintInfo) (map[string]string, []TaintInfo, error) {
+		delete(labels, "pool")
+		return labels, append(taints, TaintInfo{Key: "maintenance", Effect: "NoExecute"}), nil
+	})
+	if err != nil {
+		t.Fatalf("applyNodeMutation: %v", err)
+	}
+	if snapshot.nodes[0].Labels["pool"] != "gpu" {
+		t.Fatal("mutation must not modify the snapshot")
+	}
+
+	impact := simulateNodeChanges(snapshot, proposed)
+
+	evicted := make(map[string]ImpactedPod)
+	for _, p := range impact.EvictedPods {
+		evicted[p.Name] = p
+	}
+	if _, ok := evicted["tolerant"]; ok {
+		t.Error("tolerant pod should not be evicted")
+	}
+	if p, ok := evicted["delayed"]; !ok || p.TolerationSeconds == nil || *p.TolerationSeconds != 300 {
+		t.Errorf("delayed pod = %+v, want eviction after 300s", p)
+	}
+	if _, ok := evicted["gpu"]; !ok {
+		t.Error("gpu pod should be evicted")
+	}
+
+	if len(impact.AffectedWorkloads) != 1 || impact.AffectedWorkloads[0].Kind != "StatefulSet" {
+		t.Fatalf("affected workloads = %+v, want only the gpu StatefulSet", impact.AffectedWorkloads)
+	}
+	if w := impact.AffectedWorkloads[0]; w.EligibleNodesBefore != 1 || w.EligibleNodesAfter != 0 {
+		t.Errorf("eligible nodes = %d -> %d, want 1 -> 0", w.EligibleNodesBefore, w.EligibleNodesAfter)
+	}
+	if impact.Safe {
+		t.Error("impact should not be safe")
+	}
+}
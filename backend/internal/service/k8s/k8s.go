@@ -18,6 +18,7 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -34,6 +35,9 @@ type Service struct {
 	realtimeManager interface{}              // 实时同步管理器（使用接口避免循环依赖）
 	connPool        *ConnectionPool          // 连接池统计和管理
 	logLimiter      *logRateLimiter          // 日志限速器，避免重复日志刷屏
+
+	restConfigs    map[string]*rest.Config // 各集群的 REST 配置，exec 等流式接口需要
+	debugPodConfig DebugPodConfig          // 节点调试 Pod 配置
 }
 
 // NodeInfo Kubernetes节点信息
@@ -54,6 +58,7 @@ type NodeInfo struct {
 	Capacity            ResourceInfo       `json:"capacity"`
 	Allocatable         ResourceInfo       `json:"allocatable"`
 	Usage               *ResourceUsageInfo `json:"usage,omitempty"` // 资源使用情况
+	Allocated           *NodeAllocation    `json:"allocated,omitempty"` // 已分配资源（Pod requests/limits 之和）
 	Labels              map[string]string  `json:"labels"`
 	Taints              []TaintInfo        `json:"taints"`
 	Conditions          []NodeCondition    `json:"conditions"`
@@ -151,6 +156,8 @@ func NewService(logger *logger.Logger, realtimeMgr interface{}) *Service {
 		logger:          logger,
 		clients:         make(map[string]*kubernetes.Clientset),
 		metricsClients:  make(map[string]*metricsclientset.Clientset),
+		restConfigs:     make(map[string]*rest.Config),
+		debugPodConfig:  DefaultDebugPodConfig(),
 		cache:           cache.NewK8sCache(logger),
 		podCountCache:   podcache.NewPodCountCache(logger),
 		realtimeManager: realtimeMgr,
@@ -219,6 +226,7 @@ func (s *Service) CreateClient(clusterName, kubeconfig string) error {
 	}
 
 	s.clients[clusterName] = clientset
+	s.restConfigs[clusterName] = config
 
 	// 注册到连接池
 	s.connPool.RegisterConnection(clusterName)
@@ -264,6 +272,7 @@ func (s *Service) RemoveClient(clusterName string) {
 
 	delete(s.clients, clusterName)
 	delete(s.metricsClients, clusterName)
+	delete(s.restConfigs, clusterName)
 	s.logger.Infof("Removed Kubernetes client for cluster: %s", clusterName)
 }
 
@@ -474,6 +483,7 @@ func (s *Service) fetchNodesFromAPI(clusterName string) ([]NodeInfo, error) {
 
 	// 尝试获取资源使用情况
 	s.enrichNodesWithMetrics(clusterName, nodes)
+	s.enrichNodesWithAllocation(clusterName, nodeList.Items, nodes)
 
 	// 输出 GPU 资源汇总日志
 	if gpuNodeCount > 0 {
@@ -571,6 +581,13 @@ func (s *Service) fetchNodeFromAPIWithContext(ctx context.Context, clusterName,
 	// 尝试获取单个节点的资源使用情况（传入context以支持超时）
 	s.enrichNodeWithMetricsContext(ctx, clusterName, &nodeInfo)
 
+	// 已分配资源（Pod requests/limits 之和）
+	if pods, err := s.listNodePods(ctx, clusterName, nodeName); err == nil {
+		nodeInfo.Allocated = s.buildNodeAllocation(node, pods)
+	} else {
+		s.logger.Warningf("Failed to get pods for node %s in cluster %s: %v", nodeName, clusterName, err)
+	}
+
 	return &nodeInfo, nil
 }
 
@@ -1052,26 +1069,48 @@ func (s *Service) DrainNode(clusterName, nodeName, reason string) error {
 	return nil
 }
 
-// shouldEvictPod 判断是否应该驱逐Pod
-func (s *Service) shouldEvictPod(pod *corev1.Pod) bool {
+// 驱逐时跳过 Pod 的原因
+const (
+	EvictSkipTerminating = "terminating"
+	EvictSkipCompleted   = "completed"
+	EvictSkipDaemonSet   = "daemonset"
+	EvictSkipStatic      = "static"
+)
+
+// podEvictionSkipReason 返回驱逐时跳过Pod的原因，需要驱逐时返回空字符串
+func (s *Service) podEvictionSkipReason(pod *corev1.Pod) string {
 	// 跳过已经完成或正在删除的Pod
-	if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
-		return false
+	if pod.DeletionTimestamp != nil {
+		return EvictSkipTerminating
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return EvictSkipCompleted
 	}
 
 	// 跳过DaemonSet管理的Pod（根据requirement要求忽略daemonsets）
 	if s.isDaemonSetPod(pod) {
-		s.logger.Infof("Skipping DaemonSet pod %s/%s", pod.Namespace, pod.Name)
-		return false
+		return EvictSkipDaemonSet
 	}
 
 	// 跳过静态Pod（由kubelet直接管理）
 	if s.isStaticPod(pod) {
-		s.logger.Infof("Skipping static pod %s/%s", pod.Namespace, pod.Name)
-		return false
+		return EvictSkipStatic
 	}
 
-	return true
+	return ""
+}
+
+// shouldEvictPod 判断是否应该驱逐Pod
+func (s *Service) shouldEvictPod(pod *corev1.Pod) bool {
+	switch s.podEvictionSkipReason(pod) {
+	case "":
+		return true
+	case EvictSkipDaemonSet:
+		s.logger.Infof("Skipping DaemonSet pod %s/%s", pod.Namespace, pod.Name)
+	case EvictSkipStatic:
+		s.logger.Infof("Skipping static pod %s/%s", pod.Namespace, pod.Name)
+	}
+	return false
 }
 
 // isDaemonSetPod 检查Pod是否由DaemonSet管理
@@ -0,0 +1,104 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeUsageSample 节点资源使用采样（原始数值，用于历史存储）
+type NodeUsageSample struct {
+	NodeName          string
+	CPU               int64 // 毫核
+	Memory            int64 // 字节
+	Pods              int64
+	CPUAllocatable    int64 // 毫核
+	MemoryAllocatable int64 // 字节
+	PodsAllocatable   int64
+	CPURequests       int64 // 毫核，HasRequests 为 false 时无效
+	MemoryRequests    int64 // 字节
+	HasRequests       bool  // Pod 缓存可用时才统计 requests
+}
+
+// CollectNodeUsage 从 metrics-server 获取集群全部节点的 CPU、内存使用量和 Pod 数量
+// Pod Informer 缓存可用时同时统计 requests；没有 metrics 数据的节点不返回
+func (s *Service) CollectNodeUsage(ctx context.Context, clusterName string) ([]NodeUsageSample, error) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsClient, err := s.getMetricsClient(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metrics client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	nodeMetricsList, err := metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node metrics: %w", err)
+	}
+
+	nodeList, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	nodeNames := make([]string, len(nodeList.Items))
+	for i := range nodeList.Items {
+		nodeNames[i] = nodeList.Items[i].Name
+	}
+	podCounts := s.getPodCountsWithFallback(clusterName, nodeNames)
+
+	// requests 只从 Informer 缓存统计，避免每个采样周期全量拉取 Pod
+	requested := make(map[string]*podResources)
+	pods, podsErr := s.getPodsFromInformer(clusterName)
+	if podsErr == nil {
+		for _, pod := range pods {
+			if pod.Spec.NodeName == "" || isPodTerminated(pod) {
+				continue
+			}
+			res, ok := requested[pod.Spec.NodeName]
+			if !ok {
+				res = &podResources{}
+				requested[pod.Spec.NodeName] = res
+			}
+			res.add(computePodResources(pod))
+		}
+	}
+
+	usage := make(map[string]int, len(nodeMetricsList.Items))
+	for i := range nodeMetricsList.Items {
+		usage[nodeMetricsList.Items[i].Name] = i
+	}
+
+	samples := make([]NodeUsageSample, 0, len(nodeList.Items))
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		idx, ok := usage[node.Name]
+		if !ok {
+			continue
+		}
+		metric := &nodeMetricsList.Items[idx]
+		sample := NodeUsageSample{
+			NodeName:          node.Name,
+			CPU:               metric.Usage.Cpu().MilliValue(),
+			Memory:            metric.Usage.Memory().Value(),
+			Pods:              int64(podCounts[node.Name]),
+			CPUAllocatable:    node.Status.Allocatable.Cpu().MilliValue(),
+			MemoryAllocatable: node.Status.Allocatable.Memory().Value(),
+			PodsAllocatable:   node.Status.Allocatable.Pods().Value(),
+			HasRequests:       podsErr == nil,
+		}
+		if res, ok := requested[node.Name]; ok {
+			sample.CPURequests = res.cpuRequests
+			sample.MemoryRequests = res.memoryRequests
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
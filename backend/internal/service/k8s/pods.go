@@ -0,0 +1,525 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// NodeAllocation 节点已分配资源（调度器视角：节点上非终止 Pod 的 requests/limits 之和）
+// 百分比相对于 allocatable 计算，limits 百分比超过 100 表示该资源已超卖
+type NodeAllocation struct {
+	CPURequests          string             `json:"cpu_requests"`
+	CPULimits            string             `json:"cpu_limits"`
+	MemoryRequests       string             `json:"memory_requests"`
+	MemoryLimits         string             `json:"memory_limits"`
+	GPURequests          map[string]string  `json:"gpu_requests,omitempty"`
+	GPULimits            map[string]string  `json:"gpu_limits,omitempty"`
+	CPURequestPercent    float64            `json:"cpu_request_percent"`
+	CPULimitPercent      float64            `json:"cpu_limit_percent"`
+	MemoryRequestPercent float64            `json:"memory_request_percent"`
+	MemoryLimitPercent   float64            `json:"memory_limit_percent"`
+	GPURequestPercent    map[string]float64 `json:"gpu_request_percent,omitempty"`
+	PodCount             int                `json:"pod_count"`
+}
+
+// PodInfo 节点上的 Pod 信息
+type PodInfo struct {
+	Name           string            `json:"name"`
+	Namespace      string            `json:"namespace"`
+	NodeName       string            `json:"node_name"`
+	Phase          string            `json:"phase"`
+	Reason         string            `json:"reason,omitempty"` // 容器等待/终止原因，例如 CrashLoopBackOff
+	QOSClass       string            `json:"qos_class"`
+	OwnerKind      string            `json:"owner_kind,omitempty"`
+	OwnerName      string            `json:"owner_name,omitempty"`
+	Ready          string            `json:"ready"` // 就绪容器数/容器总数
+	RestartCount   int32             `json:"restart_count"`
+	PodIP          string            `json:"pod_ip"`
+	CPURequests    string            `json:"cpu_requests"`
+	CPULimits      string            `json:"cpu_limits"`
+	MemoryRequests string            `json:"memory_requests"`
+	MemoryLimits   string            `json:"memory_limits"`
+	GPURequests    map[string]string `json:"gpu_requests,omitempty"`
+	Age            string            `json:"age"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+// NodePodsRequest 节点 Pod 列表请求
+type NodePodsRequest struct {
+	Namespace         string `form:"namespace"`
+	Phase             string `form:"phase"`
+	Search            string `form:"search"` // 按 Pod 名称或 Owner 名称模糊匹配
+	IncludeTerminated bool   `form:"include_terminated"`
+	Page              int    `form:"page"`
+	PageSize          int    `form:"page_size"`
+}
+
+// NodePodsResult 节点 Pod 列表（分页）及节点已分配资源
+type NodePodsResult struct {
+	Allocation *NodeAllocation `json:"allocation"`
+	Pods       []PodInfo       `json:"pods"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+}
+
+// podResources Pod 的资源 requests/limits（CPU 为毫核，内存为字节）
+type podResources struct {
+	cpuRequests    int64
+	cpuLimits      int64
+	memoryRequests int64
+	memoryLimits   int64
+	gpuRequests    map[string]int64
+	gpuLimits      map[string]int64
+}
+
+// add 累加另一个 Pod 的资源
+func (r *podResources) add(o podResources) {
+	r.cpuRequests += o.cpuRequests
+	r.cpuLimits += o.cpuLimits
+	r.memoryRequests += o.memoryRequests
+	r.memoryLimits += o.memoryLimits
+	for k, v := range o.gpuRequests {
+		if r.gpuRequests == nil {
+			r.gpuRequests = make(map[string]int64)
+		}
+		r.gpuRequests[k] += v
+	}
+	for k, v := range o.gpuLimits {
+		if r.gpuLimits == nil {
+			r.gpuLimits = make(map[string]int64)
+		}
+		r.gpuLimits[k] += v
+	}
+}
+
+// isGPUResource 判断资源名称是否为 GPU 资源（与 extractGPUResources 保持一致）
+func isGPUResource(name corev1.ResourceName) bool {
+	switch string(name) {
+	case "nvidia.com/gpu", "amd.com/gpu", "intel.com/gpu", "gpu", "kubernetes.io/gpu":
+		return true
+	}
+	return strings.HasPrefix(string(name), "nvidia.com/mig-")
+}
+
+// computePodResources 按调度器的计算方式统计 Pod 的资源需求：
+// max(普通容器之和, 单个 init 容器最大值) + Pod overhead
+// limits 只累加显式设置的值，与 kubectl describe node 一致
+func computePodResources(pod *corev1.Pod) podResources {
+	requests := sumContainerResources(pod.Spec.Containers, func(c corev1.Container) corev1.ResourceList { return c.Resources.Requests })
+	limits := sumContainerResources(pod.Spec.Containers, func(c corev1.Container) corev1.ResourceList { return c.Resources.Limits })
+
+	for _, c := range pod.Spec.InitContainers {
+		maxResourceList(requests, c.Resources.Requests)
+		maxResourceList(limits, c.Resources.Limits)
+	}
+
+	for name, q := range pod.Spec.Overhead {
+		addQuantity(requests, name, q)
+		if _, ok := limits[name]; ok {
+			addQuantity(limits, name, q)
+		}
+	}
+
+	res := podResources{
+		cpuRequests:    quantityMilli(requests, corev1.ResourceCPU),
+		cpuLimits:      quantityMilli(limits, corev1.ResourceCPU),
+		memoryRequests: quantityValue(requests, corev1.ResourceMemory),
+		memoryLimits:   quantityValue(limits, corev1.ResourceMemory),
+	}
+	for name, q := range requests {
+		if isGPUResource(name) && !q.IsZero() {
+			if res.gpuRequests == nil {
+				res.gpuRequests = make(map[string]int64)
+			}
+			res.gpuRequests[string(name)] = q.Value()
+		}
+	}
+	for name, q := range limits {
+		if isGPUResource(name) && !q.IsZero() {
+			if res.gpuLimits == nil {
+				res.gpuLimits = make(map[string]int64)
+			}
+			res.gpuLimits[string(name)] = q.Value()
+		}
+	}
+	return res
+}
+
+func sumContainerResources(containers []corev1.Container, get func(corev1.Container) corev1.ResourceList) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for _, c := range containers {
+		for name, q := range get(c) {
+			addQuantity(total, name, q)
+		}
+	}
+	return total
+}
+
+func addQuantity(list corev1.ResourceList, name corev1.ResourceName, q resource.Quantity) {
+	if existing, ok := list[name]; ok {
+		existing.Add(q)
+		list[name] = existing
+		return
+	}
+	list[name] = q.DeepCopy()
+}
+
+func maxResourceList(list, other corev1.ResourceList) {
+	for name, q := range other {
+		if existing, ok := list[name]; !ok || q.Cmp(existing) > 0 {
+			list[name] = q.DeepCopy()
+		}
+	}
+}
+
+func quantityMilli(list corev1.ResourceList, name corev1.ResourceName) int64 {
+	if q, ok := list[name]; ok {
+		return q.MilliValue()
+	}
+	return 0
+}
+
+func quantityValue(list corev1.ResourceList, name corev1.ResourceName) int64 {
+	if q, ok := list[name]; ok {
+		return q.Value()
+	}
+	return 0
+}
+
+// percentOf 计算占比（保留一位小数），分母为 0 时返回 0
+func percentOf(used, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(int64(float64(used)*1000/float64(total))) / 10
+}
+
+// isPodTerminated 判断 Pod 是否已终止（终止的 Pod 不再占用节点资源）
+func isPodTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// buildNodeAllocation 根据节点 allocatable 和节点上的 Pod 计算已分配资源
+func (s *Service) buildNodeAllocation(node *corev1.Node, pods []*corev1.Pod) *NodeAllocation {
+	var total podResources
+	count := 0
+	for _, pod := range pods {
+		if isPodTerminated(pod) {
+			continue
+		}
+		total.add(computePodResources(pod))
+		count++
+	}
+
+	allocatable := node.Status.Allocatable
+	alloc := &NodeAllocation{
+		CPURequests:          s.formatCPU(total.cpuRequests),
+		CPULimits:            s.formatCPU(total.cpuLimits),
+		MemoryRequests:       s.formatMemory(total.memoryRequests),
+		MemoryLimits:         s.formatMemory(total.memoryLimits),
+		CPURequestPercent:    percentOf(total.cpuRequests, allocatable.Cpu().MilliValue()),
+		CPULimitPercent:      percentOf(total.cpuLimits, allocatable.Cpu().MilliValue()),
+		MemoryRequestPercent: percentOf(total.memoryRequests, allocatable.Memory().Value()),
+		MemoryLimitPercent:   percentOf(total.memoryLimits, allocatable.Memory().Value()),
+		PodCount:             count,
+	}
+
+	if len(total.gpuRequests) > 0 {
+		alloc.GPURequests = make(map[string]string, len(total.gpuRequests))
+		alloc.GPURequestPercent = make(map[string]float64, len(total.gpuRequests))
+		for name, v := range total.gpuRequests {
+			alloc.GPURequests[name] = fmt.Sprintf("%d", v)
+			if q, ok := allocatable[corev1.ResourceName(name)]; ok {
+				alloc.GPURequestPercent[name] = percentOf(v, q.Value())
+			}
+		}
+	}
+	if len(total.gpuLimits) > 0 {
+		alloc.GPULimits = make(map[string]string, len(total.gpuLimits))
+		for name, v := range total.gpuLimits {
+			alloc.GPULimits[name] = fmt.Sprintf("%d", v)
+		}
+	}
+
+	return alloc
+}
+
+// getPodsFromInformer 从 Informer 缓存获取集群全部 Pod，缓存不可用时返回 error
+func (s *Service) getPodsFromInformer(clusterName string) ([]*corev1.Pod, error) {
+	type PodCacheProvider interface {
+		GetAllPodsFromCache(clusterName string) ([]*corev1.Pod, error)
+	}
+	if s.realtimeManager == nil {
+		return nil, fmt.Errorf("realtime manager not available")
+	}
+	rtMgr, ok := s.realtimeManager.(PodCacheProvider)
+	if !ok {
+		return nil, fmt.Errorf("realtime manager does not provide pod cache")
+	}
+	return rtMgr.GetAllPodsFromCache(clusterName)
+}
+
+// listNodePods 获取节点上的 Pod（包含已终止的 Pod）
+// 优先使用 Informer 本地缓存，缓存不可用时按 spec.nodeName 查询 API
+func (s *Service) listNodePods(ctx context.Context, clusterName, nodeName string) ([]*corev1.Pod, error) {
+	type PodCacheProvider interface {
+		GetPodsFromCache(clusterName, nodeName string) ([]*corev1.Pod, error)
+	}
+	if rtMgr, ok := s.realtimeManager.(PodCacheProvider); ok {
+		pods, err := rtMgr.GetPodsFromCache(clusterName, nodeName)
+		if err == nil {
+			return pods, nil
+		}
+		logKey := fmt.Sprintf("informer_cache_failed_node_pods_%s", clusterName)
+		if s.logLimiter.shouldLog(logKey) {
+			s.logger.Debugf("Failed to get pods from Informer cache for cluster %s: %v, falling back to API", clusterName, err)
+		}
+	}
+
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	podList, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": nodeName}).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+	}
+
+	pods := make([]*corev1.Pod, 0, len(podList.Items))
+	for i := range podList.Items {
+		pods = append(pods, &podList.Items[i])
+	}
+	return pods, nil
+}
+
+// enrichNodesWithAllocation 为节点列表添加已分配资源
+// 只使用 Informer 缓存，避免列表请求全量拉取 Pod 给 API Server 带来压力
+func (s *Service) enrichNodesWithAllocation(clusterName string, k8sNodes []corev1.Node, nodes []NodeInfo) {
+	pods, err := s.getPodsFromInformer(clusterName)
+	if err != nil {
+		return
+	}
+
+	podsByNode := make(map[string][]*corev1.Pod)
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" {
+			podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+		}
+	}
+
+	for i := range k8sNodes {
+		if i < len(nodes) && nodes[i].Name == k8sNodes[i].Name {
+			nodes[i].Allocated = s.buildNodeAllocation(&k8sNodes[i], podsByNode[k8sNodes[i].Name])
+		}
+	}
+}
+
+// GetNodeAllocation 获取节点已分配资源（Pod requests/limits 之和及超卖比例）
+func (s *Service) GetNodeAllocation(ctx context.Context, clusterName, nodeName string) (*NodeAllocation, error) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	pods, err := s.listNodePods(ctx, clusterName, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildNodeAllocation(node, pods), nil
+}
+
+// ListNodePods 分页获取节点上的 Pod 列表，并返回节点已分配资源
+func (s *Service) ListNodePods(ctx context.Context, clusterName, nodeName string, req NodePodsRequest) (*NodePodsResult, error) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	pods, err := s.listNodePods(ctx, clusterName, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &NodePodsResult{
+		Allocation: s.buildNodeAllocation(node, pods),
+		Pods:       []PodInfo{},
+	}
+
+	search := strings.ToLower(req.Search)
+	filtered := make([]PodInfo, 0, len(pods))
+	for _, pod := range pods {
+		if !req.IncludeTerminated && isPodTerminated(pod) {
+			continue
+		}
+		if req.Namespace != "" && pod.Namespace != req.Namespace {
+			continue
+		}
+		if req.Phase != "" && !strings.EqualFold(string(pod.Status.Phase), req.Phase) {
+			continue
+		}
+		info := s.podToPodInfo(pod)
+		if search != "" && !strings.Contains(strings.ToLower(info.Name), search) &&
+			!strings.Contains(strings.ToLower(info.OwnerName), search) {
+			continue
+		}
+		filtered = append(filtered, info)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].Namespace != filtered[j].Namespace {
+			return filtered[i].Namespace < filtered[j].Namespace
+		}
+		return filtered[i].Name < filtered[j].Name
+	})
+
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 500 {
+		pageSize = 50
+	}
+	result.Total = len(filtered)
+	result.Page = page
+	result.PageSize = pageSize
+
+	start := (page - 1) * pageSize
+	if start < len(filtered) {
+		end := start + pageSize
+		if end > len(filtered) {
+			end = len(filtered)
+		}
+		result.Pods = filtered[start:end]
+	}
+
+	return result, nil
+}
+
+// podToPodInfo 转换 Pod 为 PodInfo
+func (s *Service) podToPodInfo(pod *corev1.Pod) PodInfo {
+	res := computePodResources(pod)
+	ownerKind, ownerName := podOwner(pod)
+
+	ready := 0
+	var restarts int32
+	reason := pod.Status.Reason
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Ready {
+			ready++
+		}
+		restarts += cs.RestartCount
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			reason = cs.State.Waiting.Reason
+		} else if cs.State.Terminated != nil && cs.State.Terminated.Reason != "" && reason == "" {
+			reason = cs.State.Terminated.Reason
+		}
+	}
+	if pod.DeletionTimestamp != nil {
+		reason = "Terminating"
+	}
+
+	info := PodInfo{
+		Name:           pod.Name,
+		Namespace:      pod.Namespace,
+		NodeName:       pod.Spec.NodeName,
+		Phase:          string(pod.Status.Phase),
+		Reason:         reason,
+		QOSClass:       string(podQOSClass(pod)),
+		OwnerKind:      ownerKind,
+		OwnerName:      ownerName,
+		Ready:          fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+		RestartCount:   restarts,
+		PodIP:          pod.Status.PodIP,
+		CPURequests:    s.formatCPU(res.cpuRequests),
+		CPULimits:      s.formatCPU(res.cpuLimits),
+		MemoryRequests: s.formatMemory(res.memoryRequests),
+		MemoryLimits:   s.formatMemory(res.memoryLimits),
+		Age:            s.getAge(pod.CreationTimestamp.Time),
+		CreatedAt:      pod.CreationTimestamp.Time,
+	}
+	if len(res.gpuRequests) > 0 {
+		info.GPURequests = make(map[string]string, len(res.gpuRequests))
+		for name, v := range res.gpuRequests {
+			info.GPURequests[name] = fmt.Sprintf("%d", v)
+		}
+	}
+	return info
+}
+
+// podOwner 获取 Pod 的控制器
+// ReplicaSet 通过 pod-template-hash 标签还原为所属的 Deployment，避免额外查询
+func podOwner(pod *corev1.Pod) (string, string) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		if len(pod.OwnerReferences) == 0 {
+			return "", ""
+		}
+		ref = &pod.OwnerReferences[0]
+	}
+
+	if ref.Kind == "ReplicaSet" {
+		if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+	}
+	return ref.Kind, ref.Name
+}
+
+// podQOSClass 获取 Pod 的 QoS 等级，状态中未填写时按容器资源配置推断
+func podQOSClass(pod *corev1.Pod) corev1.PodQOSClass {
+	if pod.Status.QOSClass != "" {
+		return pod.Status.QOSClass
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	hasAny := false
+	guaranteed := true
+	for _, c := range containers {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			req, hasReq := c.Resources.Requests[name]
+			lim, hasLim := c.Resources.Limits[name]
+			if (hasReq && !req.IsZero()) || (hasLim && !lim.IsZero()) {
+				hasAny = true
+			}
+			if !hasLim || lim.IsZero() {
+				guaranteed = false
+				continue
+			}
+			// 未设置 requests 时默认等于 limits
+			if hasReq && req.Cmp(lim) != 0 {
+				guaranteed = false
+			}
+		}
+	}
+
+	switch {
+	case !hasAny:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	default:
+		return corev1.PodQOSBurstable
+	}
+}
@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func container(cpuReq, memReq, cpuLim, memLim string) corev1.Container {
+	c := corev1.Container{Resources: corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}}
+	if cpuReq != "" {
+		c.Resources.Requests[corev1.ResourceCPU] = resource.MustParse(cpuReq)
+	}
+	if memReq != "" {
+		c.Resources.Requests[corev1.ResourceMemory] = resource.MustParse(memReq)
+	}
+	if cpuLim != "" {
+		c.Resources.Limits[corev1.ResourceCPU] = resource.MustParse(cpuLim)
+	}
+	if memLim != "" {
+		c.Resources.Limits[corev1.ResourceMemory] = resource.MustParse(memLim)
+	}
+	return c
+}
+
+func TestComputePodResources(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{
+			container("100m", "128Mi", "200m", "256Mi"),
+			container("300m", "", "", ""),
+		},
+		// init 容器大于普通容器之和时以 init 容器为准
+		InitContainers: []corev1.Container{container("", "1Gi", "", "")},
+	}}
+	pod.Spec.Containers[0].Resources.Limits["nvidia.com/gpu"] = resource.MustParse("2")
+	pod.Spec.Containers[0].Resources.Requests["nvidia.com/gpu"] = resource.MustParse("2")
+
+	res := computePodResources(pod)
+	if res.cpuRequests != 400 {
+		t.Errorf("cpuRequests = %d, want 400", res.cpuRequests)
+	}
+	if res.cpuLimits != 200 {
+		t.Errorf("cpuLimits = %d, want 200", res.cpuLimits)
+	}
+	if res.memoryRequests != 1<<30 {
+		t.Errorf("memoryRequests = %d, want %d", res.memoryRequests, 1<<30)
+	}
+	if res.gpuRequests["nvidia.com/gpu"] != 2 {
+		t.Errorf("gpuRequests = %v, want 2", res.gpuRequests)
+	}
+}
+
+func TestPodQOSClass(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []corev1.Container
+		want       corev1.PodQOSClass
+	}{
+		{"best effort", []corev1.Container{container("", "", "", "")}, corev1.PodQOSBestEffort},
+		{"guaranteed", []corev1.Container{container("100m", "64Mi", "100m", "64Mi")}, corev1.PodQOSGuaranteed},
+		{"limits only", []corev1.Container{container("", "", "100m", "64Mi")}, corev1.PodQOSGuaranteed},
+		{"burstable", []corev1.Container{container("100m", "64Mi", "200m", "64Mi")}, corev1.PodQOSBurstable},
+	}
+	for _, tt := range tests {
+		pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: tt.containers}}
+		if got := podQOSClass(pod); got != tt.want {
+			t.Errorf("%s: podQOSClass = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPodOwner(t *testing.T) {
+	controller := true
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{"pod-template-hash": "7d4b9c"},
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "ReplicaSet", Name: "web-7d4b9c", Controller: &controller},
+		},
+	}}
+	if kind, name := podOwner(pod); kind != "Deployment" || name != "web" {
+		t.Errorf("podOwner = %s/%s, want Deployment/web", kind, name)
+	}
+
+	pod.OwnerReferences[0] = metav1.OwnerReference{Kind: "DaemonSet", Name: "node-exporter", Controller: &controller}
+	if kind, name := podOwner(pod); kind != "DaemonSet" || name != "node-exporter" {
+		t.Errorf("podOwner = %s/%s, want DaemonSet/node-exporter", kind, name)
+	}
+}
+
+func TestPercentOf(t *testing.T) {
+	if got := percentOf(1500, 1000); got != 150 {
+		t.Errorf("percentOf(1500, 1000) = %v, want 150", got)
+	}
+	if got := percentOf(1, 0); got != 0 {
+		t.Errorf("percentOf with zero total = %v, want 0", got)
+	}
+}
@@ -0,0 +1,293 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// WorkloadRef 工作负载引用
+type WorkloadRef struct {
+	Kind      string `json:"kind" binding:"required"` // Pod、Deployment、StatefulSet、DaemonSet、ReplicaSet、Job、CronJob
+	Namespace string `json:"namespace"`
+	Name      string `json:"name" binding:"required"`
+}
+
+// NodeFit 单个节点的调度模拟结果
+type NodeFit struct {
+	NodeName   string   `json:"node_name"`
+	Fits       bool     `json:"fits"`
+	Reasons    []string `json:"reasons,omitempty"`
+	FreeCPU    string   `json:"free_cpu"`
+	FreeMemory string   `json:"free_memory"`
+	FreePods   int64    `json:"free_pods"`
+}
+
+// ScheduleSimulation 调度模拟结果：Pod 可以运行在哪些节点上，以及其他节点不满足的原因
+type ScheduleSimulation struct {
+	ClusterName    string            `json:"cluster_name"`
+	Namespace      string            `json:"namespace,omitempty"`
+	Workload       string            `json:"workload,omitempty"` // Kind/Name
+	CPURequests    string            `json:"cpu_requests"`
+	MemoryRequests string            `json:"memory_requests"`
+	GPURequests    map[string]string `json:"gpu_requests,omitempty"`
+	Nodes          []NodeFit         `json:"nodes"`
+	FitCount       int               `json:"fit_count"`
+	Notes          []string          `json:"notes,omitempty"` // 未评估的调度约束等提示
+	AnalyzedAt     time.Time         `json:"analyzed_at"`
+}
+
+// daemonSetDefaultTolerations DaemonSet 控制器自动为 Pod 添加的容忍
+var daemonSetDefaultTolerations = []corev1.Toleration{
+	{Key: corev1.TaintNodeNotReady, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeUnreachable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute},
+	{Key: corev1.TaintNodeDiskPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeMemoryPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodePIDPressure, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+	{Key: corev1.TaintNodeUnschedulable, Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+}
+
+// ResolveWorkloadPod 根据工作负载引用获取其 Pod 模板
+func (s *Service) ResolveWorkloadPod(ctx context.Context, clusterName string, ref WorkloadRef) (*corev1.Pod, error) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	var template *corev1.PodTemplateSpec
+	kind := normalizeWorkloadKind(ref.Kind)
+	switch kind {
+	case "Pod":
+		pod, err := client.CoreV1().Pods(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get pod %s/%s: %w", namespace, ref.Name, err)
+		}
+		// 已调度的 Pod 清除 nodeName 重新评估，保留 UID 以便不计入其自身占用
+		pod = pod.DeepCopy()
+		pod.Spec.NodeName = ""
+		return pod, nil
+	case "Deployment":
+		obj, err := client.AppsV1().Deployments(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment %s/%s: %w", namespace, ref.Name, err)
+		}
+		template = &obj.Spec.Template
+	case "StatefulSet":
+		obj, err := client.AppsV1().StatefulSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statefulset %s/%s: %w", namespace, ref.Name, err)
+		}
+		template = &obj.Spec.Template
+	case "DaemonSet":
+		obj, err := client.AppsV1().DaemonSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daemonset %s/%s: %w", namespace, ref.Name, err)
+		}
+		template = &obj.Spec.Template
+	case "ReplicaSet":
+		obj, err := client.AppsV1().ReplicaSets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replicaset %s/%s: %w", namespace, ref.Name, err)
+		}
+		template = &obj.Spec.Template
+	case "Job":
+		obj, err := client.BatchV1().Jobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job %s/%s: %w", namespace, ref.Name, err)
+		}
+		template = &obj.Spec.Template
+	case "CronJob":
+		obj, err := client.BatchV1().CronJobs(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cronjob %s/%s: %w", namespace, ref.Name, err)
+		}
+		template = &obj.Spec.JobTemplate.Spec.Template
+	default:
+		return nil, fmt.Errorf("unsupported workload kind: %s", ref.Kind)
+	}
+
+	pod := WorkloadTemplatePod(kind, namespace, ref.Name, template.Spec)
+	pod.Labels = template.Labels
+	return pod, nil
+}
+
+// WorkloadTemplatePod 根据工作负载的 Pod 模板构造待调度的 Pod
+// DaemonSet 会补充控制器自动添加的默认容忍
+func WorkloadTemplatePod(kind, namespace, name string, spec corev1.PodSpec) *corev1.Pod {
+	spec = *spec.DeepCopy()
+	if normalizeWorkloadKind(kind) == "DaemonSet" {
+		spec.Tolerations = append(spec.Tolerations, daemonSetDefaultTolerations...)
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       spec,
+	}
+}
+
+// normalizeWorkloadKind 规范化工作负载类型，支持 kubectl 常用缩写
+func normalizeWorkloadKind(kind string) string {
+	switch strings.ToLower(kind) {
+	case "pod", "pods", "po":
+		return "Pod"
+	case "deployment", "deployments", "deploy":
+		return "Deployment"
+	case "statefulset", "statefulsets", "sts":
+		return "StatefulSet"
+	case "daemonset", "daemonsets", "ds":
+		return "DaemonSet"
+	case "replicaset", "replicasets", "rs":
+		return "ReplicaSet"
+	case "job", "jobs":
+		return "Job"
+	case "cronjob", "cronjobs", "cj":
+		return "CronJob"
+	}
+	return kind
+}
+
+// SimulateScheduling 使用已有的节点数据（标签、污点、可分配资源、已分配 requests、禁止调度状态）
+// 逐个节点评估 Pod 能否调度，返回每个节点的结果和不满足的原因
+// 已存在的 Pod 不计入自身占用的资源
+func (s *Service) SimulateScheduling(ctx context.Context, clusterName string, pod *corev1.Pod) (*ScheduleSimulation, error) {
+	snapshot, err := s.loadClusterSnapshot(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	sim := s.simulateScheduling(snapshot, pod)
+	sim.ClusterName = clusterName
+	return sim, nil
+}
+
+// simulateScheduling 基于集群快照进行调度模拟
+func (s *Service) simulateScheduling(snapshot *clusterSnapshot, pod *corev1.Pod) *ScheduleSimulation {
+	res := computePodResources(pod)
+	sim := &ScheduleSimulation{
+		Namespace:      pod.Namespace,
+		CPURequests:    s.formatCPU(res.cpuRequests),
+		MemoryRequests: s.formatMemory(res.memoryRequests),
+		Nodes:          make([]NodeFit, 0, len(snapshot.nodes)),
+		Notes:          schedulingNotes(pod),
+		AnalyzedAt:     time.Now(),
+	}
+	if len(res.gpuRequests) > 0 {
+		sim.GPURequests = make(map[string]string, len(res.gpuRequests))
+		for name, v := range res.gpuRequests {
+			sim.GPURequests[name] = fmt.Sprintf("%d", v)
+		}
+	}
+
+	for i := range snapshot.nodes {
+		node := &snapshot.nodes[i]
+		capacity := newNodeCapacity(node, excludePod(snapshot.podsByNode[node.Name], pod.UID))
+
+		fit := NodeFit{
+			NodeName:   node.Name,
+			FreeCPU:    s.formatCPU(max(capacity.cpu, 0)),
+			FreeMemory: s.formatMemory(max(capacity.memory, 0)),
+			FreePods:   max(capacity.pods, 0),
+		}
+		if pod.Spec.NodeName != "" && pod.Spec.NodeName != node.Name {
+			fit.Reasons = append(fit.Reasons, fmt.Sprintf("Pod 指定了 nodeName=%s", pod.Spec.NodeName))
+		}
+		if !isNodeReady(node) {
+			fit.Reasons = append(fit.Reasons, "节点未就绪")
+		}
+		fit.Reasons = append(fit.Reasons, podNodeMismatchReasons(pod, node)...)
+		fit.Reasons = append(fit.Reasons, s.capacityShortfalls(capacity, res)...)
+		fit.Fits = len(fit.Reasons) == 0
+		if fit.Fits {
+			sim.FitCount++
+		}
+		sim.Nodes = append(sim.Nodes, fit)
+	}
+
+	sort.SliceStable(sim.Nodes, func(i, j int) bool {
+		if sim.Nodes[i].Fits != sim.Nodes[j].Fits {
+			return sim.Nodes[i].Fits
+		}
+		return sim.Nodes[i].NodeName < sim.Nodes[j].NodeName
+	})
+	return sim
+}
+
+// capacityShortfalls 返回节点剩余资源不足以容纳 Pod requests 的全部原因
+func (s *Service) capacityShortfalls(c *nodeCapacity, res podResources) []string {
+	var reasons []string
+	if c.pods < 1 {
+		reasons = append(reasons, "Pod 数量已达上限")
+	}
+	if res.cpuRequests > c.cpu {
+		reasons = append(reasons, fmt.Sprintf("CPU 不足（请求 %s，剩余 %s）", s.formatCPU(res.cpuRequests), s.formatCPU(max(c.cpu, 0))))
+	}
+	if res.memoryRequests > c.memory {
+		reasons = append(reasons, fmt.Sprintf("内存不足（请求 %s，剩余 %s）", s.formatMemory(res.memoryRequests), s.formatMemory(max(c.memory, 0))))
+	}
+	names := make([]string, 0, len(res.gpuRequests))
+	for name := range res.gpuRequests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if v := res.gpuRequests[name]; v > c.gpu[name] {
+			reasons = append(reasons, fmt.Sprintf("%s 不足（请求 %d，剩余 %d）", name, v, max(c.gpu[name], 0)))
+		}
+	}
+	return reasons
+}
+
+// schedulingNotes 列出 Pod 中模拟器未评估的调度约束
+func schedulingNotes(pod *corev1.Pod) []string {
+	var notes []string
+	if affinity := pod.Spec.Affinity; affinity != nil && (affinity.PodAffinity != nil || affinity.PodAntiAffinity != nil) {
+		notes = append(notes, "未评估 Pod 间亲和/反亲和")
+	}
+	if len(pod.Spec.TopologySpreadConstraints) > 0 {
+		notes = append(notes, "未评估拓扑分布约束")
+	}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil {
+			notes = append(notes, "未评估 PV 的节点亲和性")
+			break
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		hasHostPort := false
+		for _, port := range c.Ports {
+			if port.HostPort > 0 {
+				hasHostPort = true
+				break
+			}
+		}
+		if hasHostPort {
+			notes = append(notes, "未评估 hostPort 冲突")
+			break
+		}
+	}
+	return notes
+}
+
+// excludePod 过滤掉指定 UID 的 Pod，模拟已存在的 Pod 时不计入其自身占用
+func excludePod(pods []*corev1.Pod, uid types.UID) []*corev1.Pod {
+	if uid == "" {
+		return pods
+	}
+	result := make([]*corev1.Pod, 0, len(pods))
+	for _, p := range pods {
+		if p.UID != uid {
+			result = append(result, p)
+		}
+	}
+	return result
+}
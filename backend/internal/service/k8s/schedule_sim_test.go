@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSimulateScheduling(t *testing.T) {
+	gpu := testNode("gpu", "4", false)
+	gpu.Labels = map[string]string{"accelerator": "nvidia"}
+	gpu.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+
+	snapshot := &clusterSnapshot{
+		nodes: []corev1.Node{
+			testNode("busy", "2", false),
+			testNode("free", "4", false),
+			testNode("cordoned", "8", true),
+			gpu,
+		},
+		podsByNode: map[string][]*corev1.Pod{},
+	}
+	existing := testPod("existing", "busy", "1500m", "ReplicaSet", nil)
+	existing.UID = "existing-uid"
+	snapshot.podsByNode["busy"] = []*corev1.Pod{existing}
+
+	s := &Service{}
+	pod := testPod("web", "", "1", "", nil)
+	sim := s.simulateScheduling(snapshot, pod)
+
+	fits := make(map[string]NodeFit)
+	for _, fit := range sim.Nodes {
+		fits[fit.NodeName] = fit
+	}
+	if sim.FitCount != 1 || !fits["free"].Fits || sim.Nodes[0].NodeName != "free" {
+		t.Fatalf("expected only free to fit and be listed first, got %+v", sim.Nodes)
+	}
+	if reasons := fits["busy"].Reasons; len(reasons) != 1 || !strings.HasPrefix(reasons[0], "CPU 不足") {
+		t.Errorf("busy: expected CPU shortfall, got %v", reasons)
+	}
+	if reasons := fits["cordoned"].Reasons; len(reasons) != 1 || reasons[0] != "节点已禁止调度" {
+		t.Errorf("cordoned: expected unschedulable, got %v", reasons)
+	}
+	if reasons := fits["gpu"].Reasons; len(reasons) != 1 || !strings.Contains(reasons[0], "dedicated=gpu:NoSchedule") {
+		t.Errorf("gpu: expected untolerated taint, got %v", reasons)
+	}
+
+	// 模拟已存在的 Pod 时不计入其自身占用；nodeSelector 不满足的原因需列出
+	existing = existing.DeepCopy()
+	existing.Spec.NodeName = ""
+	existing.Spec.NodeSelector = map[string]string{"accelerator": "nvidia"}
+	existing.Spec.Tolerations = []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	sim = s.simulateScheduling(snapshot, existing)
+	fits = make(map[string]NodeFit)
+	for _, fit := range sim.Nodes {
+		fits[fit.NodeName] = fit
+	}
+	if !fits["gpu"].Fits || sim.FitCount != 1 {
+		t.Fatalf("expected only gpu to fit, got %+v", sim.Nodes)
+	}
+	if reasons := fits["busy"].Reasons; len(reasons) != 1 || reasons[0] != "不满足节点选择器 accelerator=nvidia" {
+		t.Errorf("busy: expected only node selector mismatch, got %v", reasons)
+	}
+}
@@ -0,0 +1,314 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// nodeCapacity 节点剩余可分配资源（allocatable 减去已调度 Pod 的 requests）
+type nodeCapacity struct {
+	node   *corev1.Node
+	cpu    int64 // 毫核
+	memory int64 // 字节
+	pods   int64
+	gpu    map[string]int64
+}
+
+// newNodeCapacity 根据节点上的 Pod 计算剩余可分配资源
+func newNodeCapacity(node *corev1.Node, pods []*corev1.Pod) *nodeCapacity {
+	allocatable := node.Status.Allocatable
+	c := &nodeCapacity{
+		node:   node,
+		cpu:    allocatable.Cpu().MilliValue(),
+		memory: allocatable.Memory().Value(),
+		pods:   allocatable.Pods().Value(),
+		gpu:    make(map[string]int64),
+	}
+	for name, q := range allocatable {
+		if isGPUResource(name) {
+			c.gpu[string(name)] = q.Value()
+		}
+	}
+	for _, pod := range pods {
+		if isPodTerminated(pod) {
+			continue
+		}
+		c.reserve(computePodResources(pod))
+	}
+	return c
+}
+
+// fits 判断剩余资源是否能容纳 Pod 的 requests，不满足时返回原因
+func (c *nodeCapacity) fits(res podResources) (bool, string) {
+	if c.pods < 1 {
+		return false, "Pod 数量已达上限"
+	}
+	if res.cpuRequests > c.cpu {
+		return false, "CPU 不足"
+	}
+	if res.memoryRequests > c.memory {
+		return false, "内存不足"
+	}
+	for name, v := range res.gpuRequests {
+		if v > c.gpu[name] {
+			return false, fmt.Sprintf("%s 不足", name)
+		}
+	}
+	return true, ""
+}
+
+// reserve 占用 Pod 的 requests
+func (c *nodeCapacity) reserve(res podResources) {
+	c.cpu -= res.cpuRequests
+	c.memory -= res.memoryRequests
+	c.pods--
+	for name, v := range res.gpuRequests {
+		c.gpu[name] -= v
+	}
+}
+
+// isNodeReady 判断节点 Ready 条件是否为 True
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podToleratesNodeTaints 判断 Pod 是否容忍节点上全部 NoSchedule/NoExecute 污点
+// 返回第一个不能容忍的污点
+func podToleratesNodeTaints(pod *corev1.Pod, taints []corev1.Taint) (bool, *corev1.Taint) {
+	untolerated := untoleratedTaints(pod, taints)
+	if len(untolerated) == 0 {
+		return true, nil
+	}
+	return false, untolerated[0]
+}
+
+// untoleratedTaints 返回 Pod 不能容忍的全部 NoSchedule/NoExecute 污点
+func untoleratedTaints(pod *corev1.Pod, taints []corev1.Taint) []*corev1.Taint {
+	var result []*corev1.Taint
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range pod.Spec.Tolerations {
+			if pod.Spec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			result = append(result, taint)
+		}
+	}
+	return result
+}
+
+// podMatchesNodeSelector 判断节点标签是否满足 Pod 的 nodeSelector 和必需的节点亲和性
+func podMatchesNodeSelector(pod *corev1.Pod, node *corev1.Node) bool {
+	return len(nodeSelectorMismatches(pod, node)) == 0 && podMatchesNodeAffinity(pod, node)
+}
+
+// nodeSelectorMismatches 返回节点不满足的 nodeSelector 条目
+func nodeSelectorMismatches(pod *corev1.Pod, node *corev1.Node) []string {
+	var mismatches []string
+	for key, value := range pod.Spec.NodeSelector {
+		if actual, ok := node.Labels[key]; !ok || actual != value {
+			mismatches = append(mismatches, key+"="+value)
+		}
+	}
+	sort.Strings(mismatches)
+	return mismatches
+}
+
+// podMatchesNodeAffinity 判断节点是否满足 Pod 必需的节点亲和性
+// 节点亲和性的多个 term 之间为“或”关系，term 内的表达式为“与”关系
+func podMatchesNodeAffinity(pod *corev1.Pod, node *corev1.Node) bool {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		return true
+	}
+	for _, term := range terms {
+		if nodeSelectorTermMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeSelectorTermMatches 判断节点是否满足单个 NodeSelectorTerm
+func nodeSelectorTermMatches(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+
+	for _, expr := range term.MatchExpressions {
+		op, ok := nodeSelectorOperators[expr.Operator]
+		if !ok {
+			return false
+		}
+		req, err := labels.NewRequirement(expr.Key, op, expr.Values)
+		if err != nil || !req.Matches(labels.Set(node.Labels)) {
+			return false
+		}
+	}
+
+	for _, field := range term.MatchFields {
+		if field.Key != "metadata.name" {
+			return false
+		}
+		found := false
+		for _, v := range field.Values {
+			if v == node.Name {
+				found = true
+				break
+			}
+		}
+		switch field.Operator {
+		case corev1.NodeSelectorOpIn:
+			if !found {
+				return false
+			}
+		case corev1.NodeSelectorOpNotIn:
+			if found {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var nodeSelectorOperators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// podEligibleOnNode 判断节点是否满足 Pod 的调度条件（不考虑资源）：节点可调度、容忍污点、满足节点选择器
+func podEligibleOnNode(pod *corev1.Pod, node *corev1.Node) (bool, string) {
+	if reasons := podNodeMismatchReasons(pod, node); len(reasons) > 0 {
+		return false, reasons[0]
+	}
+	return true, ""
+}
+
+// podNodeMismatchReasons 返回节点不满足 Pod 调度条件（不考虑资源）的全部原因
+func podNodeMismatchReasons(pod *corev1.Pod, node *corev1.Node) []string {
+	var reasons []string
+	if node.Spec.Unschedulable && !podToleratesUnschedulable(pod) {
+		reasons = append(reasons, "节点已禁止调度")
+	}
+	for _, taint := range untoleratedTaints(pod, node.Spec.Taints) {
+		reasons = append(reasons, fmt.Sprintf("不容忍污点 %s", formatTaint(taint)))
+	}
+	if mismatches := nodeSelectorMismatches(pod, node); len(mismatches) > 0 {
+		reasons = append(reasons, fmt.Sprintf("不满足节点选择器 %s", strings.Join(mismatches, ",")))
+	}
+	if !podMatchesNodeAffinity(pod, node) {
+		reasons = append(reasons, "不满足必需的节点亲和性")
+	}
+	return reasons
+}
+
+// podToleratesUnschedulable 判断 Pod 是否容忍禁止调度的节点（例如 DaemonSet Pod）
+func podToleratesUnschedulable(pod *corev1.Pod) bool {
+	taint := &corev1.Taint{Key: corev1.TaintNodeUnschedulable, Effect: corev1.TaintEffectNoSchedule}
+	for i := range pod.Spec.Tolerations {
+		if pod.Spec.Tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// podFitsNode 判断 Pod 能否调度到节点：满足调度条件、节点就绪且剩余资源足够
+// 不考虑 Pod 间亲和/反亲和与拓扑分布约束
+func podFitsNode(pod *corev1.Pod, capacity *nodeCapacity, res podResources) (bool, string) {
+	if ok, reason := podEligibleOnNode(pod, capacity.node); !ok {
+		return false, reason
+	}
+	if !isNodeReady(capacity.node) {
+		return false, "节点未就绪"
+	}
+	return capacity.fits(res)
+}
+
+// formatTaint 格式化污点为 key=value:Effect
+func formatTaint(taint *corev1.Taint) string {
+	var b strings.Builder
+	b.WriteString(taint.Key)
+	if taint.Value != "" {
+		b.WriteString("=")
+		b.WriteString(taint.Value)
+	}
+	b.WriteString(":")
+	b.WriteString(string(taint.Effect))
+	return b.String()
+}
+
+// clusterSnapshot 集群节点与 Pod 的快照，用于调度相关的模拟分析
+type clusterSnapshot struct {
+	nodes      []corev1.Node
+	pods       []*corev1.Pod
+	podsByNode map[string][]*corev1.Pod
+}
+
+// loadClusterSnapshot 获取集群全部节点和 Pod
+// Pod 优先使用 Informer 本地缓存，缓存不可用时查询 API
+func (s *Service) loadClusterSnapshot(ctx context.Context, clusterName string) (*clusterSnapshot, error) {
+	client, err := s.getClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	pods, err := s.getPodsFromInformer(clusterName)
+	if err != nil {
+		podList, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		pods = make([]*corev1.Pod, 0, len(podList.Items))
+		for i := range podList.Items {
+			pods = append(pods, &podList.Items[i])
+		}
+	}
+
+	snapshot := &clusterSnapshot{
+		nodes:      nodeList.Items,
+		pods:       pods,
+		podsByNode: make(map[string][]*corev1.Pod),
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" {
+			snapshot.podsByNode[pod.Spec.NodeName] = append(snapshot.podsByNode[pod.Spec.NodeName], pod)
+		}
+	}
+	return snapshot, nil
+}
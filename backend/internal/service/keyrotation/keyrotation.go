@@ -0,0 +1,736 @@
+package keyrotation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/ansible"
+	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/pkg/crypto"
+	"kube-node-manager/pkg/logger"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultConcurrency = 10
+	maxConcurrency     = 50
+	// hostTimeout 单台主机单次操作的超时
+	hostTimeout = 30 * time.Second
+	// staleAfter 执行中的轮换超过该时间没有更新视为已中断（例如副本重启）
+	staleAfter = 30 * time.Minute
+)
+
+var (
+	// ErrRotationInProgress 同一密钥已有轮换在执行
+	ErrRotationInProgress = errors.New("a rotation of this ssh key is already in progress")
+	// ErrKeyNotFound 密钥不存在
+	ErrKeyNotFound = errors.New("ssh key not found")
+)
+
+// NodeResolver 解析节点实际使用的 SSH 密钥、端口、用户和地址
+type NodeResolver interface {
+	GetNodeSSHConfig(ctx context.Context, clusterName, nodeName string) (*model.SystemSSHKey, string, error)
+}
+
+// NodeLister 列出集群节点
+type NodeLister interface {
+	ListNodesWithCache(clusterName string, forceRefresh bool) ([]k8s.NodeInfo, error)
+}
+
+// Service SSH 密钥轮换服务
+// 轮换流程：用旧密钥登录所有引用节点追加新公钥 -> 用新密钥登录验证 -> 在一个事务中替换密钥记录的私钥
+// （节点配置和清单按 ID 引用密钥，随之一起切换）-> 用新密钥登录删除旧公钥。
+// 分发或验证阶段任一主机失败都会撤销已分发的新公钥，密钥记录保持不变。
+type Service struct {
+	db        *gorm.DB
+	logger    *logger.Logger
+	encryptor *crypto.Encryptor
+	nodes     NodeResolver
+	lister    NodeLister
+	installer keyInstaller
+}
+
+// NewService 创建 SSH 密钥轮换服务，encryptionKey 与 SSH 密钥服务使用的加密密钥一致
+func NewService(db *gorm.DB, logger *logger.Logger, encryptionKey string, nodes NodeResolver, lister NodeLister) *Service {
+	if encryptionKey == "" {
+		encryptionKey = "default-encryption-key-change-in-production"
+	}
+	return &Service{
+		db:        db,
+		logger:    logger,
+		encryptor: crypto.NewEncryptor(encryptionKey),
+		nodes:     nodes,
+		lister:    lister,
+		installer: sshInstaller{},
+	}
+}
+
+// rotationRun 一次轮换执行过程中的状态
+type rotationRun struct {
+	rotation     *model.SSHKeyRotation
+	oldKey       *model.SystemSSHKey
+	newKey       *model.SystemSSHKey
+	oldPublicKey string
+	added        map[int]bool // 已追加新公钥的目标，回滚时需要删除
+	mu           sync.Mutex
+}
+
+// Start 发起密钥轮换，校验通过后在后台执行，返回轮换记录
+func (s *Service) Start(keySource string, keyID uint, req model.SSHKeyRotateRequest, userID uint) (*model.SSHKeyRotation, error) {
+	oldKey, err := s.loadKey(keySource, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if oldKey.Type != model.SSHKeyTypePrivateKey {
+		return nil, fmt.Errorf("only private key type ssh keys can be rotated")
+	}
+	oldSigner, err := parseSigner(oldKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current private key: %w", err)
+	}
+
+	if keySource == model.SSHKeySourceAnsible {
+		var projects int64
+		if err := s.db.Model(&model.AnsibleProject{}).Where("ssh_key_id = ?", keyID).Count(&projects).Error; err != nil {
+			return nil, err
+		}
+		if projects > 0 {
+			return nil, fmt.Errorf("ssh key is used by %d git projects, its deploy key cannot be rotated automatically", projects)
+		}
+	}
+
+	if err := s.checkNotRunning(keySource, keyID); err != nil {
+		return nil, err
+	}
+
+	newKey, newPublicKey, newFingerprint, err := generateKey(oldKey, fmt.Sprintf("kube-node-manager-%s-key-%d-%d", keySource, keyID, time.Now().Unix()))
+	if err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	if err := s.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", userID, err)
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if concurrency > maxConcurrency {
+		concurrency = maxConcurrency
+	}
+
+	runningKey := model.SSHKeyRotationRunningKey(keySource, keyID)
+	rotation := &model.SSHKeyRotation{
+		KeySource:      keySource,
+		KeyID:          keyID,
+		KeyName:        oldKey.Name,
+		Status:         model.SSHKeyRotationRunning,
+		RunningKey:     &runningKey,
+		Phase:          model.SSHKeyRotationPhasePlan,
+		OldFingerprint: ssh.FingerprintSHA256(oldSigner.PublicKey()),
+		NewFingerprint: newFingerprint,
+		NewPublicKey:   newPublicKey,
+		Targets:        model.SSHKeyRotationTargets{},
+		Concurrency:    concurrency,
+		UserID:         userID,
+		Username:       user.Username,
+		StartedAt:      time.Now(),
+	}
+	// running_key 唯一索引保证多个副本或并发请求同时发起时只有一个能创建成功
+	if err := s.db.Create(rotation).Error; err != nil {
+		var running int64
+		if s.db.Model(&model.SSHKeyRotation{}).Where("running_key = ?", runningKey).Count(&running); running > 0 {
+			return nil, ErrRotationInProgress
+		}
+		return nil, fmt.Errorf("failed to create rotation record: %w", err)
+	}
+
+	s.logger.Infof("SSH key rotation %d started: %s key %d (%s) by %s", rotation.ID, keySource, keyID, oldKey.Name, user.Username)
+
+	run := &rotationRun{
+		rotation:     rotation,
+		oldKey:       oldKey,
+		newKey:       newKey,
+		oldPublicKey: string(ssh.MarshalAuthorizedKey(oldSigner.PublicKey())),
+		added:        make(map[int]bool),
+	}
+	// 在启动后台执行前复制返回值，避免与后台执行并发读写
+	result := *rotation
+	go s.execute(run)
+	return &result, nil
+}
+
+// GetRotation 获取轮换记录
+func (s *Service) GetRotation(id uint) (*model.SSHKeyRotation, error) {
+	var rotation model.SSHKeyRotation
+	if err := s.db.First(&rotation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("rotation not found")
+		}
+		return nil, err
+	}
+	return &rotation, nil
+}
+
+// ListRotations 分页查询轮换记录（不包含目标主机明细）
+func (s *Service) ListRotations(req model.SSHKeyRotationListRequest) ([]model.SSHKeyRotation, int64, error) {
+	query := s.db.Model(&model.SSHKeyRotation{})
+	if req.KeySource != "" {
+		query = query.Where("key_source = ?", req.KeySource)
+	}
+	if req.KeyID > 0 {
+		query = query.Where("key_id = ?", req.KeyID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	var rotations []model.SSHKeyRotation
+	if err := query.Omit("targets").
+		Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&rotations).Error; err != nil {
+		return nil, 0, err
+	}
+	return rotations, total, nil
+}
+
+// checkNotRunning 检查同一密钥没有执行中的轮换，长时间没有更新的视为已中断
+func (s *Service) checkNotRunning(keySource string, keyID uint) error {
+	var running []model.SSHKeyRotation
+	if err := s.db.Omit("targets").
+		Where("key_source = ? AND key_id = ? AND status = ?", keySource, keyID, model.SSHKeyRotationRunning).
+		Find(&running).Error; err != nil {
+		return err
+	}
+
+	for _, rotation := range running {
+		if time.Since(rotation.UpdatedAt) < staleAfter {
+			return ErrRotationInProgress
+		}
+		s.logger.Warningf("SSH key rotation %d has not been updated since %s, marking it as failed", rotation.ID, rotation.UpdatedAt.Format(time.RFC3339))
+		message := fmt.Sprintf("interrupted during %s phase, the current key is unchanged but the new public key may remain in authorized_keys on distributed hosts", rotation.Phase)
+		if rotation.Phase == model.SSHKeyRotationPhaseCleanup {
+			message = "interrupted after the key was switched, the old public key may remain in authorized_keys on some hosts"
+		}
+		now := time.Now()
+		if err := s.db.Model(&model.SSHKeyRotation{}).Where("id = ?", rotation.ID).Updates(map[string]interface{}{
+			"status":        model.SSHKeyRotationFailed,
+			"running_key":   nil,
+			"error_message": message,
+			"completed_at":  &now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadKey 加载并解密密钥，统一转换为 SystemSSHKey
+func (s *Service) loadKey(keySource string, keyID uint) (*model.SystemSSHKey, error) {
+	var key model.SystemSSHKey
+	switch keySource {
+	case model.SSHKeySourceSystem:
+		if err := s.db.First(&key, keyID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrKeyNotFound
+			}
+			return nil, err
+		}
+	case model.SSHKeySourceAnsible:
+		var ansibleKey model.AnsibleSSHKey
+		if err := s.db.First(&ansibleKey, keyID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrKeyNotFound
+			}
+			return nil, err
+		}
+		key = model.SystemSSHKey{
+			ID:         ansibleKey.ID,
+			Name:       ansibleKey.Name,
+			Type:       ansibleKey.Type,
+			Username:   ansibleKey.Username,
+			PrivateKey: ansibleKey.PrivateKey,
+			Passphrase: ansibleKey.Passphrase,
+			Password:   ansibleKey.Password,
+			Port:       ansibleKey.Port,
+			IsDefault:  ansibleKey.IsDefault,
+		}
+	default:
+		return nil, fmt.Errorf("unknown ssh key source: %s", keySource)
+	}
+
+	var err error
+	if key.PrivateKey, err = s.encryptor.Decrypt(key.PrivateKey); err != nil {
+		return nil, fmt.Errorf("failed to decrypt private key: %w", err)
+	}
+	if key.Passphrase, err = s.encryptor.Decrypt(key.Passphrase); err != nil {
+		return nil, fmt.Errorf("failed to decrypt passphrase: %w", err)
+	}
+	if key.Password, err = s.encryptor.Decrypt(key.Password); err != nil {
+		return nil, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+	if key.Port == 0 {
+		key.Port = 22
+	}
+	return &key, nil
+}
+
+// execute 后台执行轮换
+func (s *Service) execute(run *rotationRun) {
+	rotation := run.rotation
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Errorf("SSH key rotation %d panicked: %v", rotation.ID, r)
+			s.finish(run, model.SSHKeyRotationFailed, fmt.Sprintf("internal error: %v", r))
+		}
+	}()
+
+	targets, err := s.planTargets(run.rotation.KeySource, run.oldKey)
+	if err != nil {
+		s.logger.Errorf("SSH key rotation %d: failed to collect targets: %v", rotation.ID, err)
+		s.finish(run, model.SSHKeyRotationFailed, err.Error())
+		return
+	}
+	run.mu.Lock()
+	rotation.Targets = targets
+	rotation.TotalTargets = len(targets)
+	run.mu.Unlock()
+	s.setPhase(run, model.SSHKeyRotationPhaseDistribute)
+
+	// 1. 用旧密钥登录，追加新公钥
+	failed := s.forEachTarget(run, model.SSHKeyRotationTargetPending, func(ctx context.Context, target model.SSHKeyRotationTarget) (string, error) {
+		if err := s.installer.AddKey(ctx, target, run.oldKey, rotation.NewPublicKey); err != nil {
+			return "", err
+		}
+		return model.SSHKeyRotationTargetDistributed, nil
+	})
+	if failed > 0 {
+		s.rollback(run, fmt.Sprintf("failed to distribute the new public key to %d of %d hosts", failed, len(targets)))
+		return
+	}
+
+	// 2. 只用新密钥登录，确认新公钥生效
+	s.setPhase(run, model.SSHKeyRotationPhaseVerify)
+	failed = s.forEachTarget(run, model.SSHKeyRotationTargetDistributed, func(ctx context.Context, target model.SSHKeyRotationTarget) (string, error) {
+		if err := s.installer.Verify(ctx, target, run.newKey); err != nil {
+			return "", fmt.Errorf("new key login failed: %w", err)
+		}
+		return model.SSHKeyRotationTargetVerified, nil
+	})
+	if failed > 0 {
+		s.rollback(run, fmt.Sprintf("failed to verify the new key on %d of %d hosts", failed, len(targets)))
+		return
+	}
+
+	// 3. 替换密钥记录，所有按 ID 引用该密钥的节点配置和清单同时切换
+	s.setPhase(run, model.SSHKeyRotationPhaseSwitch)
+	if err := s.switchKey(run); err != nil {
+		s.logger.Errorf("SSH key rotation %d: failed to switch key: %v", rotation.ID, err)
+		s.rollback(run, fmt.Sprintf("failed to switch the key record: %v", err))
+		return
+	}
+
+	// 4. 用新密钥登录，删除旧公钥；此时已完成切换，失败只记录警告
+	failed = s.forEachTarget(run, model.SSHKeyRotationTargetVerified, func(ctx context.Context, target model.SSHKeyRotationTarget) (string, error) {
+		if err := s.installer.RemoveKey(ctx, target, run.newKey, run.oldPublicKey); err != nil {
+			return model.SSHKeyRotationTargetVerified, fmt.Errorf("old key not removed: %w", err)
+		}
+		return model.SSHKeyRotationTargetCleaned, nil
+	})
+
+	message := ""
+	if failed > 0 {
+		message = fmt.Sprintf("key switched, but the old public key could not be removed from %d hosts, remove it manually", failed)
+	}
+	s.finish(run, model.SSHKeyRotationCompleted, message)
+	s.logger.Infof("SSH key rotation %d completed: %d hosts, %d cleanup failures", rotation.ID, len(targets), failed)
+}
+
+// forEachTarget 并发处理处于指定状态的目标，fn 返回目标的新状态；出错时状态为空则标记为失败。返回失败数
+func (s *Service) forEachTarget(run *rotationRun, status string, fn func(ctx context.Context, target model.SSHKeyRotationTarget) (string, error)) int {
+	run.mu.Lock()
+	var indexes []int
+	for i, target := range run.rotation.Targets {
+		if target.Status == status {
+			indexes = append(indexes, i)
+		}
+	}
+	concurrency := run.rotation.Concurrency
+	run.mu.Unlock()
+
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	var wg sync.WaitGroup
+	var failedMu sync.Mutex
+	failed := 0
+	sem := make(chan struct{}, concurrency)
+	for _, idx := range indexes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			run.mu.Lock()
+			target := run.rotation.Targets[idx]
+			run.mu.Unlock()
+
+			ctx, cancel := context.WithTimeout(context.Background(), hostTimeout)
+			newStatus, err := fn(ctx, target)
+			cancel()
+
+			errMsg := ""
+			if err != nil {
+				errMsg = err.Error()
+				if newStatus == "" {
+					newStatus = model.SSHKeyRotationTargetFailed
+				}
+				failedMu.Lock()
+				failed++
+				failedMu.Unlock()
+				s.logger.Warningf("SSH key rotation %d: %s@%s:%d: %v", run.rotation.ID, target.Username, target.Host, target.Port, err)
+			}
+			s.updateTarget(run, idx, newStatus, errMsg)
+		}(idx)
+	}
+	wg.Wait()
+	return failed
+}
+
+// updateTarget 更新目标状态并保存，前端轮询记录获得进度
+func (s *Service) updateTarget(run *rotationRun, idx int, status, errMsg string) {
+	run.mu.Lock()
+	target := &run.rotation.Targets[idx]
+	target.Status = status
+	target.Error = errMsg
+	target.UpdatedAt = time.Now()
+	if status == model.SSHKeyRotationTargetDistributed {
+		run.added[idx] = true
+	}
+	s.save(run, "targets")
+	run.mu.Unlock()
+}
+
+// setPhase 更新轮换阶段
+func (s *Service) setPhase(run *rotationRun, phase string) {
+	run.mu.Lock()
+	run.rotation.Phase = phase
+	s.save(run, "phase", "targets", "total_targets")
+	run.mu.Unlock()
+}
+
+// save 保存轮换记录的指定字段，调用方需持有 run.mu
+func (s *Service) save(run *rotationRun, fields ...string) {
+	fields = append(fields, "updated_at")
+	if err := s.db.Model(run.rotation).Select(fields).Updates(run.rotation).Error; err != nil {
+		s.logger.Errorf("Failed to save SSH key rotation %d: %v", run.rotation.ID, err)
+	}
+}
+
+// finish 结束轮换
+func (s *Service) finish(run *rotationRun, status model.SSHKeyRotationStatus, message string) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	now := time.Now()
+	run.rotation.Status = status
+	run.rotation.RunningKey = nil
+	run.rotation.Phase = model.SSHKeyRotationPhaseDone
+	run.rotation.ErrorMessage = message
+	run.rotation.CompletedAt = &now
+	s.save(run, "status", "running_key", "phase", "error_message", "completed_at", "targets", "total_targets")
+}
+
+// rollback 删除已分发的新公钥，密钥记录保持不变
+func (s *Service) rollback(run *rotationRun, reason string) {
+	s.logger.Warningf("SSH key rotation %d: %s, rolling back", run.rotation.ID, reason)
+	s.setPhase(run, model.SSHKeyRotationPhaseRollback)
+
+	run.mu.Lock()
+	var indexes []int
+	for idx := range run.added {
+		indexes = append(indexes, idx)
+	}
+	run.mu.Unlock()
+	sort.Ints(indexes)
+
+	var wg sync.WaitGroup
+	var failedMu sync.Mutex
+	failed := 0
+	sem := make(chan struct{}, run.rotation.Concurrency)
+	for _, idx := range indexes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			run.mu.Lock()
+			target := run.rotation.Targets[idx]
+			run.mu.Unlock()
+
+			ctx, cancel := context.WithTimeout(context.Background(), hostTimeout)
+			err := s.installer.RemoveKey(ctx, target, run.oldKey, run.rotation.NewPublicKey)
+			cancel()
+
+			status := model.SSHKeyRotationTargetRolledBack
+			errMsg := target.Error
+			if err != nil {
+				status = model.SSHKeyRotationTargetFailed
+				errMsg = strings.TrimPrefix(errMsg+"; ", "; ") + "rollback failed: " + err.Error()
+				failedMu.Lock()
+				failed++
+				failedMu.Unlock()
+				s.logger.Errorf("SSH key rotation %d: failed to roll back %s@%s:%d: %v", run.rotation.ID, target.Username, target.Host, target.Port, err)
+			}
+			s.updateTarget(run, idx, status, errMsg)
+		}(idx)
+	}
+	wg.Wait()
+
+	message := reason + "; the new public key has been removed and the current key is unchanged"
+	if failed > 0 {
+		message = fmt.Sprintf("%s; failed to remove the new public key from %d hosts, the current key is unchanged", reason, failed)
+	}
+	s.finish(run, model.SSHKeyRotationRolledBack, message)
+}
+
+// switchKey 在一个事务中替换密钥记录的私钥并推进轮换阶段
+func (s *Service) switchKey(run *rotationRun) error {
+	encrypted, err := s.encryptor.Encrypt(run.newKey.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	var table interface{} = &model.SystemSSHKey{}
+	if run.rotation.KeySource == model.SSHKeySourceAnsible {
+		table = &model.AnsibleSSHKey{}
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(table).Where("id = ?", run.rotation.KeyID).Updates(map[string]interface{}{
+			"private_key": encrypted,
+			"passphrase":  "",
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrKeyNotFound
+		}
+
+		run.rotation.Phase = model.SSHKeyRotationPhaseCleanup
+		return tx.Model(run.rotation).Select("phase", "updated_at").Updates(run.rotation).Error
+	})
+}
+
+// generateKey 生成新的 ed25519 密钥，返回继承原密钥用户和端口的新密钥、authorized_keys 格式公钥和指纹
+func generateKey(oldKey *model.SystemSSHKey, comment string) (*model.SystemSSHKey, string, string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, comment)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	newKey := *oldKey
+	newKey.PrivateKey = string(pem.EncodeToMemory(block))
+	newKey.Passphrase = ""
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey))) + " " + comment
+	return &newKey, line, ssh.FingerprintSHA256(sshPublicKey), nil
+}
+
+// parseSigner 解析密钥的私钥
+func parseSigner(key *model.SystemSSHKey) (ssh.Signer, error) {
+	if key.Passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase([]byte(key.PrivateKey), []byte(key.Passphrase))
+	}
+	return ssh.ParsePrivateKey([]byte(key.PrivateKey))
+}
+
+// planTargets 收集引用该密钥的所有主机：
+// 节点配置中指定了该密钥的节点、该密钥为默认密钥时未指定密钥的节点、关联该密钥的 Ansible 清单中的主机
+func (s *Service) planTargets(keySource string, key *model.SystemSSHKey) (model.SSHKeyRotationTargets, error) {
+	planner := newTargetPlanner()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	// 节点配置的密钥先查 system_ssh_keys，不存在时才使用 ansible_ssh_keys 中同 ID 的密钥
+	nodeSettingsApply := true
+	if keySource == model.SSHKeySourceAnsible {
+		var count int64
+		if err := s.db.Model(&model.SystemSSHKey{}).Where("id = ?", key.ID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		nodeSettingsApply = count == 0
+	}
+
+	var unresolved []string
+	resolve := func(clusterName, nodeName string) {
+		nodeKey, host, err := s.nodes.GetNodeSSHConfig(ctx, clusterName, nodeName)
+		if err != nil {
+			unresolved = append(unresolved, fmt.Sprintf("%s/%s (%v)", clusterName, nodeName, err))
+			return
+		}
+		planner.add(host, nodeKey.Port, nodeKey.Username, fmt.Sprintf("node:%s/%s", clusterName, nodeName))
+	}
+
+	if nodeSettingsApply {
+		var settings []model.NodeSettings
+		if err := s.db.Where("system_ssh_key_id = ?", key.ID).Find(&settings).Error; err != nil {
+			return nil, err
+		}
+		for _, setting := range settings {
+			resolve(setting.ClusterName, setting.NodeName)
+		}
+	}
+
+	isDefault, err := s.isEffectiveDefault(keySource, key)
+	if err != nil {
+		return nil, err
+	}
+	if isDefault {
+		if err := s.planDefaultKeyNodes(resolve); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(unresolved) > 0 {
+		sort.Strings(unresolved)
+		return nil, fmt.Errorf("cannot resolve %d nodes that use this key, fix or remove their node settings first: %s",
+			len(unresolved), strings.Join(unresolved, "; "))
+	}
+
+	if keySource == model.SSHKeySourceAnsible {
+		var inventories []model.AnsibleInventory
+		if err := s.db.Where("ssh_key_id = ?", key.ID).Find(&inventories).Error; err != nil {
+			return nil, err
+		}
+		for _, inventory := range inventories {
+			for _, host := range ansible.InventorySSHHosts(inventory.Content) {
+				user := host.User
+				if user == "" {
+					user = key.Username
+				}
+				port := host.Port
+				if port == 0 {
+					port = key.Port
+				}
+				planner.add(host.Host, port, user, "inventory:"+inventory.Name)
+			}
+		}
+	}
+
+	return planner.targets, nil
+}
+
+// isEffectiveDefault 判断未指定密钥的节点是否使用该密钥（system_ssh_keys 中没有默认密钥时才使用 ansible_ssh_keys 中的默认密钥）
+func (s *Service) isEffectiveDefault(keySource string, key *model.SystemSSHKey) (bool, error) {
+	if !key.IsDefault {
+		return false, nil
+	}
+	if keySource == model.SSHKeySourceSystem {
+		return true, nil
+	}
+	var count int64
+	if err := s.db.Model(&model.SystemSSHKey{}).Where("is_default = ?", true).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// planDefaultKeyNodes 收集所有活跃集群中未在节点配置里指定密钥的节点
+func (s *Service) planDefaultKeyNodes(resolve func(clusterName, nodeName string)) error {
+	var clusters []model.Cluster
+	if err := s.db.Where("status = ?", model.ClusterStatusActive).Find(&clusters).Error; err != nil {
+		return err
+	}
+
+	var configured []model.NodeSettings
+	if err := s.db.Where("system_ssh_key_id IS NOT NULL").Find(&configured).Error; err != nil {
+		return err
+	}
+	hasKey := make(map[string]bool, len(configured))
+	for _, setting := range configured {
+		hasKey[setting.ClusterName+"/"+setting.NodeName] = true
+	}
+
+	for _, cluster := range clusters {
+		nodes, err := s.lister.ListNodesWithCache(cluster.Name, false)
+		if err != nil {
+			return fmt.Errorf("failed to list nodes of cluster %s: %w", cluster.Name, err)
+		}
+		for _, node := range nodes {
+			if !hasKey[cluster.Name+"/"+node.Name] {
+				resolve(cluster.Name, node.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// targetPlanner 按 用户@主机:端口 合并目标
+type targetPlanner struct {
+	targets model.SSHKeyRotationTargets
+	index   map[string]int
+}
+
+func newTargetPlanner() *targetPlanner {
+	return &targetPlanner{targets: model.SSHKeyRotationTargets{}, index: make(map[string]int)}
+}
+
+func (p *targetPlanner) add(host string, port int, username, source string) {
+	if port == 0 {
+		port = 22
+	}
+	key := fmt.Sprintf("%s@%s:%d", username, host, port)
+	if idx, ok := p.index[key]; ok {
+		p.targets[idx].Sources = append(p.targets[idx].Sources, source)
+		return
+	}
+	p.index[key] = len(p.targets)
+	p.targets = append(p.targets, model.SSHKeyRotationTarget{
+		Host:      host,
+		Port:      port,
+		Username:  username,
+		Sources:   []string{source},
+		Status:    model.SSHKeyRotationTargetPending,
+		UpdatedAt: time.Now(),
+	})
+}
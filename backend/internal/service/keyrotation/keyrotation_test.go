@@ -0,0 +1,384 @@
+package keyrotation
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/pkg/crypto"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+// fakeHosts 模拟各主机的 authorized_keys，只有已授权的密钥才能登录
+type fakeHosts struct {
+	mu         sync.Mutex
+	authorized map[string]map[string]bool
+	rejectNew  map[string]bool // 新密钥无法登录的主机（例如 sshd 不支持 ed25519）
+}
+
+func (f *fakeHosts) login(target model.SSHKeyRotationTarget, key *model.SystemSSHKey) (map[string]bool, error) {
+	signer, err := parseSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	blob := keyBlob(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	keys, ok := f.authorized[target.Host]
+	if !ok || !keys[blob] {
+		return nil, fmt.Errorf("permission denied (publickey)")
+	}
+	return keys, nil
+}
+
+func (f *fakeHosts) AddKey(_ context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey, publicKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys, err := f.login(target, key)
+	if err != nil {
+		return err
+	}
+	if !f.rejectNew[target.Host] {
+		keys[keyBlob(publicKey)] = true
+	}
+	return nil
+}
+
+func (f *fakeHosts) RemoveKey(_ context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey, publicKey string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys, err := f.login(target, key)
+	if err != nil {
+		return err
+	}
+	delete(keys, keyBlob(publicKey))
+	return nil
+}
+
+func (f *fakeHosts) Verify(_ context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err := f.login(target, key)
+	return err
+}
+
+type fakeNodes map[string]string
+
+func (f fakeNodes) GetNodeSSHConfig(_ context.Context, clusterName, nodeName string) (*model.SystemSSHKey, string, error) {
+	host, ok := f[clusterName+"/"+nodeName]
+	if !ok {
+		return nil, "", fmt.Errorf("node not found")
+	}
+	return &model.SystemSSHKey{Username: "root", Port: 22}, host, nil
+}
+
+func (f fakeNodes) ListNodesWithCache(string, bool) ([]k8s.NodeInfo, error) {
+	return nil, nil
+}
+
+func newTestKey(t *testing.T) (string, string) {
+	t.Helper()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(block)), keyBlob(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+}
+
+func setupRotation(t *testing.T, rejectNew map[string]bool) (*Service, *fakeHosts, string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.User{}, &model.Cluster{}, &model.SystemSSHKey{}, &model.AnsibleSSHKey{},
+		&model.AnsibleInventory{}, &model.AnsibleProject{}, &model.NodeSettings{}, &model.SSHKeyRotation{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Create(&model.User{Username: "admin", Email: "admin@example.com", Password: "x", Role: model.RoleAdmin, Status: model.StatusActive}).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	privateKey, oldBlob := newTestKey(t)
+	encrypted, err := crypto.NewEncryptor("test-key").Encrypt(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&model.AnsibleSSHKey{ID: 7, Name: "fleet", Type: model.SSHKeyTypePrivateKey, Username: "root", PrivateKey: encrypted, Port: 22, CreatedBy: 1}).Error; err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	keyID := uint(7)
+	if err := db.Create(&model.NodeSettings{ClusterName: "prod", NodeName: "node-1", SystemSSHKeyID: &keyID}).Error; err != nil {
+		t.Fatalf("create node settings: %v", err)
+	}
+	inventory := model.AnsibleInventory{
+		Name:     "workers",
+		SSHKeyID: &keyID,
+		Content:  "[all]\nnode-1 ansible_host=10.0.0.1\nnode-2 ansible_host=10.0.0.2 ansible_user=root\n",
+		UserID:   1,
+	}
+	if err := db.Create(&inventory).Error; err != nil {
+		t.Fatalf("create inventory: %v", err)
+	}
+
+	hosts := &fakeHosts{
+		authorized: map[string]map[string]bool{
+			"10.0.0.1": {oldBlob: true},
+			"10.0.0.2": {oldBlob: true},
+		},
+		rejectNew: rejectNew,
+	}
+	s := NewService(db, logger.NewLogger(), "test-key", fakeNodes{"prod/node-1": "10.0.0.1"}, fakeNodes{})
+	s.installer = hosts
+	return s, hosts, oldBlob
+}
+
+func waitRotation(t *testing.T, s *Service, id uint) *model.SSHKeyRotation {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		rotation, err := s.GetRotation(id)
+		if err != nil {
+			t.Fatalf("get rotation: %v", err)
+		}
+		if rotation.Status != model.SSHKeyRotationRunning {
+			return rotation
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("rotation did not finish")
+	return nil
+}
+
+// TestRotateKey 测试新公钥分发验证后切换密钥并删除旧公钥
+func TestRotateKey(t *testing.T) {
+	s, hosts, oldBlob := setupRotation(t, nil)
+
+	started, err := s.Start(model.SSHKeySourceAnsible, 7, model.SSHKeyRotateRequest{}, 1)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := s.Start(model.SSHKeySourceAnsible, 7, model.SSHKeyRotateRequest{}, 1); err != ErrRotationInProgress {
+		t.Errorf("expected ErrRotationInProgress, got %v", err)
+	}
+
+	rotation := waitRotation(t, s, started.ID)
+	if rotation.Status != model.SSHKeyRotationCompleted {
+		t.Fatalf("expected completed, got %s: %s", rotation.Status, rotation.ErrorMessage)
+	}
+	// node-1 同时被节点配置和清单引用，只处理一次
+	if rotation.TotalTargets != 2 {
+		t.Fatalf("expected 2 targets, got %+v", rotation.Targets)
+	}
+	for _, target := range rotation.Targets {
+		if target.Status != model.SSHKeyRotationTargetCleaned {
+			t.Errorf("target %s not cleaned: %s %s", target.Host, target.Status, target.Error)
+		}
+	}
+	if len(rotation.Targets[0].Sources) != 2 {
+		t.Errorf("expected node-1 to be referenced twice, got %v", rotation.Targets[0].Sources)
+	}
+
+	key, err := s.loadKey(model.SSHKeySourceAnsible, 7)
+	if err != nil {
+		t.Fatalf("load key: %v", err)
+	}
+	signer, err := parseSigner(key)
+	if err != nil {
+		t.Fatalf("parse rotated key: %v", err)
+	}
+	if ssh.FingerprintSHA256(signer.PublicKey()) != rotation.NewFingerprint {
+		t.Error("key record was not switched to the new key")
+	}
+	newBlob := keyBlob(rotation.NewPublicKey)
+	for host, keys := range hosts.authorized {
+		if keys[oldBlob] || !keys[newBlob] || len(keys) != 1 {
+			t.Errorf("host %s should only authorize the new key, got %v", host, keys)
+		}
+	}
+}
+
+// TestConcurrentStart 测试多个副本同时对同一密钥发起轮换，只有一个能创建执行中的记录
+func TestConcurrentStart(t *testing.T) {
+	s, hosts, _ := setupRotation(t, nil)
+	other := NewService(s.db, s.logger, "test-key", s.nodes, s.lister)
+	other.installer = hosts
+
+	// 所有请求都通过 checkNotRunning 后才放行插入，稳定复现先检查后插入的竞争
+	const starts = 8
+	var arrived sync.Mutex
+	waiting := 0
+	gate := make(chan struct{})
+	err := s.db.Callback().Create().Before("gorm:begin_transaction").Register("test:start_barrier", func(db *gorm.DB) {
+		if db.Statement.Schema == nil || db.Statement.Schema.Table != "ssh_key_rotations" {
+			return
+		}
+		arrived.Lock()
+		if waiting++; waiting == starts {
+			close(gate)
+		}
+		arrived.Unlock()
+		select {
+		case <-gate:
+		case <-time.After(2 * time.Second):
+		}
+	})
+	if err != nil {
+		t.Fatalf("register callback: %v", err)
+	}
+
+	// 阻塞主机操作，保证发起期间已创建的轮换不会执行完
+	hosts.mu.Lock()
+	var wg sync.WaitGroup
+	results := make(chan error, starts)
+	started := make(chan uint, starts)
+	for i := 0; i < starts; i++ {
+		replica := s
+		if i%2 == 1 {
+			replica = other
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rotation, err := replica.Start(model.SSHKeySourceAnsible, 7, model.SSHKeyRotateRequest{}, 1)
+			if err == nil {
+				started <- rotation.ID
+			}
+			results <- err
+		}()
+	}
+	wg.Wait()
+	hosts.mu.Unlock()
+	close(results)
+	close(started)
+
+	for err := range results {
+		if err != nil && err != ErrRotationInProgress {
+			t.Errorf("unexpected start error: %v", err)
+		}
+	}
+	if len(started) != 1 {
+		t.Fatalf("started %d rotations, want exactly 1", len(started))
+	}
+	var running int64
+	s.db.Model(&model.SSHKeyRotation{}).Where("status = ?", model.SSHKeyRotationRunning).Count(&running)
+	if running > 1 {
+		t.Errorf("running rotations = %d, want at most 1", running)
+	}
+
+	// 轮换结束后释放 running_key，可以再次发起
+	rotation := waitRotation(t, s, <-started)
+	if rotation.RunningKey != nil {
+		t.Errorf("running key should be cleared after finish, got %q", *rotation.RunningKey)
+	}
+	next, err := other.Start(model.SSHKeySourceAnsible, 7, model.SSHKeyRotateRequest{}, 1)
+	if err != nil {
+		t.Fatalf("start after finish: %v", err)
+	}
+	waitRotation(t, s, next.ID)
+}
+
+// TestRotateKeyRollback 测试部分主机验证失败时撤销新公钥并保留原密钥
+func TestRotateKeyRollback(t *testing.T) {
+	s, hosts, oldBlob := setupRotation(t, map[string]bool{"10.0.0.2": true})
+
+	started, err := s.Start(model.SSHKeySourceAnsible, 7, model.SSHKeyRotateRequest{Concurrency: 1}, 1)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	rotation := waitRotation(t, s, started.ID)
+	if rotation.Status != model.SSHKeyRotationRolledBack {
+		t.Fatalf("expected rolled_back, got %s: %s", rotation.Status, rotation.ErrorMessage)
+	}
+	for _, target := range rotation.Targets {
+		if target.Status != model.SSHKeyRotationTargetRolledBack {
+			t.Errorf("target %s not rolled back: %s %s", target.Host, target.Status, target.Error)
+		}
+	}
+	if rotation.Targets[1].Error == "" {
+		t.Error("failed target should keep its error")
+	}
+
+	key, err := s.loadKey(model.SSHKeySourceAnsible, 7)
+	if err != nil {
+		t.Fatalf("load key: %v", err)
+	}
+	signer, err := parseSigner(key)
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	if ssh.FingerprintSHA256(signer.PublicKey()) != rotation.OldFingerprint {
+		t.Error("key record should be unchanged after rollback")
+	}
+	for host, keys := range hosts.authorized {
+		if !keys[oldBlob] || len(keys) != 1 {
+			t.Errorf("host %s should only authorize the old key, got %v", host, keys)
+		}
+	}
+}
+
+// TestAuthorizedKeysCommands 在本地 shell 中执行追加和删除公钥的命令
+func TestAuthorizedKeysCommands(t *testing.T) {
+	home := t.TempDir()
+	run := func(command string) {
+		t.Helper()
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Env = append(os.Environ(), "HOME="+home)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("command failed: %v: %s", err, output)
+		}
+	}
+	authorizedKeys := filepath.Join(home, ".ssh", "authorized_keys")
+
+	oldKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOld old@example"
+	newKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINew kube-node-manager"
+	if err := os.MkdirAll(filepath.Dir(authorizedKeys), 0700); err != nil {
+		t.Fatal(err)
+	}
+	// 最后一行没有换行
+	if err := os.WriteFile(authorizedKeys, []byte("ssh-rsa AAAAB3Other other\n"+oldKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run(addKeyCommand(newKey))
+	run(addKeyCommand(newKey))
+	content, _ := os.ReadFile(authorizedKeys)
+	if want := "ssh-rsa AAAAB3Other other\n" + oldKey + "\n" + newKey + "\n"; string(content) != want {
+		t.Fatalf("unexpected content after add:\n%s", content)
+	}
+
+	run(removeKeyCommand(oldKey))
+	content, _ = os.ReadFile(authorizedKeys)
+	if want := "ssh-rsa AAAAB3Other other\n" + newKey + "\n"; string(content) != want {
+		t.Fatalf("unexpected content after remove:\n%s", content)
+	}
+	info, err := os.Stat(authorizedKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("permissions changed: %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,117 @@
+package keyrotation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/node"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// keyInstaller 在目标主机上维护 authorized_keys
+type keyInstaller interface {
+	// AddKey 使用 key 登录，追加 publicKey（已存在时不重复添加）
+	AddKey(ctx context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey, publicKey string) error
+	// RemoveKey 使用 key 登录，删除 publicKey
+	RemoveKey(ctx context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey, publicKey string) error
+	// Verify 验证 key 可以登录
+	Verify(ctx context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey) error
+}
+
+// sshInstaller 通过 SSH 执行命令修改 ~/.ssh/authorized_keys
+type sshInstaller struct{}
+
+func (sshInstaller) AddKey(ctx context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey, publicKey string) error {
+	return runCommand(ctx, target, key, addKeyCommand(publicKey))
+}
+
+func (sshInstaller) RemoveKey(ctx context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey, publicKey string) error {
+	// 空匹配会删除所有行
+	if keyBlob(publicKey) == "" {
+		return fmt.Errorf("invalid public key")
+	}
+	return runCommand(ctx, target, key, removeKeyCommand(publicKey))
+}
+
+func (sshInstaller) Verify(ctx context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey) error {
+	return runCommand(ctx, target, key, "true")
+}
+
+// runCommand 以目标主机的登录用户连接并执行命令，ctx 结束时断开连接
+func runCommand(ctx context.Context, target model.SSHKeyRotationTarget, key *model.SystemSSHKey, command string) error {
+	loginKey := *key
+	loginKey.Username = target.Username
+	config, err := node.NewSSHClientConfig(&loginKey)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(target.Host, strconv.Itoa(target.Port))
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			client.Close()
+		case <-done:
+		}
+	}()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	output, err := session.CombinedOutput(command)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// keyBlob 返回 authorized_keys 行中的 base64 公钥部分，只包含 [A-Za-z0-9+/=]，可以直接放在单引号中
+func keyBlob(publicKey string) string {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return ""
+	}
+	return fields[1]
+}
+
+// addKeyCommand 追加公钥，文件末尾没有换行时先补一个换行，避免与最后一行连在一起
+func addKeyCommand(publicKey string) string {
+	line := strings.TrimSpace(publicKey)
+	return fmt.Sprintf(`umask 077; mkdir -p ~/.ssh && touch ~/.ssh/authorized_keys || exit 1
+grep -qF '%s' ~/.ssh/authorized_keys && exit 0
+[ -z "$(tail -c1 ~/.ssh/authorized_keys)" ] || echo >> ~/.ssh/authorized_keys
+echo '%s' >> ~/.ssh/authorized_keys`, keyBlob(line), line)
+}
+
+// removeKeyCommand 删除包含指定公钥的行，通过重写原文件保留文件权限和属主
+func removeKeyCommand(publicKey string) string {
+	return fmt.Sprintf(`f=~/.ssh/authorized_keys
+[ -f "$f" ] || exit 0
+grep -vF '%s' "$f" > "$f.knm-rotate"
+if [ $? -gt 1 ]; then rm -f "$f.knm-rotate"; exit 1; fi
+cat "$f.knm-rotate" > "$f"; rc=$?
+rm -f "$f.knm-rotate"
+exit $rc`, keyBlob(publicKey))
+}
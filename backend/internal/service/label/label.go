@@ -7,6 +7,7 @@ import (
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/audit"
 	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/internal/service/nodechange"
 	"kube-node-manager/internal/service/progress"
 	"kube-node-manager/pkg/logger"
 	"strings"
@@ -22,6 +23,8 @@ type Service struct {
 	auditSvc    *audit.Service
 	k8sSvc      *k8s.Service
 	progressSvc *progress.Service
+
+	changeSvc *nodechange.Service
 }
 
 // UpdateLabelsRequest 更新节点标签请求
@@ -30,6 +33,8 @@ type UpdateLabelsRequest struct {
 	NodeName    string            `json:"node_name" binding:"required"`
 	Labels      map[string]string `json:"labels" binding:"required"`
 	Operation   string            `json:"operation"` // add, remove, replace
+
+	OperationID string `json:"-"` // 所属操作 ID，为空时作为单独的操作记录
 }
 
 // BatchUpdateRequest 批量更新标签请求
@@ -38,6 +43,8 @@ type BatchUpdateRequest struct {
 	NodeNames   []string          `json:"node_names" binding:"required"`
 	Labels      map[string]string `json:"labels" binding:"required"`
 	Operation   string            `json:"operation"` // add, remove, replace
+
+	OperationID string `json:"operation_id,omitempty"` // 由服务端生成，随任务参数持久化以便恢复后仍归属同一操作
 }
 
 // TemplateCreateRequest 创建标签模板请求
@@ -83,11 +90,12 @@ type TemplateInfo struct {
 
 // ApplyTemplateRequest 应用模板请求
 type ApplyTemplateRequest struct {
-	ClusterName string            `json:"cluster_name" binding:"required"`
-	NodeNames   []string          `json:"node_names" binding:"required"`
-	TemplateID  uint              `json:"template_id" binding:"required"`
-	Operation   string            `json:"operation"` // add, replace
-	Labels      map[string]string `json:"labels"`    // 用户选择的具体标签值
+	ClusterName       string            `json:"cluster_name" binding:"required"`
+	NodeNames         []string          `json:"node_names" binding:"required"`
+	TemplateID        uint              `json:"template_id" binding:"required"`
+	Operation         string            `json:"operation"`          // add, replace
+	Labels            map[string]string `json:"labels"`             // 用户选择的具体标签值
+	AcknowledgeImpact bool              `json:"acknowledge_impact"` // 确认已知晓变更影响（驱逐 Pod、工作负载失去可调度节点）
 }
 
 // LabelUsage 标签使用情况
@@ -111,6 +119,20 @@ func NewService(db *gorm.DB, logger *logger.Logger, auditSvc *audit.Service, k8s
 // SetProgressService 设置进度推送服务
 func (s *Service) SetProgressService(progressSvc *progress.Service) {
 	s.progressSvc = progressSvc
+
+	// 注册批量任务恢复器，用于取消或副本中断后继续处理剩余节点
+	progressSvc.RegisterResumer("batch_label", func(params json.RawMessage, userID uint) (progress.BatchProcessor, error) {
+		var req BatchUpdateRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		return &LabelProcessor{svc: s, req: req, userID: userID}, nil
+	})
+}
+
+// SetNodeChangeService 设置节点变更记录服务
+func (s *Service) SetNodeChangeService(changeSvc *nodechange.Service) {
+	s.changeSvc = changeSvc
 }
 
 // getClusterIDByName 根据集群名称获取集群ID
@@ -121,6 +143,9 @@ func (s *Service) getClusterIDByName(clusterName string) (uint, error) {
 // UpdateNodeLabels 更新单个节点标签
 func (s *Service) UpdateNodeLabels(req UpdateLabelsRequest, userID uint) error {
 	s.logger.Debugf("[UpdateNodeLabels] Starting for node %s in cluster %s", req.NodeName, req.ClusterName)
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
 	
 	// 获取当前节点信息，强制刷新缓存确保获取最新的标签
 	s.logger.Debugf("[UpdateNodeLabels] Getting node info for %s", req.NodeName)
@@ -144,20 +169,83 @@ func (s *Service) UpdateNodeLabels(req UpdateLabelsRequest, userID uint) error {
 		return fmt.Errorf("failed to get node %s in cluster %s: %w", req.NodeName, req.ClusterName, err)
 	}
 
-	// 准备更新的标签
+	updatedLabels, err := s.mergeLabels(req.NodeName, currentNode.Labels, req.Labels, req.Operation)
+	if err != nil {
+		return err
+	}
+
+	// 更新节点标签
+	s.logger.Debugf("[UpdateNodeLabels] Calling k8sSvc.UpdateNodeLabels for node %s", req.NodeName)
+	updateReq := k8s.LabelUpdateRequest{
+		NodeName: req.NodeName,
+		Labels:   updatedLabels,
+	}
+
+	if err := s.k8sSvc.UpdateNodeLabels(req.ClusterName, updateReq); err != nil {
+		s.logger.Errorf("Failed to update node labels: %v", err)
+		var clusterID *uint
+		if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
+			clusterID = &cID
+		}
+		s.auditSvc.Log(audit.LogRequest{
+			UserID:       userID,
+			ClusterID:    clusterID,
+			NodeName:     req.NodeName,
+			Action:       model.ActionUpdate,
+			ResourceType: model.ResourceLabel,
+			Details:      fmt.Sprintf("Failed to update labels for node %s", req.NodeName),
+			Status:       model.AuditStatusFailed,
+			ErrorMsg:     err.Error(),
+		})
+		return fmt.Errorf("failed to update node labels: %w", err)
+	}
+
+	// 成功日志已在k8s服务中记录，避免重复
+	s.logger.Debugf("[UpdateNodeLabels] Successfully updated labels for node %s", req.NodeName)
+	if s.changeSvc != nil {
+		s.changeSvc.Record(nodechange.RecordRequest{
+			OperationID:  req.OperationID,
+			ClusterName:  req.ClusterName,
+			NodeName:     req.NodeName,
+			ResourceType: model.NodeChangeLabel,
+			Before:       nodechange.LabelState(currentNode.Labels),
+			After:        nodechange.LabelState(updatedLabels),
+			UserID:       userID,
+		})
+	}
+	var clusterID *uint
+	if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
+		clusterID = &cID
+	}
+	s.auditSvc.Log(audit.LogRequest{
+		UserID:       userID,
+		ClusterID:    clusterID,
+		NodeName:     req.NodeName,
+		Action:       model.ActionUpdate,
+		ResourceType: model.ResourceLabel,
+		Details:      fmt.Sprintf("Updated labels for node %s in cluster %s", req.NodeName, req.ClusterName),
+		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
+	})
+
+	return nil
+}
+
+// mergeLabels 按操作类型计算节点更新后的标签
+func (s *Service) mergeLabels(nodeName string, current, labels map[string]string, operation string) (map[string]string, error) {
 	updatedLabels := make(map[string]string)
 
 	// 复制现有标签
-	if currentNode.Labels != nil {
-		for k, v := range currentNode.Labels {
+	if current != nil {
+		for k, v := range current {
 			updatedLabels[k] = v
 		}
 	}
 
-	switch strings.ToLower(req.Operation) {
+	switch strings.ToLower(operation) {
 	case "add", "":
 		// 添加或更新标签
-		for k, v := range req.Labels {
+		for k, v := range labels {
 			// 验证并清理标签值
 			cleanedValue := s.sanitizeLabelValue(v)
 			if cleanedValue != "" {
@@ -165,30 +253,30 @@ func (s *Service) UpdateNodeLabels(req UpdateLabelsRequest, userID uint) error {
 			} else {
 				s.logger.Warningf("Skipping invalid label value for key %s", k)
 				// 如果清理后的值为空，保留原有标签值（如果存在）
-				if existingValue, exists := currentNode.Labels[k]; exists {
+				if existingValue, exists := current[k]; exists {
 					updatedLabels[k] = existingValue
 				}
 			}
 		}
 	case "remove":
 		// 删除指定标签
-		for k := range req.Labels {
+		for k := range labels {
 			if _, exists := updatedLabels[k]; exists {
 				delete(updatedLabels, k)
 			} else {
-				s.logger.Debugf("Label key %s not found on node %s", k, req.NodeName)
+				s.logger.Debugf("Label key %s not found on node %s", k, nodeName)
 			}
 		}
 	case "replace":
 		// 替换所有自定义标签（保留系统标签）
 		systemLabels := make(map[string]string)
-		for k, v := range currentNode.Labels {
+		for k, v := range current {
 			if s.isSystemLabel(k) {
 				systemLabels[k] = v
 			}
 		}
 		updatedLabels = systemLabels
-		for k, v := range req.Labels {
+		for k, v := range labels {
 			// 验证并清理标签值
 			cleanedValue := s.sanitizeLabelValue(v)
 			if cleanedValue != "" {
@@ -198,52 +286,10 @@ func (s *Service) UpdateNodeLabels(req UpdateLabelsRequest, userID uint) error {
 			}
 		}
 	default:
-		return fmt.Errorf("invalid operation: %s", req.Operation)
-	}
-
-	// 更新节点标签
-	s.logger.Debugf("[UpdateNodeLabels] Calling k8sSvc.UpdateNodeLabels for node %s", req.NodeName)
-	updateReq := k8s.LabelUpdateRequest{
-		NodeName: req.NodeName,
-		Labels:   updatedLabels,
+		return nil, fmt.Errorf("invalid operation: %s", operation)
 	}
 
-	if err := s.k8sSvc.UpdateNodeLabels(req.ClusterName, updateReq); err != nil {
-		s.logger.Errorf("Failed to update node labels: %v", err)
-		var clusterID *uint
-		if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
-			clusterID = &cID
-		}
-		s.auditSvc.Log(audit.LogRequest{
-			UserID:       userID,
-			ClusterID:    clusterID,
-			NodeName:     req.NodeName,
-			Action:       model.ActionUpdate,
-			ResourceType: model.ResourceLabel,
-			Details:      fmt.Sprintf("Failed to update labels for node %s", req.NodeName),
-			Status:       model.AuditStatusFailed,
-			ErrorMsg:     err.Error(),
-		})
-		return fmt.Errorf("failed to update node labels: %w", err)
-	}
-
-	// 成功日志已在k8s服务中记录，避免重复
-	s.logger.Debugf("[UpdateNodeLabels] Successfully updated labels for node %s", req.NodeName)
-	var clusterID *uint
-	if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
-		clusterID = &cID
-	}
-	s.auditSvc.Log(audit.LogRequest{
-		UserID:       userID,
-		ClusterID:    clusterID,
-		NodeName:     req.NodeName,
-		Action:       model.ActionUpdate,
-		ResourceType: model.ResourceLabel,
-		Details:      fmt.Sprintf("Updated labels for node %s in cluster %s", req.NodeName, req.ClusterName),
-		Status:       model.AuditStatusSuccess,
-	})
-
-	return nil
+	return updatedLabels, nil
 }
 
 // LabelProcessor 实现 BatchProcessor 接口
@@ -259,6 +305,7 @@ func (p *LabelProcessor) ProcessNode(ctx context.Context, nodeName string, index
 		NodeName:    nodeName,
 		Labels:      p.req.Labels,
 		Operation:   p.req.Operation,
+		OperationID: p.req.OperationID,
 	}
 
 	err := p.svc.UpdateNodeLabels(updateReq, p.userID)
@@ -278,6 +325,12 @@ func (s *Service) BatchUpdateLabels(req BatchUpdateRequest, userID uint) error {
 func (s *Service) BatchUpdateLabelsWithProgress(req BatchUpdateRequest, userID uint, taskID string) error {
 	s.logger.Infof("Starting batch update for %d nodes in cluster %s", len(req.NodeNames), req.ClusterName)
 
+	// 同一批次的节点共享操作 ID，带进度的任务直接使用任务 ID
+	req.OperationID = taskID
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
+
 	// 注意：使用 Informer + WebSocket 实时同步后，无需手动清除缓存
 	// Informer 会自动检测到节点变化并通过 WebSocket 推送给前端
 
@@ -291,7 +344,7 @@ func (s *Service) BatchUpdateLabelsWithProgress(req BatchUpdateRequest, userID u
 
 		// 使用进度推送的并发处理
 		maxConcurrency := 5 // 限制并发数避免过载
-		if err := s.progressSvc.ProcessBatchWithProgress(
+		if err := s.progressSvc.ProcessResumableBatch(
 			context.Background(),
 			taskID,
 			"batch_label",
@@ -299,6 +352,7 @@ func (s *Service) BatchUpdateLabelsWithProgress(req BatchUpdateRequest, userID u
 			userID,
 			maxConcurrency,
 			processor,
+			req,
 		); err != nil {
 			var clusterID *uint
 			if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
@@ -312,6 +366,7 @@ func (s *Service) BatchUpdateLabelsWithProgress(req BatchUpdateRequest, userID u
 				Details:      fmt.Sprintf("Batch update labels failed for %d nodes", len(req.NodeNames)),
 				Status:       model.AuditStatusFailed,
 				ErrorMsg:     err.Error(),
+				OperationID:  req.OperationID,
 			})
 			return err
 		}
@@ -325,6 +380,7 @@ func (s *Service) BatchUpdateLabelsWithProgress(req BatchUpdateRequest, userID u
 				NodeName:    nodeName,
 				Labels:      req.Labels,
 				Operation:   req.Operation,
+				OperationID: req.OperationID,
 			}
 
 			if err := s.UpdateNodeLabels(updateReq, userID); err != nil {
@@ -356,6 +412,7 @@ func (s *Service) BatchUpdateLabelsWithProgress(req BatchUpdateRequest, userID u
 				Details:      fmt.Sprintf("Batch update labels failed for %d nodes", len(errors)),
 				Status:       model.AuditStatusFailed,
 				ErrorMsg:     combinedError,
+				OperationID:  req.OperationID,
 			})
 			return fmt.Errorf("batch update failed for some nodes: %s", combinedError)
 		}
@@ -372,6 +429,7 @@ func (s *Service) BatchUpdateLabelsWithProgress(req BatchUpdateRequest, userID u
 		ResourceType: model.ResourceLabel,
 		Details:      fmt.Sprintf("Batch updated labels for %d nodes in cluster %s", len(req.NodeNames), req.ClusterName),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return nil
@@ -695,6 +753,10 @@ func (s *Service) ApplyTemplate(req ApplyTemplateRequest, userID uint) error {
 		Operation:   operation,
 	}
 
+	if err := s.checkImpactAcknowledged(batchReq, req.AcknowledgeImpact); err != nil {
+		return err
+	}
+
 	if err := s.BatchUpdateLabels(batchReq, userID); err != nil {
 		var clusterID *uint
 		if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
@@ -721,7 +783,7 @@ func (s *Service) ApplyTemplate(req ApplyTemplateRequest, userID uint) error {
 		ClusterID:    clusterID,
 		Action:       model.ActionUpdate,
 		ResourceType: model.ResourceLabel,
-		Details:      fmt.Sprintf("Applied template %s to %d nodes in cluster %s", template.Name, len(req.NodeNames), req.ClusterName),
+		Details:      fmt.Sprintf("Applied template %s to %d nodes in cluster %s%s", template.Name, len(req.NodeNames), req.ClusterName, impactNote(req.AcknowledgeImpact)),
 		Status:       model.AuditStatusSuccess,
 	})
 
@@ -2,17 +2,21 @@ package node
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/audit"
 	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/internal/service/nodechange"
 	"kube-node-manager/internal/service/progress"
+	"kube-node-manager/internal/service/sshca"
 	"kube-node-manager/internal/service/sshkey"
 	"kube-node-manager/pkg/logger"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // Service 节点管理服务
@@ -24,6 +28,11 @@ type Service struct {
 	sshKeySvc       *sshkey.Service
 	progressSvc     *progress.Service
 	concurrencyCtrl *ConcurrencyController // 并发控制器
+
+	changeSvc *nodechange.Service
+
+	sftpConfig SFTPConfig     // 节点文件传输配置
+	sshCA      *sshca.Service // SSH 证书颁发服务，未启用时使用系统密钥
 }
 
 // ListRequest 节点列表请求
@@ -49,6 +58,8 @@ type CordonRequest struct {
 	ClusterName string `json:"cluster_name" binding:"required"`
 	NodeName    string `json:"node_name"` // 从URL路径参数获取，不需要binding验证
 	Reason      string `json:"reason"`    // 禁止调度的原因说明
+
+	OperationID string `json:"-"` // 所属操作 ID，为空时作为单独的操作记录
 }
 
 // BatchNodeRequest 批量节点操作请求
@@ -56,6 +67,24 @@ type BatchNodeRequest struct {
 	ClusterName string   `json:"cluster_name"`
 	Nodes       []string `json:"nodes" binding:"required"`
 	Reason      string   `json:"reason"` // 批量操作的原因说明
+	DryRun      bool     `json:"dry_run"` // 仅驱逐时有效：只返回预检结果，不执行操作
+
+	OperationID string `json:"operation_id,omitempty"` // 由服务端生成，随任务参数持久化以便恢复后仍归属同一操作
+}
+
+// DrainPreflightRequest 驱逐预检请求
+type DrainPreflightRequest struct {
+	ClusterName string   `json:"cluster_name" binding:"required"`
+	Nodes       []string `json:"nodes" binding:"required,min=1"`
+}
+
+// ScheduleSimulateRequest 调度模拟请求，pod_spec 与 workload 二选一
+type ScheduleSimulateRequest struct {
+	ClusterName string           `json:"cluster_name" binding:"required"`
+	Namespace   string           `json:"namespace"`
+	PodSpec     *corev1.PodSpec  `json:"pod_spec"`
+	Kind        string           `json:"kind"` // pod_spec 所属的工作负载类型，DaemonSet 会补充默认容忍
+	Workload    *k8s.WorkloadRef `json:"workload"`
 }
 
 // DrainRequest 节点驱逐请求
@@ -63,6 +92,8 @@ type DrainRequest struct {
 	ClusterName string `json:"cluster_name" binding:"required"`
 	NodeName    string `json:"node_name"` // 从URL路径参数获取，不需要binding验证
 	Reason      string `json:"reason"`    // 驱逐的原因说明
+
+	OperationID string `json:"-"` // 所属操作 ID，为空时作为单独的操作记录
 }
 
 // CordonInfoRequest 获取禁止调度信息请求
@@ -117,7 +148,46 @@ func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, auditSv
 		auditSvc:        auditSvc,
 		sshKeySvc:       sshKeySvc,
 		concurrencyCtrl: NewConcurrencyController(),
+		sftpConfig:      DefaultSFTPConfig(),
+	}
+}
+
+// SetNodeChangeService 设置节点变更记录服务
+func (s *Service) SetNodeChangeService(changeSvc *nodechange.Service) {
+	s.changeSvc = changeSvc
+}
+
+// cordonState 获取节点当前的调度状态快照，未启用变更记录或获取失败时返回 nil
+func (s *Service) cordonState(clusterName, nodeName string) *model.NodeStateSnapshot {
+	if s.changeSvc == nil {
+		return nil
+	}
+	node, err := s.k8sSvc.GetNodeWithCache(clusterName, nodeName, true)
+	if err != nil {
+		s.logger.Warningf("Failed to get node %s before changing schedulability: %v", nodeName, err)
+		return nil
+	}
+	state := nodechange.CordonState(!node.Schedulable, node.UnschedulableReason)
+	return &state
+}
+
+// recordCordonChange 记录调度状态变更，reason 为空时保留原有的禁止调度原因
+func (s *Service) recordCordonChange(operationID, clusterName, nodeName string, before *model.NodeStateSnapshot, cordoned bool, reason string, userID uint) {
+	if s.changeSvc == nil || before == nil {
+		return
+	}
+	if cordoned && reason == "" {
+		reason = before.CordonReason
 	}
+	s.changeSvc.Record(nodechange.RecordRequest{
+		OperationID:  operationID,
+		ClusterName:  clusterName,
+		NodeName:     nodeName,
+		ResourceType: model.NodeChangeCordon,
+		Before:       *before,
+		After:        nodechange.CordonState(cordoned, reason),
+		UserID:       userID,
+	})
 }
 
 // GetNodeSettings 获取节点配置
@@ -213,7 +283,11 @@ func (s *Service) GetNodeSSHConfig(ctx context.Context, clusterName, nodeName st
 			s.logger.Errorf("Failed to get default SSH key: %v", err)
 			return nil, "", fmt.Errorf("failed to get default system ssh key: %v", err)
 		}
-		if sshKey == nil {
+		if sshKey == nil && s.sshCA.Enabled() {
+			// 启用 SSH CA 后节点可以不配置密钥，只使用平台签发的证书登录
+			s.logger.Info("No default SSH key found, will authenticate with SSH CA certificate only")
+			sshKey = &model.SystemSSHKey{Name: "ssh-ca", Username: "root"}
+		} else if sshKey == nil {
 			s.logger.Error("No default SSH key found")
 			return nil, "", fmt.Errorf("no default system ssh key found and no specific key configured")
 		}
@@ -350,11 +424,16 @@ func (s *Service) Get(req GetRequest, userID uint) (*k8s.NodeInfo, error) {
 
 // Cordon 禁止调度节点（标记为不可调度）
 func (s *Service) Cordon(req CordonRequest, userID uint) error {
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
+
 	// 获取集群ID
 	var clusterID *uint
 	if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
 		clusterID = &cID
 	}
+	before := s.cordonState(req.ClusterName, req.NodeName)
 
 	// 执行禁止调度操作（仅设置不可调度，不删除pods），并添加原因注释
 	err := s.k8sSvc.CordonNodeWithReason(req.ClusterName, req.NodeName, req.Reason)
@@ -379,6 +458,7 @@ func (s *Service) Cordon(req CordonRequest, userID uint) error {
 	}
 
 	s.logger.Infof("Successfully cordoned node %s for cluster %s", req.NodeName, req.ClusterName)
+	s.recordCordonChange(req.OperationID, req.ClusterName, req.NodeName, before, true, req.Reason, userID)
 	reasonMsg := ""
 	if req.Reason != "" {
 		reasonMsg = fmt.Sprintf(" (原因: %s)", req.Reason)
@@ -392,6 +472,7 @@ func (s *Service) Cordon(req CordonRequest, userID uint) error {
 		Details:      fmt.Sprintf("Cordoned node %s for cluster %s%s", req.NodeName, req.ClusterName, reasonMsg),
 		Reason:       req.Reason,
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return nil
@@ -399,11 +480,16 @@ func (s *Service) Cordon(req CordonRequest, userID uint) error {
 
 // Uncordon 解除调度节点（标记为可调度）
 func (s *Service) Uncordon(req CordonRequest, userID uint) error {
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
+
 	// 获取集群ID
 	var clusterID *uint
 	if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
 		clusterID = &cID
 	}
+	before := s.cordonState(req.ClusterName, req.NodeName)
 
 	err := s.k8sSvc.UncordonNode(req.ClusterName, req.NodeName)
 	if err != nil {
@@ -422,6 +508,7 @@ func (s *Service) Uncordon(req CordonRequest, userID uint) error {
 	}
 
 	s.logger.Infof("Successfully uncordoned node %s for cluster %s", req.NodeName, req.ClusterName)
+	s.recordCordonChange(req.OperationID, req.ClusterName, req.NodeName, before, false, "", userID)
 	s.auditSvc.Log(audit.LogRequest{
 		UserID:       userID,
 		ClusterID:    clusterID,
@@ -430,6 +517,7 @@ func (s *Service) Uncordon(req CordonRequest, userID uint) error {
 		ResourceType: model.ResourceNode,
 		Details:      fmt.Sprintf("Uncordoned node %s for cluster %s", req.NodeName, req.ClusterName),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return nil
@@ -606,6 +694,19 @@ func (s *Service) GetMetrics(clusterName, nodeName string, userID uint) (*NodeMe
 	return metrics, nil
 }
 
+// ListPods 获取节点上的 Pod 列表及已分配资源（requests/limits 之和）
+func (s *Service) ListPods(clusterName, nodeName string, req k8s.NodePodsRequest) (*k8s.NodePodsResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := s.k8sSvc.ListNodePods(ctx, clusterName, nodeName, req)
+	if err != nil {
+		s.logger.Errorf("Failed to list pods on node %s in cluster %s: %v", nodeName, clusterName, err)
+		return nil, err
+	}
+	return result, nil
+}
+
 // GetNodesByLabels 根据标签获取节点
 func (s *Service) GetNodesByLabels(clusterName string, labels map[string]string, userID uint) ([]k8s.NodeInfo, error) {
 	nodes, err := s.k8sSvc.ListNodes(clusterName)
@@ -657,6 +758,7 @@ func (s *Service) ValidateNodeOperation(clusterName, nodeName string, operation
 
 // BatchCordon 批量禁止调度节点
 func (s *Service) BatchCordon(req BatchNodeRequest, userID uint) (map[string]interface{}, error) {
+	req.OperationID = nodechange.NewOperationID(userID)
 	results := make(map[string]interface{})
 	errors := make(map[string]string)
 	successful := make([]string, 0)
@@ -669,6 +771,7 @@ func (s *Service) BatchCordon(req BatchNodeRequest, userID uint) (map[string]int
 			ClusterName: req.ClusterName,
 			NodeName:    nodeName,
 			Reason:      req.Reason,
+			OperationID: req.OperationID,
 		}
 
 		if err := s.Cordon(cordonReq, userID); err != nil {
@@ -697,6 +800,7 @@ func (s *Service) BatchCordon(req BatchNodeRequest, userID uint) (map[string]int
 		ResourceType: model.ResourceNode,
 		Details:      fmt.Sprintf("Batch cordon %d nodes in cluster %s: %d successful, %d failed", len(req.Nodes), req.ClusterName, len(successful), len(errors)),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return results, nil
@@ -704,6 +808,7 @@ func (s *Service) BatchCordon(req BatchNodeRequest, userID uint) (map[string]int
 
 // BatchUncordon 批量解除调度节点
 func (s *Service) BatchUncordon(req BatchNodeRequest, userID uint) (map[string]interface{}, error) {
+	req.OperationID = nodechange.NewOperationID(userID)
 	results := make(map[string]interface{})
 	errors := make(map[string]string)
 	successful := make([]string, 0)
@@ -716,6 +821,7 @@ func (s *Service) BatchUncordon(req BatchNodeRequest, userID uint) (map[string]i
 			ClusterName: req.ClusterName,
 			NodeName:    nodeName,
 			Reason:      req.Reason,
+			OperationID: req.OperationID,
 		}
 
 		if err := s.Uncordon(uncordonReq, userID); err != nil {
@@ -744,6 +850,7 @@ func (s *Service) BatchUncordon(req BatchNodeRequest, userID uint) (map[string]i
 		ResourceType: model.ResourceNode,
 		Details:      fmt.Sprintf("Batch uncordon %d nodes in cluster %s: %d successful, %d failed", len(req.Nodes), req.ClusterName, len(successful), len(errors)),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return results, nil
@@ -752,12 +859,17 @@ func (s *Service) BatchUncordon(req BatchNodeRequest, userID uint) (map[string]i
 // Drain 驱逐节点
 func (s *Service) Drain(req DrainRequest, userID uint) error {
 	s.logger.Infof("User %d initiating drain operation on node %s in cluster %s", userID, req.NodeName, req.ClusterName)
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
 
 	// 获取集群ID以正确记录审计日志
 	var clusterID *uint
 	if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
 		clusterID = &cID
 	}
+	// 驱逐会先禁止调度，撤销时只能恢复调度状态，已驱逐的 Pod 不会迁回
+	before := s.cordonState(req.ClusterName, req.NodeName)
 
 	// 调用k8s服务进行节点驱逐
 	if err := s.k8sSvc.DrainNode(req.ClusterName, req.NodeName, req.Reason); err != nil {
@@ -777,6 +889,7 @@ func (s *Service) Drain(req DrainRequest, userID uint) error {
 	}
 
 	// 记录禁止调度的审计日志（这样就不需要依赖后续的同步过程）
+	s.recordCordonChange(req.OperationID, req.ClusterName, req.NodeName, before, true, req.Reason, userID)
 	s.auditSvc.Log(audit.LogRequest{
 		UserID:       userID,
 		ClusterID:    clusterID,
@@ -786,6 +899,7 @@ func (s *Service) Drain(req DrainRequest, userID uint) error {
 		Details:      fmt.Sprintf("Cordoned node %s in cluster %s", req.NodeName, req.ClusterName),
 		Reason:       req.Reason,
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	// 记录驱逐操作的审计日志
@@ -804,14 +918,75 @@ func (s *Service) Drain(req DrainRequest, userID uint) error {
 	return nil
 }
 
+// DrainPreflight 驱逐预检（dry-run）：分析驱逐节点后每个 Pod 的结果，不修改集群
+func (s *Service) DrainPreflight(req DrainPreflightRequest, userID uint) (*k8s.DrainAnalysis, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	analysis, err := s.k8sSvc.AnalyzeDrain(ctx, req.ClusterName, req.Nodes)
+	if err != nil {
+		s.logger.Errorf("Failed to analyze drain of nodes %v in cluster %s: %v", req.Nodes, req.ClusterName, err)
+		return nil, fmt.Errorf("failed to analyze drain: %w", err)
+	}
+
+	s.logger.Infof("User %d ran drain preflight on %d nodes in cluster %s: %d evict, %d blocked, safe=%v",
+		userID, len(req.Nodes), req.ClusterName, analysis.Summary.EvictPods, analysis.Summary.BlockedPods, analysis.Safe)
+	return analysis, nil
+}
+
+// SimulateScheduling 调度模拟：评估 Pod 规格或已有工作负载可以调度到哪些节点，不修改集群
+func (s *Service) SimulateScheduling(req ScheduleSimulateRequest, userID uint) (*k8s.ScheduleSimulation, error) {
+	if (req.PodSpec == nil) == (req.Workload == nil) {
+		return nil, fmt.Errorf("exactly one of pod_spec and workload must be specified")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var pod *corev1.Pod
+	workload := ""
+	if req.Workload != nil {
+		ref := *req.Workload
+		if ref.Namespace == "" {
+			ref.Namespace = req.Namespace
+		}
+		resolved, err := s.k8sSvc.ResolveWorkloadPod(ctx, req.ClusterName, ref)
+		if err != nil {
+			s.logger.Errorf("Failed to resolve workload %s/%s in cluster %s: %v", ref.Kind, ref.Name, req.ClusterName, err)
+			return nil, err
+		}
+		pod = resolved
+		workload = ref.Kind + "/" + ref.Name
+	} else {
+		pod = k8s.WorkloadTemplatePod(req.Kind, req.Namespace, "", *req.PodSpec)
+	}
+
+	sim, err := s.k8sSvc.SimulateScheduling(ctx, req.ClusterName, pod)
+	if err != nil {
+		s.logger.Errorf("Failed to simulate scheduling in cluster %s: %v", req.ClusterName, err)
+		return nil, fmt.Errorf("failed to simulate scheduling: %w", err)
+	}
+	sim.Workload = workload
+
+	s.logger.Infof("User %d ran scheduling simulation in cluster %s: %d of %d nodes fit",
+		userID, req.ClusterName, sim.FitCount, len(sim.Nodes))
+	return sim, nil
+}
+
 // BatchDrain 批量驱逐节点
 func (s *Service) BatchDrain(req BatchNodeRequest, userID uint) (map[string]interface{}, error) {
+	req.OperationID = nodechange.NewOperationID(userID)
 	results := make(map[string]interface{})
 	errors := make(map[string]string)
 	successful := make([]string, 0)
 
 	s.logger.Infof("User %d initiating batch drain operation on %d nodes in cluster %s", userID, len(req.Nodes), req.ClusterName)
 
+	// 执行前记录预检结果，便于事后对照驱逐影响
+	if analysis, err := s.DrainPreflight(DrainPreflightRequest{ClusterName: req.ClusterName, Nodes: req.Nodes}, userID); err == nil {
+		results["preflight"] = analysis
+	}
+
 	// 注意：使用 Informer + WebSocket 实时同步后，无需手动清除缓存
 	// Informer 会自动检测到节点变化并通过 WebSocket 推送给前端
 
@@ -820,6 +995,7 @@ func (s *Service) BatchDrain(req BatchNodeRequest, userID uint) (map[string]inte
 			ClusterName: req.ClusterName,
 			NodeName:    nodeName,
 			Reason:      req.Reason,
+			OperationID: req.OperationID,
 		}
 
 		if err := s.Drain(drainReq, userID); err != nil {
@@ -854,6 +1030,7 @@ func (s *Service) BatchDrain(req BatchNodeRequest, userID uint) (map[string]inte
 		Details:      fmt.Sprintf("Batch drain %d nodes in cluster %s: %d successful, %d failed", len(req.Nodes), req.ClusterName, len(successful), len(errors)),
 		Reason:       req.Reason,
 		Status:       status,
+		OperationID:  req.OperationID,
 	})
 
 	return results, nil
@@ -867,6 +1044,29 @@ func (s *Service) GetNodeCordonInfo(clusterName, nodeName string) (map[string]in
 // SetProgressService 设置进度推送服务
 func (s *Service) SetProgressService(progressSvc *progress.Service) {
 	s.progressSvc = progressSvc
+
+	// 注册批量任务恢复器，用于取消或副本中断后继续处理剩余节点
+	progressSvc.RegisterResumer("batch_cordon", func(params json.RawMessage, userID uint) (progress.BatchProcessor, error) {
+		var req BatchNodeRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		return &CordonProcessor{svc: s, clusterName: req.ClusterName, reason: req.Reason, operationID: req.OperationID, userID: userID}, nil
+	})
+	progressSvc.RegisterResumer("batch_uncordon", func(params json.RawMessage, userID uint) (progress.BatchProcessor, error) {
+		var req BatchNodeRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		return &UncordonProcessor{svc: s, clusterName: req.ClusterName, reason: req.Reason, operationID: req.OperationID, userID: userID}, nil
+	})
+	progressSvc.RegisterResumer("batch_drain", func(params json.RawMessage, userID uint) (progress.BatchProcessor, error) {
+		var req BatchNodeRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		return &DrainProcessor{svc: s, clusterName: req.ClusterName, reason: req.Reason, operationID: req.OperationID, userID: userID}, nil
+	})
 }
 
 // CordonProcessor 禁止调度处理器
@@ -874,6 +1074,7 @@ type CordonProcessor struct {
 	svc         *Service
 	clusterName string
 	reason      string
+	operationID string
 	userID      uint
 }
 
@@ -884,6 +1085,7 @@ func (p *CordonProcessor) ProcessNode(ctx context.Context, nodeName string, inde
 		ClusterName: p.clusterName,
 		NodeName:    nodeName,
 		Reason:      p.reason,
+		OperationID: p.operationID,
 	}
 	err := p.svc.Cordon(req, p.userID)
 
@@ -899,6 +1101,7 @@ type UncordonProcessor struct {
 	svc         *Service
 	clusterName string
 	reason      string
+	operationID string
 	userID      uint
 }
 
@@ -909,6 +1112,7 @@ func (p *UncordonProcessor) ProcessNode(ctx context.Context, nodeName string, in
 		ClusterName: p.clusterName,
 		NodeName:    nodeName,
 		Reason:      p.reason,
+		OperationID: p.operationID,
 	}
 	err := p.svc.Uncordon(req, p.userID)
 
@@ -924,6 +1128,7 @@ type DrainProcessor struct {
 	svc         *Service
 	clusterName string
 	reason      string
+	operationID string
 	userID      uint
 }
 
@@ -934,6 +1139,7 @@ func (p *DrainProcessor) ProcessNode(ctx context.Context, nodeName string, index
 		ClusterName: p.clusterName,
 		NodeName:    nodeName,
 		Reason:      p.reason,
+		OperationID: p.operationID,
 	}
 	err := p.svc.Drain(req, p.userID)
 
@@ -950,10 +1156,14 @@ func (s *Service) BatchCordonWithProgress(req BatchNodeRequest, userID uint, tas
 		return fmt.Errorf("progress service not set")
 	}
 
+	// 带进度的任务以任务 ID 作为操作 ID，同一批次的节点可以整体撤销
+	req.OperationID = taskID
+
 	processor := &CordonProcessor{
 		svc:         s,
 		clusterName: req.ClusterName,
 		reason:      req.Reason,
+		operationID: req.OperationID,
 		userID:      userID,
 	}
 
@@ -965,7 +1175,7 @@ func (s *Service) BatchCordonWithProgress(req BatchNodeRequest, userID uint, tas
 		clusterSize, avgLatency, concurrency)
 
 	ctx := context.Background()
-	err := s.progressSvc.ProcessBatchWithProgress(
+	err := s.progressSvc.ProcessResumableBatch(
 		ctx,
 		taskID,
 		"batch_cordon",
@@ -973,6 +1183,7 @@ func (s *Service) BatchCordonWithProgress(req BatchNodeRequest, userID uint, tas
 		userID,
 		concurrency,
 		processor,
+		req,
 	)
 
 	// 注意：使用 Informer + WebSocket 实时同步后，无需手动清除缓存
@@ -987,10 +1198,14 @@ func (s *Service) BatchUncordonWithProgress(req BatchNodeRequest, userID uint, t
 		return fmt.Errorf("progress service not set")
 	}
 
+	// 带进度的任务以任务 ID 作为操作 ID，同一批次的节点可以整体撤销
+	req.OperationID = taskID
+
 	processor := &UncordonProcessor{
 		svc:         s,
 		clusterName: req.ClusterName,
 		reason:      req.Reason,
+		operationID: req.OperationID,
 		userID:      userID,
 	}
 
@@ -1002,7 +1217,7 @@ func (s *Service) BatchUncordonWithProgress(req BatchNodeRequest, userID uint, t
 		clusterSize, avgLatency, concurrency)
 
 	ctx := context.Background()
-	err := s.progressSvc.ProcessBatchWithProgress(
+	err := s.progressSvc.ProcessResumableBatch(
 		ctx,
 		taskID,
 		"batch_uncordon",
@@ -1010,6 +1225,7 @@ func (s *Service) BatchUncordonWithProgress(req BatchNodeRequest, userID uint, t
 		userID,
 		concurrency,
 		processor,
+		req,
 	)
 
 	// 注意：使用 Informer + WebSocket 实时同步后，无需手动清除缓存
@@ -1024,10 +1240,14 @@ func (s *Service) BatchDrainWithProgress(req BatchNodeRequest, userID uint, task
 		return fmt.Errorf("progress service not set")
 	}
 
+	// 带进度的任务以任务 ID 作为操作 ID，同一批次的节点可以整体撤销
+	req.OperationID = taskID
+
 	processor := &DrainProcessor{
 		svc:         s,
 		clusterName: req.ClusterName,
 		reason:      req.Reason,
+		operationID: req.OperationID,
 		userID:      userID,
 	}
 
@@ -1039,7 +1259,7 @@ func (s *Service) BatchDrainWithProgress(req BatchNodeRequest, userID uint, task
 		clusterSize, avgLatency, concurrency)
 
 	ctx := context.Background()
-	err := s.progressSvc.ProcessBatchWithProgress(
+	err := s.progressSvc.ProcessResumableBatch(
 		ctx,
 		taskID,
 		"batch_drain",
@@ -1047,6 +1267,7 @@ func (s *Service) BatchDrainWithProgress(req BatchNodeRequest, userID uint, task
 		userID,
 		concurrency,
 		processor,
+		req,
 	)
 
 	// 注意：使用 Informer + WebSocket 实时同步后，无需手动清除缓存
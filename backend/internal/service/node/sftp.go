@@ -0,0 +1,388 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/sshca"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	progressInterval = 500 * time.Millisecond // 传输进度回调的最小间隔
+	transferBuffer   = 1 << 20                // 大于 SFTP 单包大小时客户端会并发读写，提高传输速度
+	maxSymlinks      = 40                     // 解析路径时最多展开的符号链接数
+)
+
+var (
+	ErrSFTPDisabled       = errors.New("sftp file transfer is disabled")
+	ErrSFTPPathNotAllowed = errors.New("path is not in the allowed directories")
+	ErrSFTPFileTooLarge   = errors.New("file exceeds the size limit")
+)
+
+// SFTPConfig 节点文件传输配置
+type SFTPConfig struct {
+	Enabled         bool
+	MaxUploadSize   int64    // 单个上传文件的最大字节数
+	MaxDownloadSize int64    // 单个下载文件的最大字节数
+	DownloadPaths   []string // 允许浏览和下载的目录
+	UploadPaths     []string // 允许上传的目录
+}
+
+// DefaultSFTPConfig 默认文件传输配置
+// 默认只允许下载日志和临时文件，/etc、/root 等包含凭据的目录需要在配置中显式添加
+func DefaultSFTPConfig() SFTPConfig {
+	return SFTPConfig{
+		Enabled:         true,
+		MaxUploadSize:   100 << 20,
+		MaxDownloadSize: 500 << 20,
+		DownloadPaths:   []string{"/var/log", "/tmp"},
+		UploadPaths:     []string{"/tmp"},
+	}
+}
+
+// SetSFTPConfig 设置文件传输配置，未配置的字段使用默认值
+func (s *Service) SetSFTPConfig(config SFTPConfig) {
+	defaults := DefaultSFTPConfig()
+	if config.MaxUploadSize <= 0 {
+		config.MaxUploadSize = defaults.MaxUploadSize
+	}
+	if config.MaxDownloadSize <= 0 {
+		config.MaxDownloadSize = defaults.MaxDownloadSize
+	}
+	if len(config.DownloadPaths) == 0 {
+		config.DownloadPaths = defaults.DownloadPaths
+	}
+	if len(config.UploadPaths) == 0 {
+		config.UploadPaths = defaults.UploadPaths
+	}
+	s.sftpConfig = config
+}
+
+// GetSFTPConfig 获取文件传输配置
+func (s *Service) GetSFTPConfig() SFTPConfig {
+	return s.sftpConfig
+}
+
+// NewSSHClientConfig 根据系统 SSH 密钥生成 SSH 客户端配置
+func NewSSHClientConfig(sshKey *model.SystemSSHKey) (*ssh.ClientConfig, error) {
+	return newSSHClientConfig(sshKey, nil, true)
+}
+
+// newSSHClientConfig certSigner 非空时优先使用证书认证，withKey 为 false 时不使用系统密钥
+func newSSHClientConfig(sshKey *model.SystemSSHKey, certSigner ssh.Signer, withKey bool) (*ssh.ClientConfig, error) {
+	// 同一种认证方式只会尝试一次，证书和私钥需要放在同一个 PublicKeys 中
+	var signers []ssh.Signer
+	var authMethods []ssh.AuthMethod
+	if certSigner != nil {
+		signers = append(signers, certSigner)
+	}
+	if withKey && sshKey.Type == model.SSHKeyTypePrivateKey {
+		signer, err := ssh.ParsePrivateKey([]byte(sshKey.PrivateKey))
+		if err != nil && sshKey.Passphrase != "" {
+			// 尝试带密码的私钥
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(sshKey.PrivateKey), []byte(sshKey.Passphrase))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		signers = append(signers, signer)
+	} else if withKey && sshKey.Type == model.SSHKeyTypePassword {
+		authMethods = append(authMethods, ssh.Password(sshKey.Password))
+	}
+	if len(signers) > 0 {
+		authMethods = append([]ssh.AuthMethod{ssh.PublicKeys(signers...)}, authMethods...)
+	}
+
+	return &ssh.ClientConfig{
+		User:            sshKey.Username,
+		Auth:            authMethods,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 注意：生产环境应验证 Host Key
+		Timeout:         5 * time.Second,
+	}, nil
+}
+
+// SFTPFileInfo 节点上的文件信息
+type SFTPFileInfo struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Mode    string    `json:"mode"`
+	IsDir   bool      `json:"is_dir"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// ProgressFunc 传输进度回调，transferred 为已传输字节数，total 为文件总大小
+type ProgressFunc func(transferred, total int64)
+
+// SFTPSession 到节点的 SFTP 会话，使用完需要 Close
+type SFTPSession struct {
+	Host   string
+	ssh    *ssh.Client
+	client *sftp.Client
+	config SFTPConfig
+}
+
+// OpenSFTP 使用节点的 SSH 配置建立 SFTP 会话，启用 SSH CA 时使用为 userID 签发的短期证书
+func (s *Service) OpenSFTP(ctx context.Context, clusterName, nodeName string, userID uint) (*SFTPSession, error) {
+	if !s.sftpConfig.Enabled {
+		return nil, ErrSFTPDisabled
+	}
+
+	sshKey, host, err := s.GetNodeSSHConfig(ctx, clusterName, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := s.SSHClientConfig(sshKey, sshca.IssueRequest{
+		UserID:  userID,
+		Purpose: model.SSHCertPurposeSFTP,
+		Target:  clusterName + "/" + nodeName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sshClient, err := ssh.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(sshKey.Port)), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s:%d: %w", host, sshKey.Port, err)
+	}
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("failed to start sftp subsystem: %w", err)
+	}
+	return &SFTPSession{Host: host, ssh: sshClient, client: client, config: s.sftpConfig}, nil
+}
+
+// Close 关闭 SFTP 会话和 SSH 连接
+func (f *SFTPSession) Close() error {
+	f.client.Close()
+	return f.ssh.Close()
+}
+
+// List 列出目录内容，目录在前，按名称排序
+func (f *SFTPSession) List(dir string) (string, []SFTPFileInfo, error) {
+	dir, err := f.resolve(dir, f.config.DownloadPaths)
+	if err != nil {
+		return "", nil, err
+	}
+
+	entries, err := f.client.ReadDir(dir)
+	if err != nil {
+		return dir, nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+	files := make([]SFTPFileInfo, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, SFTPFileInfo{
+			Name:    entry.Name(),
+			Path:    path.Join(dir, entry.Name()),
+			Size:    entry.Size(),
+			Mode:    entry.Mode().String(),
+			IsDir:   entry.IsDir(),
+			ModTime: entry.ModTime(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].IsDir != files[j].IsDir {
+			return files[i].IsDir
+		}
+		return files[i].Name < files[j].Name
+	})
+	return dir, files, nil
+}
+
+// Stat 获取允许下载的文件信息，目录或超过大小限制时返回错误
+func (f *SFTPSession) Stat(filePath string) (string, os.FileInfo, error) {
+	filePath, err := f.resolve(filePath, f.config.DownloadPaths)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := f.client.Stat(filePath)
+	if err != nil {
+		return filePath, nil, fmt.Errorf("failed to stat %s: %w", filePath, err)
+	}
+	if info.IsDir() {
+		return filePath, nil, fmt.Errorf("%s is a directory", filePath)
+	}
+	if info.Size() > f.config.MaxDownloadSize {
+		return filePath, nil, fmt.Errorf("%w: %s is %d bytes, limit is %d bytes", ErrSFTPFileTooLarge, filePath, info.Size(), f.config.MaxDownloadSize)
+	}
+	return filePath, info, nil
+}
+
+// Download 把节点上的文件写入 w，返回传输的字节数
+func (f *SFTPSession) Download(filePath string, w io.Writer, progress ProgressFunc) (int64, error) {
+	filePath, info, err := f.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+	file, err := f.client.Open(filePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	pw := &progressWriter{w: w, total: info.Size(), fn: progress}
+	// 只读取 Stat 时的大小，传输过程中持续写入的日志文件不会超出声明的长度
+	n, err := io.CopyBuffer(pw, io.LimitReader(file, info.Size()), make([]byte, transferBuffer))
+	pw.report()
+	if err != nil {
+		return n, fmt.Errorf("failed to download %s: %w", filePath, err)
+	}
+	return n, nil
+}
+
+// Upload 把 r 的内容上传到节点上的 filePath，先写入临时文件再重命名，避免传输中断留下不完整的文件
+// size 为客户端声明的文件大小，仅用于进度计算和提前拒绝超限文件，实际写入量同样受大小限制
+func (f *SFTPSession) Upload(filePath string, r io.Reader, size int64, progress ProgressFunc) (string, int64, error) {
+	if size > f.config.MaxUploadSize {
+		return filePath, 0, fmt.Errorf("%w: %d bytes, limit is %d bytes", ErrSFTPFileTooLarge, size, f.config.MaxUploadSize)
+	}
+	filePath, err := f.resolve(filePath, f.config.UploadPaths)
+	if err != nil {
+		return filePath, 0, err
+	}
+	if info, err := f.client.Stat(filePath); err == nil && info.IsDir() {
+		return filePath, 0, fmt.Errorf("%s is a directory", filePath)
+	}
+
+	tmpPath := path.Join(path.Dir(filePath), "."+path.Base(filePath)+".uploading")
+	file, err := f.client.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return filePath, 0, fmt.Errorf("failed to create %s: %w", tmpPath, err)
+	}
+
+	pw := &progressWriter{w: file, total: size, fn: progress}
+	n, err := io.CopyBuffer(pw, io.LimitReader(r, f.config.MaxUploadSize+1), make([]byte, transferBuffer))
+	pw.report()
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > f.config.MaxUploadSize {
+		err = fmt.Errorf("%w: limit is %d bytes", ErrSFTPFileTooLarge, f.config.MaxUploadSize)
+	}
+	if err != nil {
+		f.client.Remove(tmpPath)
+		return filePath, n, fmt.Errorf("failed to upload %s: %w", filePath, err)
+	}
+
+	// posix-rename 可以覆盖已有文件，服务端不支持时先删除目标文件再重命名
+	if err := f.client.PosixRename(tmpPath, filePath); err != nil {
+		f.client.Remove(filePath)
+		if err := f.client.Rename(tmpPath, filePath); err != nil {
+			f.client.Remove(tmpPath)
+			return filePath, n, fmt.Errorf("failed to move uploaded file to %s: %w", filePath, err)
+		}
+	}
+	return filePath, n, nil
+}
+
+// resolve 把路径解析为节点上的真实路径（展开符号链接），并检查是否在允许的目录内
+func (f *SFTPSession) resolve(p string, allowed []string) (string, error) {
+	if !path.IsAbs(p) {
+		return p, fmt.Errorf("%w: %s is not an absolute path", ErrSFTPPathNotAllowed, p)
+	}
+
+	resolved, err := f.realPath(p)
+	if err != nil {
+		return p, fmt.Errorf("failed to resolve %s: %w", p, err)
+	}
+	if !withinPaths(resolved, allowed) {
+		return resolved, fmt.Errorf("%w: %s (allowed: %s)", ErrSFTPPathNotAllowed, resolved, strings.Join(allowed, ", "))
+	}
+	return resolved, nil
+}
+
+// realPath 在客户端逐级展开符号链接，部分 SFTP 服务端的 realpath 只做路径规范化，不能依赖
+// 最后一级不存在时（上传新文件）返回其所在目录的真实路径加文件名
+func (f *SFTPSession) realPath(p string) (string, error) {
+	current := "/"
+	remaining := strings.Split(p, "/")
+	links := 0
+	for len(remaining) > 0 {
+		part := remaining[0]
+		remaining = remaining[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = path.Dir(current)
+			continue
+		}
+
+		next := path.Join(current, part)
+		info, err := f.client.Lstat(next)
+		if err != nil {
+			if os.IsNotExist(err) && len(remaining) == 0 {
+				return next, nil
+			}
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			current = next
+			continue
+		}
+
+		if links++; links > maxSymlinks {
+			return "", fmt.Errorf("too many levels of symbolic links")
+		}
+		target, err := f.client.ReadLink(next)
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(target) {
+			current = "/"
+		}
+		remaining = append(strings.Split(target, "/"), remaining...)
+	}
+	return current, nil
+}
+
+// withinPaths 路径是否等于或位于某个允许的目录下
+func withinPaths(p string, allowed []string) bool {
+	for _, dir := range allowed {
+		dir = path.Clean(dir)
+		if dir == "/" || p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// progressWriter 统计写入字节数并按间隔回调进度
+type progressWriter struct {
+	w           io.Writer
+	total       int64
+	transferred int64
+	fn          ProgressFunc
+	last        time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.transferred += int64(n)
+	if p.fn != nil && time.Since(p.last) >= progressInterval {
+		p.last = time.Now()
+		p.fn(p.transferred, p.total)
+	}
+	return n, err
+}
+
+// report 回调最终进度
+func (p *progressWriter) report() {
+	if p.fn != nil {
+		p.fn(p.transferred, p.total)
+	}
+}
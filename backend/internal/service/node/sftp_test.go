@@ -0,0 +1,123 @@
+package node
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestSFTPSession 通过内存管道连接进程内 SFTP 服务端，服务端直接访问本地文件系统
+func newTestSFTPSession(t *testing.T, config SFTPConfig) *SFTPSession {
+	t.Helper()
+	serverRead, clientWrite := io.Pipe()
+	clientRead, serverWrite := io.Pipe()
+
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverRead, serverWrite})
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientRead, clientWrite)
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+	t.Cleanup(func() {
+		// 先关闭服务端，客户端的接收协程才能退出
+		server.Close()
+		client.Close()
+	})
+	return &SFTPSession{client: client, config: config}
+}
+
+func TestSFTPPathRestrictionsAndLimits(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads := filepath.Join(root, "uploads")
+	logs := filepath.Join(root, "logs")
+	secret := filepath.Join(root, "secret")
+	for _, dir := range []string{uploads, logs, secret} {
+		os.Mkdir(dir, 0755)
+	}
+	os.WriteFile(filepath.Join(logs, "kubelet.log"), []byte("kubelet started\n"), 0644)
+	os.WriteFile(filepath.Join(logs, "big.log"), bytes.Repeat([]byte("x"), 2048), 0644)
+	os.WriteFile(filepath.Join(secret, "token"), []byte("s3cr3t"), 0600)
+	// 指向允许目录之外的符号链接
+	os.Symlink(secret, filepath.Join(logs, "escape"))
+
+	sess := newTestSFTPSession(t, SFTPConfig{
+		Enabled:         true,
+		MaxUploadSize:   1024,
+		MaxDownloadSize: 1024,
+		DownloadPaths:   []string{logs},
+		UploadPaths:     []string{uploads},
+	})
+
+	var buf bytes.Buffer
+	var lastProgress int64
+	n, err := sess.Download(filepath.Join(logs, "kubelet.log"), &buf, func(transferred, total int64) { lastProgress = transferred })
+	if err != nil || n != 16 || buf.String() != "kubelet started\n" || lastProgress != 16 {
+		t.Fatalf("download: n=%d err=%v content=%q progress=%d", n, err, buf.String(), lastProgress)
+	}
+
+	if _, err := sess.Download(filepath.Join(logs, "big.log"), io.Discard, nil); !errors.Is(err, ErrSFTPFileTooLarge) {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+	if _, err := sess.Download(filepath.Join(secret, "token"), io.Discard, nil); !errors.Is(err, ErrSFTPPathNotAllowed) {
+		t.Errorf("expected path error, got %v", err)
+	}
+	if _, err := sess.Download(filepath.Join(logs, "escape", "token"), io.Discard, nil); !errors.Is(err, ErrSFTPPathNotAllowed) {
+		t.Errorf("symlink escape not rejected: %v", err)
+	}
+	if _, err := sess.Download(filepath.Join(logs, "..", "secret", "token"), io.Discard, nil); !errors.Is(err, ErrSFTPPathNotAllowed) {
+		t.Errorf("dot-dot escape not rejected: %v", err)
+	}
+
+	target, n, err := sess.Upload(filepath.Join(uploads, "config.yaml"), strings.NewReader("key: value\n"), 11, nil)
+	if err != nil || n != 11 || target != filepath.Join(uploads, "config.yaml") {
+		t.Fatalf("upload: target=%s n=%d err=%v", target, n, err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "key: value\n" {
+		t.Errorf("unexpected uploaded content %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(uploads, ".config.yaml.uploading")); !os.IsNotExist(err) {
+		t.Errorf("temporary upload file left behind: %v", err)
+	}
+
+	// 客户端声明的大小不可信，实际写入超过限制时同样拒绝，并且不留下文件
+	_, _, err = sess.Upload(filepath.Join(uploads, "big.bin"), bytes.NewReader(make([]byte, 2048)), 10, nil)
+	if !errors.Is(err, ErrSFTPFileTooLarge) {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(uploads, "big.bin")); !os.IsNotExist(err) {
+		t.Errorf("oversized upload should not be kept: %v", err)
+	}
+	if _, _, err := sess.Upload(filepath.Join(logs, "kubelet.log"), strings.NewReader("x"), 1, nil); !errors.Is(err, ErrSFTPPathNotAllowed) {
+		t.Errorf("upload outside upload paths not rejected: %v", err)
+	}
+}
+
+func TestDefaultSFTPPathsExcludeCredentials(t *testing.T) {
+	var s Service
+	s.SetSFTPConfig(SFTPConfig{Enabled: true})
+	config := s.GetSFTPConfig()
+	for _, p := range []string{"/etc/shadow", "/root/.ssh/id_rsa", "/var/lib/kubelet/kubeconfig", "/home/admin/.ssh/id_rsa"} {
+		if withinPaths(p, config.DownloadPaths) {
+			t.Errorf("%s should not be downloadable by default", p)
+		}
+	}
+	if !withinPaths("/var/log/messages", config.DownloadPaths) || !withinPaths("/tmp/dump.tar", config.DownloadPaths) {
+		t.Errorf("default download paths should include logs and temp files: %v", config.DownloadPaths)
+	}
+}
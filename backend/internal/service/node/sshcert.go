@@ -0,0 +1,41 @@
+package node
+
+import (
+	"fmt"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/sshca"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SetSSHCA 设置 SSH 证书颁发服务，启用后终端和文件传输使用短期证书连接节点
+func (s *Service) SetSSHCA(sshCA *sshca.Service) {
+	s.sshCA = sshCA
+}
+
+// SSHClientConfig 生成连接节点的 SSH 客户端配置
+// 启用 SSH CA 时为本次连接签发短期证书并优先使用，系统密钥按配置作为后备；返回的证书记录为空表示未使用证书
+func (s *Service) SSHClientConfig(sshKey *model.SystemSSHKey, req sshca.IssueRequest) (*ssh.ClientConfig, *model.SSHCertificate, error) {
+	if !s.sshCA.Enabled() {
+		config, err := NewSSHClientConfig(sshKey)
+		return config, nil, err
+	}
+
+	cert, err := s.sshCA.Issue(req)
+	if err != nil {
+		// 没有可用的系统密钥时无法降级
+		if !s.sshCA.KeyFallback() || sshKey.Type == "" {
+			return nil, nil, fmt.Errorf("failed to issue ssh certificate: %w", err)
+		}
+		s.logger.Warningf("Failed to issue SSH certificate for %s, falling back to SSH key: %v", req.Target, err)
+		config, err := NewSSHClientConfig(sshKey)
+		return config, nil, err
+	}
+
+	config, err := newSSHClientConfig(sshKey, cert.Signer, s.sshCA.KeyFallback())
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, cert.Record, nil
+}
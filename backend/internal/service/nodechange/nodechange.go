@@ -0,0 +1,306 @@
+package nodechange
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrOperationNotFound 操作不存在或没有记录到任何节点变更
+	ErrOperationNotFound = errors.New("operation not found")
+	// ErrAlreadyUndone 操作的所有变更都已撤销
+	ErrAlreadyUndone = errors.New("operation has already been undone")
+)
+
+// Service 节点变更记录服务：记录标签、污点、调度状态变更前后的快照，并支持按操作撤销
+type Service struct {
+	db       *gorm.DB
+	logger   *logger.Logger
+	k8sSvc   *k8s.Service
+	auditSvc *audit.Service
+}
+
+// RecordRequest 记录节点变更请求
+type RecordRequest struct {
+	OperationID  string
+	ClusterName  string
+	NodeName     string
+	ResourceType model.NodeChangeResource
+	Before       model.NodeStateSnapshot
+	After        model.NodeStateSnapshot
+	UserID       uint
+}
+
+// Operation 一次操作的所有节点变更
+type Operation struct {
+	OperationID     string             `json:"operation_id"`
+	ClusterName     string             `json:"cluster_name"`
+	UserID          uint               `json:"user_id"`
+	NodeCount       int                `json:"node_count"`
+	Undone          bool               `json:"undone"`
+	Undoable        bool               `json:"undoable"`                    // 存在尚未撤销的变更
+	UndoOperationID string             `json:"undo_operation_id,omitempty"` // 执行撤销的操作 ID
+	CreatedAt       time.Time          `json:"created_at"`
+	Changes         []model.NodeChange `json:"changes"`
+}
+
+// NewService 创建节点变更记录服务
+func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, auditSvc *audit.Service) *Service {
+	return &Service{
+		db:       db,
+		logger:   logger,
+		k8sSvc:   k8sSvc,
+		auditSvc: auditSvc,
+	}
+}
+
+// NewOperationID 生成操作 ID，批量任务直接使用任务 ID 作为操作 ID
+func NewOperationID(userID uint) string {
+	return fmt.Sprintf("op_%d_%d", userID, time.Now().UnixNano())
+}
+
+// LabelState 标签快照
+func LabelState(labels map[string]string) model.NodeStateSnapshot {
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return model.NodeStateSnapshot{Labels: copied}
+}
+
+// TaintState 污点快照
+func TaintState(taints []k8s.TaintInfo) model.NodeStateSnapshot {
+	snapshots := make([]model.TaintSnapshot, 0, len(taints))
+	for _, t := range taints {
+		snapshots = append(snapshots, model.TaintSnapshot{Key: t.Key, Value: t.Value, Effect: t.Effect})
+	}
+	return model.NodeStateSnapshot{Taints: snapshots}
+}
+
+// CordonState 调度状态快照
+func CordonState(unschedulable bool, reason string) model.NodeStateSnapshot {
+	snapshot := model.NodeStateSnapshot{Unschedulable: &unschedulable}
+	if unschedulable {
+		snapshot.CordonReason = reason
+	}
+	return snapshot
+}
+
+// NodeState 从节点当前信息生成指定资源类型的快照
+func NodeState(resource model.NodeChangeResource, node *k8s.NodeInfo) model.NodeStateSnapshot {
+	switch resource {
+	case model.NodeChangeLabel:
+		return LabelState(node.Labels)
+	case model.NodeChangeTaint:
+		return TaintState(node.Taints)
+	default:
+		return CordonState(!node.Schedulable, node.UnschedulableReason)
+	}
+}
+
+// Record 记录一个节点的变更，只保存发生变化的标签键和污点键；没有变化时不记录
+// 变更已经作用到集群，记录失败只打印日志，不影响调用方
+func (s *Service) Record(req RecordRequest) {
+	before, after, changed := trimSnapshots(req.ResourceType, req.Before, req.After)
+	if !changed {
+		return
+	}
+
+	clusterID, err := s.auditSvc.GetClusterIDByName(req.ClusterName)
+	if err != nil {
+		s.logger.Warningf("Failed to record %s change of node %s: %v", req.ResourceType, req.NodeName, err)
+		return
+	}
+
+	change := model.NodeChange{
+		OperationID:  req.OperationID,
+		ClusterID:    clusterID,
+		ClusterName:  req.ClusterName,
+		NodeName:     req.NodeName,
+		ResourceType: req.ResourceType,
+		Before:       before,
+		After:        after,
+		UserID:       req.UserID,
+	}
+	if err := s.db.Create(&change).Error; err != nil {
+		s.logger.Errorf("Failed to record %s change of node %s: %v", req.ResourceType, req.NodeName, err)
+	}
+}
+
+// GetOperation 获取操作的所有节点变更
+func (s *Service) GetOperation(operationID string) (*Operation, error) {
+	var changes []model.NodeChange
+	if err := s.db.Where("operation_id = ?", operationID).Order("id ASC").Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node changes: %w", err)
+	}
+	if len(changes) == 0 {
+		return nil, ErrOperationNotFound
+	}
+
+	op := &Operation{
+		OperationID: operationID,
+		ClusterName: changes[0].ClusterName,
+		UserID:      changes[0].UserID,
+		CreatedAt:   changes[0].CreatedAt,
+		Changes:     changes,
+		Undone:      true,
+	}
+	nodes := make(map[string]bool)
+	for _, change := range changes {
+		nodes[change.ClusterName+"/"+change.NodeName] = true
+		if change.UndoneAt == nil {
+			op.Undone = false
+			op.Undoable = true
+		} else if op.UndoOperationID == "" {
+			op.UndoOperationID = change.UndoOperationID
+		}
+	}
+	op.NodeCount = len(nodes)
+	return op, nil
+}
+
+// ListNodeChanges 查询节点最近的变更记录
+func (s *Service) ListNodeChanges(clusterName, nodeName string, limit int) ([]model.NodeChange, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	clusterID, err := s.auditSvc.GetClusterIDByName(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s not found", clusterName)
+	}
+
+	var changes []model.NodeChange
+	if err := s.db.Where("cluster_id = ? AND node_name = ?", clusterID, nodeName).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list node changes: %w", err)
+	}
+	return changes, nil
+}
+
+// trimSnapshots 只保留前后不一致的标签键和污点键，返回是否有变化
+func trimSnapshots(resource model.NodeChangeResource, before, after model.NodeStateSnapshot) (model.NodeStateSnapshot, model.NodeStateSnapshot, bool) {
+	switch resource {
+	case model.NodeChangeLabel:
+		keys := changedLabelKeys(before.Labels, after.Labels)
+		if len(keys) == 0 {
+			return before, after, false
+		}
+		return pickLabels(before.Labels, keys), pickLabels(after.Labels, keys), true
+	case model.NodeChangeTaint:
+		keys := changedTaintKeys(before.Taints, after.Taints)
+		if len(keys) == 0 {
+			return before, after, false
+		}
+		return pickTaints(before.Taints, keys), pickTaints(after.Taints, keys), true
+	default:
+		return before, after, !sameCordonState(before, after)
+	}
+}
+
+// changedLabelKeys 返回值或存在性不同的标签键
+func changedLabelKeys(before, after map[string]string) []string {
+	var keys []string
+	for k, v := range before {
+		if av, ok := after[k]; !ok || av != v {
+			keys = append(keys, k)
+		}
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pickLabels 取出指定键的标签，不存在的键不出现在结果中
+func pickLabels(labels map[string]string, keys []string) model.NodeStateSnapshot {
+	picked := make(map[string]string)
+	for _, k := range keys {
+		if v, ok := labels[k]; ok {
+			picked[k] = v
+		}
+	}
+	return model.NodeStateSnapshot{Labels: picked}
+}
+
+// taintGroups 按键分组污点，每组为排序后的 value:effect 列表
+func taintGroups(taints []model.TaintSnapshot) map[string][]string {
+	groups := make(map[string][]string)
+	for _, t := range taints {
+		groups[t.Key] = append(groups[t.Key], t.Value+":"+t.Effect)
+	}
+	for k := range groups {
+		sort.Strings(groups[k])
+	}
+	return groups
+}
+
+// sameGroup 比较两组污点是否一致
+func sameGroup(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// changedTaintKeys 返回值或效果不同的污点键
+func changedTaintKeys(before, after []model.TaintSnapshot) []string {
+	beforeGroups := taintGroups(before)
+	afterGroups := taintGroups(after)
+
+	var keys []string
+	for k, group := range beforeGroups {
+		if !sameGroup(group, afterGroups[k]) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range afterGroups {
+		if _, ok := beforeGroups[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// pickTaints 取出指定键的污点
+func pickTaints(taints []model.TaintSnapshot, keys []string) model.NodeStateSnapshot {
+	wanted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		wanted[k] = true
+	}
+	picked := make([]model.TaintSnapshot, 0)
+	for _, t := range taints {
+		if wanted[t.Key] {
+			picked = append(picked, t)
+		}
+	}
+	return model.NodeStateSnapshot{Taints: picked}
+}
+
+// sameCordonState 比较调度状态和禁止调度原因
+func sameCordonState(a, b model.NodeStateSnapshot) bool {
+	return unschedulable(a) == unschedulable(b) && a.CordonReason == b.CordonReason
+}
+
+func unschedulable(s model.NodeStateSnapshot) bool {
+	return s.Unschedulable != nil && *s.Unschedulable
+}
@@ -0,0 +1,145 @@
+package nodechange
+
+import (
+	"reflect"
+	"testing"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+)
+
+func TestTrimLabelSnapshots(t *testing.T) {
+	before := LabelState(map[string]string{"zone": "a", "role": "gpu", "kubernetes.io/os": "linux"})
+	after := LabelState(map[string]string{"zone": "b", "kubernetes.io/os": "linux", "team": "infra"})
+
+	b, a, changed := trimSnapshots(model.NodeChangeLabel, before, after)
+	if !changed {
+		t.Fatal("expected label change")
+	}
+	if want := map[string]string{"zone": "a", "role": "gpu"}; !reflect.DeepEqual(b.Labels, want) {
+		t.Errorf("before = %v, want %v", b.Labels, want)
+	}
+	if want := map[string]string{"zone": "b", "team": "infra"}; !reflect.DeepEqual(a.Labels, want) {
+		t.Errorf("after = %v, want %v", a.Labels, want)
+	}
+
+	if _, _, changed := trimSnapshots(model.NodeChangeLabel, before, before); changed {
+		t.Error("identical labels should not be recorded")
+	}
+}
+
+func TestLabelDriftAndRestore(t *testing.T) {
+	change := model.NodeChange{
+		ResourceType: model.NodeChangeLabel,
+		Before:       model.NodeStateSnapshot{Labels: map[string]string{"zone": "a", "role": "gpu"}},
+		After:        model.NodeStateSnapshot{Labels: map[string]string{"zone": "b", "team": "infra"}},
+	}
+
+	current := map[string]string{"zone": "b", "team": "infra", "other": "x"}
+	if hasDrifted(change, LabelState(current)) {
+		t.Fatal("unrelated label should not count as drift")
+	}
+	want := map[string]string{"zone": "a", "role": "gpu", "other": "x"}
+	if got := restoreLabels(current, change); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored = %v, want %v", got, want)
+	}
+
+	// 操作后被其他人删除了 team 标签
+	if !hasDrifted(change, LabelState(map[string]string{"zone": "b"})) {
+		t.Error("removed label should count as drift")
+	}
+	// 操作后又重新加上了被删除的 role 标签
+	if !hasDrifted(change, LabelState(map[string]string{"zone": "b", "team": "infra", "role": "cpu"})) {
+		t.Error("re-added label should count as drift")
+	}
+}
+
+func TestTaintDriftAndRestore(t *testing.T) {
+	before := []k8s.TaintInfo{
+		{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"},
+		{Key: "maintenance", Effect: "NoExecute"},
+	}
+	after := []k8s.TaintInfo{
+		{Key: "dedicated", Value: "cpu", Effect: "NoSchedule"},
+		{Key: "maintenance", Effect: "NoExecute"},
+	}
+	b, a, changed := trimSnapshots(model.NodeChangeTaint, TaintState(before), TaintState(after))
+	if !changed {
+		t.Fatal("expected taint change")
+	}
+	change := model.NodeChange{ResourceType: model.NodeChangeTaint, Before: b, After: a}
+	if len(change.Before.Taints) != 1 || change.Before.Taints[0].Value != "gpu" {
+		t.Fatalf("unexpected trimmed before: %+v", change.Before.Taints)
+	}
+
+	current := append(after, k8s.TaintInfo{Key: "new", Effect: "PreferNoSchedule"})
+	if hasDrifted(change, TaintState(current)) {
+		t.Fatal("unrelated taint should not count as drift")
+	}
+	restored := restoreTaints(current, change)
+	if len(restored) != 3 {
+		t.Fatalf("expected 3 taints after restore, got %+v", restored)
+	}
+	for _, taint := range restored {
+		if taint.Key == "dedicated" && taint.Value != "gpu" {
+			t.Errorf("dedicated taint not restored: %+v", taint)
+		}
+	}
+
+	drifted := []k8s.TaintInfo{{Key: "dedicated", Value: "cpu", Effect: "NoExecute"}}
+	if !hasDrifted(change, TaintState(drifted)) {
+		t.Error("changed effect should count as drift")
+	}
+}
+
+func TestCordonDrift(t *testing.T) {
+	change := model.NodeChange{
+		ResourceType: model.NodeChangeCordon,
+		Before:       CordonState(false, ""),
+		After:        CordonState(true, "maintenance"),
+	}
+	if hasDrifted(change, CordonState(true, "maintenance")) {
+		t.Error("cordoned node should not count as drift")
+	}
+	if !hasDrifted(change, CordonState(false, "")) {
+		t.Error("uncordoned node should count as drift")
+	}
+}
+
+func TestMergeChanges(t *testing.T) {
+	changes := []model.NodeChange{
+		{
+			ClusterName:  "c1",
+			NodeName:     "n1",
+			ResourceType: model.NodeChangeTaint,
+			Before:       model.NodeStateSnapshot{},
+			After:        model.NodeStateSnapshot{Taints: []model.TaintSnapshot{{Key: "a", Effect: "NoSchedule"}}},
+		},
+		{
+			ClusterName:  "c1",
+			NodeName:     "n1",
+			ResourceType: model.NodeChangeTaint,
+			Before:       model.NodeStateSnapshot{Taints: []model.TaintSnapshot{{Key: "b", Value: "old", Effect: "NoSchedule"}}},
+			After:        model.NodeStateSnapshot{Taints: []model.TaintSnapshot{{Key: "b", Value: "new", Effect: "NoSchedule"}}},
+		},
+		{
+			ClusterName:  "c1",
+			NodeName:     "n2",
+			ResourceType: model.NodeChangeTaint,
+			After:        model.NodeStateSnapshot{Taints: []model.TaintSnapshot{{Key: "a", Effect: "NoSchedule"}}},
+		},
+	}
+
+	merged := mergeChanges(changes)
+	if len(merged) != 2 {
+		t.Fatalf("expected 2 merged changes, got %d", len(merged))
+	}
+	n1 := merged[0]
+	if keys := taintKeys(n1.Before, n1.After); !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("merged keys = %v", keys)
+	}
+	restored := restoreTaints([]k8s.TaintInfo{{Key: "a", Effect: "NoSchedule"}, {Key: "b", Value: "new", Effect: "NoSchedule"}}, n1)
+	if len(restored) != 1 || restored[0].Key != "b" || restored[0].Value != "old" {
+		t.Errorf("restored = %+v", restored)
+	}
+}
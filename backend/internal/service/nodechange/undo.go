@@ -0,0 +1,422 @@
+package nodechange
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/audit"
+	"kube-node-manager/internal/service/k8s"
+)
+
+// DriftedNode 自操作以来被其他变更修改过的节点
+type DriftedNode struct {
+	ClusterName  string                   `json:"cluster_name"`
+	NodeName     string                   `json:"node_name"`
+	ResourceType model.NodeChangeResource `json:"resource_type"`
+	Expected     model.NodeStateSnapshot  `json:"expected"` // 操作完成后的状态
+	Current      model.NodeStateSnapshot  `json:"current"`  // 节点当前状态
+	Error        string                   `json:"error,omitempty"`
+}
+
+// DriftError 节点状态已偏离操作结果，拒绝撤销
+type DriftError struct {
+	Nodes []DriftedNode
+}
+
+func (e *DriftError) Error() string {
+	names := make([]string, 0, len(e.Nodes))
+	for _, n := range e.Nodes {
+		names = append(names, n.NodeName)
+	}
+	return fmt.Sprintf("cannot undo: %d node(s) changed since the operation: %s", len(e.Nodes), strings.Join(names, ", "))
+}
+
+// UndoFailure 恢复失败的节点资源
+type UndoFailure struct {
+	ClusterName  string                   `json:"cluster_name"`
+	NodeName     string                   `json:"node_name"`
+	ResourceType model.NodeChangeResource `json:"resource_type"`
+	Error        string                   `json:"error"`
+}
+
+// UndoResult 撤销结果
+type UndoResult struct {
+	OperationID     string        `json:"operation_id"`
+	UndoOperationID string        `json:"undo_operation_id"`
+	Restored        []string      `json:"restored"`
+	Failed          []UndoFailure `json:"failed"`
+}
+
+// Undo 将操作涉及的所有节点恢复到操作前的状态
+// 只恢复操作修改过的标签键、污点键和调度状态；任一节点在操作之后被修改过时整体拒绝
+func (s *Service) Undo(operationID string, userID uint) (*UndoResult, error) {
+	var pending []model.NodeChange
+	if err := s.db.Where("operation_id = ? AND undone_at IS NULL", operationID).
+		Order("id ASC").
+		Find(&pending).Error; err != nil {
+		return nil, fmt.Errorf("failed to get node changes: %w", err)
+	}
+	if len(pending) == 0 {
+		var count int64
+		if err := s.db.Model(&model.NodeChange{}).Where("operation_id = ?", operationID).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to get node changes: %w", err)
+		}
+		if count == 0 {
+			return nil, ErrOperationNotFound
+		}
+		return nil, ErrAlreadyUndone
+	}
+
+	merged := mergeChanges(pending)
+
+	// 先检查所有节点，确认都没有偏离后再开始恢复
+	type target struct {
+		change model.NodeChange
+		node   *k8s.NodeInfo
+	}
+	targets := make([]target, 0, len(merged))
+	var drifted []DriftedNode
+	for _, change := range merged {
+		node, err := s.k8sSvc.GetNodeWithCache(change.ClusterName, change.NodeName, true)
+		if err != nil {
+			drifted = append(drifted, DriftedNode{
+				ClusterName:  change.ClusterName,
+				NodeName:     change.NodeName,
+				ResourceType: change.ResourceType,
+				Expected:     change.After,
+				Error:        err.Error(),
+			})
+			continue
+		}
+		current := NodeState(change.ResourceType, node)
+		if hasDrifted(change, current) {
+			drifted = append(drifted, DriftedNode{
+				ClusterName:  change.ClusterName,
+				NodeName:     change.NodeName,
+				ResourceType: change.ResourceType,
+				Expected:     change.After,
+				Current:      scopeSnapshot(change, current),
+			})
+			continue
+		}
+		targets = append(targets, target{change: change, node: node})
+	}
+	if len(drifted) > 0 {
+		return nil, &DriftError{Nodes: drifted}
+	}
+
+	// 标记为已撤销，多个请求同时撤销时只有一个成功
+	undoOperationID := NewOperationID(userID)
+	ids := make([]uint, 0, len(pending))
+	for _, change := range pending {
+		ids = append(ids, change.ID)
+	}
+	now := time.Now()
+	result := s.db.Model(&model.NodeChange{}).
+		Where("id IN ? AND undone_at IS NULL", ids).
+		Updates(map[string]interface{}{
+			"undone_at":         now,
+			"undone_by":         userID,
+			"undo_operation_id": undoOperationID,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to mark operation as undone: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAlreadyUndone
+	}
+
+	undoResult := &UndoResult{
+		OperationID:     operationID,
+		UndoOperationID: undoOperationID,
+		Restored:        []string{},
+		Failed:          []UndoFailure{},
+	}
+	for _, t := range targets {
+		if err := s.restore(t.change, t.node); err != nil {
+			s.logger.Errorf("Failed to undo %s change of node %s: %v", t.change.ResourceType, t.change.NodeName, err)
+			undoResult.Failed = append(undoResult.Failed, UndoFailure{
+				ClusterName:  t.change.ClusterName,
+				NodeName:     t.change.NodeName,
+				ResourceType: t.change.ResourceType,
+				Error:        err.Error(),
+			})
+			// 恢复失败的节点取消撤销标记，允许重试
+			s.db.Model(&model.NodeChange{}).
+				Where("operation_id = ? AND cluster_name = ? AND node_name = ? AND resource_type = ? AND undo_operation_id = ?",
+					operationID, t.change.ClusterName, t.change.NodeName, t.change.ResourceType, undoOperationID).
+				Updates(map[string]interface{}{"undone_at": nil, "undone_by": nil, "undo_operation_id": ""})
+			continue
+		}
+
+		s.Record(RecordRequest{
+			OperationID:  undoOperationID,
+			ClusterName:  t.change.ClusterName,
+			NodeName:     t.change.NodeName,
+			ResourceType: t.change.ResourceType,
+			Before:       t.change.After,
+			After:        t.change.Before,
+			UserID:       userID,
+		})
+		undoResult.Restored = append(undoResult.Restored, t.change.NodeName)
+	}
+
+	s.logUndo(merged, undoResult, userID)
+
+	if len(undoResult.Restored) == 0 {
+		return undoResult, fmt.Errorf("failed to undo operation %s on all %d nodes", operationID, len(undoResult.Failed))
+	}
+	return undoResult, nil
+}
+
+// restore 把节点上被操作修改过的部分恢复为操作前的值，其余部分保持当前状态
+func (s *Service) restore(change model.NodeChange, node *k8s.NodeInfo) error {
+	switch change.ResourceType {
+	case model.NodeChangeLabel:
+		return s.k8sSvc.UpdateNodeLabels(change.ClusterName, k8s.LabelUpdateRequest{
+			NodeName: change.NodeName,
+			Labels:   restoreLabels(node.Labels, change),
+		})
+	case model.NodeChangeTaint:
+		return s.k8sSvc.UpdateNodeTaints(change.ClusterName, k8s.TaintUpdateRequest{
+			NodeName: change.NodeName,
+			Taints:   restoreTaints(node.Taints, change),
+		})
+	default:
+		if unschedulable(change.Before) {
+			return s.k8sSvc.CordonNodeWithReason(change.ClusterName, change.NodeName, change.Before.CordonReason)
+		}
+		return s.k8sSvc.UncordonNode(change.ClusterName, change.NodeName)
+	}
+}
+
+// logUndo 记录撤销操作的审计日志
+func (s *Service) logUndo(changes []model.NodeChange, result *UndoResult, userID uint) {
+	clusterName := changes[0].ClusterName
+	var clusterID *uint
+	if cID, err := s.auditSvc.GetClusterIDByName(clusterName); err == nil {
+		clusterID = &cID
+	}
+
+	resourceType := model.ResourceNode
+	switch changes[0].ResourceType {
+	case model.NodeChangeLabel:
+		resourceType = model.ResourceLabel
+	case model.NodeChangeTaint:
+		resourceType = model.ResourceTaint
+	}
+
+	status := model.AuditStatusSuccess
+	errorMsg := ""
+	if len(result.Failed) > 0 {
+		failed := make([]string, 0, len(result.Failed))
+		for _, f := range result.Failed {
+			failed = append(failed, fmt.Sprintf("Node %s/%s (%s): %s", f.ClusterName, f.NodeName, f.ResourceType, f.Error))
+		}
+		sort.Strings(failed)
+		errorMsg = strings.Join(failed, "; ")
+		if len(result.Restored) == 0 {
+			status = model.AuditStatusFailed
+		}
+	}
+
+	nodeName := ""
+	if len(changes) == 1 {
+		nodeName = changes[0].NodeName
+	}
+	s.auditSvc.Log(audit.LogRequest{
+		UserID:       userID,
+		ClusterID:    clusterID,
+		NodeName:     nodeName,
+		Action:       model.ActionUpdate,
+		ResourceType: resourceType,
+		Details: fmt.Sprintf("Undid operation %s in cluster %s: %d restored, %d failed",
+			result.OperationID, clusterName, len(result.Restored), len(result.Failed)),
+		Status:      status,
+		ErrorMsg:    errorMsg,
+		OperationID: result.UndoOperationID,
+	})
+}
+
+// mergeChanges 合并同一节点同一资源的多次变更：每个键取最早的变更前值和最晚的变更后值
+func mergeChanges(changes []model.NodeChange) []model.NodeChange {
+	index := make(map[string]int)
+	var merged []model.NodeChange
+	for _, change := range changes {
+		key := change.ClusterName + "/" + change.NodeName + "/" + string(change.ResourceType)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, change)
+			continue
+		}
+
+		m := &merged[i]
+		switch change.ResourceType {
+		case model.NodeChangeLabel:
+			m.Before, m.After = mergeLabelChange(m.Before, m.After, change.Before, change.After)
+		case model.NodeChangeTaint:
+			m.Before, m.After = mergeTaintChange(m.Before, m.After, change.Before, change.After)
+		default:
+			m.After = change.After
+		}
+	}
+	return merged
+}
+
+// mergeLabelChange 合并两次标签变更
+func mergeLabelChange(before, after, nextBefore, nextAfter model.NodeStateSnapshot) (model.NodeStateSnapshot, model.NodeStateSnapshot) {
+	mergedBefore := LabelState(before.Labels)
+	mergedAfter := LabelState(after.Labels)
+	known := make(map[string]bool)
+	for _, k := range labelKeys(before, after) {
+		known[k] = true
+	}
+	for _, k := range labelKeys(nextBefore, nextAfter) {
+		if !known[k] {
+			if v, ok := nextBefore.Labels[k]; ok {
+				mergedBefore.Labels[k] = v
+			}
+		}
+		if v, ok := nextAfter.Labels[k]; ok {
+			mergedAfter.Labels[k] = v
+		} else {
+			delete(mergedAfter.Labels, k)
+		}
+	}
+	return mergedBefore, mergedAfter
+}
+
+// mergeTaintChange 合并两次污点变更
+func mergeTaintChange(before, after, nextBefore, nextAfter model.NodeStateSnapshot) (model.NodeStateSnapshot, model.NodeStateSnapshot) {
+	known := make(map[string]bool)
+	for _, k := range taintKeys(before, after) {
+		known[k] = true
+	}
+	next := make(map[string]bool)
+	mergedBefore := append([]model.TaintSnapshot{}, before.Taints...)
+	for _, k := range taintKeys(nextBefore, nextAfter) {
+		next[k] = true
+		if !known[k] {
+			mergedBefore = append(mergedBefore, pickTaints(nextBefore.Taints, []string{k}).Taints...)
+		}
+	}
+
+	var mergedAfter []model.TaintSnapshot
+	for _, t := range after.Taints {
+		if !next[t.Key] {
+			mergedAfter = append(mergedAfter, t)
+		}
+	}
+	mergedAfter = append(mergedAfter, nextAfter.Taints...)
+	return model.NodeStateSnapshot{Taints: mergedBefore}, model.NodeStateSnapshot{Taints: mergedAfter}
+}
+
+// labelKeys 变更涉及的标签键
+func labelKeys(before, after model.NodeStateSnapshot) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, labels := range []map[string]string{before.Labels, after.Labels} {
+		for k := range labels {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// taintKeys 变更涉及的污点键
+func taintKeys(before, after model.NodeStateSnapshot) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, taints := range [][]model.TaintSnapshot{before.Taints, after.Taints} {
+		for _, t := range taints {
+			if !seen[t.Key] {
+				seen[t.Key] = true
+				keys = append(keys, t.Key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// scopeSnapshot 只保留变更涉及的键，用于展示偏离详情
+func scopeSnapshot(change model.NodeChange, current model.NodeStateSnapshot) model.NodeStateSnapshot {
+	switch change.ResourceType {
+	case model.NodeChangeLabel:
+		return pickLabels(current.Labels, labelKeys(change.Before, change.After))
+	case model.NodeChangeTaint:
+		return pickTaints(current.Taints, taintKeys(change.Before, change.After))
+	default:
+		return current
+	}
+}
+
+// hasDrifted 判断节点当前状态在变更涉及的键上是否与操作完成后的状态不同
+func hasDrifted(change model.NodeChange, current model.NodeStateSnapshot) bool {
+	switch change.ResourceType {
+	case model.NodeChangeLabel:
+		for _, k := range labelKeys(change.Before, change.After) {
+			cv, cok := current.Labels[k]
+			av, aok := change.After.Labels[k]
+			if cok != aok || cv != av {
+				return true
+			}
+		}
+		return false
+	case model.NodeChangeTaint:
+		currentGroups := taintGroups(current.Taints)
+		afterGroups := taintGroups(change.After.Taints)
+		for _, k := range taintKeys(change.Before, change.After) {
+			if !sameGroup(currentGroups[k], afterGroups[k]) {
+				return true
+			}
+		}
+		return false
+	default:
+		return !sameCordonState(current, change.After)
+	}
+}
+
+// restoreLabels 计算恢复后的完整标签
+func restoreLabels(current map[string]string, change model.NodeChange) map[string]string {
+	restored := make(map[string]string, len(current))
+	for k, v := range current {
+		restored[k] = v
+	}
+	for _, k := range labelKeys(change.Before, change.After) {
+		if v, ok := change.Before.Labels[k]; ok {
+			restored[k] = v
+		} else {
+			delete(restored, k)
+		}
+	}
+	return restored
+}
+
+// restoreTaints 计算恢复后的完整污点列表，未涉及的污点保持原样（包括添加时间）
+func restoreTaints(current []k8s.TaintInfo, change model.NodeChange) []k8s.TaintInfo {
+	touched := make(map[string]bool)
+	for _, k := range taintKeys(change.Before, change.After) {
+		touched[k] = true
+	}
+
+	restored := make([]k8s.TaintInfo, 0, len(current))
+	for _, t := range current {
+		if !touched[t.Key] {
+			restored = append(restored, t)
+		}
+	}
+	now := time.Now()
+	for _, t := range change.Before.Taints {
+		restored = append(restored, k8s.TaintInfo{Key: t.Key, Value: t.Value, Effect: t.Effect, TimeAdded: &now})
+	}
+	return restored
+}
@@ -0,0 +1,75 @@
+package nodeevent
+
+import (
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/model"
+)
+
+// cleanupLoop 每天在配置的时间清理过期事件
+func (s *Service) cleanupLoop() {
+	defer s.wg.Done()
+
+	nextRun := s.calculateNextCleanupTime()
+	s.logger.Infof("Next node event cleanup scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+	for {
+		timer := time.NewTimer(time.Until(nextRun))
+		select {
+		case <-timer.C:
+			if err := s.Cleanup(); err != nil {
+				s.logger.Errorf("Scheduled node event cleanup failed: %v", err)
+			}
+			nextRun = s.calculateNextCleanupTime()
+			s.logger.Infof("Next node event cleanup scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// calculateNextCleanupTime 计算下次清理时间
+func (s *Service) calculateNextCleanupTime() time.Time {
+	now := time.Now()
+
+	hour, minute := 3, 0
+	fmt.Sscanf(s.config.CleanupTime, "%d:%d", &hour, &minute)
+
+	nextRun := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if now.After(nextRun) {
+		nextRun = nextRun.Add(24 * time.Hour)
+	}
+	return nextRun
+}
+
+// Cleanup 分批删除最后发生时间超出保留期的事件
+func (s *Service) Cleanup() error {
+	startTime := time.Now()
+	cutoff := startTime.AddDate(0, 0, -s.config.RetentionDays)
+
+	var deleted int64
+	for {
+		result := s.db.
+			Where("last_timestamp < ?", cutoff).
+			Limit(s.config.BatchSize).
+			Delete(&model.NodeEvent{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete node events: %w", result.Error)
+		}
+
+		deleted += result.RowsAffected
+		if result.RowsAffected == 0 {
+			break
+		}
+
+		// 短暂休息，避免长时间锁表
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	s.logger.Infof("Node event cleanup completed: %d events before %s deleted in %v",
+		deleted, cutoff.Format("2006-01-02 15:04:05"), time.Since(startTime))
+	return nil
+}
@@ -0,0 +1,283 @@
+package nodeevent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kube-node-manager/internal/informer"
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	seenTTL         = 2 * time.Hour    // 去重缓存保留时长，超过 Event 默认 TTL（1 小时）
+	broadcastWindow = 10 * time.Minute // 只推送最近发生的事件，避免 Informer 初始同步时重放历史事件
+)
+
+// Config 节点事件持久化配置
+type Config struct {
+	Enabled       bool   // 是否启用节点 Event 监听和持久化
+	RetentionDays int    // 保留天数
+	CleanupTime   string // 清理时间（格式：HH:MM）
+	BatchSize     int    // 批量删除大小
+}
+
+// Broadcaster 节点事件实时推送接口（WebSocket Hub）
+type Broadcaster interface {
+	SendNodeEvent(clusterName, nodeName string, data interface{})
+}
+
+// Listener 节点事件监听接口，事件首次入库或重复上报时收到通知
+// 多副本部署时只有实际写入数据库的副本会通知监听者
+type Listener interface {
+	OnNodeEventRecorded(event model.NodeEvent)
+}
+
+// seenEvent 本副本最近处理过的 Event 状态
+type seenEvent struct {
+	count int32
+	last  time.Time
+}
+
+// Service 节点事件服务：接收 Informer 推送的节点 Event，按 UID 去重入库，并通知监听者
+type Service struct {
+	db          *gorm.DB
+	logger      *logger.Logger
+	config      *Config
+	broadcaster Broadcaster
+	listeners   []Listener
+
+	mu         sync.Mutex
+	clusterIDs map[string]uint      // 集群名称 -> 集群 ID
+	seen       map[string]seenEvent // cluster/uid -> 最近处理的状态
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService 创建节点事件服务
+func NewService(db *gorm.DB, logger *logger.Logger, config *Config) *Service {
+	if config.RetentionDays <= 0 {
+		config.RetentionDays = 30
+	}
+	if config.CleanupTime == "" {
+		config.CleanupTime = "03:00"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		db:         db,
+		logger:     logger,
+		config:     config,
+		clusterIDs: make(map[string]uint),
+		seen:       make(map[string]seenEvent),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Enabled 是否启用节点事件持久化
+func (s *Service) Enabled() bool {
+	return s.config.Enabled
+}
+
+// SetBroadcaster 设置实时推送
+func (s *Service) SetBroadcaster(broadcaster Broadcaster) {
+	s.broadcaster = broadcaster
+}
+
+// AddListener 添加节点事件监听者，需要在启动 Informer 前调用
+func (s *Service) AddListener(listener Listener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// Start 启动过期数据清理
+func (s *Service) Start() {
+	if !s.config.Enabled {
+		s.logger.Info("Node event history is disabled")
+		return
+	}
+
+	s.logger.Infof("Starting node event history (retention: %d days, cleanup time: %s)",
+		s.config.RetentionDays, s.config.CleanupTime)
+
+	s.wg.Add(2)
+	go s.cleanupLoop()
+	go s.pruneLoop()
+}
+
+// Stop 停止清理
+func (s *Service) Stop() {
+	if !s.config.Enabled {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Node event history stopped")
+}
+
+// OnK8sEvent 实现 informer.K8sEventHandler 接口
+func (s *Service) OnK8sEvent(event informer.K8sEvent) {
+	e := event.Event
+	uid := string(e.UID)
+	if uid == "" {
+		return
+	}
+
+	converted := k8s.ConvertNodeEvent(e)
+	key := event.ClusterName + "/" + uid
+	if !s.markSeen(key, converted.Count, converted.LastTimestamp) {
+		return
+	}
+
+	clusterID, err := s.getClusterID(event.ClusterName)
+	if err != nil {
+		s.logger.Warningf("Failed to record node event %s/%s: %v", event.ClusterName, e.Name, err)
+		s.forget(key)
+		return
+	}
+
+	record := model.NodeEvent{
+		ClusterID:      clusterID,
+		ClusterName:    event.ClusterName,
+		NodeName:       e.InvolvedObject.Name,
+		EventUID:       uid,
+		Type:           converted.Type,
+		Reason:         converted.Reason,
+		Message:        converted.Message,
+		Source:         converted.Source,
+		Count:          converted.Count,
+		FirstTimestamp: converted.FirstTimestamp,
+		LastTimestamp:  converted.LastTimestamp,
+	}
+
+	recorded, err := s.save(&record)
+	if err != nil {
+		s.logger.Errorf("Failed to save node event %s/%s: %v", event.ClusterName, e.Name, err)
+		s.forget(key)
+		return
+	}
+
+	if s.broadcaster != nil && time.Since(record.LastTimestamp) <= broadcastWindow {
+		s.broadcaster.SendNodeEvent(record.ClusterName, record.NodeName, record)
+	}
+	if recorded {
+		for _, listener := range s.listeners {
+			listener.OnNodeEventRecorded(record)
+		}
+	}
+}
+
+// markSeen 记录本副本处理过的 Event 状态，内容没有比上次更新时返回 false
+func (s *Service) markSeen(key string, count int32, last time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if prev, ok := s.seen[key]; ok && !isNewer(prev, count, last) {
+		return false
+	}
+	s.seen[key] = seenEvent{count: count, last: last}
+	return true
+}
+
+// forget 处理失败时移除去重记录，下次同步时重试
+func (s *Service) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, key)
+}
+
+// isNewer 判断 Event 是否比上次处理时有新的上报
+func isNewer(prev seenEvent, count int32, last time.Time) bool {
+	return count > prev.count || last.After(prev.last)
+}
+
+// save 写入 Event，返回本次是否实际新增或更新了记录
+// 只在 count 增加或 lastTimestamp 变新时更新，多副本重复写入时只有一个副本成功
+func (s *Service) save(record *model.NodeEvent) (bool, error) {
+	result := s.db.Model(&model.NodeEvent{}).
+		Where("cluster_id = ? AND event_uid = ? AND (count < ? OR last_timestamp < ?)",
+			record.ClusterID, record.EventUID, record.Count, record.LastTimestamp).
+		Updates(map[string]interface{}{
+			"type":           record.Type,
+			"message":        record.Message,
+			"count":          record.Count,
+			"last_timestamp": record.LastTimestamp,
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update node event: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	result = s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to create node event: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// getClusterID 根据集群名称获取集群 ID
+func (s *Service) getClusterID(clusterName string) (uint, error) {
+	s.mu.Lock()
+	id, ok := s.clusterIDs[clusterName]
+	s.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var cluster model.Cluster
+	if err := s.db.Select("id").Where("name = ?", clusterName).First(&cluster).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("cluster %s not found", clusterName)
+		}
+		return 0, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	s.mu.Lock()
+	s.clusterIDs[clusterName] = cluster.ID
+	s.mu.Unlock()
+	return cluster.ID, nil
+}
+
+// pruneLoop 定期清理去重缓存中已过期的 Event
+func (s *Service) pruneLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.pruneSeen(time.Now().Add(-seenTTL))
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// pruneSeen 移除最后发生时间早于截止时间的去重记录
+func (s *Service) pruneSeen(cutoff time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, e := range s.seen {
+		if e.last.Before(cutoff) {
+			delete(s.seen, key)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package nodeevent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarkSeen(t *testing.T) {
+	s := &Service{seen: make(map[string]seenEvent)}
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	if !s.markSeen("c/uid", 1, base) {
+		t.Fatal("first occurrence should be processed")
+	}
+	// 全量同步或多次通知带来的相同内容被忽略
+	if s.markSeen("c/uid", 1, base) {
+		t.Error("unchanged event should be skipped")
+	}
+	if !s.markSeen("c/uid", 2, base.Add(time.Minute)) {
+		t.Error("repeated event with higher count should be processed")
+	}
+	// 乱序到达的旧版本被忽略
+	if s.markSeen("c/uid", 1, base) {
+		t.Error("stale event should be skipped")
+	}
+
+	s.forget("c/uid")
+	if !s.markSeen("c/uid", 2, base.Add(time.Minute)) {
+		t.Error("forgotten event should be processed again")
+	}
+
+	s.markSeen("c/old", 1, base.Add(-3*time.Hour))
+	s.pruneSeen(base.Add(-seenTTL))
+	if _, ok := s.seen["c/old"]; ok {
+		t.Error("expired entry should be pruned")
+	}
+	if _, ok := s.seen["c/uid"]; !ok {
+		t.Error("recent entry should be kept")
+	}
+}
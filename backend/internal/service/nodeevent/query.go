@@ -0,0 +1,98 @@
+package nodeevent
+
+import (
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/model"
+)
+
+const maxPageSize = 500
+
+// ListRequest 节点事件查询请求
+type ListRequest struct {
+	ClusterName string
+	NodeName    string
+	Type        string // Normal 或 Warning，为空时不过滤
+	Reason      string
+	StartTime   *time.Time // 按最后发生时间过滤
+	EndTime     *time.Time
+	Page        int
+	PageSize    int
+}
+
+// ListResponse 节点事件查询响应
+type ListResponse struct {
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+	Items      []model.NodeEvent `json:"items"`
+}
+
+// List 查询节点的历史事件，按最后发生时间倒序
+func (s *Service) List(req ListRequest) (*ListResponse, error) {
+	if req.ClusterName == "" {
+		return nil, fmt.Errorf("cluster_name is required")
+	}
+	if req.NodeName == "" {
+		return nil, fmt.Errorf("node_name is required")
+	}
+
+	clusterID, err := s.getClusterID(req.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&model.NodeEvent{}).
+		Where("cluster_id = ? AND node_name = ?", clusterID, req.NodeName)
+	if req.Type != "" {
+		query = query.Where("type = ?", req.Type)
+	}
+	if req.Reason != "" {
+		query = query.Where("reason = ?", req.Reason)
+	}
+	if req.StartTime != nil {
+		query = query.Where("last_timestamp >= ?", *req.StartTime)
+	}
+	if req.EndTime != nil {
+		query = query.Where("last_timestamp <= ?", *req.EndTime)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count node events: %w", err)
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 {
+		req.PageSize = 20
+	}
+	if req.PageSize > maxPageSize {
+		req.PageSize = maxPageSize
+	}
+
+	var events []model.NodeEvent
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("last_timestamp DESC").
+		Limit(req.PageSize).
+		Offset(offset).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to query node events: %w", err)
+	}
+
+	totalPages := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPages++
+	}
+
+	return &ListResponse{
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+		Items:      events,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package nodemetrics
+
+import (
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/model"
+)
+
+// cleanupLoop 每天在配置的时间按层级清理过期数据
+func (s *Service) cleanupLoop() {
+	defer s.wg.Done()
+
+	nextRun := s.calculateNextCleanupTime()
+	s.logger.Infof("Next node metrics cleanup scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+	for {
+		timer := time.NewTimer(time.Until(nextRun))
+		select {
+		case <-timer.C:
+			if err := s.Cleanup(); err != nil {
+				s.logger.Errorf("Scheduled node metrics cleanup failed: %v", err)
+			}
+			nextRun = s.calculateNextCleanupTime()
+			s.logger.Infof("Next node metrics cleanup scheduled at: %s", nextRun.Format("2006-01-02 15:04:05"))
+
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// calculateNextCleanupTime 计算下次清理时间
+func (s *Service) calculateNextCleanupTime() time.Time {
+	now := time.Now()
+
+	hour, minute := 2, 30
+	fmt.Sscanf(s.config.CleanupTime, "%d:%d", &hour, &minute)
+
+	nextRun := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if now.After(nextRun) {
+		nextRun = nextRun.Add(24 * time.Hour)
+	}
+	return nextRun
+}
+
+// Cleanup 删除各层级超出保留期的数据
+func (s *Service) Cleanup() error {
+	startTime := time.Now()
+	var total int64
+
+	for _, spec := range s.tiers() {
+		cutoff := startTime.Add(-spec.retention)
+		deleted, err := s.deleteBefore(spec.tier, cutoff)
+		total += deleted
+		if err != nil {
+			return fmt.Errorf("failed to clean up %s samples: %w", spec.tier, err)
+		}
+		if deleted > 0 {
+			s.logger.Infof("Node metrics cleanup: %d %s samples before %s deleted",
+				deleted, spec.tier, cutoff.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	s.logger.Infof("Node metrics cleanup completed: %d samples deleted in %v", total, time.Since(startTime))
+	return nil
+}
+
+// deleteBefore 分批删除指定层级早于截止时间的数据
+func (s *Service) deleteBefore(tier model.MetricTier, cutoff time.Time) (int64, error) {
+	var deleted int64
+	for {
+		result := s.db.
+			Where("tier = ? AND timestamp < ?", tier, cutoff).
+			Limit(s.config.BatchSize).
+			Delete(&model.NodeMetricSample{})
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+
+		deleted += result.RowsAffected
+		if result.RowsAffected == 0 {
+			return deleted, nil
+		}
+
+		// 短暂休息，避免长时间锁表
+		time.Sleep(100 * time.Millisecond)
+	}
+}
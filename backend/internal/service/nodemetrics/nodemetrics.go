@@ -0,0 +1,367 @@
+package nodemetrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Config 节点资源指标历史配置
+type Config struct {
+	Enabled          bool          // 是否启用采样
+	Interval         time.Duration // 采样周期
+	RawRetention     time.Duration // 原始采样保留时长
+	FiveMinRetention time.Duration // 5 分钟聚合保留时长
+	HourlyRetention  time.Duration // 1 小时聚合保留时长
+	CleanupTime      string        // 清理时间（格式：HH:MM）
+	BatchSize        int           // 批量删除大小
+}
+
+// tierSpec 降采样层级定义
+type tierSpec struct {
+	tier       model.MetricTier
+	resolution time.Duration
+	retention  time.Duration
+}
+
+// Service 节点资源指标历史服务：定期采样 metrics-server 数据，逐级降采样并按层级保留
+type Service struct {
+	db     *gorm.DB
+	logger *logger.Logger
+	k8sSvc *k8s.Service
+	config *Config
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService 创建节点资源指标历史服务
+func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service, config *Config) *Service {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.RawRetention <= 0 {
+		config.RawRetention = 48 * time.Hour
+	}
+	if config.FiveMinRetention <= 0 {
+		config.FiveMinRetention = 14 * 24 * time.Hour
+	}
+	if config.HourlyRetention <= 0 {
+		config.HourlyRetention = 180 * 24 * time.Hour
+	}
+	if config.CleanupTime == "" {
+		config.CleanupTime = "02:30"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1000
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		db:     db,
+		logger: logger,
+		k8sSvc: k8sSvc,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// tiers 返回从细到粗的降采样层级
+func (s *Service) tiers() []tierSpec {
+	return []tierSpec{
+		{tier: model.MetricTierRaw, resolution: s.config.Interval, retention: s.config.RawRetention},
+		{tier: model.MetricTierFiveMin, resolution: 5 * time.Minute, retention: s.config.FiveMinRetention},
+		{tier: model.MetricTierHourly, resolution: time.Hour, retention: s.config.HourlyRetention},
+	}
+}
+
+// Start 启动采样和清理协程
+func (s *Service) Start() {
+	if !s.config.Enabled {
+		s.logger.Info("Node metrics history is disabled")
+		return
+	}
+
+	s.logger.Infof("Starting node metrics history (interval: %v, retention: raw %v, 5m %v, 1h %v)",
+		s.config.Interval, s.config.RawRetention, s.config.FiveMinRetention, s.config.HourlyRetention)
+
+	s.wg.Add(2)
+	go s.sampleLoop()
+	go s.cleanupLoop()
+}
+
+// Stop 停止采样和清理
+func (s *Service) Stop() {
+	if !s.config.Enabled {
+		return
+	}
+
+	s.logger.Info("Stopping node metrics history...")
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Node metrics history stopped")
+}
+
+// sampleLoop 定时采样循环
+func (s *Service) sampleLoop() {
+	defer s.wg.Done()
+
+	s.sampleAndRollup()
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sampleAndRollup()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// sampleAndRollup 采样所有集群并聚合已结束的时间桶
+func (s *Service) sampleAndRollup() {
+	now := time.Now()
+	s.sampleAllClusters(now)
+	if err := s.rollup(now); err != nil {
+		s.logger.Errorf("Failed to roll up node metrics: %v", err)
+	}
+}
+
+// sampleAllClusters 采样所有活跃集群的节点资源使用情况
+func (s *Service) sampleAllClusters(now time.Time) {
+	var clusters []model.Cluster
+	if err := s.db.Where("status = ?", model.ClusterStatusActive).Find(&clusters).Error; err != nil {
+		s.logger.Errorf("Failed to list clusters for node metrics sampling: %v", err)
+		return
+	}
+
+	// 时间点按采样周期对齐，多副本部署时同一时间点只保留一条
+	timestamp := now.Truncate(s.config.Interval)
+
+	var wg sync.WaitGroup
+	for _, cls := range clusters {
+		wg.Add(1)
+		go func(c model.Cluster) {
+			defer wg.Done()
+			if err := s.sampleCluster(c, timestamp); err != nil {
+				s.logger.Warningf("Failed to sample node metrics for cluster %s: %v", c.Name, err)
+			}
+		}(cls)
+	}
+	wg.Wait()
+}
+
+// sampleCluster 采样单个集群并写入原始层级
+func (s *Service) sampleCluster(cluster model.Cluster, timestamp time.Time) error {
+	samples, err := s.k8sSvc.CollectNodeUsage(s.ctx, cluster.Name)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+
+	rows := make([]model.NodeMetricSample, 0, len(samples))
+	for _, sample := range samples {
+		row := model.NodeMetricSample{
+			ClusterID:         cluster.ID,
+			ClusterName:       cluster.Name,
+			NodeName:          sample.NodeName,
+			Tier:              model.MetricTierRaw,
+			Timestamp:         timestamp,
+			SampleCount:       1,
+			CPUAvg:            float64(sample.CPU),
+			CPUMin:            sample.CPU,
+			CPUMax:            sample.CPU,
+			MemoryAvg:         float64(sample.Memory),
+			MemoryMin:         sample.Memory,
+			MemoryMax:         sample.Memory,
+			PodsAvg:           float64(sample.Pods),
+			PodsMin:           sample.Pods,
+			PodsMax:           sample.Pods,
+			CPUAllocatable:    sample.CPUAllocatable,
+			MemoryAllocatable: sample.MemoryAllocatable,
+			PodsAllocatable:   sample.PodsAllocatable,
+		}
+		if sample.HasRequests {
+			row.RequestSamples = 1
+			row.CPURequestsAvg = float64(sample.CPURequests)
+			row.MemoryRequestsAvg = float64(sample.MemoryRequests)
+		}
+		rows = append(rows, row)
+	}
+
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 200).Error
+}
+
+// rollup 将已结束的时间桶逐级聚合到更粗的层级
+func (s *Service) rollup(now time.Time) error {
+	tiers := s.tiers()
+	for i := 1; i < len(tiers); i++ {
+		if err := s.rollupTier(tiers[i-1], tiers[i], now); err != nil {
+			return fmt.Errorf("failed to roll up %s samples: %w", tiers[i].tier, err)
+		}
+	}
+	return nil
+}
+
+// rollupTier 将 source 层级聚合到 target 层级：从 target 最新的桶之后开始，到当前未结束的桶之前为止
+func (s *Service) rollupTier(source, target tierSpec, now time.Time) error {
+	end := now.Truncate(target.resolution)
+
+	var start time.Time
+	var latest model.NodeMetricSample
+	if err := s.db.Where("tier = ?", target.tier).Order("timestamp DESC").Limit(1).Find(&latest).Error; err != nil {
+		return err
+	}
+	if latest.ID != 0 {
+		start = latest.Timestamp.Add(target.resolution)
+	} else {
+		var earliest model.NodeMetricSample
+		if err := s.db.Where("tier = ?", source.tier).Order("timestamp ASC").Limit(1).Find(&earliest).Error; err != nil {
+			return err
+		}
+		if earliest.ID == 0 {
+			return nil
+		}
+		start = earliest.Timestamp.Truncate(target.resolution)
+	}
+
+	// 超出源层级保留期的数据已被清理，不再回溯
+	if floor := end.Add(-source.retention).Truncate(target.resolution); start.Before(floor) {
+		start = floor
+	}
+
+	// 分块处理，避免一次加载过多数据
+	chunk := 12 * target.resolution
+	for chunkStart := start; chunkStart.Before(end); chunkStart = chunkStart.Add(chunk) {
+		chunkEnd := chunkStart.Add(chunk)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+
+		var rows []model.NodeMetricSample
+		if err := s.db.Where("tier = ? AND timestamp >= ? AND timestamp < ?", source.tier, chunkStart, chunkEnd).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+
+		aggregated := aggregateSamples(rows, target.tier, func(t time.Time) time.Time {
+			return t.Truncate(target.resolution)
+		})
+		if len(aggregated) == 0 {
+			continue
+		}
+		if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(aggregated, 200).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aggregateSamples 按集群、节点和时间桶聚合数据点：平均值按采样数加权，最小值和最大值取极值，
+// requests 平均值按统计到 requests 的采样数加权，可分配资源取桶内最新的值；结果按集群、节点、时间排序
+func aggregateSamples(rows []model.NodeMetricSample, tier model.MetricTier, bucket func(time.Time) time.Time) []model.NodeMetricSample {
+	type key struct {
+		clusterID uint
+		nodeName  string
+		timestamp time.Time
+	}
+	type accumulator struct {
+		sample            model.NodeMetricSample
+		latest            time.Time
+		cpuSum            float64
+		memorySum         float64
+		podsSum           float64
+		cpuRequestsSum    float64
+		memoryRequestsSum float64
+	}
+
+	groups := make(map[key]*accumulator)
+	keys := make([]key, 0)
+	for _, row := range rows {
+		count := row.SampleCount
+		if count <= 0 {
+			count = 1
+		}
+		k := key{clusterID: row.ClusterID, nodeName: row.NodeName, timestamp: bucket(row.Timestamp)}
+		acc, ok := groups[k]
+		if !ok {
+			acc = &accumulator{sample: model.NodeMetricSample{
+				ClusterID:   row.ClusterID,
+				ClusterName: row.ClusterName,
+				NodeName:    row.NodeName,
+				Tier:        tier,
+				Timestamp:   k.timestamp,
+				CPUMin:      row.CPUMin,
+				CPUMax:      row.CPUMax,
+				MemoryMin:   row.MemoryMin,
+				MemoryMax:   row.MemoryMax,
+				PodsMin:     row.PodsMin,
+				PodsMax:     row.PodsMax,
+			}}
+			groups[k] = acc
+			keys = append(keys, k)
+		}
+
+		acc.sample.SampleCount += count
+		acc.cpuSum += row.CPUAvg * float64(count)
+		acc.memorySum += row.MemoryAvg * float64(count)
+		acc.podsSum += row.PodsAvg * float64(count)
+		if row.RequestSamples > 0 {
+			acc.sample.RequestSamples += row.RequestSamples
+			acc.cpuRequestsSum += row.CPURequestsAvg * float64(row.RequestSamples)
+			acc.memoryRequestsSum += row.MemoryRequestsAvg * float64(row.RequestSamples)
+		}
+		acc.sample.CPUMin = min(acc.sample.CPUMin, row.CPUMin)
+		acc.sample.CPUMax = max(acc.sample.CPUMax, row.CPUMax)
+		acc.sample.MemoryMin = min(acc.sample.MemoryMin, row.MemoryMin)
+		acc.sample.MemoryMax = max(acc.sample.MemoryMax, row.MemoryMax)
+		acc.sample.PodsMin = min(acc.sample.PodsMin, row.PodsMin)
+		acc.sample.PodsMax = max(acc.sample.PodsMax, row.PodsMax)
+		if !row.Timestamp.Before(acc.latest) {
+			acc.latest = row.Timestamp
+			acc.sample.CPUAllocatable = row.CPUAllocatable
+			acc.sample.MemoryAllocatable = row.MemoryAllocatable
+			acc.sample.PodsAllocatable = row.PodsAllocatable
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].clusterID != keys[j].clusterID {
+			return keys[i].clusterID < keys[j].clusterID
+		}
+		if keys[i].nodeName != keys[j].nodeName {
+			return keys[i].nodeName < keys[j].nodeName
+		}
+		return keys[i].timestamp.Before(keys[j].timestamp)
+	})
+
+	result := make([]model.NodeMetricSample, 0, len(keys))
+	for _, k := range keys {
+		acc := groups[k]
+		n := float64(acc.sample.SampleCount)
+		acc.sample.CPUAvg = acc.cpuSum / n
+		acc.sample.MemoryAvg = acc.memorySum / n
+		acc.sample.PodsAvg = acc.podsSum / n
+		if acc.sample.RequestSamples > 0 {
+			acc.sample.CPURequestsAvg = acc.cpuRequestsSum / float64(acc.sample.RequestSamples)
+			acc.sample.MemoryRequestsAvg = acc.memoryRequestsSum / float64(acc.sample.RequestSamples)
+		}
+		result = append(result, acc.sample)
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package nodemetrics
+
+import (
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+)
+
+func rawSample(node string, ts time.Time, cpu, memory, pods int64) model.NodeMetricSample {
+	return model.NodeMetricSample{
+		ClusterID:      1,
+		ClusterName:    "test",
+		NodeName:       node,
+		Tier:           model.MetricTierRaw,
+		Timestamp:      ts,
+		SampleCount:    1,
+		CPUAvg:         float64(cpu),
+		CPUMin:         cpu,
+		CPUMax:         cpu,
+		MemoryAvg:      float64(memory),
+		MemoryMin:      memory,
+		MemoryMax:      memory,
+		PodsAvg:        float64(pods),
+		PodsMin:        pods,
+		PodsMax:        pods,
+		CPUAllocatable: 4000,
+	}
+}
+
+func TestAggregateSamples(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	rows := []model.NodeMetricSample{
+		rawSample("n1", base, 100, 1000, 10),
+		rawSample("n1", base.Add(time.Minute), 300, 3000, 12),
+		rawSample("n1", base.Add(5*time.Minute), 500, 5000, 14),
+		rawSample("n2", base, 200, 2000, 20),
+	}
+	rows[1].CPUAllocatable = 8000
+	rows[0].RequestSamples, rows[0].CPURequestsAvg = 1, 1000
+	rows[1].RequestSamples, rows[1].CPURequestsAvg = 1, 2000
+
+	fiveMin := aggregateSamples(rows, model.MetricTierFiveMin, func(t time.Time) time.Time {
+		return t.Truncate(5 * time.Minute)
+	})
+	if len(fiveMin) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(fiveMin))
+	}
+	first := fiveMin[0]
+	if first.NodeName != "n1" || !first.Timestamp.Equal(base) || first.Tier != model.MetricTierFiveMin {
+		t.Fatalf("unexpected first bucket: %+v", first)
+	}
+	if first.SampleCount != 2 || first.CPUAvg != 200 || first.CPUMin != 100 || first.CPUMax != 300 || first.PodsAvg != 11 {
+		t.Errorf("unexpected aggregation: %+v", first)
+	}
+	if first.CPUAllocatable != 8000 {
+		t.Errorf("expected latest allocatable 8000, got %d", first.CPUAllocatable)
+	}
+	if first.RequestSamples != 2 || first.CPURequestsAvg != 1500 {
+		t.Errorf("unexpected requests aggregation: %d samples, avg %v", first.RequestSamples, first.CPURequestsAvg)
+	}
+	if fiveMin[1].RequestSamples != 0 || fiveMin[1].CPURequestsAvg != 0 {
+		t.Errorf("expected no requests for bucket without request samples: %+v", fiveMin[1])
+	}
+
+	// 再次聚合时平均值按采样数加权
+	hourly := aggregateSamples(fiveMin, model.MetricTierHourly, func(t time.Time) time.Time {
+		return t.Truncate(time.Hour)
+	})
+	if len(hourly) != 2 || hourly[0].SampleCount != 3 || hourly[0].CPUAvg != 300 || hourly[0].CPUMax != 500 {
+		t.Errorf("unexpected hourly aggregation: %+v", hourly)
+	}
+}
+
+func TestSelectTier(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	tiers := []tierSpec{
+		{tier: model.MetricTierRaw, resolution: time.Minute, retention: 48 * time.Hour},
+		{tier: model.MetricTierFiveMin, resolution: 5 * time.Minute, retention: 14 * 24 * time.Hour},
+		{tier: model.MetricTierHourly, resolution: time.Hour, retention: 180 * 24 * time.Hour},
+	}
+
+	cases := []struct {
+		start time.Time
+		step  time.Duration
+		want  model.MetricTier
+	}{
+		{now.Add(-time.Hour), 30 * time.Second, model.MetricTierRaw},
+		{now.Add(-time.Hour), 2 * time.Minute, model.MetricTierRaw},
+		{now.Add(-6 * time.Hour), 10 * time.Minute, model.MetricTierFiveMin},
+		{now.Add(-7 * 24 * time.Hour), time.Minute, model.MetricTierFiveMin},
+		{now.Add(-30 * 24 * time.Hour), 6 * time.Hour, model.MetricTierHourly},
+		{now.Add(-365 * 24 * time.Hour), time.Hour, model.MetricTierHourly},
+	}
+	for _, c := range cases {
+		if got := selectTier(tiers, c.start, c.step, now).tier; got != c.want {
+			t.Errorf("selectTier(start=%v, step=%v) = %s, want %s", now.Sub(c.start), c.step, got, c.want)
+		}
+	}
+}
+
+func TestBuildSeries(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	rows := []model.NodeMetricSample{
+		rawSample("n1", base, 100, 1000, 10),
+		rawSample("n1", base.Add(time.Minute), 300, 3000, 12),
+		rawSample("n1", base.Add(2*time.Minute), 500, 5000, 14),
+		rawSample("n2", base, 200, 2000, 20),
+	}
+
+	series := buildSeries(rows, base, 2*time.Minute, AggregationMax)
+	if len(series) != 2 || series[0].NodeName != "n1" || len(series[0].Points) != 2 {
+		t.Fatalf("unexpected series: %+v", series)
+	}
+	if p := series[0].Points[0]; p.CPU != 300 || p.Memory != 3000 || p.Pods != 12 {
+		t.Errorf("unexpected max point: %+v", p)
+	}
+	if series[0].CPUAllocatable != 4000 {
+		t.Errorf("expected allocatable 4000, got %d", series[0].CPUAllocatable)
+	}
+}
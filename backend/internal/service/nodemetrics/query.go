@@ -0,0 +1,175 @@
+package nodemetrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 查询支持的聚合方式
+const (
+	AggregationAvg = "avg"
+	AggregationMax = "max"
+	AggregationMin = "min"
+)
+
+const (
+	defaultQueryPoints = 120  // 未指定步长时每条序列的目标点数
+	maxQueryPoints     = 1000 // 每条序列的最大点数
+)
+
+// QueryRequest 历史指标查询参数
+type QueryRequest struct {
+	ClusterName string
+	NodeNames   []string // 为空时返回集群全部节点
+	Start       time.Time
+	End         time.Time
+	Step        time.Duration // 为 0 时按时间范围自动选择
+	Aggregation string        // avg、max、min，默认 avg
+}
+
+// Point 时间序列数据点
+type Point struct {
+	Timestamp time.Time `json:"timestamp"`
+	CPU       float64   `json:"cpu"`    // 毫核
+	Memory    float64   `json:"memory"` // 字节
+	Pods      float64   `json:"pods"`
+}
+
+// Series 单个节点的时间序列
+type Series struct {
+	NodeName          string  `json:"node_name"`
+	CPUAllocatable    int64   `json:"cpu_allocatable"`    // 毫核，取区间内最新的值
+	MemoryAllocatable int64   `json:"memory_allocatable"` // 字节
+	PodsAllocatable   int64   `json:"pods_allocatable"`
+	Points            []Point `json:"points"`
+}
+
+// QueryResult 历史指标查询结果
+type QueryResult struct {
+	ClusterName string           `json:"cluster_name"`
+	Start       time.Time        `json:"start"`
+	End         time.Time        `json:"end"`
+	Step        int64            `json:"step"` // 秒
+	Aggregation string           `json:"aggregation"`
+	Tier        model.MetricTier `json:"tier"` // 数据来源层级
+	Series      []Series         `json:"series"`
+}
+
+// Query 查询节点资源使用历史，按步长重新分桶聚合
+// 自动选择保留期覆盖起始时间、且精度不低于步长的最粗层级
+func (s *Service) Query(req QueryRequest) (*QueryResult, error) {
+	if req.ClusterName == "" {
+		return nil, fmt.Errorf("cluster_name is required")
+	}
+	if !req.End.After(req.Start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+	if req.Aggregation == "" {
+		req.Aggregation = AggregationAvg
+	}
+	if req.Aggregation != AggregationAvg && req.Aggregation != AggregationMax && req.Aggregation != AggregationMin {
+		return nil, fmt.Errorf("invalid aggregation %q, expected avg, max or min", req.Aggregation)
+	}
+
+	span := req.End.Sub(req.Start)
+	step := req.Step
+	if step <= 0 {
+		step = span / defaultQueryPoints
+	}
+	if floor := span / maxQueryPoints; step < floor {
+		step = floor
+	}
+	spec := selectTier(s.tiers(), req.Start, step, time.Now())
+	if step < spec.resolution {
+		step = spec.resolution
+	}
+	step = step.Round(time.Second)
+
+	var cluster model.Cluster
+	if err := s.db.Where("name = ?", req.ClusterName).First(&cluster).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("cluster %s not found", req.ClusterName)
+		}
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	query := s.db.Where("cluster_id = ? AND tier = ? AND timestamp >= ? AND timestamp < ?",
+		cluster.ID, spec.tier, req.Start, req.End)
+	if len(req.NodeNames) > 0 {
+		query = query.Where("node_name IN ?", req.NodeNames)
+	}
+	var rows []model.NodeMetricSample
+	if err := query.Order("node_name, timestamp").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query node metrics: %w", err)
+	}
+
+	return &QueryResult{
+		ClusterName: req.ClusterName,
+		Start:       req.Start,
+		End:         req.End,
+		Step:        int64(step / time.Second),
+		Aggregation: req.Aggregation,
+		Tier:        spec.tier,
+		Series:      buildSeries(rows, req.Start, step, req.Aggregation),
+	}, nil
+}
+
+// selectTier 在保留期覆盖起始时间的层级中选择精度不低于步长的最粗层级；
+// 所有层级精度都低于步长时选择最细的，都不覆盖起始时间时选择保留最久的
+func selectTier(tiers []tierSpec, start time.Time, step time.Duration, now time.Time) tierSpec {
+	var selected *tierSpec
+	for i := range tiers {
+		if start.Before(now.Add(-tiers[i].retention)) {
+			continue
+		}
+		if selected == nil || tiers[i].resolution <= step {
+			selected = &tiers[i]
+		}
+	}
+	if selected != nil {
+		return *selected
+	}
+
+	longest := tiers[0]
+	for _, t := range tiers[1:] {
+		if t.retention > longest.retention {
+			longest = t
+		}
+	}
+	return longest
+}
+
+// buildSeries 将数据点按节点分组，并以 start 为起点按步长分桶
+func buildSeries(rows []model.NodeMetricSample, start time.Time, step time.Duration, aggregation string) []Series {
+	buckets := aggregateSamples(rows, "", func(t time.Time) time.Time {
+		return start.Add(t.Sub(start) / step * step)
+	})
+
+	series := make([]Series, 0)
+	for _, b := range buckets {
+		if len(series) == 0 || series[len(series)-1].NodeName != b.NodeName {
+			series = append(series, Series{NodeName: b.NodeName, Points: []Point{}})
+		}
+		current := &series[len(series)-1]
+		current.CPUAllocatable = b.CPUAllocatable
+		current.MemoryAllocatable = b.MemoryAllocatable
+		current.PodsAllocatable = b.PodsAllocatable
+
+		point := Point{Timestamp: b.Timestamp}
+		switch aggregation {
+		case AggregationMax:
+			point.CPU, point.Memory, point.Pods = float64(b.CPUMax), float64(b.MemoryMax), float64(b.PodsMax)
+		case AggregationMin:
+			point.CPU, point.Memory, point.Pods = float64(b.CPUMin), float64(b.MemoryMin), float64(b.PodsMin)
+		default:
+			point.CPU, point.Memory, point.Pods = b.CPUAvg, b.MemoryAvg, b.PodsAvg
+		}
+		current.Points = append(current.Points, point)
+	}
+	return series
+}
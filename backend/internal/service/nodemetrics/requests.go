@@ -0,0 +1,55 @@
+package nodemetrics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// requestHistoryStep 容量趋势使用的数据精度
+const requestHistoryStep = time.Hour
+
+// RequestHistory 获取集群自 start 起统计到 requests 的历史数据点（每个节点、每个时间桶一条）
+// 优先使用小时聚合层级，保留期不覆盖 start 时使用能覆盖的最粗层级
+func (s *Service) RequestHistory(clusterName string, start time.Time) ([]model.NodeMetricSample, error) {
+	var cluster model.Cluster
+	if err := s.db.Where("name = ?", clusterName).First(&cluster).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("cluster %s not found", clusterName)
+		}
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	spec := selectTier(s.tiers(), start, requestHistoryStep, time.Now())
+
+	var rows []model.NodeMetricSample
+	if err := s.db.Select("node_name, timestamp, pods_avg, request_samples, cpu_requests_avg, memory_requests_avg").
+		Where("cluster_id = ? AND tier = ? AND timestamp >= ? AND request_samples > 0", cluster.ID, spec.tier, start).
+		Order("timestamp").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query request history: %w", err)
+	}
+	return rows, nil
+}
+
+// LatestUsage 获取集群各节点最近一次采样的资源使用量，超过两个采样周期的数据视为过期
+func (s *Service) LatestUsage(clusterName string) (map[string]model.NodeMetricSample, error) {
+	since := time.Now().Add(-2 * s.config.Interval)
+
+	var rows []model.NodeMetricSample
+	if err := s.db.Where("cluster_name = ? AND tier = ? AND timestamp >= ?", clusterName, model.MetricTierRaw, since).
+		Order("timestamp").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to query latest node usage: %w", err)
+	}
+
+	latest := make(map[string]model.NodeMetricSample, len(rows))
+	for _, row := range rows {
+		latest[row.NodeName] = row
+	}
+	return latest, nil
+}
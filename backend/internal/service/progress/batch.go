@@ -0,0 +1,586 @@
+package progress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"kube-node-manager/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// batchHeartbeatInterval 执行副本续期心跳并检查取消请求的间隔
+	batchHeartbeatInterval = 5 * time.Second
+	// batchStaleTimeout 心跳超过该时长未更新的运行中任务视为执行副本已中断
+	batchStaleTimeout = time.Minute
+	// orphanCheckInterval 检查中断任务的间隔
+	orphanCheckInterval = 30 * time.Second
+	// defaultResumeConcurrency 未记录并发数的任务恢复时使用的并发数
+	defaultResumeConcurrency = 5
+)
+
+// replicaID 当前副本标识，记录在任务上用于区分执行者
+var replicaID = func() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}()
+
+// BatchResumer 根据持久化的请求参数重建批量处理器，用于恢复中断或取消的任务
+type BatchResumer func(params json.RawMessage, userID uint) (BatchProcessor, error)
+
+// BatchTaskReport 批量任务执行报告
+type BatchTaskReport struct {
+	TaskID       string            `json:"task_id"`
+	Action       string            `json:"action"`
+	Status       model.TaskStatus  `json:"status"`
+	Total        int               `json:"total"`
+	SuccessNodes []string          `json:"success_nodes"`
+	FailedNodes  []model.NodeError `json:"failed_nodes"`
+	PendingNodes []string          `json:"pending_nodes"` // 尚未执行的节点
+	Message      string            `json:"message"`
+	Error        string            `json:"error,omitempty"`
+	Owner        string            `json:"owner,omitempty"` // 执行任务的副本
+	Resumable    bool              `json:"resumable"`       // 是否可以恢复剩余节点
+	CreatedAt    time.Time         `json:"created_at"`
+	CompletedAt  *time.Time        `json:"completed_at,omitempty"`
+}
+
+// clientMessage 客户端通过 WebSocket 发送的控制消息
+type clientMessage struct {
+	Type   string `json:"type"` // cancel
+	TaskID string `json:"task_id"`
+}
+
+// RegisterResumer 注册指定操作类型的任务恢复器
+func (s *Service) RegisterResumer(action string, resumer BatchResumer) {
+	s.resumerMutex.Lock()
+	defer s.resumerMutex.Unlock()
+	s.resumers[action] = resumer
+}
+
+// getResumer 获取指定操作类型的任务恢复器
+func (s *Service) getResumer(action string) (BatchResumer, bool) {
+	s.resumerMutex.RLock()
+	defer s.resumerMutex.RUnlock()
+	resumer, exists := s.resumers[action]
+	return resumer, exists
+}
+
+// ProcessResumableBatch 带进度的可取消批量处理
+// params 为重建处理器所需的请求参数，数据库模式下随批量计划一起持久化，用于任务中断后恢复
+func (s *Service) ProcessResumableBatch(
+	ctx context.Context,
+	taskID string,
+	action string,
+	nodeNames []string,
+	userID uint,
+	maxConcurrency int,
+	processor BatchProcessor,
+	params interface{},
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	s.registerCancel(taskID, cancel)
+	defer s.releaseCancel(taskID)
+
+	if s.useDatabase && s.dbProgressService != nil {
+		var paramsJSON string
+		if params != nil {
+			data, err := json.Marshal(params)
+			if err != nil {
+				s.logger.Warningf("Failed to marshal params for task %s, it will not be resumable: %v", taskID, err)
+			} else {
+				paramsJSON = string(data)
+			}
+		}
+		return s.dbProgressService.ProcessBatchWithProgress(ctx, taskID, action, nodeNames, userID, maxConcurrency, processor, paramsJSON)
+	}
+
+	return s.processBatchInMemory(ctx, taskID, action, nodeNames, userID, maxConcurrency, processor)
+}
+
+// registerCancel 登记本副本上运行中任务的取消函数
+func (s *Service) registerCancel(taskID string, cancel context.CancelFunc) {
+	s.cancelMutex.Lock()
+	defer s.cancelMutex.Unlock()
+	s.cancelFuncs[taskID] = cancel
+}
+
+// releaseCancel 任务结束后释放取消函数
+func (s *Service) releaseCancel(taskID string) {
+	s.cancelMutex.Lock()
+	cancel, exists := s.cancelFuncs[taskID]
+	delete(s.cancelFuncs, taskID)
+	s.cancelMutex.Unlock()
+
+	if exists {
+		cancel()
+	}
+}
+
+// cancelLocal 取消本副本上运行的任务，任务不在本副本时返回 false
+func (s *Service) cancelLocal(taskID string) bool {
+	s.cancelMutex.Lock()
+	cancel, exists := s.cancelFuncs[taskID]
+	s.cancelMutex.Unlock()
+
+	if exists {
+		cancel()
+	}
+	return exists
+}
+
+// CancelTask 取消运行中的批量任务
+// 正在处理的节点会执行完毕，尚未开始的节点不再处理
+func (s *Service) CancelTask(taskID string, userID uint) error {
+	if s.useDatabase && s.dbProgressService != nil {
+		// 先持久化取消请求，任务在其他副本上执行时由其心跳协程感知
+		if err := s.dbProgressService.RequestCancel(taskID, userID); err != nil {
+			return err
+		}
+		s.cancelLocal(taskID)
+		s.logger.Infof("Cancel requested for task %s by user %d", taskID, userID)
+		return nil
+	}
+
+	s.taskMutex.RLock()
+	task, exists := s.tasks[taskID]
+	s.taskMutex.RUnlock()
+	if !exists || task.UserID != userID {
+		return fmt.Errorf("task %s not found", taskID)
+	}
+	if !task.IsRunning || !s.cancelLocal(taskID) {
+		return fmt.Errorf("task %s is not running", taskID)
+	}
+
+	s.logger.Infof("Cancel requested for task %s by user %d", taskID, userID)
+	return nil
+}
+
+// ResumeTask 在当前副本上继续处理已取消或已中断任务的剩余节点
+func (s *Service) ResumeTask(taskID string, userID uint) error {
+	if !s.useDatabase || s.dbProgressService == nil {
+		return fmt.Errorf("resuming tasks requires database mode")
+	}
+
+	task, err := s.dbProgressService.GetTask(taskID, userID)
+	if err != nil {
+		return err
+	}
+	if task.Status != model.TaskStatusCancelled && task.Status != model.TaskStatusFailed {
+		return fmt.Errorf("task %s is %s, only cancelled or interrupted tasks can be resumed", taskID, task.Status)
+	}
+
+	pending := task.PendingNodeList()
+	if len(pending) == 0 {
+		return fmt.Errorf("task %s has no remaining nodes", taskID)
+	}
+
+	resumer, exists := s.getResumer(task.Action)
+	if !exists || task.Params == "" {
+		return fmt.Errorf("task %s (%s) does not support resume", taskID, task.Action)
+	}
+	processor, err := resumer(json.RawMessage(task.Params), task.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to restore task %s: %w", taskID, err)
+	}
+
+	if err := s.dbProgressService.claimForResume(task, len(pending)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.registerCancel(taskID, cancel)
+
+	go func() {
+		defer s.releaseCancel(taskID)
+		if err := s.dbProgressService.runBatch(ctx, task, pending, processor); err != nil {
+			s.logger.Warningf("Resumed task %s finished with error: %v", taskID, err)
+		}
+	}()
+
+	s.logger.Infof("Resumed task %s on replica %s with %d remaining nodes", taskID, replicaID, len(pending))
+	return nil
+}
+
+// GetTaskReport 获取批量任务的执行报告，包括未执行的节点
+func (s *Service) GetTaskReport(taskID string, userID uint) (*BatchTaskReport, error) {
+	if s.useDatabase && s.dbProgressService != nil {
+		task, err := s.dbProgressService.GetTask(taskID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		report := &BatchTaskReport{
+			TaskID:       task.TaskID,
+			Action:       task.Action,
+			Status:       task.Status,
+			Total:        task.Total,
+			SuccessNodes: []string{},
+			FailedNodes:  []model.NodeError{},
+			PendingNodes: task.PendingNodeList(),
+			Message:      task.Message,
+			Error:        task.ErrorMsg,
+			Owner:        task.Owner,
+			CreatedAt:    task.CreatedAt,
+			CompletedAt:  task.CompletedAt,
+		}
+		if task.SuccessNodes != "" {
+			json.Unmarshal([]byte(task.SuccessNodes), &report.SuccessNodes)
+		}
+		if task.FailedNodes != "" {
+			json.Unmarshal([]byte(task.FailedNodes), &report.FailedNodes)
+		}
+		if report.PendingNodes == nil {
+			report.PendingNodes = []string{}
+		}
+		_, hasResumer := s.getResumer(task.Action)
+		report.Resumable = hasResumer && task.Params != "" && len(report.PendingNodes) > 0 &&
+			(task.Status == model.TaskStatusCancelled || task.Status == model.TaskStatusFailed)
+		return report, nil
+	}
+
+	s.taskMutex.RLock()
+	defer s.taskMutex.RUnlock()
+	task, exists := s.tasks[taskID]
+	if !exists || task.UserID != userID {
+		return nil, fmt.Errorf("task %s not found", taskID)
+	}
+
+	status := model.TaskStatusRunning
+	if task.Completed {
+		status = model.TaskStatusCompleted
+	}
+	return &BatchTaskReport{
+		TaskID:       task.TaskID,
+		Action:       task.Action,
+		Status:       status,
+		Total:        task.Total,
+		SuccessNodes: append([]string{}, task.SuccessNodes...),
+		FailedNodes:  append([]model.NodeError{}, task.FailedNodes...),
+		PendingNodes: pendingNodes(task.NodePlan, task.SuccessNodes, task.FailedNodes),
+		Message:      fmt.Sprintf("正在处理 (%d/%d)", task.Current, task.Total),
+	}, nil
+}
+
+// handleClientMessage 处理客户端发送的控制消息
+func (s *Service) handleClientMessage(conn *Connection, data []byte) {
+	var msg clientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	switch msg.Type {
+	case "cancel":
+		if err := s.CancelTask(msg.TaskID, conn.userID); err != nil {
+			s.logger.Warningf("Failed to cancel task %s for user %d: %v", msg.TaskID, conn.userID, err)
+			s.sendToUser(conn.userID, ProgressMessage{
+				TaskID:    msg.TaskID,
+				UserID:    conn.userID,
+				Type:      "cancel_failed",
+				Error:     err.Error(),
+				Timestamp: time.Now(),
+			})
+		}
+	default:
+		s.logger.Warningf("Unknown client message type %q from user %d", msg.Type, conn.userID)
+	}
+}
+
+// cancelledTask 内存模式下标记任务已取消并推送结果
+func (s *Service) cancelledTask(taskID string, userID uint) {
+	s.taskMutex.Lock()
+	task, exists := s.tasks[taskID]
+	if exists {
+		task.IsRunning = false
+		delete(s.tasks, taskID)
+	}
+	s.taskMutex.Unlock()
+
+	if !exists {
+		return
+	}
+
+	pending := pendingNodes(task.NodePlan, task.SuccessNodes, task.FailedNodes)
+	message := ProgressMessage{
+		TaskID:       taskID,
+		UserID:       userID,
+		Type:         "cancelled",
+		Action:       task.Action,
+		Current:      task.Current,
+		Total:        task.Total,
+		Progress:     float64(task.Current) / float64(task.Total) * 100,
+		SuccessNodes: task.SuccessNodes,
+		FailedNodes:  task.FailedNodes,
+		PendingNodes: pending,
+		Message:      cancelledSummary(len(task.SuccessNodes), len(task.FailedNodes), len(pending)),
+		Timestamp:    time.Now(),
+	}
+
+	s.connMutex.RLock()
+	_, hasConnection := s.connections[userID]
+	s.connMutex.RUnlock()
+
+	if hasConnection {
+		s.sendToUser(userID, message)
+	} else {
+		s.queueCompletionMessage(userID, message)
+	}
+	s.logger.Infof("Task %s cancelled: %s", taskID, message.Message)
+}
+
+// pendingNodes 计算计划中既未成功也未失败的节点
+func pendingNodes(plan []string, successNodes []string, failedNodes []model.NodeError) []string {
+	done := make(map[string]bool, len(successNodes)+len(failedNodes))
+	for _, node := range successNodes {
+		done[node] = true
+	}
+	for _, nodeErr := range failedNodes {
+		done[nodeErr.NodeName] = true
+	}
+
+	pending := []string{}
+	for _, node := range plan {
+		if !done[node] {
+			pending = append(pending, node)
+		}
+	}
+	return pending
+}
+
+// cancelledSummary 生成取消任务的结果摘要
+func cancelledSummary(success, failed, pending int) string {
+	return fmt.Sprintf("批量操作已取消：%d个成功，%d个失败，%d个未执行", success, failed, pending)
+}
+
+// GetTask 获取用户的数据库任务
+func (dps *DatabaseProgressService) GetTask(taskID string, userID uint) (*model.ProgressTask, error) {
+	var task model.ProgressTask
+	if err := dps.db.Where("task_id = ? AND user_id = ?", taskID, userID).First(&task).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("task %s not found", taskID)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return &task, nil
+}
+
+// RequestCancel 持久化取消请求，由执行任务的副本在下次心跳时处理
+func (dps *DatabaseProgressService) RequestCancel(taskID string, userID uint) error {
+	result := dps.db.Model(&model.ProgressTask{}).
+		Where("task_id = ? AND user_id = ? AND status = ?", taskID, userID, model.TaskStatusRunning).
+		Update("cancel_requested", true)
+	if result.Error != nil {
+		return fmt.Errorf("failed to cancel task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		task, err := dps.GetTask(taskID, userID)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("task %s is not running (status: %s)", taskID, task.Status)
+	}
+	return nil
+}
+
+// CancelledTask 标记任务已取消，并推送包含未执行节点的结果
+func (dps *DatabaseProgressService) CancelledTask(taskID string, userID uint) error {
+	var task model.ProgressTask
+	if err := dps.db.Where("task_id = ?", taskID).First(&task).Error; err != nil {
+		dps.logger.Warningf("Task %s not found for cancel marking: %v", taskID, err)
+		return err
+	}
+
+	// 任务已被其他副本判定为中断并接管，不再覆盖其状态
+	if task.Status != model.TaskStatusRunning {
+		return fmt.Errorf("task %s is no longer running on this replica (status: %s)", taskID, task.Status)
+	}
+
+	var successNodes []string
+	var failedNodes []model.NodeError
+	if task.SuccessNodes != "" {
+		json.Unmarshal([]byte(task.SuccessNodes), &successNodes)
+	}
+	if task.FailedNodes != "" {
+		json.Unmarshal([]byte(task.FailedNodes), &failedNodes)
+	}
+	pending := task.PendingNodeList()
+
+	task.MarkCancelled()
+	task.Message = cancelledSummary(len(successNodes), len(failedNodes), len(pending))
+	if err := dps.db.Save(&task).Error; err != nil {
+		dps.logger.Errorf("Failed to mark task %s as cancelled: %v", taskID, err)
+		return err
+	}
+
+	dps.notifyTaskResult(&task, "cancelled", userID)
+	dps.logger.Infof("Task %s cancelled: %s", taskID, task.Message)
+	return fmt.Errorf("%s", task.Message)
+}
+
+// claimForResume 将任务重新标记为运行中并由当前副本接管，防止多个副本重复恢复
+func (dps *DatabaseProgressService) claimForResume(task *model.ProgressTask, remaining int) error {
+	now := time.Now()
+	result := dps.db.Model(&model.ProgressTask{}).
+		Where("task_id = ? AND status = ?", task.TaskID, task.Status).
+		Updates(map[string]interface{}{
+			"status":           model.TaskStatusRunning,
+			"owner":            replicaID,
+			"heartbeat_at":     now,
+			"cancel_requested": false,
+			"error_msg":        "",
+			"message":          fmt.Sprintf("任务已恢复，继续处理剩余 %d 个节点", remaining),
+			"completed_at":     nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to resume task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("task %s has already been resumed", task.TaskID)
+	}
+
+	task.Status = model.TaskStatusRunning
+	task.Owner = replicaID
+	task.HeartbeatAt = &now
+	task.CancelRequested = false
+	task.ErrorMsg = ""
+	task.CompletedAt = nil
+	return nil
+}
+
+// keepTaskAlive 定期续期任务心跳，并在收到取消请求或任务被其他副本接管时取消执行
+func (dps *DatabaseProgressService) keepTaskAlive(ctx context.Context, taskID string, cancel context.CancelFunc) {
+	ticker := time.NewTicker(batchHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := dps.db.Model(&model.ProgressTask{}).
+				Where("task_id = ?", taskID).
+				Update("heartbeat_at", time.Now()).Error; err != nil {
+				dps.logger.Warningf("Failed to update heartbeat for task %s: %v", taskID, err)
+				continue
+			}
+
+			var state model.ProgressTask
+			if err := dps.db.Select("status", "cancel_requested").
+				Where("task_id = ?", taskID).First(&state).Error; err != nil {
+				continue
+			}
+			if state.CancelRequested || state.Status != model.TaskStatusRunning {
+				dps.logger.Infof("Stopping task %s (cancel_requested=%v, status=%s)", taskID, state.CancelRequested, state.Status)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// startOrphanRecovery 定期检查执行副本已中断的任务
+func (dps *DatabaseProgressService) startOrphanRecovery() {
+	dps.pollingWg.Add(1)
+	defer dps.pollingWg.Done()
+
+	ticker := time.NewTicker(orphanCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-dps.stopPolling:
+			return
+		case <-ticker.C:
+			dps.recoverOrphanedTasks()
+		}
+	}
+}
+
+// recoverOrphanedTasks 将心跳超时的运行中任务标记为中断，并报告剩余节点
+func (dps *DatabaseProgressService) recoverOrphanedTasks() {
+	cutoff := time.Now().Add(-batchStaleTimeout)
+
+	var tasks []model.ProgressTask
+	if err := dps.db.Where("status = ? AND heartbeat_at IS NOT NULL AND heartbeat_at < ?", model.TaskStatusRunning, cutoff).
+		Find(&tasks).Error; err != nil {
+		dps.logger.Errorf("Failed to query orphaned tasks: %v", err)
+		return
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		pending := task.PendingNodeList()
+		errorMsg := fmt.Sprintf("执行副本 %s 已中断，%d个节点未执行", task.Owner, len(pending))
+
+		// 条件更新保证多个副本同时检查时只有一个副本接管
+		now := time.Now()
+		result := dps.db.Model(&model.ProgressTask{}).
+			Where("id = ? AND status = ? AND heartbeat_at < ?", task.ID, model.TaskStatusRunning, cutoff).
+			Updates(map[string]interface{}{
+				"status":       model.TaskStatusFailed,
+				"error_msg":    errorMsg,
+				"message":      "任务已中断，可恢复剩余节点",
+				"completed_at": now,
+			})
+		if result.Error != nil {
+			dps.logger.Errorf("Failed to mark orphaned task %s: %v", task.TaskID, result.Error)
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		task.Status = model.TaskStatusFailed
+		task.ErrorMsg = errorMsg
+		task.Message = "任务已中断，可恢复剩余节点"
+		task.CompletedAt = &now
+		dps.notifyTaskResult(task, "error", task.UserID)
+		dps.logger.Warningf("Task %s marked as interrupted: %s", task.TaskID, errorMsg)
+	}
+}
+
+// notifyTaskResult 保存任务结果消息并推送，消息包含未执行的节点
+func (dps *DatabaseProgressService) notifyTaskResult(task *model.ProgressTask, msgType string, userID uint) {
+	if err := dps.createProgressMessage(task, msgType); err != nil {
+		return
+	}
+	if dps.usePolling {
+		return
+	}
+
+	var successNodes []string
+	var failedNodes []model.NodeError
+	if task.SuccessNodes != "" {
+		json.Unmarshal([]byte(task.SuccessNodes), &successNodes)
+	}
+	if task.FailedNodes != "" {
+		json.Unmarshal([]byte(task.FailedNodes), &failedNodes)
+	}
+
+	progressMsg := ProgressMessage{
+		TaskID:       task.TaskID,
+		UserID:       userID,
+		Type:         msgType,
+		Action:       task.Action,
+		Current:      task.Current,
+		Total:        task.Total,
+		Progress:     task.Progress,
+		CurrentNode:  task.CurrentNode,
+		Message:      task.Message,
+		Error:        task.ErrorMsg,
+		Timestamp:    time.Now(),
+		SuccessNodes: successNodes,
+		FailedNodes:  failedNodes,
+		PendingNodes: task.PendingNodeList(),
+	}
+	if err := dps.notifier.Notify(context.Background(), progressMsg); err != nil {
+		dps.logger.Warningf("Failed to send %s notification for task %s: %v", msgType, task.TaskID, err)
+	}
+}
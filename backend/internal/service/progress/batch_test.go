@@ -0,0 +1,337 @@
+package progress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// recordingProcessor 记录处理过的节点，failNodes 中的节点返回错误
+type recordingProcessor struct {
+	mu        sync.Mutex
+	processed []string
+	failNodes map[string]bool
+	started   chan string   // 非空时每个节点开始处理时发送节点名
+	release   chan struct{} // 非空时等待关闭后才完成处理
+}
+
+func (p *recordingProcessor) ProcessNode(ctx context.Context, nodeName string, index int) error {
+	p.mu.Lock()
+	p.processed = append(p.processed, nodeName)
+	p.mu.Unlock()
+
+	if p.started != nil {
+		p.started <- nodeName
+	}
+	if p.release != nil {
+		<-p.release
+	}
+	if p.failNodes[nodeName] {
+		return fmt.Errorf("failed to process %s", nodeName)
+	}
+	return nil
+}
+
+func (p *recordingProcessor) nodes() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	nodes := append([]string{}, p.processed...)
+	sort.Strings(nodes)
+	return nodes
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.ProgressTask{}, &model.ProgressMessage{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// newDatabaseService 创建数据库模式的进度服务（使用轮询通知，不启动后台协程）
+func newDatabaseService(db *gorm.DB) *Service {
+	s := NewService(logger.NewLogger())
+	s.dbProgressService = &DatabaseProgressService{
+		db:          db,
+		logger:      s.logger,
+		wsService:   s,
+		stopPolling: make(chan struct{}),
+		usePolling:  true,
+	}
+	s.useDatabase = true
+	return s
+}
+
+func getTask(t *testing.T, db *gorm.DB, taskID string) *model.ProgressTask {
+	t.Helper()
+	var task model.ProgressTask
+	if err := db.Where("task_id = ?", taskID).First(&task).Error; err != nil {
+		t.Fatalf("get task %s: %v", taskID, err)
+	}
+	return &task
+}
+
+func taskResults(t *testing.T, task *model.ProgressTask) ([]string, []model.NodeError) {
+	t.Helper()
+	var success []string
+	var failed []model.NodeError
+	if task.SuccessNodes != "" {
+		if err := json.Unmarshal([]byte(task.SuccessNodes), &success); err != nil {
+			t.Fatalf("unmarshal success nodes: %v", err)
+		}
+	}
+	if task.FailedNodes != "" {
+		if err := json.Unmarshal([]byte(task.FailedNodes), &failed); err != nil {
+			t.Fatalf("unmarshal failed nodes: %v", err)
+		}
+	}
+	sort.Strings(success)
+	return success, failed
+}
+
+// TestCancelStopsPendingNodes 测试取消后正在处理的节点执行完毕，尚未开始的节点不再处理
+func TestCancelStopsPendingNodes(t *testing.T) {
+	db := newTestDB(t)
+	s := newDatabaseService(db)
+
+	processor := &recordingProcessor{
+		started: make(chan string, 4),
+		release: make(chan struct{}),
+	}
+	nodes := []string{"node-1", "node-2", "node-3", "node-4"}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.ProcessResumableBatch(context.Background(), "task-cancel", "batch_label", nodes, 1, 1, processor, map[string]string{"key": "value"})
+	}()
+
+	// 并发数为 1，取消时只有一个节点在处理
+	first := <-processor.started
+	if err := s.CancelTask("task-cancel", 1); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	close(processor.release)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected cancelled batch to return an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch did not stop after cancel")
+	}
+
+	if got := processor.nodes(); !reflect.DeepEqual(got, []string{first}) {
+		t.Errorf("processed nodes = %v, want only %s", got, first)
+	}
+
+	task := getTask(t, db, "task-cancel")
+	if task.Status != model.TaskStatusCancelled {
+		t.Errorf("status = %s, want cancelled", task.Status)
+	}
+	if !task.CancelRequested {
+		t.Error("cancel request should be persisted")
+	}
+	success, failed := taskResults(t, task)
+	if !reflect.DeepEqual(success, []string{first}) || len(failed) != 0 {
+		t.Errorf("persisted results = %v / %v, want %s succeeded", success, failed, first)
+	}
+	wantPending := make([]string, 0, len(nodes)-1)
+	for _, node := range nodes {
+		if node != first {
+			wantPending = append(wantPending, node)
+		}
+	}
+	if pending := task.PendingNodeList(); !reflect.DeepEqual(pending, wantPending) {
+		t.Errorf("pending nodes = %v, want %v", pending, wantPending)
+	}
+
+	if err := s.CancelTask("task-cancel", 1); err == nil {
+		t.Error("cancelling a finished task should fail")
+	}
+}
+
+// TestPersistNodeResults 测试每个节点的成功/失败结果都保存在任务记录中
+func TestPersistNodeResults(t *testing.T) {
+	db := newTestDB(t)
+	s := newDatabaseService(db)
+
+	processor := &recordingProcessor{failNodes: map[string]bool{"node-2": true}}
+	nodes := []string{"node-1", "node-2", "node-3"}
+
+	if err := s.ProcessResumableBatch(context.Background(), "task-results", "batch_label", nodes, 1, 2, processor, nil); err == nil {
+		t.Fatal("expected error for partially failed batch")
+	}
+
+	task := getTask(t, db, "task-results")
+	if task.Status != model.TaskStatusFailed {
+		t.Errorf("status = %s, want failed", task.Status)
+	}
+	success, failed := taskResults(t, task)
+	if !reflect.DeepEqual(success, []string{"node-1", "node-3"}) {
+		t.Errorf("success nodes = %v", success)
+	}
+	if len(failed) != 1 || failed[0].NodeName != "node-2" || failed[0].Error != "failed to process node-2" {
+		t.Errorf("failed nodes = %v", failed)
+	}
+	if pending := task.PendingNodeList(); len(pending) != 0 {
+		t.Errorf("pending nodes = %v, want none", pending)
+	}
+	if task.Params != "" {
+		t.Errorf("params = %q, want empty for batch without params", task.Params)
+	}
+}
+
+// TestResumeTask 测试恢复任务只处理剩余节点，并保留已有结果
+func TestResumeTask(t *testing.T) {
+	db := newTestDB(t)
+	s := newDatabaseService(db)
+
+	task := &model.ProgressTask{
+		TaskID:       "task-resume",
+		UserID:       1,
+		Action:       "batch_label",
+		Status:       model.TaskStatusCancelled,
+		Total:        4,
+		NodePlan:     `["node-1","node-2","node-3","node-4"]`,
+		SuccessNodes: `["node-1"]`,
+		FailedNodes:  `[{"node_name":"node-2","error":"boom"}]`,
+		Params:       `{"key":"value"}`,
+		Concurrency:  2,
+	}
+	if err := db.Create(task).Error; err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	processor := &recordingProcessor{}
+	var restoredParams string
+	s.RegisterResumer("batch_label", func(params json.RawMessage, userID uint) (BatchProcessor, error) {
+		restoredParams = string(params)
+		return processor, nil
+	})
+
+	if err := s.ResumeTask("task-resume", 2); err == nil {
+		t.Error("resuming another user's task should fail")
+	}
+	if err := s.ResumeTask("task-resume", 1); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if err := s.ResumeTask("task-resume", 1); err == nil {
+		t.Error("resuming a running task should fail")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for getTask(t, db, "task-resume").Status == model.TaskStatusRunning {
+		if time.Now().After(deadline) {
+			t.Fatal("resumed task did not finish")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if restoredParams != `{"key":"value"}` {
+		t.Errorf("resumer params = %s", restoredParams)
+	}
+	if got := processor.nodes(); !reflect.DeepEqual(got, []string{"node-3", "node-4"}) {
+		t.Errorf("processed nodes = %v, want only the pending nodes", got)
+	}
+
+	resumed := getTask(t, db, "task-resume")
+	success, failed := taskResults(t, resumed)
+	if !reflect.DeepEqual(success, []string{"node-1", "node-3", "node-4"}) {
+		t.Errorf("success nodes = %v", success)
+	}
+	if len(failed) != 1 || failed[0].NodeName != "node-2" {
+		t.Errorf("failed nodes = %v, want the earlier failure kept", failed)
+	}
+	if resumed.Owner != replicaID {
+		t.Errorf("owner = %s, want %s", resumed.Owner, replicaID)
+	}
+}
+
+// TestOrphanClaimSingleWinner 测试多个副本同时处理中断任务时只有一个副本成功
+func TestOrphanClaimSingleWinner(t *testing.T) {
+	db := newTestDB(t)
+	replicas := []*DatabaseProgressService{
+		newDatabaseService(db).dbProgressService,
+		newDatabaseService(db).dbProgressService,
+		newDatabaseService(db).dbProgressService,
+	}
+
+	stale := time.Now().Add(-2 * batchStaleTimeout)
+	fresh := time.Now()
+	tasks := []*model.ProgressTask{
+		{TaskID: "task-orphan", UserID: 1, Action: "batch_label", Status: model.TaskStatusRunning, Total: 2,
+			NodePlan: `["node-1","node-2"]`, SuccessNodes: `["node-1"]`, Owner: "dead-replica", HeartbeatAt: &stale},
+		{TaskID: "task-alive", UserID: 1, Action: "batch_label", Status: model.TaskStatusRunning, Total: 1,
+			NodePlan: `["node-1"]`, Owner: "live-replica", HeartbeatAt: &fresh},
+	}
+	for _, task := range tasks {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, replica := range replicas {
+		wg.Add(1)
+		go func(dps *DatabaseProgressService) {
+			defer wg.Done()
+			dps.recoverOrphanedTasks()
+		}(replica)
+	}
+	wg.Wait()
+
+	var messages int64
+	db.Model(&model.ProgressMessage{}).Where("task_id = ? AND type = ?", "task-orphan", "error").Count(&messages)
+	if messages != 1 {
+		t.Errorf("orphaned task reported %d times, want once", messages)
+	}
+	orphan := getTask(t, db, "task-orphan")
+	if orphan.Status != model.TaskStatusFailed {
+		t.Errorf("orphan status = %s, want failed", orphan.Status)
+	}
+	if alive := getTask(t, db, "task-alive"); alive.Status != model.TaskStatusRunning {
+		t.Errorf("task with fresh heartbeat should keep running, got %s", alive.Status)
+	}
+
+	// 多个副本读取到同一个中断任务后同时恢复
+	var mu sync.Mutex
+	winners := 0
+	for _, replica := range replicas {
+		task := *orphan
+		wg.Add(1)
+		go func(dps *DatabaseProgressService, task *model.ProgressTask) {
+			defer wg.Done()
+			if err := dps.claimForResume(task, 1); err == nil {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(replica, &task)
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Errorf("%d replicas claimed the task, want exactly one", winners)
+	}
+	if claimed := getTask(t, db, "task-orphan"); claimed.Status != model.TaskStatusRunning {
+		t.Errorf("claimed task status = %s, want running", claimed.Status)
+	}
+}
@@ -90,6 +90,9 @@ func NewDatabaseProgressService(db *gorm.DB, dbConfig *config.DatabaseConfig, lo
 		go dps.startFallbackPolling()
 	}
 
+	// 检查执行副本已中断的批量任务
+	go dps.startOrphanRecovery()
+
 	return dps
 }
 
@@ -144,7 +147,8 @@ func (dps *DatabaseProgressService) UpdateNodeLists(taskID string, successNodes
 		}
 	}
 
-	if err := dps.db.Save(&task).Error; err != nil {
+	// 只更新节点列表，避免覆盖其他副本写入的取消标记和心跳
+	if err := dps.db.Model(&task).Select("success_nodes", "failed_nodes", "updated_at").Updates(&task).Error; err != nil {
 		dps.logger.Errorf("Failed to save task %s with updated node lists: %v", taskID, err)
 		return err
 	}
@@ -165,7 +169,7 @@ func (dps *DatabaseProgressService) UpdateProgress(taskID string, current int, c
 	task.UpdateProgress(current, currentNode)
 	task.Message = fmt.Sprintf("正在处理节点 %s (%d/%d)", currentNode, current, task.Total)
 
-	if err := dps.db.Save(&task).Error; err != nil {
+	if err := dps.db.Model(&task).Select("current", "current_node", "progress", "message", "updated_at").Updates(&task).Error; err != nil {
 		dps.logger.Errorf("Failed to update task %s progress: %v", taskID, err)
 		return err
 	}
@@ -396,6 +400,12 @@ func (dps *DatabaseProgressService) createProgressMessage(task *model.ProgressTa
 		Message:      task.Message,
 		ErrorMsg:     task.ErrorMsg,
 	}
+	if msgType == "error" || msgType == "cancelled" {
+		if pending := task.PendingNodeList(); len(pending) > 0 {
+			pendingJSON, _ := json.Marshal(pending)
+			msg.PendingNodes = string(pendingJSON)
+		}
+	}
 
 	if err := dps.db.Create(msg).Error; err != nil {
 		dps.logger.Errorf("Failed to create progress message for task %s: %v", task.TaskID, err)
@@ -403,7 +413,7 @@ func (dps *DatabaseProgressService) createProgressMessage(task *model.ProgressTa
 	}
 
 	// 只记录完成和错误消息，避免进度消息日志噪音
-	if msgType == "complete" || msgType == "error" {
+	if msgType == "complete" || msgType == "error" || msgType == "cancelled" {
 		dps.logger.Infof("Created %s message for task %s, user %d", msgType, task.TaskID, task.UserID)
 	}
 	return nil
@@ -435,7 +445,7 @@ func (dps *DatabaseProgressService) processUnsentMessages() {
 
 	// 优先处理完成和错误消息，然后处理普通进度消息
 	query := dps.db.Where("processed = ? AND created_at > ?", false, dps.lastProcessedTime).
-		Order("CASE WHEN type IN ('complete', 'error') THEN 0 ELSE 1 END, created_at ASC").
+		Order("CASE WHEN type IN ('complete', 'error', 'cancelled') THEN 0 ELSE 1 END, created_at ASC").
 		Limit(100) // 限制批次大小
 
 	if err := query.Find(&messages).Error; err != nil {
@@ -472,6 +482,11 @@ func (dps *DatabaseProgressService) processUnsentMessages() {
 			json.Unmarshal([]byte(msg.FailedNodes), &failedNodes)
 		}
 		
+		var pendingNodes []string
+		if msg.PendingNodes != "" {
+			json.Unmarshal([]byte(msg.PendingNodes), &pendingNodes)
+		}
+
 		// 转换为WebSocket消息格式
 		wsMessage := ProgressMessage{
 			TaskID:       msg.TaskID,
@@ -483,6 +498,7 @@ func (dps *DatabaseProgressService) processUnsentMessages() {
 			CurrentNode:  msg.CurrentNode,
 			SuccessNodes: successNodes,
 			FailedNodes:  failedNodes,
+			PendingNodes: pendingNodes,
 			Message:      msg.Message,
 			Error:        msg.ErrorMsg,
 			Timestamp:    msg.CreatedAt,
@@ -503,7 +519,7 @@ func (dps *DatabaseProgressService) processUnsentMessages() {
 			if msg.Type == "complete" {
 				dps.logger.Infof("Sent completion message for task %s to user %d", msg.TaskID, msg.UserID)
 			}
-		} else if msg.Type == "complete" || msg.Type == "error" {
+		} else if msg.Type == "complete" || msg.Type == "error" || msg.Type == "cancelled" {
 			// 没有连接但是重要消息，等待一下再重试
 			time.Sleep(100 * time.Millisecond)
 			// 再次检查连接
@@ -661,7 +677,7 @@ func (dps *DatabaseProgressService) startFallbackPolling() {
 			dps.logger.Info("Fallback polling stopped")
 			return
 		case <-ticker.C:
-			// 只处理重要消息（complete, error）
+			// 只处理重要消息（complete, error, cancelled）
 			dps.processFallbackMessages()
 		}
 	}
@@ -675,7 +691,7 @@ func (dps *DatabaseProgressService) processFallbackMessages() {
 	cutoff := time.Now().Add(-30 * time.Second) // 只处理最近 30 秒的消息
 	query := dps.db.Where("processed = ? AND type IN (?) AND created_at > ?", 
 		false, 
-		[]string{"complete", "error"}, 
+		[]string{"complete", "error", "cancelled"}, 
 		cutoff,
 	).Order("created_at ASC").Limit(50)
 	
@@ -701,6 +717,10 @@ func (dps *DatabaseProgressService) processFallbackMessages() {
 		if msg.FailedNodes != "" {
 			json.Unmarshal([]byte(msg.FailedNodes), &failedNodes)
 		}
+		var pendingNodes []string
+		if msg.PendingNodes != "" {
+			json.Unmarshal([]byte(msg.PendingNodes), &pendingNodes)
+		}
 		
 		wsMessage := ProgressMessage{
 			TaskID:       msg.TaskID,
@@ -712,6 +732,7 @@ func (dps *DatabaseProgressService) processFallbackMessages() {
 			CurrentNode:  msg.CurrentNode,
 			SuccessNodes: successNodes,
 			FailedNodes:  failedNodes,
+			PendingNodes: pendingNodes,
 			Message:      msg.Message,
 			Error:        msg.ErrorMsg,
 			Timestamp:    msg.CreatedAt,
@@ -725,6 +746,7 @@ func (dps *DatabaseProgressService) processFallbackMessages() {
 }
 
 // ProcessBatchWithProgress 带数据库持久化的批量处理
+// params 为重建处理器所需的请求参数(JSON)，为空时任务中断后无法恢复
 func (dps *DatabaseProgressService) ProcessBatchWithProgress(
 	ctx context.Context,
 	taskID string,
@@ -733,13 +755,58 @@ func (dps *DatabaseProgressService) ProcessBatchWithProgress(
 	userID uint,
 	maxConcurrency int,
 	processor BatchProcessor,
+	params string,
 ) error {
 	total := len(nodeNames)
+	planJSON, err := json.Marshal(nodeNames)
+	if err != nil {
+		return fmt.Errorf("failed to marshal node plan: %w", err)
+	}
 
-	// 创建数据库任务
-	if err := dps.CreateTask(taskID, action, total, userID); err != nil {
+	// 创建数据库任务，同时持久化批量计划
+	now := time.Now()
+	task := &model.ProgressTask{
+		TaskID:      taskID,
+		UserID:      userID,
+		Action:      action,
+		Status:      model.TaskStatusRunning,
+		Current:     0,
+		Total:       total,
+		Progress:    0,
+		Message:     "任务已创建，准备开始处理",
+		NodePlan:    string(planJSON),
+		Params:      params,
+		Concurrency: maxConcurrency,
+		Owner:       replicaID,
+		HeartbeatAt: &now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := dps.db.Create(task).Error; err != nil {
+		dps.logger.Errorf("Failed to create task %s: %v", taskID, err)
 		return err
 	}
+	dps.logger.Infof("Created database task %s with %d total items for user %d", taskID, total, userID)
+
+	return dps.runBatch(ctx, task, nodeNames, processor)
+}
+
+// runBatch 处理任务计划中的指定节点
+// 新任务传入全部节点；恢复任务只传入剩余节点，已有的成功/失败结果保留在 task 中
+func (dps *DatabaseProgressService) runBatch(ctx context.Context, task *model.ProgressTask, nodeNames []string, processor BatchProcessor) error {
+	taskID := task.TaskID
+	userID := task.UserID
+	total := task.Total
+
+	maxConcurrency := task.Concurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultResumeConcurrency
+	}
+
+	// 心跳协程负责续期并感知其他副本发起的取消请求
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go dps.keepTaskAlive(ctx, taskID, cancel)
 
 	// 使用信号量控制并发
 	semaphore := make(chan struct{}, maxConcurrency)
@@ -747,7 +814,13 @@ func (dps *DatabaseProgressService) ProcessBatchWithProgress(
 	var mu sync.Mutex
 	var failedNodes []model.NodeError
 	var successNodes []string
-	completed := 0 // 已完成的节点数（成功或失败）
+	if task.SuccessNodes != "" {
+		json.Unmarshal([]byte(task.SuccessNodes), &successNodes)
+	}
+	if task.FailedNodes != "" {
+		json.Unmarshal([]byte(task.FailedNodes), &failedNodes)
+	}
+	completed := len(successNodes) + len(failedNodes) // 已完成的节点数（成功或失败）
 
 	for i, nodeName := range nodeNames {
 		wg.Add(1)
@@ -770,9 +843,16 @@ func (dps *DatabaseProgressService) ProcessBatchWithProgress(
 				wg.Done()
 			}()
 
-			// 获取信号量
-			semaphore <- struct{}{}
+			// 获取信号量，任务取消后尚未开始的节点保持未执行状态
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-semaphore }()
+			if ctx.Err() != nil {
+				return
+			}
 
 			// 处理节点
 			err := processor.ProcessNode(ctx, node, index)
@@ -817,6 +897,11 @@ func (dps *DatabaseProgressService) ProcessBatchWithProgress(
 	dps.logger.Infof("Task %s completed: processed=%d, success=%d, failed=%d", 
 		taskID, finalCompleted, finalSuccess, finalFailed)
 
+	// 任务被取消，剩余节点未执行
+	if ctx.Err() != nil && finalCompleted < total {
+		return dps.CancelledTask(taskID, userID)
+	}
+
 	// 确保最后一次进度更新显示 100%
 	if len(failedNodes) == 0 {
 		dps.UpdateProgress(taskID, total, "完成", userID)
@@ -28,7 +28,7 @@ var upgrader = websocket.Upgrader{
 type ProgressMessage struct {
 	TaskID       string              `json:"task_id"`
 	UserID       uint                `json:"user_id"`         // 用户ID（用于通知路由）
-	Type         string              `json:"type"`            // progress, complete, error
+	Type         string              `json:"type"`            // progress, complete, error, cancelled
 	Action       string              `json:"action"`          // batch_label, batch_taint
 	Current      int                 `json:"current"`         // 当前完成数量
 	Total        int                 `json:"total"`           // 总数量
@@ -36,6 +36,7 @@ type ProgressMessage struct {
 	CurrentNode  string              `json:"current_node"`    // 当前处理的节点
 	SuccessNodes []string            `json:"success_nodes"`   // 成功节点列表
 	FailedNodes  []model.NodeError   `json:"failed_nodes"`    // 失败节点列表
+	PendingNodes []string            `json:"pending_nodes,omitempty"` // 未执行节点列表（取消或中断时）
 	Message      string              `json:"message"`         // 消息内容
 	Error        string              `json:"error,omitempty"` // 错误信息
 	Timestamp    time.Time           `json:"timestamp"`
@@ -54,20 +55,23 @@ type TaskProgress struct {
 	UserID          uint
 	SuccessNodes    []string            // 成功节点列表
 	FailedNodes     []model.NodeError   // 失败节点列表
+	NodePlan        []string            // 计划处理的全部节点
 	PendingMessages []ProgressMessage   // 待发送的消息队列
 }
 
 // Connection WebSocket连接
 type Connection struct {
-	ws       *websocket.Conn
-	send     chan ProgressMessage
-	userID   uint
-	lastSeen time.Time // 添加最后活跃时间
+	ws        *websocket.Conn
+	send      chan ProgressMessage
+	userID    uint
+	sessionID string    // 建立连接时令牌所属的会话
+	lastSeen  time.Time // 添加最后活跃时间
 }
 
 // TokenValidator JWT token验证接口
 type TokenValidator interface {
 	ValidateToken(tokenString string) (*auth.Claims, error)
+	IsSessionActive(sessionID string) bool
 }
 
 // Service 进度推送服务
@@ -88,6 +92,12 @@ type Service struct {
 	// 数据库进度服务（用于多副本环境）
 	dbProgressService *DatabaseProgressService
 	useDatabase       bool
+	// 本副本上运行中批量任务的取消函数 map[taskID]cancel
+	cancelFuncs map[string]context.CancelFunc
+	cancelMutex sync.Mutex
+	// 按操作类型注册的任务恢复器
+	resumers     map[string]BatchResumer
+	resumerMutex sync.RWMutex
 }
 
 // NewService 创建进度推送服务
@@ -96,6 +106,8 @@ func NewService(logger *logger.Logger) *Service {
 		connections:    make(map[uint]map[*Connection]bool),
 		tasks:          make(map[string]*TaskProgress),
 		completedTasks: make(map[uint][]ProgressMessage),
+		cancelFuncs:    make(map[string]context.CancelFunc),
+		resumers:       make(map[string]BatchResumer),
 		logger:         logger,
 		useDatabase:    false, // 默认使用内存模式
 	}
@@ -168,10 +180,11 @@ func (s *Service) HandleWebSocket(c *gin.Context) {
 
 	// 创建连接
 	conn := &Connection{
-		ws:       ws,
-		send:     make(chan ProgressMessage, 512), // 增加缓冲区大小
-		userID:   userID,
-		lastSeen: time.Now(),
+		ws:        ws,
+		send:      make(chan ProgressMessage, 512), // 增加缓冲区大小
+		userID:    userID,
+		sessionID: claims.SessionID,
+		lastSeen:  time.Now(),
 	}
 
 	// 注册连接
@@ -317,6 +330,7 @@ func (s *Service) readPump(conn *Connection) {
 			// 减少日志噪音，只记录重要消息
 			if len(message) > 0 && string(message) != "ping" {
 				s.logger.Infof("Received text message from user %d: %s", conn.userID, string(message))
+				s.handleClientMessage(conn, message)
 			}
 		case websocket.BinaryMessage:
 			s.logger.Infof("Received binary message from user %d", conn.userID)
@@ -597,14 +611,14 @@ func (s *Service) ErrorTask(taskID string, err error, userID uint) {
 	s.sendToUser(userID, message)
 }
 
-// cleanupStaleConnections 定期清理不活跃的连接
+// cleanupStaleConnections 定期清理不活跃的连接以及会话已被撤销的连接
 func (s *Service) cleanupStaleConnections() {
-	ticker := time.NewTicker(60 * time.Second) // 每60秒检查一次
+	ticker := time.NewTicker(30 * time.Second) // 每30秒检查一次
 	defer ticker.Stop()
 
 	for range ticker.C {
 		now := time.Now()
-		var staleConns []*Connection
+		var staleConns, revokedConns []*Connection
 
 		s.connMutex.RLock()
 		for _, userConns := range s.connections {
@@ -612,11 +626,25 @@ func (s *Service) cleanupStaleConnections() {
 				// 如果连接超过2分钟没有活动，标记为过期
 				if now.Sub(conn.lastSeen) > 2*time.Minute {
 					staleConns = append(staleConns, conn)
+				} else {
+					revokedConns = append(revokedConns, conn)
 				}
 			}
 		}
 		s.connMutex.RUnlock()
 
+		// 会话检查可能访问数据库，放在锁外进行
+		if s.authService != nil {
+			for _, conn := range revokedConns {
+				if s.authService.IsSessionActive(conn.sessionID) {
+					continue
+				}
+				s.logger.Warningf("Closing progress connection for user %d: session %s revoked", conn.userID, conn.sessionID)
+				s.removeConnection(conn)
+				conn.ws.Close()
+			}
+		}
+
 		// 清理过期连接
 		for _, conn := range staleConns {
 			s.logger.Warningf("Cleaning up stale connection for user %d", conn.userID)
@@ -715,6 +743,7 @@ func (s *Service) sendCurrentTaskStatus(userID uint) {
 		statuses := []model.TaskStatus{
 			model.TaskStatusCompleted, // 优先发送已完成的任务
 			model.TaskStatusFailed,    // 其次是失败的
+			model.TaskStatusCancelled, // 已取消的
 			model.TaskStatusRunning,   // 最后是运行中的
 		}
 
@@ -759,6 +788,10 @@ func (s *Service) sendCurrentTaskStatus(userID uint) {
 					msgType = "complete"
 					progress = 100
 					message = fmt.Sprintf("批量操作完成，共处理 %d 个节点", task.Total)
+				} else if task.Status == model.TaskStatusCancelled {
+					msgType = "cancelled"
+					progress = task.Progress
+					message = task.Message
 				} else {
 					msgType = "error"
 					progress = task.Progress
@@ -766,7 +799,7 @@ func (s *Service) sendCurrentTaskStatus(userID uint) {
 				}
 
 				// 对于完成/失败状态，延长过期时间到5分钟，确保用户有足够时间看到结果
-				if (msgType == "complete" || msgType == "error") && task.CompletedAt != nil {
+				if msgType != "progress" && task.CompletedAt != nil {
 					if time.Since(*task.CompletedAt) > 5*time.Minute {
 						continue
 					}
@@ -797,6 +830,9 @@ func (s *Service) sendCurrentTaskStatus(userID uint) {
 					FailedNodes:  failedNodes,
 					Timestamp:    task.UpdatedAt,
 				}
+				if msgType != "progress" && msgType != "complete" {
+					progressMessage.PendingNodes = task.PendingNodeList()
+				}
 
 				s.sendToUser(userID, progressMessage)
 				s.logger.Infof("Sent recovery DB task status for %s to user %d: %s (%.1f%%)", task.TaskID, userID, msgType, progress)
@@ -835,16 +871,28 @@ func (s *Service) ProcessBatchWithProgress(
 	maxConcurrency int,
 	processor BatchProcessor,
 ) error {
-	// 如果启用了数据库模式，使用数据库进度服务
-	if s.useDatabase && s.dbProgressService != nil {
-		return s.dbProgressService.ProcessBatchWithProgress(ctx, taskID, action, nodeNames, userID, maxConcurrency, processor)
-	}
+	return s.ProcessResumableBatch(ctx, taskID, action, nodeNames, userID, maxConcurrency, processor, nil)
+}
 
-	// 否则使用原有的内存模式
+// processBatchInMemory 内存模式的批量处理，ctx 取消后尚未开始的节点不再处理
+func (s *Service) processBatchInMemory(
+	ctx context.Context,
+	taskID string,
+	action string,
+	nodeNames []string,
+	userID uint,
+	maxConcurrency int,
+	processor BatchProcessor,
+) error {
 	total := len(nodeNames)
 
 	// 创建任务
 	s.CreateTask(taskID, action, total, userID)
+	s.taskMutex.Lock()
+	if task, exists := s.tasks[taskID]; exists {
+		task.NodePlan = nodeNames
+	}
+	s.taskMutex.Unlock()
 
 	// 使用信号量控制并发
 	semaphore := make(chan struct{}, maxConcurrency)
@@ -859,9 +907,16 @@ func (s *Service) ProcessBatchWithProgress(
 		go func(index int, node string) {
 			defer wg.Done()
 
-			// 获取信号量
-			semaphore <- struct{}{}
+			// 获取信号量，任务取消后尚未开始的节点保持未执行状态
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
 			defer func() { <-semaphore }()
+			if ctx.Err() != nil {
+				return
+			}
 
 			// 先获取当前索引用于日志
 			mu.Lock()
@@ -912,6 +967,13 @@ func (s *Service) ProcessBatchWithProgress(
 	s.logger.Infof("All nodes processed for task %s, processed=%d, success=%d, failed=%d", 
 		taskID, processed, len(successNodes), len(failedNodes))
 
+	// 任务被取消，剩余节点未执行
+	if ctx.Err() != nil && len(successNodes)+len(failedNodes) < total {
+		pending := total - len(successNodes) - len(failedNodes)
+		s.cancelledTask(taskID, userID)
+		return fmt.Errorf("%s", cancelledSummary(len(successNodes), len(failedNodes), pending))
+	}
+
 	// 确保最后一次进度更新显示 100%
 	if len(failedNodes) == 0 {
 		s.logger.Infof("Sending final 100%% progress update for task %s", taskID)
@@ -12,16 +12,23 @@ import (
 	"kube-node-manager/internal/service/anomaly"
 	"kube-node-manager/internal/service/audit"
 	"kube-node-manager/internal/service/auth"
+	"kube-node-manager/internal/service/capacity"
 	"kube-node-manager/internal/service/cluster"
 	"kube-node-manager/internal/service/feishu"
 	"kube-node-manager/internal/service/gitlab"
 	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/internal/service/keyrotation"
 	"kube-node-manager/internal/service/label"
 	"kube-node-manager/internal/service/ldap"
 	"kube-node-manager/internal/service/node"
+	"kube-node-manager/internal/service/nodechange"
+	"kube-node-manager/internal/service/nodeevent"
+	"kube-node-manager/internal/service/nodemetrics"
 	"kube-node-manager/internal/service/progress"
+	"kube-node-manager/internal/service/sshca"
 	"kube-node-manager/internal/service/sshkey"
 	"kube-node-manager/internal/service/taint"
+	"kube-node-manager/internal/service/timeline"
 	"kube-node-manager/internal/service/user"
 	"kube-node-manager/internal/websocket"
 	"kube-node-manager/pkg/logger"
@@ -43,8 +50,15 @@ type Services struct {
 	Gitlab        *gitlab.Service
 	Feishu        *feishu.Service
 	Anomaly       *anomaly.Service
+	NodeMetrics   *nodemetrics.Service // 节点资源指标历史服务
+	NodeEvents    *nodeevent.Service   // 节点事件历史服务
+	Capacity      *capacity.Service    // 容量规划服务
+	Timeline      *timeline.Service    // 节点生命周期时间线服务
+	NodeChanges   *nodechange.Service  // 节点变更记录与撤销服务
 	Ansible       *ansible.Service    // Ansible 任务服务
 	SSHKey        *sshkey.Service     // 系统级 SSH 密钥服务
+	SSHCA         *sshca.Service      // SSH 证书颁发服务
+	KeyRotation   *keyrotation.Service // SSH 密钥轮换服务
 	Realtime      *realtime.Manager   // 实时同步管理器
 	WSHub         *websocket.Hub      // WebSocket Hub（导出供 handler 使用）
 }
@@ -99,6 +113,22 @@ func (a *nodeServiceAdapter) Uncordon(req interface{}, userID uint) error {
 	return a.svc.Uncordon(uncordonReq, userID)
 }
 
+func (a *nodeServiceAdapter) DrainPreflight(req interface{}, userID uint) (interface{}, error) {
+	preflightReq, ok := req.(node.DrainPreflightRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid request type")
+	}
+	return a.svc.DrainPreflight(preflightReq, userID)
+}
+
+func (a *nodeServiceAdapter) Drain(req interface{}, userID uint) error {
+	drainReq, ok := req.(node.DrainRequest)
+	if !ok {
+		return fmt.Errorf("invalid request type")
+	}
+	return a.svc.Drain(drainReq, userID)
+}
+
 // auditServiceAdapter 适配器，将 audit.Service 适配为 feishu.AuditServiceInterface
 type auditServiceAdapter struct {
 	svc *audit.Service
@@ -168,7 +198,42 @@ func (a *anomalyServiceAdapter) GetActiveAnomalies(clusterID *uint) (interface{}
 }
 
 func NewServices(db *gorm.DB, logger *logger.Logger, cfg *config.Config) *Services {
-	auditSvc := audit.NewService(db, logger)
+	// 创建审计日志服务（哈希链、保留归档、导出到外部系统）
+	auditConfig := &audit.Config{
+		Retention: audit.RetentionConfig{
+			Enabled:       cfg.Audit.Retention.Enabled,
+			RetentionDays: cfg.Audit.Retention.RetentionDays,
+			CleanupTime:   cfg.Audit.Retention.CleanupTime,
+			BatchSize:     cfg.Audit.Retention.BatchSize,
+			Archive:       cfg.Audit.Retention.Archive,
+			ArchiveDir:    cfg.Audit.Retention.ArchiveDir,
+		},
+		Export: audit.ExportConfig{
+			QueueSize:     cfg.Audit.Export.QueueSize,
+			BatchSize:     cfg.Audit.Export.BatchSize,
+			FlushInterval: time.Duration(cfg.Audit.Export.FlushInterval) * time.Second,
+			Syslog: audit.SyslogConfig{
+				Enabled:  cfg.Audit.Export.Syslog.Enabled,
+				Network:  cfg.Audit.Export.Syslog.Network,
+				Address:  cfg.Audit.Export.Syslog.Address,
+				Facility: cfg.Audit.Export.Syslog.Facility,
+				AppName:  cfg.Audit.Export.Syslog.AppName,
+			},
+			File: audit.FileSinkConfig{
+				Enabled:    cfg.Audit.Export.File.Enabled,
+				Path:       cfg.Audit.Export.File.Path,
+				MaxSizeMB:  cfg.Audit.Export.File.MaxSizeMB,
+				MaxBackups: cfg.Audit.Export.File.MaxBackups,
+			},
+			HTTP: audit.HTTPSinkConfig{
+				Enabled: cfg.Audit.Export.HTTP.Enabled,
+				URL:     cfg.Audit.Export.HTTP.URL,
+				Headers: cfg.Audit.Export.HTTP.Headers,
+				Timeout: time.Duration(cfg.Audit.Export.HTTP.Timeout) * time.Second,
+			},
+		},
+	}
+	auditSvc := audit.NewService(db, logger, auditConfig)
 	
 	// 创建实时同步管理器（必须在 k8s service 之前创建）
 	realtimeMgr := realtime.NewManager(logger)
@@ -177,6 +242,13 @@ func NewServices(db *gorm.DB, logger *logger.Logger, cfg *config.Config) *Servic
 	
 	// 创建 K8s 服务
 	k8sSvc := k8s.NewService(logger, realtimeMgr)
+	k8sSvc.SetDebugPodConfig(k8s.DebugPodConfig{
+		Enabled:        cfg.Terminal.DebugPod.Enabled,
+		Image:          cfg.Terminal.DebugPod.Image,
+		Namespace:      cfg.Terminal.DebugPod.Namespace,
+		MaxDuration:    time.Duration(cfg.Terminal.DebugPod.MaxDuration) * time.Minute,
+		StartupTimeout: time.Duration(cfg.Terminal.DebugPod.StartupTimeout) * time.Second,
+	})
 	
 	// 注册 Pod 事件处理器（连接 PodCountCache 到 Informer）
 	// 这样 Informer 就能实时更新 Pod 统计数据
@@ -241,11 +313,32 @@ func NewServices(db *gorm.DB, logger *logger.Logger, cfg *config.Config) *Servic
 	}
 	sshKeySvc := sshkey.NewService(db, logger, encryptionKey)
 
+	// 平台 SSH CA：为终端会话和 Ansible 任务签发短期证书，原有密钥按配置作为后备
+	sshCASvc := sshca.NewService(db, logger, encryptionKey, sshca.Config{
+		Enabled:          cfg.SSHCA.Enabled,
+		TerminalValidity: time.Duration(cfg.SSHCA.TerminalValidity) * time.Minute,
+		AnsibleValidity:  time.Duration(cfg.SSHCA.AnsibleValidity) * time.Minute,
+		KeyFallback:      cfg.SSHCA.KeyFallback,
+		RolePrincipals:   cfg.SSHCA.RolePrincipals,
+		NodePrincipals:   cfg.SSHCA.NodePrincipals,
+	})
+
 	// 创建服务实例
-	authSvc := auth.NewService(db, logger, cfg.JWT, ldapSvc, auditSvc)
+	authSvc := auth.NewService(db, logger, cfg.JWT, cfg.Security, ldapSvc, auditSvc)
 	labelSvc := label.NewService(db, logger, auditSvc, k8sSvc)
 	taintSvc := taint.NewService(db, logger, auditSvc, k8sSvc)
 	nodeSvc := node.NewService(db, logger, k8sSvc, auditSvc, sshKeySvc)
+	nodeSvc.SetSFTPConfig(node.SFTPConfig{
+		Enabled:         cfg.Terminal.SFTP.Enabled,
+		MaxUploadSize:   int64(cfg.Terminal.SFTP.MaxUploadSizeMB) << 20,
+		MaxDownloadSize: int64(cfg.Terminal.SFTP.MaxDownloadSizeMB) << 20,
+		DownloadPaths:   cfg.Terminal.SFTP.DownloadPaths,
+		UploadPaths:     cfg.Terminal.SFTP.UploadPaths,
+	})
+	nodeSvc.SetSSHCA(sshCASvc)
+	keyRotationSvc := keyrotation.NewService(db, logger, encryptionKey, nodeSvc, k8sSvc)
+	userSvc := user.NewService(db, logger, auditSvc)
+	userSvc.SetSessionRevoker(authSvc)
 
 	// 设置进度服务
 	progressSvc.SetAuthService(authSvc)
@@ -253,6 +346,12 @@ func NewServices(db *gorm.DB, logger *logger.Logger, cfg *config.Config) *Servic
 	taintSvc.SetProgressService(progressSvc)
 	nodeSvc.SetProgressService(progressSvc)
 
+	// 记录标签、污点、调度状态变更前后的快照，支持按操作撤销
+	nodeChangeSvc := nodechange.NewService(db, logger, k8sSvc, auditSvc)
+	labelSvc.SetNodeChangeService(nodeChangeSvc)
+	taintSvc.SetNodeChangeService(nodeChangeSvc)
+	nodeSvc.SetNodeChangeService(nodeChangeSvc)
+
 	// 创建集群和飞书服务
 	clusterSvc := cluster.NewService(db, logger, auditSvc, k8sSvc)
 	feishuSvc := feishu.NewService(db, logger)
@@ -284,6 +383,50 @@ func NewServices(db *gorm.DB, logger *logger.Logger, cfg *config.Config) *Servic
 	// 创建异常监控服务
 	anomalySvc := anomaly.NewService(db, logger, k8sSvc, clusterSvc, cacheInstance, cacheTTL, cleanupSvc, cfg.Monitoring.Enabled, cfg.Monitoring.Interval)
 
+	// 创建节点资源指标历史服务
+	metricsConfig := &nodemetrics.Config{
+		Enabled:          cfg.Monitoring.Metrics.Enabled,
+		Interval:         time.Duration(cfg.Monitoring.Metrics.Interval) * time.Second,
+		RawRetention:     time.Duration(cfg.Monitoring.Metrics.RawRetentionHours) * time.Hour,
+		FiveMinRetention: time.Duration(cfg.Monitoring.Metrics.FiveMinRetentionDays) * 24 * time.Hour,
+		HourlyRetention:  time.Duration(cfg.Monitoring.Metrics.HourlyRetentionDays) * 24 * time.Hour,
+		CleanupTime:      cfg.Monitoring.Metrics.CleanupTime,
+		BatchSize:        cfg.Monitoring.Metrics.BatchSize,
+	}
+	nodeMetricsSvc := nodemetrics.NewService(db, logger, k8sSvc, metricsConfig)
+	anomalySvc.SetNodeMetricsService(nodeMetricsSvc)
+
+	// 创建容量规划服务
+	capacityConfig := &capacity.Config{
+		PoolLabel:      cfg.Monitoring.Capacity.PoolLabel,
+		LossNodes:      cfg.Monitoring.Capacity.LossNodes,
+		TrendDays:      cfg.Monitoring.Capacity.TrendDays,
+		ReportEnabled:  cfg.Monitoring.ReportSchedulerEnabled && cfg.Monitoring.Capacity.Report.Enabled,
+		ReportTime:     cfg.Monitoring.Capacity.Report.Time,
+		ReportChatIDs:  cfg.Monitoring.Capacity.Report.ChatIDs,
+		ReportClusters: cfg.Monitoring.Capacity.Report.Clusters,
+	}
+	capacitySvc := capacity.NewService(db, logger, k8sSvc, nodeMetricsSvc, capacityConfig)
+	capacitySvc.SetMessageSender(feishuSvc)
+
+	// 创建节点事件历史服务（监听节点 Kubernetes Event，入库后推送 WebSocket 并交给异常规则）
+	nodeEventConfig := &nodeevent.Config{
+		Enabled:       cfg.Monitoring.NodeEvents.Enabled,
+		RetentionDays: cfg.Monitoring.NodeEvents.RetentionDays,
+		CleanupTime:   cfg.Monitoring.NodeEvents.CleanupTime,
+		BatchSize:     cfg.Monitoring.NodeEvents.BatchSize,
+	}
+	nodeEventSvc := nodeevent.NewService(db, logger, nodeEventConfig)
+	if nodeEventConfig.Enabled {
+		nodeEventSvc.SetBroadcaster(realtimeMgr.GetWebSocketHub())
+		nodeEventSvc.AddListener(anomalySvc)
+		realtimeMgr.RegisterK8sEventHandler(nodeEventSvc)
+	}
+
+	// 创建节点时间线服务
+	timelineSvc := timeline.NewService(db, logger, k8sSvc)
+	timelineSvc.SetStoredNodeEvents(nodeEventConfig.Enabled)
+
 	// 创建适配器并设置飞书服务的依赖
 	clusterAdapter := &clusterServiceAdapter{svc: clusterSvc}
 	nodeAdapter := &nodeServiceAdapter{svc: nodeSvc}
@@ -300,10 +443,21 @@ func NewServices(db *gorm.DB, logger *logger.Logger, cfg *config.Config) *Servic
 	feishuSvc.SetAnomalyService(anomalyAdapter)
 
 	ansibleSvc := ansible.NewService(db, logger, k8sSvc, realtimeMgr.GetWebSocketHub(), encryptionKey)
+	ansibleSvc.SetAdhocPolicy(ansible.AdhocPolicy{
+		Enabled:         cfg.Ansible.Adhoc.Enabled,
+		AllowedCommands: cfg.Ansible.Adhoc.AllowedCommands,
+		DeniedPatterns:  cfg.Ansible.Adhoc.DeniedPatterns,
+		DefaultForks:    cfg.Ansible.Adhoc.DefaultForks,
+		MaxForks:        cfg.Ansible.Adhoc.MaxForks,
+		DefaultTimeout:  cfg.Ansible.Adhoc.DefaultTimeout,
+		MaxTimeout:      cfg.Ansible.Adhoc.MaxTimeout,
+	})
+	ansibleSvc.SetSSHCA(sshCASvc)
+	sshCASvc.SetTemplateInstaller(ansibleSvc.GetTemplateService())
 
 	return &Services{
 		Auth:          authSvc,
-		User:          user.NewService(db, logger, auditSvc),
+		User:          userSvc,
 		Cluster:       clusterSvc,
 		Node:          nodeSvc,
 		Label:         labelSvc,
@@ -315,8 +469,15 @@ func NewServices(db *gorm.DB, logger *logger.Logger, cfg *config.Config) *Servic
 		Gitlab:        gitlab.NewService(db, logger),
 		Feishu:        feishuSvc,
 		Anomaly:       anomalySvc,
+		NodeMetrics:   nodeMetricsSvc,
+		NodeEvents:    nodeEventSvc,
+		Capacity:      capacitySvc,
+		Timeline:      timelineSvc,
+		NodeChanges:   nodeChangeSvc,
 		Ansible:       ansibleSvc,
 		SSHKey:        sshKeySvc,
+		SSHCA:         sshCASvc,
+		KeyRotation:   keyRotationSvc,
 		Realtime:      realtimeMgr,
 		WSHub:         realtimeMgr.GetWebSocketHub(),
 	}
@@ -0,0 +1,507 @@
+package sshca
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/crypto"
+	"kube-node-manager/pkg/logger"
+
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+)
+
+const (
+	caName    = "default"
+	caComment = "kube-node-manager-ca"
+	clockSkew = time.Minute // 证书生效时间提前量，容忍节点与平台之间的时钟偏差
+
+	// TrustTemplateName 内置的节点信任 CA 模板名称
+	TrustTemplateName = "节点信任 SSH CA"
+)
+
+var (
+	ErrCADisabled   = errors.New("ssh certificate authority is disabled")
+	ErrNoPrincipals = errors.New("no ssh principals configured for user role")
+)
+
+// Config SSH CA 配置
+type Config struct {
+	Enabled          bool
+	TerminalValidity time.Duration       // 终端和文件传输证书有效期
+	AnsibleValidity  time.Duration       // Ansible 任务证书有效期
+	KeyFallback      bool                // 证书之外同时提供原有 SSH 密钥
+	RolePrincipals   map[string][]string // 用户角色 -> 证书 principals
+	NodePrincipals   map[string][]string // 节点登录用户 -> 接受的 principals
+}
+
+// DefaultConfig 默认 SSH CA 配置
+func DefaultConfig() Config {
+	return Config{
+		Enabled:          false,
+		TerminalValidity: 5 * time.Minute,
+		AnsibleValidity:  2 * time.Hour,
+		KeyFallback:      true,
+		RolePrincipals:   map[string][]string{string(model.RoleAdmin): {"knm-admin"}, string(model.RoleUser): {"knm-operator"}},
+		NodePrincipals:   map[string][]string{"root": {"knm-admin"}},
+	}
+}
+
+// TemplateInstaller 创建和更新 Ansible 模板（由 ansible.TemplateService 实现）
+type TemplateInstaller interface {
+	CreateTemplate(req model.TemplateCreateRequest, userID uint) (*model.AnsibleTemplate, error)
+	UpdateTemplate(id uint, req model.TemplateUpdateRequest, userID uint) (*model.AnsibleTemplate, error)
+}
+
+// Service SSH 证书颁发服务
+type Service struct {
+	db        *gorm.DB
+	logger    *logger.Logger
+	encryptor *crypto.Encryptor
+	config    Config
+	templates TemplateInstaller
+
+	mu     sync.Mutex
+	ca     *model.SSHCertAuthority
+	signer ssh.Signer // 已加载的 CA 签名器
+}
+
+// NewService 创建 SSH 证书颁发服务，未配置的字段使用默认值
+func NewService(db *gorm.DB, logger *logger.Logger, encryptionKey string, config Config) *Service {
+	if encryptionKey == "" {
+		// 与 SSH 密钥服务使用相同的默认密钥
+		encryptionKey = "default-encryption-key-change-in-production"
+	}
+
+	defaults := DefaultConfig()
+	if config.TerminalValidity <= 0 {
+		config.TerminalValidity = defaults.TerminalValidity
+	}
+	if config.AnsibleValidity <= 0 {
+		config.AnsibleValidity = defaults.AnsibleValidity
+	}
+	if config.RolePrincipals == nil {
+		config.RolePrincipals = defaults.RolePrincipals
+	}
+	if config.NodePrincipals == nil {
+		config.NodePrincipals = defaults.NodePrincipals
+	}
+
+	return &Service{
+		db:        db,
+		logger:    logger,
+		encryptor: crypto.NewEncryptor(encryptionKey),
+		config:    config,
+	}
+}
+
+// SetTemplateInstaller 设置用于安装节点信任模板的 Ansible 模板服务
+func (s *Service) SetTemplateInstaller(templates TemplateInstaller) {
+	s.templates = templates
+}
+
+// Enabled 是否启用证书签发
+func (s *Service) Enabled() bool {
+	return s != nil && s.config.Enabled
+}
+
+// KeyFallback 是否在证书之外继续使用原有 SSH 密钥
+func (s *Service) KeyFallback() bool {
+	return s == nil || !s.config.Enabled || s.config.KeyFallback
+}
+
+// IssueRequest 证书签发请求
+type IssueRequest struct {
+	UserID   uint
+	Purpose  string        // terminal/sftp/ansible
+	Target   string        // 集群/节点或 Ansible 任务，写入证书 Key ID
+	Validity time.Duration // 不超过按用途配置的有效期，为 0 时使用配置的有效期
+}
+
+// Certificate 签发的短期证书及其临时私钥
+type Certificate struct {
+	Signer        ssh.Signer // 带证书的签名器，可直接用于 ssh.PublicKeys
+	PrivateKeyPEM []byte     // 临时私钥（OpenSSH 格式）
+	CertLine      []byte     // 证书（authorized_keys 格式，写入 <私钥文件>-cert.pub 后 ssh 会自动加载）
+	Record        *model.SSHCertificate
+}
+
+// Issue 生成临时密钥对并用 CA 签发用户证书，principals 由用户角色决定
+func (s *Service) Issue(req IssueRequest) (*Certificate, error) {
+	if !s.Enabled() {
+		return nil, ErrCADisabled
+	}
+
+	var user model.User
+	if err := s.db.First(&user, req.UserID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", req.UserID, err)
+	}
+	if user.Status != model.StatusActive {
+		return nil, fmt.Errorf("user %s is not active", user.Username)
+	}
+	principals := s.config.RolePrincipals[string(user.Role)]
+	if len(principals) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoPrincipals, user.Role)
+	}
+
+	caSigner, _, err := s.loadCA()
+	if err != nil {
+		return nil, err
+	}
+
+	// 调用方只能缩短有效期，保证签发的都是短期证书
+	validity := s.defaultValidity(req.Purpose)
+	if req.Validity > 0 && req.Validity < validity {
+		validity = req.Validity
+	}
+
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	keySigner, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ephemeral key signer: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             keySigner.PublicKey(),
+		Serial:          serial,
+		CertType:        ssh.UserCert,
+		KeyId:           fmt.Sprintf("knm:%s:%s:%s", user.Username, req.Purpose, req.Target),
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(now.Add(-clockSkew).Unix()),
+		ValidBefore:     uint64(now.Add(validity).Unix()),
+		Permissions:     ssh.Permissions{Extensions: certExtensions(req.Purpose)},
+	}
+	if err := cert.SignCert(rand.Reader, caSigner); err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	certSigner, err := ssh.NewCertSigner(cert, keySigner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate signer: %w", err)
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, cert.KeyId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal ephemeral key: %w", err)
+	}
+
+	record := &model.SSHCertificate{
+		Serial:      serial,
+		KeyID:       cert.KeyId,
+		Principals:  model.StringArray(principals),
+		Purpose:     req.Purpose,
+		Target:      req.Target,
+		UserID:      user.ID,
+		Username:    user.Username,
+		Fingerprint: ssh.FingerprintSHA256(cert.Key),
+		ValidAfter:  time.Unix(int64(cert.ValidAfter), 0),
+		ValidBefore: time.Unix(int64(cert.ValidBefore), 0),
+	}
+	if err := s.db.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to record certificate: %w", err)
+	}
+
+	s.logger.Infof("Issued SSH certificate serial=%d key_id=%s principals=%v valid_before=%s",
+		serial, cert.KeyId, principals, record.ValidBefore.Format(time.RFC3339))
+
+	return &Certificate{
+		Signer:        certSigner,
+		PrivateKeyPEM: pem.EncodeToMemory(block),
+		CertLine:      ssh.MarshalAuthorizedKey(cert),
+		Record:        record,
+	}, nil
+}
+
+// defaultValidity 按用途返回证书有效期
+func (s *Service) defaultValidity(purpose string) time.Duration {
+	if purpose == model.SSHCertPurposeAnsible {
+		return s.config.AnsibleValidity
+	}
+	return s.config.TerminalValidity
+}
+
+// certExtensions 证书扩展：终端和 Ansible（become 可能需要 tty）允许分配 PTY，文件传输不需要
+func certExtensions(purpose string) map[string]string {
+	if purpose == model.SSHCertPurposeSFTP {
+		return map[string]string{}
+	}
+	return map[string]string{"permit-pty": ""}
+}
+
+// randomSerial 生成随机证书序列号（63 位，兼容数据库 BIGINT）
+func randomSerial() (uint64, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return 0, fmt.Errorf("failed to generate certificate serial: %w", err)
+	}
+	return binary.BigEndian.Uint64(buf[:]) >> 1, nil
+}
+
+// loadCA 加载 CA 密钥，不存在时生成（多个副本同时生成时以先写入的为准）
+func (s *Service) loadCA() (ssh.Signer, *model.SSHCertAuthority, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.signer != nil {
+		return s.signer, s.ca, nil
+	}
+
+	var ca model.SSHCertAuthority
+	err := s.db.Where("name = ?", caName).First(&ca).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if createErr := s.createCA(); createErr != nil {
+			s.logger.Warningf("Failed to create SSH CA, reloading in case another replica created it: %v", createErr)
+		}
+		err = s.db.Where("name = ?", caName).First(&ca).Error
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load ssh ca: %w", err)
+	}
+
+	privateKey, err := s.encryptor.Decrypt(ca.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt ssh ca key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse ssh ca key: %w", err)
+	}
+
+	s.ca = &ca
+	s.signer = signer
+	return signer, s.ca, nil
+}
+
+// createCA 生成 Ed25519 CA 密钥对并加密保存
+func (s *Service) createCA() error {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate ca key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		return err
+	}
+	block, err := ssh.MarshalPrivateKey(privateKey, caComment)
+	if err != nil {
+		return err
+	}
+	encrypted, err := s.encryptor.Encrypt(string(pem.EncodeToMemory(block)))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt ca key: %w", err)
+	}
+
+	publicKey := signer.PublicKey()
+	ca := &model.SSHCertAuthority{
+		Name:        caName,
+		KeyType:     publicKey.Type(),
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))) + " " + caComment,
+		PrivateKey:  encrypted,
+		Fingerprint: ssh.FingerprintSHA256(publicKey),
+	}
+	if err := s.db.Create(ca).Error; err != nil {
+		return err
+	}
+
+	s.logger.Infof("Created SSH CA %s (%s)", ca.Fingerprint, ca.KeyType)
+	return nil
+}
+
+// CAInfo CA 公钥与签发策略
+type CAInfo struct {
+	Enabled          bool                `json:"enabled"`
+	KeyType          string              `json:"key_type,omitempty"`
+	PublicKey        string              `json:"public_key,omitempty"`
+	Fingerprint      string              `json:"fingerprint,omitempty"`
+	CreatedAt        *time.Time          `json:"created_at,omitempty"`
+	TerminalValidity int                 `json:"terminal_validity"` // 分钟
+	AnsibleValidity  int                 `json:"ansible_validity"`  // 分钟
+	KeyFallback      bool                `json:"key_fallback"`
+	RolePrincipals   map[string][]string `json:"role_principals"`
+	NodePrincipals   map[string][]string `json:"node_principals"`
+	TrustTemplateID  *uint               `json:"trust_template_id"` // 已安装的节点信任模板
+}
+
+// GetCAInfo 获取 CA 信息，启用时如果 CA 不存在会自动生成
+func (s *Service) GetCAInfo() (*CAInfo, error) {
+	info := &CAInfo{
+		Enabled:          s.config.Enabled,
+		TerminalValidity: int(s.config.TerminalValidity / time.Minute),
+		AnsibleValidity:  int(s.config.AnsibleValidity / time.Minute),
+		KeyFallback:      s.config.KeyFallback,
+		RolePrincipals:   s.config.RolePrincipals,
+		NodePrincipals:   s.config.NodePrincipals,
+	}
+
+	var template model.AnsibleTemplate
+	if err := s.db.Select("id").Where("name = ?", TrustTemplateName).First(&template).Error; err == nil {
+		info.TrustTemplateID = &template.ID
+	}
+
+	if !s.config.Enabled {
+		return info, nil
+	}
+
+	_, ca, err := s.loadCA()
+	if err != nil {
+		return nil, err
+	}
+	info.KeyType = ca.KeyType
+	info.PublicKey = ca.PublicKey
+	info.Fingerprint = ca.Fingerprint
+	info.CreatedAt = &ca.CreatedAt
+	return info, nil
+}
+
+// ListCertificates 查询证书签发记录
+func (s *Service) ListCertificates(req model.SSHCertificateListRequest) ([]model.SSHCertificate, int64, error) {
+	query := s.db.Model(&model.SSHCertificate{})
+	if req.Purpose != "" {
+		query = query.Where("purpose = ?", req.Purpose)
+	}
+	if req.UserID > 0 {
+		query = query.Where("user_id = ?", req.UserID)
+	}
+	if req.Keyword != "" {
+		query = query.Where("key_id LIKE ? OR target LIKE ?", "%"+req.Keyword+"%", "%"+req.Keyword+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	var certs []model.SSHCertificate
+	if err := query.Order("created_at DESC").
+		Offset((req.Page - 1) * req.PageSize).
+		Limit(req.PageSize).
+		Find(&certs).Error; err != nil {
+		return nil, 0, err
+	}
+	return certs, total, nil
+}
+
+// InstallTrustTemplate 创建或更新内置的节点信任模板，模板内容包含当前 CA 公钥和节点 principals 配置
+func (s *Service) InstallTrustTemplate(userID uint) (*model.AnsibleTemplate, error) {
+	if !s.Enabled() {
+		return nil, ErrCADisabled
+	}
+	if s.templates == nil {
+		return nil, fmt.Errorf("ansible template service is not available")
+	}
+
+	_, ca, err := s.loadCA()
+	if err != nil {
+		return nil, err
+	}
+	content := TrustPlaybook(ca.PublicKey, s.config.NodePrincipals)
+	description := fmt.Sprintf("配置节点 sshd 信任平台 SSH CA（%s），并按登录用户写入接受的 principals", ca.Fingerprint)
+
+	var existing model.AnsibleTemplate
+	err = s.db.Where("name = ?", TrustTemplateName).First(&existing).Error
+	switch {
+	case err == nil:
+		return s.templates.UpdateTemplate(existing.ID, model.TemplateUpdateRequest{
+			Description:     description,
+			PlaybookContent: content,
+			ChangeNote:      "Sync SSH CA public key and node principals",
+		}, userID)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return s.templates.CreateTemplate(model.TemplateCreateRequest{
+			Name:            TrustTemplateName,
+			Description:     description,
+			PlaybookContent: content,
+			Tags:            "ssh,security",
+			RiskLevel:       "high",
+			ChangeNote:      "Built-in SSH CA trust template",
+		}, userID)
+	default:
+		return nil, fmt.Errorf("failed to get trust template: %w", err)
+	}
+}
+
+// TrustPlaybook 生成节点信任 CA 的 Playbook
+// 写入 CA 公钥和每个登录用户的 AuthorizedPrincipalsFile，authorized_keys 保持不变，原有密钥仍可登录
+func TrustPlaybook(publicKey string, nodePrincipals map[string][]string) string {
+	users := make([]string, 0, len(nodePrincipals))
+	for user := range nodePrincipals {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	var b strings.Builder
+	b.WriteString(`---
+# 由 kube-node-manager 生成，CA 公钥或 principals 配置变化后请重新安装本模板
+- name: 信任平台 SSH CA
+  hosts: all
+  become: true
+  tasks:
+    - name: 写入 CA 公钥
+      ansible.builtin.copy:
+        dest: /etc/ssh/kube-node-manager-ca.pub
+        content: ` + strconv.Quote(publicKey+"\n") + `
+        owner: root
+        group: root
+        mode: "0644"
+
+    - name: 创建 principals 目录
+      ansible.builtin.file:
+        path: /etc/ssh/auth_principals
+        state: directory
+        owner: root
+        group: root
+        mode: "0755"
+`)
+	for _, user := range users {
+		b.WriteString(`
+    - name: ` + strconv.Quote("写入 "+user+" 接受的 principals") + `
+      ansible.builtin.copy:
+        dest: ` + strconv.Quote("/etc/ssh/auth_principals/"+user) + `
+        content: ` + strconv.Quote(strings.Join(nodePrincipals[user], "\n")+"\n") + `
+        owner: root
+        group: root
+        mode: "0644"
+`)
+	}
+	b.WriteString(`
+    - name: 配置 sshd 信任 CA
+      ansible.builtin.lineinfile:
+        path: /etc/ssh/sshd_config
+        regexp: "^\\s*#?\\s*{{ item.key }}\\s"
+        line: "{{ item.key }} {{ item.value }}"
+        insertbefore: BOF
+        validate: /usr/sbin/sshd -t -f %s
+      loop:
+        - { key: TrustedUserCAKeys, value: /etc/ssh/kube-node-manager-ca.pub }
+        - { key: AuthorizedPrincipalsFile, value: /etc/ssh/auth_principals/%u }
+      notify: 重新加载 sshd
+
+  handlers:
+    - name: 重新加载 sshd
+      ansible.builtin.shell: systemctl reload sshd || systemctl reload ssh
+`)
+	return b.String()
+}
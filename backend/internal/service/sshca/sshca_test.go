@@ -0,0 +1,165 @@
+package sshca
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/pkg/logger"
+
+	"github.com/glebarez/sqlite"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&model.User{}, &model.SSHCertAuthority{}, &model.SSHCertificate{}, &model.AnsibleTemplate{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	users := []model.User{
+		{Username: "admin", Email: "admin@example.com", Password: "x", Role: model.RoleAdmin, Status: model.StatusActive},
+		{Username: "viewer", Email: "viewer@example.com", Password: "x", Role: model.RoleViewer, Status: model.StatusActive},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatalf("create users: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Enabled = true
+	return NewService(db, logger.NewLogger(), "test-key", config)
+}
+
+// TestIssueCertificate 测试签发的证书可以被信任 CA 的 sshd 校验通过
+func TestIssueCertificate(t *testing.T) {
+	s := newTestService(t)
+
+	cert, err := s.Issue(IssueRequest{UserID: 1, Purpose: model.SSHCertPurposeTerminal, Target: "prod/node-1"})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	info, err := s.GetCAInfo()
+	if err != nil {
+		t.Fatalf("get ca: %v", err)
+	}
+	caKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(info.PublicKey))
+	if err != nil {
+		t.Fatalf("parse ca key: %v", err)
+	}
+
+	parsed, _, _, _, err := ssh.ParseAuthorizedKey(cert.CertLine)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	sshCert, ok := parsed.(*ssh.Certificate)
+	if !ok {
+		t.Fatalf("expected certificate, got %T", parsed)
+	}
+
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), caKey.Marshal())
+		},
+	}
+	if err := checker.CheckCert("knm-admin", sshCert); err != nil {
+		t.Errorf("certificate rejected for admin principal: %v", err)
+	}
+	if err := checker.CheckCert("knm-operator", sshCert); err == nil {
+		t.Error("certificate should not be valid for other principals")
+	}
+	if sshCert.KeyId != "knm:admin:terminal:prod/node-1" {
+		t.Errorf("unexpected key id: %s", sshCert.KeyId)
+	}
+	if lifetime := time.Until(time.Unix(int64(sshCert.ValidBefore), 0)); lifetime > 5*time.Minute || lifetime < 4*time.Minute {
+		t.Errorf("unexpected certificate lifetime: %s", lifetime)
+	}
+
+	// 临时私钥与证书中的公钥对应
+	key, err := ssh.ParsePrivateKey(cert.PrivateKeyPEM)
+	if err != nil {
+		t.Fatalf("parse ephemeral key: %v", err)
+	}
+	if !bytes.Equal(key.PublicKey().Marshal(), sshCert.Key.Marshal()) {
+		t.Error("ephemeral key does not match certificate")
+	}
+
+	var record model.SSHCertificate
+	if err := s.db.First(&record, "serial = ?", sshCert.Serial).Error; err != nil {
+		t.Fatalf("certificate record not found: %v", err)
+	}
+
+	// Ansible 任务超时更长时仍按配置的有效期签发，更短时按任务超时签发
+	long, err := s.Issue(IssueRequest{UserID: 1, Purpose: model.SSHCertPurposeAnsible, Target: "task-1", Validity: 6 * time.Hour})
+	if err != nil {
+		t.Fatalf("issue ansible certificate: %v", err)
+	}
+	if lifetime := time.Until(long.Record.ValidBefore); lifetime > s.config.AnsibleValidity {
+		t.Errorf("validity should be capped at %s, got %s", s.config.AnsibleValidity, lifetime)
+	}
+	short, err := s.Issue(IssueRequest{UserID: 1, Purpose: model.SSHCertPurposeAnsible, Target: "task-2", Validity: 10 * time.Minute})
+	if err != nil {
+		t.Fatalf("issue ansible certificate: %v", err)
+	}
+	if lifetime := time.Until(short.Record.ValidBefore); lifetime > 10*time.Minute || lifetime < 9*time.Minute {
+		t.Errorf("expected task timeout to shorten validity, got %s", lifetime)
+	}
+
+	if _, err := s.Issue(IssueRequest{UserID: 2, Purpose: model.SSHCertPurposeTerminal}); !errors.Is(err, ErrNoPrincipals) {
+		t.Errorf("expected ErrNoPrincipals for viewer, got %v", err)
+	}
+
+	// 其他副本加载同一个 CA
+	other := NewService(s.db, logger.NewLogger(), "test-key", s.config)
+	otherInfo, err := other.GetCAInfo()
+	if err != nil {
+		t.Fatalf("get ca from other replica: %v", err)
+	}
+	if otherInfo.Fingerprint != info.Fingerprint {
+		t.Errorf("replicas should share the CA, got %s and %s", otherInfo.Fingerprint, info.Fingerprint)
+	}
+}
+
+// TestTrustPlaybook 测试生成的节点信任 Playbook
+func TestTrustPlaybook(t *testing.T) {
+	publicKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIExample kube-node-manager-ca"
+	content := TrustPlaybook(publicKey, map[string][]string{
+		"root":   {"knm-admin"},
+		"ubuntu": {"knm-admin", "knm-operator"},
+	})
+
+	var plays []map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &plays); err != nil {
+		t.Fatalf("invalid playbook: %v\n%s", err, content)
+	}
+	if len(plays) != 1 || plays[0]["hosts"] != "all" {
+		t.Fatalf("unexpected plays: %+v", plays)
+	}
+
+	tasks := plays[0]["tasks"].([]interface{})
+	copyArgs := func(i int) map[string]interface{} {
+		return tasks[i].(map[string]interface{})["ansible.builtin.copy"].(map[string]interface{})
+	}
+	if got := copyArgs(0)["content"]; got != publicKey+"\n" {
+		t.Errorf("unexpected ca key content: %q", got)
+	}
+	if got := copyArgs(3)["dest"]; got != "/etc/ssh/auth_principals/ubuntu" {
+		t.Errorf("unexpected principals file: %v", got)
+	}
+	if got := copyArgs(3)["content"]; got != "knm-admin\nknm-operator\n" {
+		t.Errorf("unexpected principals content: %q", got)
+	}
+	if !strings.Contains(content, "TrustedUserCAKeys") || !strings.Contains(content, "AuthorizedPrincipalsFile") {
+		t.Error("playbook should configure sshd")
+	}
+}
@@ -0,0 +1,53 @@
+package taint
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kube-node-manager/internal/service/k8s"
+)
+
+// SimulateImpact 模拟批量污点变更的影响，不修改集群
+func (s *Service) SimulateImpact(req BatchUpdateRequest) (*k8s.ChangeImpact, error) {
+	if err := s.validateTaints(req.Taints, req.Operation); err != nil {
+		return nil, fmt.Errorf("invalid taints: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	impact, err := s.k8sSvc.SimulateNodeChanges(ctx, req.ClusterName, req.NodeNames,
+		func(labels map[string]string, taints []k8s.TaintInfo) (map[string]string, []k8s.TaintInfo, error) {
+			updated, err := mergeTaints(taints, req.Taints, req.Operation)
+			return labels, updated, err
+		})
+	if err != nil {
+		s.logger.Errorf("Failed to simulate taint change on cluster %s: %v", req.ClusterName, err)
+		return nil, fmt.Errorf("failed to simulate taint change: %w", err)
+	}
+	return impact, nil
+}
+
+// checkImpactAcknowledged 变更会驱逐 Pod 或使工作负载失去全部可调度节点时，要求调用方显式确认
+func (s *Service) checkImpactAcknowledged(req BatchUpdateRequest, acknowledged bool) error {
+	if acknowledged {
+		return nil
+	}
+	impact, err := s.SimulateImpact(req)
+	if err != nil {
+		return err
+	}
+	if !impact.Safe {
+		return &k8s.ImpactNotAcknowledgedError{Impact: impact}
+	}
+	return nil
+}
+
+// impactNote 审计详情中记录调用方已确认变更影响
+func impactNote(acknowledged bool) string {
+	if acknowledged {
+		return " (impact acknowledged)"
+	}
+	return ""
+}
@@ -7,6 +7,7 @@ import (
 	"kube-node-manager/internal/model"
 	"kube-node-manager/internal/service/audit"
 	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/internal/service/nodechange"
 	"kube-node-manager/internal/service/progress"
 	"kube-node-manager/pkg/logger"
 	"strings"
@@ -22,6 +23,8 @@ type Service struct {
 	auditSvc    *audit.Service
 	k8sSvc      *k8s.Service
 	progressSvc *progress.Service
+
+	changeSvc *nodechange.Service
 }
 
 // UpdateTaintsRequest 更新节点污点请求
@@ -30,6 +33,8 @@ type UpdateTaintsRequest struct {
 	NodeName    string          `json:"node_name" binding:"required"`
 	Taints      []k8s.TaintInfo `json:"taints" binding:"required"`
 	Operation   string          `json:"operation"` // add, remove, replace
+
+	OperationID string `json:"-"` // 所属操作 ID，为空时作为单独的操作记录
 }
 
 // BatchUpdateRequest 批量更新污点请求
@@ -38,6 +43,8 @@ type BatchUpdateRequest struct {
 	NodeNames   []string        `json:"node_names" binding:"required"`
 	Taints      []k8s.TaintInfo `json:"taints" binding:"required"`
 	Operation   string          `json:"operation"` // add, remove, replace
+
+	OperationID string `json:"operation_id,omitempty"` // 由服务端生成，随任务参数持久化以便恢复后仍归属同一操作
 }
 
 // CopyTaintsRequest 复制污点请求
@@ -45,6 +52,8 @@ type CopyTaintsRequest struct {
 	ClusterName    string `json:"cluster_name" binding:"required"`
 	SourceNodeName string `json:"source_node_name" binding:"required"`
 	TargetNodeName string `json:"target_node_name" binding:"required"`
+
+	OperationID string `json:"-"` // 所属操作 ID，为空时作为单独的操作记录
 }
 
 // BatchCopyTaintsRequest 批量复制污点请求
@@ -52,6 +61,8 @@ type BatchCopyTaintsRequest struct {
 	ClusterName     string   `json:"cluster_name" binding:"required"`
 	SourceNodeName  string   `json:"source_node_name" binding:"required"`
 	TargetNodeNames []string `json:"target_node_names" binding:"required"`
+
+	OperationID string `json:"operation_id,omitempty"` // 由服务端生成，随任务参数持久化以便恢复后仍归属同一操作
 }
 
 // TemplateCreateRequest 创建污点模板请求
@@ -99,11 +110,12 @@ type TemplateInfo struct {
 
 // ApplyTemplateRequest 应用模板请求
 type ApplyTemplateRequest struct {
-	ClusterName string          `json:"cluster_name" binding:"required"`
-	NodeNames   []string        `json:"node_names" binding:"required"`
-	TemplateID  uint            `json:"template_id" binding:"required"`
-	Operation   string          `json:"operation"`        // add, replace
-	Taints      []k8s.TaintInfo `json:"taints,omitempty"` // 前端选择的污点值
+	ClusterName       string          `json:"cluster_name" binding:"required"`
+	NodeNames         []string        `json:"node_names" binding:"required"`
+	TemplateID        uint            `json:"template_id" binding:"required"`
+	Operation         string          `json:"operation"`          // add, replace
+	Taints            []k8s.TaintInfo `json:"taints,omitempty"`   // 前端选择的污点值
+	AcknowledgeImpact bool            `json:"acknowledge_impact"` // 确认已知晓变更影响（驱逐 Pod、工作负载失去可调度节点）
 }
 
 // TaintUsage 污点使用情况
@@ -142,6 +154,49 @@ func NewService(db *gorm.DB, logger *logger.Logger, auditSvc *audit.Service, k8s
 // SetProgressService 设置进度推送服务
 func (s *Service) SetProgressService(progressSvc *progress.Service) {
 	s.progressSvc = progressSvc
+
+	// 注册批量任务恢复器，用于取消或副本中断后继续处理剩余节点
+	progressSvc.RegisterResumer("batch_taint", func(params json.RawMessage, userID uint) (progress.BatchProcessor, error) {
+		var req BatchUpdateRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		return &TaintProcessor{svc: s, req: req, userID: userID}, nil
+	})
+	progressSvc.RegisterResumer("batch_copy_taint", func(params json.RawMessage, userID uint) (progress.BatchProcessor, error) {
+		var copyParams taintCopyParams
+		if err := json.Unmarshal(params, &copyParams); err != nil {
+			return nil, err
+		}
+		return &TaintCopyProcessor{svc: s, req: copyParams.Request, userID: userID, sourceTaints: copyParams.SourceTaints}, nil
+	})
+}
+
+// taintCopyParams 污点复制任务的恢复参数，保存任务开始时源节点的污点以保证恢复后结果一致
+type taintCopyParams struct {
+	Request      BatchCopyTaintsRequest `json:"request"`
+	SourceTaints []k8s.TaintInfo        `json:"source_taints"`
+}
+
+// SetNodeChangeService 设置节点变更记录服务
+func (s *Service) SetNodeChangeService(changeSvc *nodechange.Service) {
+	s.changeSvc = changeSvc
+}
+
+// recordChange 记录节点污点变更前后的快照
+func (s *Service) recordChange(operationID, clusterName, nodeName string, before, after []k8s.TaintInfo, userID uint) {
+	if s.changeSvc == nil {
+		return
+	}
+	s.changeSvc.Record(nodechange.RecordRequest{
+		OperationID:  operationID,
+		ClusterName:  clusterName,
+		NodeName:     nodeName,
+		ResourceType: model.NodeChangeTaint,
+		Before:       nodechange.TaintState(before),
+		After:        nodechange.TaintState(after),
+		UserID:       userID,
+	})
 }
 
 // getClusterIDByName 根据集群名称获取集群ID
@@ -151,6 +206,10 @@ func (s *Service) getClusterIDByName(clusterName string) (uint, error) {
 
 // UpdateNodeTaints 更新单个节点污点
 func (s *Service) UpdateNodeTaints(req UpdateTaintsRequest, userID uint) error {
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
+
 	// 验证污点信息
 	if err := s.validateTaints(req.Taints, req.Operation); err != nil {
 		return fmt.Errorf("invalid taints: %w", err)
@@ -177,46 +236,9 @@ func (s *Service) UpdateNodeTaints(req UpdateTaintsRequest, userID uint) error {
 		return fmt.Errorf("failed to get node %s in cluster %s: %w", req.NodeName, req.ClusterName, err)
 	}
 
-	// 准备更新的污点
-	var updatedTaints []k8s.TaintInfo
-
-	switch strings.ToLower(req.Operation) {
-	case TaintOperationAdd, "":
-		// 添加污点，保留现有污点
-		updatedTaints = append(updatedTaints, currentNode.Taints...)
-		for _, newTaint := range req.Taints {
-			// 检查是否已存在相同键的污点，如果存在则更新
-			found := false
-			for i, existingTaint := range updatedTaints {
-				if existingTaint.Key == newTaint.Key {
-					updatedTaints[i] = newTaint
-					found = true
-					break
-				}
-			}
-			if !found {
-				updatedTaints = append(updatedTaints, newTaint)
-			}
-		}
-	case TaintOperationRemove:
-		// 删除指定的污点
-		for _, existingTaint := range currentNode.Taints {
-			shouldRemove := false
-			for _, removeTaint := range req.Taints {
-				if existingTaint.Key == removeTaint.Key {
-					shouldRemove = true
-					break
-				}
-			}
-			if !shouldRemove {
-				updatedTaints = append(updatedTaints, existingTaint)
-			}
-		}
-	case TaintOperationReplace:
-		// 替换所有污点
-		updatedTaints = req.Taints
-	default:
-		return fmt.Errorf("invalid operation: %s", req.Operation)
+	updatedTaints, err := mergeTaints(currentNode.Taints, req.Taints, req.Operation)
+	if err != nil {
+		return err
 	}
 
 	// 设置时间戳
@@ -253,6 +275,7 @@ func (s *Service) UpdateNodeTaints(req UpdateTaintsRequest, userID uint) error {
 	}
 
 	s.logger.Infof("Successfully updated taints for node %s", req.NodeName)
+	s.recordChange(req.OperationID, req.ClusterName, req.NodeName, currentNode.Taints, updatedTaints, userID)
 	var clusterID *uint
 	if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
 		clusterID = &cID
@@ -265,11 +288,58 @@ func (s *Service) UpdateNodeTaints(req UpdateTaintsRequest, userID uint) error {
 		ResourceType: model.ResourceTaint,
 		Details:      fmt.Sprintf("Updated taints for node %s in cluster %s", req.NodeName, req.ClusterName),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return nil
 }
 
+// mergeTaints 按操作类型计算节点更新后的污点
+func mergeTaints(current, taints []k8s.TaintInfo, operation string) ([]k8s.TaintInfo, error) {
+	var updatedTaints []k8s.TaintInfo
+
+	switch strings.ToLower(operation) {
+	case TaintOperationAdd, "":
+		// 添加污点，保留现有污点
+		updatedTaints = append(updatedTaints, current...)
+		for _, newTaint := range taints {
+			// 检查是否已存在相同键的污点，如果存在则更新
+			found := false
+			for i, existingTaint := range updatedTaints {
+				if existingTaint.Key == newTaint.Key {
+					updatedTaints[i] = newTaint
+					found = true
+					break
+				}
+			}
+			if !found {
+				updatedTaints = append(updatedTaints, newTaint)
+			}
+		}
+	case TaintOperationRemove:
+		// 删除指定的污点
+		for _, existingTaint := range current {
+			shouldRemove := false
+			for _, removeTaint := range taints {
+				if existingTaint.Key == removeTaint.Key {
+					shouldRemove = true
+					break
+				}
+			}
+			if !shouldRemove {
+				updatedTaints = append(updatedTaints, existingTaint)
+			}
+		}
+	case TaintOperationReplace:
+		// 替换所有污点
+		updatedTaints = taints
+	default:
+		return nil, fmt.Errorf("invalid operation: %s", operation)
+	}
+
+	return updatedTaints, nil
+}
+
 // TaintProcessor 实现 BatchProcessor 接口
 type TaintProcessor struct {
 	svc    *Service
@@ -283,6 +353,7 @@ func (p *TaintProcessor) ProcessNode(ctx context.Context, nodeName string, index
 		NodeName:    nodeName,
 		Taints:      p.req.Taints,
 		Operation:   p.req.Operation,
+		OperationID: p.req.OperationID,
 	}
 
 	err := p.svc.UpdateNodeTaints(updateReq, p.userID)
@@ -302,6 +373,12 @@ func (s *Service) BatchUpdateTaints(req BatchUpdateRequest, userID uint) error {
 func (s *Service) BatchUpdateTaintsWithProgress(req BatchUpdateRequest, userID uint, taskID string) error {
 	s.logger.Infof("Starting batch taint update for %d nodes in cluster %s", len(req.NodeNames), req.ClusterName)
 
+	// 同一批次的节点共享操作 ID，带进度的任务直接使用任务 ID
+	req.OperationID = taskID
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
+
 	// 注意：使用 Informer + WebSocket 实时同步后，无需手动清除缓存
 	// Informer 会自动检测到节点变化并通过 WebSocket 推送给前端
 
@@ -315,7 +392,7 @@ func (s *Service) BatchUpdateTaintsWithProgress(req BatchUpdateRequest, userID u
 
 		// 使用进度推送的并发处理
 		maxConcurrency := 5 // 限制并发数避免过载
-		if err := s.progressSvc.ProcessBatchWithProgress(
+		if err := s.progressSvc.ProcessResumableBatch(
 			context.Background(),
 			taskID,
 			"batch_taint",
@@ -323,6 +400,7 @@ func (s *Service) BatchUpdateTaintsWithProgress(req BatchUpdateRequest, userID u
 			userID,
 			maxConcurrency,
 			processor,
+			req,
 		); err != nil {
 			var clusterID *uint
 			if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
@@ -336,6 +414,7 @@ func (s *Service) BatchUpdateTaintsWithProgress(req BatchUpdateRequest, userID u
 				Details:      fmt.Sprintf("Batch update taints failed for %d nodes", len(req.NodeNames)),
 				Status:       model.AuditStatusFailed,
 				ErrorMsg:     err.Error(),
+				OperationID:  req.OperationID,
 			})
 			return err
 		}
@@ -349,6 +428,7 @@ func (s *Service) BatchUpdateTaintsWithProgress(req BatchUpdateRequest, userID u
 				NodeName:    nodeName,
 				Taints:      req.Taints,
 				Operation:   req.Operation,
+				OperationID: req.OperationID,
 			}
 
 			if err := s.UpdateNodeTaints(updateReq, userID); err != nil {
@@ -374,6 +454,7 @@ func (s *Service) BatchUpdateTaintsWithProgress(req BatchUpdateRequest, userID u
 				Details:      fmt.Sprintf("Batch update taints failed for %d nodes", len(errors)),
 				Status:       model.AuditStatusFailed,
 				ErrorMsg:     combinedError,
+				OperationID:  req.OperationID,
 			})
 			return fmt.Errorf("batch update failed for some nodes: %s", combinedError)
 		}
@@ -390,6 +471,7 @@ func (s *Service) BatchUpdateTaintsWithProgress(req BatchUpdateRequest, userID u
 		ResourceType: model.ResourceTaint,
 		Details:      fmt.Sprintf("Batch updated taints for %d nodes in cluster %s", len(req.NodeNames), req.ClusterName),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return nil
@@ -717,6 +799,10 @@ func (s *Service) ApplyTemplate(req ApplyTemplateRequest, userID uint) error {
 		Operation:   operation,
 	}
 
+	if err := s.checkImpactAcknowledged(batchReq, req.AcknowledgeImpact); err != nil {
+		return err
+	}
+
 	if err := s.BatchUpdateTaints(batchReq, userID); err != nil {
 		var clusterID *uint
 		if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
@@ -743,7 +829,7 @@ func (s *Service) ApplyTemplate(req ApplyTemplateRequest, userID uint) error {
 		ClusterID:    clusterID,
 		Action:       model.ActionUpdate,
 		ResourceType: model.ResourceTaint,
-		Details:      fmt.Sprintf("Applied template %s to %d nodes in cluster %s", template.Name, len(req.NodeNames), req.ClusterName),
+		Details:      fmt.Sprintf("Applied template %s to %d nodes in cluster %s%s", template.Name, len(req.NodeNames), req.ClusterName, impactNote(req.AcknowledgeImpact)),
 		Status:       model.AuditStatusSuccess,
 	})
 
@@ -941,6 +1027,8 @@ func (s *Service) RemoveTaint(clusterName, nodeName, taintKey string, userID uin
 		return fmt.Errorf("failed to update node taints: %w", err)
 	}
 
+	operationID := nodechange.NewOperationID(userID)
+	s.recordChange(operationID, clusterName, nodeName, node.Taints, updatedTaints, userID)
 	s.auditSvc.Log(audit.LogRequest{
 		UserID:       userID,
 		NodeName:     nodeName,
@@ -948,6 +1036,7 @@ func (s *Service) RemoveTaint(clusterName, nodeName, taintKey string, userID uin
 		ResourceType: model.ResourceTaint,
 		Details:      fmt.Sprintf("Removed taint %s from node %s in cluster %s", taintKey, nodeName, clusterName),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  operationID,
 	})
 
 	return nil
@@ -956,6 +1045,10 @@ func (s *Service) RemoveTaint(clusterName, nodeName, taintKey string, userID uin
 // CopyNodeTaints 复制节点污点
 // 从源节点复制所有污点到目标节点，完全替代目标节点的现有污点
 func (s *Service) CopyNodeTaints(req CopyTaintsRequest, userID uint) error {
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
+
 	// 获取源节点信息，强制刷新缓存确保获取最新的污点
 	sourceNode, err := s.k8sSvc.GetNodeWithCache(req.ClusterName, req.SourceNodeName, true)
 	if err != nil {
@@ -1014,6 +1107,7 @@ func (s *Service) CopyNodeTaints(req CopyTaintsRequest, userID uint) error {
 		NodeName:    req.TargetNodeName,
 		Taints:      copiedTaints,
 		Operation:   TaintOperationReplace,
+		OperationID: req.OperationID,
 	}
 
 	if err := s.UpdateNodeTaints(updateReq, userID); err != nil {
@@ -1048,6 +1142,7 @@ func (s *Service) CopyNodeTaints(req CopyTaintsRequest, userID uint) error {
 		ResourceType: model.ResourceTaint,
 		Details:      fmt.Sprintf("Copied %d taints from node %s to node %s in cluster %s", len(copiedTaints), req.SourceNodeName, req.TargetNodeName, req.ClusterName),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return nil
@@ -1084,6 +1179,7 @@ func (p *TaintCopyProcessor) ProcessNode(ctx context.Context, nodeName string, i
 		NodeName:    nodeName,
 		Taints:      copiedTaints,
 		Operation:   TaintOperationReplace,
+		OperationID: p.req.OperationID,
 	}
 
 	if err := p.svc.UpdateNodeTaints(updateReq, p.userID); err != nil {
@@ -1104,6 +1200,12 @@ func (s *Service) BatchCopyTaints(req BatchCopyTaintsRequest, userID uint) error
 func (s *Service) BatchCopyTaintsWithProgress(req BatchCopyTaintsRequest, userID uint, taskID string) error {
 	s.logger.Infof("Starting batch taint copy from node %s to %d target nodes in cluster %s", req.SourceNodeName, len(req.TargetNodeNames), req.ClusterName)
 
+	// 同一批次的节点共享操作 ID，带进度的任务直接使用任务 ID
+	req.OperationID = taskID
+	if req.OperationID == "" {
+		req.OperationID = nodechange.NewOperationID(userID)
+	}
+
 	// 注意：使用 Informer + WebSocket 实时同步后，无需手动清除缓存
 	// Informer 会自动检测到节点变化并通过 WebSocket 推送给前端
 
@@ -1143,7 +1245,7 @@ func (s *Service) BatchCopyTaintsWithProgress(req BatchCopyTaintsRequest, userID
 
 		// 使用进度推送的并发处理
 		maxConcurrency := 5 // 限制并发数避免过载
-		if err := s.progressSvc.ProcessBatchWithProgress(
+		if err := s.progressSvc.ProcessResumableBatch(
 			context.Background(),
 			taskID,
 			"batch_copy_taint",
@@ -1151,6 +1253,7 @@ func (s *Service) BatchCopyTaintsWithProgress(req BatchCopyTaintsRequest, userID
 			userID,
 			maxConcurrency,
 			processor,
+			taintCopyParams{Request: req, SourceTaints: sourceTaints},
 		); err != nil {
 			var clusterID *uint
 			if cID, err := s.getClusterIDByName(req.ClusterName); err == nil {
@@ -1164,6 +1267,7 @@ func (s *Service) BatchCopyTaintsWithProgress(req BatchCopyTaintsRequest, userID
 				Details:      fmt.Sprintf("Batch copy taints from node %s failed for %d target nodes", req.SourceNodeName, len(req.TargetNodeNames)),
 				Status:       model.AuditStatusFailed,
 				ErrorMsg:     err.Error(),
+				OperationID:  req.OperationID,
 			})
 			return err
 		}
@@ -1176,6 +1280,7 @@ func (s *Service) BatchCopyTaintsWithProgress(req BatchCopyTaintsRequest, userID
 				ClusterName:    req.ClusterName,
 				SourceNodeName: req.SourceNodeName,
 				TargetNodeName: targetNodeName,
+				OperationID:    req.OperationID,
 			}
 
 			if err := s.CopyNodeTaints(copyReq, userID); err != nil {
@@ -1201,6 +1306,7 @@ func (s *Service) BatchCopyTaintsWithProgress(req BatchCopyTaintsRequest, userID
 				Details:      fmt.Sprintf("Batch copy taints from node %s failed for %d nodes", req.SourceNodeName, len(errors)),
 				Status:       model.AuditStatusFailed,
 				ErrorMsg:     combinedError,
+				OperationID:  req.OperationID,
 			})
 			return fmt.Errorf("batch copy failed for some nodes: %s", combinedError)
 		}
@@ -1217,6 +1323,7 @@ func (s *Service) BatchCopyTaintsWithProgress(req BatchCopyTaintsRequest, userID
 		ResourceType: model.ResourceTaint,
 		Details:      fmt.Sprintf("Batch copied taints from node %s to %d target nodes in cluster %s", req.SourceNodeName, len(req.TargetNodeNames), req.ClusterName),
 		Status:       model.AuditStatusSuccess,
+		OperationID:  req.OperationID,
 	})
 
 	return nil
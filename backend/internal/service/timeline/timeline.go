@@ -0,0 +1,458 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kube-node-manager/internal/model"
+	"kube-node-manager/internal/service/ansible"
+	"kube-node-manager/internal/service/k8s"
+	"kube-node-manager/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// 时间线事件来源
+const (
+	SourceAudit    = "audit"     // 审计日志中的节点操作（禁止调度、标签、污点等）
+	SourceAnomaly  = "anomaly"   // 节点异常记录
+	SourceAnsible  = "ansible"   // 清单包含该节点的 Ansible 任务
+	SourceCordon   = "cordon"    // 节点上的禁止调度注解
+	SourceK8sEvent = "k8s_event" // Kubernetes Events
+)
+
+// AllSources 全部事件来源
+var AllSources = []string{SourceAudit, SourceAnomaly, SourceAnsible, SourceCordon, SourceK8sEvent}
+
+// 事件级别
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+const (
+	defaultTimelineRange = 7 * 24 * time.Hour
+	defaultTimelineLimit = 500
+	maxTimelineLimit     = 2000
+	k8sSourceTimeout     = 15 * time.Second
+)
+
+// Service 节点生命周期时间线服务：合并审计日志、异常记录、Ansible 任务、禁止调度注解和 Kubernetes Events
+type Service struct {
+	db     *gorm.DB
+	logger *logger.Logger
+	k8sSvc *k8s.Service
+
+	storedNodeEvents bool // 节点 Event 已持久化时从数据库读取，否则只能从 API Server 读取最近的 Event
+}
+
+// Request 时间线查询参数
+type Request struct {
+	ClusterName string
+	NodeName    string
+	Sources     []string // 为空时包含全部来源
+	Start       time.Time
+	End         time.Time
+	Limit       int
+}
+
+// Event 时间线事件
+type Event struct {
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	Type     string    `json:"type"` // 来源内的细分类型，如审计动作、异常类型、任务状态、Event 类型
+	Severity string    `json:"severity"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message,omitempty"`
+	Actor    string    `json:"actor,omitempty"`  // 操作人或上报组件
+	RefID    uint      `json:"ref_id,omitempty"` // 来源记录 ID（审计日志、异常记录、Ansible 任务）
+}
+
+// Result 时间线查询结果，事件按时间倒序
+type Result struct {
+	ClusterName  string            `json:"cluster_name"`
+	NodeName     string            `json:"node_name"`
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Sources      []string          `json:"sources"`
+	Events       []Event           `json:"events"`
+	Total        int               `json:"total"`                   // 截断前的事件总数
+	Truncated    bool              `json:"truncated"`               // 是否因 limit 截断
+	SourceErrors map[string]string `json:"source_errors,omitempty"` // 获取失败的来源，不影响其他来源
+}
+
+// NewService 创建节点时间线服务
+func NewService(db *gorm.DB, logger *logger.Logger, k8sSvc *k8s.Service) *Service {
+	return &Service{
+		db:     db,
+		logger: logger,
+		k8sSvc: k8sSvc,
+	}
+}
+
+// SetStoredNodeEvents 设置节点 Event 是否已持久化（启用节点事件历史时）
+func (s *Service) SetStoredNodeEvents(enabled bool) {
+	s.storedNodeEvents = enabled
+}
+
+// ParseSources 解析并校验逗号分隔的来源列表，为空时返回全部来源
+func ParseSources(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return AllSources, nil
+	}
+
+	valid := make(map[string]bool, len(AllSources))
+	for _, source := range AllSources {
+		valid[source] = true
+	}
+
+	seen := make(map[string]bool)
+	sources := make([]string, 0)
+	for _, source := range strings.Split(value, ",") {
+		source = strings.TrimSpace(source)
+		if source == "" || seen[source] {
+			continue
+		}
+		if !valid[source] {
+			return nil, fmt.Errorf("invalid source %q, expected one of %s", source, strings.Join(AllSources, ", "))
+		}
+		seen[source] = true
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// GetTimeline 获取节点生命周期时间线
+// 各来源并行获取，单个来源失败时记录到 SourceErrors 并返回其余来源的事件
+func (s *Service) GetTimeline(req Request) (*Result, error) {
+	if req.ClusterName == "" || req.NodeName == "" {
+		return nil, fmt.Errorf("cluster_name and node_name are required")
+	}
+	if req.End.IsZero() {
+		req.End = time.Now()
+	}
+	if req.Start.IsZero() {
+		req.Start = req.End.Add(-defaultTimelineRange)
+	}
+	if !req.End.After(req.Start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+	if len(req.Sources) == 0 {
+		req.Sources = AllSources
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultTimelineLimit
+	}
+	req.Limit = min(req.Limit, maxTimelineLimit)
+
+	var cluster model.Cluster
+	if err := s.db.Where("name = ?", req.ClusterName).First(&cluster).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("cluster %s not found", req.ClusterName)
+		}
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+
+	collectors := map[string]func(model.Cluster, Request) ([]Event, error){
+		SourceAudit:    s.auditEvents,
+		SourceAnomaly:  s.anomalyEvents,
+		SourceAnsible:  s.ansibleEvents,
+		SourceCordon:   s.cordonEvents,
+		SourceK8sEvent: s.k8sEvents,
+	}
+
+	var (
+		mu           sync.Mutex
+		wg           sync.WaitGroup
+		events       []Event
+		sourceErrors = make(map[string]string)
+	)
+	for _, source := range req.Sources {
+		collect, ok := collectors[source]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(source string) {
+			defer wg.Done()
+			items, err := collect(cluster, req)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				s.logger.Warningf("Failed to collect %s timeline events for node %s in cluster %s: %v", source, req.NodeName, req.ClusterName, err)
+				sourceErrors[source] = err.Error()
+				return
+			}
+			events = append(events, items...)
+		}(source)
+	}
+	wg.Wait()
+
+	events = filterAndSort(events, req.Start, req.End)
+	result := &Result{
+		ClusterName: req.ClusterName,
+		NodeName:    req.NodeName,
+		Start:       req.Start,
+		End:         req.End,
+		Sources:     req.Sources,
+		Total:       len(events),
+	}
+	if len(events) > req.Limit {
+		events = events[:req.Limit]
+		result.Truncated = true
+	}
+	result.Events = events
+	if len(sourceErrors) > 0 {
+		result.SourceErrors = sourceErrors
+	}
+	return result, nil
+}
+
+// filterAndSort 保留时间范围内的事件并按时间倒序排列，同一时间按来源排序保证结果稳定
+func filterAndSort(events []Event, start, end time.Time) []Event {
+	filtered := make([]Event, 0, len(events))
+	for _, e := range events {
+		if e.Time.Before(start) || e.Time.After(end) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if !filtered[i].Time.Equal(filtered[j].Time) {
+			return filtered[i].Time.After(filtered[j].Time)
+		}
+		return filtered[i].Source < filtered[j].Source
+	})
+	return filtered
+}
+
+// auditEvents 审计日志中针对该节点的操作
+func (s *Service) auditEvents(cluster model.Cluster, req Request) ([]Event, error) {
+	var logs []model.AuditLog
+	if err := s.db.Preload("User").
+		Where("cluster_id = ? AND node_name = ? AND created_at BETWEEN ? AND ?", cluster.ID, req.NodeName, req.Start, req.End).
+		Order("created_at DESC").
+		Limit(req.Limit).
+		Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+
+	events := make([]Event, 0, len(logs))
+	for _, log := range logs {
+		title := log.Details
+		if title == "" {
+			title = fmt.Sprintf("%s %s", log.Action, log.ResourceType)
+		}
+		severity := SeverityInfo
+		message := log.Reason
+		if log.Status == model.AuditStatusFailed {
+			severity = SeverityWarning
+			message = strings.TrimSpace(message + " " + log.ErrorMsg)
+		}
+		events = append(events, Event{
+			Time:     log.CreatedAt,
+			Source:   SourceAudit,
+			Type:     fmt.Sprintf("%s_%s", log.ResourceType, log.Action),
+			Severity: severity,
+			Title:    title,
+			Message:  message,
+			Actor:    log.User.Username,
+			RefID:    log.ID,
+		})
+	}
+	return events, nil
+}
+
+// anomalyEvents 与时间范围有交集的异常记录，分别生成开始和恢复事件
+func (s *Service) anomalyEvents(cluster model.Cluster, req Request) ([]Event, error) {
+	var anomalies []model.NodeAnomaly
+	if err := s.db.Where("cluster_id = ? AND node_name = ? AND start_time <= ? AND (end_time IS NULL OR end_time >= ?)",
+		cluster.ID, req.NodeName, req.End, req.Start).
+		Order("start_time DESC").
+		Limit(req.Limit).
+		Find(&anomalies).Error; err != nil {
+		return nil, fmt.Errorf("failed to query anomalies: %w", err)
+	}
+
+	events := make([]Event, 0, len(anomalies)*2)
+	for _, a := range anomalies {
+		severity := SeverityWarning
+		if a.AnomalyType == model.AnomalyTypeNotReady || a.AnomalyType == model.AnomalyTypeNetworkUnavailable {
+			severity = SeverityCritical
+		}
+		events = append(events, Event{
+			Time:     a.StartTime,
+			Source:   SourceAnomaly,
+			Type:     string(a.AnomalyType),
+			Severity: severity,
+			Title:    fmt.Sprintf("异常开始: %s", a.AnomalyType),
+			Message:  strings.TrimSpace(a.Reason + " " + a.Message),
+			RefID:    a.ID,
+		})
+		if a.EndTime != nil {
+			events = append(events, Event{
+				Time:     *a.EndTime,
+				Source:   SourceAnomaly,
+				Type:     string(a.AnomalyType),
+				Severity: SeverityInfo,
+				Title:    fmt.Sprintf("异常恢复: %s", a.AnomalyType),
+				Message:  fmt.Sprintf("持续 %s", (time.Duration(a.Duration) * time.Second).String()),
+				RefID:    a.ID,
+			})
+		}
+	}
+	return events, nil
+}
+
+// ansibleEvents 执行时清单包含该节点（按节点名或 InternalIP 匹配）的 Ansible 任务
+func (s *Service) ansibleEvents(cluster model.Cluster, req Request) ([]Event, error) {
+	hosts := []string{req.NodeName}
+	if node, err := s.k8sSvc.GetNode(req.ClusterName, req.NodeName); err == nil && node.InternalIP != "" {
+		hosts = append(hosts, node.InternalIP)
+	}
+
+	query := s.db.Preload("User").Where("created_at BETWEEN ? AND ?", req.Start, req.End)
+	conditions := make([]string, 0, len(hosts))
+	args := make([]interface{}, 0, len(hosts))
+	for _, host := range hosts {
+		conditions = append(conditions, "inventory_snapshot LIKE ?")
+		args = append(args, "%"+host+"%")
+	}
+	query = query.Where(strings.Join(conditions, " OR "), args...)
+
+	var tasks []model.AnsibleTask
+	if err := query.Omit("full_log", "playbook_content").
+		Order("created_at DESC").
+		Limit(req.Limit).
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to query ansible tasks: %w", err)
+	}
+
+	events := make([]Event, 0, len(tasks))
+	for _, task := range tasks {
+		// LIKE 只是粗筛，按清单内容精确匹配主机
+		if !ansible.InventoryHasHost(task.InventorySnapshot, hosts...) {
+			continue
+		}
+
+		at := task.CreatedAt
+		if task.StartedAt != nil {
+			at = *task.StartedAt
+		}
+		severity := SeverityInfo
+		if task.Status == model.AnsibleTaskStatusFailed {
+			severity = SeverityWarning
+		}
+		message := fmt.Sprintf("状态 %s，主机 %d（成功 %d，失败 %d）", task.Status, task.HostsTotal, task.HostsOk, task.HostsFailed)
+		if task.DryRun {
+			message += "，检查模式"
+		}
+		if task.ErrorMsg != "" {
+			message += "：" + task.ErrorMsg
+		}
+		event := Event{
+			Time:     at,
+			Source:   SourceAnsible,
+			Type:     string(task.Status),
+			Severity: severity,
+			Title:    fmt.Sprintf("Ansible 任务: %s", task.Name),
+			Message:  message,
+			RefID:    task.ID,
+		}
+		if task.User != nil {
+			event.Actor = task.User.Username
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// cordonEvents 节点当前的禁止调度注解（由本系统或 kubectl 插件写入）
+func (s *Service) cordonEvents(_ model.Cluster, req Request) ([]Event, error) {
+	info, err := s.k8sSvc.GetNodeCordonInfo(req.ClusterName, req.NodeName)
+	if err != nil {
+		return nil, err
+	}
+	if cordoned, _ := info["cordoned"].(bool); !cordoned {
+		return nil, nil
+	}
+	timestamp, _ := info["timestamp"].(string)
+	at, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		// 没有记录时间的禁止调度无法放入时间线
+		return nil, nil
+	}
+
+	reason, _ := info["reason"].(string)
+	actor, _ := info["timestamp_source"].(string)
+	return []Event{{
+		Time:     at,
+		Source:   SourceCordon,
+		Type:     "cordoned",
+		Severity: SeverityWarning,
+		Title:    "节点被禁止调度（当前状态）",
+		Message:  reason,
+		Actor:    actor,
+	}}, nil
+}
+
+// k8sEvents 节点相关的 Kubernetes Events
+func (s *Service) k8sEvents(cluster model.Cluster, req Request) ([]Event, error) {
+	var nodeEvents []k8s.NodeEvent
+	if s.storedNodeEvents {
+		var records []model.NodeEvent
+		if err := s.db.Where("cluster_id = ? AND node_name = ? AND last_timestamp BETWEEN ? AND ?", cluster.ID, req.NodeName, req.Start, req.End).
+			Order("last_timestamp DESC").
+			Limit(req.Limit).
+			Find(&records).Error; err != nil {
+			return nil, fmt.Errorf("failed to query node events: %w", err)
+		}
+		for _, r := range records {
+			nodeEvents = append(nodeEvents, k8s.NodeEvent{
+				Type:           r.Type,
+				Reason:         r.Reason,
+				Message:        r.Message,
+				Source:         r.Source,
+				Count:          r.Count,
+				FirstTimestamp: r.FirstTimestamp,
+				LastTimestamp:  r.LastTimestamp,
+			})
+		}
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), k8sSourceTimeout)
+		defer cancel()
+
+		var err error
+		if nodeEvents, err = s.k8sSvc.ListNodeEvents(ctx, req.ClusterName, req.NodeName); err != nil {
+			return nil, err
+		}
+	}
+
+	events := make([]Event, 0, len(nodeEvents))
+	for _, e := range nodeEvents {
+		severity := SeverityInfo
+		if e.Type == "Warning" {
+			severity = SeverityWarning
+		}
+		message := e.Message
+		if e.Count > 1 {
+			message = fmt.Sprintf("%s（%d 次，首次 %s）", message, e.Count, e.FirstTimestamp.Format(time.RFC3339))
+		}
+		events = append(events, Event{
+			Time:     e.LastTimestamp,
+			Source:   SourceK8sEvent,
+			Type:     e.Type,
+			Severity: severity,
+			Title:    e.Reason,
+			Message:  message,
+			Actor:    e.Source,
+		})
+	}
+	return events, nil
+}
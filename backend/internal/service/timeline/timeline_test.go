@@ -0,0 +1,44 @@
+package timeline
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSources(t *testing.T) {
+	sources, err := ParseSources("")
+	if err != nil || !reflect.DeepEqual(sources, AllSources) {
+		t.Fatalf("expected all sources, got %v, %v", sources, err)
+	}
+
+	sources, err = ParseSources(" audit, k8s_event,audit ")
+	if err != nil || !reflect.DeepEqual(sources, []string{SourceAudit, SourceK8sEvent}) {
+		t.Errorf("unexpected sources: %v, %v", sources, err)
+	}
+
+	if _, err := ParseSources("audit,unknown"); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+func TestFilterAndSort(t *testing.T) {
+	base := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Time: base.Add(time.Hour), Source: SourceK8sEvent, Title: "NodeNotReady"},
+		{Time: base.Add(-time.Hour), Source: SourceAudit, Title: "too early"},
+		{Time: base.Add(time.Hour), Source: SourceAnomaly, Title: "异常开始: NotReady"},
+		{Time: base.Add(30 * time.Minute), Source: SourceAudit, Title: "Cordoned node"},
+		{Time: base.Add(3 * time.Hour), Source: SourceAnsible, Title: "too late"},
+	}
+
+	got := filterAndSort(events, base, base.Add(2*time.Hour))
+	titles := make([]string, 0, len(got))
+	for _, e := range got {
+		titles = append(titles, e.Title)
+	}
+	want := []string{"异常开始: NotReady", "NodeNotReady", "Cordoned node"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("filterAndSort() = %v, want %v", titles, want)
+	}
+}
@@ -1,6 +1,8 @@
 package user
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"kube-node-manager/internal/model"
@@ -12,9 +14,15 @@ import (
 )
 
 type Service struct {
-	db     *gorm.DB
-	logger *logger.Logger
-	audit  *audit.Service
+	db      *gorm.DB
+	logger  *logger.Logger
+	audit   *audit.Service
+	revoker SessionRevoker
+}
+
+// SessionRevoker 会话撤销接口，用户被禁用、删除或重置密码时强制其下线
+type SessionRevoker interface {
+	RevokeUserSessions(userID uint, reason string) (int64, error)
 }
 
 type ListRequest struct {
@@ -24,6 +32,8 @@ type ListRequest struct {
 	Email    string `json:"email"`
 	Role     string `json:"role"`
 	Status   string `json:"status"`
+	// ServiceAccount 按账号类型过滤：true 只返回服务账号，false 只返回普通用户
+	ServiceAccount *bool `json:"service_account" form:"service_account"`
 }
 
 type ListResponse struct {
@@ -41,6 +51,12 @@ type CreateRequest struct {
 	Status   model.UserStatus `json:"status"`
 }
 
+// CreateServiceAccountRequest 创建服务账号请求
+type CreateServiceAccountRequest struct {
+	Username string         `json:"username" binding:"required"`
+	Role     model.UserRole `json:"role"`
+}
+
 type UpdateRequest struct {
 	Username string           `json:"username"`
 	Email    string           `json:"email" binding:"omitempty,email"`
@@ -66,6 +82,23 @@ func NewService(db *gorm.DB, logger *logger.Logger, audit *audit.Service) *Servi
 	}
 }
 
+// SetSessionRevoker 设置会话撤销器
+func (s *Service) SetSessionRevoker(revoker SessionRevoker) {
+	s.revoker = revoker
+}
+
+// revokeSessions 撤销用户的全部会话，失败只记录日志
+func (s *Service) revokeSessions(user *model.User, reason string) {
+	if s.revoker == nil {
+		return
+	}
+	if count, err := s.revoker.RevokeUserSessions(user.ID, reason); err != nil {
+		s.logger.Errorf("Failed to revoke sessions for user %s: %v", user.Username, err)
+	} else if count > 0 {
+		s.logger.Infof("Revoked %d sessions for user %s (%s)", count, user.Username, reason)
+	}
+}
+
 func (s *Service) List(req ListRequest) (*ListResponse, error) {
 	query := s.db.Model(&model.User{})
 
@@ -81,6 +114,9 @@ func (s *Service) List(req ListRequest) (*ListResponse, error) {
 	if req.Status != "" {
 		query = query.Where("status = ?", req.Status)
 	}
+	if req.ServiceAccount != nil {
+		query = query.Where("is_service_account = ?", *req.ServiceAccount)
+	}
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -164,6 +200,51 @@ func (s *Service) Create(req CreateRequest, operatorID uint) (*model.User, error
 	return &user, nil
 }
 
+// CreateServiceAccount 创建服务账号
+// 服务账号没有可用的密码，不能交互式登录，只能通过管理员为其签发的访问令牌调用 API
+func (s *Service) CreateServiceAccount(req CreateServiceAccountRequest, operatorID uint) (*model.User, error) {
+	email := fmt.Sprintf("%s@service-account.local", req.Username)
+
+	var existingUser model.User
+	if err := s.db.Unscoped().Where("username = ? OR email = ?", req.Username, email).First(&existingUser).Error; err == nil {
+		return nil, errors.New("username already exists")
+	}
+
+	user := model.User{
+		Username:         req.Username,
+		Email:            email,
+		Role:             req.Role,
+		Status:           model.StatusActive,
+		IsServiceAccount: true,
+	}
+	if user.Role == "" {
+		user.Role = model.RoleViewer
+	}
+
+	// 随机密码不会返回给任何人，登录流程也会拒绝服务账号
+	randomPassword := make([]byte, 32)
+	if _, err := rand.Read(randomPassword); err != nil {
+		return nil, err
+	}
+	if err := user.HashPassword(hex.EncodeToString(randomPassword)); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	s.audit.Log(audit.LogRequest{
+		UserID:       operatorID,
+		Action:       model.ActionCreate,
+		ResourceType: model.ResourceUser,
+		Details:      fmt.Sprintf("Created service account: %s (role: %s)", user.Username, user.Role),
+		Status:       model.AuditStatusSuccess,
+	})
+
+	return &user, nil
+}
+
 func (s *Service) Update(id uint, req UpdateRequest, operatorID uint) (*model.User, error) {
 	var user model.User
 	if err := s.db.First(&user, id).Error; err != nil {
@@ -217,6 +298,10 @@ func (s *Service) Update(id uint, req UpdateRequest, operatorID uint) (*model.Us
 		return nil, err
 	}
 
+	if user.Status != model.StatusActive {
+		s.revokeSessions(&user, model.SessionRevokedUserBlocked)
+	}
+
 	updateDetails := fmt.Sprintf("Updated user: %s", user.Username)
 	if user.IsLDAPUser {
 		updateDetails = fmt.Sprintf("Updated LDAP user (role/status only): %s", user.Username)
@@ -252,6 +337,8 @@ func (s *Service) Delete(id uint, operatorID uint) error {
 		return err
 	}
 
+	s.revokeSessions(&user, model.SessionRevokedUserBlocked)
+
 	s.audit.Log(audit.LogRequest{
 		UserID:       operatorID,
 		Action:       model.ActionDelete,
@@ -273,6 +360,9 @@ func (s *Service) UpdatePassword(id uint, req UpdatePasswordRequest, operatorID
 	if user.IsLDAPUser {
 		return errors.New("LDAP users cannot change password locally. Please contact your LDAP administrator")
 	}
+	if user.IsServiceAccount {
+		return errors.New("service accounts do not have passwords")
+	}
 
 	if !user.CheckPassword(req.CurrentPassword) {
 		return errors.New("current password is incorrect")
@@ -308,6 +398,9 @@ func (s *Service) ResetPassword(id uint, req ResetPasswordRequest, operatorID ui
 	if user.IsLDAPUser {
 		return errors.New("Cannot reset password for LDAP users. LDAP users are authenticated through LDAP directory")
 	}
+	if user.IsServiceAccount {
+		return errors.New("service accounts do not have passwords")
+	}
 
 	// 直接设置新密码，不需要验证当前密码
 	if err := user.HashPassword(req.Password); err != nil {
@@ -318,6 +411,8 @@ func (s *Service) ResetPassword(id uint, req ResetPasswordRequest, operatorID ui
 		return err
 	}
 
+	s.revokeSessions(&user, model.SessionRevokedPassword)
+
 	s.audit.Log(audit.LogRequest{
 		UserID:       operatorID,
 		Action:       model.ActionUpdate,
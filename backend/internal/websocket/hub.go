@@ -13,7 +13,7 @@ import (
 
 // Message WebSocket 消息
 type Message struct {
-	Type      string      `json:"type"`       // 消息类型：node_add, node_update, node_delete, ping, pong
+	Type      string      `json:"type"`       // 消息类型：node_add, node_update, node_delete, node_event, ping, pong
 	ClusterName string    `json:"cluster_name,omitempty"` // 集群名称
 	NodeName  string      `json:"node_name,omitempty"`    // 节点名称
 	Data      interface{} `json:"data,omitempty"`         // 消息数据
@@ -419,6 +419,18 @@ func (h *Hub) SendNodeUpdate(clusterName, nodeName string, changes []string, dat
 	h.Broadcast(message)
 }
 
+// SendNodeEvent 向订阅了指定集群的客户端发送节点 Kubernetes Event
+func (h *Hub) SendNodeEvent(clusterName, nodeName string, data interface{}) {
+	message := Message{
+		Type:        "node_event",
+		ClusterName: clusterName,
+		NodeName:    nodeName,
+		Data:        data,
+		Timestamp:   time.Now(),
+	}
+	h.Broadcast(message)
+}
+
 // SendJSON 向客户端发送 JSON 消息（辅助方法）
 func SendJSON(conn *websocket.Conn, v interface{}) error {
 	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
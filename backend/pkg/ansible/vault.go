@@ -0,0 +1,154 @@
+package ansible
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Ansible Vault 1.1 (AES256) 格式参数，与 ansible-vault 保持一致
+const (
+	vaultHeader     = "$ANSIBLE_VAULT;1.1;AES256"
+	vaultSaltLen    = 32
+	vaultKeyLen     = 32
+	vaultIVLen      = 16
+	vaultIterations = 10000
+	vaultLineWidth  = 80
+)
+
+// VaultEncrypt 使用 Ansible Vault 1.1 AES256 格式加密内容
+// 生成的内容可以直接通过 ansible-playbook --vault-password-file 解密
+func VaultEncrypt(plaintext []byte, password string) (string, error) {
+	if password == "" {
+		return "", fmt.Errorf("vault password cannot be empty")
+	}
+
+	salt := make([]byte, vaultSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	cipherKey, hmacKey, iv := deriveVaultKeys(password, salt)
+
+	block, err := aes.NewCipher(cipherKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	padded := pkcs7Pad(plaintext, aes.BlockSize)
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, padded)
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(ciphertext)
+
+	// 内层：salt、hmac、密文分别十六进制编码并按行拼接；外层再整体十六进制编码
+	inner := strings.Join([]string{
+		hex.EncodeToString(salt),
+		hex.EncodeToString(mac.Sum(nil)),
+		hex.EncodeToString(ciphertext),
+	}, "\n")
+	body := hex.EncodeToString([]byte(inner))
+
+	var builder strings.Builder
+	builder.WriteString(vaultHeader)
+	builder.WriteString("\n")
+	for start := 0; start < len(body); start += vaultLineWidth {
+		end := start + vaultLineWidth
+		if end > len(body) {
+			end = len(body)
+		}
+		builder.WriteString(body[start:end])
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+// VaultDecrypt 解密 Ansible Vault 1.1 AES256 格式的内容
+func VaultDecrypt(vaultText string, password string) ([]byte, error) {
+	lines := strings.Split(strings.TrimSpace(vaultText), "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != vaultHeader {
+		return nil, fmt.Errorf("unsupported vault format")
+	}
+
+	inner, err := hex.DecodeString(strings.Join(lines[1:], ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault body: %w", err)
+	}
+
+	parts := strings.Split(string(inner), "\n")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid vault body")
+	}
+
+	salt, err := hex.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault salt: %w", err)
+	}
+	expectedMAC, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault hmac: %w", err)
+	}
+	ciphertext, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault ciphertext: %w", err)
+	}
+
+	cipherKey, hmacKey, iv := deriveVaultKeys(password, salt)
+
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write(ciphertext)
+	if !hmac.Equal(mac.Sum(nil), expectedMAC) {
+		return nil, fmt.Errorf("vault hmac mismatch, wrong password or corrupted data")
+	}
+
+	block, err := aes.NewCipher(cipherKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	padded := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, iv).XORKeyStream(padded, ciphertext)
+
+	return pkcs7Unpad(padded, aes.BlockSize)
+}
+
+// deriveVaultKeys 通过 PBKDF2-SHA256 派生加密密钥、HMAC 密钥和 IV
+func deriveVaultKeys(password string, salt []byte) (cipherKey, hmacKey, iv []byte) {
+	derived := pbkdf2.Key([]byte(password), salt, vaultIterations, 2*vaultKeyLen+vaultIVLen, sha256.New)
+	return derived[:vaultKeyLen], derived[vaultKeyLen : 2*vaultKeyLen], derived[2*vaultKeyLen:]
+}
+
+// pkcs7Pad PKCS#7 填充
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	padding := blockSize - len(data)%blockSize
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// pkcs7Unpad 去除 PKCS#7 填充
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, fmt.Errorf("invalid padded data length")
+	}
+
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+
+	return data[:len(data)-padding], nil
+}
@@ -0,0 +1,47 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestVaultEncryptDecrypt 测试 Vault 加解密往返
+func TestVaultEncryptDecrypt(t *testing.T) {
+	plaintext := []byte("db_password: s3cr3t\napi_token: abc123\n")
+
+	vaultText, err := VaultEncrypt(plaintext, "vault-pass")
+	if err != nil {
+		t.Fatalf("VaultEncrypt() error = %v", err)
+	}
+
+	if !strings.HasPrefix(vaultText, "$ANSIBLE_VAULT;1.1;AES256\n") {
+		t.Errorf("unexpected vault header: %q", strings.SplitN(vaultText, "\n", 2)[0])
+	}
+	if strings.Contains(vaultText, "s3cr3t") {
+		t.Error("vault text contains plaintext secret")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(vaultText), "\n")[1:] {
+		if len(line) > 80 {
+			t.Errorf("vault line too long: %d", len(line))
+		}
+	}
+
+	decrypted, err := VaultDecrypt(vaultText, "vault-pass")
+	if err != nil {
+		t.Fatalf("VaultDecrypt() error = %v", err)
+	}
+	if string(decrypted) != string(plaintext) {
+		t.Errorf("VaultDecrypt() = %q, want %q", decrypted, plaintext)
+	}
+
+	if _, err := VaultDecrypt(vaultText, "wrong-pass"); err == nil {
+		t.Error("VaultDecrypt() with wrong password should fail")
+	}
+}
+
+// TestVaultEncryptEmptyPassword 测试空密码
+func TestVaultEncryptEmptyPassword(t *testing.T) {
+	if _, err := VaultEncrypt([]byte("x: 1"), ""); err == nil {
+		t.Error("VaultEncrypt() with empty password should fail")
+	}
+}
@@ -91,10 +91,15 @@ func (c *ColumnDefinition) GetType(dbType DatabaseType) string {
 func AllTableSchemas() []TableSchema {
 	return []TableSchema{
 		usersTableSchema(),
+		userSessionsTableSchema(),
+		apiTokensTableSchema(),
+		userMFATableSchema(),
+		loginThrottlesTableSchema(),
 		clustersTableSchema(),
 		labelTemplatesTableSchema(),
 		taintTemplatesTableSchema(),
 		auditLogsTableSchema(),
+		auditChainStateTableSchema(),
 		progressTasksTableSchema(),
 		progressMessagesTableSchema(),
 		gitlabSettingsTableSchema(),
@@ -103,13 +108,21 @@ func AllTableSchemas() []TableSchema {
 		feishuUserMappingsTableSchema(),
 		feishuUserSessionsTableSchema(),
 		nodeAnomaliesTableSchema(),
+		nodeMetricSamplesTableSchema(),
+		nodeEventsTableSchema(),
+		nodeChangesTableSchema(),
+		capacityReportRunsTableSchema(),
 		anomalyReportConfigsTableSchema(),
 		cacheEntriesTableSchema(),
 		ansibleTasksTableSchema(),
 		ansibleTemplatesTableSchema(),
+		ansibleTemplateRevisionsTableSchema(),
 		ansibleLogsTableSchema(),
+		ansibleHostResultsTableSchema(),
 		ansibleInventoriesTableSchema(),
 		ansibleSSHKeysTableSchema(),
+		ansibleSecretsTableSchema(),
+		ansibleProjectsTableSchema(),
 		ansibleSchedulesTableSchema(),
 		ansibleFavoritesTableSchema(),
 		ansibleTaskHistoryTableSchema(),
@@ -117,6 +130,9 @@ func AllTableSchemas() []TableSchema {
 		ansibleTaskTagsTableSchema(),
 		ansibleWorkflowsTableSchema(),
 		ansibleWorkflowExecutionsTableSchema(),
+		sshCertAuthoritiesTableSchema(),
+		sshCertificatesTableSchema(),
+		sshKeyRotationsTableSchema(),
 		schemaMigrationsTableSchema(),
 	}
 }
@@ -133,6 +149,7 @@ func usersTableSchema() TableSchema {
 			{Name: "role", Type: "VARCHAR(50)", Nullable: false, DefaultValue: strPtr("user")},
 			{Name: "status", Type: "VARCHAR(50)", Nullable: false, DefaultValue: strPtr("active")},
 			{Name: "is_ldap_user", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false")},
+			{Name: "is_service_account", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false"), Comment: "是否为服务账号"},
 			{Name: "last_login", Type: "TIMESTAMP", Nullable: true},
 			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
 			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
@@ -147,6 +164,107 @@ func usersTableSchema() TableSchema {
 	}
 }
 
+// userSessionsTableSchema user_sessions 表结构
+func userSessionsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "user_sessions",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "session_id", Type: "VARCHAR(64)", Nullable: false, Unique: true},
+			{Name: "user_id", Type: "INTEGER", Nullable: false},
+			{Name: "refresh_jti", Type: "VARCHAR(64)", Nullable: false, Comment: "当前有效刷新令牌ID"},
+			{Name: "ip_address", Type: "VARCHAR(64)", Nullable: true},
+			{Name: "user_agent", Type: "VARCHAR(512)", Nullable: true},
+			{Name: "last_used_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "expires_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "revoked_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "revoked_reason", Type: "VARCHAR(255)", Nullable: true},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_user_sessions_session_id", Columns: []string{"session_id"}, Unique: true},
+			{Name: "idx_user_sessions_user_id", Columns: []string{"user_id"}},
+			{Name: "idx_user_sessions_expires_at", Columns: []string{"expires_at"}},
+			{Name: "idx_user_sessions_revoked_at", Columns: []string{"revoked_at"}},
+		},
+		Comment: "用户登录会话表",
+	}
+}
+
+// apiTokensTableSchema api_tokens 表结构
+func apiTokensTableSchema() TableSchema {
+	return TableSchema{
+		Name: "api_tokens",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "name", Type: "VARCHAR(100)", Nullable: false},
+			{Name: "user_id", Type: "INTEGER", Nullable: false},
+			{Name: "token_hash", Type: "VARCHAR(64)", Nullable: false, Unique: true, Comment: "令牌SHA-256摘要"},
+			{Name: "token_hint", Type: "VARCHAR(20)", Nullable: true},
+			{Name: "scopes", Type: "JSONB", Nullable: true, Comment: "权限范围"},
+			{Name: "clusters", Type: "JSONB", Nullable: true, Comment: "允许访问的集群"},
+			{Name: "expires_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "last_used_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "last_used_ip", Type: "VARCHAR(64)", Nullable: true},
+			{Name: "revoked_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "created_by", Type: "INTEGER", Nullable: true},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_api_tokens_token_hash", Columns: []string{"token_hash"}, Unique: true},
+			{Name: "idx_api_tokens_user_id", Columns: []string{"user_id"}},
+			{Name: "idx_api_tokens_expires_at", Columns: []string{"expires_at"}},
+			{Name: "idx_api_tokens_revoked_at", Columns: []string{"revoked_at"}},
+		},
+		Comment: "个人访问令牌表",
+	}
+}
+
+// userMFATableSchema user_mfa 表结构
+func userMFATableSchema() TableSchema {
+	return TableSchema{
+		Name: "user_mfa",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "user_id", Type: "INTEGER", Nullable: false, Unique: true},
+			{Name: "secret", Type: "TEXT", Nullable: false, Comment: "加密的TOTP密钥"},
+			{Name: "enabled", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false")},
+			{Name: "enabled_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "recovery_codes", Type: "JSONB", Nullable: true, Comment: "恢复码摘要"},
+			{Name: "last_used_step", Type: "BIGINT", Nullable: true},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_user_mfa_user_id", Columns: []string{"user_id"}, Unique: true},
+		},
+		Comment: "用户多因素认证表",
+	}
+}
+
+// loginThrottlesTableSchema login_throttles 表结构
+func loginThrottlesTableSchema() TableSchema {
+	return TableSchema{
+		Name: "login_throttles",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "throttle_key", Type: "VARCHAR(255)", Nullable: false, Unique: true},
+			{Name: "failures", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
+			{Name: "window_start", Type: "TIMESTAMP", Nullable: true},
+			{Name: "locked_until", Type: "TIMESTAMP", Nullable: true},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_login_throttles_throttle_key", Columns: []string{"throttle_key"}, Unique: true},
+			{Name: "idx_login_throttles_locked_until", Columns: []string{"locked_until"}},
+		},
+		Comment: "登录失败限流表",
+	}
+}
+
 // clustersTableSchema clusters 表结构
 func clustersTableSchema() TableSchema {
 	return TableSchema{
@@ -246,10 +364,16 @@ func auditLogsTableSchema() TableSchema {
 			{Name: "error_msg", Type: "TEXT", Nullable: true},
 			{Name: "ip_address", Type: "VARCHAR(50)", Nullable: true},
 			{Name: "user_agent", Type: "TEXT", Nullable: true},
+			{Name: "api_token_id", Type: "INTEGER", Nullable: true, Comment: "访问令牌ID"},
+			{Name: "operation_id", Type: "VARCHAR(64)", Nullable: true, Comment: "节点变更操作ID"},
+			{Name: "prev_hash", Type: "VARCHAR(64)", Nullable: true, Comment: "上一条记录的哈希"},
+			{Name: "hash", Type: "VARCHAR(64)", Nullable: true, Comment: "本条记录的哈希"},
 			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
 		},
 		Indexes: []IndexDefinition{
 			{Name: "idx_audit_logs_user_id", Columns: []string{"user_id"}},
+			{Name: "idx_audit_logs_api_token_id", Columns: []string{"api_token_id"}},
+			{Name: "idx_audit_logs_operation_id", Columns: []string{"operation_id"}},
 			{Name: "idx_audit_logs_cluster_id", Columns: []string{"cluster_id"}},
 			{Name: "idx_audit_logs_created_at", Columns: []string{"created_at"}},
 		},
@@ -257,6 +381,22 @@ func auditLogsTableSchema() TableSchema {
 	}
 }
 
+// auditChainStateTableSchema audit_chain_state 表结构
+func auditChainStateTableSchema() TableSchema {
+	return TableSchema{
+		Name: "audit_chain_state",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "last_log_id", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
+			{Name: "last_hash", Type: "VARCHAR(64)", Nullable: true, Comment: "最新一条审计日志的哈希"},
+			{Name: "anchor_id", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0"), Comment: "最近归档删除的最后一条记录ID"},
+			{Name: "anchor_hash", Type: "VARCHAR(64)", Nullable: true, Comment: "最近归档删除的最后一条记录哈希"},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Comment: "审计日志哈希链状态表",
+	}
+}
+
 // progressTasksTableSchema progress_tasks 表结构
 func progressTasksTableSchema() TableSchema {
 	return TableSchema{
@@ -275,6 +415,12 @@ func progressTasksTableSchema() TableSchema {
 			{Name: "failed_nodes", Type: "TEXT", Nullable: true, Comment: "JSON数组"},
 			{Name: "message", Type: "TEXT", Nullable: true},
 			{Name: "error_msg", Type: "TEXT", Nullable: true},
+			{Name: "node_plan", Type: "TEXT", Nullable: true, Comment: "JSON数组，计划处理的全部节点"},
+			{Name: "params", Type: "TEXT", Nullable: true, Comment: "JSON，恢复任务所需的请求参数"},
+			{Name: "concurrency", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
+			{Name: "owner", Type: "VARCHAR(255)", Nullable: true, Comment: "执行任务的副本标识"},
+			{Name: "heartbeat_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "cancel_requested", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false")},
 			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
 			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
 			{Name: "completed_at", Type: "TIMESTAMP", Nullable: true},
@@ -284,6 +430,7 @@ func progressTasksTableSchema() TableSchema {
 			{Name: "idx_progress_tasks_task_id", Columns: []string{"task_id"}, Unique: true},
 			{Name: "idx_progress_tasks_user_id", Columns: []string{"user_id"}},
 			{Name: "idx_progress_tasks_status", Columns: []string{"status"}},
+			{Name: "idx_progress_tasks_owner", Columns: []string{"owner"}},
 			{Name: "idx_progress_tasks_deleted_at", Columns: []string{"deleted_at"}},
 		},
 		Comment: "进度任务表",
@@ -308,6 +455,7 @@ func progressMessagesTableSchema() TableSchema {
 			{Name: "failed_nodes", Type: "TEXT", Nullable: true, Comment: "JSON数组"},
 			{Name: "message", Type: "TEXT", Nullable: true},
 			{Name: "error_msg", Type: "TEXT", Nullable: true},
+			{Name: "pending_nodes", Type: "TEXT", Nullable: true, Comment: "JSON数组，未执行节点"},
 			{Name: "processed", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false")},
 			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
 			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
@@ -470,6 +618,116 @@ func nodeAnomaliesTableSchema() TableSchema {
 	}
 }
 
+// nodeMetricSamplesTableSchema node_metric_samples 表结构
+func nodeMetricSamplesTableSchema() TableSchema {
+	return TableSchema{
+		Name: "node_metric_samples",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "cluster_id", Type: "INTEGER", Nullable: false},
+			{Name: "cluster_name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "node_name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "tier", Type: "VARCHAR(10)", Nullable: false, Comment: "降采样层级: raw/5m/1h"},
+			{Name: "timestamp", Type: "TIMESTAMP", Nullable: false},
+			{Name: "sample_count", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("1")},
+			{Name: "cpu_avg", Type: "DOUBLE PRECISION", Nullable: true, Comment: "毫核"},
+			{Name: "cpu_min", Type: "BIGINT", Nullable: true},
+			{Name: "cpu_max", Type: "BIGINT", Nullable: true},
+			{Name: "memory_avg", Type: "DOUBLE PRECISION", Nullable: true, Comment: "字节"},
+			{Name: "memory_min", Type: "BIGINT", Nullable: true},
+			{Name: "memory_max", Type: "BIGINT", Nullable: true},
+			{Name: "pods_avg", Type: "DOUBLE PRECISION", Nullable: true},
+			{Name: "pods_min", Type: "BIGINT", Nullable: true},
+			{Name: "pods_max", Type: "BIGINT", Nullable: true},
+			{Name: "cpu_allocatable", Type: "BIGINT", Nullable: true},
+			{Name: "memory_allocatable", Type: "BIGINT", Nullable: true},
+			{Name: "pods_allocatable", Type: "BIGINT", Nullable: true},
+			{Name: "request_samples", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0"), Comment: "统计到 requests 的采样数"},
+			{Name: "cpu_requests_avg", Type: "DOUBLE PRECISION", Nullable: true, Comment: "毫核"},
+			{Name: "memory_requests_avg", Type: "DOUBLE PRECISION", Nullable: true, Comment: "字节"},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_node_metric_point", Columns: []string{"cluster_id", "node_name", "tier", "timestamp"}, Unique: true},
+			{Name: "idx_node_metric_tier_time", Columns: []string{"tier", "timestamp"}},
+		},
+		Comment: "节点资源使用历史表",
+	}
+}
+
+// nodeEventsTableSchema node_events 表结构
+func nodeEventsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "node_events",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "cluster_id", Type: "INTEGER", Nullable: false},
+			{Name: "cluster_name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "node_name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "event_uid", Type: "VARCHAR(64)", Nullable: false, Comment: "Kubernetes Event UID"},
+			{Name: "type", Type: "VARCHAR(20)", Nullable: true, Comment: "Normal/Warning"},
+			{Name: "reason", Type: "VARCHAR(128)", Nullable: true},
+			{Name: "message", Type: "TEXT", Nullable: true},
+			{Name: "source", Type: "VARCHAR(255)", Nullable: true, Comment: "上报组件"},
+			{Name: "count", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("1")},
+			{Name: "first_timestamp", Type: "TIMESTAMP", Nullable: false},
+			{Name: "last_timestamp", Type: "TIMESTAMP", Nullable: false},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_node_event_uid", Columns: []string{"cluster_id", "event_uid"}, Unique: true},
+			{Name: "idx_node_event_node", Columns: []string{"cluster_id", "node_name", "last_timestamp"}},
+			{Name: "idx_node_event_reason", Columns: []string{"reason"}},
+			{Name: "idx_node_event_last", Columns: []string{"last_timestamp"}},
+		},
+		Comment: "节点 Kubernetes Event 历史表",
+	}
+}
+
+// nodeChangesTableSchema node_changes 表结构
+func nodeChangesTableSchema() TableSchema {
+	return TableSchema{
+		Name: "node_changes",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "operation_id", Type: "VARCHAR(64)", Nullable: false},
+			{Name: "cluster_id", Type: "INTEGER", Nullable: false},
+			{Name: "cluster_name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "node_name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "resource_type", Type: "VARCHAR(20)", Nullable: false, Comment: "label/taint/cordon"},
+			{Name: "before_state", Type: "TEXT", Nullable: true, Comment: "变更前快照（JSON）"},
+			{Name: "after_state", Type: "TEXT", Nullable: true, Comment: "变更后快照（JSON）"},
+			{Name: "user_id", Type: "INTEGER", Nullable: false},
+			{Name: "undone_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "undone_by", Type: "INTEGER", Nullable: true},
+			{Name: "undo_operation_id", Type: "VARCHAR(64)", Nullable: true, Comment: "执行撤销的操作ID"},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_node_change_operation", Columns: []string{"operation_id"}},
+			{Name: "idx_node_change_node", Columns: []string{"cluster_id", "node_name", "created_at"}},
+		},
+		Comment: "节点标签/污点/调度状态变更记录表",
+	}
+}
+
+// capacityReportRunsTableSchema capacity_report_runs 表结构
+func capacityReportRunsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "capacity_report_runs",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "run_key", Type: "VARCHAR(64)", Nullable: false, Comment: "报告日期"},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_capacity_report_runs_run_key", Columns: []string{"run_key"}, Unique: true},
+		},
+		Comment: "定时容量报告发送记录表",
+	}
+}
+
 // anomalyReportConfigsTableSchema anomaly_report_configs 表结构
 func anomalyReportConfigsTableSchema() TableSchema {
 	return TableSchema{
@@ -525,6 +783,7 @@ func ansibleTasksTableSchema() TableSchema {
 			{Name: "template_id", Type: "INTEGER", Nullable: true, ForeignKey: &ForeignKeyDef{
 				Table: "ansible_templates", Column: "id", OnDelete: "SET NULL",
 			}},
+			{Name: "template_revision", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0"), Comment: "执行时使用的模板版本号"},
 			{Name: "cluster_id", Type: "INTEGER", Nullable: true, ForeignKey: &ForeignKeyDef{
 				Table: "clusters", Column: "id", OnDelete: "SET NULL",
 			}},
@@ -544,17 +803,28 @@ func ansibleTasksTableSchema() TableSchema {
 			{Name: "hosts_skipped", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
 			{Name: "error_msg", Type: "TEXT", Nullable: true},
 			{Name: "playbook_content", Type: "TEXT", Nullable: false, Comment: "Playbook内容"},
+			{Name: "inventory_snapshot", Type: "TEXT", Nullable: true, Comment: "执行时解析的主机清单快照"},
+			{Name: "project_id", Type: "INTEGER", Nullable: true, ForeignKey: &ForeignKeyDef{
+				Table: "ansible_projects", Column: "id", OnDelete: "SET NULL",
+			}},
+			{Name: "playbook_path", Type: "VARCHAR(512)", Nullable: true},
+			{Name: "commit_sha", Type: "VARCHAR(64)", Nullable: true, Comment: "执行时固定的项目提交"},
 			{Name: "full_log", Type: "TEXT", Nullable: true, Comment: "完整日志"},
 			{Name: "log_size", Type: "BIGINT", Nullable: false, DefaultValue: strPtr("0")},
 			{Name: "extra_vars", Type: "JSONB", Nullable: true, Comment: "额外变量"},
+			{Name: "secret_vars", Type: "JSONB", Nullable: true, Comment: "密钥变量引用"},
 			{Name: "retry_policy", Type: "JSONB", Nullable: true, Comment: "重试策略"},
 			{Name: "retry_count", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
 			{Name: "max_retries", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
 			{Name: "dry_run", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false")},
 			{Name: "batch_config", Type: "JSONB", Nullable: true, Comment: "分批执行配置"},
+			{Name: "adhoc", Type: "JSONB", Nullable: true, Comment: "临时命令配置"},
 			{Name: "current_batch", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
 			{Name: "total_batches", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
 			{Name: "batch_status", Type: "VARCHAR(50)", Nullable: true},
+			{Name: "batch_results", Type: "JSONB", Nullable: true, Comment: "各批次执行结果"},
+			{Name: "batch_control", Type: "VARCHAR(20)", Nullable: true, DefaultValue: strPtr("''"), Comment: "待处理的批次控制请求(pause/continue)"},
+			{Name: "batch_heartbeat_at", Type: "TIMESTAMP", Nullable: true, Comment: "执行实例最后心跳时间"},
 			{Name: "preflight_checks", Type: "JSONB", Nullable: true, Comment: "前置检查结果"},
 			{Name: "timeout_seconds", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0")},
 			{Name: "is_timed_out", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false")},
@@ -575,6 +845,7 @@ func ansibleTasksTableSchema() TableSchema {
 			{Name: "idx_ansible_tasks_template_id", Columns: []string{"template_id"}},
 			{Name: "idx_ansible_tasks_cluster_id", Columns: []string{"cluster_id"}},
 			{Name: "idx_ansible_tasks_inventory_id", Columns: []string{"inventory_id"}},
+			{Name: "idx_ansible_tasks_project_id", Columns: []string{"project_id"}},
 			{Name: "idx_ansible_tasks_status", Columns: []string{"status"}},
 			{Name: "idx_ansible_tasks_user_id", Columns: []string{"user_id"}},
 			{Name: "idx_ansible_tasks_priority", Columns: []string{"priority"}},
@@ -594,10 +865,16 @@ func ansibleTemplatesTableSchema() TableSchema {
 			{Name: "name", Type: "VARCHAR(255)", Nullable: false, Comment: "模板名称"},
 			{Name: "description", Type: "TEXT", Nullable: true},
 			{Name: "playbook_content", Type: "TEXT", Nullable: false, Comment: "Playbook内容"},
+			{Name: "project_id", Type: "INTEGER", Nullable: true, ForeignKey: &ForeignKeyDef{
+				Table: "ansible_projects", Column: "id", OnDelete: "SET NULL",
+			}},
+			{Name: "playbook_path", Type: "VARCHAR(512)", Nullable: true},
 			{Name: "variables", Type: "JSONB", Nullable: true, Comment: "变量定义"},
 			{Name: "required_vars", Type: "JSONB", Nullable: true, Comment: "必需变量列表"},
+			{Name: "secret_vars", Type: "JSONB", Nullable: true, Comment: "密钥变量引用"},
 			{Name: "tags", Type: "VARCHAR(255)", Nullable: true},
 			{Name: "risk_level", Type: "VARCHAR(20)", Nullable: false, DefaultValue: strPtr("low")},
+			{Name: "current_revision", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0"), Comment: "当前版本号"},
 			{Name: "user_id", Type: "INTEGER", Nullable: false, ForeignKey: &ForeignKeyDef{
 				Table: "users", Column: "id", OnDelete: "RESTRICT",
 			}},
@@ -607,6 +884,7 @@ func ansibleTemplatesTableSchema() TableSchema {
 		},
 		Indexes: []IndexDefinition{
 			{Name: "idx_ansible_templates_user_id", Columns: []string{"user_id"}},
+			{Name: "idx_ansible_templates_project_id", Columns: []string{"project_id"}},
 			{Name: "idx_ansible_templates_deleted_at", Columns: []string{"deleted_at"}},
 			{Name: "idx_ansible_templates_name_deleted_at", Columns: []string{"name", "deleted_at"}, Unique: true},
 		},
@@ -614,6 +892,40 @@ func ansibleTemplatesTableSchema() TableSchema {
 	}
 }
 
+// ansibleTemplateRevisionsTableSchema ansible_template_revisions 表结构
+func ansibleTemplateRevisionsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "ansible_template_revisions",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "template_id", Type: "INTEGER", Nullable: false, ForeignKey: &ForeignKeyDef{
+				Table: "ansible_templates", Column: "id", OnDelete: "CASCADE",
+			}},
+			{Name: "revision", Type: "INTEGER", Nullable: false, Comment: "版本号"},
+			{Name: "name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "description", Type: "TEXT", Nullable: true},
+			{Name: "playbook_content", Type: "TEXT", Nullable: true, Comment: "Playbook内容"},
+			{Name: "project_id", Type: "INTEGER", Nullable: true},
+			{Name: "playbook_path", Type: "VARCHAR(512)", Nullable: true},
+			{Name: "variables", Type: "JSONB", Nullable: true},
+			{Name: "required_vars", Type: "JSONB", Nullable: true},
+			{Name: "secret_vars", Type: "JSONB", Nullable: true},
+			{Name: "tags", Type: "VARCHAR(255)", Nullable: true},
+			{Name: "risk_level", Type: "VARCHAR(20)", Nullable: true},
+			{Name: "change_note", Type: "TEXT", Nullable: true, Comment: "变更说明"},
+			{Name: "user_id", Type: "INTEGER", Nullable: false, ForeignKey: &ForeignKeyDef{
+				Table: "users", Column: "id", OnDelete: "RESTRICT",
+			}},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_template_revision", Columns: []string{"template_id", "revision"}, Unique: true},
+			{Name: "idx_ansible_template_revisions_user_id", Columns: []string{"user_id"}},
+		},
+		Comment: "Ansible模板版本表",
+	}
+}
+
 // ansibleLogsTableSchema ansible_logs 表结构
 func ansibleLogsTableSchema() TableSchema {
 	return TableSchema{
@@ -636,6 +948,32 @@ func ansibleLogsTableSchema() TableSchema {
 	}
 }
 
+// ansibleHostResultsTableSchema ansible_host_results 表结构
+func ansibleHostResultsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "ansible_host_results",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "task_id", Type: "INTEGER", Nullable: false, ForeignKey: &ForeignKeyDef{
+				Table: "ansible_tasks", Column: "id", OnDelete: "CASCADE",
+			}},
+			{Name: "host", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "status", Type: "VARCHAR(20)", Nullable: false},
+			{Name: "return_code", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("-1")},
+			{Name: "output", Type: "TEXT", Nullable: true},
+			{Name: "output_hash", Type: "VARCHAR(64)", Nullable: true},
+			{Name: "truncated", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("false")},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_ansible_host_results_task_id", Columns: []string{"task_id"}},
+			{Name: "idx_ansible_host_results_status", Columns: []string{"status"}},
+			{Name: "idx_ansible_host_results_output_hash", Columns: []string{"output_hash"}},
+		},
+		Comment: "Ansible临时命令主机结果表",
+	}
+}
+
 // ansibleInventoriesTableSchema ansible_inventories 表结构
 func ansibleInventoriesTableSchema() TableSchema {
 	return TableSchema{
@@ -653,6 +991,7 @@ func ansibleInventoriesTableSchema() TableSchema {
 			}},
 			{Name: "content", Type: "TEXT", Nullable: false, Comment: "清单内容(INI或YAML)"},
 			{Name: "hosts_data", Type: "JSONB", Nullable: true, Comment: "结构化主机数据"},
+			{Name: "k8s_filter", Type: "JSONB", Nullable: true, Comment: "K8s节点筛选条件"},
 			{Name: "environment", Type: "VARCHAR(20)", Nullable: false, DefaultValue: strPtr("dev")},
 			{Name: "user_id", Type: "INTEGER", Nullable: false, ForeignKey: &ForeignKeyDef{
 				Table: "users", Column: "id", OnDelete: "RESTRICT",
@@ -703,6 +1042,65 @@ func ansibleSSHKeysTableSchema() TableSchema {
 	}
 }
 
+// ansibleSecretsTableSchema ansible_secrets 表结构
+func ansibleSecretsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "ansible_secrets",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "name", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "description", Type: "TEXT", Nullable: true},
+			{Name: "value", Type: "TEXT", Nullable: false, Comment: "加密存储"},
+			{Name: "created_by", Type: "INTEGER", Nullable: false, ForeignKey: &ForeignKeyDef{
+				Table: "users", Column: "id", OnDelete: "RESTRICT",
+			}},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "deleted_at", Type: "TIMESTAMP", Nullable: true},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_ansible_secrets_created_by", Columns: []string{"created_by"}},
+			{Name: "idx_ansible_secrets_deleted_at", Columns: []string{"deleted_at"}},
+			{Name: "idx_ansible_secrets_name_deleted_at", Columns: []string{"name", "deleted_at"}, Unique: true},
+		},
+		Comment: "Ansible密钥变量表",
+	}
+}
+
+// ansibleProjectsTableSchema ansible_projects 表结构
+func ansibleProjectsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "ansible_projects",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "name", Type: "VARCHAR(255)", Nullable: false, Comment: "项目名称"},
+			{Name: "description", Type: "TEXT", Nullable: true},
+			{Name: "repo_url", Type: "VARCHAR(1024)", Nullable: false, Comment: "Git仓库地址"},
+			{Name: "ref", Type: "VARCHAR(255)", Nullable: false, DefaultValue: strPtr("main")},
+			{Name: "ssh_key_id", Type: "INTEGER", Nullable: true, ForeignKey: &ForeignKeyDef{
+				Table: "ansible_ssh_keys", Column: "id", OnDelete: "SET NULL",
+			}},
+			{Name: "sync_status", Type: "VARCHAR(20)", Nullable: false, DefaultValue: strPtr("never")},
+			{Name: "sync_error", Type: "TEXT", Nullable: true},
+			{Name: "last_commit_sha", Type: "VARCHAR(64)", Nullable: true, Comment: "最近同步的提交"},
+			{Name: "last_synced_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "user_id", Type: "INTEGER", Nullable: false, ForeignKey: &ForeignKeyDef{
+				Table: "users", Column: "id", OnDelete: "RESTRICT",
+			}},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "deleted_at", Type: "TIMESTAMP", Nullable: true},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_ansible_projects_ssh_key_id", Columns: []string{"ssh_key_id"}},
+			{Name: "idx_ansible_projects_user_id", Columns: []string{"user_id"}},
+			{Name: "idx_ansible_projects_deleted_at", Columns: []string{"deleted_at"}},
+			{Name: "idx_ansible_projects_name_deleted_at", Columns: []string{"name", "deleted_at"}, Unique: true},
+		},
+		Comment: "Ansible Git项目表",
+	}
+}
+
 // ansibleSchedulesTableSchema ansible_schedules 表结构
 func ansibleSchedulesTableSchema() TableSchema {
 	return TableSchema{
@@ -720,6 +1118,7 @@ func ansibleSchedulesTableSchema() TableSchema {
 			{Name: "cluster_id", Type: "INTEGER", Nullable: true, ForeignKey: &ForeignKeyDef{
 				Table: "clusters", Column: "id", OnDelete: "SET NULL",
 			}},
+			{Name: "template_revision", Type: "INTEGER", Nullable: false, DefaultValue: strPtr("0"), Comment: "固定的模板版本号(0表示最新版本)"},
 			{Name: "cron_expr", Type: "VARCHAR(100)", Nullable: false, Comment: "Cron表达式"},
 			{Name: "extra_vars", Type: "JSONB", Nullable: true},
 			{Name: "enabled", Type: "BOOLEAN", Nullable: false, DefaultValue: strPtr("true")},
@@ -902,6 +1301,92 @@ func ansibleWorkflowExecutionsTableSchema() TableSchema {
 	}
 }
 
+// sshCertAuthoritiesTableSchema ssh_cert_authorities 表结构
+func sshCertAuthoritiesTableSchema() TableSchema {
+	return TableSchema{
+		Name: "ssh_cert_authorities",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "name", Type: "VARCHAR(100)", Nullable: false, Unique: true},
+			{Name: "key_type", Type: "VARCHAR(50)", Nullable: false},
+			{Name: "public_key", Type: "TEXT", Nullable: false},
+			{Name: "private_key", Type: "TEXT", Nullable: false, Comment: "CA私钥(加密)"},
+			{Name: "fingerprint", Type: "VARCHAR(100)", Nullable: true},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_ssh_cert_authorities_name", Columns: []string{"name"}, Unique: true},
+		},
+		Comment: "SSH证书颁发机构表",
+	}
+}
+
+// sshCertificatesTableSchema ssh_certificates 表结构
+func sshCertificatesTableSchema() TableSchema {
+	return TableSchema{
+		Name: "ssh_certificates",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "serial", Type: "BIGINT", Nullable: false, Unique: true},
+			{Name: "key_id", Type: "VARCHAR(255)", Nullable: false},
+			{Name: "principals", Type: "JSONB", Nullable: true},
+			{Name: "purpose", Type: "VARCHAR(20)", Nullable: false, Comment: "terminal/sftp/ansible"},
+			{Name: "target", Type: "VARCHAR(255)", Nullable: true},
+			{Name: "user_id", Type: "INTEGER", Nullable: false},
+			{Name: "username", Type: "VARCHAR(100)", Nullable: true},
+			{Name: "fingerprint", Type: "VARCHAR(100)", Nullable: true},
+			{Name: "valid_after", Type: "TIMESTAMP", Nullable: true},
+			{Name: "valid_before", Type: "TIMESTAMP", Nullable: true},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_ssh_certificates_serial", Columns: []string{"serial"}, Unique: true},
+			{Name: "idx_ssh_certificates_purpose", Columns: []string{"purpose"}},
+			{Name: "idx_ssh_certificates_user_id", Columns: []string{"user_id"}},
+			{Name: "idx_ssh_certificates_created_at", Columns: []string{"created_at"}},
+		},
+		Comment: "SSH证书签发记录表",
+	}
+}
+
+// sshKeyRotationsTableSchema ssh_key_rotations 表结构
+func sshKeyRotationsTableSchema() TableSchema {
+	return TableSchema{
+		Name: "ssh_key_rotations",
+		Columns: []ColumnDefinition{
+			{Name: "id", Type: "SERIAL", PrimaryKey: true, AutoIncr: true, Nullable: false},
+			{Name: "key_source", Type: "VARCHAR(20)", Nullable: false, Comment: "system/ansible"},
+			{Name: "key_id", Type: "INTEGER", Nullable: false},
+			{Name: "key_name", Type: "VARCHAR(255)", Nullable: true},
+			{Name: "status", Type: "VARCHAR(20)", Nullable: false, Comment: "running/completed/rolled_back/failed"},
+			{Name: "running_key", Type: "VARCHAR(64)", Nullable: true, Comment: "执行中为 <来源>:<密钥ID>，结束后清空"},
+			{Name: "phase", Type: "VARCHAR(20)", Nullable: true},
+			{Name: "old_fingerprint", Type: "VARCHAR(100)", Nullable: true},
+			{Name: "new_fingerprint", Type: "VARCHAR(100)", Nullable: true},
+			{Name: "new_public_key", Type: "TEXT", Nullable: true},
+			{Name: "targets", Type: "JSONB", Nullable: true},
+			{Name: "total_targets", Type: "INTEGER", Nullable: true, DefaultValue: strPtr("0")},
+			{Name: "concurrency", Type: "INTEGER", Nullable: true, DefaultValue: strPtr("0")},
+			{Name: "error_message", Type: "TEXT", Nullable: true},
+			{Name: "user_id", Type: "INTEGER", Nullable: false},
+			{Name: "username", Type: "VARCHAR(100)", Nullable: true},
+			{Name: "started_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "completed_at", Type: "TIMESTAMP", Nullable: true},
+			{Name: "created_at", Type: "TIMESTAMP", Nullable: false},
+			{Name: "updated_at", Type: "TIMESTAMP", Nullable: false},
+		},
+		Indexes: []IndexDefinition{
+			{Name: "idx_ssh_key_rotation_key", Columns: []string{"key_source", "key_id"}},
+			{Name: "idx_ssh_key_rotations_status", Columns: []string{"status"}},
+			{Name: "idx_ssh_key_rotations_running_key", Columns: []string{"running_key"}, Unique: true},
+			{Name: "idx_ssh_key_rotations_user_id", Columns: []string{"user_id"}},
+			{Name: "idx_ssh_key_rotations_created_at", Columns: []string{"created_at"}},
+		},
+		Comment: "SSH密钥轮换记录表",
+	}
+}
+
 // schemaMigrationsTableSchema schema_migrations 表结构
 func schemaMigrationsTableSchema() TableSchema {
 	return TableSchema{
@@ -11,6 +11,20 @@ export function getAnomalyById(id) {
   })
 }
 
+/**
+ * 获取异常前后时间窗口内的节点资源指标
+ * @param {number} id - 异常记录ID
+ * @param {Object} params - 查询参数
+ * @param {number} params.padding - 异常前后扩展的分钟数，默认30
+ */
+export function getAnomalyMetrics(id, params) {
+  return request({
+    url: `/api/v1/anomalies/${id}/metrics`,
+    method: 'get',
+    params
+  })
+}
+
 /**
  * 获取异常记录列表
  * @param {Object} params - 查询参数
@@ -203,6 +203,48 @@ export function validateTemplate(data) {
   })
 }
 
+/**
+ * 列出模板版本
+ */
+export function listTemplateRevisions(id) {
+  return request({
+    url: `/api/v1/ansible/templates/${id}/revisions`,
+    method: 'get'
+  })
+}
+
+/**
+ * 获取模板指定版本
+ */
+export function getTemplateRevision(id, revision) {
+  return request({
+    url: `/api/v1/ansible/templates/${id}/revisions/${revision}`,
+    method: 'get'
+  })
+}
+
+/**
+ * 比较模板两个版本
+ */
+export function diffTemplateRevisions(id, params) {
+  return request({
+    url: `/api/v1/ansible/templates/${id}/diff`,
+    method: 'get',
+    params
+  })
+}
+
+/**
+ * 回滚模板到指定版本
+ */
+export function rollbackTemplate(id, data) {
+  return request({
+    url: `/api/v1/ansible/templates/${id}/rollback`,
+    method: 'post',
+    data
+  })
+}
+
 // 主机清单管理 API
 
 /**
@@ -269,6 +311,17 @@ export function generateInventory(data) {
   })
 }
 
+/**
+ * 预览从集群生成的主机清单
+ */
+export function previewInventory(data) {
+  return request({
+    url: '/api/v1/ansible/inventories/preview',
+    method: 'post',
+    data
+  })
+}
+
 /**
  * 刷新 K8s 来源的主机清单
  */
@@ -345,6 +398,204 @@ export function testSSHConnection(id, data) {
   })
 }
 
+/**
+ * 轮换 SSH 密钥（生成新密钥并分发到所有引用该密钥的主机）
+ */
+export function rotateSSHKey(id, data) {
+  return request({
+    url: `/api/v1/ansible/ssh-keys/${id}/rotate`,
+    method: 'post',
+    data
+  })
+}
+
+/**
+ * 列出 SSH 密钥轮换记录
+ */
+export function listSSHKeyRotations(params) {
+  return request({
+    url: '/api/v1/ssh-key-rotations',
+    method: 'get',
+    params
+  })
+}
+
+/**
+ * 获取 SSH 密钥轮换详情
+ */
+export function getSSHKeyRotation(id) {
+  return request({
+    url: `/api/v1/ssh-key-rotations/${id}`,
+    method: 'get'
+  })
+}
+
+// SSH 证书颁发机构 API
+
+/**
+ * 获取 SSH CA 信息
+ */
+export function getSSHCA() {
+  return request({
+    url: '/api/v1/ssh-ca',
+    method: 'get'
+  })
+}
+
+/**
+ * 列出 SSH 证书签发记录
+ */
+export function listSSHCertificates(params) {
+  return request({
+    url: '/api/v1/ssh-ca/certificates',
+    method: 'get',
+    params
+  })
+}
+
+/**
+ * 安装节点信任 SSH CA 模板
+ */
+export function installSSHCATrustTemplate() {
+  return request({
+    url: '/api/v1/ssh-ca/trust-template',
+    method: 'post'
+  })
+}
+
+// 密钥变量管理 API
+
+/**
+ * 列出密钥变量（不包含密钥值）
+ */
+export function listSecrets(params) {
+  return request({
+    url: '/api/v1/ansible/secrets',
+    method: 'get',
+    params
+  })
+}
+
+/**
+ * 创建密钥变量
+ */
+export function createSecret(data) {
+  return request({
+    url: '/api/v1/ansible/secrets',
+    method: 'post',
+    data
+  })
+}
+
+/**
+ * 更新密钥变量
+ */
+export function updateSecret(id, data) {
+  return request({
+    url: `/api/v1/ansible/secrets/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+/**
+ * 删除密钥变量
+ */
+export function deleteSecret(id) {
+  return request({
+    url: `/api/v1/ansible/secrets/${id}`,
+    method: 'delete'
+  })
+}
+
+// Git 项目管理 API
+
+/**
+ * 列出 Git 项目
+ */
+export function listProjects(params) {
+  return request({
+    url: '/api/v1/ansible/projects',
+    method: 'get',
+    params
+  })
+}
+
+/**
+ * 创建 Git 项目
+ */
+export function createProject(data) {
+  return request({
+    url: '/api/v1/ansible/projects',
+    method: 'post',
+    data
+  })
+}
+
+/**
+ * 更新 Git 项目
+ */
+export function updateProject(id, data) {
+  return request({
+    url: `/api/v1/ansible/projects/${id}`,
+    method: 'put',
+    data
+  })
+}
+
+/**
+ * 删除 Git 项目
+ */
+export function deleteProject(id) {
+  return request({
+    url: `/api/v1/ansible/projects/${id}`,
+    method: 'delete'
+  })
+}
+
+/**
+ * 同步 Git 项目
+ */
+export function syncProject(id) {
+  return request({
+    url: `/api/v1/ansible/projects/${id}/sync`,
+    method: 'post'
+  })
+}
+
+// 临时命令 API
+
+/**
+ * 获取临时命令策略
+ */
+export function getAdhocConfig() {
+  return request({
+    url: '/api/v1/ansible/adhoc/config',
+    method: 'get'
+  })
+}
+
+/**
+ * 在多个主机上执行临时命令
+ */
+export function createAdhocTask(data) {
+  return request({
+    url: '/api/v1/ansible/adhoc',
+    method: 'post',
+    data
+  })
+}
+
+/**
+ * 获取临时命令各主机结果（含按输出分组）
+ */
+export function getHostResults(id) {
+  return request({
+    url: `/api/v1/ansible/tasks/${id}/host-results`,
+    method: 'get'
+  })
+}
+
 // WebSocket 连接
 
 /**
@@ -0,0 +1,31 @@
+import request from '@/utils/request'
+
+const apiTokenApi = {
+  // 获取访问令牌列表（管理员可查看全部用户）
+  getTokens(params) {
+    return request({
+      url: '/api/v1/api-tokens',
+      method: 'get',
+      params
+    })
+  },
+
+  // 创建访问令牌，明文令牌只在响应中返回一次
+  createToken(data) {
+    return request({
+      url: '/api/v1/api-tokens',
+      method: 'post',
+      data
+    })
+  },
+
+  // 撤销访问令牌
+  revokeToken(id) {
+    return request({
+      url: `/api/v1/api-tokens/${id}`,
+      method: 'delete'
+    })
+  }
+}
+
+export default apiTokenApi
@@ -73,6 +73,31 @@ const auditApi = {
     })
   },
 
+  // 获取操作的节点变更详情（变更前后快照）
+  getOperationChanges(operationId) {
+    return request({
+      url: `/api/v1/audit/changes/${operationId}`,
+      method: 'get'
+    })
+  },
+
+  // 撤销操作，将涉及的节点恢复到操作前的状态
+  undoOperation(operationId) {
+    return request({
+      url: `/api/v1/audit/changes/${operationId}/undo`,
+      method: 'post'
+    })
+  },
+
+  // 校验审计日志哈希链
+  verifyChain() {
+    return request({
+      url: '/api/v1/audit/verify',
+      method: 'get',
+      timeout: 120000
+    })
+  },
+
   // 清理过期日志
   cleanupExpiredLogs(days) {
     return request({
@@ -10,6 +10,67 @@ const authApi = {
     })
   },
 
+  // 登录第二步：提交 MFA 验证码或恢复码
+  loginMFA(data) {
+    return request({
+      url: '/api/v1/auth/login/mfa',
+      method: 'post',
+      data
+    })
+  },
+
+  // 登录过程中绑定 MFA（角色要求启用 MFA 但尚未绑定）
+  setupMFAForLogin(mfaToken) {
+    return request({
+      url: '/api/v1/auth/login/mfa/setup',
+      method: 'post',
+      data: { mfa_token: mfaToken }
+    })
+  },
+
+  // 获取当前用户的 MFA 状态
+  getMFAStatus() {
+    return request({
+      url: '/api/v1/auth/mfa',
+      method: 'get'
+    })
+  },
+
+  // 生成 MFA 密钥
+  setupMFA() {
+    return request({
+      url: '/api/v1/auth/mfa/setup',
+      method: 'post'
+    })
+  },
+
+  // 校验验证码后启用 MFA
+  enableMFA(code) {
+    return request({
+      url: '/api/v1/auth/mfa/enable',
+      method: 'post',
+      data: { code }
+    })
+  },
+
+  // 关闭 MFA
+  disableMFA(code) {
+    return request({
+      url: '/api/v1/auth/mfa/disable',
+      method: 'post',
+      data: { code }
+    })
+  },
+
+  // 重新生成恢复码
+  regenerateRecoveryCodes(code) {
+    return request({
+      url: '/api/v1/auth/mfa/recovery-codes',
+      method: 'post',
+      data: { code }
+    })
+  },
+
   // 获取用户信息
   getUserInfo() {
     return request({
@@ -19,21 +80,64 @@ const authApi = {
   },
 
   // 刷新Token
-  refreshToken() {
+  refreshToken(refreshToken) {
     return request({
       url: '/api/v1/auth/refresh',
-      method: 'post'
+      method: 'post',
+      data: { refresh_token: refreshToken }
     })
   },
 
-  // 用户登出
-  logout() {
+  // 用户登出（撤销当前会话）
+  logout(refreshToken) {
     return request({
       url: '/api/v1/auth/logout',
+      method: 'post',
+      data: { refresh_token: refreshToken }
+    })
+  },
+
+  // 退出全部设备
+  logoutAll() {
+    return request({
+      url: '/api/v1/auth/logout-all',
       method: 'post'
     })
   },
 
+  // 获取当前用户的登录会话
+  getSessions() {
+    return request({
+      url: '/api/v1/auth/sessions',
+      method: 'get'
+    })
+  },
+
+  // 撤销当前用户的指定会话
+  revokeSession(sessionId) {
+    return request({
+      url: `/api/v1/auth/sessions/${sessionId}`,
+      method: 'delete'
+    })
+  },
+
+  // 管理员：获取会话列表
+  getAllSessions(params) {
+    return request({
+      url: '/api/v1/sessions',
+      method: 'get',
+      params
+    })
+  },
+
+  // 管理员：撤销任意会话
+  adminRevokeSession(sessionId) {
+    return request({
+      url: `/api/v1/sessions/${sessionId}`,
+      method: 'delete'
+    })
+  },
+
   // 修改密码
   changePassword(data) {
     return request({
@@ -171,6 +171,15 @@ const labelApi = {
     })
   },
 
+  // 模拟标签变更影响（dry-run）
+  simulateImpact(data) {
+    return request({
+      url: '/api/v1/labels/simulate',
+      method: 'post',
+      data
+    })
+  },
+
   // 应用标签模板到节点
   applyTemplate(data, config = {}) {
     return request({
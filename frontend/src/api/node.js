@@ -94,6 +94,18 @@ const nodeApi = {
     })
   },
 
+  // 驱逐预检（dry-run）
+  drainPreflight(nodeNames, clusterName) {
+    return request({
+      url: '/api/v1/nodes/drain-preflight',
+      method: 'post',
+      data: {
+        nodes: nodeNames,
+        cluster_name: clusterName
+      }
+    })
+  },
+
   // 批量禁止调度（带进度）
   batchCordonWithProgress(nodeNames, clusterName, reason = '') {
     return request({
@@ -142,21 +154,30 @@ const nodeApi = {
     })
   },
 
-  // 获取节点上的Pods
-  getNodePods(nodeName, params) {
+  // 获取节点上的Pods（分页）及已分配资源
+  getNodePods(clusterName, nodeName, params) {
     return request({
-      url: `/api/v1/nodes/${nodeName}/pods`,
+      url: `/api/v1/nodes/${encodeURIComponent(clusterName)}/${nodeName}/pods`,
       method: 'get',
-      params
+      params: { cluster_name: clusterName, ...params }
     })
   },
 
-  // 获取节点事件
-  getNodeEvents(nodeName, params) {
+  // 获取节点生命周期时间线（审计、异常、Ansible、禁止调度、K8s Events）
+  getNodeTimeline(clusterName, nodeName, params) {
     return request({
-      url: `/api/v1/nodes/${nodeName}/events`,
+      url: `/api/v1/nodes/${encodeURIComponent(clusterName)}/${nodeName}/timeline`,
       method: 'get',
-      params
+      params: { cluster_name: clusterName, ...params }
+    })
+  },
+
+  // 获取节点事件历史（已持久化的 Kubernetes Event）
+  getNodeEvents(clusterName, nodeName, params) {
+    return request({
+      url: `/api/v1/nodes/${encodeURIComponent(clusterName)}/${nodeName}/events`,
+      method: 'get',
+      params: { cluster_name: clusterName, ...params }
     })
   },
 
@@ -0,0 +1,34 @@
+import request from '@/utils/request'
+
+/**
+ * 获取批量任务执行报告（包括未执行的节点）
+ * @param {string} taskId - 任务ID
+ */
+export function getProgressTask(taskId) {
+  return request({
+    url: `/api/v1/progress/tasks/${taskId}`,
+    method: 'get'
+  })
+}
+
+/**
+ * 取消运行中的批量任务
+ * @param {string} taskId - 任务ID
+ */
+export function cancelProgressTask(taskId) {
+  return request({
+    url: `/api/v1/progress/tasks/${taskId}/cancel`,
+    method: 'post'
+  })
+}
+
+/**
+ * 继续处理已取消或已中断任务的剩余节点
+ * @param {string} taskId - 任务ID
+ */
+export function resumeProgressTask(taskId) {
+  return request({
+    url: `/api/v1/progress/tasks/${taskId}/resume`,
+    method: 'post'
+  })
+}
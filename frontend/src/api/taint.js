@@ -186,6 +186,15 @@ const taintApi = {
     })
   },
 
+  // 模拟污点变更影响（dry-run）
+  simulateImpact(data) {
+    return request({
+      url: '/api/v1/taints/simulate',
+      method: 'post',
+      data
+    })
+  },
+
   // 应用污点模板到节点
   applyTemplate(data) {
     return request({
@@ -71,6 +71,39 @@ const userApi = {
     })
   },
 
+  // 创建服务账号
+  createServiceAccount(data) {
+    return request({
+      url: '/api/v1/service-accounts',
+      method: 'post',
+      data
+    })
+  },
+
+  // 强制用户全部会话下线
+  revokeUserSessions(id) {
+    return request({
+      url: `/api/v1/users/${id}/sessions`,
+      method: 'delete'
+    })
+  },
+
+  // 重置用户MFA（管理员）
+  resetUserMFA(id) {
+    return request({
+      url: `/api/v1/users/${id}/mfa`,
+      method: 'delete'
+    })
+  },
+
+  // 解除用户登录锁定（管理员）
+  unlockUser(id) {
+    return request({
+      url: `/api/v1/users/${id}/unlock`,
+      method: 'post'
+    })
+  },
+
   // 获取用户角色列表
   getUserRoles() {
     return request({
@@ -65,6 +65,12 @@ const router = createRouter({
           component: () => import('@/views/profile/UserProfile.vue'),
           meta: { title: '个人信息', icon: 'User', requiresAuth: true }
         },
+        {
+          path: 'api-tokens',
+          name: 'ApiTokens',
+          component: () => import('@/views/profile/ApiTokens.vue'),
+          meta: { title: '访问令牌', icon: 'Key', requiresAuth: true }
+        },
         {
           path: 'gitlab-settings',
           name: 'GitlabSettings',
@@ -119,6 +125,12 @@ const router = createRouter({
           component: () => import('@/views/ansible/TaskCenter.vue'),
           meta: { title: 'Ansible任务', icon: 'Operation', requiresAuth: true }
         },
+        {
+          path: 'ansible-adhoc',
+          name: 'AnsibleAdhoc',
+          component: () => import('@/views/ansible/AdhocCommand.vue'),
+          meta: { title: '临时命令', icon: 'Promotion', requiresAuth: true }
+        },
         {
           path: 'ansible-templates',
           name: 'AnsibleTemplates',
@@ -1,6 +1,22 @@
 import { defineStore } from 'pinia'
 import authApi from '@/api/auth'
-import { getToken, setToken, removeToken } from '@/utils/auth'
+import { getToken, setToken, removeToken, getRefreshToken, setRefreshToken } from '@/utils/auth'
+
+// loginError 提供更友好的登录错误信息
+const loginError = (error) => {
+  const status = error.response?.status
+  if (status === 401) {
+    return new Error(error.response.data?.error === 'invalid credentials' ? '用户名、密码或验证码错误' : (error.response.data?.error || '用户名或密码错误'))
+  } else if (status === 429) {
+    const retryAfter = parseInt(error.response.headers?.['retry-after'] || '0')
+    return new Error(retryAfter > 0 ? `登录失败次数过多，请在 ${Math.ceil(retryAfter / 60)} 分钟后重试` : '登录失败次数过多，请稍后重试')
+  } else if (status >= 500) {
+    return new Error('服务器内部错误，请稍后重试')
+  } else if (error.message === 'Network Error') {
+    return new Error('网络连接失败，请检查网络状态')
+  }
+  return error
+}
 
 export const useAuthStore = defineStore('auth', {
   state: () => ({
@@ -32,37 +48,49 @@ export const useAuthStore = defineStore('auth', {
           throw new Error('登录响应数据为空')
         }
         
-        const { token, user } = response.data
-        
-        // 验证必要的字段是否存在
-        if (!token) {
-          throw new Error('登录响应中缺少 token')
+        // 需要第二因素验证时不保存令牌，由登录页继续完成 MFA 步骤
+        if (response.data.mfa_required || response.data.mfa_setup_required) {
+          return response
         }
         
-        if (!user) {
-          throw new Error('登录响应中缺少用户信息')
-        }
-        
-        this.token = token
-        this.userInfo = user
-        this.permissions = user.permissions || []
-        
-        setToken(token)
-        
+        this.applyLoginResponse(response.data)
         return response
       } catch (error) {
-        // 提供更友好的错误信息
-        if (error.response && error.response.status === 401) {
-          throw new Error('用户名或密码错误')
-        } else if (error.response && error.response.status >= 500) {
-          throw new Error('服务器内部错误，请稍后重试')
-        } else if (error.message === 'Network Error') {
-          throw new Error('网络连接失败，请检查网络状态')
-        }
-        throw error
+        throw loginError(error)
+      }
+    },
+
+    // 登录第二步：提交 TOTP 验证码或恢复码
+    async completeMFALogin(payload) {
+      try {
+        const response = await authApi.loginMFA(payload)
+        this.applyLoginResponse(response.data)
+        return response
+      } catch (error) {
+        throw loginError(error)
       }
     },
 
+    applyLoginResponse(data) {
+      const { token, refresh_token, user } = data || {}
+      
+      // 验证必要的字段是否存在
+      if (!token) {
+        throw new Error('登录响应中缺少 token')
+      }
+      
+      if (!user) {
+        throw new Error('登录响应中缺少用户信息')
+      }
+      
+      this.token = token
+      this.userInfo = user
+      this.permissions = user.permissions || []
+      
+      setToken(token)
+      setRefreshToken(refresh_token)
+    },
+
     async getUserInfo() {
       try {
         const response = await authApi.getUserInfo()
@@ -77,6 +105,16 @@ export const useAuthStore = defineStore('auth', {
       }
     },
 
+    // 通知服务端撤销当前会话后清理本地状态
+    async signOut() {
+      try {
+        await authApi.logout(getRefreshToken())
+      } catch (error) {
+        console.warn('服务端退出登录失败:', error)
+      }
+      this.logout()
+    },
+
     logout() {
       this.token = null
       this.userInfo = null
@@ -86,16 +124,18 @@ export const useAuthStore = defineStore('auth', {
 
     async refreshToken() {
       try {
-        const response = await authApi.refreshToken()
+        const response = await authApi.refreshToken(getRefreshToken())
         
         // 安全检查响应数据结构
         if (!response || !response.data || !response.data.token) {
           throw new Error('刷新令牌响应无效')
         }
         
-        const { token } = response.data
+        const { token, refresh_token } = response.data
         this.token = token
         setToken(token)
+        // 刷新令牌每次使用后轮换，必须保存新的刷新令牌
+        setRefreshToken(refresh_token)
         return response
       } catch (error) {
         this.logout()
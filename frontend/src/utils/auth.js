@@ -1,4 +1,5 @@
 const TOKEN_KEY = 'kube_node_manager_token'
+const REFRESH_TOKEN_KEY = 'kube_node_manager_refresh_token'
 const USER_KEY = 'kube_node_manager_user'
 const CLUSTER_KEY = 'kube_node_manager_cluster'
 
@@ -15,6 +16,17 @@ export function setToken(token) {
 
 export function removeToken() {
   localStorage.removeItem(TOKEN_KEY)
+  localStorage.removeItem(REFRESH_TOKEN_KEY)
+}
+
+export function getRefreshToken() {
+  return localStorage.getItem(REFRESH_TOKEN_KEY)
+}
+
+export function setRefreshToken(token) {
+  if (token) {
+    localStorage.setItem(REFRESH_TOKEN_KEY, token)
+  }
 }
 
 /**
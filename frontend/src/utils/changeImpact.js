@@ -0,0 +1,57 @@
+/**
+ * 标签/污点变更影响确认相关工具函数
+ */
+import { h } from 'vue'
+import { ElMessageBox } from 'element-plus'
+
+// 确认框中最多列出的条目数
+const MAX_ITEMS = 10
+
+const formatPod = (pod) => {
+  const delay = pod.toleration_seconds ? `（${pod.toleration_seconds} 秒后）` : ''
+  return `${pod.namespace}/${pod.name} @ ${pod.node_name}: ${pod.reason}${delay}`
+}
+
+const formatWorkload = (workload) =>
+  `${workload.namespace}/${workload.kind}/${workload.name}（${workload.pods} 个 Pod，可调度节点 ${workload.eligible_nodes_before} → ${workload.eligible_nodes_after}）${workload.reason ? '：' + workload.reason : ''}`
+
+const renderList = (title, items, format) => {
+  if (!items || items.length === 0) return null
+  const rows = items.slice(0, MAX_ITEMS).map(item => h('li', format(item)))
+  if (items.length > MAX_ITEMS) {
+    rows.push(h('li', `... 及其他 ${items.length - MAX_ITEMS} 项`))
+  }
+  return h('div', { style: 'margin-top: 8px;' }, [
+    h('strong', `${title}（${items.length}）`),
+    h('ul', { style: 'margin: 4px 0; padding-left: 18px; max-height: 180px; overflow-y: auto;' }, rows)
+  ])
+}
+
+/**
+ * 展示变更影响并要求用户显式确认
+ * @param {Object} impact 后端返回的影响模拟结果
+ * @returns {Promise<boolean>} 无影响或用户确认时返回 true
+ */
+export async function confirmChangeImpact(impact) {
+  if (!impact || impact.safe) {
+    return true
+  }
+
+  const message = h('div', [
+    h('p', '此变更将影响正在运行的工作负载，请确认后再继续：'),
+    renderList('将被驱逐的 Pod', impact.evicted_pods, formatPod),
+    renderList('将失去全部可调度节点的工作负载', impact.affected_workloads, formatWorkload)
+  ])
+
+  try {
+    await ElMessageBox.confirm(message, '变更影响确认', {
+      confirmButtonText: '我已知晓影响，继续应用',
+      cancelButtonText: '取消',
+      type: 'warning',
+      customStyle: { maxWidth: '720px' }
+    })
+    return true
+  } catch {
+    return false
+  }
+}
@@ -100,7 +100,7 @@ service.interceptors.response.use(
           message = '网关超时'
           break
         default:
-          message = response.data?.message || `请求失败 (${response.status})`
+          message = response.data?.message || response.data?.error || `请求失败 (${response.status})`
       }
     } else if (error.code === 'ECONNABORTED') {
       message = '请求超时，请稍后重试'
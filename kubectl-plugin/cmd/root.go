@@ -29,7 +29,8 @@ var rootCmd = &cobra.Command{
 功能包括：
 • 查看节点的 deeproute.cn/user-type 标签归属
 • 对节点执行 cordon 操作并添加详细说明 annotations
-• 管理节点的调度状态`,
+• 管理节点的调度状态
+• 模拟调度，查看 Pod 可以运行在哪些节点上`,
 	Example: `  # 查看所有节点的调度状态
   kubectl node_mgr get
 
@@ -46,7 +47,10 @@ var rootCmd = &cobra.Command{
   kubectl node_mgr cordon list
 
   # 取消节点的 cordon 状态
-  kubectl node_mgr uncordon node1`,
+  kubectl node_mgr uncordon node1
+
+  # 模拟调度工作负载
+  kubectl node_mgr schedule --workload deploy/web -n default --cluster prod`,
 }
 
 // Execute 添加所有子命令到根命令并设置标志
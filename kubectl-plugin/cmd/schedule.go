@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	scheduleFile     string
+	scheduleWorkload string
+	scheduleServer   string
+	scheduleToken    string
+	scheduleCluster  string
+	scheduleOutput   string
+)
+
+// scheduleCmd 代表 schedule 命令
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule (-f FILE | --workload KIND/NAME)",
+	Short: "模拟调度：查看 Pod 可以运行在哪些节点上",
+	Long: `调用 kube-node-manager 的调度模拟接口，逐个节点评估 Pod 能否调度，
+并列出不满足的原因（标签、污点、可分配资源、已分配 requests、禁止调度状态）。
+
+Pod 规格可以来自文件（Pod、Deployment、StatefulSet、DaemonSet、ReplicaSet、Job、CronJob），
+也可以引用集群中已有的工作负载。
+
+服务地址和访问令牌可以通过 KNM_SERVER、KNM_TOKEN 环境变量设置，集群名称可以通过 KNM_CLUSTER 设置。
+只读权限的访问令牌（nodes:read）即可调用。`,
+	Example: `  # 评估清单文件中的 Pod 可以调度到哪些节点
+  kubectl node_mgr schedule -f deployment.yaml --cluster prod
+
+  # 评估集群中已有的工作负载
+  kubectl node_mgr schedule --workload deploy/web -n default --cluster prod
+
+  # 从标准输入读取清单
+  kubectl get deploy web -o yaml | kubectl node_mgr schedule -f - --cluster prod
+
+  # 以 JSON 格式输出
+  kubectl node_mgr schedule --workload sts/db -n data --cluster prod -o json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runScheduleCommand()
+	},
+}
+
+// scheduleRequest 调度模拟请求
+type scheduleRequest struct {
+	ClusterName string          `json:"cluster_name"`
+	Namespace   string          `json:"namespace,omitempty"`
+	Kind        string          `json:"kind,omitempty"`
+	PodSpec     json.RawMessage `json:"pod_spec,omitempty"`
+	Workload    *workloadRef    `json:"workload,omitempty"`
+}
+
+type workloadRef struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// ScheduleResult 调度模拟结果
+type ScheduleResult struct {
+	ClusterName    string            `json:"cluster_name"`
+	Namespace      string            `json:"namespace,omitempty"`
+	Workload       string            `json:"workload,omitempty"`
+	CPURequests    string            `json:"cpu_requests"`
+	MemoryRequests string            `json:"memory_requests"`
+	GPURequests    map[string]string `json:"gpu_requests,omitempty"`
+	Nodes          []NodeFitInfo     `json:"nodes"`
+	FitCount       int               `json:"fit_count"`
+	Notes          []string          `json:"notes,omitempty"`
+	AnalyzedAt     time.Time         `json:"analyzed_at"`
+}
+
+// NodeFitInfo 单个节点的调度模拟结果
+type NodeFitInfo struct {
+	NodeName   string   `json:"node_name"`
+	Fits       bool     `json:"fits"`
+	Reasons    []string `json:"reasons,omitempty"`
+	FreeCPU    string   `json:"free_cpu"`
+	FreeMemory string   `json:"free_memory"`
+	FreePods   int64    `json:"free_pods"`
+}
+
+func init() {
+	rootCmd.AddCommand(scheduleCmd)
+
+	scheduleCmd.Flags().StringVarP(&scheduleFile, "filename", "f", "", "包含 Pod 或工作负载的清单文件，- 表示标准输入")
+	scheduleCmd.Flags().StringVar(&scheduleWorkload, "workload", "", "集群中已有的工作负载，格式为 KIND/NAME（如 deploy/web）")
+	scheduleCmd.Flags().StringVar(&scheduleServer, "server", os.Getenv("KNM_SERVER"), "kube-node-manager 服务地址 (默认为 $KNM_SERVER)")
+	scheduleCmd.Flags().StringVar(&scheduleToken, "token", os.Getenv("KNM_TOKEN"), "kube-node-manager 访问令牌 (默认为 $KNM_TOKEN)")
+	scheduleCmd.Flags().StringVar(&scheduleCluster, "cluster", os.Getenv("KNM_CLUSTER"), "kube-node-manager 中的集群名称 (默认为 $KNM_CLUSTER)")
+	scheduleCmd.Flags().StringVarP(&scheduleOutput, "output", "o", "table", "输出格式 (table|json|yaml)")
+}
+
+func runScheduleCommand() {
+	if (scheduleFile == "") == (scheduleWorkload == "") {
+		checkError(fmt.Errorf("exactly one of -f and --workload must be specified"))
+	}
+	if scheduleServer == "" || scheduleToken == "" {
+		checkError(fmt.Errorf("--server and --token (or KNM_SERVER and KNM_TOKEN) are required"))
+	}
+	if scheduleCluster == "" {
+		checkError(fmt.Errorf("--cluster (or KNM_CLUSTER) is required"))
+	}
+
+	namespace, _ := rootCmd.PersistentFlags().GetString("namespace")
+	req := scheduleRequest{ClusterName: scheduleCluster, Namespace: namespace}
+
+	if scheduleWorkload != "" {
+		parts := strings.SplitN(scheduleWorkload, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			checkError(fmt.Errorf("invalid workload %q, expected KIND/NAME", scheduleWorkload))
+		}
+		req.Workload = &workloadRef{Kind: parts[0], Namespace: namespace, Name: parts[1]}
+	} else {
+		kind, manifestNamespace, spec, err := readPodSpec(scheduleFile)
+		checkError(err)
+		if req.Namespace == "" {
+			req.Namespace = manifestNamespace
+		}
+		req.Kind = kind
+		req.PodSpec = spec
+	}
+
+	result, err := postScheduleSimulation(req)
+	checkError(err)
+
+	switch scheduleOutput {
+	case "json":
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		checkError(err)
+		fmt.Println(string(jsonData))
+	case "yaml":
+		yamlData, err := yaml.Marshal(result)
+		checkError(err)
+		fmt.Print(string(yamlData))
+	default:
+		outputScheduleTable(result)
+	}
+}
+
+// readPodSpec 从清单中提取 Pod 规格，返回资源类型、命名空间和 JSON 格式的 Pod 规格
+func readPodSpec(filename string) (string, string, json.RawMessage, error) {
+	var data []byte
+	var err error
+	if filename == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var manifest map[string]interface{}
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return "", "", nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	kind, _ := manifest["kind"].(string)
+	namespace := ""
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		namespace, _ = metadata["namespace"].(string)
+	}
+
+	var path []string
+	switch kind {
+	case "Pod":
+		path = []string{"spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	case "PodTemplate":
+		path = []string{"template", "spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		path = []string{"spec", "template", "spec"}
+	default:
+		return "", "", nil, fmt.Errorf("unsupported manifest kind %q", kind)
+	}
+
+	var value interface{} = manifest
+	for _, key := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = m[key]
+	}
+	if value == nil {
+		return "", "", nil, fmt.Errorf("%s has no pod spec at %s", kind, strings.Join(path, "."))
+	}
+
+	spec, err := json.Marshal(value)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return kind, namespace, spec, nil
+}
+
+// postScheduleSimulation 调用调度模拟接口
+func postScheduleSimulation(req scheduleRequest) (*ScheduleResult, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimRight(scheduleServer, "/") + "/api/v1/nodes/schedule-simulate"
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+scheduleToken)
+
+	client := &http.Client{Timeout: 90 * time.Second}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope struct {
+		Message string          `json:"message"`
+		Error   string          `json:"error"`
+		Data    *ScheduleResult `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return nil, fmt.Errorf("unexpected response (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if resp.StatusCode != http.StatusOK || envelope.Data == nil {
+		msg := envelope.Message
+		if envelope.Error != "" {
+			msg = envelope.Error
+		}
+		return nil, fmt.Errorf("schedule simulation failed (HTTP %d): %s", resp.StatusCode, msg)
+	}
+	return envelope.Data, nil
+}
+
+func outputScheduleTable(result *ScheduleResult) {
+	fmt.Printf("Requests: cpu=%s memory=%s", result.CPURequests, result.MemoryRequests)
+	for name, v := range result.GPURequests {
+		fmt.Printf(" %s=%s", name, v)
+	}
+	fmt.Printf("\nFits on %d of %d nodes\n\n", result.FitCount, len(result.Nodes))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NODE\tFITS\tFREE-CPU\tFREE-MEMORY\tFREE-PODS\tREASONS")
+	for _, node := range result.Nodes {
+		fits := "No"
+		if node.Fits {
+			fits = "Yes"
+		}
+		reasons := "-"
+		if len(node.Reasons) > 0 {
+			reasons = strings.Join(node.Reasons, "; ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			node.NodeName,
+			fits,
+			node.FreeCPU,
+			node.FreeMemory,
+			node.FreePods,
+			reasons,
+		)
+	}
+	w.Flush()
+
+	for _, note := range result.Notes {
+		fmt.Printf("\nNote: %s", note)
+	}
+	if len(result.Notes) > 0 {
+		fmt.Println()
+	}
+}